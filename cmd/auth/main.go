@@ -10,11 +10,13 @@ import (
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/app/auth"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 )
 
 func main() {
 	cfg := config.MustLoad()
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sl.SetRedactFields(cfg.LogRedactFields)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: sl.ReplaceAttr}))
 
 	logger.Info("starting auth-service", slog.String("env", cfg.Env))
 
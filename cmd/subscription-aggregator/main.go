@@ -32,11 +32,13 @@ import (
 
 	subscriptionaggregator "github.com/magabrotheeeer/subscription-aggregator/internal/app/subscription-aggregator"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 )
 
 func main() {
 	cfg := config.MustLoad()
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sl.SetRedactFields(cfg.LogRedactFields)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: sl.ReplaceAttr}))
 
 	logger.Info("starting subscription-aggregator", slog.String("env", cfg.Env))
 
@@ -203,8 +203,8 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Подпись webhook для проверки подлинности",
-                        "name": "X-Api-Signature",
+                        "description": "Подпись webhook в формате t=<unix-время>,hmac-sha256=<hex>",
+                        "name": "X-Webhook-Signature",
                         "in": "header",
                         "required": true
                     },
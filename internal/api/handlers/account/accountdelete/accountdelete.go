@@ -0,0 +1,86 @@
+// Package accountdelete реализует HTTP-обработчик для удаления аккаунта текущего пользователя.
+//
+// Handler извлекает UID пользователя из контекста и вызывает бизнес-логику удаления:
+// персональные данные анонимизируются сразу или после grace-периода в зависимости от конфигурации,
+// платежные токены отзываются немедленно. Запрос сохраняется для подтверждения соответствия GDPR.
+package accountdelete
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на удаление аккаунта.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики для удаления аккаунта
+}
+
+// Service описывает интерфейс бизнес-логики удаления аккаунта.
+type Service interface {
+	DeleteAccount(ctx context.Context, userUID string) (*models.DeletionRequest, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Удалить аккаунт
+// @Description Анонимизирует персональные данные пользователя, деактивирует подписки и отзывает платежные токены.
+// @Description При отложенном удалении аккаунт блокируется сразу, а данные анонимизируются после grace-периода.
+// @Tags Account
+// @Produce  json
+// @Success 200 {object} map[string]any "Аккаунт удален"
+// @Success 202 {object} map[string]any "Удаление запланировано"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при удалении аккаунта"
+// @Router /me [delete]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.account.delete"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user_uid not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	req, err := h.service.DeleteAccount(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to delete account", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not delete account"))
+		return
+	}
+
+	log.Info("account deletion processed", slog.String("mode", req.Mode))
+	if req.CompletedAt == nil {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	render.JSON(w, r, response.OKWithData(map[string]any{
+		"deletion": req,
+	}))
+}
@@ -0,0 +1,100 @@
+package accountdelete
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс accountdelete.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) DeleteAccount(ctx context.Context, userUID string) (*models.DeletionRequest, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.(*models.DeletionRequest), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestAccountDeleteHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	completed := time.Now()
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "немедленное удаление",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("DeleteAccount", mock.Anything, "user123").Return(&models.DeletionRequest{
+					ID: 1, UserUID: "user123", Mode: models.DeletionModeImmediate, CompletedAt: &completed,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"mode":"immediate"`,
+		},
+		{
+			name:    "отложенное удаление",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("DeleteAccount", mock.Anything, "user123").Return(&models.DeletionRequest{
+					ID: 2, UserUID: "user123", Mode: models.DeletionModeScheduled,
+				}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `"mode":"scheduled"`,
+		},
+		{
+			name:           "отсутствует авторизация",
+			userUID:        "",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("DeleteAccount", mock.Anything, "user123").Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not delete account"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
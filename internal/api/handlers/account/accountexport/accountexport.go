@@ -0,0 +1,91 @@
+// Package accountexport реализует HTTP-обработчик для выгрузки всех данных текущего пользователя.
+//
+// Handler собирает профиль, подписки, платежи, маскированные платежные токены и историю уведомлений
+// и отдает их единым JSON-файлом для скачивания (право на доступ к данным по GDPR).
+package accountexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на выгрузку данных пользователя.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики для сбора данных
+}
+
+// Service описывает интерфейс бизнес-логики выгрузки данных.
+type Service interface {
+	ExportData(ctx context.Context, userUID string) (*models.DataExport, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Выгрузить мои данные
+// @Description Возвращает JSON-файл со всеми данными пользователя: профиль, подписки, платежи,
+// @Description маскированные платежные токены и история уведомлений.
+// @Tags Account
+// @Produce  json
+// @Success 200 {object} models.DataExport "Выгрузка данных"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при выгрузке данных"
+// @Router /me/export [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.account.export"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user_uid not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	export, err := h.service.ExportData(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to export user data", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not export data"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="export-%s.json"`, export.ExportedAt.Format("20060102-150405")))
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		log.Error("failed to write export", sl.Err(err))
+		return
+	}
+	log.Info("user data exported")
+}
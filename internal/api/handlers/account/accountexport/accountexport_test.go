@@ -0,0 +1,108 @@
+package accountexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс accountexport.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ExportData(ctx context.Context, userUID string) (*models.DataExport, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.(*models.DataExport), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestAccountExportHandler_Bundle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mockService := new(MockService)
+	mockService.On("ExportData", mock.Anything, "user123").Return(&models.DataExport{
+		ExportedAt:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Profile:       models.ExportProfile{UUID: "user123", Email: "u@example.com", Username: "u"},
+		Subscriptions: []*models.Entry{{ID: 1, ServiceName: "Netflix"}},
+		Payments:      []*models.Payment{{ID: 1, PaymentID: "pay_1", Amount: 20000}},
+		PaymentTokens: []models.MaskedPaymentToken{{ID: 1, Token: "****7890"}},
+		Notifications: []models.NotificationRecord{},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, "user123"))
+	w := httptest.NewRecorder()
+
+	New(logger, mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="export-20250102-030405.json"`, w.Header().Get("Content-Disposition"))
+
+	var bundle map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bundle))
+	for _, section := range []string{"exported_at", "profile", "subscriptions", "payments", "payment_tokens", "notifications"} {
+		assert.Contains(t, bundle, section)
+	}
+	assert.NotContains(t, w.Body.String(), "password")
+	mockService.AssertExpectations(t)
+}
+
+func TestAccountExportHandler_Errors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "отсутствует авторизация",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ExportData", mock.Anything, "user123").Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not export data"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package accountreminders реализует HTTP-обработчик для получения настроек напоминаний пользователя.
+//
+// Handler возвращает собственный срок напоминаний пользователя о скором окончании подписок
+// и сроки по умолчанию, которые действуют, пока собственный срок не задан.
+package accountreminders
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение настроек напоминаний.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики аккаунта
+}
+
+// Service описывает интерфейс получения настроек напоминаний.
+type Service interface {
+	GetReminderSettings(ctx context.Context, userUID string) (*models.ReminderSettings, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить настройки напоминаний
+// @Description Возвращает, за сколько дней до окончания подписок пользователь получает напоминания.
+// @Description reminder_days_before равен null, пока действуют сроки по умолчанию default_days_before.
+// @Tags Account
+// @Produce  json
+// @Success 200 {object} response.OKResponse{data=models.ReminderSettings} "Настройки напоминаний"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при получении настроек"
+// @Router /me/reminders [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.account.reminders"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	settings, err := h.service.GetReminderSettings(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get reminder settings", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not get reminder settings"))
+		return
+	}
+
+	response.OK(w, settings)
+}
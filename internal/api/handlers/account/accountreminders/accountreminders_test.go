@@ -0,0 +1,96 @@
+package accountreminders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс accountreminders.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetReminderSettings(ctx context.Context, userUID string) (*models.ReminderSettings, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.(*models.ReminderSettings), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestAccountRemindersHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	days := 7
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "собственный срок пользователя",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetReminderSettings", mock.Anything, "user123").
+					Return(&models.ReminderSettings{ReminderDaysBefore: &days, DefaultDaysBefore: []int{1}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"reminder_days_before":7,"default_days_before":[1],"digest":false}}`,
+		},
+		{
+			name:    "сроки по умолчанию",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetReminderSettings", mock.Anything, "user123").
+					Return(&models.ReminderSettings{DefaultDaysBefore: []int{3, 1}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"reminder_days_before":null,"default_days_before":[3,1],"digest":false}}`,
+		},
+		{
+			name:           "отсутствует авторизация",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetReminderSettings", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not get reminder settings"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/me/reminders", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package accountremindersupdate реализует HTTP-обработчик для изменения настроек напоминаний пользователя.
+//
+// Handler сохраняет, за сколько дней до окончания подписок пользователь получает напоминания
+// и приходят ли они одним письмом-дайджестом. Срок null возвращает сроки по умолчанию
+// из конфигурации планировщика.
+package accountremindersupdate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Request — тело запроса на изменение срока напоминаний.
+type Request struct {
+	ReminderDaysBefore *int `json:"reminder_days_before"` // от 1 до 30 дней; null — сроки по умолчанию
+	Digest             bool `json:"digest"`               // напоминания за проход планировщика одним письмом
+}
+
+// Handler обрабатывает запросы на изменение срока напоминаний.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики аккаунта
+}
+
+// Service описывает интерфейс изменения настроек напоминаний.
+type Service interface {
+	UpdateReminderSettings(ctx context.Context, userUID string, days *int, digest bool) (*models.ReminderSettings, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Изменить срок напоминаний
+// @Description Задает, за сколько дней до окончания подписок пользователь получает напоминания.
+// @Description Значение null возвращает сроки по умолчанию. С digest=true напоминания обо всех подписках
+// @Description за проход планировщика приходят одним письмом.
+// @Tags Account
+// @Accept  json
+// @Produce  json
+// @Param request body Request true "Срок напоминаний"
+// @Success 200 {object} response.OKResponse{data=models.ReminderSettings} "Настройки сохранены"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 422 {object} response.ErrorResponse "Срок вне допустимого диапазона"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при сохранении настроек"
+// @Router /me/reminders [put]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.account.reminders.update"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid request body"))
+		return
+	}
+
+	settings, err := h.service.UpdateReminderSettings(r.Context(), userUID, req.ReminderDaysBefore, req.Digest)
+	switch {
+	case errors.Is(err, models.ErrInvalidReminderDays):
+		log.Error("invalid reminder days", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to update reminder settings", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not update reminder settings"))
+		return
+	}
+
+	log.Info("reminder settings updated")
+	response.OK(w, settings)
+}
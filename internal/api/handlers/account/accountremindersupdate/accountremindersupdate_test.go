@@ -0,0 +1,135 @@
+package accountremindersupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс accountremindersupdate.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) UpdateReminderSettings(ctx context.Context, userUID string, days *int, digest bool) (*models.ReminderSettings, error) {
+	args := m.Called(ctx, userUID, days, digest)
+	if res := args.Get(0); res != nil {
+		return res.(*models.ReminderSettings), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestAccountRemindersUpdateHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	days := 7
+	invalid := 45
+
+	tests := []struct {
+		name           string
+		userUID        string
+		body           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "собственный срок",
+			userUID: "user123",
+			body:    `{"reminder_days_before":7}`,
+			setupMock: func(m *MockService) {
+				m.On("UpdateReminderSettings", mock.Anything, "user123", &days, false).
+					Return(&models.ReminderSettings{ReminderDaysBefore: &days, DefaultDaysBefore: []int{1}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"reminder_days_before":7,"default_days_before":[1],"digest":false}}`,
+		},
+		{
+			name:    "сброс к срокам по умолчанию",
+			userUID: "user123",
+			body:    `{"reminder_days_before":null}`,
+			setupMock: func(m *MockService) {
+				m.On("UpdateReminderSettings", mock.Anything, "user123", (*int)(nil), false).
+					Return(&models.ReminderSettings{DefaultDaysBefore: []int{1}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"reminder_days_before":null,"default_days_before":[1],"digest":false}}`,
+		},
+		{
+			name:    "напоминания одним письмом",
+			userUID: "user123",
+			body:    `{"reminder_days_before":null,"digest":true}`,
+			setupMock: func(m *MockService) {
+				m.On("UpdateReminderSettings", mock.Anything, "user123", (*int)(nil), true).
+					Return(&models.ReminderSettings{DefaultDaysBefore: []int{1}, Digest: true}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"reminder_days_before":null,"default_days_before":[1],"digest":true}}`,
+		},
+		{
+			name:    "срок вне диапазона",
+			userUID: "user123",
+			body:    `{"reminder_days_before":45}`,
+			setupMock: func(m *MockService) {
+				m.On("UpdateReminderSettings", mock.Anything, "user123", &invalid, false).
+					Return(nil, models.ValidateReminderDays(&invalid)).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: fmt.Sprintf(`{"status":"Error","error":"invalid reminder days: field ReminderDaysBefore must be between 1 and %d"}`,
+				models.MaxReminderDaysBefore),
+		},
+		{
+			name:           "некорректный JSON",
+			userUID:        "user123",
+			body:           `{"reminder_days_before":"week"}`,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
+		},
+		{
+			name:           "отсутствует авторизация",
+			body:           `{"reminder_days_before":7}`,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			body:    `{"reminder_days_before":7}`,
+			setupMock: func(m *MockService) {
+				m.On("UpdateReminderSettings", mock.Anything, "user123", &days, false).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not update reminder settings"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/me/reminders", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package notificationtest реализует HTTP-обработчик отправки тестового уведомления текущему пользователю.
+//
+// Handler отправляет на почту пользователя безобидное тестовое письмо тем же путем, что и
+// остальные уведомления, чтобы пользователь мог проверить доставку. Один пользователь может
+// запрашивать письмо не чаще одного раза за interval.
+package notificationtest
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+)
+
+// Handler обрабатывает запросы на отправку тестового уведомления.
+type Handler struct {
+	log      *slog.Logger  // Логгер для записи информации и ошибок
+	service  Service       // Сервис отправки уведомлений
+	interval time.Duration // Минимальный промежуток между тестовыми письмами одного пользователя
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // Время последнего запроса письма по UID пользователя
+}
+
+// Service описывает интерфейс отправки тестового уведомления.
+type Service interface {
+	SendTestNotification(ctx context.Context, userUID string) error
+}
+
+// New создает новый Handler с переданными логгером, сервисом и ограничением частоты.
+// Если clk равен nil, используется системное время.
+func New(log *slog.Logger, service Service, interval time.Duration, clk clock.Clock) *Handler {
+	return &Handler{
+		log:      log,
+		service:  service,
+		interval: interval,
+		clock:    clock.OrReal(clk),
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Отправить тестовое уведомление
+// @Description Отправляет тестовое письмо на почту текущего пользователя, чтобы проверить доставку уведомлений. Повторный запрос раньше smtp_test_interval отклоняется с 429 и заголовком Retry-After.
+// @Tags Account
+// @Produce  json
+// @Success 200 {object} response.OKResponse "Письмо отправлено"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 429 {object} response.ErrorResponse "Тестовое письмо уже запрашивалось недавно"
+// @Failure 502 {object} response.ErrorResponse "Не удалось отправить письмо"
+// @Router /me/notifications/test [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.account.notificationtest"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	if wait := h.reserve(userUID); wait > 0 {
+		log.Warn("test notification rate limited", slog.String("user_uid", userUID), slog.Duration("retry_after", wait))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		render.JSON(w, r, response.Error("test notification was requested recently"))
+		return
+	}
+
+	if err := h.service.SendTestNotification(r.Context(), userUID); err != nil {
+		log.Error("failed to send test notification", sl.Err(err))
+		w.WriteHeader(http.StatusBadGateway)
+		render.JSON(w, r, response.Error("could not send test notification"))
+		return
+	}
+
+	log.Info("test notification sent")
+	response.OK(w, map[string]any{
+		"sent": true,
+	})
+}
+
+// reserve отмечает запрос тестового письма пользователем userUID и возвращает 0
+// или время, через которое пользователь сможет запросить письмо снова.
+// Попытка учитывается и при ошибке отправки, чтобы запросы не нагружали SMTP-сервер.
+func (h *Handler) reserve(userUID string) time.Duration {
+	now := h.clock.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for uid, sent := range h.lastSent {
+		if now.Sub(sent) >= h.interval {
+			delete(h.lastSent, uid)
+		}
+	}
+	if sent, ok := h.lastSent[userUID]; ok {
+		return h.interval - now.Sub(sent)
+	}
+	h.lastSent[userUID] = now
+	return 0
+}
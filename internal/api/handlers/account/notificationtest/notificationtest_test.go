@@ -0,0 +1,95 @@
+package notificationtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+)
+
+// MockService реализует интерфейс notificationtest.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) SendTestNotification(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
+func serve(h *Handler, userUID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/me/notifications/test", nil)
+	req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: userUID}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestNotificationTestHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("письмо отправлено", func(t *testing.T) {
+		service := new(MockService)
+		service.On("SendTestNotification", mock.Anything, "user123").Return(nil).Once()
+		h := New(logger, service, time.Minute, nil)
+
+		w := serve(h, "user123")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"OK","data":{"sent":true}}`, w.Body.String())
+		service.AssertExpectations(t)
+	})
+
+	t.Run("повторный запрос раньше интервала отклоняется", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+		service := new(MockService)
+		service.On("SendTestNotification", mock.Anything, "user123").Return(nil).Twice()
+		service.On("SendTestNotification", mock.Anything, "user456").Return(nil).Once()
+		h := New(logger, service, time.Minute, clk)
+
+		assert.Equal(t, http.StatusOK, serve(h, "user123").Code)
+
+		clk.Advance(20 * time.Second)
+		w := serve(h, "user123")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "40", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"status":"Error","error":"test notification was requested recently"}`, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, serve(h, "user456").Code, "limit is per user")
+
+		clk.Advance(40 * time.Second)
+		assert.Equal(t, http.StatusOK, serve(h, "user123").Code)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("ошибка отправки", func(t *testing.T) {
+		service := new(MockService)
+		service.On("SendTestNotification", mock.Anything, "user123").Return(errors.New("smtp down")).Once()
+		h := New(logger, service, time.Minute, nil)
+
+		w := serve(h, "user123")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.JSONEq(t, `{"status":"Error","error":"could not send test notification"}`, w.Body.String())
+		service.AssertExpectations(t)
+	})
+
+	t.Run("пользователь не авторизован", func(t *testing.T) {
+		service := new(MockService)
+		w := serve(New(logger, service, time.Minute, nil), "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"status":"Error","error":"unauthorized"}`, w.Body.String())
+		service.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,72 @@
+// Package integrity реализует HTTP-обработчик проверки целостности данных.
+//
+// Handler запускает обслуживающую задачу, которая ищет аномалии данных: подписки без
+// пользователя, платежи со ссылкой на несуществующую подписку, отрицательные цены
+// и next_payment_date раньше start_date, — и возвращает отчет. Данные не исправляются.
+// Доступен только администраторам.
+package integrity
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на проверку целостности данных.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики обслуживающих задач
+}
+
+// Service описывает интерфейс бизнес-логики проверки целостности.
+type Service interface {
+	CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Проверить целостность данных
+// @Description Ищет подписки без пользователя, платежи со ссылкой на несуществующую подписку, подписки с отрицательной ценой и с next_payment_date раньше start_date. Для каждого вида аномалии возвращает число записей и ID первых 100 из них; данные не исправляются.
+// @Tags Admin
+// @Produce  json
+// @Success 200 {object} response.OKResponse{data=models.IntegrityReport} "Отчет о целостности"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при проверке"
+// @Router /admin/maintenance/integrity-check [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.integrity"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	report, err := h.service.CheckIntegrity(r.Context())
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to check data integrity", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not check data integrity"))
+		return
+	}
+
+	log.Info("data integrity checked", slog.Int("total", report.Total))
+	response.OK(w, report)
+}
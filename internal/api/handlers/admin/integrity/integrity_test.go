@@ -0,0 +1,82 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс integrity.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error) {
+	args := m.Called(ctx)
+	if res := args.Get(0); res != nil {
+		return res.(*models.IntegrityReport), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestIntegrityHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	empty := models.IntegrityIssue{IDs: []int{}}
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "найдены аномалии",
+			setupMock: func(m *MockService) {
+				m.On("CheckIntegrity", mock.Anything).Return(&models.IntegrityReport{
+					OrphanSubscriptions: models.IntegrityIssue{Count: 1, IDs: []int{7}},
+					OrphanPayments:      empty,
+					NegativePrices:      models.IntegrityIssue{Count: 2, IDs: []int{3, 4}},
+					PaymentBeforeStart:  empty,
+					Total:               3,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"orphan_subscriptions":{"count":1,"ids":[7]},` +
+				`"orphan_payments":{"count":0,"ids":[]},"negative_prices":{"count":2,"ids":[3,4]},` +
+				`"payment_before_start":{"count":0,"ids":[]},"total":3}}`,
+		},
+		{
+			name: "ошибка сервиса",
+			setupMock: func(m *MockService) {
+				m.On("CheckIntegrity", mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not check data integrity"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/maintenance/integrity-check", nil)
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package recompute реализует HTTP-обработчик для пересчета дат следующего платежа.
+//
+// Handler запускает обслуживающую задачу, которая сверяет next_payment_date всех активных подписок
+// с датой, рассчитанной от start_date и counter_months, исправляет расхождения и возвращает
+// количество проверенных и исправленных записей. Доступен только администраторам.
+package recompute
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на пересчет дат следующего платежа.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики обслуживающих задач
+}
+
+// Service описывает интерфейс бизнес-логики пересчета дат.
+type Service interface {
+	RecomputePaymentDates(ctx context.Context) (*models.RecomputeResult, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Пересчитать даты следующего платежа
+// @Description Сверяет next_payment_date активных подписок с расчетной датой и исправляет расхождения.
+// @Tags Admin
+// @Produce  json
+// @Success 200 {object} map[string]any "Итог пересчета"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при пересчете"
+// @Router /admin/maintenance/recompute-payment-dates [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.recompute"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	result, err := h.service.RecomputePaymentDates(r.Context())
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to recompute payment dates", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not recompute payment dates"))
+		return
+	}
+
+	log.Info("payment dates recomputed", slog.Int("corrected", result.Corrected))
+	response.OK(w, map[string]any{
+		"result": result,
+	})
+}
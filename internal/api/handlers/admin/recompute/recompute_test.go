@@ -0,0 +1,73 @@
+package recompute
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс recompute.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) RecomputePaymentDates(ctx context.Context) (*models.RecomputeResult, error) {
+	args := m.Called(ctx)
+	if res := args.Get(0); res != nil {
+		return res.(*models.RecomputeResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestRecomputeHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "успешный пересчет",
+			setupMock: func(m *MockService) {
+				m.On("RecomputePaymentDates", mock.Anything).Return(&models.RecomputeResult{Checked: 10, Corrected: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"result":{"checked":10,"corrected":2,"failed":0}}}`,
+		},
+		{
+			name: "ошибка сервиса",
+			setupMock: func(m *MockService) {
+				m.On("RecomputePaymentDates", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not recompute payment dates"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/maintenance/recompute-payment-dates", nil)
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package replay реализует HTTP-обработчик для повторной отправки уведомлений за прошедший день.
+//
+// Handler повторяет поиск напоминаний об окончании подписок и пробных периодов так, будто сегодня
+// указанный день, например после простоя планировщика. Найденные уведомления записываются в outbox
+// с теми же ключами дедупликации, поэтому уже отправленные не дублируются. Доступен только администраторам.
+package replay
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// dateLayout — формат параметра date.
+const dateLayout = "2006-01-02"
+
+// Handler обрабатывает запросы на повторную отправку уведомлений.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис планировщика уведомлений
+}
+
+// Service описывает интерфейс повторного поиска уведомлений за день.
+type Service interface {
+	ReplayNotifications(ctx context.Context, date time.Time) (*models.NotificationReplayResult, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Повторить уведомления за день
+// @Description Повторяет поиск напоминаний об окончании подписок и пробных периодов за прошедший день и ставит найденные уведомления в очередь. Уже отправленные уведомления пропускаются.
+// @Tags Admin
+// @Produce  json
+// @Param date query string true "День в формате 2006-01-02, не позже сегодняшнего"
+// @Success 200 {object} map[string]any "Итог повтора"
+// @Failure 400 {object} response.ErrorResponse "Некорректная дата или дата из будущего"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при повторе"
+// @Router /admin/notifications/replay [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.replay"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	date, err := time.Parse(dateLayout, r.URL.Query().Get("date"))
+	if err != nil {
+		log.Error("invalid date", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("date must be in format 2006-01-02"))
+		return
+	}
+
+	result, err := h.service.ReplayNotifications(r.Context(), date)
+	if errors.Is(err, models.ErrReplayDateInFuture) {
+		log.Error("replay date is in the future", slog.String("date", date.Format(dateLayout)))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to replay notifications", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not replay notifications"))
+		return
+	}
+
+	log.Info("notifications replayed", slog.String("date", result.Date),
+		slog.Int("reminders_queued", result.Reminders.Queued),
+		slog.Int("trial_expiring_queued", result.TrialExpiring.Queued))
+	response.OK(w, map[string]any{
+		"result": result,
+	})
+}
@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс replay.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ReplayNotifications(ctx context.Context, date time.Time) (*models.NotificationReplayResult, error) {
+	args := m.Called(ctx, date)
+	if res := args.Get(0); res != nil {
+		return res.(*models.NotificationReplayResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestReplayHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	day := time.Date(2025, 7, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "успешный повтор",
+			query: "?date=2025-07-08",
+			setupMock: func(m *MockService) {
+				m.On("ReplayNotifications", mock.Anything, day).Return(&models.NotificationReplayResult{
+					Date:          "2025-07-08",
+					Reminders:     models.NotificationReplayStats{Found: 2, Queued: 1, Skipped: 1},
+					TrialExpiring: models.NotificationReplayStats{Found: 1, Queued: 1},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"result":{"date":"2025-07-08",` +
+				`"reminders":{"found":2,"queued":1,"skipped":1,"failed":0},` +
+				`"trial_expiring":{"found":1,"queued":1,"skipped":0,"failed":0}}}}`,
+		},
+		{
+			name:           "дата не указана",
+			query:          "",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"date must be in format 2006-01-02"}`,
+		},
+		{
+			name:           "некорректная дата",
+			query:          "?date=08-07-2025",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"date must be in format 2006-01-02"}`,
+		},
+		{
+			name:  "дата из будущего",
+			query: "?date=2025-07-08",
+			setupMock: func(m *MockService) {
+				m.On("ReplayNotifications", mock.Anything, day).Return(nil, models.ErrReplayDateInFuture)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   fmt.Sprintf(`{"status":"Error","error":%q}`, models.ErrReplayDateInFuture.Error()),
+		},
+		{
+			name:  "ошибка сервиса",
+			query: "?date=2025-07-08",
+			setupMock: func(m *MockService) {
+				m.On("ReplayNotifications", mock.Anything, day).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not replay notifications"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/notifications/replay"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
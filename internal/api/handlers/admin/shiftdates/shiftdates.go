@@ -0,0 +1,103 @@
+// Package shiftdates реализует HTTP-обработчик массового сдвига дат следующего платежа.
+//
+// Handler сдвигает next_payment_date активных подписок, выбранных фильтром, на заданное
+// количество дней в одной транзакции и возвращает количество измененных подписок.
+// Используется для ручной корректировки дат списания. Доступен только администраторам.
+package shiftdates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на сдвиг дат следующего платежа.
+type Handler struct {
+	log      *slog.Logger        // Логгер для записи информации и ошибок
+	service  Service             // Сервис бизнес-логики обслуживающих задач
+	validate *validator.Validate // Валидатор структуры входящих данных
+}
+
+// Service описывает интерфейс бизнес-логики сдвига дат следующего платежа.
+type Service interface {
+	ShiftPaymentDates(ctx context.Context, req models.PaymentDateShiftRequest) (*models.PaymentDateShiftResult, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:      log,
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Сдвинуть даты следующего платежа
+// @Description Сдвигает next_payment_date активных подписок, выбранных фильтром (service_name, user_uid, due_from, due_to; хотя бы одно условие), на offset_days дней (от -366 до 366, кроме 0) в одной транзакции.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param request body models.PaymentDateShiftRequest true "Фильтр подписок и сдвиг в днях"
+// @Success 200 {object} map[string]any "Итог сдвига"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при сдвиге дат"
+// @Router /admin/subscriptions/shift-payment-dates [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.shiftdates"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req models.PaymentDateShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
+		return
+	}
+
+	result, err := h.service.ShiftPaymentDates(r.Context(), req)
+	switch {
+	case errors.Is(err, models.ErrInvalidShiftRequest):
+		log.Error("invalid shift request", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to shift payment dates", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not shift payment dates"))
+		return
+	}
+
+	log.Info("payment dates shifted", slog.Int("offset_days", result.OffsetDays), slog.Int("shifted", result.Shifted))
+	response.OK(w, map[string]any{
+		"result": result,
+	})
+}
@@ -0,0 +1,115 @@
+package shiftdates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс shiftdates.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ShiftPaymentDates(ctx context.Context, req models.PaymentDateShiftRequest) (*models.PaymentDateShiftResult, error) {
+	args := m.Called(ctx, req)
+	if res := args.Get(0); res != nil {
+		return res.(*models.PaymentDateShiftResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestShiftDatesHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "сдвиг вперед",
+			body: `{"filter":{"service_name":"Netflix"},"offset_days":7}`,
+			setupMock: func(m *MockService) {
+				m.On("ShiftPaymentDates", mock.Anything, models.PaymentDateShiftRequest{
+					Filter: models.PaymentDateShiftFilter{ServiceName: "Netflix"}, OffsetDays: 7,
+				}).Return(&models.PaymentDateShiftResult{OffsetDays: 7, Shifted: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"result":{"offset_days":7,"shifted":3}}}`,
+		},
+		{
+			name: "сдвиг назад",
+			body: `{"filter":{"due_from":"2025-07-01","due_to":"2025-07-31"},"offset_days":-3}`,
+			setupMock: func(m *MockService) {
+				m.On("ShiftPaymentDates", mock.Anything, models.PaymentDateShiftRequest{
+					Filter: models.PaymentDateShiftFilter{DueFrom: "2025-07-01", DueTo: "2025-07-31"}, OffsetDays: -3,
+				}).Return(&models.PaymentDateShiftResult{OffsetDays: -3, Shifted: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"result":{"offset_days":-3,"shifted":1}}}`,
+		},
+		{
+			name:           "некорректный JSON",
+			body:           `{"filter":`,
+			setupMock:      func(*MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
+		},
+		{
+			name:           "нулевой сдвиг",
+			body:           `{"filter":{"service_name":"Netflix"},"offset_days":0}`,
+			setupMock:      func(*MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"field OffsetDays is a required field"}`,
+		},
+		{
+			name: "пустой фильтр",
+			body: `{"filter":{},"offset_days":7}`,
+			setupMock: func(m *MockService) {
+				m.On("ShiftPaymentDates", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: filter must contain at least one condition", models.ErrInvalidShiftRequest))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid shift request: filter must contain at least one condition"}`,
+		},
+		{
+			name: "ошибка сервиса",
+			body: `{"filter":{"service_name":"Netflix"},"offset_days":7}`,
+			setupMock: func(m *MockService) {
+				m.On("ShiftPaymentDates", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not shift payment dates"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/subscriptions/shift-payment-dates", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Package stats реализует HTTP-обработчик для получения агрегированной статистики сервиса.
+//
+// Handler вызывает бизнес-логику сбора статистики (количество пользователей, активных подписок,
+// MRR и конверсию пробного периода) и возвращает результат в JSON-формате.
+// Доступен только администраторам.
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение статистики сервиса.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики для сбора статистики
+}
+
+// Service описывает интерфейс бизнес-логики получения статистики.
+type Service interface {
+	GetStats(ctx context.Context) (*models.AdminStats, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить статистику сервиса
+// @Description Возвращает агрегированную статистику: пользователи, активные подписки, MRR, конверсия пробного периода.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]any "Статистика сервиса"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при сборе статистики"
+// @Router /admin/stats [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.stats"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	stats, err := h.service.GetStats(r.Context())
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get stats", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not get stats"))
+		return
+	}
+
+	log.Info("success to get stats")
+	render.JSON(w, r, response.OKWithData(map[string]any{
+		"stats": stats,
+	}))
+}
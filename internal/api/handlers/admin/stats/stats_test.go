@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс stats.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetStats(ctx context.Context) (*models.AdminStats, error) {
+	args := m.Called(ctx)
+	if res := args.Get(0); res != nil {
+		return res.(*models.AdminStats), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestStatsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "успешное получение статистики",
+			setupMock: func(m *MockService) {
+				m.On("GetStats", mock.Anything).Return(&models.AdminStats{
+					TotalUsers:          3,
+					UsersByStatus:       map[string]int{"trial": 2, "active": 1},
+					ActiveSubscriptions: 5,
+					MRR:                 1500,
+					TrialConversions:    1,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"stats":{"total_users":3,"users_by_status":{"trial":2,"active":1},
+				"active_subscriptions":5,"mrr":1500,"trial_conversions":1}}}`,
+		},
+		{
+			name: "ошибка сервиса",
+			setupMock: func(m *MockService) {
+				m.On("GetStats", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not get stats"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
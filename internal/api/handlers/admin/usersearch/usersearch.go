@@ -0,0 +1,105 @@
+// Package usersearch реализует HTTP-обработчик поиска пользователей для службы поддержки.
+//
+// Handler ищет пользователей по части имени или электронной почты без учета регистра
+// и возвращает страницу результатов без хэшей паролей. Доступен только администраторам.
+package usersearch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/pagination"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// maxQueryLength — максимальная длина строки поиска.
+const maxQueryLength = 100
+
+// Handler обрабатывает запросы на поиск пользователей.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики поиска пользователей
+}
+
+// Service описывает интерфейс бизнес-логики поиска пользователей.
+type Service interface {
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Найти пользователей
+// @Description Ищет пользователей по части имени или электронной почты без учета регистра. Результаты упорядочены по имени.
+// @Tags Admin
+// @Produce  json
+// @Param q query string true "Часть имени пользователя или электронной почты"
+// @Param limit query int false "Максимальное количество записей (по умолчанию 10, не больше 100)" minimum(1)
+// @Param offset query int false "Смещение для пагинации (по умолчанию 0)" minimum(0)
+// @Success 200 {object} response.OKResponse "Найденные пользователи"
+// @Failure 400 {object} response.ErrorResponse "Пустая или слишком длинная строка поиска, некорректный limit или offset"
+// @Failure 403 {object} response.ErrorResponse "Доступ запрещён"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при поиске"
+// @Router /admin/users/search [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.admin.usersearch"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		log.Warn("empty search query")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("query parameter q is required"))
+		return
+	}
+	if len([]rune(query)) > maxQueryLength {
+		log.Warn("search query is too long", slog.Int("length", len([]rune(query))))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("query parameter q is too long"))
+		return
+	}
+
+	limit, offset, err := pagination.Parse(r)
+	if err != nil {
+		log.Warn("invalid pagination parameters", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	users, err := h.service.SearchUsers(r.Context(), query, limit, offset)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to search users", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not search users"))
+		return
+	}
+
+	log.Info("success to search users", slog.Int("count", len(users)))
+	render.JSON(w, r, response.OKWithData(map[string]any{
+		"users":  users,
+		"limit":  limit,
+		"offset": offset,
+	}))
+}
@@ -0,0 +1,112 @@
+package usersearch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс usersearch.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.UserSummary), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestUserSearchHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "успешный поиск",
+			query: "?q=%20Alice%20&limit=5&offset=10",
+			setupMock: func(m *MockService) {
+				m.On("SearchUsers", mock.Anything, "Alice", 5, 10).Return([]*models.UserSummary{
+					{UUID: "uid-1", Email: "alice@example.com", Username: "alice", Role: "user", SubscriptionStatus: "trial"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"limit":5,"offset":10,"users":[{"uid":"uid-1","email":"alice@example.com",
+				"username":"alice","role":"user","subscription_status":"trial"}]}}`,
+		},
+		{
+			name:  "пагинация по умолчанию",
+			query: "?q=example",
+			setupMock: func(m *MockService) {
+				m.On("SearchUsers", mock.Anything, "example", 10, 0).Return([]*models.UserSummary{}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"limit":10,"offset":0,"users":[]}}`,
+		},
+		{
+			name:           "пустая строка поиска",
+			query:          "?q=%20",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"query parameter q is required"}`,
+		},
+		{
+			name:           "слишком длинная строка поиска",
+			query:          "?q=" + strings.Repeat("a", maxQueryLength+1),
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"query parameter q is too long"}`,
+		},
+		{
+			name:           "некорректный limit",
+			query:          "?q=alice&limit=0",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"limit must be a positive integer"}`,
+		},
+		{
+			name:  "ошибка сервиса",
+			query: "?q=alice",
+			setupMock: func(m *MockService) {
+				m.On("SearchUsers", mock.Anything, "alice", 10, 0).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not search users"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -82,7 +82,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Error("invalid request body"))
 		return
 	}
-	log.Info("request body decoded", slog.Any("request", req))
+	log.Info("request body decoded", sl.Redact("request", req))
 
 	if err := h.validate.Struct(req); err != nil {
 		log.Error("validation failed", sl.Err(err))
@@ -94,6 +94,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	grpcResp, err := h.authClient.Login(r.Context(), req.Username, req.Password)
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("login failed", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("invalid credentials"))
@@ -4,6 +4,7 @@ package register
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -13,6 +14,7 @@ import (
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // Request — входные данные для регистрации
@@ -67,7 +69,8 @@ func New(log *slog.Logger, authClient AuthService, subscriptionService Subscript
 // @Param request body Request true "Данные нового пользователя"
 // @Success 200 {object} map[string]any "Успешная регистрация"
 // @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
-// @Failure 422 {object} response.ErrorResponse "Ошибка валидации данных"
+// @Failure 409 {object} response.ErrorResponse "Пользователь с таким username или email уже существует"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации данных или недопустимый домен email"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при регистрации"
 // @Router /register [post]
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -85,7 +88,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Error("invalid request body"))
 		return
 	}
-	log.Info("request body decoded", slog.Any("request", req))
+	log.Info("request body decoded", sl.Redact("request", req))
 
 	if err := h.validate.Struct(req); err != nil {
 		log.Error("validation failed", sl.Err(err))
@@ -96,7 +99,23 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Info("all fields are validated")
 
 	userUID, err := h.authClient.Register(r.Context(), req.Email, req.Username, req.Password)
+	if errors.Is(err, models.ErrUserExists) {
+		log.Warn("user already exists", slog.String("username", req.Username))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("user already exists"))
+		return
+	}
+	// Домен email проверяет сервис авторизации, поэтому ограничение действует и для прямых вызовов gRPC
+	if errors.Is(err, models.ErrEmailDomainNotAllowed) {
+		log.Warn("email domain is not allowed", slog.String("email", req.Email))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(models.ErrEmailDomainNotAllowed.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("registration failed", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("failed to register user"))
@@ -104,6 +123,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	_, err = h.subscriptionService.CreateEntrySubscriptionAggregator(r.Context(), req.Username, userUID)
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to create entry with trial period", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("failed to create entry with trial period"))
@@ -14,6 +14,8 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // Мок клиента с методом Register
@@ -105,6 +107,19 @@ func TestRegisterHandler_ServeHTTP(t *testing.T) {
 			wantError:      "failed to register user",
 			wantStatus:     "Error",
 		},
+		{
+			name: "user already exists",
+			requestBody: Request{
+				Username: "user1",
+				Password: "password123",
+				Email:    "user1@example.com",
+			},
+			mockErr:        models.ErrUserExists,
+			wantStatusCode: http.StatusConflict,
+			wantData:       nil,
+			wantError:      "user already exists",
+			wantStatus:     "Error",
+		},
 	}
 
 	for _, tt := range tests {
@@ -112,7 +127,7 @@ func TestRegisterHandler_ServeHTTP(t *testing.T) {
 			authMock.ExpectedCalls = nil
 			authMock.Calls = nil
 
-			if tt.name == "valid registration" || tt.name == "registration grpc error" {
+			if tt.name == "valid registration" || tt.name == "registration grpc error" || tt.name == "user already exists" {
 				if tt.mockErr != nil {
 					authMock.On("Register", mock.Anything,
 						mock.Anything,
@@ -179,3 +194,24 @@ func TestRegisterHandler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterHandler_EmailDomainNotAllowed(t *testing.T) {
+	authMock := new(AuthClientMock)
+	subscriptionService := new(SubscriptionServiceMock)
+	handler := New(newNoopLogger(), authMock, subscriptionService)
+
+	authMock.On("Register", mock.Anything, "user1@gmail.com", "user1", "password123").
+		Return("", models.ErrEmailDomainNotAllowed).Once()
+
+	body, err := json.Marshal(Request{Username: "user1", Password: "password123", Email: "user1@gmail.com"})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{"status":"Error","error":"email domain is not allowed for registration"}`, rec.Body.String())
+	authMock.AssertExpectations(t)
+	subscriptionService.AssertExpectations(t)
+}
@@ -4,21 +4,26 @@ package paymentcreate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 )
 
 // CreatePaymentMethodRequestApp представляет запрос на создание платежного метода.
 type CreatePaymentMethodRequestApp struct {
 	PaymentMethodToken string `json:"payment_method_token" validate:"required"`
+	PromoCode          string `json:"promo_code,omitempty" validate:"omitempty,max=64"`
 }
 
 // ProviderClient определяет интерфейс для работы с платежным провайдером.
@@ -30,6 +35,10 @@ type ProviderClient interface {
 type Service interface {
 	GetOrCreatePaymentToken(context context.Context, userUID string, token string) (int, error)
 	GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID string) (string, error)
+	GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error)
+	ApplyPromo(ctx context.Context, code string, amount int64) (*models.PromoApplication, error)
+	ReleasePromo(ctx context.Context, promoCodeID int) error
+	RecordPromoUsage(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error
 }
 
 // Handler обрабатывает запросы на создание платежных методов.
@@ -52,7 +61,7 @@ func New(log *slog.Logger, providerClient ProviderClient, ps Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Создать платеж
-// @Description Создает новый платеж через YooKassa для активной подписки пользователя
+// @Description Создает новый платеж через YooKassa для активной подписки пользователя. Если передан promo_code, сумма платежа уменьшается на скидку промокода.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
@@ -60,8 +69,9 @@ func New(log *slog.Logger, providerClient ProviderClient, ps Service) *Handler {
 // @Success 200 {object} paymentprovider.CreatePaymentResponse "Успешное создание платежа"
 // @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
-// @Failure 422 {object} response.ErrorResponse "Ошибка валидации"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации или промокод недействителен"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при создании платежа"
+// @Failure 503 {object} response.ErrorResponse "Платежный провайдер временно недоступен"
 // @Router /payments/create [post]
 // @Security BearerAuth
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -87,8 +97,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userUID, ok := r.Context().Value(middlewarectx.UserUID).(string)
-	if !ok || userUID == "" {
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
 		log.Error("user UID not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
@@ -96,39 +106,118 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	subscriptionID, err := h.paymentService.GetActiveSubscriptionIDByUserUID(r.Context(), userUID)
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to get active subscription", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("internal error"))
 		return
 	}
-	_, err = h.paymentService.GetOrCreatePaymentToken(r.Context(), userUID, req.PaymentMethodToken)
+	tokenID, err := h.paymentService.GetOrCreatePaymentToken(r.Context(), userUID, req.PaymentMethodToken)
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to create or read payment token", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("internal error"))
 		return
 	}
 
+	plan, err := h.paymentService.GetUserPlan(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get user plan", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	amount := plan.Price
+	var promo *models.PromoApplication
+	if req.PromoCode != "" {
+		promo, err = h.paymentService.ApplyPromo(r.Context(), req.PromoCode, plan.Price)
+		if promoErr := promoError(err); promoErr != nil {
+			log.Warn("promo code rejected", slog.String("promo_code", req.PromoCode), sl.Err(err))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error(promoErr.Error()))
+			return
+		}
+		if err != nil {
+			if response.ContextError(w, log, err) {
+				return
+			}
+			log.Error("failed to apply promo code", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+		amount = promo.Amount
+	}
+
 	paymentReq := yookassa.CreatePaymentRequest{
 		PaymentToken: req.PaymentMethodToken,
 		Amount: yookassa.Amount{
-			Value:    "200.00",
-			Currency: "RUB",
+			Value:    money.FormatDecimal(amount),
+			Currency: plan.Currency,
 		},
 		Metadata: map[string]string{
-			"user_uid":        userUID,
-			"subscription_id": subscriptionID,
+			"user_uid":         userUID,
+			"subscription_id":  subscriptionID,
+			"payment_token_id": strconv.Itoa(tokenID),
+			"plan_id":          strconv.Itoa(plan.ID),
 		},
 	}
+	if promo != nil {
+		paymentReq.Metadata["promo_code_id"] = strconv.Itoa(promo.PromoCodeID)
+	}
 
 	paymentResp, err := h.providerClient.CreatePayment(paymentReq)
+	if err != nil && promo != nil {
+		// Платеж не создан: зарезервированное использование промокода возвращается
+		if releaseErr := h.paymentService.ReleasePromo(context.WithoutCancel(r.Context()), promo.PromoCodeID); releaseErr != nil {
+			log.Error("failed to release promo code", slog.Int("promo_code_id", promo.PromoCodeID), sl.Err(releaseErr))
+		}
+	}
+	if errors.Is(err, yookassa.ErrProviderUnavailable) {
+		log.Error("payment provider is unavailable", sl.Err(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		render.JSON(w, r, response.Error("payment provider unavailable"))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to create payment method from provider", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("payment provider error"))
 		return
 	}
 
+	if promo != nil {
+		// Платеж уже создан у провайдера, а использование промокода засчитано до списания,
+		// поэтому ошибка сохранения связи с платежом не отменяет платеж и не превышает лимит
+		err := h.paymentService.RecordPromoUsage(r.Context(), promo.PromoCodeID, userUID, paymentResp.ID, promo.Discount)
+		if err != nil {
+			log.Error("failed to record promo code usage", slog.Int("promo_code_id", promo.PromoCodeID),
+				slog.String("payment_id", paymentResp.ID), sl.Err(err))
+		}
+	}
+
 	log.Info("success to create payment method", slog.Any("payment-resp", paymentResp))
 	render.JSON(w, r, paymentResp)
 }
+
+// promoError возвращает ошибку недействительного промокода, содержащуюся в err, или nil.
+func promoError(err error) error {
+	for _, target := range []error{models.ErrPromoCodeNotFound, models.ErrPromoCodeExpired, models.ErrPromoCodeExhausted} {
+		if errors.Is(err, target) {
+			return target
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 )
 
@@ -45,12 +47,40 @@ func (m *MockService) GetActiveSubscriptionIDByUserUID(ctx context.Context, user
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockService) GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Plan), args.Error(1)
+}
+
+func (m *MockService) ApplyPromo(ctx context.Context, code string, amount int64) (*models.PromoApplication, error) {
+	args := m.Called(ctx, code, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoApplication), args.Error(1)
+}
+
+func (m *MockService) ReleasePromo(ctx context.Context, promoCodeID int) error {
+	args := m.Called(ctx, promoCodeID)
+	return args.Error(0)
+}
+
+func (m *MockService) RecordPromoUsage(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error {
+	args := m.Called(ctx, promoCodeID, userUID, paymentID, discount)
+	return args.Error(0)
+}
+
 func newNoopLogger() *slog.Logger {
 	h := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})
 	return slog.New(h)
 }
 
 func TestPaymentCreateHandler_ServeHTTP(t *testing.T) {
+	standardPlan := &models.Plan{ID: 1, Code: "standard", Price: 20000, Currency: "RUB", IsDefault: true}
+
 	tests := []struct {
 		name           string
 		requestBody    interface{}
@@ -68,12 +98,15 @@ func TestPaymentCreateHandler_ServeHTTP(t *testing.T) {
 			setupMocks: func(pc *MockProviderClient, ps *MockService) {
 				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
 				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
 				pc.On("CreatePayment", mock.MatchedBy(func(req yookassa.CreatePaymentRequest) bool {
 					return req.PaymentToken == "token123" &&
 						req.Amount.Value == "200.00" &&
 						req.Amount.Currency == "RUB" &&
 						req.Metadata["user_uid"] == "user123" &&
-						req.Metadata["subscription_id"] == "sub123"
+						req.Metadata["subscription_id"] == "sub123" &&
+						req.Metadata["payment_token_id"] == "42" &&
+						req.Metadata["plan_id"] == "1"
 				})).Return(&yookassa.CreatePaymentResponse{
 					ID:     "payment123",
 					Status: "succeeded",
@@ -86,6 +119,148 @@ func TestPaymentCreateHandler_ServeHTTP(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"id":"payment123","status":"succeeded","amount":{"value":"200.00","currency":"RUB"},"created_at":"0001-01-01T00:00:00Z"}`,
 		},
+		{
+			name: "success - charge amount of selected plan",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").
+					Return(&models.Plan{ID: 2, Code: "premium", Price: 49990, Currency: "USD"}, nil).Once()
+				pc.On("CreatePayment", mock.MatchedBy(func(req yookassa.CreatePaymentRequest) bool {
+					return req.Amount.Value == "499.90" &&
+						req.Amount.Currency == "USD" &&
+						req.Metadata["plan_id"] == "2"
+				})).Return(&yookassa.CreatePaymentResponse{
+					ID:     "payment124",
+					Status: "pending",
+					Amount: yookassa.Amount{
+						Value:    "499.90",
+						Currency: "USD",
+					},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"payment124","status":"pending","amount":{"value":"499.90","currency":"USD"},"created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "success - promo code reduces charged amount",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "SUMMER25",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "SUMMER25", int64(20000)).
+					Return(&models.PromoApplication{PromoCodeID: 5, Amount: 15000, Discount: 5000}, nil).Once()
+				pc.On("CreatePayment", mock.MatchedBy(func(req yookassa.CreatePaymentRequest) bool {
+					return req.Amount.Value == "150.00" &&
+						req.Metadata["promo_code_id"] == "5"
+				})).Return(&yookassa.CreatePaymentResponse{
+					ID:     "payment125",
+					Status: "pending",
+					Amount: yookassa.Amount{
+						Value:    "150.00",
+						Currency: "RUB",
+					},
+				}, nil).Once()
+				ps.On("RecordPromoUsage", mock.Anything, 5, "user123", "payment125", int64(5000)).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"payment125","status":"pending","amount":{"value":"150.00","currency":"RUB"},"created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "success - promo usage record error does not fail created payment",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "LIMITED",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "LIMITED", int64(20000)).
+					Return(&models.PromoApplication{PromoCodeID: 6, Amount: 18000, Discount: 2000}, nil).Once()
+				pc.On("CreatePayment", mock.Anything).Return(&yookassa.CreatePaymentResponse{
+					ID:     "payment126",
+					Status: "pending",
+				}, nil).Once()
+				ps.On("RecordPromoUsage", mock.Anything, 6, "user123", "payment126", int64(2000)).
+					Return(errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"payment126","status":"pending","amount":{"value":"","currency":""},"created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "expired promo code",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "OLD",
+			},
+			userUID: "user123",
+			setupMocks: func(_ *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "OLD", int64(20000)).Return(nil, models.ErrPromoCodeExpired).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"promo code is not valid at this time"}`,
+		},
+		{
+			name: "exhausted promo code",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "LIMITED",
+			},
+			userUID: "user123",
+			setupMocks: func(_ *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "LIMITED", int64(20000)).Return(nil, models.ErrPromoCodeExhausted).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"promo code usage limit reached"}`,
+		},
+		{
+			name: "unknown promo code",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "UNKNOWN",
+			},
+			userUID: "user123",
+			setupMocks: func(_ *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "UNKNOWN", int64(20000)).
+					Return(nil, fmt.Errorf("failed to get promo code: %w", models.ErrPromoCodeNotFound)).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"promo code not found"}`,
+		},
+		{
+			name: "get user plan error",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+			},
+			userUID: "user123",
+			setupMocks: func(_ *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(nil, models.ErrPlanNotFound).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
 		{
 			name:           "invalid JSON",
 			requestBody:    "not a json",
@@ -148,11 +323,65 @@ func TestPaymentCreateHandler_ServeHTTP(t *testing.T) {
 			setupMocks: func(pc *MockProviderClient, ps *MockService) {
 				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
 				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				pc.On("CreatePayment", mock.Anything).Return(nil, errors.New("provider error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"payment provider error"}`,
+		},
+		{
+			name: "provider circuit open",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				pc.On("CreatePayment", mock.Anything).Return(nil, yookassa.ErrProviderUnavailable).Once()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"Error","error":"payment provider unavailable"}`,
+		},
+		{
+			name: "provider error releases reserved promo code",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "SUMMER25",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "SUMMER25", int64(20000)).
+					Return(&models.PromoApplication{PromoCodeID: 5, Amount: 15000, Discount: 5000}, nil).Once()
 				pc.On("CreatePayment", mock.Anything).Return(nil, errors.New("provider error")).Once()
+				ps.On("ReleasePromo", mock.Anything, 5).Return(nil).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"payment provider error"}`,
 		},
+		{
+			name: "provider circuit open releases reserved promo code",
+			requestBody: CreatePaymentMethodRequestApp{
+				PaymentMethodToken: "token123",
+				PromoCode:          "SUMMER25",
+			},
+			userUID: "user123",
+			setupMocks: func(pc *MockProviderClient, ps *MockService) {
+				ps.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
+				ps.On("GetOrCreatePaymentToken", mock.Anything, "user123", "token123").Return(42, nil).Once()
+				ps.On("GetUserPlan", mock.Anything, "user123").Return(standardPlan, nil).Once()
+				ps.On("ApplyPromo", mock.Anything, "SUMMER25", int64(20000)).
+					Return(&models.PromoApplication{PromoCodeID: 5, Amount: 15000, Discount: 5000}, nil).Once()
+				pc.On("CreatePayment", mock.Anything).Return(nil, yookassa.ErrProviderUnavailable).Once()
+				ps.On("ReleasePromo", mock.Anything, 5).Return(errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"Error","error":"payment provider unavailable"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -240,6 +469,8 @@ func TestPaymentCreateHandler_Validation(t *testing.T) {
 			if tt.expectedStatus == http.StatusOK {
 				paymentService.On("GetActiveSubscriptionIDByUserUID", mock.Anything, "user123").Return("sub123", nil).Once()
 				paymentService.On("GetOrCreatePaymentToken", mock.Anything, "user123", tt.requestBody.PaymentMethodToken).Return(42, nil).Once()
+				paymentService.On("GetUserPlan", mock.Anything, "user123").
+					Return(&models.Plan{ID: 1, Price: 20000, Currency: "RUB"}, nil).Once()
 				providerClient.On("CreatePayment", mock.Anything).Return(&yookassa.CreatePaymentResponse{
 					ID:     "payment123",
 					Status: "succeeded",
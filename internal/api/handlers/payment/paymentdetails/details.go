@@ -0,0 +1,96 @@
+// Package paymentdetails обрабатывает получение данных отдельного платежа пользователя.
+package paymentdetails
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service определяет интерфейс для получения данных платежа.
+type Service interface {
+	GetPaymentDetails(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error)
+}
+
+// Handler обрабатывает запросы на получение данных платежа.
+type Handler struct {
+	log            *slog.Logger // Логгер для записи информации и ошибок
+	paymentService Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, ps Service) *Handler {
+	return &Handler{
+		log:            log,
+		paymentService: ps,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить платеж
+// @Description Возвращает платеж пользователя: сумму, валюту, статус, дату создания и связанную подписку. Идентификатор платежа у провайдера маскируется.
+// @Tags Payments
+// @Produce  json
+// @Param id path int true "ID платежа"
+// @Success 200 {object} response.OKResponse{data=models.PaymentDetails} "Данные платежа"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Платеж не найден"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при получении платежа"
+// @Router /me/payments/{id} [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.payment.details"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	res, err := h.paymentService.GetPaymentDetails(r.Context(), userUID, id)
+	if errors.Is(err, models.ErrPaymentNotFound) {
+		log.Warn("payment not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("payment not found"))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get payment", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	log.Info("payment retrieved", slog.Int("id", id))
+	response.OK(w, res)
+}
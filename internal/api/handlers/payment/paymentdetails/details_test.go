@@ -0,0 +1,124 @@
+package paymentdetails
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetPaymentDetails(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentDetails), args.Error(1)
+}
+
+func TestPaymentDetailsHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	subscriptionID := 3
+	paid := &models.PaymentDetails{
+		ID:             7,
+		PaymentID:      "********************************5e6f",
+		Amount:         49900,
+		Currency:       "RUB",
+		Status:         models.PaymentStatusSucceeded,
+		SubscriptionID: &subscriptionID,
+		ServiceName:    "Spotify",
+		CreatedAt:      time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		id             string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "платеж пользователя",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentDetails", mock.Anything, "user123", 7).Return(paid, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"id":7,"payment_id":"********************************5e6f","amount":49900,
+				"currency":"RUB","status":"succeeded","subscription_id":3,"service_name":"Spotify","created_at":"2025-05-01T12:00:00Z"}}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+		{
+			name:    "платеж другого пользователя или не существует",
+			userUID: "user123",
+			id:      "8",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentDetails", mock.Anything, "user123", 8).Return(nil, models.ErrPaymentNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"payment not found"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentDetails", mock.Anything, "user123", 7).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/payments/"+tt.id, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/pagination"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
@@ -17,7 +18,7 @@ import (
 
 // Service определяет интерфейс для работы с платежами.
 type Service interface {
-	ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error)
+	ListPaymentTokens(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error)
 }
 
 // Handler обрабатывает запросы на получение списка платежных методов.
@@ -38,11 +39,14 @@ func New(log *slog.Logger, ps Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Получить список платежных токенов
-// @Description Возвращает список всех платежных токенов пользователя
+// @Description Возвращает страницу платежных токенов пользователя в порядке добавления. Значения токенов маскированы: видны только последние 4 символа.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Максимальное количество токенов (по умолчанию 10, не больше 100)" minimum(1) example(10)
+// @Param offset query int false "Смещение для пагинации (по умолчанию 0)" minimum(0) example(0)
 // @Success 200 {object} map[string]any "Список платежных токенов"
+// @Failure 400 {object} response.ErrorResponse "Некорректный limit или offset"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при получении токенов"
 // @Router /payments/tokens [get]
@@ -55,16 +59,27 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
-	userUID, ok := r.Context().Value(middlewarectx.UserUID).(string)
-	if !ok || userUID == "" {
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
 		log.Error("user UID not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
 		return
 	}
 
-	paymentTokens, err := h.paymentService.ListPaymentTokens(r.Context(), userUID)
+	limit, offset, err := pagination.Parse(r)
 	if err != nil {
+		log.Warn("invalid pagination parameters", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	paymentTokens, err := h.paymentService.ListPaymentTokens(r.Context(), userUID, limit, offset)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to get payment tokens", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("internal error"))
@@ -74,6 +89,6 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Info("list tokens", "count", len(paymentTokens))
 	render.JSON(w, r, response.OKWithData(map[string]any{
 		"list_count":     len(paymentTokens),
-		"payment tokens": paymentTokens,
+		"payment tokens": models.MaskPaymentTokens(paymentTokens),
 	}))
 }
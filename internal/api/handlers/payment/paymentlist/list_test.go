@@ -21,8 +21,8 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
-	args := m.Called(ctx, userUID)
+func (m *MockService) ListPaymentTokens(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error) {
+	args := m.Called(ctx, userUID, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -43,6 +43,7 @@ func TestPaymentListHandler_ServeHTTP(t *testing.T) {
 	tests := []struct {
 		name           string
 		userUID        string
+		query          string
 		setupMocks     func(*MockService)
 		expectedStatus int
 		expectedBody   string
@@ -51,20 +52,38 @@ func TestPaymentListHandler_ServeHTTP(t *testing.T) {
 			name:    "success - return payment tokens",
 			userUID: "user123",
 			setupMocks: func(ps *MockService) {
-				ps.On("ListPaymentTokens", mock.Anything, "user123").Return(paymentTokens, nil).Once()
+				ps.On("ListPaymentTokens", mock.Anything, "user123", 10, 0).Return(paymentTokens, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"status":"OK","data":{"list_count":2,"payment tokens":[{"id":1,"user_uid":"user123","token":"token1","created_at":"0001-01-01T00:00:00Z"},{"id":2,"user_uid":"user123","token":"token2","created_at":"0001-01-01T00:00:00Z"}]}}`,
+			expectedBody:   `{"status":"OK","data":{"list_count":2,"payment tokens":[{"id":1,"user_uid":"user123","token":"**ken1","created_at":"0001-01-01T00:00:00Z"},{"id":2,"user_uid":"user123","token":"**ken2","created_at":"0001-01-01T00:00:00Z"}]}}`,
 		},
 		{
 			name:    "success - empty list",
 			userUID: "user456",
 			setupMocks: func(ps *MockService) {
-				ps.On("ListPaymentTokens", mock.Anything, "user456").Return([]*models.PaymentToken{}, nil).Once()
+				ps.On("ListPaymentTokens", mock.Anything, "user456", 10, 0).Return([]*models.PaymentToken{}, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"status":"OK","data":{"list_count":0,"payment tokens":[]}}`,
 		},
+		{
+			name:    "success - custom pagination",
+			userUID: "user123",
+			query:   "?limit=1&offset=1",
+			setupMocks: func(ps *MockService) {
+				ps.On("ListPaymentTokens", mock.Anything, "user123", 1, 1).Return(paymentTokens[1:], nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"list_count":1,"payment tokens":[{"id":2,"user_uid":"user123","token":"**ken2","created_at":"0001-01-01T00:00:00Z"}]}}`,
+		},
+		{
+			name:           "invalid limit",
+			userUID:        "user123",
+			query:          "?limit=-1",
+			setupMocks:     func(*MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"limit must be a positive integer"}`,
+		},
 		{
 			name:           "missing user UID",
 			userUID:        "",
@@ -76,7 +95,7 @@ func TestPaymentListHandler_ServeHTTP(t *testing.T) {
 			name:    "service error",
 			userUID: "user789",
 			setupMocks: func(ps *MockService) {
-				ps.On("ListPaymentTokens", mock.Anything, "user789").Return(nil, assert.AnError).Once()
+				ps.On("ListPaymentTokens", mock.Anything, "user789", 10, 0).Return(nil, assert.AnError).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"internal error"}`,
@@ -90,7 +109,7 @@ func TestPaymentListHandler_ServeHTTP(t *testing.T) {
 
 			tt.setupMocks(paymentService)
 
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/list", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/list"+tt.query, nil)
 
 			ctx := context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID)
 			ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-id")
@@ -159,7 +178,7 @@ func TestPaymentListHandler_ContextHandling(t *testing.T) {
 
 			// Настраиваем мок только для успешного случая
 			if tt.expectedStatus == http.StatusOK {
-				paymentService.On("ListPaymentTokens", mock.Anything, "user123").Return([]*models.PaymentToken{}, nil).Once()
+				paymentService.On("ListPaymentTokens", mock.Anything, "user123", 10, 0).Return([]*models.PaymentToken{}, nil).Once()
 			}
 
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/list", nil)
@@ -191,7 +210,7 @@ func TestPaymentListHandler_ResponseFormat(t *testing.T) {
 	paymentService := new(MockService)
 	handler := New(newNoopLogger(), paymentService)
 
-	paymentService.On("ListPaymentTokens", mock.Anything, "user123").Return(paymentTokens, nil).Once()
+	paymentService.On("ListPaymentTokens", mock.Anything, "user123", 10, 0).Return(paymentTokens, nil).Once()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/list", nil)
 
@@ -218,3 +237,25 @@ func TestPaymentListHandler_ResponseFormat(t *testing.T) {
 
 	paymentService.AssertExpectations(t)
 }
+
+func TestPaymentListHandler_TokensMasked(t *testing.T) {
+	const fullToken = "pm_2d8c4e1a-000f-5000-9000-1b2c3d4e5f60"
+	paymentService := new(MockService)
+	handler := New(newNoopLogger(), paymentService)
+
+	paymentService.On("ListPaymentTokens", mock.Anything, "user123", 10, 0).
+		Return([]*models.PaymentToken{{ID: 1, UserUID: "user123", Token: fullToken}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/payments/list", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, "user123"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), fullToken)
+	assert.NotContains(t, w.Body.String(), fullToken[:len(fullToken)-4])
+	assert.Contains(t, w.Body.String(), `"token":"`+models.MaskToken(fullToken)+`"`)
+
+	paymentService.AssertExpectations(t)
+}
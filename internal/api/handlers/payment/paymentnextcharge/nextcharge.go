@@ -0,0 +1,83 @@
+// Package paymentnextcharge обрабатывает запрос пользователя о следующем списании за подписку на сервис.
+package paymentnextcharge
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service определяет интерфейс для расчета следующего списания.
+type Service interface {
+	GetNextCharge(ctx context.Context, userUID string) (*models.NextCharge, error)
+}
+
+// Handler обрабатывает запросы на получение следующего списания.
+type Handler struct {
+	log            *slog.Logger // Логгер для записи информации и ошибок
+	paymentService Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, ps Service) *Handler {
+	return &Handler{
+		log:            log,
+		paymentService: ps,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить следующее списание
+// @Description Возвращает сумму (в копейках), валюту и дату следующего списания за подписку на сервис по тарифу пользователя. В пробном периоде возвращается первое списание после его окончания.
+// @Tags Payments
+// @Produce  json
+// @Success 200 {object} response.OKResponse{data=models.NextCharge} "Следующее списание"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Списание не запланировано: подписка истекла или отменена"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при расчете списания"
+// @Router /me/next-charge [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.payment.nextcharge"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	charge, err := h.paymentService.GetNextCharge(r.Context(), userUID)
+	if errors.Is(err, models.ErrNoUpcomingCharge) {
+		log.Info("no upcoming charge")
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get next charge", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	response.OK(w, charge)
+}
@@ -0,0 +1,106 @@
+package paymentnextcharge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetNextCharge(ctx context.Context, userUID string) (*models.NextCharge, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NextCharge), args.Error(1)
+}
+
+func TestPaymentNextChargeHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	date := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "активная подписка",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetNextCharge", mock.Anything, "user123").
+					Return(&models.NextCharge{Amount: 49900, Currency: "RUB", Date: date, PlanCode: "premium"}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"amount":49900,"currency":"RUB","date":"2025-08-15T00:00:00Z","plan_code":"premium","trial":false}}`,
+		},
+		{
+			name:    "пробный период",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetNextCharge", mock.Anything, "user123").
+					Return(&models.NextCharge{Amount: 20000, Currency: "RUB", Date: date, PlanCode: "standard", Trial: true}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"amount":20000,"currency":"RUB","date":"2025-08-15T00:00:00Z","plan_code":"standard","trial":true}}`,
+		},
+		{
+			name:    "подписка истекла",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetNextCharge", mock.Anything, "user123").Return(nil, models.ErrNoUpcomingCharge).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"no upcoming charge"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GetNextCharge", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockService)
+			tt.setupMock(service)
+			handler := New(logger, service)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/next-charge", nil)
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			service.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package paymentreceipt обрабатывает получение квитанции об оплате по платежу пользователя.
+package paymentreceipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/receipt"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service определяет интерфейс для получения данных квитанции.
+type Service interface {
+	GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error)
+}
+
+// Handler обрабатывает запросы на получение квитанции об оплате.
+type Handler struct {
+	log            *slog.Logger // Логгер для записи информации и ошибок
+	paymentService Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, ps Service) *Handler {
+	return &Handler{
+		log:            log,
+		paymentService: ps,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить квитанцию об оплате
+// @Description Возвращает HTML-квитанцию по успешному платежу пользователя: сумма, валюта, дата, сервис и идентификатор платежа. Сумма форматируется для локали из Accept-Language или сохраненной локали пользователя.
+// @Tags Payments
+// @Produce  html
+// @Param id path int true "ID платежа"
+// @Param Accept-Language header string false "Предпочитаемый язык, например en-US"
+// @Success 200 {string} string "HTML-квитанция"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Платеж не найден"
+// @Failure 409 {object} response.ErrorResponse "Платеж не завершен успешно"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при формировании квитанции"
+// @Router /me/payments/{id}/receipt [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.payment.receipt"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	res, err := h.paymentService.GetPaymentReceipt(r.Context(), userUID, id)
+	if errors.Is(err, models.ErrPaymentNotFound) {
+		log.Warn("payment not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("payment not found"))
+		return
+	}
+	if errors.Is(err, models.ErrPaymentNotSucceeded) {
+		log.Warn("receipt requested for unsuccessful payment", slog.Int("id", id))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(models.ErrPaymentNotSucceeded.Error()))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get payment receipt", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	// Квитанция собирается в буфер, чтобы при ошибке шаблона не отдать клиенту обрезанный документ
+	var buf bytes.Buffer
+	if err := receipt.RenderHTML(&buf, res, middlewarectx.GetLocale(r.Context())); err != nil {
+		log.Error("failed to render receipt", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	log.Info("payment receipt generated", slog.Int("id", id))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=\"receipt-"+strconv.Itoa(res.ID)+".html\"")
+	_, _ = w.Write(buf.Bytes())
+}
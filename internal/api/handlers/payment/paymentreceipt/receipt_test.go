@@ -0,0 +1,156 @@
+package paymentreceipt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentReceipt), args.Error(1)
+}
+
+func TestPaymentReceiptHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	paid := &models.PaymentReceipt{
+		ID:          7,
+		PaymentID:   "2c5d6f1e-000f-5000-8000-1a2b3c4d5e6f",
+		Amount:      49900,
+		Currency:    "RUB",
+		Status:      models.PaymentStatusSucceeded,
+		ServiceName: "Spotify",
+		CreatedAt:   time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		locale         string
+		id             string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+		expectedHTML   []string
+	}{
+		{
+			name:    "квитанция по успешному платежу",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentReceipt", mock.Anything, "user123", 7).Return(paid, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedHTML:   []string{"2c5d6f1e-000f-5000-8000-1a2b3c4d5e6f", "499,00 ₽", "№ 7", "Spotify"},
+		},
+		{
+			name:    "сумма в локали запроса",
+			userUID: "user123",
+			locale:  "en-US",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentReceipt", mock.Anything, "user123", 7).Return(paid, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedHTML:   []string{"₽499.00"},
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+		{
+			name:    "платеж другого пользователя",
+			userUID: "user123",
+			id:      "8",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentReceipt", mock.Anything, "user123", 8).Return(nil, models.ErrPaymentNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"payment not found"}`,
+		},
+		{
+			name:    "платеж не завершен",
+			userUID: "user123",
+			id:      "9",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentReceipt", mock.Anything, "user123", 9).Return(nil, models.ErrPaymentNotSucceeded).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":"Error","error":"receipt is available only for succeeded payments"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("GetPaymentReceipt", mock.Anything, "user123", 7).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/payments/"+tt.id+"/receipt", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID})
+			if tt.locale != "" {
+				ctx = middlewarectx.SetLocale(ctx, tt.locale)
+			}
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			if len(tt.expectedHTML) > 0 {
+				assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+				for _, s := range tt.expectedHTML {
+					assert.Contains(t, w.Body.String(), s)
+				}
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
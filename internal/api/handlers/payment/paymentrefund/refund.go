@@ -0,0 +1,183 @@
+// Package paymentrefund обрабатывает запросы пользователя на возврат платежа.
+package paymentrefund
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
+)
+
+// Статусы возврата, которые возвращает платежный провайдер.
+const (
+	refundStatusSucceeded = "succeeded"
+	refundStatusPending   = "pending"
+)
+
+// ProviderClient определяет интерфейс для создания возврата у платежного провайдера.
+type ProviderClient interface {
+	CreateRefund(reqParams yookassa.CreateRefundRequest) (*yookassa.CreateRefundResponse, error)
+}
+
+// Service определяет интерфейс для проверки и сохранения возвратов.
+type Service interface {
+	GetRefundablePayment(ctx context.Context, userUID string, id int) (*models.Payment, error)
+	RecordRefund(ctx context.Context, providerPaymentID string, refund *models.Refund) error
+}
+
+// Handler обрабатывает запросы на возврат платежа.
+type Handler struct {
+	log            *slog.Logger   // Логгер для записи информации и ошибок
+	providerClient ProviderClient // Клиент для работы с провайдером
+	paymentService Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, providerClient ProviderClient, ps Service) *Handler {
+	return &Handler{
+		log:            log,
+		providerClient: providerClient,
+		paymentService: ps,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Вернуть платеж
+// @Description Возвращает пользователю полную сумму успешного платежа через платежного провайдера. Возврат доступен в течение настроенного срока после оплаты; оплаченный платежом месяц подписки отменяется.
+// @Tags Payments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID платежа"
+// @Success 200 {object} response.OKResponse "Возврат выполнен"
+// @Success 202 {object} response.OKResponse "Возврат принят провайдером и ожидает завершения"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Платеж не найден"
+// @Failure 409 {object} response.ErrorResponse "Платеж не успешен или уже возвращен"
+// @Failure 422 {object} response.ErrorResponse "Срок возврата истек"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при возврате"
+// @Failure 502 {object} response.ErrorResponse "Провайдер отклонил возврат"
+// @Failure 503 {object} response.ErrorResponse "Платежный провайдер временно недоступен"
+// @Router /me/payments/{id}/refund [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.payment.refund"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	payment, err := h.paymentService.GetRefundablePayment(r.Context(), userUID, id)
+	switch {
+	case errors.Is(err, models.ErrPaymentNotFound):
+		log.Warn("payment not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("payment not found"))
+		return
+	case errors.Is(err, models.ErrRefundNotAllowed), errors.Is(err, models.ErrPaymentAlreadyRefunded):
+		log.Warn("payment cannot be refunded", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrRefundWindowExpired):
+		log.Warn("refund window has expired", slog.Int("id", id))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get payment", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	refundResp, err := h.providerClient.CreateRefund(yookassa.CreateRefundRequest{
+		PaymentID: payment.PaymentID,
+		Amount: yookassa.Amount{
+			Value:    money.FormatDecimal(payment.Amount),
+			Currency: payment.Currency,
+		},
+		// Повторный запрос по тому же платежу не создает второй возврат
+		IdempotenceKey: "refund:" + payment.PaymentID,
+	})
+	if errors.Is(err, yookassa.ErrProviderUnavailable) {
+		log.Error("payment provider is unavailable", sl.Err(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		render.JSON(w, r, response.Error("payment provider unavailable"))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to create refund", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("payment provider error"))
+		return
+	}
+
+	refund := &models.Refund{
+		RefundID: refundResp.ID,
+		Amount:   payment.Amount,
+		Currency: payment.Currency,
+		Status:   refundResp.Status,
+	}
+	switch refundResp.Status {
+	case refundStatusSucceeded:
+		if err := h.paymentService.RecordRefund(r.Context(), payment.PaymentID, refund); err != nil {
+			if response.ContextError(w, log, err) {
+				return
+			}
+			log.Error("failed to record refund", slog.String("refund_id", refund.RefundID), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+	case refundStatusPending:
+		// Возврат сохранится при получении уведомления refund.succeeded
+		log.Info("refund is pending", slog.Int("id", id), slog.String("refund_id", refund.RefundID))
+		w.WriteHeader(http.StatusAccepted)
+		render.JSON(w, r, response.OKWithData(map[string]any{"refund": refund}))
+		return
+	default:
+		log.Warn("refund rejected by provider", slog.Int("id", id), slog.String("status", refundResp.Status))
+		w.WriteHeader(http.StatusBadGateway)
+		render.JSON(w, r, response.Error(models.ErrRefundRejected.Error()))
+		return
+	}
+
+	log.Info("payment refunded", slog.Int("id", id), slog.String("refund_id", refund.RefundID))
+	render.JSON(w, r, response.OKWithData(map[string]any{"refund": refund}))
+}
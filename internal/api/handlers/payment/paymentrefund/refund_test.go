@@ -0,0 +1,196 @@
+package paymentrefund
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetRefundablePayment(ctx context.Context, userUID string, id int) (*models.Payment, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockService) RecordRefund(ctx context.Context, providerPaymentID string, refund *models.Refund) error {
+	args := m.Called(ctx, providerPaymentID, refund)
+	return args.Error(0)
+}
+
+type MockProviderClient struct {
+	mock.Mock
+}
+
+func (m *MockProviderClient) CreateRefund(reqParams yookassa.CreateRefundRequest) (*yookassa.CreateRefundResponse, error) {
+	args := m.Called(reqParams)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*yookassa.CreateRefundResponse), args.Error(1)
+}
+
+func TestPaymentRefundHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	payment := &models.Payment{ID: 7, UserUID: "user123", PaymentID: "pay_1", Amount: 20000, Currency: "RUB",
+		Status: models.PaymentStatusSucceeded}
+	wantReq := yookassa.CreateRefundRequest{
+		PaymentID:      "pay_1",
+		Amount:         yookassa.Amount{Value: "200.00", Currency: "RUB"},
+		IdempotenceKey: "refund:pay_1",
+	}
+	wantRefund := &models.Refund{RefundID: "refund_1", Amount: 20000, Currency: "RUB", Status: "succeeded"}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		id             string
+		setupMocks     func(*MockService, *MockProviderClient)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "возврат в пределах срока",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, p *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(payment, nil).Once()
+				p.On("CreateRefund", wantReq).Return(&yookassa.CreateRefundResponse{ID: "refund_1", PaymentID: "pay_1", Status: "succeeded"}, nil).Once()
+				s.On("RecordRefund", mock.Anything, "pay_1", wantRefund).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"refund_id":"refund_1"`,
+		},
+		{
+			name:    "возврат ожидает завершения",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, p *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(payment, nil).Once()
+				p.On("CreateRefund", wantReq).Return(&yookassa.CreateRefundResponse{ID: "refund_1", PaymentID: "pay_1", Status: "pending"}, nil).Once()
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `"status":"pending"`,
+		},
+		{
+			name:    "срок возврата истек",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, _ *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(nil, models.ErrRefundWindowExpired).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"refund window has expired"}`,
+		},
+		{
+			name:    "платеж другого пользователя",
+			userUID: "user123",
+			id:      "8",
+			setupMocks: func(s *MockService, _ *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 8).Return(nil, models.ErrPaymentNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"payment not found"}`,
+		},
+		{
+			name:    "платеж уже возвращен",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, _ *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(nil, models.ErrPaymentAlreadyRefunded).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":"Error","error":"payment has already been refunded"}`,
+		},
+		{
+			name:    "провайдер отклонил возврат",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, p *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(payment, nil).Once()
+				p.On("CreateRefund", wantReq).Return(&yookassa.CreateRefundResponse{ID: "refund_1", Status: "canceled"}, nil).Once()
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"status":"Error","error":"refund rejected by payment provider"}`,
+		},
+		{
+			name:    "провайдер недоступен",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, p *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(payment, nil).Once()
+				p.On("CreateRefund", wantReq).Return(nil, yookassa.ErrProviderUnavailable).Once()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"status":"Error","error":"payment provider unavailable"}`,
+		},
+		{
+			name:    "ошибка сохранения возврата",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(s *MockService, p *MockProviderClient) {
+				s.On("GetRefundablePayment", mock.Anything, "user123", 7).Return(payment, nil).Once()
+				p.On("CreateRefund", wantReq).Return(&yookassa.CreateRefundResponse{ID: "refund_1", PaymentID: "pay_1", Status: "succeeded"}, nil).Once()
+				s.On("RecordRefund", mock.Anything, "pay_1", wantRefund).Return(errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMocks:     func(_ *MockService, _ *MockProviderClient) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMocks:     func(_ *MockService, _ *MockProviderClient) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockService)
+			provider := new(MockProviderClient)
+			tt.setupMocks(service, provider)
+			handler := New(logger, provider, service)
+
+			req := httptest.NewRequest(http.MethodPost, "/me/payments/"+tt.id+"/refund", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			service.AssertExpectations(t)
+			provider.AssertExpectations(t)
+		})
+	}
+}
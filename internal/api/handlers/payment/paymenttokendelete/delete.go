@@ -0,0 +1,99 @@
+// Package paymenttokendelete обрабатывает удаление сохраненного платежного токена пользователя.
+package paymenttokendelete
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service определяет интерфейс для удаления платежных токенов.
+type Service interface {
+	DeletePaymentToken(ctx context.Context, userUID string, id int) error
+}
+
+// Handler обрабатывает запросы на удаление платежного токена.
+type Handler struct {
+	log            *slog.Logger // Логгер для записи информации и ошибок
+	paymentService Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, ps Service) *Handler {
+	return &Handler{
+		log:            log,
+		paymentService: ps,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Удалить сохраненный платежный токен
+// @Description Отзывает сохраненный платежный токен (карту) пользователя. Отозванный токен больше не используется для списаний.
+// @Tags Payments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID платежного токена"
+// @Success 200 {object} response.OKResponse "Токен удален"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Токен не найден"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при удалении токена"
+// @Router /me/payment-tokens/{id} [delete]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.payment.tokendelete"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	err = h.paymentService.DeletePaymentToken(r.Context(), userUID, id)
+	if errors.Is(err, models.ErrPaymentTokenNotFound) {
+		log.Warn("payment token not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("payment token not found"))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to delete payment token", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return
+	}
+
+	log.Info("payment token deleted", slog.Int("id", id))
+	render.JSON(w, r, response.OKWithData(map[string]any{
+		"deleted_id": id,
+	}))
+}
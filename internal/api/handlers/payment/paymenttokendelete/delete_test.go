@@ -0,0 +1,111 @@
+package paymenttokendelete
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) DeletePaymentToken(ctx context.Context, userUID string, id int) error {
+	args := m.Called(ctx, userUID, id)
+	return args.Error(0)
+}
+
+func TestPaymentTokenDeleteHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		id             string
+		setupMocks     func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "удаление собственного токена",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(ps *MockService) {
+				ps.On("DeletePaymentToken", mock.Anything, "user123", 7).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"deleted_id":7}}`,
+		},
+		{
+			name:    "чужой токен",
+			userUID: "user123",
+			id:      "8",
+			setupMocks: func(ps *MockService) {
+				ps.On("DeletePaymentToken", mock.Anything, "user123", 8).
+					Return(fmt.Errorf("failed to delete token: %w", models.ErrPaymentTokenNotFound)).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"payment token not found"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMocks:     func(*MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMocks:     func(*MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			id:      "7",
+			setupMocks: func(ps *MockService) {
+				ps.On("DeletePaymentToken", mock.Anything, "user123", 7).Return(assert.AnError).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := new(MockService)
+			tt.setupMocks(ps)
+			handler := New(logger, ps)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/me/payment-tokens/"+tt.id, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			if tt.userUID != "" {
+				ctx = context.WithValue(ctx, middlewarectx.UserUID, tt.userUID)
+			}
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			ps.AssertExpectations(t)
+		})
+	}
+}
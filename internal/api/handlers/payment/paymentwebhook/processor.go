@@ -0,0 +1,147 @@
+package paymentwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+)
+
+// Service определяет интерфейс для операций с платежами.
+type Service interface {
+	SavePayment(ctx context.Context, payload *Payload) (int, error)
+	AcquireWebhook(ctx context.Context, payload *Payload) (bool, error)
+	ReleaseWebhook(ctx context.Context, payload *Payload) error
+	UpdateStatusActiveForSubscription(ctx context.Context, userUID string) error
+	ActivateTrialSubscription(ctx context.Context, userUID string) error
+	UpdateStatusCancelForSubscription(ctx context.Context, userUID string) error
+	ProcessRefund(ctx context.Context, payload *Payload) error
+}
+
+// SenderService определяет интерфейс для отправки уведомлений.
+type SenderService interface {
+	SendInfoFailurePayment(payload *Payload) error
+	SendInfoSuccessPayment(payload *Payload) error
+}
+
+// Processor обрабатывает webhook-уведомления, принятые Handler: сохраняет платежи и возвраты
+// и меняет статус подписки пользователя.
+type Processor struct {
+	log            *slog.Logger
+	paymentService Service
+	senderService  SenderService
+	timeout        time.Duration // Ограничение на обработку одного уведомления; 0 — без ограничения
+}
+
+// NewProcessor создает новый экземпляр Processor.
+func NewProcessor(log *slog.Logger, paymentService Service, senderService SenderService, timeout time.Duration) *Processor {
+	return &Processor{
+		log:            log,
+		paymentService: paymentService,
+		senderService:  senderService,
+		timeout:        timeout,
+	}
+}
+
+// HandleMessage обрабатывает уведомление из очереди rabbitmq.PaymentWebhooksQueue.
+// Неразбираемое сообщение возвращает rabbitmq.ErrPermanent и не возвращается в очередь.
+func (p *Processor) HandleMessage(body []byte) error {
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("%w: invalid webhook payload: %v", rabbitmq.ErrPermanent, err)
+	}
+
+	ctx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return p.Process(ctx, &payload)
+}
+
+// Process обрабатывает одно уведомление. Уже обработанное уведомление пропускается.
+// При ошибке отметка обработки снимается, чтобы повторная доставка обработала его снова.
+func (p *Processor) Process(ctx context.Context, payload *Payload) error {
+	const op = "paymentwebhook.Process"
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.String("event", payload.Event),
+		slog.String("payment_id", payload.Object.ID),
+	)
+
+	first, err := p.paymentService.AcquireWebhook(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !first {
+		log.Info("duplicate webhook skipped")
+		return nil
+	}
+
+	switch strings.ToLower(payload.Event) {
+	case PaymentSucceeded, PaymentCanceled, PaymentWaitingForCapture:
+		err = p.processPayment(ctx, log, payload)
+	case RefundSucceeded:
+		err = p.paymentService.ProcessRefund(ctx, payload)
+	default:
+		log.Info("ignored webhook event")
+	}
+	if err != nil {
+		if releaseErr := p.paymentService.ReleaseWebhook(ctx, payload); releaseErr != nil {
+			log.Error("failed to release webhook idempotency key", sl.Err(releaseErr))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("webhook processed successfully")
+	return nil
+}
+
+// processPayment сохраняет платеж из уведомления payment.* и обновляет подписку пользователя.
+// Ошибку возвращает только сохранение платежа: уведомления пользователю и смена статуса
+// подписки после сохранения не повторяются, их ошибки записываются в журнал.
+func (p *Processor) processPayment(ctx context.Context, log *slog.Logger, payload *Payload) error {
+	if _, err := p.paymentService.SavePayment(ctx, payload); err != nil {
+		return err
+	}
+
+	userUID := payload.Object.Metadata["user_uid"]
+	switch strings.ToLower(payload.Event) {
+	case PaymentSucceeded:
+		err := p.senderService.SendInfoSuccessPayment(payload)
+		if err != nil {
+			log.Error("failed to send info about success payment", sl.Err(err))
+		}
+		// Списание при окончании пробного периода уже могло активировать подписку в планировщике,
+		// поэтому для него используется идемпотентная активация вместо продления на месяц
+		if payload.Object.Metadata[models.PaymentMetadataPurpose] == models.PaymentPurposeTrialConversion {
+			err = p.paymentService.ActivateTrialSubscription(ctx, userUID)
+		} else {
+			err = p.paymentService.UpdateStatusActiveForSubscription(ctx, userUID)
+		}
+		if err != nil {
+			log.Error("failed to update status", sl.Err(err))
+		}
+	case PaymentCanceled:
+		err := p.senderService.SendInfoFailurePayment(payload)
+		if err != nil {
+			log.Error("failed to send info about failure payment", sl.Err(err))
+		}
+		err = p.paymentService.UpdateStatusCancelForSubscription(ctx, userUID)
+		if err != nil {
+			log.Error("failed to update status", sl.Err(err))
+		}
+	case PaymentWaitingForCapture:
+		// Деньги только заблокированы: подписка меняется после payment.succeeded или payment.canceled
+		log.Info("payment is waiting for capture")
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package paymentwebhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) SavePayment(ctx context.Context, payload *Payload) (int, error) {
+	args := m.Called(ctx, payload)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) AcquireWebhook(ctx context.Context, payload *Payload) (bool, error) {
+	args := m.Called(ctx, payload)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockService) ReleaseWebhook(ctx context.Context, payload *Payload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockService) UpdateStatusActiveForSubscription(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
+func (m *MockService) ActivateTrialSubscription(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
+func (m *MockService) UpdateStatusCancelForSubscription(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
+func (m *MockService) ProcessRefund(ctx context.Context, payload *Payload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+type MockSender struct {
+	mock.Mock
+}
+
+func (m *MockSender) SendInfoFailurePayment(payload *Payload) error {
+	args := m.Called(payload)
+	return args.Error(0)
+}
+
+func (m *MockSender) SendInfoSuccessPayment(payload *Payload) error {
+	args := m.Called(payload)
+	return args.Error(0)
+}
+
+func newNoopLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestProcessor_HandleMessage_Events(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		setupMocks func(*MockService, *MockSender)
+		wantErr    bool
+	}{
+		{
+			name: "payment.succeeded продлевает подписку",
+			body: `{"event":"payment.succeeded","object":{"id":"pay_1","status":"succeeded","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123"}}}`,
+			setupMocks: func(s *MockService, n *MockSender) {
+				s.On("SavePayment", mock.Anything, mock.Anything).Return(1, nil).Once()
+				n.On("SendInfoSuccessPayment", mock.Anything).Return(nil).Once()
+				s.On("UpdateStatusActiveForSubscription", mock.Anything, "user123").Return(nil).Once()
+			},
+		},
+		{
+			name: "payment.succeeded после пробного периода активирует подписку идемпотентно",
+			body: `{"event":"payment.succeeded","object":{"id":"pay_2","status":"succeeded","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123","purpose":"` + models.PaymentPurposeTrialConversion + `"}}}`,
+			setupMocks: func(s *MockService, n *MockSender) {
+				s.On("SavePayment", mock.Anything, mock.Anything).Return(1, nil).Once()
+				n.On("SendInfoSuccessPayment", mock.Anything).Return(nil).Once()
+				s.On("ActivateTrialSubscription", mock.Anything, "user123").Return(nil).Once()
+			},
+		},
+		{
+			name: "payment.canceled отменяет подписку",
+			body: `{"event":"payment.canceled","object":{"id":"pay_3","status":"canceled","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123"}}}`,
+			setupMocks: func(s *MockService, n *MockSender) {
+				s.On("SavePayment", mock.Anything, mock.Anything).Return(1, nil).Once()
+				n.On("SendInfoFailurePayment", mock.Anything).Return(nil).Once()
+				s.On("UpdateStatusCancelForSubscription", mock.Anything, "user123").Return(nil).Once()
+			},
+		},
+		{
+			name: "payment.waiting_for_capture только сохраняет платеж",
+			body: `{"event":"payment.waiting_for_capture","object":{"id":"pay_4","status":"waiting_for_capture","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123"}}}`,
+			setupMocks: func(s *MockService, _ *MockSender) {
+				s.On("SavePayment", mock.Anything, mock.Anything).Return(1, nil).Once()
+			},
+		},
+		{
+			name: "refund.succeeded сохраняет возврат",
+			body: `{"event":"refund.succeeded","object":{"id":"refund_1","payment_id":"pay_1","status":"succeeded","amount":{"value":"200.00","currency":"RUB"}}}`,
+			setupMocks: func(s *MockService, _ *MockSender) {
+				s.On("ProcessRefund", mock.Anything, mock.MatchedBy(func(p *Payload) bool {
+					return p.Object.ID == "refund_1" && p.Object.PaymentID == "pay_1"
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "ошибка обработки возврата снимает отметку идемпотентности",
+			body: `{"event":"refund.succeeded","object":{"id":"refund_2","payment_id":"pay_1","status":"succeeded","amount":{"value":"200.00","currency":"RUB"}}}`,
+			setupMocks: func(s *MockService, _ *MockSender) {
+				s.On("ProcessRefund", mock.Anything, mock.Anything).Return(models.ErrPaymentNotFound).Once()
+				s.On("ReleaseWebhook", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			wantErr: true,
+		},
+		{
+			name: "ошибка сохранения платежа снимает отметку идемпотентности",
+			body: `{"event":"payment.succeeded","object":{"id":"pay_5","status":"succeeded","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123"}}}`,
+			setupMocks: func(s *MockService, _ *MockSender) {
+				s.On("SavePayment", mock.Anything, mock.Anything).Return(0, errors.New("db error")).Once()
+				s.On("ReleaseWebhook", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			wantErr: true,
+		},
+		{
+			name:       "неизвестное событие игнорируется",
+			body:       `{"event":"payout.succeeded","object":{"id":"po_1","status":"succeeded"}}`,
+			setupMocks: func(_ *MockService, _ *MockSender) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockService)
+			sender := new(MockSender)
+			service.On("AcquireWebhook", mock.Anything, mock.Anything).Return(true, nil).Once()
+			tt.setupMocks(service, sender)
+			processor := NewProcessor(newNoopLogger(), service, sender, time.Second)
+
+			err := processor.HandleMessage([]byte(tt.body))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			service.AssertExpectations(t)
+			sender.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProcessor_HandleMessage_Skipped(t *testing.T) {
+	t.Run("повторное уведомление", func(t *testing.T) {
+		service := new(MockService)
+		service.On("AcquireWebhook", mock.Anything, mock.Anything).Return(false, nil).Once()
+		processor := NewProcessor(newNoopLogger(), service, new(MockSender), time.Second)
+
+		err := processor.HandleMessage([]byte(`{"event":"refund.succeeded","object":{"id":"refund_1","payment_id":"pay_1"}}`))
+
+		assert.NoError(t, err)
+		service.AssertExpectations(t)
+		service.AssertNotCalled(t, "ProcessRefund", mock.Anything, mock.Anything)
+	})
+
+	t.Run("неразбираемое сообщение не возвращается в очередь", func(t *testing.T) {
+		service := new(MockService)
+		processor := NewProcessor(newNoopLogger(), service, new(MockSender), time.Second)
+
+		err := processor.HandleMessage([]byte("not a json"))
+
+		assert.ErrorIs(t, err, rabbitmq.ErrPermanent)
+		service.AssertNotCalled(t, "AcquireWebhook", mock.Anything, mock.Anything)
+	})
+}
@@ -3,64 +3,68 @@ package paymentwebhook
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
 )
 
-// Service определяет интерфейс для операций с платежами.
-type Service interface {
-	SavePayment(ctx context.Context, payload *Payload) (int, error)
-	UpdateStatusActiveForSubscription(ctx context.Context, userUID string) error
-	UpdateStatusCancelForSubscription(ctx context.Context, userUID string) error
+// Queue сохраняет принятые webhook-уведомления для асинхронной обработки.
+type Queue interface {
+	// EnqueueWebhook сохраняет уведомление и возвращает false, если оно уже было принято.
+	EnqueueWebhook(ctx context.Context, payload *Payload, body []byte) (bool, error)
 }
 
-// SenderService определяет интерфейс для отправки уведомлений.
-type SenderService interface {
-	SendInfoFailurePayment(payload *Payload) error
-	SendInfoSuccessPayment(payload *Payload) error
-}
-
-// Handler обрабатывает webhook-запросы от платежных провайдеров.
+// Handler принимает webhook-запросы от платежных провайдеров. Обработка уведомления
+// может занять долго, а провайдер повторяет запросы без быстрого ответа, поэтому
+// Handler только проверяет подпись, сохраняет уведомление в очередь и сразу отвечает 200.
+// Платеж и подписку затем обновляет Processor.
 type Handler struct {
-	log            *slog.Logger // Логгер для записи информации и ошибок
-	paymentService Service
-	senderService  SenderService
-	webhookSecret  string // Секрет для проверки подписи
+	log      *slog.Logger         // Логгер для записи информации и ошибок
+	queue    Queue                // Очередь уведомлений на обработку
+	verifier *webhooksig.Verifier // Проверка подписи и метки времени уведомления
+	timeout  time.Duration        // Сколько ждать сохранения уведомления до ответа провайдеру
 }
 
-// New создает новый экземпляр Handler.
-func New(log *slog.Logger, paymentService Service, senderService SenderService, secret string) *Handler {
+// New создает новый экземпляр Handler. verifier проверяет подпись из заголовка webhooksig.Header,
+// поэтому устаревшее повторно отправленное уведомление отклоняется. Положительный timeout
+// ограничивает сохранение уведомления в очередь: если его не удалось сохранить вовремя,
+// провайдер получает 500 и повторит запрос.
+func New(log *slog.Logger, queue Queue, verifier *webhooksig.Verifier, timeout time.Duration) *Handler {
 	return &Handler{
-		log:            log,
-		paymentService: paymentService,
-		senderService:  senderService,
-		webhookSecret:  secret,
+		log:      log,
+		queue:    queue,
+		verifier: verifier,
+		timeout:  timeout,
 	}
 }
 
 const (
 	// PaymentSucceeded статус успешного платежа.
 	PaymentSucceeded = "payment.succeeded"
-	// PaymentCanceled статус успешного платежа.
+	// PaymentCanceled статус отмененного платежа.
 	PaymentCanceled = "payment.canceled"
+	// PaymentWaitingForCapture статус платежа, ожидающего подтверждения списания.
+	PaymentWaitingForCapture = "payment.waiting_for_capture"
+	// RefundSucceeded статус успешного возврата платежа.
+	RefundSucceeded = "refund.succeeded"
 )
 
 // Payload представляет структуру данных webhook-запроса от платежного провайдера.
+// Для событий refund.* объект описывает возврат: ID — идентификатор возврата,
+// PaymentID — идентификатор возвращенного платежа.
 type Payload struct {
 	Event  string `json:"event"`
 	Object struct {
-		ID     string `json:"id"`     // payment ID
-		Status string `json:"status"` // статус платежа
-		Amount struct {
+		ID        string `json:"id"`                   // payment ID или refund ID
+		PaymentID string `json:"payment_id,omitempty"` // ID возвращенного платежа для refund.*
+		Status    string `json:"status"`               // статус платежа или возврата
+		Amount    struct {
 			Value    string `json:"value"`    // сумма в строке, например "100.00"
 			Currency string `json:"currency"` // валюта
 		} `json:"amount"`
@@ -71,25 +75,18 @@ type Payload struct {
 	} `json:"object"`
 }
 
-func (h *Handler) verifySignature(secret string, body []byte, signature string) bool {
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	expectedSig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	return hmac.Equal([]byte(expectedSig), []byte(signature))
-}
-
 // ServeHTTP godoc
 // @Summary Webhook для обработки уведомлений от YooKassa
-// @Description Обрабатывает уведомления о статусе платежей от платежного провайдера YooKassa
+// @Description Принимает уведомления payment.succeeded, payment.canceled, payment.waiting_for_capture и refund.succeeded от платежного провайдера YooKassa и ставит их в очередь обработки. Ответ 200 означает, что уведомление сохранено; платеж и подписка обновляются асинхронно. Остальные события игнорируются при обработке.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
-// @Param X-Api-Signature header string true "Подпись webhook для проверки подлинности"
+// @Param X-Webhook-Signature header string true "Подпись webhook в формате t=<unix-время>,hmac-sha256=<hex>"
 // @Param payload body Payload true "Данные уведомления от YooKassa"
-// @Success 200 "Webhook обработан успешно"
+// @Success 200 "Webhook принят в обработку"
 // @Failure 400 "Некорректные данные webhook"
 // @Failure 401 "Неверная подпись webhook"
-// @Failure 500 "Ошибка сервера при обработке webhook"
+// @Failure 500 "Не удалось сохранить webhook"
 // @Router /payments/webhook [post]
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.payment.webhook"
@@ -111,9 +108,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	signature := r.Header.Get("X-Api-Signature")
-	if signature == "" || !h.verifySignature(h.webhookSecret, body, signature) {
-		log.Error("invalid or missing webhook signature")
+	if err := h.verifier.Verify(r.Header.Get(webhooksig.Header), body); err != nil {
+		log.Error("invalid or missing webhook signature", sl.Err(err))
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -124,35 +120,25 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	if _, err := h.paymentService.SavePayment(r.Context(), &payload); err != nil {
-		log.Error("failed to process success payment", sl.Err(err))
+
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	queued, err := h.queue.EnqueueWebhook(ctx, &payload, body)
+	if err != nil {
+		log.Error("failed to enqueue webhook", slog.String("event", payload.Event), sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-
-	switch strings.ToLower(payload.Event) {
-	case PaymentSucceeded:
-		err := h.senderService.SendInfoSuccessPayment(&payload)
-		if err != nil {
-			log.Error("failed to send info about success payment", sl.Err(err))
-		}
-		err = h.paymentService.UpdateStatusActiveForSubscription(r.Context(), payload.Object.Metadata["user_uid"])
-		if err != nil {
-			log.Error("failed to update status", sl.Err(err))
-		}
-	case PaymentCanceled:
-		err := h.senderService.SendInfoFailurePayment(&payload)
-		if err != nil {
-			log.Error("failed to send info about failure payment", sl.Err(err))
-		}
-		err = h.paymentService.UpdateStatusCancelForSubscription(r.Context(), payload.Object.Metadata["user_uid"])
-		if err != nil {
-			log.Error("failed to update status", sl.Err(err))
-		}
-	default:
-		log.Info("ignored webhook event", slog.String("event", payload.Event))
+	if !queued {
+		log.Info("duplicate webhook skipped", slog.String("event", payload.Event), slog.String("payment_id", payload.Object.ID))
+		w.WriteHeader(http.StatusOK)
+		return
 	}
 
-	log.Info("webhook processed successfully", slog.String("event", payload.Event), slog.String("payment_id", payload.Object.ID))
+	log.Info("webhook accepted", slog.String("event", payload.Event), slog.String("payment_id", payload.Object.ID))
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,179 @@
+package paymentwebhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
+)
+
+const testSecret = "webhook_secret"
+
+type MockQueue struct {
+	mock.Mock
+}
+
+func (m *MockQueue) EnqueueWebhook(ctx context.Context, payload *Payload, body []byte) (bool, error) {
+	args := m.Called(ctx, payload, body)
+	return args.Bool(0), args.Error(1)
+}
+
+func sign(t *testing.T, body []byte) string {
+	t.Helper()
+	signer, err := webhooksig.NewSigner(webhooksig.HMACSHA256, testSecret, nil)
+	require.NoError(t, err)
+	return signer.Sign(body)
+}
+
+func newTestHandler(t *testing.T, queue Queue) *Handler {
+	t.Helper()
+	verifier, err := webhooksig.NewVerifier(webhooksig.HMACSHA256, testSecret, 0, nil)
+	require.NoError(t, err)
+	return New(newNoopLogger(), queue, verifier, time.Second)
+}
+
+func TestHandler_ServeHTTP_Accepted(t *testing.T) {
+	body := []byte(`{"event":"payment.succeeded","object":{"id":"pay_1","status":"succeeded","metadata":{"user_uid":"user123"}}}`)
+
+	tests := []struct {
+		name           string
+		queued         bool
+		enqueueErr     error
+		expectedStatus int
+	}{
+		{name: "уведомление сохранено в очередь", queued: true, expectedStatus: http.StatusOK},
+		{name: "повторное уведомление", queued: false, expectedStatus: http.StatusOK},
+		{name: "ошибка сохранения — провайдер повторит запрос", enqueueErr: errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := new(MockQueue)
+			queue.On("EnqueueWebhook", mock.Anything, mock.MatchedBy(func(p *Payload) bool {
+				return p.Event == PaymentSucceeded && p.Object.ID == "pay_1"
+			}), body).Return(tt.queued, tt.enqueueErr).Once()
+			handler := newTestHandler(t, queue)
+
+			req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+			req.Header.Set(webhooksig.Header, sign(t, body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			queue.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Rejected(t *testing.T) {
+	body := []byte(`{"event":"refund.succeeded","object":{"id":"refund_1","payment_id":"pay_1"}}`)
+
+	t.Run("неверная подпись", func(t *testing.T) {
+		queue := new(MockQueue)
+		handler := newTestHandler(t, queue)
+
+		req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+		req.Header.Set(webhooksig.Header, "invalid")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		queue.AssertNotCalled(t, "EnqueueWebhook", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("устаревшая подпись", func(t *testing.T) {
+		queue := new(MockQueue)
+		handler := newTestHandler(t, queue)
+		// Повторно отправленное старое уведомление подписано давно
+		signer, err := webhooksig.NewSigner(webhooksig.HMACSHA256, testSecret, clock.NewFake(time.Now().Add(-time.Hour)))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+		req.Header.Set(webhooksig.Header, signer.Sign(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		queue.AssertNotCalled(t, "EnqueueWebhook", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("некорректный JSON", func(t *testing.T) {
+		queue := new(MockQueue)
+		handler := newTestHandler(t, queue)
+		invalid := []byte("not a json")
+
+		req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(invalid))
+		req.Header.Set(webhooksig.Header, sign(t, invalid))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		queue.AssertNotCalled(t, "EnqueueWebhook", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// chanQueue передает принятые уведомления обработчику через канал, как очередь RabbitMQ.
+type chanQueue struct {
+	messages chan []byte
+}
+
+func (q *chanQueue) EnqueueWebhook(_ context.Context, _ *Payload, body []byte) (bool, error) {
+	q.messages <- body
+	return true, nil
+}
+
+func TestHandler_ServeHTTP_ProcessesAsynchronously(t *testing.T) {
+	body := []byte(`{"event":"payment.succeeded","object":{"id":"pay_1","status":"succeeded","amount":{"value":"200.00","currency":"RUB"},"metadata":{"user_uid":"user123"}}}`)
+
+	service := new(MockService)
+	sender := new(MockSender)
+	var mu sync.Mutex
+	activated := false
+	service.On("AcquireWebhook", mock.Anything, mock.Anything).Return(true, nil).Once()
+	service.On("SavePayment", mock.Anything, mock.Anything).Return(1, nil).Once()
+	sender.On("SendInfoSuccessPayment", mock.Anything).Return(nil).Once()
+	service.On("UpdateStatusActiveForSubscription", mock.Anything, "user123").Run(func(mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		activated = true
+	}).Return(nil).Once()
+
+	queue := &chanQueue{messages: make(chan []byte, 1)}
+	processor := NewProcessor(newNoopLogger(), service, sender, time.Second)
+	release := make(chan struct{})
+	go func() {
+		msg := <-queue.messages
+		<-release // обработка начинается только после ответа провайдеру
+		_ = processor.HandleMessage(msg)
+	}()
+
+	handler := newTestHandler(t, queue)
+	req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+	req.Header.Set(webhooksig.Header, sign(t, body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	service.AssertNotCalled(t, "SavePayment", mock.Anything, mock.Anything)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return activated
+	}, time.Second, 10*time.Millisecond, "subscription is activated after the webhook is processed")
+	service.AssertExpectations(t)
+	sender.AssertExpectations(t)
+}
@@ -0,0 +1,79 @@
+// Package attention реализует HTTP-обработчик для получения подписок, требующих внимания.
+//
+// Handler возвращает активные подписки текущего пользователя в порядке ближайшего
+// следующего платежа и отмечает те, платеж по которым может не пройти.
+package attention
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение подписок, требующих внимания.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики подписок
+}
+
+// Service описывает интерфейс бизнес-логики подписок, требующих внимания.
+type Service interface {
+	ListAttention(ctx context.Context, userUID string) ([]*models.AttentionEntry, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить подписки, требующие внимания
+// @Description Возвращает активные подписки текущего пользователя в порядке ближайшей даты следующего платежа. missing_payment_token отмечает, что у пользователя нет действующей сохраненной карты, last_payment_failed — что последний платеж по подписке отклонен.
+// @Tags Subscriptions
+// @Produce  json
+// @Success 200 {object} response.OKResponse{data=[]models.AttentionEntry} "Подписки, требующие внимания"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при получении подписок"
+// @Router /me/attention [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.attention"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	entries, err := h.service.ListAttention(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to list subscriptions needing attention", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get subscriptions"))
+		return
+	}
+
+	log.Info("subscriptions needing attention listed", slog.Int("count", len(entries)))
+	response.OK(w, entries)
+}
@@ -0,0 +1,105 @@
+package attention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс attention.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ListAttention(ctx context.Context, userUID string) ([]*models.AttentionEntry, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.AttentionEntry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestAttentionHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	entries := []*models.AttentionEntry{
+		{ID: 2, ServiceName: "Spotify", Price: 300, Currency: "RUB",
+			NextPaymentDate: time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC), MissingPaymentToken: true},
+		{ID: 1, ServiceName: "Netflix", Price: 500, Currency: "RUB",
+			NextPaymentDate: time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "подписки в порядке ближайшего платежа",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ListAttention", mock.Anything, "user123").Return(entries, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":[
+				{"id":2,"service_name":"Spotify","price":300,"currency":"RUB","next_payment_date":"2025-06-16T00:00:00Z",
+				 "missing_payment_token":true,"last_payment_failed":false},
+				{"id":1,"service_name":"Netflix","price":500,"currency":"RUB","next_payment_date":"2025-06-20T00:00:00Z",
+				 "missing_payment_token":false,"last_payment_failed":false}]}`,
+		},
+		{
+			name:    "нет активных подписок",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ListAttention", mock.Anything, "user123").Return([]*models.AttentionEntry{}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":[]}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ListAttention", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to get subscriptions"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/attention", nil)
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
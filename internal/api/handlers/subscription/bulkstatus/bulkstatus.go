@@ -0,0 +1,125 @@
+// Package bulkstatus реализует HTTP-обработчик массовой смены статуса подписок пользователя.
+//
+// Handler приостанавливает, возобновляет или отменяет сразу несколько подписок, выбранных
+// по списку ID или по названию сервиса. Изменения выполняются в одной транзакции только
+// для подписок текущего пользователя; результат возвращается по каждой подписке.
+package bulkstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на массовую смену статуса подписок.
+type Handler struct {
+	log      *slog.Logger        // Логгер для записи информации и ошибок
+	service  Service             // Сервис бизнес-логики подписок
+	validate *validator.Validate // Валидатор структуры входящих данных
+}
+
+// Service описывает интерфейс бизнес-логики массовой смены статуса подписок.
+type Service interface {
+	BulkUpdateStatus(ctx context.Context, userUID string, req models.BulkStatusRequest) ([]models.BulkStatusResult, error)
+}
+
+// New создает новый Handler с переданными логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:      log,
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Массово изменить статус подписок
+// @Description Приостанавливает (paused), возобновляет (active) или отменяет (canceled) подписки пользователя, выбранные по списку ids (не более 100) или по названию сервиса service_name. Изменения выполняются в одной транзакции; чужие и несуществующие ID возвращаются с ошибкой.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param request body models.BulkStatusRequest true "Подписки и целевой статус"
+// @Success 200 {object} response.OKResponse "Результат по каждой подписке"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации"
+// @Failure 409 {object} response.ErrorResponse "Активация превысила бы число активных подписок на сервис"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при изменении статуса"
+// @Router /subscriptions/bulk-status [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.bulkstatus"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	user := middlewarectx.GetUser(r.Context())
+	if user.UID == "" {
+		log.Error("user not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	var req models.BulkStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
+		return
+	}
+
+	results, err := h.service.BulkUpdateStatus(r.Context(), user.UID, req)
+	switch {
+	case errors.Is(err, models.ErrInvalidBulkRequest):
+		log.Error("invalid bulk request", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrActiveLimitExceeded):
+		log.Info("active subscriptions limit exceeded", sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to update subscriptions status", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not update subscriptions status"))
+		return
+	}
+
+	updated := 0
+	for _, res := range results {
+		if res.Error == "" {
+			updated++
+		}
+	}
+	log.Info("subscriptions status updated", slog.String("status", req.Status), slog.Int("updated", updated))
+	response.OK(w, map[string]any{
+		"results": results,
+		"updated": updated,
+		"failed":  len(results) - updated,
+	})
+}
@@ -0,0 +1,138 @@
+package bulkstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс bulkstatus.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) BulkUpdateStatus(ctx context.Context, userUID string, req models.BulkStatusRequest) ([]models.BulkStatusResult, error) {
+	args := m.Called(ctx, userUID, req)
+	if res := args.Get(0); res != nil {
+		return res.([]models.BulkStatusResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestBulkStatusHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		body           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "пауза по списку ID",
+			userUID: "user123",
+			body:    `{"ids":[1,2,3],"status":"paused"}`,
+			setupMock: func(m *MockService) {
+				m.On("BulkUpdateStatus", mock.Anything, "user123",
+					models.BulkStatusRequest{IDs: []int{1, 2, 3}, Status: "paused"}).Return([]models.BulkStatusResult{
+					{ID: 1, Status: "paused"},
+					{ID: 2, Error: "subscription not found"},
+					{ID: 3, Status: "paused"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"updated":2,"failed":1,"results":[{"id":1,"status":"paused"},` +
+				`{"id":2,"error":"subscription not found"},{"id":3,"status":"paused"}]}}`,
+		},
+		{
+			name:    "пауза по названию сервиса",
+			userUID: "user123",
+			body:    `{"service_name":"Netflix","status":"paused"}`,
+			setupMock: func(m *MockService) {
+				m.On("BulkUpdateStatus", mock.Anything, "user123",
+					models.BulkStatusRequest{ServiceName: "Netflix", Status: "paused"}).Return([]models.BulkStatusResult{
+					{ID: 4, Status: "paused"},
+					{ID: 5, Status: "paused"},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"updated":2,"failed":0,"results":[{"id":4,"status":"paused"},` +
+				`{"id":5,"status":"paused"}]}}`,
+		},
+		{
+			name:           "неизвестный статус",
+			userUID:        "user123",
+			body:           `{"ids":[1],"status":"deleted"}`,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"field Status is not a valid"}`,
+		},
+		{
+			name:    "не указан способ выбора подписок",
+			userUID: "user123",
+			body:    `{"status":"canceled"}`,
+			setupMock: func(m *MockService) {
+				m.On("BulkUpdateStatus", mock.Anything, "user123", models.BulkStatusRequest{Status: "canceled"}).
+					Return(nil, models.ErrInvalidBulkRequest).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"exactly one of ids or service_name must be set"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			body:    `{"ids":[1],"status":"active"}`,
+			setupMock: func(m *MockService) {
+				m.On("BulkUpdateStatus", mock.Anything, "user123", models.BulkStatusRequest{IDs: []int{1}, Status: "active"}).
+					Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not update subscriptions status"}`,
+		},
+		{
+			name:           "некорректный JSON",
+			userUID:        "user123",
+			body:           `not a json`,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			body:           `{"ids":[1],"status":"paused"}`,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/bulk-status", bytes.NewReader([]byte(tt.body)))
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
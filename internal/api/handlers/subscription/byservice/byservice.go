@@ -0,0 +1,97 @@
+// Package byservice реализует HTTP-обработчик для получения подписок пользователя по названию сервиса.
+//
+// Handler извлекает название сервиса из URL-параметров и возвращает все подписки
+// текущего пользователя на этот сервис списком: их может быть несколько или ни одной.
+package byservice
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение подписок по названию сервиса.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики поиска подписок
+}
+
+// Service описывает интерфейс бизнес-логики поиска подписок по названию сервиса.
+type Service interface {
+	FindByServiceName(ctx context.Context, userUID, service string) ([]*models.Entry, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить подписки по названию сервиса
+// @Description Возвращает подписки текущего пользователя на сервис с указанным названием (без учета регистра).
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param name path string true "Название сервиса"
+// @Success 200 {object} response.OKResponse "Список подписок (может быть пустым)"
+// @Failure 400 {object} response.ErrorResponse "Некорректное название сервиса"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при поиске подписок"
+// @Router /subscriptions/by-service/{name} [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.byservice"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	name = strings.TrimSpace(name)
+	if err != nil || name == "" {
+		log.Error("invalid service name in url", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid service name"))
+		return
+	}
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	res, err := h.service.FindByServiceName(r.Context(), userUID, name)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to find subscriptions by service", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to find subscriptions"))
+		return
+	}
+
+	log.Info("found subscriptions by service", slog.String("service", name), slog.Int("count", len(res)))
+	response.OK(w, map[string]any{
+		"list_count": len(res),
+		"entries":    res,
+	})
+}
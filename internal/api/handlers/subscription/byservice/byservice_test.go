@@ -0,0 +1,134 @@
+package byservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс byservice.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) FindByServiceName(ctx context.Context, userUID, service string) ([]*models.Entry, error) {
+	args := m.Called(ctx, userUID, service)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestByServiceHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		param          string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedCount  int
+		expectedBody   string
+	}{
+		{
+			name:    "одна подписка",
+			param:   "Netflix",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("FindByServiceName", mock.Anything, "user123", "Netflix").
+					Return([]*models.Entry{{ID: 1, ServiceName: "Netflix"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:    "несколько подписок, название с пробелом",
+			param:   "Yandex%20Plus",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("FindByServiceName", mock.Anything, "user123", "Yandex Plus").
+					Return([]*models.Entry{{ID: 1, ServiceName: "Yandex Plus"}, {ID: 5, ServiceName: "Yandex Plus"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:    "нет подписок",
+			param:   "Unknown",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("FindByServiceName", mock.Anything, "user123", "Unknown").
+					Return([]*models.Entry{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+		},
+		{
+			name:           "пользователь не авторизован",
+			param:          "Netflix",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			param:   "Netflix",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("FindByServiceName", mock.Anything, "user123", "Netflix").
+					Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to find subscriptions"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions/by-service/"+tt.param, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", tt.param)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			} else {
+				var body struct {
+					Data struct {
+						ListCount int            `json:"list_count"`
+						Entries   []models.Entry `json:"entries"`
+					} `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.expectedCount, body.Data.ListCount)
+				assert.Len(t, body.Data.Entries, tt.expectedCount)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package cancelsavings обрабатывает расчет экономии от отмены подписки пользователя.
+package cancelsavings
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service описывает бизнес-логику расчета экономии от отмены подписки.
+type Service interface {
+	CancelSavings(ctx context.Context, userUID string, id int) (*models.CancelSavings, error)
+}
+
+// Handler обрабатывает запросы на расчет экономии от отмены подписки.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Экономия от отмены подписки
+// @Description Возвращает, сколько пользователь сэкономит за следующие 12 месяцев, если отменит подписку сегодня: количество и сумму ежемесячных списаний по текущей цене до окончания срока подписки. Для приостановленной или закончившейся подписки экономия нулевая. Чужая подписка возвращается как несуществующая (404).
+// @Tags Subscriptions
+// @Produce  json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} response.OKResponse{data=models.CancelSavings} "Расчет экономии"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscriptions/{id}/cancel-savings [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.cancelsavings"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	res, err := h.service.CancelSavings(r.Context(), userUID, id)
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		log.Info("subscription not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to calculate cancel savings", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not calculate savings"))
+		return
+	}
+
+	log.Info("cancel savings calculated", slog.Int("id", id), slog.Int("savings", res.Savings))
+	response.OK(w, res)
+}
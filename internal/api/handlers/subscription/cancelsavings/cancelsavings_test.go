@@ -0,0 +1,114 @@
+package cancelsavings
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) CancelSavings(ctx context.Context, userUID string, id int) (*models.CancelSavings, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CancelSavings), args.Error(1)
+}
+
+func TestCancelSavingsHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		id             string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "экономия по активной подписке",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("CancelSavings", mock.Anything, "user123", 7).Return(&models.CancelSavings{
+					ID: 7, ServiceName: "Netflix", Price: 799, Currency: "RUB",
+					From: "2025-06-15", To: "2026-06-15", Months: 12, Savings: 9588,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"id":7,"service_name":"Netflix","price":799,"currency":"RUB",` +
+				`"from":"2025-06-15","to":"2026-06-15","months":12,"savings":9588}}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+		{
+			name:    "подписка другого пользователя",
+			userUID: "user123",
+			id:      "8",
+			setupMock: func(m *MockService) {
+				m.On("CancelSavings", mock.Anything, "user123", 8).Return(nil, models.ErrSubscriptionNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"subscription not found"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("CancelSavings", mock.Anything, "user123", 7).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not calculate savings"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockService)
+			tt.setupMock(service)
+			handler := New(logger, service)
+
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions/"+tt.id+"/cancel-savings", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			service.AssertExpectations(t)
+		})
+	}
+}
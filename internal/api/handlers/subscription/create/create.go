@@ -9,6 +9,7 @@ package create
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -49,15 +50,16 @@ func New(log *slog.Logger, service Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Создать новую подписку
-// @Description Создает новую подписку для текущего пользователя. Возвращает ID созданной записи.
+// @Description Создает новую подписку для текущего пользователя. Возвращает ID созданной записи. Поля notes и metadata необязательны.
 // @Tags Subscriptions
 // @Accept  json
 // @Produce  json
 // @Param request body models.DummyEntry true "Данные новой подписки"
-// @Success 200 {object} map[string]any "Успешное создание подписки"
+// @Success 201 {object} response.OKResponse{data=response.CreatedData} "Успешное создание подписки"
 // @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
-// @Failure 422 {object} response.ErrorResponse "Ошибка валидации"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации, некорректное название сервиса, дата начала в недопустимом формате, срок подписки уже закончился, заметка длиннее 500 символов, метаданные не JSON-объект до 4 КБ или цена, срок или валюта вне допустимых ограничений"
+// @Failure 409 {object} response.ErrorResponse "Превышено число активных подписок на сервис"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при создании подписки"
 // @Router /subscriptions [post]
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +76,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Error("invalid request body"))
 		return
 	}
-	log.Info("request body decoded", slog.Any("request", req))
+	log.Info("request body decoded", sl.Redact("request", req))
 
 	if err := h.validate.Struct(req); err != nil {
 		log.Error("validation failed", sl.Err(err))
@@ -82,18 +84,28 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
 		return
 	}
+
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		log.Error("invalid service name", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	req.ServiceName = serviceName
 	log.Info("all fields are validated")
 
-	username, ok := r.Context().Value(middlewarectx.User).(string)
-	if !ok || username == "" {
+	user := middlewarectx.GetUser(r.Context())
+	username := user.Username
+	if username == "" {
 		log.Error("username not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
 		return
 	}
 
-	userUID, ok := r.Context().Value(middlewarectx.UserUID).(string)
-	if !ok || userUID == "" {
+	userUID := user.UID
+	if userUID == "" {
 		log.Error("username not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
@@ -101,7 +113,34 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, err := h.service.CreateEntry(r.Context(), username, userUID, req)
+	if errors.Is(err, models.ErrInvalidStartDate) || errors.Is(err, models.ErrEndDateInPast) {
+		log.Error("invalid start date", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrInvalidNotes) || errors.Is(err, models.ErrInvalidMetadata) {
+		log.Error("invalid notes or metadata", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrEntryOutOfLimits) {
+		log.Error("subscription is out of limits", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrActiveLimitExceeded) {
+		log.Info("active subscriptions limit exceeded", sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to create subscription", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("could not create subscription"))
@@ -109,7 +148,5 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info("succes to create subscriptions", slog.Any("id", id))
-	render.JSON(w, r, response.OKWithData(map[string]any{
-		"last_added_id": id,
-	}))
+	response.Created(w, id)
 }
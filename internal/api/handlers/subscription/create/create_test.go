@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/middleware"
@@ -54,8 +56,8 @@ func TestCreateHandler(t *testing.T) {
 				m.On("CreateEntry", mock.Anything, "testuser", "user123", mock.AnythingOfType("models.DummyEntry")).
 					Return(123, nil)
 			},
-			expectedStatus: http.StatusOK,
-			expectedBody:   `{"status":"OK","data":{"last_added_id":123}}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"status":"OK","data":{"id":123}}`,
 		},
 		{
 			name: "невалидные данные",
@@ -70,6 +72,62 @@ func TestCreateHandler(t *testing.T) {
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   `{"status":"Error","error":"field ServiceName is a required field, field Price is a required field, field StartDate is a required field, field CounterMonths is a required field"}`,
 		},
+		{
+			name: "название сервиса обрезается по краям",
+			requestBody: models.DummyEntry{
+				ServiceName:   "  Netflix  ",
+				Price:         10,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("CreateEntry", mock.Anything, "testuser", "user123", mock.MatchedBy(func(req models.DummyEntry) bool {
+					return req.ServiceName == "Netflix"
+				})).Return(124, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"status":"OK","data":{"id":124}}`,
+		},
+		{
+			name: "название сервиса из пробелов",
+			requestBody: models.DummyEntry{
+				ServiceName:   " \t ",
+				Price:         10,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username:       "testuser",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid service name: field ServiceName must not be empty"}`,
+		},
+		{
+			name: "слишком длинное название сервиса",
+			requestBody: models.DummyEntry{
+				ServiceName:   strings.Repeat("a", models.MaxServiceNameLength+1),
+				Price:         10,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username:       "testuser",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid service name: field ServiceName must be at most 100 characters"}`,
+		},
+		{
+			name: "управляющий символ в названии сервиса",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Net\nflix",
+				Price:         10,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username:       "testuser",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid service name: field ServiceName must not contain control characters"}`,
+		},
 		{
 			name:           "некорректный JSON",
 			requestBody:    "not a json",
@@ -91,6 +149,54 @@ func TestCreateHandler(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
 		},
+		{
+			name: "дата в недопустимом формате",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         10,
+				StartDate:     "01/2024",
+				CounterMonths: 12,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("CreateEntry", mock.Anything, "testuser", "user123", mock.AnythingOfType("models.DummyEntry")).
+					Return(0, fmt.Errorf("%w: date \"01/2024\" does not match any accepted format: 2006-01-02, 02-01-2006, 01-2006", models.ErrInvalidStartDate))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid start date: date \"01/2024\" does not match any accepted format: 2006-01-02, 02-01-2006, 01-2006"}`,
+		},
+		{
+			name: "цена выше допустимой",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         20000000,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("CreateEntry", mock.Anything, "testuser", "user123", mock.AnythingOfType("models.DummyEntry")).
+					Return(0, fmt.Errorf("%w: price must not exceed 10000000", models.ErrEntryOutOfLimits))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"subscription is out of allowed limits: price must not exceed 10000000"}`,
+		},
+		{
+			name: "превышено число активных подписок на сервис",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         10,
+				StartDate:     "01-2024",
+				CounterMonths: 12,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("CreateEntry", mock.Anything, "testuser", "user123", mock.AnythingOfType("models.DummyEntry")).
+					Return(0, fmt.Errorf("%w: at most 1 active subscriptions for Netflix", models.ErrActiveLimitExceeded))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":"Error","error":"too many active subscriptions for service: at most 1 active subscriptions for Netflix"}`,
+		},
 		{
 			name: "ошибка сервиса",
 			requestBody: models.DummyEntry{
@@ -143,3 +249,39 @@ func TestCreateHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHandler_Envelope(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mockService := new(MockService)
+	mockService.On("CreateEntry", mock.Anything, "testuser", "user123", mock.AnythingOfType("models.DummyEntry")).
+		Return(42, nil)
+
+	body, err := json.Marshal(models.DummyEntry{
+		ServiceName:   "Netflix",
+		Price:         10,
+		StartDate:     "01-2024",
+		CounterMonths: 12,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions", bytes.NewReader(body))
+	ctx := context.WithValue(req.Context(), middlewarectx.User, "testuser")
+	ctx = context.WithValue(ctx, middlewarectx.UserUID, "user123")
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	New(logger, mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var envelope struct {
+		Status string `json:"status"`
+		Data   struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(t, "OK", envelope.Status)
+	assert.Equal(t, 42, envelope.Data.ID)
+}
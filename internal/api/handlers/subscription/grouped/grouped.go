@@ -0,0 +1,84 @@
+// Package grouped реализует HTTP-обработчик для получения подписок пользователя, сгруппированных по статусу.
+//
+// Handler возвращает одним ответом активные, приостановленные и истекшие подписки
+// текущего пользователя с количеством и суммой ежемесячных цен в каждой группе.
+package grouped
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение сгруппированных подписок.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики подписок
+}
+
+// Service описывает интерфейс бизнес-логики группировки подписок.
+type Service interface {
+	GroupEntrysByStatus(ctx context.Context, userUID string) (*models.GroupedEntries, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить подписки, сгруппированные по статусу
+// @Description Возвращает подписки текущего пользователя в группах active, paused и expired с количеством и суммой ежемесячных цен в каждой группе.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.OKResponse "Сгруппированные подписки"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при получении подписок"
+// @Router /me/subscriptions/grouped [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.grouped"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	res, err := h.service.GroupEntrysByStatus(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to group subscriptions", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get subscriptions"))
+		return
+	}
+
+	log.Info("grouped subscriptions",
+		slog.Int("active", res.Active.Count),
+		slog.Int("paused", res.Paused.Count),
+		slog.Int("expired", res.Expired.Count),
+	)
+	response.OK(w, res)
+}
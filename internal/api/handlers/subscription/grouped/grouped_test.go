@@ -0,0 +1,108 @@
+package grouped
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс grouped.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GroupEntrysByStatus(ctx context.Context, userUID string) (*models.GroupedEntries, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.(*models.GroupedEntries), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestGroupedHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	grouped := &models.GroupedEntries{
+		Active: models.EntryGroup{Count: 2, Total: 800, Entries: []*models.Entry{
+			{ID: 1, ServiceName: "Netflix", Price: 500}, {ID: 2, ServiceName: "Spotify", Price: 300},
+		}},
+		Paused:  models.EntryGroup{Entries: []*models.Entry{}},
+		Expired: models.EntryGroup{Count: 1, Total: 400, Entries: []*models.Entry{{ID: 4, ServiceName: "Okko", Price: 400}}},
+	}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "сгруппированные подписки",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GroupEntrysByStatus", mock.Anything, "user123").Return(grouped, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "пользователь не авторизован",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("GroupEntrysByStatus", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to get subscriptions"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/subscriptions/grouped", nil)
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			} else {
+				var body struct {
+					Data models.GroupedEntries `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, 2, body.Data.Active.Count)
+				assert.Equal(t, 800, body.Data.Active.Total)
+				assert.Len(t, body.Data.Active.Entries, 2)
+				assert.Equal(t, 0, body.Data.Paused.Count)
+				assert.NotNil(t, body.Data.Paused.Entries)
+				assert.Equal(t, 1, body.Data.Expired.Count)
+				assert.Equal(t, 400, body.Data.Expired.Total)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -1,24 +1,32 @@
 package health
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/render"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
-	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/cache"
-	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/repository"
-	"github.com/streadway/amqp"
 )
 
+// Pinger определяет проверку доступности хранилища.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Connection определяет проверку состояния соединения с брокером сообщений.
+type Connection interface {
+	IsClosed() bool
+}
+
 type Handler struct {
 	log     *slog.Logger
-	storage *repository.Storage
-	rabbit  *amqp.Connection
-	cache   *cache.Cache
+	storage Pinger
+	rabbit  Connection
+	cache   Pinger
 }
 
-func New(log *slog.Logger, storage *repository.Storage, rabbit *amqp.Connection, cache *cache.Cache) *Handler {
+func New(log *slog.Logger, storage Pinger, rabbit Connection, cache Pinger) *Handler {
 	return &Handler{
 		log:     log,
 		storage: storage,
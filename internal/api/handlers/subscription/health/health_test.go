@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// PingerMock реализует интерфейс health.Pinger
+type PingerMock struct{ mock.Mock }
+
+func (m *PingerMock) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// ConnectionMock реализует интерфейс health.Connection
+type ConnectionMock struct{ mock.Mock }
+
+func (m *ConnectionMock) IsClosed() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func TestHealthHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	storage := new(PingerMock)
+	cache := new(PingerMock)
+	rabbit := new(ConnectionMock)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+
+	New(logger, storage, rabbit, cache).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"OK","data":{"status":"ok"}}`, w.Body.String())
+	storage.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	rabbit.AssertExpectations(t)
+}
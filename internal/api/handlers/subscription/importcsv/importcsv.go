@@ -0,0 +1,130 @@
+// Package importcsv реализует HTTP-обработчик для импорта подписок из выписки банка в формате CSV.
+//
+// Handler разбирает тело запроса по настроенному соответствию колонок. С параметром
+// preview=true он только возвращает разобранные строки, иначе создает подписки из корректных
+// строк. Ошибки отдельных строк возвращаются вместе с номером строки и не прерывают импорт.
+package importcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/bankcsv"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на импорт подписок из CSV.
+type Handler struct {
+	log     *slog.Logger    // Логгер для записи информации и ошибок
+	service Service         // Сервис бизнес-логики создания подписок
+	mapping bankcsv.Mapping // Соответствие колонок выписки полям подписки
+	batch   int             // Сколько подписок сохраняется в одной транзакции
+}
+
+// Service описывает интерфейс бизнес-логики импорта подписок.
+type Service interface {
+	ImportEntries(ctx context.Context, userName, userUID string, rows []models.ImportRow, batchSize int) []models.ImportRow
+}
+
+// New создает новый Handler с переданными логгером, сервисом, соответствием колонок
+// и размером пачки подписок, сохраняемых в одной транзакции.
+func New(log *slog.Logger, service Service, mapping bankcsv.Mapping, batchSize int) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+		mapping: mapping,
+		batch:   batchSize,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Импортировать подписки из выписки банка
+// @Description Разбирает CSV-выписку банка (колонки продавца, суммы и даты задаются в конфиге) и создает подписки из корректных строк пачками в отдельных транзакциях. С preview=true подписки не создаются, возвращаются только разобранные строки. Ошибки отдельных строк возвращаются с номером строки.
+// @Tags Subscriptions
+// @Accept  text/csv
+// @Produce  json
+// @Param preview query bool false "Только разобрать файл без создания подписок"
+// @Success 200 {object} response.OKResponse "Результат по каждой строке"
+// @Failure 400 {object} response.ErrorResponse "Некорректный CSV или параметр preview"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 413 {object} response.ErrorResponse "Файл слишком большой"
+// @Router /subscriptions/import [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.importcsv"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	user := middlewarectx.GetUser(r.Context())
+	if user.Username == "" || user.UID == "" {
+		log.Error("user not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	preview := false
+	if s := r.URL.Query().Get("preview"); s != "" {
+		var err error
+		if preview, err = strconv.ParseBool(s); err != nil {
+			log.Error("invalid preview parameter", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid preview parameter"))
+			return
+		}
+	}
+
+	rows, err := bankcsv.Parse(r.Body, h.mapping)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		log.Error("csv file is too large", slog.Int64("limit", maxBytesErr.Limit))
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		render.JSON(w, r, response.Error("file is too large"))
+		return
+	case errors.Is(err, bankcsv.ErrMissingColumn):
+		log.Error("csv header does not match mapping", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(fmt.Sprintf("csv file must contain columns %q, %q and %q",
+			h.mapping.MerchantColumn, h.mapping.AmountColumn, h.mapping.DateColumn)))
+		return
+	case err != nil:
+		log.Error("failed to parse csv", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid csv file"))
+		return
+	}
+
+	if !preview {
+		rows = h.service.ImportEntries(r.Context(), user.Username, user.UID, rows, h.batch)
+	}
+
+	var succeeded int
+	for _, row := range rows {
+		if row.Error == "" {
+			succeeded++
+		}
+	}
+	failed := len(rows) - succeeded
+
+	log.Info("csv processed", slog.Bool("preview", preview), slog.Int("succeeded", succeeded), slog.Int("failed", failed))
+	response.OK(w, map[string]any{
+		"preview":   preview,
+		"rows":      rows,
+		"succeeded": succeeded,
+		"failed":    failed,
+	})
+}
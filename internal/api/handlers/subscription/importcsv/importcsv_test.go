@@ -0,0 +1,173 @@
+package importcsv
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/bankcsv"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс importcsv.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ImportEntries(ctx context.Context, userName, userUID string, rows []models.ImportRow, batchSize int) []models.ImportRow {
+	args := m.Called(ctx, userName, userUID, rows, batchSize)
+	return args.Get(0).([]models.ImportRow)
+}
+
+var testMapping = bankcsv.Mapping{
+	MerchantColumn: "merchant",
+	AmountColumn:   "amount",
+	DateColumn:     "date",
+	DateLayout:     "02.01.2006",
+	Delimiter:      ';',
+	CounterMonths:  12,
+}
+
+const testCSV = "date;merchant;amount\n01.06.2025;Netflix;-599,00\n02.06.2025;Okko;abc\n"
+
+type importResponse struct {
+	Data struct {
+		Preview   bool               `json:"preview"`
+		Rows      []models.ImportRow `json:"rows"`
+		Succeeded int                `json:"succeeded"`
+		Failed    int                `json:"failed"`
+	} `json:"data"`
+}
+
+func TestImportCSVHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	netflix := &models.DummyEntry{ServiceName: "Netflix", Price: 599, StartDate: "01-06-2025", CounterMonths: 12, IsActive: true}
+	parsed := []models.ImportRow{
+		{Line: 2, Entry: netflix},
+		{Line: 3, Error: `invalid amount "abc"`},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		user           middlewarectx.UserInfo
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+		wantPreview    bool
+		wantRows       []models.ImportRow
+		wantSucceeded  int
+		wantFailed     int
+	}{
+		{
+			name:           "предпросмотр не создает подписки",
+			query:          "?preview=true",
+			body:           testCSV,
+			user:           middlewarectx.UserInfo{UID: "user123", Username: "testuser"},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusOK,
+			wantPreview:    true,
+			wantRows:       parsed,
+			wantSucceeded:  1,
+			wantFailed:     1,
+		},
+		{
+			name: "импорт корректных строк",
+			body: testCSV,
+			user: middlewarectx.UserInfo{UID: "user123", Username: "testuser"},
+			setupMock: func(m *MockService) {
+				m.On("ImportEntries", mock.Anything, "testuser", "user123", parsed, 500).
+					Return([]models.ImportRow{{Line: 2, Entry: netflix, ID: 11}, parsed[1]}).Once()
+			},
+			expectedStatus: http.StatusOK,
+			wantRows:       []models.ImportRow{{Line: 2, Entry: netflix, ID: 11}, parsed[1]},
+			wantSucceeded:  1,
+			wantFailed:     1,
+		},
+		{
+			name:           "нет нужной колонки",
+			body:           "date;amount\n01.06.2025;-599,00\n",
+			user:           middlewarectx.UserInfo{UID: "user123", Username: "testuser"},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"csv file must contain columns \"merchant\", \"amount\" and \"date\""}`,
+		},
+		{
+			name:           "пустой файл",
+			body:           "",
+			user:           middlewarectx.UserInfo{UID: "user123", Username: "testuser"},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid csv file"}`,
+		},
+		{
+			name:           "некорректный параметр preview",
+			query:          "?preview=maybe",
+			body:           testCSV,
+			user:           middlewarectx.UserInfo{UID: "user123", Username: "testuser"},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid preview parameter"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			body:           testCSV,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService, testMapping, 500)
+
+			req := httptest.NewRequest(http.MethodPost, "/subscriptions/import"+tt.query, strings.NewReader(tt.body))
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), tt.user))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			} else {
+				var body importResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantPreview, body.Data.Preview)
+				assert.Equal(t, tt.wantRows, body.Data.Rows)
+				assert.Equal(t, tt.wantSucceeded, body.Data.Succeeded)
+				assert.Equal(t, tt.wantFailed, body.Data.Failed)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportCSVHandler_BodyTooLarge(t *testing.T) {
+	handler := New(slog.New(slog.NewTextHandler(io.Discard, nil)), new(MockService), testMapping, 500)
+
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/import?preview=true",
+		strings.NewReader(testCSV+strings.Repeat("03.06.2025;Ivi;-199,00\n", 100)))
+	req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: "user123", Username: "testuser"}))
+	w := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(w, req.Body, 128)
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.JSONEq(t, `{"status":"Error","error":"file is too large"}`, w.Body.String())
+}
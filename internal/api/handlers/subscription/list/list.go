@@ -1,8 +1,12 @@
 // Package list реализует HTTP-обработчик для получения списка подписок пользователя с пагинацией.
 //
-// Handler извлекает параметры limit и offset из query строки, получает имя пользователя и роль из контекста,
+// Handler получает имя пользователя и роль из контекста, разбирает параметры limit и offset
+// из query строки через pagination с учетом максимума для роли,
 // вызывает бизнес-логику получения списка подписок через сервис и возвращает результат в JSON-формате.
 //
+// Список администратора, который может быть большим, записывается в ответ по мере чтения
+// подписок из базы через response.ListStream и не собирается в памяти целиком.
+//
 // При ошибках возвращает соответствующие HTTP-статусы и описания ошибок в ответах.
 package list
 
@@ -10,12 +14,12 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"strconv"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/pagination"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
@@ -29,19 +33,43 @@ type Handler struct {
 	log      *slog.Logger        // Логгер для записи информации и ошибок
 	service  Service             // Сервис бизнес-логики получения списка записей
 	validate *validator.Validate // Валидатор входных параметров (не используется в ServeHTTP)
+	limits   PageLimits          // Ограничения размера страницы
+}
+
+// PageLimits задает размер страницы по умолчанию и максимальные размеры страницы
+// для пользователей и администраторов. Нулевые значения заменяются значениями по умолчанию.
+// limit сверх максимума для роли урезается до него.
+type PageLimits struct {
+	Default  int // Размер страницы, если limit не передан
+	Max      int // Максимальный limit для пользователя
+	AdminMax int // Максимальный limit для администратора, просматривающего все подписки
 }
 
+// defaultAdminPageSize — значение PageLimits.AdminMax по умолчанию.
+const defaultAdminPageSize = 500
+
 // Service описывает интерфейс бизнес-логики получения списка подписок с параметрами пагинации и фильтрации.
 type Service interface {
-	ListEntrys(ctx context.Context, username, role string, limit, offset int) ([]*models.Entry, error)
+	ListEntrys(ctx context.Context, userUID, role string, limit, offset int, sort models.ListSort) ([]*models.Entry, error)
+	StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error
 }
 
-// New создает новый Handler с переданными логгером и бизнес-сервисом.
-func New(log *slog.Logger, service Service) *Handler {
+// New создает новый Handler с переданными логгером, бизнес-сервисом и ограничениями пагинации.
+func New(log *slog.Logger, service Service, limits PageLimits) *Handler {
+	if limits.Default <= 0 {
+		limits.Default = pagination.DefaultLimit
+	}
+	if limits.Max <= 0 {
+		limits.Max = pagination.MaxLimit
+	}
+	if limits.AdminMax <= 0 {
+		limits.AdminMax = defaultAdminPageSize
+	}
 	return &Handler{
 		log:      log,
 		service:  service,
 		validate: validator.New(),
+		limits:   limits,
 	}
 }
 
@@ -51,10 +79,14 @@ func New(log *slog.Logger, service Service) *Handler {
 // @Tags Subscriptions
 // @Accept  json
 // @Produce  json
-// @Param limit query int false "Максимальное количество записей (по умолчанию 10)" minimum(1) example(10)
+// @Param limit query int false "Максимальное количество записей (по умолчанию 10, больше настроенного максимума урезается до него)" minimum(1) example(10)
 // @Param offset query int false "Смещение для пагинации (по умолчанию 0)" minimum(0) example(0)
-// @Success 200 {object} map[string]any "Список подписок"
+// @Success 200 {object} response.OKResponse "Список подписок"
+// @Failure 400 {object} response.ErrorResponse "Некорректный limit или offset"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Param sort query string false "Поле сортировки (по умолчанию id)" Enums(id, next_payment_date, price, service_name)
+// @Param order query string false "Направление сортировки (по умолчанию asc)" Enums(asc, desc)
+// @Failure 422 {object} response.ErrorResponse "Недопустимая сортировка"
 // @Failure 500 {object} response.ErrorResponse "Ошибка при получении списка"
 // @Router /subscriptions [get]
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -65,34 +97,52 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
-	limitStr := r.URL.Query().Get("limit")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-
-	offsetStr := r.URL.Query().Get("offset")
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	sort, err := models.ParseListSort(r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+	if err != nil {
+		log.Warn("invalid sort parameters", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
 	}
 
-	username, ok := r.Context().Value(middlewarectx.User).(string)
-	if !ok || username == "" {
-		log.Error("username not found in context")
+	user := middlewarectx.GetUser(r.Context())
+	userUID := user.UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
 		return
 	}
-	role, ok := r.Context().Value(middlewarectx.Role).(string)
-	if !ok || role == "" {
+	role := user.Role
+	if role == "" {
 		log.Error("role not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
 		return
 	}
-	res, err := h.service.ListEntrys(r.Context(), username, role, limit, offset)
+
+	maxLimit := h.limits.Max
+	if role == "admin" {
+		maxLimit = h.limits.AdminMax
+	}
+	limit, offset, err := pagination.Limits{Default: h.limits.Default, Max: maxLimit}.Parse(r)
 	if err != nil {
+		log.Warn("invalid pagination parameters", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	if role == "admin" {
+		h.streamAll(w, r, log, limit, offset, sort)
+		return
+	}
+
+	res, err := h.service.ListEntrys(r.Context(), userUID, role, limit, offset, sort)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to list entries", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("failed to list"))
@@ -100,8 +150,31 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info("list entries", "count", len(res))
-	render.JSON(w, r, response.OKWithData(map[string]any{
+	response.OK(w, map[string]any{
 		"list_count": len(res),
 		"entries":    res,
-	}))
+	})
+}
+
+// streamAll записывает подписки всех пользователей в ответ по мере их чтения из базы.
+// Ошибка до первой записанной подписки возвращается клиенту как 500; после нее статус
+// уже отправлен, поэтому ответ обрывается без завершающего list_count.
+func (h *Handler) streamAll(w http.ResponseWriter, r *http.Request, log *slog.Logger, limit, offset int, sort models.ListSort) {
+	stream := response.NewListStream(w, "entries")
+	err := h.service.StreamAllEntrys(r.Context(), limit, offset, sort, func(entry *models.Entry) error {
+		return stream.Write(entry)
+	})
+	if err != nil {
+		log.Error("failed to stream entries", sl.Err(err), slog.Bool("response_started", stream.Started()))
+		if !stream.Started() {
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to list"))
+		}
+		return
+	}
+	if err := stream.Close(); err != nil {
+		log.Error("failed to finish entries stream", sl.Err(err))
+		return
+	}
+	log.Info("list entries streamed", "count", stream.Count())
 }
@@ -2,7 +2,9 @@ package list
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +15,7 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
@@ -23,18 +26,28 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) ListEntrys(ctx context.Context, username, role string, limit, offset int) ([]*models.Entry, error) {
-	args := m.Called(ctx, username, role, limit, offset)
+func (m *MockService) ListEntrys(ctx context.Context, userUID, role string, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
+	args := m.Called(ctx, userUID, role, limit, offset, sort)
 	return args.Get(0).([]*models.Entry), args.Error(1)
 }
 
+func (m *MockService) StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error {
+	args := m.Called(ctx, limit, offset, sort)
+	for _, e := range args.Get(0).([]*models.Entry) {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func TestListHandler(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	tests := []struct {
 		name           string
 		queryParams    string
-		username       string
+		userUID        string
 		role           string
 		setupMock      func(*MockService)
 		expectedStatus int
@@ -43,14 +56,14 @@ func TestListHandler(t *testing.T) {
 		{
 			name:        "успешный список с дефолтной пагинацией",
 			queryParams: "",
-			username:    "testuser",
+			userUID:     "user123",
 			role:        "user",
 			setupMock: func(m *MockService) {
 				entries := []*models.Entry{
 					{ServiceName: "Netflix", Price: 10, Username: "testuser", CounterMonths: 3},
 					{ServiceName: "Spotify", Price: 5, Username: "testuser", CounterMonths: 1},
 				}
-				m.On("ListEntrys", mock.Anything, "testuser", "user", 10, 0).
+				m.On("ListEntrys", mock.Anything, "user123", "user", 10, 0, models.ListSort{Field: models.SortByID}).
 					Return(entries, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -59,31 +72,37 @@ func TestListHandler(t *testing.T) {
 		{
 			name:        "кастомная пагинация",
 			queryParams: "?limit=5&offset=3",
-			username:    "testuser",
+			userUID:     "user123",
 			role:        "user",
 			setupMock: func(m *MockService) {
-				m.On("ListEntrys", mock.Anything, "testuser", "user", 5, 3).
+				m.On("ListEntrys", mock.Anything, "user123", "user", 5, 3, models.ListSort{Field: models.SortByID}).
 					Return([]*models.Entry{}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"list_count":0`,
 		},
 		{
-			name:        "некорректный параметр limit",
-			queryParams: "?limit=abc",
-			username:    "testuser",
-			role:        "user",
-			setupMock: func(m *MockService) {
-				m.On("ListEntrys", mock.Anything, "testuser", "user", 10, 0).
-					Return([]*models.Entry{}, nil)
-			},
-			expectedStatus: http.StatusOK,
-			expectedBody:   `"list_count":0`,
+			name:           "некорректный параметр limit",
+			queryParams:    "?limit=abc",
+			userUID:        "user123",
+			role:           "user",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"limit must be a positive integer"}`,
+		},
+		{
+			name:           "отрицательный offset",
+			queryParams:    "?offset=-1",
+			userUID:        "user123",
+			role:           "user",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"offset must be a non-negative integer"}`,
 		},
 		{
-			name:           "нет авторизации (username)",
+			name:           "нет авторизации (user UID)",
 			queryParams:    "",
-			username:       "",
+			userUID:        "",
 			role:           "user",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusUnauthorized,
@@ -92,7 +111,7 @@ func TestListHandler(t *testing.T) {
 		{
 			name:           "нет роли в контексте",
 			queryParams:    "",
-			username:       "testuser",
+			userUID:        "user123",
 			role:           "",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusUnauthorized,
@@ -101,10 +120,10 @@ func TestListHandler(t *testing.T) {
 		{
 			name:        "ошибка сервиса",
 			queryParams: "",
-			username:    "testuser",
+			userUID:     "user123",
 			role:        "admin",
 			setupMock: func(m *MockService) {
-				m.On("ListEntrys", mock.Anything, "testuser", "admin", 10, 0).
+				m.On("StreamAllEntrys", mock.Anything, 10, 0, models.ListSort{Field: models.SortByID}).
 					Return([]*models.Entry{}, errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -117,11 +136,11 @@ func TestListHandler(t *testing.T) {
 			mockService := new(MockService)
 			tt.setupMock(mockService)
 
-			handler := New(logger, mockService)
+			handler := New(logger, mockService, PageLimits{})
 
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/list"+tt.queryParams, nil)
 
-			ctx := context.WithValue(req.Context(), middlewarectx.User, tt.username)
+			ctx := context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID)
 			ctx = context.WithValue(ctx, middlewarectx.Role, tt.role)
 			ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-id")
 			req = req.WithContext(ctx)
@@ -138,3 +157,216 @@ func TestListHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestListHandler_PageLimits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	limits := PageLimits{Default: 10, Max: 50, AdminMax: 200}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		role           string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "limit пользователя урезается до максимума",
+			queryParams: "?limit=51",
+			role:        "user",
+			setupMock: func(m *MockService) {
+				m.On("ListEntrys", mock.Anything, "user123", "user", 50, 0, models.ListSort{Field: models.SortByID}).
+					Return([]*models.Entry{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"list_count":0`,
+		},
+		{
+			name:        "администратор в пределах своего максимума",
+			queryParams: "?limit=200",
+			role:        "admin",
+			setupMock: func(m *MockService) {
+				m.On("StreamAllEntrys", mock.Anything, 200, 0, models.ListSort{Field: models.SortByID}).
+					Return([]*models.Entry{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"list_count":0`,
+		},
+		{
+			name:        "limit администратора урезается до его максимума",
+			queryParams: "?limit=201",
+			role:        "admin",
+			setupMock: func(m *MockService) {
+				m.On("StreamAllEntrys", mock.Anything, 200, 0, models.ListSort{Field: models.SortByID}).
+					Return([]*models.Entry{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"list_count":0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			handler := New(logger, mockService, limits)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/list"+tt.queryParams, nil)
+			ctx := context.WithValue(req.Context(), middlewarectx.UserUID, "user123")
+			ctx = context.WithValue(ctx, middlewarectx.Role, tt.role)
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListHandler_Sort(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		wantSort       *models.ListSort
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "сортировка по дате следующего платежа",
+			queryParams:    "?sort=next_payment_date",
+			wantSort:       &models.ListSort{Field: models.SortByNextPaymentDate},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "сортировка по цене по убыванию",
+			queryParams:    "?sort=price&order=desc",
+			wantSort:       &models.ListSort{Field: models.SortByPrice, Desc: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "сортировка по названию сервиса",
+			queryParams:    "?sort=service_name&order=asc",
+			wantSort:       &models.ListSort{Field: models.SortByServiceName},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "id по убыванию",
+			queryParams:    "?order=desc",
+			wantSort:       &models.ListSort{Field: models.SortByID, Desc: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "недопустимое поле сортировки",
+			queryParams:    "?sort=price%20desc%2C%28SELECT%201%29",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `sort must be one of id, next_payment_date, price, service_name`,
+		},
+		{
+			name:           "недопустимое направление",
+			queryParams:    "?sort=price&order=sideways",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `order must be asc or desc`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			if tt.wantSort != nil {
+				mockService.On("ListEntrys", mock.Anything, "user123", "user", 10, 0, *tt.wantSort).
+					Return([]*models.Entry{}, nil)
+			}
+
+			handler := New(logger, mockService, PageLimits{})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/list"+tt.queryParams, nil)
+			ctx := context.WithValue(req.Context(), middlewarectx.UserUID, "user123")
+			ctx = context.WithValue(ctx, middlewarectx.Role, "user")
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// streamingService передает в fn count подписок и после первой вызывает onFirst,
+// чтобы тест видел состояние ответа, пока остальные подписки еще не прочитаны.
+type streamingService struct {
+	MockService
+	count   int
+	failAt  int // номер подписки, на которой чтение завершается ошибкой; 0 — без ошибки
+	onFirst func()
+}
+
+func (s *streamingService) StreamAllEntrys(_ context.Context, _, _ int, _ models.ListSort, fn func(*models.Entry) error) error {
+	for i := 1; i <= s.count; i++ {
+		if i == s.failAt {
+			return errors.New("connection lost")
+		}
+		if err := fn(&models.Entry{ID: i, ServiceName: "Netflix", Price: 10}); err != nil {
+			return err
+		}
+		if i == 1 && s.onFirst != nil {
+			s.onFirst()
+		}
+	}
+	return nil
+}
+
+func TestListHandler_AdminStreaming(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/list?limit=500", nil)
+		return req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: "admin1", Role: "admin"}))
+	}
+
+	t.Run("ответ начинается до чтения всех подписок", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		service := &streamingService{count: 500}
+		service.onFirst = func() {
+			assert.True(t, w.Flushed, "первая подписка отправлена клиенту до чтения остальных")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.True(t, strings.HasPrefix(w.Body.String(), `{"status":"OK","data":{"entries":[{"ID":1,`), w.Body.String())
+		}
+		New(logger, service, PageLimits{}).ServeHTTP(w, newRequest())
+
+		var body struct {
+			Data struct {
+				Entries   []models.Entry `json:"entries"`
+				ListCount int            `json:"list_count"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 500, body.Data.ListCount)
+		require.Len(t, body.Data.Entries, 500)
+		assert.Equal(t, 500, body.Data.Entries[499].ID)
+	})
+
+	t.Run("ошибка до первой подписки", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		New(logger, &streamingService{count: 10, failAt: 1}, PageLimits{}).ServeHTTP(w, newRequest())
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"status":"Error","error":"failed to list"}`, w.Body.String())
+	})
+
+	t.Run("ошибка после начала ответа обрывает его", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		New(logger, &streamingService{count: 10, failAt: 5}, PageLimits{}).ServeHTTP(w, newRequest())
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "list_count")
+		assert.False(t, json.Valid(w.Body.Bytes()), "клиент видит неполный ответ")
+	})
+}
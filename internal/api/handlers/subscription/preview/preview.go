@@ -0,0 +1,118 @@
+// Package preview реализует HTTP-обработчик для предварительного расчета стоимости подписки.
+//
+// Handler принимает те же данные, что и создание подписки, валидирует их и возвращает
+// расчетную стоимость подписки за весь срок. Данные в хранилище не записываются.
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на предварительный расчет стоимости подписки.
+type Handler struct {
+	log      *slog.Logger        // Логгер для записи информации и ошибок
+	service  Service             // Сервис бизнес-логики подписок
+	validate *validator.Validate // Валидатор структуры входящих данных
+}
+
+// Service описывает интерфейс бизнес-логики расчета стоимости подписки.
+type Service interface {
+	PreviewEntry(ctx context.Context, req models.DummyEntry) (*models.EntryPreview, error)
+}
+
+// New создает новый Handler с переданными логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:      log,
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Рассчитать стоимость подписки
+// @Description Возвращает расчетную стоимость подписки за весь срок без ее создания.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param request body models.DummyEntry true "Данные подписки"
+// @Success 200 {object} response.OKResponse{data=models.EntryPreview} "Расчетная стоимость"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации, некорректное название сервиса или цена, срок или валюта вне допустимых ограничений"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при расчете"
+// @Router /subscriptions/preview [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.preview"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req models.DummyEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
+		return
+	}
+
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		log.Error("invalid service name", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	req.ServiceName = serviceName
+
+	preview, err := h.service.PreviewEntry(r.Context(), req)
+	switch {
+	case errors.Is(err, models.ErrInvalidStartDate):
+		log.Error("invalid start date", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrEntryOutOfLimits):
+		log.Error("subscription is out of limits", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrEndDateInPast):
+		log.Error("subscription already ended", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(models.ErrEndDateInPast.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to preview subscription", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not preview subscription"))
+		return
+	}
+
+	log.Info("subscription preview calculated", slog.Float64("total", preview.Total))
+	response.OK(w, preview)
+}
@@ -0,0 +1,122 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс preview.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) PreviewEntry(ctx context.Context, req models.DummyEntry) (*models.EntryPreview, error) {
+	args := m.Called(ctx, req)
+	if res := args.Get(0); res != nil {
+		return res.(*models.EntryPreview), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestPreviewHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	valid := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-01-2030", CounterMonths: 12}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "успешный расчет",
+			requestBody: valid,
+			setupMock: func(m *MockService) {
+				m.On("PreviewEntry", mock.Anything, valid).Return(&models.EntryPreview{
+					ServiceName:     "Netflix",
+					Price:           500,
+					StartDate:       "01-01-2030",
+					EndDate:         "01-01-2031",
+					CounterMonths:   12,
+					NextPaymentDate: "01-02-2030",
+					Total:           6000,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"service_name":"Netflix","price":500,"start_date":"01-01-2030",` +
+				`"end_date":"01-01-2031","counter_months":12,"next_payment_date":"01-02-2030","total":6000}}`,
+		},
+		{
+			name:           "ошибка валидации",
+			requestBody:    models.DummyEntry{ServiceName: "Netflix", StartDate: "01-01-2030", CounterMonths: 12},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"field Price is a required field"}`,
+		},
+		{
+			name:        "некорректная дата",
+			requestBody: models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2030/01/01", CounterMonths: 12},
+			setupMock: func(m *MockService) {
+				m.On("PreviewEntry", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: parse error", models.ErrInvalidStartDate)).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid start date: parse error"}`,
+		},
+		{
+			name:        "подписка уже закончилась",
+			requestBody: models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-01-2000", CounterMonths: 1},
+			setupMock: func(m *MockService) {
+				m.On("PreviewEntry", mock.Anything, mock.Anything).Return(nil, models.ErrEndDateInPast).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"subscription end date must not be earlier than today"}`,
+		},
+		{
+			name:           "некорректный JSON",
+			requestBody:    "not a json",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			var body []byte
+			if s, ok := tt.requestBody.(string); ok {
+				body = []byte(s)
+			} else {
+				var err error
+				body, err = json.Marshal(tt.requestBody)
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/preview", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
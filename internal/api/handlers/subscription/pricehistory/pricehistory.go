@@ -0,0 +1,96 @@
+// Package pricehistory реализует HTTP-обработчик для получения истории цены сервиса.
+//
+// Handler извлекает название сервиса из URL-параметров и возвращает, как менялась цена
+// этого сервиса по всем подпискам текущего пользователя, чтобы было видно постепенный рост цены.
+package pricehistory
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение истории цены сервиса.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики подписок
+}
+
+// Service описывает интерфейс бизнес-логики истории цены сервиса.
+type Service interface {
+	PriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить историю цены сервиса
+// @Description Возвращает цены сервиса с указанным названием (без учета регистра) по всем подпискам текущего пользователя в порядке времени. Точка добавляется при каждом изменении цены или валюты.
+// @Tags Subscriptions
+// @Produce  json
+// @Param name path string true "Название сервиса"
+// @Success 200 {object} response.OKResponse "История цены (может быть пустой)"
+// @Failure 400 {object} response.ErrorResponse "Некорректное название сервиса"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при получении истории цены"
+// @Router /me/services/{name}/price-history [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.pricehistory"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	name = strings.TrimSpace(name)
+	if err != nil || name == "" {
+		log.Error("invalid service name in url", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid service name"))
+		return
+	}
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	points, err := h.service.PriceHistory(r.Context(), userUID, name)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to get price history", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get price history"))
+		return
+	}
+
+	log.Info("price history found", slog.String("service", name), slog.Int("count", len(points)))
+	response.OK(w, map[string]any{
+		"service_name": name,
+		"points":       points,
+	})
+}
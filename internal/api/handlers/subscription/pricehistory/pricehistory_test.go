@@ -0,0 +1,120 @@
+package pricehistory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс pricehistory.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) PriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error) {
+	args := m.Called(ctx, userUID, service)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.PricePoint), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestPriceHistoryHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		param          string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "несколько изменений цены по порядку",
+			param:   "Netflix",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("PriceHistory", mock.Anything, "user123", "Netflix").Return([]*models.PricePoint{
+					{Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Price: 500, Currency: "RUB", SubscriptionID: 1},
+					{Date: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Price: 600, Currency: "RUB", SubscriptionID: 1},
+					{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Price: 700, Currency: "RUB", SubscriptionID: 2},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"service_name":"Netflix","points":[` +
+				`{"date":"2023-01-01T00:00:00Z","price":500,"currency":"RUB","subscription_id":1},` +
+				`{"date":"2023-06-01T00:00:00Z","price":600,"currency":"RUB","subscription_id":1},` +
+				`{"date":"2024-03-01T00:00:00Z","price":700,"currency":"RUB","subscription_id":2}]}}`,
+		},
+		{
+			name:    "название с пробелом",
+			param:   "Yandex%20Plus",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("PriceHistory", mock.Anything, "user123", "Yandex Plus").Return([]*models.PricePoint{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":{"service_name":"Yandex Plus","points":[]}}`,
+		},
+		{
+			name:           "пустое название",
+			param:          "%20",
+			userUID:        "user123",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid service name"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			param:          "Netflix",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			param:   "Netflix",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("PriceHistory", mock.Anything, "user123", "Netflix").Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to get price history"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/services/"+tt.param+"/price-history", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", tt.param)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID})
+			req = req.WithContext(ctx)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
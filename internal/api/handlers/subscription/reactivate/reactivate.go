@@ -0,0 +1,103 @@
+// Package reactivate обрабатывает возобновление истекшей или приостановленной подписки пользователя.
+package reactivate
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Service описывает бизнес-логику возобновления подписки.
+type Service interface {
+	ReactivateEntry(ctx context.Context, userUID string, id int) (*models.Entry, error)
+}
+
+// Handler обрабатывает запросы на возобновление подписки.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service
+}
+
+// New создает новый экземпляр Handler.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Возобновить подписку
+// @Description Возобновляет истекшую или приостановленную подписку: делает ее активной и начинает срок заново с сегодняшнего дня, пересчитывая дату следующего платежа. Цена, валюта и срок в месяцах сохраняются; списание при возобновлении не выполняется. Чужая подписка возвращается как несуществующая (404).
+// @Tags Subscriptions
+// @Produce  json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} response.OKResponse{data=models.Entry} "Возобновленная подписка"
+// @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 404 {object} response.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} response.ErrorResponse "Подписка уже активна или превышено число активных подписок на сервис"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при возобновлении подписки"
+// @Router /subscriptions/{id}/reactivate [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.reactivate"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid id"))
+		return
+	}
+
+	entry, err := h.service.ReactivateEntry(r.Context(), userUID, id)
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		log.Info("subscription not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrSubscriptionAlreadyActive) || errors.Is(err, models.ErrActiveLimitExceeded) {
+		log.Info("subscription cannot be reactivated", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to reactivate subscription", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not reactivate subscription"))
+		return
+	}
+
+	log.Info("subscription reactivated", slog.Int("id", id))
+	response.OK(w, entry)
+}
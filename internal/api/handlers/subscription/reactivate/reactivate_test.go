@@ -0,0 +1,127 @@
+package reactivate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ReactivateEntry(ctx context.Context, userUID string, id int) (*models.Entry, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func TestReactivateHandler_ServeHTTP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		id             string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "возобновление истекшей подписки",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+				m.On("ReactivateEntry", mock.Anything, "user123", 7).Return(&models.Entry{
+					ID: 7, ServiceName: "Netflix", Price: 799, StartDate: today, CounterMonths: 1,
+					NextPaymentDate: today.AddDate(0, 1, 0), IsActive: true, UserUID: "user123", Currency: "RUB",
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"ID":7,"ServiceName":"Netflix","Price":799,"Username":"",` +
+				`"StartDate":"2025-06-15T00:00:00Z","CounterMonths":1,"NextPaymentDate":"2025-07-15T00:00:00Z",` +
+				`"IsActive":true,"UserUID":"user123","Currency":"RUB","Notes":"","Metadata":null}}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			id:             "7",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:           "некорректный id",
+			userUID:        "user123",
+			id:             "abc",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid id"}`,
+		},
+		{
+			name:    "подписка другого пользователя",
+			userUID: "user123",
+			id:      "8",
+			setupMock: func(m *MockService) {
+				m.On("ReactivateEntry", mock.Anything, "user123", 8).Return(nil, models.ErrSubscriptionNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"subscription not found"}`,
+		},
+		{
+			name:    "подписка уже активна",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("ReactivateEntry", mock.Anything, "user123", 7).Return(nil, models.ErrSubscriptionAlreadyActive).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":"Error","error":"subscription is already active"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			id:      "7",
+			setupMock: func(m *MockService) {
+				m.On("ReactivateEntry", mock.Anything, "user123", 7).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"could not reactivate subscription"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockService)
+			tt.setupMock(service)
+			handler := New(logger, service)
+
+			req := httptest.NewRequest(http.MethodPost, "/subscriptions/"+tt.id+"/reactivate", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			service.AssertExpectations(t)
+		})
+	}
+}
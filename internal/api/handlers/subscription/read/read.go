@@ -3,19 +3,28 @@
 // Handler извлекает ID из URL-параметров, вызывает бизнес-логику для чтения подписки по идентификатору
 // и возвращает данные подписки в JSON-формате.
 //
+// Ответ содержит заголовок ETag, вычисленный по полям подписки; запрос с совпадающим
+// If-None-Match получает 304 без тела.
+//
 // В случае ошибок формирует соответствующие HTTP-ответы с описанием проблемы.
 package read
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
@@ -30,7 +39,9 @@ type Handler struct {
 
 // Service описывает интерфейс бизнес-логики чтения подписки.
 type Service interface {
-	ReadEntry(ctx context.Context, id int) (*models.Entry, error)
+	ReadEntry(ctx context.Context, userUID string, id int) (*models.Entry, error)
+	// ReadAnyEntry возвращает подписку любого пользователя; вызывается только для администратора.
+	ReadAnyEntry(ctx context.Context, id int) (*models.Entry, error)
 }
 
 // New создает новый Handler с переданным логгером и сервисом.
@@ -44,13 +55,17 @@ func New(log *slog.Logger, service Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Получить подписку по ID
-// @Description Возвращает подписку по её уникальному идентификатору.
+// @Description Возвращает подписку текущего пользователя по её уникальному идентификатору. Чужая подписка возвращается как несуществующая (404); администратор получает подписку любого пользователя.
+// @Description Ответ содержит ETag; если If-None-Match совпадает с ним, возвращается 304 без тела.
 // @Tags Subscriptions
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID подписки"
-// @Success 200 {object} map[string]any "Успешный ответ с данными"
+// @Param If-None-Match header string false "ETag из предыдущего ответа"
+// @Success 200 {object} response.OKResponse "Успешный ответ с данными"
+// @Success 304 "Подписка не изменилась"
 // @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
 // @Failure 404 {object} response.ErrorResponse "Подписка не найдена"
 // @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /subscriptions/{id} [get]
@@ -62,6 +77,15 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
+	user := middlewarectx.GetUser(r.Context())
+	userUID := user.UID
+	if userUID == "" {
+		log.Error("user not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		log.Error("failed to decode id from url", sl.Err(err))
@@ -70,16 +94,69 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.service.ReadEntry(r.Context(), id)
+	var res *models.Entry
+	if user.Role == "admin" {
+		res, err = h.service.ReadAnyEntry(r.Context(), id)
+	} else {
+		res, err = h.service.ReadEntry(r.Context(), userUID, id)
+	}
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		log.Info("subscription not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to read subscription", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("could not read subscription"))
 		return
 	}
 
+	etag, err := entryETag(res)
+	if err != nil {
+		log.Error("failed to compute etag", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not read subscription"))
+		return
+	}
+	w.Header().Set("ETag", etag)
+	// Подписка принадлежит пользователю: общие кеши не должны ее хранить
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		log.Debug("subscription not modified", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	log.Info("success to read subscriptions", slog.Any("entry", res))
-	render.JSON(w, r, response.OKWithData(map[string]any{
+	response.OK(w, map[string]any{
 		"entry": res,
-	}))
+	})
+}
+
+// entryETag возвращает сильный ETag подписки: хеш SHA-256 ее JSON-представления.
+// Любое изменение полей подписки меняет ETag.
+func entryETag(entry *models.Entry) (string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches сообщает, совпадает ли etag с одним из значений заголовка If-None-Match.
+// Как требует RFC 9110 для If-None-Match, префикс слабого ETag W/ не учитывается, а "*" совпадает с любым ETag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
 }
@@ -2,7 +2,9 @@ package read
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +15,10 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
@@ -22,7 +27,15 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) ReadEntry(ctx context.Context, id int) (*models.Entry, error) {
+func (m *MockService) ReadEntry(ctx context.Context, userUID string, id int) (*models.Entry, error) {
+	args := m.Called(ctx, userUID, id)
+	if res := args.Get(0); res != nil {
+		return res.(*models.Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockService) ReadAnyEntry(ctx context.Context, id int) (*models.Entry, error) {
 	args := m.Called(ctx, id)
 	if res := args.Get(0); res != nil {
 		return res.(*models.Entry), args.Error(1)
@@ -37,15 +50,16 @@ func TestReadHandler(t *testing.T) {
 		name           string
 		url            string
 		mockID         int
-		username       string // not required here, but could be added if auth is implemented
+		userUID        string
 		setupMock      func(*MockService)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
-			name:   "успешное чтение подписки",
-			url:    "/subscriptions/123",
-			mockID: 123,
+			name:    "успешное чтение подписки",
+			url:     "/subscriptions/123",
+			mockID:  123,
+			userUID: "user123",
 			setupMock: func(m *MockService) {
 				entry := &models.Entry{
 					ServiceName:   "Netflix",
@@ -53,7 +67,7 @@ func TestReadHandler(t *testing.T) {
 					Username:      "testuser",
 					CounterMonths: 6,
 				}
-				m.On("ReadEntry", mock.Anything, 123).Return(entry, nil)
+				m.On("ReadEntry", mock.Anything, "user123", 123).Return(entry, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"ServiceName":"Netflix"`,
@@ -62,20 +76,63 @@ func TestReadHandler(t *testing.T) {
 			name:           "некорректный id в URL",
 			url:            "/subscriptions/abc",
 			mockID:         0,
+			userUID:        "user123",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"status":"Error","error":"failed to decode id from url"}`,
 		},
 		{
-			name:   "ошибка сервиса чтения",
-			url:    "/subscriptions/777",
-			mockID: 777,
+			name:    "ошибка сервиса чтения",
+			url:     "/subscriptions/777",
+			mockID:  777,
+			userUID: "user123",
 			setupMock: func(m *MockService) {
-				m.On("ReadEntry", mock.Anything, 777).Return(nil, errors.New("db error"))
+				m.On("ReadEntry", mock.Anything, "user123", 777).Return(nil, errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"could not read subscription"}`,
 		},
+		{
+			name:    "клиент отменил запрос",
+			url:     "/subscriptions/777",
+			mockID:  777,
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ReadEntry", mock.Anything, "user123", 777).Return(nil, fmt.Errorf("storage.ReadEntry: %w", context.Canceled))
+			},
+			expectedStatus: response.StatusClientClosedRequest,
+			expectedBody:   `{"status":"Error","error":"request canceled"}`,
+		},
+		{
+			name:    "истек срок запроса",
+			url:     "/subscriptions/777",
+			mockID:  777,
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ReadEntry", mock.Anything, "user123", 777).Return(nil, fmt.Errorf("storage.ReadEntry: %w", context.DeadlineExceeded))
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   `{"status":"Error","error":"request timed out"}`,
+		},
+		{
+			name:    "подписка не найдена",
+			url:     "/subscriptions/404",
+			mockID:  404,
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("ReadEntry", mock.Anything, "user123", 404).Return(nil, models.ErrSubscriptionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"subscription not found"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			url:            "/subscriptions/123",
+			mockID:         123,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,7 +146,8 @@ func TestReadHandler(t *testing.T) {
 			// Устанавливаем URL params с помощью роутера chi
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", strings.TrimPrefix(tt.url, "/subscriptions/"))
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID}))
 
 			w := httptest.NewRecorder()
 
@@ -103,3 +161,153 @@ func TestReadHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestReadHandler_Envelope(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mockService := new(MockService)
+	mockService.On("ReadEntry", mock.Anything, "user123", 5).Return(&models.Entry{ID: 5, ServiceName: "Netflix"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions/5", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: "user123"}))
+	w := httptest.NewRecorder()
+
+	New(logger, mockService).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var envelope map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.JSONEq(t, `"OK"`, string(envelope["status"]))
+
+	var data map[string]models.Entry
+	require.NoError(t, json.Unmarshal(envelope["data"], &data))
+	assert.Equal(t, "Netflix", data["entry"].ServiceName)
+	assert.Equal(t, 5, data["entry"].ID)
+}
+
+func TestReadHandler_ETag(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	entry := &models.Entry{ID: 5, ServiceName: "Netflix", Price: 500, CounterMonths: 12}
+	serve := func(e *models.Entry, ifNoneMatch string) *httptest.ResponseRecorder {
+		mockService := new(MockService)
+		mockService.On("ReadEntry", mock.Anything, "user123", 5).Return(e, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions/5", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "5")
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: "user123"}))
+		w := httptest.NewRecorder()
+
+		New(logger, mockService).ServeHTTP(w, req)
+		mockService.AssertExpectations(t)
+		return w
+	}
+
+	first := serve(entry, "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.True(t, strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`), "etag must be quoted, got %s", etag)
+	assert.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"))
+
+	t.Run("совпадающий If-None-Match", func(t *testing.T) {
+		w := serve(entry, etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("слабый ETag и список значений", func(t *testing.T) {
+		w := serve(entry, `"other", W/`+etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("подписка изменилась", func(t *testing.T) {
+		changed := *entry
+		changed.Price = 600
+		w := serve(&changed, etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `"ServiceName":"Netflix"`)
+	})
+}
+
+// ownedService хранит подписки с владельцами и, как сервис подписок, не отличает
+// чужую подписку от несуществующей
+type ownedService map[int]string
+
+func (s ownedService) ReadEntry(_ context.Context, userUID string, id int) (*models.Entry, error) {
+	owner, ok := s[id]
+	if !ok || owner != userUID {
+		return nil, models.ErrSubscriptionNotFound
+	}
+	return &models.Entry{ID: id, ServiceName: "Netflix", UserUID: owner}, nil
+}
+
+func (s ownedService) ReadAnyEntry(_ context.Context, id int) (*models.Entry, error) {
+	owner, ok := s[id]
+	if !ok {
+		return nil, models.ErrSubscriptionNotFound
+	}
+	return &models.Entry{ID: id, ServiceName: "Netflix", UserUID: owner}, nil
+}
+
+func TestReadHandler_ForeignSubscription(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	handler := New(logger, ownedService{7: "owner"})
+
+	get := func(userUID, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: userUID}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("owner", "7").Code)
+
+	foreign := get("intruder", "7")
+	missing := get("intruder", "8")
+	assert.Equal(t, http.StatusNotFound, foreign.Code)
+	assert.Equal(t, missing.Code, foreign.Code)
+	assert.Equal(t, missing.Body.String(), foreign.Body.String(), "чужая подписка неотличима от несуществующей")
+	assert.Empty(t, foreign.Header().Get("ETag"))
+}
+
+func TestReadHandler_AdminReadsForeignSubscription(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	handler := New(logger, ownedService{7: "owner"})
+
+	get := func(user middlewarectx.UserInfo, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(middlewarectx.SetUser(ctx, user))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	admin := middlewarectx.UserInfo{UID: "admin-uid", Role: "admin"}
+	w := get(admin, "7")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp response.OKResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	entry := resp.Data.(map[string]any)["entry"].(map[string]any)
+	assert.Equal(t, "owner", entry["UserUID"])
+
+	assert.Equal(t, http.StatusNotFound, get(admin, "8").Code)
+	assert.Equal(t, http.StatusNotFound, get(middlewarectx.UserInfo{UID: "intruder", Role: "user"}, "7").Code)
+}
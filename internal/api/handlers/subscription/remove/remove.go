@@ -8,6 +8,7 @@ package remove
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -16,8 +17,10 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // Handler обрабатывает HTTP-запросы на удаление подписки по идентификатору.
@@ -28,7 +31,9 @@ type Handler struct {
 
 // Service описывает интерфейс бизнес-логики удаления подписки.
 type Service interface {
-	RemoveEntry(ctx context.Context, id int) (int, error)
+	RemoveEntry(ctx context.Context, userUID string, id int) (int, error)
+	// RemoveAnyEntry удаляет подписку любого пользователя; вызывается только для администратора.
+	RemoveAnyEntry(ctx context.Context, id int) (int, error)
 }
 
 // New создает новый Handler с переданным логгером и сервисом.
@@ -41,13 +46,14 @@ func New(log *slog.Logger, service Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Удалить подписку по ID
-// @Description Удаляет подписку пользователя по её идентификатору. Возвращает количество удалённых записей.
+// @Description Удаляет подписку пользователя по её идентификатору. Возвращает количество удалённых записей. Чужая подписка возвращается как несуществующая (404); администратор удаляет подписку любого пользователя.
 // @Tags Subscriptions
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID подписки"
 // @Success 200 {object} map[string]any "Подписка успешно удалена"
 // @Failure 400 {object} response.ErrorResponse "Некорректный ID"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
 // @Failure 404 {object} response.ErrorResponse "Подписка не найдена"
 // @Failure 500 {object} response.ErrorResponse "Ошибка при удалении"
 // @Router /subscriptions/{id} [delete]
@@ -59,6 +65,15 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
+	user := middlewarectx.GetUser(r.Context())
+	userUID := user.UID
+	if userUID == "" {
+		log.Error("user not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -68,8 +83,22 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.service.RemoveEntry(r.Context(), id)
+	var res int
+	if user.Role == "admin" {
+		res, err = h.service.RemoveAnyEntry(r.Context(), id)
+	} else {
+		res, err = h.service.RemoveEntry(r.Context(), userUID, id)
+	}
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		log.Info("subscription not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to delete subscription", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("failed to delete subscription"))
@@ -13,6 +13,9 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // MockService реализует интерфейс remove.Service
@@ -20,7 +23,12 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) RemoveEntry(ctx context.Context, id int) (int, error) {
+func (m *MockService) RemoveEntry(ctx context.Context, userUID string, id int) (int, error) {
+	args := m.Called(ctx, userUID, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) RemoveAnyEntry(ctx context.Context, id int) (int, error) {
 	args := m.Called(ctx, id)
 	return args.Int(0), args.Error(1)
 }
@@ -32,16 +40,18 @@ func TestRemoveHandler(t *testing.T) {
 		name           string
 		url            string
 		mockID         int
+		userUID        string
 		setupMock      func(*MockService)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
-			name:   "успешное удаление",
-			url:    "/subscriptions/123",
-			mockID: 123,
+			name:    "успешное удаление",
+			url:     "/subscriptions/123",
+			mockID:  123,
+			userUID: "user123",
 			setupMock: func(m *MockService) {
-				m.On("RemoveEntry", mock.Anything, 123).Return(1, nil)
+				m.On("RemoveEntry", mock.Anything, "user123", 123).Return(1, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"deleted_count":1`,
@@ -50,20 +60,41 @@ func TestRemoveHandler(t *testing.T) {
 			name:           "некорректный id",
 			url:            "/subscriptions/abc",
 			mockID:         0,
+			userUID:        "user123",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"status":"Error","error":"invalid id"}`,
 		},
 		{
-			name:   "ошибка сервиса",
-			url:    "/subscriptions/777",
-			mockID: 777,
+			name:    "ошибка сервиса",
+			url:     "/subscriptions/777",
+			mockID:  777,
+			userUID: "user123",
 			setupMock: func(m *MockService) {
-				m.On("RemoveEntry", mock.Anything, 777).Return(0, errors.New("db error"))
+				m.On("RemoveEntry", mock.Anything, "user123", 777).Return(0, errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"failed to delete subscription"}`,
 		},
+		{
+			name:    "подписка не найдена",
+			url:     "/subscriptions/404",
+			mockID:  404,
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("RemoveEntry", mock.Anything, "user123", 404).Return(0, models.ErrSubscriptionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"subscription not found"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			url:            "/subscriptions/123",
+			mockID:         123,
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -77,7 +108,8 @@ func TestRemoveHandler(t *testing.T) {
 			// Устанавливаем URL param для ID
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", strings.TrimPrefix(tt.url, "/subscriptions/"))
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: tt.userUID}))
 
 			w := httptest.NewRecorder()
 
@@ -91,3 +123,75 @@ func TestRemoveHandler(t *testing.T) {
 		})
 	}
 }
+
+// ownedService хранит подписки с владельцами и, как сервис подписок, удаляет только
+// подписку владельца, не отличая чужую подписку от несуществующей
+type ownedService map[int]string
+
+func (s ownedService) RemoveEntry(_ context.Context, userUID string, id int) (int, error) {
+	owner, ok := s[id]
+	if !ok || owner != userUID {
+		return 0, models.ErrSubscriptionNotFound
+	}
+	delete(s, id)
+	return 1, nil
+}
+
+func (s ownedService) RemoveAnyEntry(_ context.Context, id int) (int, error) {
+	if _, ok := s[id]; !ok {
+		return 0, models.ErrSubscriptionNotFound
+	}
+	delete(s, id)
+	return 1, nil
+}
+
+func TestRemoveHandler_ForeignSubscription(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := ownedService{7: "owner"}
+	handler := New(logger, service)
+
+	remove := func(userUID, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(middlewarectx.SetUser(ctx, middlewarectx.UserInfo{UID: userUID}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	foreign := remove("intruder", "7")
+	missing := remove("intruder", "8")
+	assert.Equal(t, http.StatusNotFound, foreign.Code)
+	assert.Equal(t, missing.Body.String(), foreign.Body.String(), "чужая подписка неотличима от несуществующей")
+	assert.Contains(t, service, 7, "чужая подписка не удаляется")
+
+	assert.Equal(t, http.StatusOK, remove("owner", "7").Code)
+	assert.NotContains(t, service, 7)
+}
+
+func TestRemoveHandler_AdminRemovesForeignSubscription(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := ownedService{7: "owner"}
+	handler := New(logger, service)
+
+	remove := func(user middlewarectx.UserInfo, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(middlewarectx.SetUser(ctx, user))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, remove(middlewarectx.UserInfo{UID: "intruder", Role: "user"}, "7").Code)
+	assert.Contains(t, service, 7)
+
+	admin := middlewarectx.UserInfo{UID: "admin-uid", Role: "admin"}
+	assert.Equal(t, http.StatusOK, remove(admin, "7").Code)
+	assert.NotContains(t, service, 7)
+	assert.Equal(t, http.StatusNotFound, remove(admin, "7").Code)
+}
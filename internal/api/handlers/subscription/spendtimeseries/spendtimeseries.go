@@ -0,0 +1,100 @@
+// Package spendtimeseries реализует HTTP-обработчик для получения помесячной стоимости подписок.
+//
+// Handler возвращает стоимость подписок текущего пользователя за последние месяцы
+// в виде ряда [{month, total}], пригодного для построения графика.
+package spendtimeseries
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Границы параметра months.
+const (
+	defaultMonths = 12
+	maxMonths     = 36
+)
+
+// Handler обрабатывает запросы на получение помесячной стоимости подписок.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики подписок
+}
+
+// Service описывает интерфейс расчета помесячной стоимости подписок.
+type Service interface {
+	SpendTimeSeries(ctx context.Context, userUID string, months int) ([]models.MonthlySpend, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить помесячную стоимость подписок
+// @Description Возвращает стоимость подписок текущего пользователя за последние months месяцев, включая текущий, по одному значению на месяц в хронологическом порядке. Подписка учитывается в каждом месяце своего срока по цене, действовавшей на начало месяца, в том числе если сейчас она неактивна; отмененные подписки не учитываются, месяцы без списаний возвращаются с нулем.
+// @Tags Subscriptions
+// @Produce  json
+// @Param months query int false "Количество месяцев, от 1 до 36" default(12)
+// @Success 200 {object} response.OKResponse{data=[]models.MonthlySpend} "Помесячная стоимость подписок"
+// @Failure 400 {object} response.ErrorResponse "Некорректный параметр months"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при расчете стоимости"
+// @Router /me/spend/timeseries [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.spendtimeseries"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	months := defaultMonths
+	if raw := r.URL.Query().Get("months"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxMonths {
+			log.Warn("invalid months parameter", slog.String("months", raw))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("months must be an integer between 1 and 36"))
+			return
+		}
+		months = n
+	}
+
+	series, err := h.service.SpendTimeSeries(r.Context(), userUID, months)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to build spend time series", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get spend time series"))
+		return
+	}
+
+	log.Info("spend time series built", slog.Int("months", months))
+	response.OK(w, series)
+}
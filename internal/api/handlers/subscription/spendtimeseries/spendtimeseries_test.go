@@ -0,0 +1,121 @@
+package spendtimeseries
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс spendtimeseries.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) SpendTimeSeries(ctx context.Context, userUID string, months int) ([]models.MonthlySpend, error) {
+	args := m.Called(ctx, userUID, months)
+	if res := args.Get(0); res != nil {
+		return res.([]models.MonthlySpend), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestSpendTimeSeriesHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	series := []models.MonthlySpend{{Month: "2025-05", Total: 500}, {Month: "2025-06", Total: 800}}
+
+	tests := []struct {
+		name           string
+		userUID        string
+		query          string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "ряд за указанное количество месяцев",
+			userUID: "user123",
+			query:   "?months=2",
+			setupMock: func(m *MockService) {
+				m.On("SpendTimeSeries", mock.Anything, "user123", 2).Return(series, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":[{"month":"2025-05","total":500},{"month":"2025-06","total":800}]}`,
+		},
+		{
+			name:    "по умолчанию 12 месяцев",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("SpendTimeSeries", mock.Anything, "user123", 12).Return(series, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"OK","data":[{"month":"2025-05","total":500},{"month":"2025-06","total":800}]}`,
+		},
+		{
+			name:           "months не число",
+			userUID:        "user123",
+			query:          "?months=abc",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"months must be an integer between 1 and 36"}`,
+		},
+		{
+			name:           "months равен нулю",
+			userUID:        "user123",
+			query:          "?months=0",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"months must be an integer between 1 and 36"}`,
+		},
+		{
+			name:           "months больше максимума",
+			userUID:        "user123",
+			query:          "?months=37",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"months must be an integer between 1 and 36"}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("SpendTimeSeries", mock.Anything, "user123", 12).Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to get spend time series"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+			handler := New(logger, mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/spend/timeseries"+tt.query, nil)
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
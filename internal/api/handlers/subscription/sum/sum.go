@@ -2,6 +2,7 @@
 //
 // Handler принимает JSON-запрос с фильтром, валидирует его, извлекает имя пользователя из контекста,
 // вызывает бизнес-логику подсчёта суммы через сервис и возвращает результат в JSON-формате.
+// С параметром ?detailed=true вместе с суммой возвращается вклад каждой подписки.
 //
 // В случае ошибок формируются соответствующие HTTP-ответы с описанием проблемы.
 package sum
@@ -9,8 +10,10 @@ package sum
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
@@ -33,7 +36,8 @@ type Handler struct {
 
 // Service описывает интерфейс бизнес-логики подсчёта суммы подписок с фильтрами.
 type Service interface {
-	CountSumWithFilter(ctx context.Context, username string, req models.DummyFilterSum) (float64, error)
+	CountSumWithFilter(ctx context.Context, userUID string, req models.DummyFilterSum) (float64, error)
+	CountSumDetailed(ctx context.Context, userUID string, req models.DummyFilterSum) (*models.SumBreakdown, error)
 }
 
 // New создаёт новый Handler с переданным логгером и сервисом подсчёта.
@@ -52,8 +56,9 @@ func New(log *slog.Logger, service Service) *Handler {
 // @Accept  json
 // @Produce  json
 // @Param request body models.DummyFilterSum true "Фильтры для подсчёта суммы"
+// @Param detailed query bool false "Вернуть вклад каждой подписки в сумму"
 // @Success 200 {object} map[string]any "Успешный расчёт суммы"
-// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON или параметр detailed"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
 // @Failure 422 {object} response.ErrorResponse "Ошибка валидации фильтра"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при вычислении суммы"
@@ -66,6 +71,17 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
+	detailed := false
+	if raw := r.URL.Query().Get("detailed"); raw != "" {
+		var err error
+		if detailed, err = strconv.ParseBool(raw); err != nil {
+			log.Error("invalid detailed parameter", slog.String("detailed", raw))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid detailed parameter"))
+			return
+		}
+	}
+
 	var req models.DummyFilterSum
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error("failed to decode request body", sl.Err(err))
@@ -81,16 +97,49 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	username, ok := r.Context().Value(middlewarectx.User).(string)
-	if !ok || username == "" {
-		log.Error("username not found in context")
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
 		return
 	}
 
-	sum, err := h.service.CountSumWithFilter(r.Context(), username, req)
+	if detailed {
+		breakdown, err := h.service.CountSumDetailed(r.Context(), userUID, req)
+		if errors.Is(err, models.ErrInvalidStartDate) {
+			log.Error("invalid start date", sl.Err(err))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			if response.ContextError(w, log, err) {
+				return
+			}
+			log.Error("failed to calculate sum", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("could not calculate sum"))
+			return
+		}
+
+		log.Info("success to calculate detailed sum", slog.Any("sum", breakdown.Total),
+			slog.Int("subscriptions", len(breakdown.Subscriptions)))
+		render.JSON(w, r, response.OKWithData(breakdown))
+		return
+	}
+
+	sum, err := h.service.CountSumWithFilter(r.Context(), userUID, req)
+	if errors.Is(err, models.ErrInvalidStartDate) {
+		log.Error("invalid start date", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to calculate sum", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("could not calculate sum"))
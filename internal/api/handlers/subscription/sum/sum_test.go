@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -25,18 +26,26 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) CountSumWithFilter(ctx context.Context, username string, filter models.DummyFilterSum) (float64, error) {
-	args := m.Called(ctx, username, filter)
+func (m *MockService) CountSumWithFilter(ctx context.Context, userUID string, filter models.DummyFilterSum) (float64, error) {
+	args := m.Called(ctx, userUID, filter)
 	return args.Get(0).(float64), args.Error(1)
 }
 
+func (m *MockService) CountSumDetailed(ctx context.Context, userUID string, filter models.DummyFilterSum) (*models.SumBreakdown, error) {
+	args := m.Called(ctx, userUID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SumBreakdown), args.Error(1)
+}
+
 func TestCountSumHandler(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		username       string
+		userUID        string
 		setupMock      func(*MockService)
 		expectedStatus int
 		expectedBody   string
@@ -47,7 +56,7 @@ func TestCountSumHandler(t *testing.T) {
 				StartDate:     "",
 				CounterMonths: 0,
 			},
-			username:       "testuser",
+			userUID:        "user123",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   `{"status":"Error","error":"field StartDate is a required field, field CounterMonths is a required field"}`,
@@ -55,7 +64,7 @@ func TestCountSumHandler(t *testing.T) {
 		{
 			name:           "некорректный JSON",
 			requestBody:    "not a json",
-			username:       "testuser",
+			userUID:        "user123",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
@@ -63,7 +72,7 @@ func TestCountSumHandler(t *testing.T) {
 		{
 			name:           "нет авторизации",
 			requestBody:    models.DummyFilterSum{StartDate: "2024-01-01", CounterMonths: 6},
-			username:       "",
+			userUID:        "",
 			setupMock:      func(_ *MockService) {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
@@ -74,14 +83,28 @@ func TestCountSumHandler(t *testing.T) {
 				StartDate:     "2024-01-01",
 				CounterMonths: 6,
 			},
-			username: "testuser",
+			userUID: "user123",
 			setupMock: func(m *MockService) {
-				m.On("CountSumWithFilter", mock.Anything, "testuser", mock.Anything).
+				m.On("CountSumWithFilter", mock.Anything, "user123", mock.Anything).
 					Return(0.0, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"could not calculate sum"}`,
 		},
+		{
+			name: "некорректная дата начала",
+			requestBody: models.DummyFilterSum{
+				StartDate:     "01/2024",
+				CounterMonths: 6,
+			},
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("CountSumWithFilter", mock.Anything, "user123", mock.Anything).
+					Return(0.0, fmt.Errorf("%w: bad format", models.ErrInvalidStartDate))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid start date: bad format"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,7 +126,7 @@ func TestCountSumHandler(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/sum", bytes.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
 
-			ctx := context.WithValue(req.Context(), middlewarectx.User, tt.username)
+			ctx := context.WithValue(req.Context(), middlewarectx.UserUID, tt.userUID)
 			ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-id")
 			req = req.WithContext(ctx)
 
@@ -117,3 +140,56 @@ func TestCountSumHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCountSumHandler_Detailed(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	filter := models.DummyFilterSum{StartDate: "01-01-2024", CounterMonths: 6}
+
+	t.Run("детализация суммы по подпискам", func(t *testing.T) {
+		mockSvc := new(MockService)
+		mockSvc.On("CountSumDetailed", mock.Anything, "user123", filter).Return(&models.SumBreakdown{
+			Total: 4500,
+			Subscriptions: []models.SumContribution{
+				{ID: 1, ServiceName: "Netflix", Amount: 3000},
+				{ID: 2, ServiceName: "Spotify", Amount: 1500},
+			},
+		}, nil).Once()
+
+		body, err := json.Marshal(filter)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/sum?detailed=true", bytes.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, "user123"))
+		w := httptest.NewRecorder()
+
+		New(logger, mockSvc).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data models.SumBreakdown `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data.Subscriptions, 2)
+		var total float64
+		for _, c := range resp.Data.Subscriptions {
+			total += c.Amount
+		}
+		assert.Equal(t, resp.Data.Total, total)
+		assert.Equal(t, "Netflix", resp.Data.Subscriptions[0].ServiceName)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("некорректный параметр detailed", func(t *testing.T) {
+		mockSvc := new(MockService)
+		body, err := json.Marshal(filter)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/sum?detailed=maybe", bytes.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middlewarectx.UserUID, "user123"))
+		w := httptest.NewRecorder()
+
+		New(logger, mockSvc).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"status":"Error","error":"invalid detailed parameter"}`, w.Body.String())
+		mockSvc.AssertExpectations(t)
+	})
+}
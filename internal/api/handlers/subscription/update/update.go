@@ -9,6 +9,7 @@ package update
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -32,7 +33,7 @@ type Handler struct {
 
 // Service описывает интерфейс бизнес-логики обновления подписки.
 type Service interface {
-	UpdateEntry(ctx context.Context, req models.DummyEntry, id int, username string) (int, error)
+	UpdateEntry(ctx context.Context, req models.DummyEntry, id int, userUID, username string) (int, error)
 }
 
 // New создает новый Handler с переданными логгером и сервисом.
@@ -46,17 +47,18 @@ func New(log *slog.Logger, service Service) *Handler {
 
 // ServeHTTP godoc
 // @Summary Обновить подписку по ID
-// @Description Обновляет данные существующей подписки пользователя по идентификатору.
+// @Description Обновляет данные существующей подписки пользователя по идентификатору. Без notes и metadata в запросе они не меняются; пустая заметка и metadata: null удаляют их.
 // @Tags Subscriptions
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID подписки"
 // @Param request body models.DummyEntry true "Обновлённые данные подписки"
-// @Success 200 {object} map[string]any "Успешное обновление"
+// @Success 200 {object} response.OKResponse "Успешное обновление"
 // @Failure 400 {object} response.ErrorResponse "Некорректный ID или JSON"
 // @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
-// @Failure 422 {object} response.ErrorResponse "Ошибка валидации"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации, некорректное название сервиса, дата начала в недопустимом формате, срок подписки уже закончился, заметка длиннее 500 символов, метаданные не JSON-объект до 4 КБ или цена, срок или валюта вне допустимых ограничений"
 // @Failure 404 {object} response.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} response.ErrorResponse "Валюта отличается от валюты подписки без currency_changed или превышено число активных подписок на сервис"
 // @Failure 500 {object} response.ErrorResponse "Ошибка сервера при обновлении"
 // @Router /subscriptions/{id} [put]
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -77,7 +79,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Error("failed to decode request"))
 		return
 	}
-	log.Info("request body decoded", slog.Any("request", req))
+	log.Info("request body decoded", sl.Redact("request", req))
 
 	if err = h.validate.Struct(req); err != nil {
 		log.Error("validation failed", sl.Err(err))
@@ -85,10 +87,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
 		return
 	}
+
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		log.Error("invalid service name", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	req.ServiceName = serviceName
 	log.Info("all fields are validated")
 
-	username, ok := r.Context().Value(middlewarectx.User).(string)
-	if !ok || username == "" {
+	user := middlewarectx.GetUser(r.Context())
+	if user.Username == "" || user.UID == "" {
 		log.Error("username not found in context")
 		w.WriteHeader(http.StatusUnauthorized)
 		render.JSON(w, r, response.Error("unauthorized"))
@@ -103,8 +114,47 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	counter, err := h.service.UpdateEntry(r.Context(), req, id, username)
+	counter, err := h.service.UpdateEntry(r.Context(), req, id, user.UID, user.Username)
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		log.Info("subscription not found", slog.Int("id", id))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrInvalidStartDate) || errors.Is(err, models.ErrEndDateInPast) {
+		log.Info("invalid subscription period", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrInvalidNotes) || errors.Is(err, models.ErrInvalidMetadata) {
+		log.Error("invalid notes or metadata", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrEntryOutOfLimits) {
+		log.Error("subscription is out of limits", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrCurrencyMismatch) {
+		log.Info("currency mismatch", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrActiveLimitExceeded) {
+		log.Info("active subscriptions limit exceeded", slog.Int("id", id), sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
 	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
 		log.Error("failed to update subscription", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		render.JSON(w, r, response.Error("could not update subscription"))
@@ -112,7 +162,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info("success to update subscription", slog.Any("updated count", counter))
-	render.JSON(w, r, response.OKWithData(map[string]any{
+	response.OK(w, map[string]any{
 		"updated_count": counter,
-	}))
+	})
 }
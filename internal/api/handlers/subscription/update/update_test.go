@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -26,8 +27,8 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) UpdateEntry(ctx context.Context, req models.DummyEntry, id int, username string) (int, error) {
-	args := m.Called(ctx, req, id, username)
+func (m *MockService) UpdateEntry(ctx context.Context, req models.DummyEntry, id int, userUID, username string) (int, error) {
+	args := m.Called(ctx, req, id, userUID, username)
 	return args.Int(0), args.Error(1)
 }
 
@@ -54,7 +55,7 @@ func TestUpdateHandler(t *testing.T) {
 			},
 			username: "testuser",
 			setupMock: func(m *MockService) {
-				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "testuser").
+				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "user123", "testuser").
 					Return(1, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -83,6 +84,20 @@ func TestUpdateHandler(t *testing.T) {
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   `{"status":"Error","error":"field ServiceName is a required field, field Price is a required field, field StartDate is a required field, field CounterMonths is a required field"}`,
 		},
+		{
+			name: "название сервиса из пробелов",
+			url:  "/subscriptions/123",
+			requestBody: models.DummyEntry{
+				ServiceName:   "   ",
+				Price:         15,
+				StartDate:     "01-01-2024",
+				CounterMonths: 6,
+			},
+			username:       "testuser",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid service name: field ServiceName must not be empty"}`,
+		},
 		{
 			name: "отсутствует авторизация",
 			url:  "/subscriptions/123",
@@ -122,12 +137,64 @@ func TestUpdateHandler(t *testing.T) {
 			},
 			username: "testuser",
 			setupMock: func(m *MockService) {
-				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "testuser").
+				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "user123", "testuser").
 					Return(0, errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"could not update subscription"}`,
 		},
+		{
+			name: "подписка не найдена или принадлежит другому пользователю",
+			url:  "/subscriptions/404",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         15,
+				StartDate:     "01-01-2024",
+				CounterMonths: 6,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 404, "user123", "testuser").
+					Return(0, models.ErrSubscriptionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"status":"Error","error":"subscription not found"}`,
+		},
+		{
+			name: "срок подписки уже закончился",
+			url:  "/subscriptions/123",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         15,
+				StartDate:     "01-01-2020",
+				CounterMonths: 6,
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "user123", "testuser").
+					Return(0, models.ErrEndDateInPast)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"subscription end date must not be earlier than today"}`,
+		},
+		{
+			name: "валюта отличается от валюты подписки",
+			url:  "/subscriptions/123",
+			requestBody: models.DummyEntry{
+				ServiceName:   "Netflix",
+				Price:         15,
+				StartDate:     "01-01-2024",
+				CounterMonths: 6,
+				Currency:      "USD",
+			},
+			username: "testuser",
+			setupMock: func(m *MockService) {
+				m.On("UpdateEntry", mock.Anything, mock.AnythingOfType("models.DummyEntry"), 123, "user123", "testuser").
+					Return(0, fmt.Errorf("%w: subscription is billed in RUB, got USD", models.ErrCurrencyMismatch))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"status":"Error","error":"currency differs from subscription currency: subscription is billed in RUB, got USD"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +217,7 @@ func TestUpdateHandler(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 
 			ctx := context.WithValue(req.Context(), middlewarectx.User, tt.username)
+			ctx = context.WithValue(ctx, middlewarectx.UserUID, "user123")
 			ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-id")
 			req = req.WithContext(ctx)
 
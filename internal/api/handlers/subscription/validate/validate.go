@@ -0,0 +1,119 @@
+// Package validate реализует HTTP-обработчик проверки данных подписки без ее создания.
+//
+// Handler принимает те же данные, что и создание подписки, выполняет все проверки
+// и разбор даты начала и возвращает нормализованные данные. Данные в хранилище и кеш не записываются.
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на проверку данных подписки.
+type Handler struct {
+	log      *slog.Logger        // Логгер для записи информации и ошибок
+	service  Service             // Сервис бизнес-логики подписок
+	validate *validator.Validate // Валидатор структуры входящих данных
+}
+
+// Service описывает интерфейс бизнес-логики проверки данных подписки.
+type Service interface {
+	ValidateEntry(ctx context.Context, req models.DummyEntry) (*models.DummyEntry, error)
+}
+
+// New создает новый Handler с переданными логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:      log,
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Проверить данные подписки
+// @Description Выполняет те же проверки, что и создание подписки, и возвращает нормализованные данные без создания подписки.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param request body models.DummyEntry true "Данные подписки"
+// @Success 200 {object} response.OKResponse{data=models.DummyEntry} "Данные корректны"
+// @Failure 400 {object} response.ErrorResponse "Некорректный JSON"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 422 {object} response.ErrorResponse "Ошибка валидации, некорректные заметка или метаданные или цена, срок или валюта вне допустимых ограничений"
+// @Failure 500 {object} response.ErrorResponse "Ошибка сервера при проверке"
+// @Router /subscriptions/validate [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.validate"
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req models.DummyEntry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("failed to decode request", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
+		return
+	}
+
+	entry, err := h.service.ValidateEntry(r.Context(), req)
+	switch {
+	case errors.Is(err, models.ErrInvalidServiceName):
+		log.Error("invalid service name", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrInvalidStartDate):
+		log.Error("invalid start date", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrEntryOutOfLimits):
+		log.Error("subscription is out of limits", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrInvalidNotes), errors.Is(err, models.ErrInvalidMetadata):
+		log.Error("invalid notes or metadata", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	case errors.Is(err, models.ErrEndDateInPast):
+		log.Error("subscription already ended", sl.Err(err))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(models.ErrEndDateInPast.Error()))
+		return
+	case err != nil:
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to validate subscription", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("could not validate subscription"))
+		return
+	}
+
+	log.Info("subscription data is valid")
+	response.OK(w, entry)
+}
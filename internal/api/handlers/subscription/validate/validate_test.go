@@ -0,0 +1,121 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс validate.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ValidateEntry(ctx context.Context, req models.DummyEntry) (*models.DummyEntry, error) {
+	args := m.Called(ctx, req)
+	if res := args.Get(0); res != nil {
+		return res.(*models.DummyEntry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestValidateHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	valid := models.DummyEntry{ServiceName: " Netflix ", Price: 500, StartDate: "01-01-2030", CounterMonths: 12}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "данные корректны",
+			requestBody: valid,
+			setupMock: func(m *MockService) {
+				m.On("ValidateEntry", mock.Anything, valid).Return(&models.DummyEntry{
+					ServiceName:   "Netflix",
+					Price:         500,
+					StartDate:     "01-01-2030",
+					CounterMonths: 12,
+					IsActive:      true,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":{"service_name":"Netflix","price":500,"start_date":"01-01-2030",` +
+				`"counter_months":12,"is_active":true}}`,
+		},
+		{
+			name:           "ошибка валидации",
+			requestBody:    models.DummyEntry{ServiceName: "Netflix", StartDate: "01-01-2030", CounterMonths: 12},
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"field Price is a required field"}`,
+		},
+		{
+			name:        "некорректная дата",
+			requestBody: models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2030/01/01", CounterMonths: 12},
+			setupMock: func(m *MockService) {
+				m.On("ValidateEntry", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: parse error", models.ErrInvalidStartDate)).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid start date: parse error"}`,
+		},
+		{
+			name:        "некорректное название сервиса",
+			requestBody: models.DummyEntry{ServiceName: "Net\tflix", Price: 500, StartDate: "01-01-2030", CounterMonths: 12},
+			setupMock: func(m *MockService) {
+				m.On("ValidateEntry", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: field ServiceName must not contain control characters", models.ErrInvalidServiceName)).Once()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"status":"Error","error":"invalid service name: field ServiceName must not contain control characters"}`,
+		},
+		{
+			name:           "некорректный JSON",
+			requestBody:    "not a json",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"status":"Error","error":"invalid request body"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			var body []byte
+			if s, ok := tt.requestBody.(string); ok {
+				body = []byte(s)
+			} else {
+				var err error
+				body, err = json.Marshal(tt.requestBody)
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/subscriptions/validate", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package yearlyestimate реализует HTTP-обработчик оценки годовой стоимости подписок пользователя.
+//
+// Handler возвращает стоимость всех активных подписок текущего пользователя за год
+// отдельно по каждой валюте подписок.
+package yearlyestimate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на оценку годовой стоимости подписок.
+type Handler struct {
+	log     *slog.Logger // Логгер для записи информации и ошибок
+	service Service      // Сервис бизнес-логики подписок
+}
+
+// Service описывает интерфейс бизнес-логики оценки годовой стоимости подписок.
+type Service interface {
+	EstimateYearlyCost(ctx context.Context, userUID string) ([]models.YearlyEstimate, error)
+}
+
+// New создает новый Handler с переданным логгером и сервисом.
+func New(log *slog.Logger, service Service) *Handler {
+	return &Handler{
+		log:     log,
+		service: service,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Оценить годовую стоимость подписок
+// @Description Возвращает стоимость активных подписок текущего пользователя за год (ежемесячная цена × 12) с количеством подписок отдельно по каждой валюте. Подписки в разных валютах не суммируются.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.OKResponse{data=[]models.YearlyEstimate} "Годовая стоимость по валютам"
+// @Failure 401 {object} response.ErrorResponse "Пользователь не авторизован"
+// @Failure 500 {object} response.ErrorResponse "Ошибка при расчете стоимости"
+// @Router /me/yearly-estimate [get]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.subscription.yearlyestimate"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	userUID := middlewarectx.GetUser(r.Context()).UID
+	if userUID == "" {
+		log.Error("user UID not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, response.Error("unauthorized"))
+		return
+	}
+
+	res, err := h.service.EstimateYearlyCost(r.Context(), userUID)
+	if err != nil {
+		if response.ContextError(w, log, err) {
+			return
+		}
+		log.Error("failed to estimate yearly cost", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to estimate yearly cost"))
+		return
+	}
+
+	log.Info("estimated yearly cost", slog.Int("currencies", len(res)))
+	response.OK(w, res)
+}
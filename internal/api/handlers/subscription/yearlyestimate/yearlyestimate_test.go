@@ -0,0 +1,88 @@
+package yearlyestimate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// MockService реализует интерфейс yearlyestimate.Service
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) EstimateYearlyCost(ctx context.Context, userUID string) ([]models.YearlyEstimate, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.([]models.YearlyEstimate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestYearlyEstimateHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		userUID        string
+		setupMock      func(*MockService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "годовая стоимость по валютам",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("EstimateYearlyCost", mock.Anything, "user123").Return([]models.YearlyEstimate{
+					{Currency: "RUB", Count: 2, Total: 9600},
+					{Currency: "USD", Count: 1, Total: 120},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"status":"OK","data":[{"currency":"RUB","count":2,"total":9600},` +
+				`{"currency":"USD","count":1,"total":120}]}`,
+		},
+		{
+			name:           "пользователь не авторизован",
+			setupMock:      func(_ *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"unauthorized"}`,
+		},
+		{
+			name:    "ошибка сервиса",
+			userUID: "user123",
+			setupMock: func(m *MockService) {
+				m.On("EstimateYearlyCost", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"status":"Error","error":"failed to estimate yearly cost"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/me/yearly-estimate", nil)
+			req = req.WithContext(middlewarectx.SetUser(req.Context(), middlewarectx.UserInfo{UID: tt.userUID}))
+			w := httptest.NewRecorder()
+
+			New(logger, mockService).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
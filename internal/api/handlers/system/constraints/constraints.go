@@ -0,0 +1,39 @@
+// Package constraints реализует HTTP-обработчик для получения ограничений входных данных.
+//
+// Handler возвращает допустимые форматы дат, пределы цены и срока подписки, поддерживаемые
+// валюты и размеры страниц, заданные в конфиге сервера, чтобы клиенты могли строить формы
+// по тем же правилам, которые проверяет сервер. Эндпоинт доступен без аутентификации.
+package constraints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// Handler обрабатывает запросы на получение ограничений входных данных.
+type Handler struct {
+	log         *slog.Logger       // Логгер для записи информации и ошибок
+	constraints models.Constraints // Ограничения из конфига сервера
+}
+
+// New создает новый Handler с переданным логгером и ограничениями.
+func New(log *slog.Logger, constraints models.Constraints) *Handler {
+	return &Handler{
+		log:         log,
+		constraints: constraints,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить ограничения входных данных
+// @Description Возвращает допустимые форматы start_date (в нотации Go time.Parse), минимальные и максимальные цену и срок подписки, поддерживаемые валюты, валюту по умолчанию и размеры страниц списков.
+// @Tags System
+// @Produce  json
+// @Success 200 {object} response.OKResponse "Ограничения входных данных в поле data"
+// @Router /meta/constraints [get]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	response.OK(w, h.constraints)
+}
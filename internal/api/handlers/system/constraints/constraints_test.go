@@ -0,0 +1,69 @@
+package constraints
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestConstraintsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		constraints  models.Constraints
+		expectedBody string
+	}{
+		{
+			name: "настроенные ограничения",
+			constraints: models.Constraints{
+				DateFormats:      []string{"2006-01-02", "02.01.2006"},
+				MinPrice:         1,
+				MaxPrice:         50000,
+				MinCounterMonths: 1,
+				MaxCounterMonths: 24,
+				Currencies:       []string{"RUB", "KZT"},
+				DefaultCurrency:  "RUB",
+				PageSizeDefault:  20,
+				PageSizeMax:      200,
+			},
+			expectedBody: `{"status":"OK","data":{"date_formats":["2006-01-02","02.01.2006"],
+				"min_price":1,"max_price":50000,"min_counter_months":1,"max_counter_months":24,
+				"supported_currencies":["RUB","KZT"],"default_currency":"RUB",
+				"page_size_default":20,"page_size_max":200}}`,
+		},
+		{
+			name: "без верхних пределов и списка валют",
+			constraints: models.Constraints{
+				DateFormats:      []string{"2006-01-02"},
+				MinPrice:         1,
+				MinCounterMonths: 1,
+				Currencies:       []string{},
+				DefaultCurrency:  "USD",
+				PageSizeDefault:  10,
+				PageSizeMax:      100,
+			},
+			expectedBody: `{"status":"OK","data":{"date_formats":["2006-01-02"],
+				"min_price":1,"min_counter_months":1,"supported_currencies":[],"default_currency":"USD",
+				"page_size_default":10,"page_size_max":100}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/meta/constraints", nil)
+			w := httptest.NewRecorder()
+
+			New(logger, tt.constraints).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
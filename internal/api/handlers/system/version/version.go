@@ -0,0 +1,40 @@
+// Package version реализует HTTP-обработчик для получения сведений о сборке.
+//
+// Handler возвращает версию, git commit и время сборки приложения,
+// подставленные через ldflags. Эндпоинт доступен без аутентификации.
+package version
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo"
+)
+
+// Handler обрабатывает запросы на получение сведений о сборке.
+type Handler struct {
+	log  *slog.Logger   // Логгер для записи информации и ошибок
+	info buildinfo.Info // Сведения о сборке
+}
+
+// New создает новый Handler с переданным логгером и сведениями о сборке.
+func New(log *slog.Logger, info buildinfo.Info) *Handler {
+	return &Handler{
+		log:  log,
+		info: info,
+	}
+}
+
+// ServeHTTP godoc
+// @Summary Получить сведения о сборке
+// @Description Возвращает версию, git commit и время сборки приложения.
+// @Tags System
+// @Produce  json
+// @Success 200 {object} buildinfo.Info "Сведения о сборке"
+// @Router /version [get]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, h.info)
+}
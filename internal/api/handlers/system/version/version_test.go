@@ -0,0 +1,62 @@
+package version
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo"
+)
+
+func TestVersionHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	oldVersion, oldCommit, oldBuildTime := buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime
+	defer func() {
+		buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime = oldVersion, oldCommit, oldBuildTime
+	}()
+
+	tests := []struct {
+		name         string
+		setup        func()
+		expectedBody string
+	}{
+		{
+			name: "значения из ldflags",
+			setup: func() {
+				buildinfo.Version = "v1.2.3"
+				buildinfo.Commit = "abc1234"
+				buildinfo.BuildTime = "2024-01-01T00:00:00Z"
+			},
+			expectedBody: `{"version":"v1.2.3","commit":"abc1234","build_time":"2024-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "значения по умолчанию",
+			setup: func() {
+				buildinfo.Version = oldVersion
+				buildinfo.Commit = oldCommit
+				buildinfo.BuildTime = oldBuildTime
+			},
+			expectedBody: `{"version":"dev","commit":"unknown","build_time":"unknown"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/version", nil)
+			w := httptest.NewRecorder()
+
+			New(logger, buildinfo.Get()).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
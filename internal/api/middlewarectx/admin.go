@@ -0,0 +1,27 @@
+package middlewarectx
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+)
+
+// AdminOnlyMiddleware создает middleware, пропускающий только пользователей с ролью admin.
+//
+// Должен подключаться после JWTMiddleware, который кладёт роль в контекст.
+func AdminOnlyMiddleware(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := GetUser(r.Context()).Role
+			if role != "admin" {
+				log.Error("access denied: admin role required")
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response.Error("access denied"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middlewarectx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminOnlyMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           any
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "success - admin role",
+			role:           "admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "success",
+		},
+		{
+			name:           "forbidden - user role",
+			role:           "user",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":"Error","error":"access denied"}` + "\n",
+		},
+		{
+			name:           "forbidden - missing role",
+			role:           nil,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"status":"Error","error":"access denied"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				if _, err := w.Write([]byte("success")); err != nil {
+					t.Errorf("failed to write response: %v", err)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+			if tt.role != nil {
+				req = req.WithContext(context.WithValue(req.Context(), Role, tt.role))
+			}
+			w := httptest.NewRecorder()
+
+			AdminOnlyMiddleware(newNoopLoggerCheck())(testHandler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -3,6 +3,8 @@
 // JWTMiddleware проверяет наличие и валидность JWT токена в заголовке Authorization,
 // валидирует его через gRPC-сервис, и в случае успеха добавляет в контекст
 // имя пользователя и роль для дальнейшего использования в обработчиках.
+// Если gRPC-сервис недоступен и задан TokenParser, подпись и срок действия токена
+// проверяются локально общим секретным ключом.
 //
 // В случае ошибки проверки возвращает HTTP 401 Unauthorized с сообщением об ошибке.
 package middlewarectx
@@ -16,32 +18,31 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/jwt"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 )
 
-// Key тип для ключей контекста HTTP-запроса.
-type Key string
-
-const (
-	// User — ключ для имени пользователя в контексте
-	User Key = "username"
-	// Role — ключ для роли пользователя в контексте
-	Role Key = "role"
-	// UserUID — ключ для идентификатора пользователя в контексте
-	UserUID Key = "user_uid"
-)
-
 // Service описывает интерфейс сервиса для валидации JWT токена.
 type AuthService interface {
 	ValidateToken(ctx context.Context, token string) (*authpb.ValidateTokenResponse, error)
 }
 
+// TokenParser проверяет подпись и срок действия JWT без обращения к сервису авторизации.
+type TokenParser interface {
+	ParseToken(token string) (*jwt.CustomClaims, error)
+}
+
 // JWTMiddleware возвращает HTTP middleware, который проверяет JWT в заголовке Authorization.
 //
 // Если токен валиден, добавляет имя пользователя и роль в контекст запроса,
 // иначе возвращает ошибку с HTTP статусом 401 Unauthorized.
-func JWTMiddleware(log *slog.Logger, authClient AuthService) func(http.Handler) http.Handler {
+// Если сервис авторизации недоступен, а fallback не nil, токен проверяется через fallback.
+// Отказ самого сервиса авторизации (недействительный токен) локальной проверкой не обходится.
+func JWTMiddleware(log *slog.Logger, authClient AuthService, fallback TokenParser) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -61,16 +62,48 @@ func JWTMiddleware(log *slog.Logger, authClient AuthService) func(http.Handler)
 			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
 			resp, err := authClient.ValidateToken(r.Context(), tokenStr)
+			if err != nil && fallback != nil && authUnavailable(err) {
+				log.Warn("auth service unavailable, verifying token locally", sl.Err(err))
+				resp, err = validateLocally(fallback, tokenStr)
+			}
 			if err != nil || !resp.Valid {
 				log.Error("invalid or expired token", sl.Err(err))
 				w.WriteHeader(http.StatusUnauthorized)
 				render.JSON(w, r, response.Error("invalid or expired token"))
 				return
 			}
-			ctx := context.WithValue(r.Context(), User, resp.Username)
-			ctx = context.WithValue(ctx, Role, resp.Role)
-			ctx = context.WithValue(ctx, UserUID, resp.Useruid)
+			ctx := SetUser(r.Context(), UserInfo{
+				UID:      resp.Useruid,
+				Username: resp.Username,
+				Role:     resp.Role,
+			})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// authUnavailable сообщает, что сервис авторизации не ответил на запрос,
+// а не отклонил токен.
+func authUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateLocally проверяет токен через parser и возвращает ответ в формате сервиса авторизации.
+func validateLocally(parser TokenParser, token string) (*authpb.ValidateTokenResponse, error) {
+	claims, err := parser.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &authpb.ValidateTokenResponse{
+		Valid:    true,
+		Username: claims.Username,
+		Role:     claims.Role,
+		Useruid:  claims.UserUID,
+	}, nil
+}
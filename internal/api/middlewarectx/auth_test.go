@@ -7,11 +7,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/jwt"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
@@ -143,7 +148,7 @@ func TestJWTMiddleware(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			authClient := new(MockAuthClient)
 			logger := newNoopLoggerAuth()
-			middleware := JWTMiddleware(logger, authClient)
+			middleware := JWTMiddleware(logger, authClient, nil)
 
 			tt.setupMocks(authClient)
 
@@ -193,7 +198,7 @@ func TestJWTMiddleware(t *testing.T) {
 func TestJWTMiddleware_ContextValues(t *testing.T) {
 	authClient := new(MockAuthClient)
 	logger := newNoopLoggerAuth()
-	middleware := JWTMiddleware(logger, authClient)
+	middleware := JWTMiddleware(logger, authClient, nil)
 
 	authClient.On("ValidateToken", mock.Anything, "test_token").Return(&authpb.ValidateTokenResponse{
 		Valid:    true,
@@ -232,7 +237,7 @@ func TestJWTMiddleware_ContextValues(t *testing.T) {
 func TestJWTMiddleware_EmptyToken(t *testing.T) {
 	authClient := new(MockAuthClient)
 	logger := newNoopLoggerAuth()
-	middleware := JWTMiddleware(logger, authClient)
+	middleware := JWTMiddleware(logger, authClient, nil)
 
 	testHandler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
 		t.Error("Handler should not be called for invalid token")
@@ -250,3 +255,81 @@ func TestJWTMiddleware_EmptyToken(t *testing.T) {
 
 	authClient.AssertExpectations(t)
 }
+
+func TestJWTMiddleware_LocalFallback(t *testing.T) {
+	maker := jwt.NewJWTMaker("shared_secret", time.Hour)
+	token, err := maker.GenerateToken("testuser", "user", "user123")
+	require.NoError(t, err)
+	foreignToken, err := jwt.NewJWTMaker("other_secret", time.Hour).GenerateToken("testuser", "user", "user123")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		token          string
+		authErr        error
+		fallback       TokenParser
+		expectedStatus int
+	}{
+		{
+			name:           "auth service unavailable, token verified locally",
+			token:          token,
+			authErr:        status.Error(codes.Unavailable, "connection refused"),
+			fallback:       maker,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "auth service timed out, token verified locally",
+			token:          token,
+			authErr:        status.Error(codes.DeadlineExceeded, "deadline exceeded"),
+			fallback:       maker,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "auth service unavailable, token signed with another key",
+			token:          foreignToken,
+			authErr:        status.Error(codes.Unavailable, "connection refused"),
+			fallback:       maker,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "auth service rejected token, fallback is not used",
+			token:          token,
+			authErr:        status.Error(codes.Unauthenticated, "invalid token"),
+			fallback:       maker,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "auth service unavailable, fallback disabled",
+			token:          token,
+			authErr:        status.Error(codes.Unavailable, "connection refused"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authClient := new(MockAuthClient)
+			authClient.On("ValidateToken", mock.Anything, tt.token).Return(nil, tt.authErr).Once()
+
+			var user UserInfo
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user = GetUser(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			JWTMiddleware(newNoopLoggerAuth(), authClient, tt.fallback)(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, UserInfo{UID: "user123", Username: "testuser", Role: "user"}, user)
+			} else {
+				assert.JSONEq(t, `{"status":"Error","error":"invalid or expired token"}`, w.Body.String())
+			}
+			authClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middlewarectx
+
+import (
+	"net/http"
+)
+
+// MaxBodySizeMiddleware ограничивает размер тела запроса limit байтами.
+// При превышении лимита чтение тела возвращает ошибку *http.MaxBytesError,
+// и обработчик отвечает так же, как на некорректное тело. limit <= 0 отключает ограничение.
+func MaxBodySizeMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middlewarectx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          int64
+		body           string
+		expectedStatus int
+	}{
+		{name: "тело в пределах лимита", limit: 10, body: "0123456789", expectedStatus: http.StatusOK},
+		{name: "тело больше лимита", limit: 10, body: "0123456789A", expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "лимит отключен", limit: 0, body: strings.Repeat("a", 1024), expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := MaxBodySizeMiddleware(tt.limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.ReadAll(r.Body)
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
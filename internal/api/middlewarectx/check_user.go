@@ -2,6 +2,8 @@ package middlewarectx
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -16,11 +18,15 @@ type SubscriptionService interface {
 }
 
 // SubscriptionStatusMiddleware создает middleware для проверки статуса подписки пользователя.
+// Токен остается действительным до истечения срока и после удаления пользователя, поэтому
+// запрос пользователя, которого нет в базе или чей аккаунт удален либо ожидает удаления,
+// получает 401. Чтобы не читать базу на каждый запрос, subscriptionService обычно
+// оборачивается в UserCache.
 func SubscriptionStatusMiddleware(log *slog.Logger, subscriptionService SubscriptionService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userUID, ok := r.Context().Value(UserUID).(string)
-			if !ok || userUID == "" {
+			userUID := GetUser(r.Context()).UID
+			if userUID == "" {
 				log.Error("user identification missing")
 				w.WriteHeader(http.StatusUnauthorized)
 				render.JSON(w, r, response.Error("user identification missing"))
@@ -28,6 +34,12 @@ func SubscriptionStatusMiddleware(log *slog.Logger, subscriptionService Subscrip
 			}
 
 			model, err := subscriptionService.GetUser(r.Context(), userUID)
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Warn("user from token not found", slog.String("user_uid", userUID))
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("user not found"))
+				return
+			}
 			if err != nil {
 				log.Error("failed to get subscription status", sl.Err(err))
 				w.WriteHeader(http.StatusInternalServerError)
@@ -35,6 +47,13 @@ func SubscriptionStatusMiddleware(log *slog.Logger, subscriptionService Subscrip
 				return
 			}
 
+			if model.SubscriptionStatus == models.StatusDeleted || model.SubscriptionStatus == models.StatusPendingDeletion {
+				log.Error("account deleted, access denied")
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("account deleted"))
+				return
+			}
+
 			if model.SubscriptionStatus == "expired" {
 				log.Error("subscription expired, access denied", sl.Err(err))
 				w.WriteHeader(http.StatusForbidden)
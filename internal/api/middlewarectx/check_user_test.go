@@ -2,7 +2,9 @@ package middlewarectx
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -40,7 +42,7 @@ func TestSubscriptionStatusMiddleware(t *testing.T) {
 			name:    "success - active subscription",
 			userUID: "user123",
 			setupMocks: func(ss *MockSubscriptionService) {
-				ss.On("GetSubscriptionStatus", mock.Anything, "user123").Return("active", nil).Once()
+				ss.On("GetUser", mock.Anything, "user123").Return(&models.User{SubscriptionStatus: "active"}, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -48,7 +50,7 @@ func TestSubscriptionStatusMiddleware(t *testing.T) {
 			name:    "success - trial subscription",
 			userUID: "user456",
 			setupMocks: func(ss *MockSubscriptionService) {
-				ss.On("GetSubscriptionStatus", mock.Anything, "user456").Return("trial", nil).Once()
+				ss.On("GetUser", mock.Anything, "user456").Return(&models.User{SubscriptionStatus: "trial"}, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -56,11 +58,21 @@ func TestSubscriptionStatusMiddleware(t *testing.T) {
 			name:    "forbidden - expired subscription",
 			userUID: "user789",
 			setupMocks: func(ss *MockSubscriptionService) {
-				ss.On("GetSubscriptionStatus", mock.Anything, "user789").Return("expired", nil).Once()
+				ss.On("GetUser", mock.Anything, "user789").Return(&models.User{SubscriptionStatus: "expired"}, nil).Once()
 			},
 			expectedStatus: http.StatusForbidden,
 			expectedBody:   `{"status":"Error","error":"subscription expired, access denied"}` + "\n",
 		},
+		{
+			name:    "unauthorized - user deleted from database",
+			userUID: "user123",
+			setupMocks: func(ss *MockSubscriptionService) {
+				ss.On("GetUser", mock.Anything, "user123").
+					Return((*models.User)(nil), fmt.Errorf("storage.GetUser: %w", sql.ErrNoRows)).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"status":"Error","error":"user not found"}` + "\n",
+		},
 		{
 			name:           "unauthorized - missing user UID",
 			userUID:        "",
@@ -72,7 +84,7 @@ func TestSubscriptionStatusMiddleware(t *testing.T) {
 			name:    "internal server error - service error",
 			userUID: "user999",
 			setupMocks: func(ss *MockSubscriptionService) {
-				ss.On("GetSubscriptionStatus", mock.Anything, "user999").Return("", errors.New("service error")).Once()
+				ss.On("GetUser", mock.Anything, "user999").Return(&models.User{SubscriptionStatus: ""}, errors.New("service error")).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"status":"Error","error":"internal service error"}` + "\n",
@@ -158,7 +170,7 @@ func TestSubscriptionStatusMiddleware_ContextHandling(t *testing.T) {
 
 			// Настраиваем мок только для успешного случая
 			if tt.expectedStatus == http.StatusOK {
-				subService.On("GetSubscriptionStatus", mock.Anything, "user123").Return("active", nil).Once()
+				subService.On("GetUser", mock.Anything, "user123").Return(&models.User{SubscriptionStatus: "active"}, nil).Once()
 			}
 
 			testHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -205,11 +217,13 @@ func TestSubscriptionStatusMiddleware_SubscriptionStatuses(t *testing.T) {
 		{"pending", http.StatusOK, "success"},
 		{"expired", http.StatusForbidden, `{"status":"Error","error":"subscription expired, access denied"}` + "\n"},
 		{"cancelled", http.StatusOK, "success"}, // не expired, поэтому OK
+		{"pending_deletion", http.StatusUnauthorized, `{"status":"Error","error":"account deleted"}` + "\n"},
+		{"deleted", http.StatusUnauthorized, `{"status":"Error","error":"account deleted"}` + "\n"},
 	}
 
 	for _, status := range statuses {
 		t.Run("status_"+status.status, func(t *testing.T) {
-			subService.On("GetSubscriptionStatus", mock.Anything, "user123").Return(status.status, nil).Once()
+			subService.On("GetUser", mock.Anything, "user123").Return(&models.User{SubscriptionStatus: status.status}, nil).Once()
 
 			testHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 				w.WriteHeader(http.StatusOK)
@@ -240,7 +254,7 @@ func TestSubscriptionStatusMiddleware_HandlerNotCalledOnError(t *testing.T) {
 	logger := newNoopLoggerCheck()
 	middleware := SubscriptionStatusMiddleware(logger, subService)
 
-	subService.On("GetSubscriptionStatus", mock.Anything, "user123").Return("", errors.New("service error")).Once()
+	subService.On("GetUser", mock.Anything, "user123").Return(&models.User{SubscriptionStatus: ""}, errors.New("service error")).Once()
 
 	var handlerCalled bool
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
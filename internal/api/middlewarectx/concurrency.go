@@ -0,0 +1,89 @@
+package middlewarectx
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/render"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/response"
+)
+
+// userSlots — семафоры одновременных запросов пользователей.
+// Семафор пользователя удаляется, когда завершается его последний запрос,
+// поэтому память занимают только пользователи с активными запросами.
+type userSlots struct {
+	mu    sync.Mutex
+	limit int
+	users map[string]*userSlot
+}
+
+// userSlot — семафор одного пользователя и число запросов, которые его используют.
+type userSlot struct {
+	sem  chan struct{}
+	refs int
+}
+
+// acquire занимает место в семафоре uid без ожидания.
+// Возвращает false, если у пользователя уже limit одновременных запросов.
+func (s *userSlots) acquire(uid string) bool {
+	s.mu.Lock()
+	slot, ok := s.users[uid]
+	if !ok {
+		slot = &userSlot{sem: make(chan struct{}, s.limit)}
+		s.users[uid] = slot
+	}
+	slot.refs++
+	s.mu.Unlock()
+
+	select {
+	case slot.sem <- struct{}{}:
+		return true
+	default:
+		s.release(uid, false)
+		return false
+	}
+}
+
+// release освобождает ссылку на семафор uid и, если held, занятое в нем место.
+func (s *userSlots) release(uid string, held bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot := s.users[uid]
+	if held {
+		<-slot.sem
+	}
+	slot.refs--
+	if slot.refs == 0 {
+		delete(s.users, uid)
+	}
+}
+
+// UserConcurrencyMiddleware ограничивает число одновременных запросов одного пользователя
+// значением limit и отвечает 429, если лимит исчерпан. Пользователь определяется по uid
+// из контекста, поэтому middleware подключается после JWTMiddleware; запросы без
+// пользователя не ограничиваются. limit <= 0 отключает ограничение.
+func UserConcurrencyMiddleware(log *slog.Logger, limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		slots := &userSlots{limit: limit, users: make(map[string]*userSlot)}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uid := GetUser(r.Context()).UID
+			if uid == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !slots.acquire(uid) {
+				log.Warn("too many concurrent requests", slog.String("user_uid", uid), slog.Int("limit", limit))
+				w.WriteHeader(http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("too many concurrent requests"))
+				return
+			}
+			defer slots.release(uid, true)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
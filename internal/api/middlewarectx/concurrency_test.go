@@ -0,0 +1,93 @@
+package middlewarectx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler держит запросы пользователя blocked до закрытия release
+// и сообщает о начале каждого такого запроса в started.
+type blockingHandler struct {
+	blocked string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if GetUser(r.Context()).UID == h.blocked {
+		h.started <- struct{}{}
+		<-h.release
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func serveAs(handler http.Handler, uid string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if uid != "" {
+		req = req.WithContext(SetUser(req.Context(), UserInfo{UID: uid}))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserConcurrencyMiddleware(t *testing.T) {
+	const limit = 2
+	inner := &blockingHandler{blocked: "alice", started: make(chan struct{}, limit+1), release: make(chan struct{})}
+	handler := UserConcurrencyMiddleware(newNoopLoggerLimit(), limit)(inner)
+
+	// Занимаем все места пользователя alice
+	var wg sync.WaitGroup
+	codes := make(chan int, limit)
+	for range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serveAs(handler, "alice").Code
+		}()
+	}
+	for range limit {
+		<-inner.started
+	}
+
+	t.Run("запрос сверх лимита пользователя отклоняется", func(t *testing.T) {
+		w := serveAs(handler, "alice")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, `{"status":"Error","error":"too many concurrent requests"}`+"\n", w.Body.String())
+	})
+
+	t.Run("другой пользователь не затронут", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveAs(handler, "bob").Code)
+	})
+
+	close(inner.release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	t.Run("места освобождаются после завершения запросов", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveAs(handler, "alice").Code)
+	})
+}
+
+func TestUserConcurrencyMiddleware_Passthrough(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("запрос без пользователя не ограничивается", func(t *testing.T) {
+		handler := UserConcurrencyMiddleware(newNoopLoggerLimit(), 1)(ok)
+		assert.Equal(t, http.StatusOK, serveAs(handler, "").Code)
+	})
+
+	t.Run("нулевой лимит отключает ограничение", func(t *testing.T) {
+		handler := UserConcurrencyMiddleware(newNoopLoggerLimit(), 0)(ok)
+		assert.Equal(t, http.StatusOK, serveAs(handler, "alice").Code)
+	})
+}
@@ -0,0 +1,64 @@
+package middlewarectx
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Методы и заголовки, которые браузер может использовать в запросах к API с другого origin.
+var (
+	corsAllowedMethods = strings.Join([]string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions,
+	}, ", ")
+	corsAllowedHeaders = "Authorization, Content-Type, Accept-Language, If-None-Match"
+	// corsExposedHeaders — заголовки ответов, которые обработчики выставляют для клиента
+	corsExposedHeaders = "ETag, Retry-After, Content-Disposition"
+)
+
+// CORSMiddleware разрешает запросы к API со страниц из allowedOrigins.
+// Элемент "*" разрешает любой origin. Preflight-запрос OPTIONS от разрешенного origin
+// получает 204 и не доходит до обработчиков, поэтому не требует токена; браузер
+// кэширует его ответ на maxAge. Запросы без заголовка Origin и запросы с других origin
+// передаются дальше без CORS-заголовков. Пустой allowedOrigins отключает CORS.
+func CORSMiddleware(allowedOrigins []string, maxAge time.Duration) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+	return func(next http.Handler) http.Handler {
+		if len(allowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Ответ зависит от Origin, поэтому кэши не должны отдавать его другим origin
+			w.Header().Add("Vary", "Origin")
+			if !anyOrigin && !slices.Contains(allowedOrigins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowOrigin := origin
+			if anyOrigin {
+				allowOrigin = "*"
+			}
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				if maxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package middlewarectx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	var reached bool
+	handler := CORSMiddleware([]string{"https://app.example"}, 10*time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		reached = false
+		req := httptest.NewRequest(method, "/api/v1/subscriptions/list", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("запрос с разрешенного origin", func(t *testing.T) {
+		w := serve(http.MethodGet, "https://app.example", false)
+
+		assert.True(t, reached)
+		assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "ETag, Retry-After, Content-Disposition", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("preflight не доходит до обработчика", func(t *testing.T) {
+		w := serve(http.MethodOptions, "https://app.example", true)
+
+		assert.False(t, reached)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete)
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("другой origin не получает заголовков", func(t *testing.T) {
+		w := serve(http.MethodOptions, "https://evil.example", true)
+
+		assert.True(t, reached)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("запрос без Origin", func(t *testing.T) {
+		w := serve(http.MethodGet, "", false)
+
+		assert.True(t, reached)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+}
+
+func TestCORSMiddleware_AnyOrigin(t *testing.T) {
+	handler := CORSMiddleware([]string{"*"}, 0)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/login", nil)
+	req.Header.Set("Origin", "https://any.example")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSMiddleware_Disabled(t *testing.T) {
+	handler := CORSMiddleware(nil, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+	req.Header.Set("Origin", "https://app.example")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestExceptPublicPaths_CORS(t *testing.T) {
+	middleware := ExceptPublicPaths(PublicPaths{"/api/v1/payments/webhook"}, CORSMiddleware([]string{"*"}, 0))
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for path, want := range map[string]string{"/api/v1/payments/webhook": "", "/api/v1/login": "*"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Origin", "https://app.example")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, want, w.Header().Get("Access-Control-Allow-Origin"), path)
+	}
+}
@@ -0,0 +1,51 @@
+package middlewarectx
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/locale"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+)
+
+// Locale — ключ для локали запроса в контексте
+const Locale Key = "locale"
+
+// SetLocale возвращает копию ctx с локалью запроса.
+func SetLocale(ctx context.Context, l string) context.Context {
+	return context.WithValue(ctx, Locale, l)
+}
+
+// GetLocale возвращает локаль запроса из ctx или locale.Default, если она не задана.
+func GetLocale(ctx context.Context) string {
+	if l, ok := ctx.Value(Locale).(string); ok && l != "" {
+		return l
+	}
+	return locale.Default
+}
+
+// LocaleMiddleware определяет локаль запроса и записывает ее в контекст: по заголовку
+// Accept-Language, а если он не указывает поддерживаемый язык — по сохраненной локали
+// пользователя, иначе используется locale.Default. Сохраненная локаль читается только
+// для запросов с пользователем, поэтому middleware подключается после JWTMiddleware.
+// Ошибка чтения пользователя не прерывает запрос.
+func LocaleMiddleware(log *slog.Logger, users SubscriptionService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l, ok := locale.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+			if !ok {
+				l = locale.Default
+				if uid := GetUser(r.Context()).UID; uid != "" {
+					user, err := users.GetUser(r.Context(), uid)
+					if err != nil {
+						log.Warn("failed to get user locale, using default", slog.String("user_uid", uid), sl.Err(err))
+					} else if stored, ok := locale.Match(user.Locale); ok {
+						l = stored
+					}
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(SetLocale(r.Context(), l)))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package middlewarectx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		userUID        string
+		setupMocks     func(*MockSubscriptionService)
+		expected       string
+	}{
+		{
+			name:           "заголовок с поддерживаемым языком",
+			acceptLanguage: "en-GB,en;q=0.9,ru;q=0.8",
+			userUID:        "user123",
+			setupMocks:     func(*MockSubscriptionService) {},
+			expected:       "en-US",
+		},
+		{
+			name:    "без заголовка используется локаль пользователя",
+			userUID: "user123",
+			setupMocks: func(ss *MockSubscriptionService) {
+				ss.On("GetUser", mock.Anything, "user123").Return(&models.User{Locale: "de-DE"}, nil).Once()
+			},
+			expected: "de-DE",
+		},
+		{
+			name:           "неподдерживаемый язык — локаль пользователя",
+			acceptLanguage: "fr-FR, ja;q=0.5",
+			userUID:        "user123",
+			setupMocks: func(ss *MockSubscriptionService) {
+				ss.On("GetUser", mock.Anything, "user123").Return(&models.User{Locale: "en_US"}, nil).Once()
+			},
+			expected: "en-US",
+		},
+		{
+			name:           "неподдерживаемые язык и локаль пользователя — локаль по умолчанию",
+			acceptLanguage: "fr-FR",
+			userUID:        "user123",
+			setupMocks: func(ss *MockSubscriptionService) {
+				ss.On("GetUser", mock.Anything, "user123").Return(&models.User{Locale: "fr-FR"}, nil).Once()
+			},
+			expected: "ru-RU",
+		},
+		{
+			name:       "без заголовка и пользователя — локаль по умолчанию",
+			setupMocks: func(*MockSubscriptionService) {},
+			expected:   "ru-RU",
+		},
+		{
+			name:    "ошибка чтения пользователя не прерывает запрос",
+			userUID: "user123",
+			setupMocks: func(ss *MockSubscriptionService) {
+				ss.On("GetUser", mock.Anything, "user123").Return((*models.User)(nil), errors.New("db error")).Once()
+			},
+			expected: "ru-RU",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := new(MockSubscriptionService)
+			tt.setupMocks(ss)
+
+			var got string
+			handler := LocaleMiddleware(newNoopLoggerCheck(), ss)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = GetLocale(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.userUID != "" {
+				req = req.WithContext(SetUser(req.Context(), UserInfo{UID: tt.userUID}))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, got)
+			ss.AssertExpectations(t)
+		})
+	}
+}
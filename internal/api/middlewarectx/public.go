@@ -0,0 +1,45 @@
+package middlewarectx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PublicPaths — список путей, для которых не выполняются проверки аутентификации.
+// Путь сравнивается целиком; элемент, оканчивающийся на "/*", совпадает со всеми
+// путями с этим префиксом, например "/docs/*" совпадает с "/docs/index.html".
+type PublicPaths []string
+
+// Match сообщает, входит ли path в список.
+func (p PublicPaths) Match(path string) bool {
+	for _, public := range p {
+		if prefix, ok := strings.CutSuffix(public, "*"); ok && strings.HasSuffix(prefix, "/") {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == public {
+			return true
+		}
+	}
+	return false
+}
+
+// ExceptPublicPaths создает middleware, который выполняет mw для всех запросов,
+// кроме запросов к путям из public: они передаются дальше без mw.
+//
+// Так открытую конечную точку можно зарегистрировать в группе с JWTMiddleware
+// и освободить от проверок настройкой http_server.public_paths.
+func ExceptPublicPaths(public PublicPaths, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if public.Match(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
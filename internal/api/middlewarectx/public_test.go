@@ -0,0 +1,59 @@
+package middlewarectx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicPaths_Match(t *testing.T) {
+	public := PublicPaths{"/api/v1/login", "/docs/*", "/metrics"}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/api/v1/login", true},
+		{"/metrics", true},
+		{"/docs/index.html", true},
+		{"/docs/", true},
+		{"/docs", false},
+		{"/api/v1/login/extra", false},
+		{"/metrics-extra", false},
+		{"/api/v1/subscriptions", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, public.Match(tt.path))
+		})
+	}
+	assert.False(t, PublicPaths(nil).Match("/metrics"), "empty list exempts nothing")
+}
+
+func TestExceptPublicPaths(t *testing.T) {
+	authClient := new(MockAuthClient)
+	middleware := ExceptPublicPaths(PublicPaths{"/api/v1/status"}, JWTMiddleware(newNoopLoggerAuth(), authClient, nil))
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("открытый путь не требует токена", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("защищенный путь требует токен", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/list", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"status":"Error","error":"missing or invalid authorization header"}`, w.Body.String())
+	})
+
+	authClient.AssertNotCalled(t, "ValidateToken")
+}
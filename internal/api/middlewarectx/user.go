@@ -0,0 +1,39 @@
+package middlewarectx
+
+import "context"
+
+// Key тип для ключей контекста HTTP-запроса. Значения следует записывать
+// и читать через SetUser и GetUser, а не напрямую.
+type Key string
+
+const (
+	// User — ключ для имени пользователя в контексте
+	User Key = "username"
+	// Role — ключ для роли пользователя в контексте
+	Role Key = "role"
+	// UserUID — ключ для идентификатора пользователя в контексте
+	UserUID Key = "user_uid"
+)
+
+// UserInfo описывает аутентифицированного пользователя запроса.
+type UserInfo struct {
+	UID      string
+	Username string
+	Role     string
+}
+
+// SetUser возвращает копию ctx с данными пользователя.
+func SetUser(ctx context.Context, user UserInfo) context.Context {
+	ctx = context.WithValue(ctx, UserUID, user.UID)
+	ctx = context.WithValue(ctx, User, user.Username)
+	return context.WithValue(ctx, Role, user.Role)
+}
+
+// GetUser возвращает данные пользователя из ctx. Отсутствующие значения
+// остаются пустыми строками, поэтому обработчики проверяют нужные им поля.
+func GetUser(ctx context.Context) UserInfo {
+	uid, _ := ctx.Value(UserUID).(string)
+	username, _ := ctx.Value(User).(string)
+	role, _ := ctx.Value(Role).(string)
+	return UserInfo{UID: uid, Username: username, Role: role}
+}
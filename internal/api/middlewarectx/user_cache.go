@@ -0,0 +1,75 @@
+package middlewarectx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// UserCache кеширует результаты SubscriptionService.GetUser в памяти на ttl, чтобы
+// middleware аутентифицированных запросов не читали пользователя из базы на каждый запрос.
+// Кешируются только успешные ответы: ненайденный пользователь проверяется заново.
+// Изменения пользователя, например удаление аккаунта, становятся видны не позже чем через ttl.
+type UserCache struct {
+	users SubscriptionService
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu        sync.Mutex
+	entries   map[string]cachedUser
+	lastSweep time.Time
+}
+
+// cachedUser — пользователь в кеше и момент, до которого его можно отдавать.
+type cachedUser struct {
+	user    *models.User
+	expires time.Time
+}
+
+// NewUserCache создает UserCache поверх users. ttl <= 0 отключает кеширование;
+// если clk равен nil, используется системное время.
+func NewUserCache(users SubscriptionService, ttl time.Duration, clk clock.Clock) *UserCache {
+	return &UserCache{
+		users:   users,
+		ttl:     ttl,
+		clock:   clock.OrReal(clk),
+		entries: make(map[string]cachedUser),
+	}
+}
+
+// GetUser возвращает пользователя из кеша или, если его там нет или запись устарела, из users.
+func (c *UserCache) GetUser(ctx context.Context, userUID string) (*models.User, error) {
+	if c.ttl <= 0 {
+		return c.users.GetUser(ctx, userUID)
+	}
+
+	now := c.clock.Now()
+	c.mu.Lock()
+	cached, ok := c.entries[userUID]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.user, nil
+	}
+
+	user, err := c.users.GetUser(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Устаревшие записи удаляются не чаще раза за ttl, чтобы кеш не рос бесконечно
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for uid, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, uid)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[userUID] = cachedUser{user: user, expires: now.Add(c.ttl)}
+	return user, nil
+}
@@ -0,0 +1,91 @@
+package middlewarectx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestUserCache(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{UUID: "user123", SubscriptionStatus: "active"}
+
+	t.Run("повторный запрос в пределах ttl не читает базу", func(t *testing.T) {
+		ss := new(MockSubscriptionService)
+		ss.On("GetUser", mock.Anything, "user123").Return(user, nil).Once()
+		clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+		cache := NewUserCache(ss, time.Minute, clk)
+
+		for range 3 {
+			got, err := cache.GetUser(ctx, "user123")
+			require.NoError(t, err)
+			assert.Equal(t, user, got)
+			clk.Advance(10 * time.Second)
+		}
+		ss.AssertExpectations(t)
+	})
+
+	t.Run("устаревшая запись читается заново", func(t *testing.T) {
+		deleted := &models.User{UUID: "user123", SubscriptionStatus: models.StatusDeleted}
+		ss := new(MockSubscriptionService)
+		ss.On("GetUser", mock.Anything, "user123").Return(user, nil).Once()
+		ss.On("GetUser", mock.Anything, "user123").Return(deleted, nil).Once()
+		clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+		cache := NewUserCache(ss, time.Minute, clk)
+
+		_, err := cache.GetUser(ctx, "user123")
+		require.NoError(t, err)
+		clk.Advance(time.Minute)
+		got, err := cache.GetUser(ctx, "user123")
+		require.NoError(t, err)
+		assert.Equal(t, deleted, got)
+		ss.AssertExpectations(t)
+	})
+
+	t.Run("ненайденный пользователь не кешируется", func(t *testing.T) {
+		ss := new(MockSubscriptionService)
+		ss.On("GetUser", mock.Anything, "ghost").Return((*models.User)(nil), sql.ErrNoRows).Twice()
+		cache := NewUserCache(ss, time.Minute, nil)
+
+		for range 2 {
+			_, err := cache.GetUser(ctx, "ghost")
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		}
+		ss.AssertExpectations(t)
+	})
+
+	t.Run("нулевой ttl отключает кеш", func(t *testing.T) {
+		ss := new(MockSubscriptionService)
+		ss.On("GetUser", mock.Anything, "user123").Return(user, nil).Twice()
+		cache := NewUserCache(ss, 0, nil)
+
+		for range 2 {
+			_, err := cache.GetUser(ctx, "user123")
+			require.NoError(t, err)
+		}
+		ss.AssertExpectations(t)
+	})
+
+	t.Run("устаревшие записи других пользователей удаляются", func(t *testing.T) {
+		ss := new(MockSubscriptionService)
+		ss.On("GetUser", mock.Anything, mock.Anything).Return(user, nil)
+		clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+		cache := NewUserCache(ss, time.Minute, clk)
+
+		_, _ = cache.GetUser(ctx, "a")
+		_, _ = cache.GetUser(ctx, "b")
+		clk.Advance(2 * time.Minute)
+		_, _ = cache.GetUser(ctx, "c")
+
+		assert.Len(t, cache.entries, 1)
+		assert.Contains(t, cache.entries, "c")
+	})
+}
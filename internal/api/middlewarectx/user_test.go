@@ -0,0 +1,32 @@
+package middlewarectx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetUserGetUser(t *testing.T) {
+	user := UserInfo{UID: "uid-123", Username: "testuser", Role: "admin"}
+
+	ctx := SetUser(context.Background(), user)
+	assert.Equal(t, user, GetUser(ctx))
+
+	// Значения доступны и по отдельным ключам, которые используют существующие обработчики
+	assert.Equal(t, "uid-123", ctx.Value(UserUID))
+	assert.Equal(t, "testuser", ctx.Value(User))
+	assert.Equal(t, "admin", ctx.Value(Role))
+}
+
+func TestGetUser_Empty(t *testing.T) {
+	assert.Equal(t, UserInfo{}, GetUser(context.Background()))
+
+	// Значение неверного типа не считается данными пользователя
+	ctx := context.WithValue(context.Background(), UserUID, 42)
+	assert.Equal(t, UserInfo{}, GetUser(ctx))
+
+	// Строковый ключ с тем же текстом не совпадает с типизированным ключом
+	ctx = context.WithValue(context.Background(), "user_uid", "uid-123") //nolint:staticcheck // проверка изоляции ключей
+	assert.Equal(t, "", GetUser(ctx).UID)
+}
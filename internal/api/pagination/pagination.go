@@ -0,0 +1,69 @@
+// Package pagination разбирает параметры пагинации limit и offset из query-строки запроса.
+//
+// Все обработчики списков используют один и тот же набор правил: отсутствующий limit
+// заменяется значением по умолчанию, limit сверх максимума урезается до максимума,
+// а нечисловые и отрицательные значения отклоняются.
+package pagination
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Значения Limits по умолчанию.
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// Ошибки разбора параметров пагинации.
+var (
+	ErrInvalidLimit  = errors.New("limit must be a positive integer")
+	ErrInvalidOffset = errors.New("offset must be a non-negative integer")
+)
+
+// Limits задает размер страницы по умолчанию и максимальный размер страницы.
+// Нулевые значения заменяются DefaultLimit и MaxLimit.
+type Limits struct {
+	Default int // Размер страницы, если limit не передан
+	Max     int // Максимальный limit; большие значения урезаются до него
+}
+
+// Parse разбирает limit и offset из запроса с ограничениями по умолчанию.
+func Parse(r *http.Request) (limit, offset int, err error) {
+	return Limits{}.Parse(r)
+}
+
+// Parse разбирает limit и offset из запроса. Пустой limit заменяется l.Default,
+// limit больше l.Max урезается до l.Max, пустой offset равен нулю.
+// Возвращает ErrInvalidLimit, если limit не является положительным целым числом,
+// и ErrInvalidOffset, если offset не является неотрицательным целым числом.
+func (l Limits) Parse(r *http.Request) (limit, offset int, err error) {
+	if l.Max <= 0 {
+		l.Max = MaxLimit
+	}
+	if l.Default <= 0 {
+		l.Default = DefaultLimit
+	}
+	l.Default = min(l.Default, l.Max)
+
+	q := r.URL.Query()
+
+	limit = l.Default
+	if s := q.Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			return 0, 0, ErrInvalidLimit
+		}
+		limit = min(limit, l.Max)
+	}
+
+	if s := q.Get("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return 0, 0, ErrInvalidOffset
+		}
+	}
+	return limit, offset, nil
+}
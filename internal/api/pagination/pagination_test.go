@@ -0,0 +1,62 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_Parse(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     Limits
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantErr    error
+	}{
+		{name: "значения по умолчанию", query: "", wantLimit: DefaultLimit, wantOffset: 0},
+		{name: "явные limit и offset", query: "?limit=5&offset=3", wantLimit: 5, wantOffset: 3},
+		{name: "limit равен максимуму", query: "?limit=100", wantLimit: 100},
+		{name: "limit урезается до максимума", query: "?limit=101", wantLimit: MaxLimit},
+		{name: "пустые значения", query: "?limit=&offset=", wantLimit: DefaultLimit},
+		{name: "нулевой offset", query: "?offset=0", wantLimit: DefaultLimit},
+		{name: "настроенные ограничения", limits: Limits{Default: 20, Max: 50}, query: "", wantLimit: 20},
+		{name: "урезание до настроенного максимума", limits: Limits{Default: 20, Max: 50}, query: "?limit=51", wantLimit: 50},
+		{name: "значение по умолчанию больше максимума", limits: Limits{Default: 80, Max: 50}, query: "", wantLimit: 50},
+		{name: "нечисловой limit", query: "?limit=abc", wantErr: ErrInvalidLimit},
+		{name: "нулевой limit", query: "?limit=0", wantErr: ErrInvalidLimit},
+		{name: "отрицательный limit", query: "?limit=-1", wantErr: ErrInvalidLimit},
+		{name: "дробный limit", query: "?limit=1.5", wantErr: ErrInvalidLimit},
+		{name: "переполнение limit", query: "?limit=99999999999999999999", wantErr: ErrInvalidLimit},
+		{name: "нечисловой offset", query: "?offset=abc", wantErr: ErrInvalidOffset},
+		{name: "отрицательный offset", query: "?offset=-5", wantErr: ErrInvalidOffset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/list"+tt.query, nil)
+
+			limit, offset, err := tt.limits.Parse(r)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, limit)
+				assert.Zero(t, offset)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
+
+func TestParse_UsesDefaults(t *testing.T) {
+	limit, offset, err := Parse(httptest.NewRequest("GET", "/list?limit=1000&offset=7", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, MaxLimit, limit)
+	assert.Equal(t, 7, offset)
+}
@@ -0,0 +1,37 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest — нестандартный статус 499 (как в nginx): клиент закрыл
+// соединение раньше, чем сервер успел ответить.
+const StatusClientClosedRequest = 499
+
+// ContextError отвечает на ошибку, вызванную завершением контекста запроса, и сообщает,
+// была ли ошибка обработана. context.Canceled означает, что запрос отменил клиент: ответ 499
+// пишется в лог как предупреждение, а не как ошибка сервера. context.DeadlineExceeded означает,
+// что сервер не уложился в отведенное время: ответ 504. Для остальных ошибок ничего не
+// пишется и возвращается false, чтобы обработчик ответил как обычно. Ошибки gRPC-клиента
+// не оборачивают ошибку контекста, поэтому коды Canceled и DeadlineExceeded обрабатываются так же.
+func ContextError(w http.ResponseWriter, log *slog.Logger, err error) bool {
+	switch code := status.Code(err); {
+	case errors.Is(err, context.Canceled), code == codes.Canceled:
+		log.Warn("request canceled by client", sl.Err(err))
+		writeJSON(w, StatusClientClosedRequest, Error("request canceled"))
+		return true
+	case errors.Is(err, context.DeadlineExceeded), code == codes.DeadlineExceeded:
+		log.Error("request timed out", sl.Err(err))
+		writeJSON(w, http.StatusGatewayTimeout, Error("request timed out"))
+		return true
+	default:
+		return false
+	}
+}
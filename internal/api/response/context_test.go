@@ -0,0 +1,110 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestContextError(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name        string
+		err         error
+		wantHandled bool
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "клиент отменил запрос",
+			err:         fmt.Errorf("storage.ListEntrys: %w", context.Canceled),
+			wantHandled: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantBody:    `{"status":"Error","error":"request canceled"}`,
+		},
+		{
+			name:        "истек срок запроса",
+			err:         fmt.Errorf("storage.ListEntrys: %w", context.DeadlineExceeded),
+			wantHandled: true,
+			wantStatus:  http.StatusGatewayTimeout,
+			wantBody:    `{"status":"Error","error":"request timed out"}`,
+		},
+		{
+			name:        "gRPC-вызов отменен клиентом",
+			err:         fmt.Errorf("auth.Login: %w", status.Error(codes.Canceled, "context canceled")),
+			wantHandled: true,
+			wantStatus:  StatusClientClosedRequest,
+			wantBody:    `{"status":"Error","error":"request canceled"}`,
+		},
+		{
+			name:        "истек срок gRPC-вызова",
+			err:         status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			wantHandled: true,
+			wantStatus:  http.StatusGatewayTimeout,
+			wantBody:    `{"status":"Error","error":"request timed out"}`,
+		},
+		{
+			name:        "другая ошибка gRPC не обрабатывается",
+			err:         status.Error(codes.Unavailable, "connection refused"),
+			wantHandled: false,
+			wantStatus:  http.StatusOK,
+			wantBody:    "",
+		},
+		{
+			name:        "другая ошибка не обрабатывается",
+			err:         errors.New("db error"),
+			wantHandled: false,
+			wantStatus:  http.StatusOK,
+			wantBody:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			handled := ContextError(w, log, tt.err)
+
+			assert.Equal(t, tt.wantHandled, handled)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody == "" {
+				assert.Empty(t, w.Body.String())
+			} else {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestContextError_RequestContext(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("отмена контекста запроса", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+
+		assert.True(t, ContextError(w, log, ctx.Err()))
+		assert.Equal(t, StatusClientClosedRequest, w.Code)
+	})
+
+	t.Run("истечение таймаута контекста запроса", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		<-ctx.Done()
+		w := httptest.NewRecorder()
+
+		assert.True(t, ContextError(w, log, ctx.Err()))
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+}
@@ -4,7 +4,9 @@
 package response
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/go-playground/validator"
@@ -41,6 +43,27 @@ func OKWithData(data any) OKResponse {
 	}
 }
 
+// CreatedData описывает данные ответа о создании ресурса.
+type CreatedData struct {
+	ID int `json:"id"`
+}
+
+// OK записывает в w ответ 200 вида {"status":"OK","data":data}.
+func OK(w http.ResponseWriter, data any) {
+	writeJSON(w, http.StatusOK, OKWithData(data))
+}
+
+// Created записывает в w ответ 201 вида {"status":"OK","data":{"id":id}}.
+func Created(w http.ResponseWriter, id int) {
+	writeJSON(w, http.StatusCreated, OKWithData(CreatedData{ID: id}))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
 // Error возвращает Response с ошибкой и переданным сообщением.
 func Error(msg string) ErrorResponse {
 	return ErrorResponse{
@@ -2,6 +2,8 @@
 package response
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/go-playground/validator"
@@ -67,3 +69,20 @@ func TestValidationErrorRequired(t *testing.T) {
 	assert.Equal(t, StatusError, resp.Status)
 	assert.Contains(t, resp.Error, "field Name is a required field")
 }
+
+func TestOK(t *testing.T) {
+	w := httptest.NewRecorder()
+	OK(w, map[string]int{"count": 2})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"OK","data":{"count":2}}`, w.Body.String())
+}
+
+func TestCreated(t *testing.T) {
+	w := httptest.NewRecorder()
+	Created(w, 7)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"status":"OK","data":{"id":7}}`, w.Body.String())
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// streamFlushEvery — через сколько записанных элементов ListStream отправляет накопленное клиенту.
+const streamFlushEvery = 100
+
+// ListStream записывает ответ 200 вида {"status":"OK","data":{"<field>":[...],"list_count":N}}
+// по одному элементу, не собирая список в памяти. Заголовок ответа отправляется при записи
+// первого элемента, поэтому до него обработчик еще может ответить ошибкой; после — нет,
+// и при ошибке остается только прервать ответ, не вызывая Close.
+type ListStream struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	field   string
+	count   int
+	started bool
+}
+
+// NewListStream создает ListStream, который записывает элементы в поле field объекта data.
+func NewListStream(w http.ResponseWriter, field string) *ListStream {
+	return &ListStream{w: w, enc: json.NewEncoder(w), field: field}
+}
+
+// Started сообщает, отправлен ли уже заголовок ответа.
+func (s *ListStream) Started() bool {
+	return s.started
+}
+
+// Count возвращает количество записанных элементов.
+func (s *ListStream) Count() int {
+	return s.count
+}
+
+// Write кодирует item как очередной элемент списка.
+func (s *ListStream) Write(item any) error {
+	sep := ","
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+		sep = ""
+	}
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+	if err := s.enc.Encode(item); err != nil {
+		return err
+	}
+	s.count++
+	if s.count == 1 || s.count%streamFlushEvery == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// Close завершает список и дописывает количество элементов в поле list_count.
+func (s *ListStream) Close() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(s.w, `],"list_count":`+strconv.Itoa(s.count)+"}}\n"); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *ListStream) start() error {
+	s.started = true
+	s.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	s.w.WriteHeader(http.StatusOK)
+	field, err := json.Marshal(s.field)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.w, `{"status":"`+StatusOK+`","data":{`+string(field)+`:[`)
+	return err
+}
+
+func (s *ListStream) flush() {
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
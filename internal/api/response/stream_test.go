@@ -0,0 +1,36 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStream(t *testing.T) {
+	t.Run("элементы и количество", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		stream := NewListStream(w, "entries")
+
+		require.NoError(t, stream.Write(map[string]int{"id": 1}))
+		assert.True(t, stream.Started())
+		assert.True(t, w.Flushed, "первый элемент отправляется клиенту сразу")
+		require.NoError(t, stream.Write(map[string]int{"id": 2}))
+		require.NoError(t, stream.Close())
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"status":"OK","data":{"entries":[{"id":1},{"id":2}],"list_count":2}}`, w.Body.String())
+	})
+
+	t.Run("пустой список", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		stream := NewListStream(w, "entries")
+
+		assert.False(t, stream.Started())
+		require.NoError(t, stream.Close())
+		assert.JSONEq(t, `{"status":"OK","data":{"entries":[],"list_count":0}}`, w.Body.String())
+	})
+}
@@ -13,24 +13,31 @@ import (
 	authservices "github.com/magabrotheeeer/subscription-aggregator/internal/services/auth"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/repository"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// Проверка на этапе компиляции: конкретное хранилище удовлетворяет интерфейсу сервиса.
+var _ authservices.UserRepository = (*repository.Storage)(nil)
+
 // App представляет приложение аутентификации.
 type App struct {
-	grpcServer *grpc.Server
-	listener   net.Listener
-	logger     *slog.Logger
+	grpcServer    *grpc.Server
+	listener      net.Listener
+	healthChecker *server.HealthChecker
+	logger        *slog.Logger
 }
 
 // New создает новый экземпляр приложения аутентификации.
 func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
-	db, err := repository.New(cfg.StorageConnectionString)
+	db, err := repository.New(cfg.StorageConnectionString, cfg.StorageReplicaConnectionString, cfg.StorageStatementTimeout, cfg.DefaultCurrency)
 	if err != nil {
 		return nil, err
 	}
 
 	jwtMaker := jwt.NewJWTMaker(cfg.JWTSecretKey, cfg.TokenTTL)
-	authService := authservices.NewAuthService(db, jwtMaker)
+	authService := authservices.NewAuthService(db, jwtMaker, cfg.AllowedEmailDomains)
 
 	lis, err := net.Listen("tcp", cfg.GRPCAuthAddress)
 	if err != nil {
@@ -41,10 +48,19 @@ func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, erro
 
 	authpb.RegisterAuthServiceServer(grpcServer, server.NewAuthServer(authService, logger))
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthChecker := server.NewHealthChecker(healthServer, db.DB, cfg.GRPCHealthInterval, logger)
+
+	if cfg.GRPCReflection {
+		reflection.Register(grpcServer)
+	}
+
 	return &App{
-		grpcServer: grpcServer,
-		listener:   lis,
-		logger:     logger,
+		grpcServer:    grpcServer,
+		listener:      lis,
+		healthChecker: healthChecker,
+		logger:        logger,
 	}, nil
 }
 
@@ -52,6 +68,8 @@ func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, erro
 func (a *App) Run(ctx context.Context) error {
 	errCh := make(chan error, 1)
 
+	go a.healthChecker.Run(ctx)
+
 	go func() {
 		a.logger.Info("Auth gRPC service listening on", slog.String("address", a.listener.Addr().String()))
 		errCh <- a.grpcServer.Serve(a.listener)
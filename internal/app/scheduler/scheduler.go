@@ -3,23 +3,46 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+	accountservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/account"
 	schedulerservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/scheduler"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/cache"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/repository"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/streadway/amqp"
 )
 
+// Проверка на этапе компиляции: конкретные хранилища удовлетворяют интерфейсам сервисов.
+var (
+	_ schedulerservice.SubscriptionRepository = (*repository.Storage)(nil)
+	_ schedulerservice.Cache                  = (*cache.Cache)(nil)
+	_ accountservice.AccountRepository        = (*repository.Storage)(nil)
+	_ schedulerservice.QueueInspector         = (*rabbitmq.AMQPTopology)(nil)
+)
+
+// webhookSecret — секрет подписи уведомлений тестового провайдера; должен совпадать
+// с секретом, которым subscription-aggregator проверяет webhook-уведомления.
+const webhookSecret = "webhook_secret"
+
 // App представляет приложение планировщика.
 type App struct {
 	schedulerService *schedulerservice.SchedulerService
+	accountService   *accountservice.AccountService
+	deletionInterval time.Duration
+	metricsAddress   string
 	conn             *amqp.Connection
 	ch               *amqp.Channel
+	queueInspector   *rabbitmq.AMQPTopology
 	logger           *slog.Logger
 }
 
@@ -36,19 +59,23 @@ func waitForDB(db *repository.Storage) error {
 
 // New создает новый экземпляр приложения планировщика.
 func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
-	conn, err := rabbitmq.Connect(cfg.RabbitMQURL, cfg.RabbitMQMaxRetries, cfg.RabbitMQRetryDelay)
+	conn, err := rabbitmq.Connect(ctx, cfg.RabbitMQURL, rabbitmq.RetryPolicy{
+		MaxAttempts: cfg.RabbitMQMaxRetries,
+		BaseDelay:   cfg.RabbitMQRetryDelay,
+		MaxDelay:    cfg.RabbitMQMaxRetryDelay,
+		Jitter:      cfg.RabbitMQRetryJitter,
+	}, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect RabbitMQ: %w", err)
 	}
 
-	queues := rabbitmq.GetNotificationQueues()
-	ch, err := rabbitmq.SetupChannel(conn, queues)
+	ch, err := rabbitmq.NotificationChannel(conn, cfg.RabbitMQURL, cfg.RabbitMQManagementURL, cfg.RabbitMQDeclareTopology)
 	if err != nil {
 		closeResources(nil, conn, logger)
 		return nil, fmt.Errorf("failed to setup RabbitMQ channel: %w", err)
 	}
 
-	db, err := repository.New(cfg.StorageConnectionString)
+	db, err := repository.New(cfg.StorageConnectionString, cfg.StorageReplicaConnectionString, cfg.StorageStatementTimeout, cfg.DefaultCurrency)
 	if err != nil {
 		closeResources(ch, conn, logger)
 		return nil, fmt.Errorf("failed to connect storage: %w", err)
@@ -65,12 +92,29 @@ func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, er
 		return nil, fmt.Errorf("cache not initialized: %w", err)
 	}
 
-	schedulerService := schedulerservice.NewSchedulerService(db, cacheRedis, logger)
+	var provider yookassa.Provider = yookassa.NewClient("заглушка", "заглушка")
+	if cfg.PaymentsTestMode {
+		logger.Warn("payments test mode is enabled: payments are simulated, no real charges are made")
+		signer, err := webhooksig.NewSigner(webhooksig.HMACSHA256, webhookSecret, nil)
+		if err != nil {
+			closeResources(ch, conn, logger)
+			return nil, err
+		}
+		provider = yookassa.NewStubProvider(cfg.PaymentsTestWebhookURL, signer)
+	}
+	providerService := yookassa.NewResilientClient(provider, cfg.PaymentProvider)
+
+	schedulerService := schedulerservice.NewSchedulerService(db, cacheRedis, providerService, clock.Real{}, cfg.Scheduler, logger)
+	accountService := accountservice.NewAccountService(db, cfg.AccountDeletion, cfg.ReminderDaysBefore, logger)
 
 	return &App{
 		schedulerService: schedulerService,
+		accountService:   accountService,
+		deletionInterval: cfg.AccountDeletionInterval,
+		metricsAddress:   cfg.MetricsAddress,
 		conn:             conn,
 		ch:               ch,
+		queueInspector:   rabbitmq.NewAMQPTopology(conn, "", ""),
 		logger:           logger,
 	}, nil
 }
@@ -88,10 +132,60 @@ func closeResources(ch *amqp.Channel, conn *amqp.Connection, logger *slog.Logger
 	}
 }
 
+// processAccountDeletions с периодом scheduler.account_deletion_interval анонимизирует аккаунты с наступившим сроком отложенного удаления.
+func (a *App) processAccountDeletions(ctx context.Context) {
+	ticker := time.NewTicker(a.deletionInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := a.accountService.ProcessDueDeletions(ctx)
+		if err != nil {
+			a.logger.Error("failed to process account deletions", slog.Any("err", err))
+		} else {
+			a.logger.Info("account deletions processed", slog.Int("count", processed))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serveMetrics отдает метрики планировщика по адресу scheduler.metrics_address до отмены ctx.
+func (a *App) serveMetrics(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{
+		Addr:              a.metricsAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Close(); err != nil {
+			a.logger.Error("failed to close metrics server", slog.Any("err", err))
+		}
+	}()
+
+	a.logger.Info("starting metrics server", slog.String("address", a.metricsAddress))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		a.logger.Error("metrics server stopped", slog.Any("err", err))
+	}
+}
+
 // Run запускает планировщик.
 func (a *App) Run(ctx context.Context) error {
-	go a.schedulerService.FindExpiringSubscriptionsDueTomorrow(ctx, a.ch)
-	go a.schedulerService.FindExpiringSubscriptionsDueToday(ctx, a.ch)
+	go a.serveMetrics(ctx)
+	go a.schedulerService.FindExpiringSubscriptionsDueTomorrow(ctx)
+	go a.schedulerService.FindExpiringSubscriptionsDueToday(ctx)
+	go a.schedulerService.RelayOutbox(ctx, a.ch)
+	go a.schedulerService.ConvertEndedTrials(ctx)
+	go a.schedulerService.ExpireLapsedSubscriptions(ctx)
+	go a.processAccountDeletions(ctx)
+	go a.schedulerService.ArchiveInactiveSubscriptions(ctx)
+	go a.schedulerService.MonitorDeadLetterQueue(ctx, a.queueInspector)
 
 	<-ctx.Done()
 
@@ -13,6 +13,9 @@ import (
 	"github.com/streadway/amqp"
 )
 
+// Проверка на этапе компиляции: конкретное хранилище удовлетворяет интерфейсу сервиса.
+var _ senderservice.SubscriptionRepository = (*repository.Storage)(nil)
+
 // App представляет приложение отправителя уведомлений.
 type App struct {
 	conn          *amqp.Connection
@@ -22,18 +25,22 @@ type App struct {
 }
 
 // New создает новый экземпляр приложения отправителя.
-func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
-	db, err := repository.New(cfg.StorageConnectionString)
+func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
+	db, err := repository.New(cfg.StorageConnectionString, cfg.StorageReplicaConnectionString, cfg.StorageStatementTimeout, cfg.DefaultCurrency)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := rabbitmq.Connect(cfg.RabbitMQURL, cfg.RabbitMQMaxRetries, cfg.RabbitMQRetryDelay)
+	conn, err := rabbitmq.Connect(ctx, cfg.RabbitMQURL, rabbitmq.RetryPolicy{
+		MaxAttempts: cfg.RabbitMQMaxRetries,
+		BaseDelay:   cfg.RabbitMQRetryDelay,
+		MaxDelay:    cfg.RabbitMQMaxRetryDelay,
+		Jitter:      cfg.RabbitMQRetryJitter,
+	}, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	queues := rabbitmq.GetNotificationQueues()
-	ch, err := rabbitmq.SetupChannel(conn, queues)
+	ch, err := rabbitmq.NotificationChannel(conn, cfg.RabbitMQURL, cfg.RabbitMQManagementURL, cfg.RabbitMQDeclareTopology)
 	if err != nil {
 		if closeErr := conn.Close(); closeErr != nil {
 			logger.Error("failed to close connection", "error", closeErr)
@@ -42,7 +49,11 @@ func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, erro
 	}
 
 	newTransport := smtp.NewTransport(cfg, logger)
-	senderService := senderservice.NewSenderService(db, logger, newTransport)
+	senderService := senderservice.NewSenderService(db, logger, newTransport, senderservice.RetryPolicy{
+		MaxAttempts: cfg.SMTPMaxAttempts,
+		BaseDelay:   cfg.SMTPRetryDelay,
+		MaxDelay:    cfg.SMTPMaxRetryDelay,
+	})
 
 	return &App{
 		conn:          conn,
@@ -52,30 +63,33 @@ func New(_ context.Context, cfg *config.Config, logger *slog.Logger) (*App, erro
 	}, nil
 }
 
-// Run запускает отправитель уведомлений.
+// Run запускает отправитель уведомлений и блокируется до отмены ctx. При отмене
+// потребитель перестает принимать сообщения и дожидается уже начатых отправок,
+// и только после этого закрываются канал и соединение с RabbitMQ.
 func (a *App) Run(ctx context.Context) error {
-	err := rabbitmq.ConsumerMessage(ctx, a.ch, "subscription_expiring_queue", a.senderService.SendInfoExpiringSubscription)
-	if err != nil {
-		a.logger.Error("failed to start subscription_expiring_queue consumer", slog.Any("err", err))
-		return err
-	}
+	router := rabbitmq.NewRouter()
+	router.Handle(rabbitmq.RoutingKeySubscriptionExpiring, a.senderService.SendInfoExpiringSubscription)
+	router.Handle(rabbitmq.RoutingKeyReminderDigest, a.senderService.SendReminderDigest)
+	router.Handle(rabbitmq.RoutingKeyTrialExpiring, a.senderService.SendInfoExpiringTrialPeriodSubscription)
+	router.Handle(rabbitmq.RoutingKeyTrialExpired, a.senderService.SendInfoTrialExpired)
+	router.Handle(rabbitmq.RoutingKeySubscriptionExpired, a.senderService.SendInfoSubscriptionExpired)
+	router.Handle(rabbitmq.RoutingKeyPaymentSucceeded, a.senderService.SendInfoSuccessPaymentMessage)
+	router.Handle(rabbitmq.RoutingKeyPaymentFailed, a.senderService.SendInfoFailurePaymentMessage)
 
-	err = rabbitmq.ConsumerMessage(ctx, a.ch, "trial_expiring_queue", a.senderService.SendInfoExpiringTrialPeriodSubscription)
+	err := rabbitmq.ConsumerRouted(ctx, a.ch, rabbitmq.NotificationsQueue, router)
 	if err != nil {
-		a.logger.Error("failed to start trial_expiring_queue consumer", slog.Any("err", err))
-		return err
+		a.logger.Error("notifications consumer stopped", slog.Any("err", err))
+	} else {
+		a.logger.Info("Sender service shutting down gracefully")
 	}
 
-	<-ctx.Done()
-	a.logger.Info("Sender service shutting down gracefully")
-
-	if err := a.ch.Close(); err != nil {
-		a.logger.Error("failed to close channel", slog.Any("err", err))
+	if closeErr := a.ch.Close(); closeErr != nil {
+		a.logger.Error("failed to close channel", slog.Any("err", closeErr))
 	}
 
-	if err := a.conn.Close(); err != nil {
-		a.logger.Error("failed to close connection", slog.Any("err", err))
+	if closeErr := a.conn.Close(); closeErr != nil {
+		a.logger.Error("failed to close connection", slog.Any("err", closeErr))
 	}
 
-	return nil
+	return err
 }
@@ -8,71 +8,182 @@ import (
 
 	httpSwagger "github.com/swaggo/http-swagger"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/account/accountdelete"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/account/accountexport"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/account/accountreminders"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/account/accountremindersupdate"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/account/notificationtest"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/integrity"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/recompute"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/replay"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/shiftdates"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/stats"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/admin/usersearch"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/auth/login"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/auth/register"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentcreate"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentdetails"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentlist"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentnextcharge"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentreceipt"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentrefund"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymenttokendelete"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/attention"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/bulkstatus"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/byservice"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/cancelsavings"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/create"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/grouped"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/importcsv"
 
 	//	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/health"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/list"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/preview"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/pricehistory"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/reactivate"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/read"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/remove"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/spendtimeseries"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/sum"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/update"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/validate"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/yearlyestimate"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/system/constraints"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/system/version"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/bankcsv"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	accountservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/account"
+	adminservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/admin"
 	paymentservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/payment"
+	schedulerservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/scheduler"
 	senderservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/sender"
 	subservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/subscription"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 
 	"log/slog"
+	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/grpc/client"
 )
 
+// webhookSecret — секрет проверки подписи webhook-уведомлений платежного провайдера;
+// им же тестовый провайдер подписывает уведомления.
+const webhookSecret = "webhook_secret"
+
 // RegisterRoutes регистрирует все маршруты приложения.
 func RegisterRoutes(r chi.Router, logger *slog.Logger,
 	subscriptionService *subservice.SubscriptionService,
 	authClient *client.AuthClient,
-	providerClient *yookassa.Client,
+	tokenFallback middlewarectx.TokenParser,
+	providerClient *yookassa.ResilientClient,
 	paymentService *paymentservice.Service,
-	senderService *senderservice.SenderService) {
+	senderService *senderservice.SenderService,
+	adminService *adminservice.AdminService,
+	accountService *accountservice.AccountService,
+	schedulerService *schedulerservice.SchedulerService,
+	pageLimits list.PageLimits,
+	maxRequestBodySize int64,
+	maxImportBodySize int64,
+	maxConcurrentRequestsPerUser int,
+	userCacheTTL time.Duration,
+	testNotificationInterval time.Duration,
+	webhookTimeout time.Duration,
+	webhookVerifier *webhooksig.Verifier,
+	bankMapping bankcsv.Mapping,
+	importBatchSize int,
+	entryConstraints models.Constraints,
+	corsAllowedOrigins []string,
+	corsMaxAge time.Duration,
+	publicPaths middlewarectx.PublicPaths) {
 	// Глобальные middleware
 	r.Use(
 		middleware.RequestID,
 		middleware.Logger,
 		middleware.Recoverer,
 		middleware.URLFormat,
+		// Открытые пути из http_server.public_paths (webhook, метрики, документация) вызываются не из браузера
+		middlewarectx.ExceptPublicPaths(publicPaths, middlewarectx.CORSMiddleware(corsAllowedOrigins, corsMaxAge)),
 	)
+	// Лимит тела JSON-запросов; у импорта CSV-выписки свой лимит http_server.max_import_body_size
+	bodyLimit := middlewarectx.MaxBodySizeMiddleware(maxRequestBodySize)
+
+	// Пользователь из токена читается middleware на каждый аутентифицированный запрос
+	users := middlewarectx.NewUserCache(subscriptionService, userCacheTTL, nil)
 
 	r.Route("/api/v1", func(r chi.Router) {
 		// Открытые конечные точки
-		r.Post("/register", register.New(logger, authClient, subscriptionService).ServeHTTP)
-		r.Post("/login", login.New(logger, authClient).ServeHTTP)
+		r.With(bodyLimit).Post("/register", register.New(logger, authClient, subscriptionService).ServeHTTP)
+		r.With(bodyLimit).Post("/login", login.New(logger, authClient).ServeHTTP)
+		r.Get("/meta/constraints", constraints.New(logger, entryConstraints).ServeHTTP)
 
-		// Группа с JWT аутентификацией
+		// Группа с JWT аутентификацией; пути из http_server.public_paths проверки пропускают
 		r.Group(func(r chi.Router) {
-			r.Use(middlewarectx.JWTMiddleware(logger, authClient))
-			r.Use(middlewarectx.SubscriptionStatusMiddleware(logger, subscriptionService))
-			r.Use(middlewarectx.RateLimitMiddleware(logger))
-			r.Post("/subscriptions", create.New(logger, subscriptionService).ServeHTTP)
-			r.Get("/subscriptions/{id}", read.New(logger, subscriptionService).ServeHTTP)
-			r.Delete("/subscriptions/{id}", remove.New(logger, subscriptionService).ServeHTTP)
-			r.Put("/subscriptions/{id}", update.New(logger, subscriptionService).ServeHTTP)
-			r.Get("/subscriptions/list", list.New(logger, subscriptionService).ServeHTTP)
-			r.Post("/subscriptions/sum", sum.New(logger, subscriptionService).ServeHTTP)
-			r.Post("/payment", paymentcreate.New(logger, providerClient, paymentService).ServeHTTP)
-			r.Get("/payments/list", paymentlist.New(logger, paymentService).ServeHTTP)
+			r.Use(middlewarectx.ExceptPublicPaths(publicPaths, middlewarectx.JWTMiddleware(logger, authClient, tokenFallback)))
+			r.Use(middlewarectx.ExceptPublicPaths(publicPaths, middlewarectx.SubscriptionStatusMiddleware(logger, users)))
+			r.Use(middlewarectx.LocaleMiddleware(logger, users))
+			r.Use(middlewarectx.ExceptPublicPaths(publicPaths, middlewarectx.RateLimitMiddleware(logger)))
+			r.Use(middlewarectx.UserConcurrencyMiddleware(logger, maxConcurrentRequestsPerUser))
+			r.With(middlewarectx.MaxBodySizeMiddleware(maxImportBodySize)).
+				Post("/subscriptions/import", importcsv.New(logger, subscriptionService, bankMapping, importBatchSize).ServeHTTP)
+
+			// Остальные аутентифицированные конечные точки принимают JSON
+			r.Group(func(r chi.Router) {
+				r.Use(bodyLimit)
+				r.Post("/subscriptions", create.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/subscriptions/{id}", read.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/subscriptions/{id}/cancel-savings", cancelsavings.New(logger, subscriptionService).ServeHTTP)
+				r.Post("/subscriptions/{id}/reactivate", reactivate.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/subscriptions/by-service/{name}", byservice.New(logger, subscriptionService).ServeHTTP)
+				r.Delete("/subscriptions/{id}", remove.New(logger, subscriptionService).ServeHTTP)
+				r.Put("/subscriptions/{id}", update.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/subscriptions/list", list.New(logger, subscriptionService, pageLimits).ServeHTTP)
+				r.Post("/subscriptions/sum", sum.New(logger, subscriptionService).ServeHTTP)
+				r.Post("/subscriptions/preview", preview.New(logger, subscriptionService).ServeHTTP)
+				r.Post("/subscriptions/validate", validate.New(logger, subscriptionService).ServeHTTP)
+				r.Post("/subscriptions/bulk-status", bulkstatus.New(logger, subscriptionService).ServeHTTP)
+				r.Post("/payment", paymentcreate.New(logger, providerClient, paymentService).ServeHTTP)
+				r.Get("/payments/list", paymentlist.New(logger, paymentService).ServeHTTP)
+				r.Get("/me/subscriptions/grouped", grouped.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/me/yearly-estimate", yearlyestimate.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/me/attention", attention.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/me/spend/timeseries", spendtimeseries.New(logger, subscriptionService).ServeHTTP)
+				r.Get("/me/services/{name}/price-history", pricehistory.New(logger, subscriptionService).ServeHTTP)
+				r.Delete("/me", accountdelete.New(logger, accountService).ServeHTTP)
+				r.Get("/me/export", accountexport.New(logger, accountService).ServeHTTP)
+				r.Get("/me/reminders", accountreminders.New(logger, accountService).ServeHTTP)
+				r.Put("/me/reminders", accountremindersupdate.New(logger, accountService).ServeHTTP)
+				r.Post("/me/notifications/test", notificationtest.New(logger, senderService, testNotificationInterval, clock.Real{}).ServeHTTP)
+				r.Delete("/me/payment-tokens/{id}", paymenttokendelete.New(logger, paymentService).ServeHTTP)
+				r.Get("/me/next-charge", paymentnextcharge.New(logger, paymentService).ServeHTTP)
+				r.Get("/me/payments/{id}", paymentdetails.New(logger, paymentService).ServeHTTP)
+				r.Get("/me/payments/{id}/receipt", paymentreceipt.New(logger, paymentService).ServeHTTP)
+				r.Post("/me/payments/{id}/refund", paymentrefund.New(logger, providerClient, paymentService).ServeHTTP)
+
+				// Административные конечные точки
+				r.Group(func(r chi.Router) {
+					r.Use(middlewarectx.AdminOnlyMiddleware(logger))
+					r.Get("/admin/stats", stats.New(logger, adminService).ServeHTTP)
+					r.Get("/admin/users/search", usersearch.New(logger, adminService).ServeHTTP)
+					r.Post("/admin/maintenance/recompute-payment-dates", recompute.New(logger, adminService).ServeHTTP)
+					r.Post("/admin/maintenance/integrity-check", integrity.New(logger, adminService).ServeHTTP)
+					r.Post("/admin/subscriptions/shift-payment-dates", shiftdates.New(logger, adminService).ServeHTTP)
+					r.Post("/admin/notifications/replay", replay.New(logger, schedulerService).ServeHTTP)
+				})
+			})
 		})
 
 		// Webhook endpoint (без аутентификации)
-		r.Post("/payments/webhook", paymentwebhook.New(logger, paymentService, senderService, "webhook_secret").ServeHTTP)
+		r.With(bodyLimit).Post("/payments/webhook", paymentwebhook.New(logger, paymentService, webhookVerifier, webhookTimeout).ServeHTTP)
 	})
 	//r.Get("/health", health.New(logger).ServeHTTP)
 
 	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/version", version.New(logger, buildinfo.Get()).ServeHTTP)
 	// Swagger docs endpoint
 	r.Get("/docs/*", httpSwagger.WrapHandler)
 }
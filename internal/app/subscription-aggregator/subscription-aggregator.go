@@ -8,59 +8,203 @@ import (
 	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/streadway/amqp"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/subscription/list"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/api/middlewarectx"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/grpc/client"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/cache"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/bankcsv"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/dateparse"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/jwt"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/smtp"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/migrations"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+	accountservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/account"
+	adminservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/admin"
 	paymentservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/payment"
+	schedulerservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/scheduler"
 	senderservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/sender"
 	subsaggregatorservice "github.com/magabrotheeeer/subscription-aggregator/internal/services/subscription"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/repository"
 )
 
+// Проверка на этапе компиляции: конкретные хранилища удовлетворяют интерфейсам сервисов.
+var (
+	_ subsaggregatorservice.SubscriptionRepository = (*repository.Storage)(nil)
+	_ subsaggregatorservice.Cache                  = (*cache.Cache)(nil)
+	_ adminservice.Repository                      = (*repository.Storage)(nil)
+	_ adminservice.Cache                           = (*cache.Cache)(nil)
+	_ accountservice.AccountRepository             = (*repository.Storage)(nil)
+	_ paymentservice.PaymentRepository             = (*repository.Storage)(nil)
+	_ paymentservice.IdempotencyStore              = (*repository.Storage)(nil)
+	_ paymentservice.IdempotencyStore              = (*cache.Cache)(nil)
+	_ senderservice.SubscriptionRepository         = (*repository.Storage)(nil)
+)
+
 // App представляет основное приложение subscription-aggregator.
 type App struct {
-	server *http.Server
-	logger *slog.Logger
-	db     *repository.Storage
-	cache  cache.Cache
+	server          *http.Server
+	shutdownTimeout time.Duration
+	logger          *slog.Logger
+	db              *repository.Storage
+	cache           cache.Cache
+	conn            *amqp.Connection
+	ch              *amqp.Channel
+	relayCh         *amqp.Channel             // Канал релея outbox, отдельный от канала обработчика очереди
+	webhooks        *paymentwebhook.Processor // Обработчик очереди webhook-уведомлений платежного провайдера
+	scheduler       *schedulerservice.SchedulerService
 }
 
 // New создает новый экземпляр основного приложения.
 func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
-	db, err := repository.New(cfg.StorageConnectionString)
+	db, err := repository.New(cfg.StorageConnectionString, cfg.StorageReplicaConnectionString, cfg.StorageStatementTimeout, cfg.DefaultCurrency)
 	if err != nil {
 		return nil, err
 	}
 	if err = migrations.Run(db.DB, "./migrations"); err != nil {
 		return nil, err
 	}
+	backfilled, err := db.BackfillCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if backfilled > 0 {
+		logger.Info("subscriptions without currency got the default currency",
+			slog.Int64("count", backfilled), slog.String("currency", cfg.DefaultCurrency))
+	}
 
 	cacheRedis, err := cache.InitServer(ctx, cfg.RedisConnection)
 	if err != nil {
 		return nil, err
 	}
 
-	authClient, err := client.NewAuthClient(cfg.GRPCAuthAddress)
+	authClient, err := client.NewAuthClient(cfg.GRPCAuthAddress, logger)
 	if err != nil {
 		return nil, err
 	}
+	// Без локальной проверки недоступность сервиса авторизации отклоняет все запросы
+	var tokenFallback middlewarectx.TokenParser
+	if cfg.JWTLocalFallback {
+		tokenFallback = jwt.NewJWTMaker(cfg.JWTSecretKey, cfg.TokenTTL)
+	}
 
-	providerService := yookassa.NewClient("заглушка", "заглушка")
-	paymentService := paymentservice.New(db, logger)
-	subscriptionService := subsaggregatorservice.NewSubscriptionService(db, cacheRedis, logger)
+	webhookVerifier, err := webhooksig.NewVerifier(webhooksig.HMACSHA256, webhookSecret, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	var provider yookassa.Provider = yookassa.NewClient("заглушка", "заглушка")
+	if cfg.PaymentsTestMode {
+		logger.Warn("payments test mode is enabled: payments are simulated, no real charges are made")
+		signer, err := webhooksig.NewSigner(webhooksig.HMACSHA256, webhookSecret, nil)
+		if err != nil {
+			return nil, err
+		}
+		provider = yookassa.NewStubProvider(cfg.PaymentsTestWebhookURL, signer)
+	}
+	providerService := yookassa.NewResilientClient(provider, cfg.PaymentProvider)
+	var idempotencyStore paymentservice.IdempotencyStore = db
+	if cfg.IdempotencyStore == "redis" {
+		idempotencyStore = cacheRedis
+	}
+	paymentService := paymentservice.New(db, idempotencyStore, cfg.IdempotencyTTL, cfg.RefundWindow, nil, logger)
+	dateFormats := cfg.DateFormats
+	if len(dateFormats) == 0 {
+		dateFormats = dateparse.DefaultLayouts
+	}
+	entryLimits := models.EntryLimits{
+		MaxPrice:            cfg.MaxPrice,
+		MaxCounterMonths:    cfg.MaxCounterMonths,
+		Currencies:          cfg.SupportedCurrencies,
+		AllowEndedUpdates:   cfg.AllowEndedUpdates,
+		MaxActivePerService: cfg.MaxActivePerService,
+	}
+	subscriptionService := subsaggregatorservice.NewSubscriptionService(db, cacheRedis, clock.Real{}, dateFormats, entryLimits, logger)
+	if cfg.RedisWarmupLimit > 0 {
+		warmupCache(ctx, subscriptionService, cfg.RedisWarmupLimit, cfg.RedisWarmupTimeout, logger)
+	}
+	adminService := adminservice.NewAdminService(db, cacheRedis, clock.Real{}, logger)
+	accountService := accountservice.NewAccountService(db, cfg.AccountDeletion, cfg.ReminderDaysBefore, logger)
+	// Планировщик нужен Main API для повтора уведомлений и релея outbox: принятые webhook-уведомления
+	// публикуются в очередь без отдельно запущенного планировщика
+	schedulerService := schedulerservice.NewSchedulerService(db, cacheRedis, providerService, clock.Real{}, cfg.Scheduler, logger)
 
 	// Создаем SMTP transport и sender service
 	smtpTransport := smtp.NewTransport(cfg, logger)
-	senderService := senderservice.NewSenderService(db, logger, smtpTransport)
+	senderService := senderservice.NewSenderService(db, logger, smtpTransport, senderservice.RetryPolicy{
+		MaxAttempts: cfg.SMTPMaxAttempts,
+		BaseDelay:   cfg.SMTPRetryDelay,
+		MaxDelay:    cfg.SMTPMaxRetryDelay,
+	})
+
+	// Webhook-уведомления принимаются HTTP-обработчиком, а обрабатываются из очереди
+	conn, err := rabbitmq.Connect(ctx, cfg.RabbitMQURL, rabbitmq.RetryPolicy{
+		MaxAttempts: cfg.RabbitMQMaxRetries,
+		BaseDelay:   cfg.RabbitMQRetryDelay,
+		MaxDelay:    cfg.RabbitMQMaxRetryDelay,
+		Jitter:      cfg.RabbitMQRetryJitter,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := rabbitmq.NotificationChannel(conn, cfg.RabbitMQURL, cfg.RabbitMQManagementURL, cfg.RabbitMQDeclareTopology)
+	if err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			logger.Error("failed to close connection", "error", closeErr)
+		}
+		return nil, err
+	}
+	relayCh, err := rabbitmq.OpenChannel(conn)
+	if err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			logger.Error("failed to close connection", "error", closeErr)
+		}
+		return nil, err
+	}
+	webhooks := paymentwebhook.NewProcessor(logger, paymentService, senderService, cfg.WebhookProcessTimeout)
 
 	router := chi.NewRouter()
 
-	RegisterRoutes(router, logger, subscriptionService, authClient, providerService, paymentService, senderService)
+	RegisterRoutes(router, logger, subscriptionService, authClient, tokenFallback, providerService, paymentService, senderService, adminService, accountService, schedulerService,
+		list.PageLimits{Default: cfg.PageSizeDefault, Max: cfg.PageSizeMax, AdminMax: cfg.AdminPageSizeMax},
+		cfg.MaxRequestBodySize.Bytes(),
+		cfg.MaxImportBodySize.Bytes(),
+		cfg.MaxConcurrentRequestsPerUser,
+		cfg.UserCacheTTL,
+		cfg.SMTPTestInterval,
+		cfg.WebhookTimeout,
+		webhookVerifier,
+		bankcsv.Mapping{
+			MerchantColumn: cfg.BankMerchantColumn,
+			AmountColumn:   cfg.BankAmountColumn,
+			DateColumn:     cfg.BankDateColumn,
+			DateLayout:     cfg.BankDateLayout,
+			Delimiter:      []rune(cfg.BankDelimiter)[0],
+			CounterMonths:  cfg.BankCounterMonths,
+		},
+		cfg.BankBatchSize,
+		models.Constraints{
+			DateFormats:      dateFormats,
+			MinPrice:         1,
+			MaxPrice:         entryLimits.MaxPrice,
+			MinCounterMonths: 1,
+			MaxCounterMonths: entryLimits.MaxCounterMonths,
+			Currencies:       entryLimits.Currencies,
+			DefaultCurrency:  cfg.DefaultCurrency,
+			PageSizeDefault:  cfg.PageSizeDefault,
+			PageSizeMax:      cfg.PageSizeMax,
+		},
+		cfg.CORSAllowedOrigins,
+		cfg.CORSMaxAge,
+		cfg.PublicPaths)
 
 	srv := &http.Server{
 		Addr:         cfg.AddressHTTP,
@@ -71,14 +215,39 @@ func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, er
 	}
 
 	return &App{
-		server: srv,
-		logger: logger,
-		db:     db,
-		cache:  *cacheRedis,
+		server:          srv,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		logger:          logger,
+		db:              db,
+		cache:           *cacheRedis,
+		conn:            conn,
+		ch:              ch,
+		relayCh:         relayCh,
+		webhooks:        webhooks,
+		scheduler:       schedulerService,
 	}, nil
 }
 
-// Run запускает основное приложение.
+// warmupCache загружает в кеш список тарифов и последние активные подписки. Прогрев ограничен timeout,
+// а его ошибка только записывается в лог: без прогрева приложение работает, но первые
+// запросы читают подписки из базы.
+func warmupCache(ctx context.Context, svc *subsaggregatorservice.SubscriptionService, limit int, timeout time.Duration, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	warmed, err := svc.WarmupCache(ctx, limit)
+	if err != nil {
+		logger.Warn("cache warmup failed, continuing with a cold cache", sl.Err(err))
+		return
+	}
+	logger.Info("cache warmed up", slog.Int("subscriptions", warmed), slog.Duration("duration", time.Since(start)))
+}
+
+// Run запускает HTTP-сервер, релей outbox и обработку очереди webhook-уведомлений и блокируется
+// до отмены ctx или остановки сервера или обработчика очереди. При остановке сервер перестает
+// принимать запросы, релей и обработчик очереди дорабатывают начатые уведомления, после чего
+// закрываются соединения с RabbitMQ и базой данных.
 func (a *App) Run(ctx context.Context) error {
 	errCh := make(chan error, 1)
 	go func() {
@@ -91,17 +260,54 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	consumerCtx, stopConsumer := context.WithCancel(ctx)
+	defer stopConsumer()
+	consumerDone := make(chan error, 1)
+	go func() {
+		consumerDone <- rabbitmq.ConsumerMessage(consumerCtx, a.ch, rabbitmq.PaymentWebhooksQueue, a.webhooks.HandleMessage)
+	}()
+	// Релеи Main API и планировщика забирают разные уведомления, поэтому могут работать одновременно
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		a.scheduler.RelayOutbox(consumerCtx, a.relayCh)
+	}()
+
+	var err error
+	serverStopped, consumerStopped := false, false
 	select {
-	case err := <-errCh:
-		return err
+	case err = <-errCh:
+		serverStopped = true
+	case err = <-consumerDone:
+		consumerStopped = true
+		a.logger.Error("payment webhooks consumer stopped", "error", err)
 	case <-ctx.Done():
-		timeoutCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	}
+
+	if !serverStopped {
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
 		defer cancel()
 		a.logger.Info("shutting down HTTP server gracefully")
-		err := a.server.Shutdown(timeoutCtx)
-		if closeErr := a.db.DB.Close(); closeErr != nil {
-			a.logger.Error("failed to close database connection", "error", closeErr)
+		if shutdownErr := a.server.Shutdown(timeoutCtx); shutdownErr != nil && err == nil {
+			err = shutdownErr
 		}
-		return err
 	}
+	stopConsumer()
+	if !consumerStopped {
+		<-consumerDone
+	}
+	<-relayDone
+	if closeErr := a.relayCh.Close(); closeErr != nil {
+		a.logger.Error("failed to close relay channel", "error", closeErr)
+	}
+	if closeErr := a.ch.Close(); closeErr != nil {
+		a.logger.Error("failed to close channel", "error", closeErr)
+	}
+	if closeErr := a.conn.Close(); closeErr != nil {
+		a.logger.Error("failed to close connection", "error", closeErr)
+	}
+	if closeErr := a.db.Close(); closeErr != nil {
+		a.logger.Error("failed to close database connection", "error", closeErr)
+	}
+	return err
 }
@@ -2,30 +2,171 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// ErrInvalidConfig возвращается, если значение конфига не прошло проверку.
+var ErrInvalidConfig = errors.New("invalid config")
+
 // Config общая структура для хранения настроек
 type Config struct {
 	Env                     string `yaml:"env"`
 	GRPCAuthAddress         string `yaml:"grpc_auth_address"`
-	StorageConnectionString string `yaml:"storage_connection_string"`
+	StorageConnectionString string `yaml:"storage_connection_string"` // если не задана, собирается из секции storage
+	// StorageReplicaConnectionString — реплика для списков, поиска и сводок; пусто — все запросы идут в основную базу
+	StorageReplicaConnectionString string `yaml:"storage_replica_connection_string" env:"STORAGE_REPLICA_CONNECTION_STRING"`
+	// DefaultCurrency — код валюты ISO 4217 для подписок, у которых валюта не сохранена
+	DefaultCurrency string `yaml:"default_currency" env:"DEFAULT_CURRENCY" env-default:"RUB"`
+	// DateFormats — допустимые форматы start_date подписки в нотации time.Parse, пробуются по порядку;
+	// импорт из CSV передает даты в формате 02-01-2006, поэтому его стоит оставить в списке
+	DateFormats []string `yaml:"date_formats" env:"DATE_FORMATS" env-default:"2006-01-02,02-01-2006,01-2006"`
+	// StorageStatementTimeout — максимальное время выполнения одного SQL-запроса; 0 — без ограничения
+	StorageStatementTimeout time.Duration `yaml:"storage_statement_timeout" env:"STORAGE_STATEMENT_TIMEOUT" env-default:"30s"`
+	Storage                 `yaml:"storage"`
 	RedisConnection         `yaml:"redis_connection"`
 	HTTPServer              `yaml:"http_server"`
 	JWTToken                `yaml:"jwttoken"`
 	SMTP                    `yaml:"smtp"`
 	RabbitMQ                `yaml:"rabbitmq"`
+	AccountDeletion         `yaml:"account_deletion"`
+	Idempotency             `yaml:"idempotency"`
+	Registration            `yaml:"registration"`
+	AuthGRPC                `yaml:"auth_grpc"`
+	PaymentProvider         `yaml:"payment_provider"`
+	Logging                 `yaml:"logging"`
+	Pagination              `yaml:"pagination"`
+	Scheduler               `yaml:"scheduler"`
+	BankImport              `yaml:"bank_import"`
+	SubscriptionLimits      `yaml:"subscription_limits"`
+}
+
+// SubscriptionLimits хранит ограничения значений подписки; их проверяет сервис подписок
+// и отдает GET /meta/constraints. Нулевое значение снимает ограничение, по умолчанию
+// ограничения не заданы
+type SubscriptionLimits struct {
+	MaxPrice         int `yaml:"max_price" env-default:"0"`          // максимальная цена за месяц
+	MaxCounterMonths int `yaml:"max_counter_months" env-default:"0"` // максимальный срок в месяцах
+	// SupportedCurrencies — допустимые коды валют ISO 4217; пустой список разрешает любую валюту
+	SupportedCurrencies []string `yaml:"supported_currencies"`
+	// AllowEndedUpdates разрешает обновлять подписки так, что их срок уже закончился
+	AllowEndedUpdates bool `yaml:"allow_ended_updates" env-default:"false"`
+	// MaxActivePerService — сколько активных подписок на один сервис может быть у пользователя; 0 снимает ограничение
+	MaxActivePerService int `yaml:"max_active_per_service" env-default:"0"`
+}
+
+// BankImport хранит соответствие колонок выписки банка полям подписки при импорте из CSV
+type BankImport struct {
+	BankMerchantColumn string `yaml:"bank_merchant_column" env-default:"merchant"` // становится service_name
+	BankAmountColumn   string `yaml:"bank_amount_column" env-default:"amount"`     // становится price
+	BankDateColumn     string `yaml:"bank_date_column" env-default:"date"`         // становится start_date
+	BankDateLayout     string `yaml:"bank_date_layout" env-default:"02.01.2006"`   // формат даты в нотации time.Parse
+	BankDelimiter      string `yaml:"bank_delimiter" env-default:","`
+	BankCounterMonths  int    `yaml:"bank_counter_months" env-default:"12"` // срок создаваемых подписок
+	BankBatchSize      int    `yaml:"bank_batch_size" env-default:"500"`    // подписок в одной транзакции импорта
+}
+
+// Scheduler хранит периодичность фоновых задач планировщика
+type Scheduler struct {
+	ExpiringTomorrowInterval time.Duration `yaml:"expiring_tomorrow_interval" env-default:"12h"` // уведомления о подписках, истекающих завтра
+	ExpiringTodayInterval    time.Duration `yaml:"expiring_today_interval" env-default:"24h"`    // уведомления о подписках, истекающих сегодня
+	TrialConversionInterval  time.Duration `yaml:"trial_conversion_interval" env-default:"24h"`  // списания по окончании пробного периода
+	PaymentDateInterval      time.Duration `yaml:"payment_date_interval" env-default:"24h"`      // перенос прошедших дат следующего платежа
+	AccountDeletionInterval  time.Duration `yaml:"account_deletion_interval" env-default:"24h"`  // отложенное удаление аккаунтов
+	ArchiveInterval          time.Duration `yaml:"archive_interval" env-default:"24h"`           // архивация старых неактивных подписок
+	ArchiveRetention         time.Duration `yaml:"archive_retention" env-default:"8760h"`        // сколько хранить подписку после окончания срока
+	OutboxRelayInterval      time.Duration `yaml:"outbox_relay_interval" env-default:"10s"`      // публикация уведомлений из outbox и повтор неудавшихся
+	ExpiryInterval           time.Duration `yaml:"expiry_interval" env-default:"1h"`             // перевод пользователей с истекшей подпиской в статус expired
+	MetricsAddress           string        `yaml:"metrics_address" env-default:":9091"`          // адрес обработчика /metrics планировщика
+	// DeadLetterQueue — очередь недоставленных сообщений, размер которой отслеживает планировщик; пустое значение отключает проверку
+	DeadLetterQueue         string        `yaml:"dead_letter_queue" env-default:""`
+	DeadLetterThreshold     int           `yaml:"dead_letter_threshold" env-default:"100"`     // при скольких сообщениях в очереди писать предупреждение, не меньше 1
+	DeadLetterCheckInterval time.Duration `yaml:"dead_letter_check_interval" env-default:"1m"` // как часто проверять размер очереди
+	// ReminderDaysBefore — за сколько дней до окончания подписки напоминать пользователям без собственного срока
+	ReminderDaysBefore []int `yaml:"reminder_days_before" env-default:"1"`
+	// MinDaysBetweenNotifications — сколько дней должно пройти после уведомления о подписке до следующего; 0 отключает ограничение
+	MinDaysBetweenNotifications int `yaml:"min_days_between_notifications" env-default:"0"`
+	// ExpiryGracePeriod — сколько после subscription_expiry пользователь остается в статусе active, прежде чем доступ будет отключен
+	ExpiryGracePeriod time.Duration `yaml:"expiry_grace_period" env-default:"0s"`
+}
+
+// Pagination хранит ограничения размера страницы для списков подписок
+type Pagination struct {
+	PageSizeDefault  int `yaml:"page_size_default" env-default:"10"`
+	PageSizeMax      int `yaml:"page_size_max" env-default:"100"`
+	AdminPageSizeMax int `yaml:"admin_page_size_max" env-default:"500"`
+}
+
+// Logging хранит настройки логирования
+type Logging struct {
+	LogRedactFields []string `yaml:"log_redact_fields" env-default:"password,token,authorization,payment_method_token"` // значения этих полей скрываются в логах
+}
+
+// PaymentProvider хранит настройки повторов и автоматического выключателя для платежного провайдера
+type PaymentProvider struct {
+	ProviderMaxRetries       int           `yaml:"provider_max_retries" env-default:"2"`
+	ProviderRetryDelay       time.Duration `yaml:"provider_retry_delay" env-default:"200ms"`
+	ProviderBreakerThreshold int           `yaml:"provider_breaker_threshold" env-default:"5"`
+	ProviderBreakerCooldown  time.Duration `yaml:"provider_breaker_cooldown" env-default:"30s"`
+	// PaymentsTestMode включает тестового провайдера без реальных списаний: токен "fail_*" — отказ
+	PaymentsTestMode bool `yaml:"payments_test_mode" env:"PAYMENTS_TEST_MODE" env-default:"false"`
+	// PaymentsTestWebhookURL — адрес, на который тестовый провайдер отправляет webhook-уведомления
+	PaymentsTestWebhookURL string `yaml:"payments_test_webhook_url" env:"PAYMENTS_TEST_WEBHOOK_URL"`
+	// RefundWindow — сколько времени после оплаты пользователь может запросить возврат; 0 отключает возвраты
+	RefundWindow time.Duration `yaml:"refund_window" env:"REFUND_WINDOW" env-default:"336h"`
+	// WebhookTimeout ограничивает сохранение webhook-уведомления перед ответом провайдеру
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"5s"`
+	// WebhookProcessTimeout ограничивает обработку одного webhook-уведомления из очереди
+	WebhookProcessTimeout time.Duration `yaml:"webhook_process_timeout" env-default:"1m"`
+}
+
+// AuthGRPC хранит настройки gRPC-сервера авторизации
+type AuthGRPC struct {
+	GRPCReflection     bool          `yaml:"grpc_reflection" env-default:"true"` // в production можно отключить
+	GRPCHealthInterval time.Duration `yaml:"grpc_health_interval" env-default:"10s"`
+}
+
+// Registration хранит настройки регистрации пользователей
+type Registration struct {
+	AllowedEmailDomains []string `yaml:"allowed_email_domains"` // пустой список — разрешены любые домены
+}
+
+// Idempotency хранит настройки хранилища идемпотентности webhook-уведомлений
+type Idempotency struct {
+	IdempotencyStore string        `yaml:"idempotency_store" env-default:"postgres"` // postgres или redis
+	IdempotencyTTL   time.Duration `yaml:"idempotency_ttl" env-default:"72h"`
+}
+
+// AccountDeletion хранит настройки удаления аккаунта пользователя
+type AccountDeletion struct {
+	DeletionImmediate   bool          `yaml:"deletion_immediate" env-default:"true"`
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env-default:"720h"`
 }
 
 // RabbitMQ cхранит в себе подключение к RabbitMQ
 type RabbitMQ struct {
-	RabbitMQURL        string        `yaml:"rabbitmq_url"`
-	RabbitMQMaxRetries int           `yaml:"rabbitmq_maxretries"`
-	RabbitMQRetryDelay time.Duration `yaml:"rabbitmq_retry_delay"`
+	RabbitMQURL string `yaml:"rabbitmq_url"`
+	// RabbitMQMaxRetries — число попыток подключения при старте, включая первую
+	RabbitMQMaxRetries int `yaml:"rabbitmq_maxretries" env-default:"5"`
+	// RabbitMQRetryDelay — задержка перед первым повтором; каждая следующая вдвое больше
+	RabbitMQRetryDelay    time.Duration `yaml:"rabbitmq_retry_delay" env-default:"1s"`
+	RabbitMQMaxRetryDelay time.Duration `yaml:"rabbitmq_max_retry_delay" env-default:"30s"`
+	// RabbitMQRetryJitter — доля от 0 до 1, на которую задержка случайно уменьшается
+	RabbitMQRetryJitter float64 `yaml:"rabbitmq_retry_jitter" env-default:"0.5"`
+	// RabbitMQDeclareTopology — сервисы сами объявляют exchange, очереди и привязки при старте.
+	// Если выключено, топология создается заранее и при старте только проверяется
+	RabbitMQDeclareTopology bool `yaml:"rabbitmq_declare_topology" env-default:"true"`
+	// RabbitMQManagementURL — адрес HTTP API management для проверки привязок при старте
+	// без объявления топологии; если не задан, проверяются только exchange и очереди
+	RabbitMQManagementURL string `yaml:"rabbitmq_management_url"`
 }
 
 // SMTP хранит в себе подключение к SMTP
@@ -34,6 +175,12 @@ type SMTP struct {
 	SMTPPort string `yaml:"smtp_port"`
 	SMTPUser string `yaml:"smtp_user"`
 	SMTPPass string `yaml:"smtp_pass"`
+	// Повторы отправки при временных ошибках SMTP (4xx, сетевые ошибки)
+	SMTPMaxAttempts   int           `yaml:"smtp_max_attempts" env-default:"3"`
+	SMTPRetryDelay    time.Duration `yaml:"smtp_retry_delay" env-default:"1s"`
+	SMTPMaxRetryDelay time.Duration `yaml:"smtp_max_retry_delay" env-default:"30s"`
+	// SMTPTestInterval — как часто один пользователь может запрашивать тестовое письмо
+	SMTPTestInterval time.Duration `yaml:"smtp_test_interval" env-default:"1m"`
 }
 
 // HTTPServer структура для настройки сервера
@@ -41,6 +188,23 @@ type HTTPServer struct {
 	AddressHTTP string        `yaml:"addresshttp"`
 	TimeoutHTTP time.Duration `yaml:"timeouthttp"`
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout — сколько ждать завершения активных запросов при остановке сервера
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+	// MaxRequestBodySize — максимальный размер тела JSON-запроса API, например "1MB"
+	MaxRequestBodySize ByteSize `yaml:"max_request_body_size" env:"MAX_REQUEST_BODY_SIZE" env-default:"1MB"`
+	// MaxImportBodySize — максимальный размер CSV-выписки в POST /subscriptions/import
+	MaxImportBodySize ByteSize `yaml:"max_import_body_size" env:"MAX_IMPORT_BODY_SIZE" env-default:"10MB"`
+	// MaxConcurrentRequestsPerUser — сколько запросов один пользователь может выполнять одновременно; 0 — без ограничения
+	MaxConcurrentRequestsPerUser int `yaml:"max_concurrent_requests_per_user" env:"MAX_CONCURRENT_REQUESTS_PER_USER" env-default:"10"`
+	// UserCacheTTL — сколько middleware аутентифицированных запросов хранят пользователя в памяти; 0 — читать из базы на каждый запрос
+	UserCacheTTL time.Duration `yaml:"user_cache_ttl" env:"USER_CACHE_TTL" env-default:"15s"`
+	// PublicPaths — пути, освобожденные от аутентификации и CORS; "/docs/*" совпадает со всеми путями с префиксом "/docs/".
+	// Регистрации и входа в списке нет: они открыты и так, а браузерным клиентам нужен для них CORS
+	PublicPaths []string `yaml:"public_paths" env-default:"/api/v1/payments/webhook,/metrics,/version,/docs/*"`
+	// CORSAllowedOrigins — origin страниц, которым разрешены запросы к API из браузера; "*" — любым; пусто — CORS выключен
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// CORSMaxAge — сколько браузер кэширует ответ на preflight-запрос
+	CORSMaxAge time.Duration `yaml:"cors_max_age" env:"CORS_MAX_AGE" env-default:"10m"`
 }
 
 // RedisConnection структура для настройки подключения к redis
@@ -52,12 +216,18 @@ type RedisConnection struct {
 	RedisMaxRetries   int           `yaml:"max_retries"`
 	RedisDialTimeout  time.Duration `yaml:"dial_timeout"`
 	RedisTimeoutRedis time.Duration `yaml:"timeoutredis"`
+	// RedisWarmupLimit — сколько последних активных подписок загрузить в кеш при запуске; 0 отключает прогрев
+	RedisWarmupLimit int `yaml:"warmup_limit" env:"REDIS_WARMUP_LIMIT" env-default:"0"`
+	// RedisWarmupTimeout ограничивает прогрев кеша, чтобы он не задерживал запуск
+	RedisWarmupTimeout time.Duration `yaml:"warmup_timeout" env-default:"10s"`
 }
 
 // JWTToken структура для работы с jwt-токеном
 type JWTToken struct {
 	JWTSecretKey string        `yaml:"jwt_secret_key"`
 	TokenTTL     time.Duration `yaml:"token_ttl"`
+	// JWTLocalFallback — проверять подпись токена ключом jwt_secret_key, если сервис авторизации недоступен
+	JWTLocalFallback bool `yaml:"jwt_local_fallback" env:"JWT_LOCAL_FALLBACK" env-default:"false"`
 }
 
 // MustLoad функция для загрузки конфига, возвращает конфиг, сгенерированный из config/config.go
@@ -69,10 +239,171 @@ func MustLoad() *Config {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		log.Fatalf("file: %s - does not exist", configPath)
 	}
-	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+	cfg, err := Load(configPath)
+	if err != nil {
 		log.Fatalf("cannot read config: %s", err)
 	}
-	return &cfg
+	return cfg
+}
+
+// Load читает конфиг из файла и переменных окружения и проверяет его.
+// Длительности задаются строками вида "15s" или "24h", размеры — строками вида "10MB".
+func Load(configPath string) (*Config, error) {
+	const op = "config.Load"
+
+	var cfg Config
+	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if cfg.StorageConnectionString == "" && cfg.StorageHost != "" {
+		// Параметры уже проверены в Validate
+		cfg.StorageConnectionString, _ = cfg.DSN()
+	}
+	return &cfg, nil
+}
+
+// Validate проверяет значения конфига: длительности и размеры не могут быть отрицательными,
+// периоды планировщика и максимальные размеры тела запроса должны быть положительными,
+// сроки напоминаний — от 1 до 30 дней, разделитель выписки банка — одним символом.
+// Подключение к Postgres задается либо строкой storage_connection_string, либо секцией storage.
+func (c *Config) Validate() error {
+	var errs []error
+	validateNonNegative(reflect.ValueOf(c).Elem(), "", &errs)
+
+	positive := []struct {
+		name  string
+		value int64
+	}{
+		{"http_server.max_request_body_size", c.MaxRequestBodySize.Bytes()},
+		{"http_server.max_import_body_size", c.MaxImportBodySize.Bytes()},
+		{"scheduler.expiring_tomorrow_interval", int64(c.ExpiringTomorrowInterval)},
+		{"scheduler.expiring_today_interval", int64(c.ExpiringTodayInterval)},
+		{"scheduler.trial_conversion_interval", int64(c.TrialConversionInterval)},
+		{"scheduler.payment_date_interval", int64(c.PaymentDateInterval)},
+		{"scheduler.account_deletion_interval", int64(c.AccountDeletionInterval)},
+		{"scheduler.archive_interval", int64(c.ArchiveInterval)},
+		{"scheduler.archive_retention", int64(c.ArchiveRetention)},
+		{"scheduler.expiry_interval", int64(c.ExpiryInterval)},
+		{"smtp.smtp_test_interval", int64(c.SMTPTestInterval)},
+		{"payment_provider.webhook_timeout", int64(c.WebhookTimeout)},
+		{"payment_provider.webhook_process_timeout", int64(c.WebhookProcessTimeout)},
+	}
+	for _, p := range positive {
+		if p.value == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be positive", ErrInvalidConfig, p.name))
+		}
+	}
+	if utf8.RuneCountInString(c.BankDelimiter) != 1 {
+		errs = append(errs, fmt.Errorf("%w: bank_import.bank_delimiter must be a single character", ErrInvalidConfig))
+	}
+	if c.BankCounterMonths <= 0 {
+		errs = append(errs, fmt.Errorf("%w: bank_import.bank_counter_months must be positive", ErrInvalidConfig))
+	}
+	if c.BankBatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("%w: bank_import.bank_batch_size must be positive", ErrInvalidConfig))
+	}
+	if c.DeadLetterThreshold < 1 {
+		errs = append(errs, fmt.Errorf("%w: scheduler.dead_letter_threshold must be at least 1", ErrInvalidConfig))
+	}
+	if c.RabbitMQMaxRetryDelay > 0 && c.RabbitMQMaxRetryDelay < c.RabbitMQRetryDelay {
+		errs = append(errs, fmt.Errorf("%w: rabbitmq.rabbitmq_max_retry_delay must not be less than rabbitmq.rabbitmq_retry_delay", ErrInvalidConfig))
+	}
+	if c.RabbitMQRetryJitter < 0 || c.RabbitMQRetryJitter > 1 {
+		errs = append(errs, fmt.Errorf("%w: rabbitmq.rabbitmq_retry_jitter must be between 0 and 1", ErrInvalidConfig))
+	}
+	if c.JWTLocalFallback && c.JWTSecretKey == "" {
+		errs = append(errs, fmt.Errorf("%w: jwttoken.jwt_secret_key must be set when jwttoken.jwt_local_fallback is enabled", ErrInvalidConfig))
+	}
+	if c.StorageHost != "" {
+		if c.StorageConnectionString != "" {
+			errs = append(errs, fmt.Errorf("%w: storage_connection_string and storage.storage_host must not be set together", ErrInvalidConfig))
+		} else if _, err := c.DSN(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.RedisWarmupLimit < 0 {
+		errs = append(errs, fmt.Errorf("%w: redis_connection.warmup_limit must not be negative", ErrInvalidConfig))
+	}
+	if !isCurrencyCode(c.DefaultCurrency) {
+		errs = append(errs, fmt.Errorf("%w: default_currency must be a three-letter uppercase ISO 4217 code", ErrInvalidConfig))
+	}
+	if c.MaxPrice < 0 {
+		errs = append(errs, fmt.Errorf("%w: subscription_limits.max_price must not be negative", ErrInvalidConfig))
+	}
+	if c.MaxCounterMonths < 0 {
+		errs = append(errs, fmt.Errorf("%w: subscription_limits.max_counter_months must not be negative", ErrInvalidConfig))
+	}
+	if c.MaxActivePerService < 0 {
+		errs = append(errs, fmt.Errorf("%w: subscription_limits.max_active_per_service must not be negative", ErrInvalidConfig))
+	}
+	for _, code := range c.SupportedCurrencies {
+		if !isCurrencyCode(code) {
+			errs = append(errs, fmt.Errorf("%w: subscription_limits.supported_currencies must contain three-letter uppercase ISO 4217 codes, got %q", ErrInvalidConfig, code))
+		}
+	}
+	if len(c.ReminderDaysBefore) == 0 {
+		errs = append(errs, fmt.Errorf("%w: scheduler.reminder_days_before must not be empty", ErrInvalidConfig))
+	}
+	for _, days := range c.ReminderDaysBefore {
+		if days < 1 || days > 30 {
+			errs = append(errs, fmt.Errorf("%w: scheduler.reminder_days_before must contain days between 1 and 30, got %d", ErrInvalidConfig, days))
+		}
+	}
+	if c.MinDaysBetweenNotifications < 0 {
+		errs = append(errs, fmt.Errorf("%w: scheduler.min_days_between_notifications must not be negative", ErrInvalidConfig))
+	}
+	if c.SMTPMaxRetryDelay > 0 && c.SMTPMaxRetryDelay < c.SMTPRetryDelay {
+		errs = append(errs, fmt.Errorf("%w: smtp.smtp_max_retry_delay must not be less than smtp.smtp_retry_delay", ErrInvalidConfig))
+	}
+	return errors.Join(errs...)
+}
+
+// isCurrencyCode сообщает, является ли code трехбуквенным кодом валюты в верхнем регистре.
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+)
+
+// validateNonNegative рекурсивно обходит структуру конфига и добавляет ошибку
+// для каждого отрицательного поля типа time.Duration или ByteSize.
+func validateNonNegative(v reflect.Value, prefix string, errs *[]error) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		value := v.Field(i)
+		switch field.Type {
+		case durationType, byteSizeType:
+			if value.Int() < 0 {
+				*errs = append(*errs, fmt.Errorf("%w: %s must not be negative", ErrInvalidConfig, name))
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			validateNonNegative(value, name, errs)
+		}
+	}
 }
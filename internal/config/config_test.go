@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -166,3 +167,214 @@ jwttoken:
 	assert.Empty(t, output)
 	assert.False(t, panicked)
 }
+
+// writeConfig записывает конфиг во временный файл и возвращает путь к нему
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_DurationsAndSizes(t *testing.T) {
+	path := writeConfig(t, `
+env: test
+http_server:
+  addresshttp: ":8080"
+  timeouthttp: 15s
+  shutdown_timeout: 1m30s
+  max_request_body_size: 10MB
+scheduler:
+  expiring_tomorrow_interval: 6h
+  account_deletion_interval: 30m
+`)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 15*time.Second, cfg.TimeoutHTTP)
+	assert.Equal(t, 90*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, 10*Megabyte, cfg.MaxRequestBodySize)
+	assert.Equal(t, 6*time.Hour, cfg.ExpiringTomorrowInterval)
+	assert.Equal(t, 30*time.Minute, cfg.AccountDeletionInterval)
+	// Значения по умолчанию
+	assert.Equal(t, 24*time.Hour, cfg.ExpiringTodayInterval)
+	assert.Equal(t, 24*time.Hour, cfg.TrialConversionInterval)
+	assert.Equal(t, 24*time.Hour, cfg.PaymentDateInterval)
+	assert.Equal(t, 30*time.Second, cfg.StorageStatementTimeout)
+	assert.Equal(t, 336*time.Hour, cfg.RefundWindow)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "env: test\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 15*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, Megabyte, cfg.MaxRequestBodySize)
+	assert.Equal(t, 10*Megabyte, cfg.MaxImportBodySize)
+	assert.Equal(t, 12*time.Hour, cfg.ExpiringTomorrowInterval)
+	assert.Equal(t, ",", cfg.BankDelimiter)
+	assert.Equal(t, 12, cfg.BankCounterMonths)
+	assert.Equal(t, 5, cfg.RabbitMQMaxRetries)
+	assert.Equal(t, time.Second, cfg.RabbitMQRetryDelay)
+	assert.Equal(t, 30*time.Second, cfg.RabbitMQMaxRetryDelay)
+	assert.Equal(t, 0.5, cfg.RabbitMQRetryJitter)
+	assert.True(t, cfg.RabbitMQDeclareTopology)
+	assert.Equal(t, "RUB", cfg.DefaultCurrency)
+	assert.Equal(t, []int{1}, cfg.ReminderDaysBefore)
+	assert.Equal(t, 0, cfg.RedisWarmupLimit)
+	assert.Equal(t, 10*time.Second, cfg.RedisWarmupTimeout)
+	assert.Zero(t, cfg.MaxPrice)
+	assert.Zero(t, cfg.MaxCounterMonths)
+	assert.False(t, cfg.AllowEndedUpdates)
+	assert.Empty(t, cfg.SupportedCurrencies)
+	assert.Equal(t, []string{"/api/v1/payments/webhook", "/metrics", "/version", "/docs/*"}, cfg.PublicPaths)
+	assert.Empty(t, cfg.CORSAllowedOrigins)
+	assert.Equal(t, 10*time.Minute, cfg.CORSMaxAge)
+}
+
+func TestLoad_SizeFromEnv(t *testing.T) {
+	t.Setenv("MAX_REQUEST_BODY_SIZE", "256KB")
+
+	cfg, err := Load(writeConfig(t, "env: test\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 256*Kilobyte, cfg.MaxRequestBodySize)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "длительность без единицы",
+			content: "http_server:\n  timeouthttp: 15\n",
+		},
+		{
+			name:    "некорректная длительность",
+			content: "scheduler:\n  expiring_today_interval: daily\n",
+		},
+		{
+			name:    "некорректный размер",
+			content: "http_server:\n  max_request_body_size: 10XB\n",
+		},
+		{
+			name:    "отрицательная длительность",
+			content: "jwttoken:\n  token_ttl: -1h\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "разделитель выписки из нескольких символов",
+			content: "bank_import:\n  bank_delimiter: \";;\"\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "отрицательный размер пачки импорта",
+			content: "bank_import:\n  bank_batch_size: -5\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "jitter подключения к RabbitMQ больше единицы",
+			content: "rabbitmq:\n  rabbitmq_retry_jitter: 1.5\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "валюта по умолчанию не в формате ISO 4217",
+			content: "default_currency: rub\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "отрицательная максимальная цена подписки",
+			content: "subscription_limits:\n  max_price: -1\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "отрицательное число активных подписок на сервис",
+			content: "subscription_limits:\n  max_active_per_service: -1\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "недопустимый код в списке валют",
+			content: "subscription_limits:\n  supported_currencies: [USD, eur]\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "срок напоминания больше 30 дней",
+			content: "scheduler:\n  reminder_days_before: [7, 45]\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "отрицательный промежуток между уведомлениями",
+			content: "scheduler:\n  min_days_between_notifications: -1\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "локальная проверка JWT без секретного ключа",
+			content: "jwttoken:\n  jwt_local_fallback: true\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "отрицательный порог очереди недоставленных сообщений",
+			content: "scheduler:\n  dead_letter_threshold: -1\n",
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "максимальная задержка SMTP меньше начальной",
+			content: "smtp:\n  smtp_retry_delay: 10s\n  smtp_max_retry_delay: 1s\n",
+			wantErr: ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.content))
+
+			assert.Error(t, err)
+			assert.Nil(t, cfg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_ZeroSizeFromEnv(t *testing.T) {
+	// Нулевое значение в YAML заменяется значением по умолчанию, а из переменной окружения — нет
+	t.Setenv("MAX_REQUEST_BODY_SIZE", "0")
+
+	cfg, err := Load(writeConfig(t, "env: test\n"))
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Nil(t, cfg)
+}
+
+func TestConfig_ValidateReportsFieldName(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "env: test\n"))
+	require.NoError(t, err)
+
+	cfg.RedisDialTimeout = -time.Second
+	cfg.DeletionGracePeriod = -time.Hour
+	cfg.PaymentDateInterval = 0
+
+	err = cfg.Validate()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "redis_connection.dial_timeout")
+	assert.ErrorContains(t, err, "account_deletion.deletion_grace_period")
+	assert.ErrorContains(t, err, "scheduler.payment_date_interval")
+}
+
+func TestConfig_ValidateDeadLetterThreshold(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "env: test\n"))
+	require.NoError(t, err)
+
+	// Нулевой порог из YAML заменяется значением по умолчанию, поэтому проверяем Validate напрямую
+	cfg.DeadLetterThreshold = 0
+	err = cfg.Validate()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "scheduler.dead_letter_threshold")
+
+	cfg.DeadLetterThreshold = 1
+	assert.NoError(t, cfg.Validate())
+}
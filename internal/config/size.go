@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSize возвращается, если размер не удалось разобрать.
+var ErrInvalidSize = errors.New("invalid size")
+
+// Единицы размера. Множители двоичные: 1KB = 1024B.
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1024 * Byte
+	Megabyte          = 1024 * Kilobyte
+	Gigabyte          = 1024 * Megabyte
+)
+
+// sizeUnits сопоставляет суффикс единице; более длинные суффиксы проверяются первыми.
+var sizeUnits = []struct {
+	suffix string
+	unit   ByteSize
+}{
+	{"GB", Gigabyte},
+	{"MB", Megabyte},
+	{"KB", Kilobyte},
+	{"B", Byte},
+}
+
+// ByteSize — размер в байтах, который в конфиге задается строкой вида "512KB", "10MB" или "1GB".
+// Число без суффикса трактуется как количество байт.
+type ByteSize int64
+
+// ParseByteSize разбирает размер вида "10MB". Суффикс нечувствителен к регистру,
+// допускается пробел между числом и единицей. Отрицательные и дробные значения не допускаются.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	upper := strings.ToUpper(s)
+
+	unit := Byte
+	number := upper
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			unit = u.unit
+			number = strings.TrimSpace(strings.TrimSuffix(upper, u.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("%w: %q overflows", ErrInvalidSize, s)
+	}
+	return ByteSize(n) * unit, nil
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler для чтения размера из YAML и переменных окружения.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// String возвращает размер в наибольшей единице, в которой он выражается целым числом.
+func (b ByteSize) String() string {
+	for _, u := range sizeUnits {
+		if b != 0 && b%u.unit == 0 {
+			return strconv.FormatInt(int64(b/u.unit), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Bytes возвращает размер в байтах.
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{name: "байты без суффикса", input: "512", want: 512},
+		{name: "байты с суффиксом", input: "512B", want: 512},
+		{name: "килобайты", input: "64KB", want: 64 * Kilobyte},
+		{name: "мегабайты", input: "10MB", want: 10 * Megabyte},
+		{name: "гигабайты", input: "2GB", want: 2 * Gigabyte},
+		{name: "нижний регистр", input: "10mb", want: 10 * Megabyte},
+		{name: "пробел перед единицей", input: " 10 MB ", want: 10 * Megabyte},
+		{name: "ноль", input: "0", want: 0},
+		{name: "пустая строка", input: "", wantErr: true},
+		{name: "только единица", input: "MB", wantErr: true},
+		{name: "неизвестная единица", input: "10TB", wantErr: true},
+		{name: "дробное значение", input: "1.5MB", wantErr: true},
+		{name: "отрицательное значение", input: "-1MB", wantErr: true},
+		{name: "переполнение", input: "9999999999GB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSize)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestByteSize_UnmarshalText(t *testing.T) {
+	var size ByteSize
+	assert.NoError(t, size.UnmarshalText([]byte("1MB")))
+	assert.Equal(t, int64(1<<20), size.Bytes())
+
+	assert.ErrorIs(t, size.UnmarshalText([]byte("ten")), ErrInvalidSize)
+	assert.Equal(t, int64(1<<20), size.Bytes(), "значение не меняется при ошибке")
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "100B", ByteSize(100).String())
+	assert.Equal(t, "1536B", ByteSize(1536).String())
+	assert.Equal(t, "512KB", (512 * Kilobyte).String())
+	assert.Equal(t, "10MB", (10 * Megabyte).String())
+	assert.Equal(t, "1GB", Gigabyte.String())
+}
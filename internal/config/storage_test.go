@@ -0,0 +1,126 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_DSN(t *testing.T) {
+	base := Storage{
+		StorageHost:     "db",
+		StoragePort:     5432,
+		StorageUser:     "app",
+		StoragePassword: "secret",
+		StorageDBName:   "subscriptions",
+		StorageSSLMode:  "prefer",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Storage)
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "без TLS",
+			modify: func(s *Storage) { s.StorageSSLMode = "disable" },
+			want:   "postgres://app:secret@db:5432/subscriptions?sslmode=disable",
+		},
+		{
+			name: "проверка сертификата сервера",
+			modify: func(s *Storage) {
+				s.StorageSSLMode = "verify-full"
+				s.StorageSSLRootCert = "/etc/ssl/pg/root.crt"
+			},
+			want: "postgres://app:secret@db:5432/subscriptions?sslmode=verify-full&sslrootcert=%2Fetc%2Fssl%2Fpg%2Froot.crt",
+		},
+		{
+			name:   "спецсимволы в пароле экранируются",
+			modify: func(s *Storage) { s.StoragePassword = "p@ss:w/rd?" },
+			want:   "postgres://app:p%40ss%3Aw%2Frd%3F@db:5432/subscriptions?sslmode=prefer",
+		},
+		{
+			name:   "без пароля",
+			modify: func(s *Storage) { s.StoragePassword = "" },
+			want:   "postgres://app@db:5432/subscriptions?sslmode=prefer",
+		},
+		{
+			name:   "адрес IPv6",
+			modify: func(s *Storage) { s.StorageHost = "::1" },
+			want:   "postgres://app:secret@[::1]:5432/subscriptions?sslmode=prefer",
+		},
+		{name: "неизвестный sslmode", modify: func(s *Storage) { s.StorageSSLMode = "on" }, wantErr: true},
+		{name: "verify-ca без корневого сертификата", modify: func(s *Storage) { s.StorageSSLMode = "verify-ca" }, wantErr: true},
+		{
+			name: "корневой сертификат при выключенном TLS",
+			modify: func(s *Storage) {
+				s.StorageSSLMode = "disable"
+				s.StorageSSLRootCert = "/etc/ssl/pg/root.crt"
+			},
+			wantErr: true,
+		},
+		{name: "порт вне диапазона", modify: func(s *Storage) { s.StoragePort = 70000 }, wantErr: true},
+		{name: "без пользователя", modify: func(s *Storage) { s.StorageUser = "" }, wantErr: true},
+		{name: "без имени базы", modify: func(s *Storage) { s.StorageDBName = "" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base
+			tt.modify(&s)
+
+			got, err := s.DSN()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoad_StorageFromComponents(t *testing.T) {
+	t.Setenv("STORAGE_PASSWORD", "from-env")
+
+	cfg, err := Load(writeConfig(t, `
+storage:
+  storage_host: db
+  storage_user: app
+  storage_dbname: subscriptions
+  storage_sslmode: require
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "postgres://app:from-env@db:5432/subscriptions?sslmode=require", cfg.StorageConnectionString)
+}
+
+func TestLoad_StorageConnectionString(t *testing.T) {
+	t.Run("полная строка подключения используется как есть", func(t *testing.T) {
+		dsn := "postgres://user:pass@db:5432/db?sslmode=disable"
+		cfg, err := Load(writeConfig(t, "storage_connection_string: \""+dsn+"\"\n"))
+		require.NoError(t, err)
+		assert.Equal(t, dsn, cfg.StorageConnectionString)
+	})
+
+	t.Run("строка подключения и параметры одновременно", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, `
+storage_connection_string: "postgres://user:pass@db:5432/db"
+storage:
+  storage_host: db
+  storage_user: app
+  storage_dbname: subscriptions
+`))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.Nil(t, cfg)
+	})
+
+	t.Run("некорректные параметры", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, "storage:\n  storage_host: db\n  storage_user: app\n"))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "storage.storage_dbname")
+		assert.Nil(t, cfg)
+	})
+}
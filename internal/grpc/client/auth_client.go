@@ -3,11 +3,15 @@ package client
 
 import (
 	"context"
+	"log/slog"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // AuthClient инкапсулирует gRPC клиент для взаимодействия с AuthService.
@@ -19,8 +23,12 @@ type AuthClient struct {
 }
 
 // NewAuthClient создает новый AuthClient, подключаясь к указанному адресу с нешифрованным соединением.
-func NewAuthClient(addr string) (*AuthClient, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Каждый вызов логируется в log и учитывается в метриках Prometheus (см. MetricsInterceptor).
+func NewAuthClient(addr string, log *slog.Logger) (*AuthClient, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(MetricsInterceptor(log, PrometheusRecorder{})),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -43,12 +51,20 @@ func (a *AuthClient) Login(ctx context.Context, username, password string) (*aut
 }
 
 // Register вызывает гRPC метод Register для регистрации пользователя с email, именем пользователя и паролем.
+// Ответ codes.AlreadyExists возвращается как models.ErrUserExists,
+// codes.PermissionDenied — как models.ErrEmailDomainNotAllowed.
 func (a *AuthClient) Register(ctx context.Context, email, username, password string) (string, error) {
 	resp, err := a.client.Register(ctx, &authpb.RegisterRequest{
 		Email:    email,
 		Username: username,
 		Password: password,
 	})
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return "", models.ErrUserExists
+	case codes.PermissionDenied:
+		return "", models.ErrEmailDomainNotAllowed
+	}
 	if err != nil {
 		return "", err
 	}
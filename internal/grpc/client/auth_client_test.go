@@ -15,6 +15,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
 // MockAuthServiceClient - мок для gRPC клиента
@@ -48,7 +49,7 @@ func (m *MockAuthServiceClient) ValidateToken(ctx context.Context, req *authpb.V
 
 // TestAuthClient_NewAuthClient тестирует создание нового клиента
 func TestAuthClient_NewAuthClient(t *testing.T) {
-	client, err := NewAuthClient("localhost:50051")
+	client, err := NewAuthClient("localhost:50051", newNoopLogger())
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 	assert.NotNil(t, client.conn)
@@ -57,7 +58,7 @@ func TestAuthClient_NewAuthClient(t *testing.T) {
 
 // TestAuthClient_Close тестирует закрытие соединения
 func TestAuthClient_Close(t *testing.T) {
-	client, err := NewAuthClient("localhost:50051")
+	client, err := NewAuthClient("localhost:50051", newNoopLogger())
 	require.NoError(t, err)
 	require.NotNil(t, client)
 
@@ -161,6 +162,7 @@ func TestAuthClient_Register(t *testing.T) {
 		mockError     error
 		expectedUID   string
 		expectedError bool
+		expectedErrIs error
 	}{
 		{
 			name:     "successful registration",
@@ -184,6 +186,17 @@ func TestAuthClient_Register(t *testing.T) {
 			mockResponse:  nil,
 			mockError:     status.Error(codes.AlreadyExists, "username already exists"),
 			expectedError: true,
+			expectedErrIs: models.ErrUserExists,
+		},
+		{
+			name:          "email domain not allowed",
+			email:         "test@gmail.com",
+			username:      "testuser",
+			password:      "password123",
+			mockResponse:  nil,
+			mockError:     status.Error(codes.PermissionDenied, "email domain is not allowed for registration"),
+			expectedError: true,
+			expectedErrIs: models.ErrEmailDomainNotAllowed,
 		},
 		{
 			name:          "invalid email",
@@ -224,6 +237,9 @@ func TestAuthClient_Register(t *testing.T) {
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.Empty(t, uid)
+				if tt.expectedErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectedErrIs)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedUID, uid)
@@ -543,7 +559,7 @@ func TestAuthClient_ConcurrentAccess(t *testing.T) {
 // TestAuthClient_ResourceCleanup тестирует правильную очистку ресурсов
 func TestAuthClient_ResourceCleanup(t *testing.T) {
 	// Создаем реальный клиент для тестирования очистки
-	client, err := NewAuthClient("localhost:50051")
+	client, err := NewAuthClient("localhost:50051", newNoopLogger())
 	require.NoError(t, err)
 	require.NotNil(t, client)
 
@@ -570,7 +586,7 @@ func TestAuthClient_InvalidAddresses(t *testing.T) {
 
 	for _, addr := range invalidAddresses {
 		t.Run("address_"+addr, func(t *testing.T) {
-			client, err := NewAuthClient(addr)
+			client, err := NewAuthClient(addr, newNoopLogger())
 			// gRPC клиент может создаться успешно даже с невалидными адресами
 			// Проверяем, что клиент создался (ошибка соединения будет при первом вызове)
 			if err != nil {
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RPCStats описывает результат одного вызова gRPC-метода на стороне клиента.
+type RPCStats struct {
+	Method       string        // Полное имя метода, например /auth.AuthService/Login
+	Code         codes.Code    // Код ответа gRPC; codes.OK при успехе
+	Duration     time.Duration // Время от отправки запроса до получения ответа
+	RequestSize  int           // Размер запроса в байтах (protobuf)
+	ResponseSize int           // Размер ответа в байтах (protobuf); 0 при ошибке
+}
+
+// Recorder сохраняет метрики вызовов gRPC-клиента.
+type Recorder interface {
+	RecordRPC(stats RPCStats)
+}
+
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_client_rpc_duration_seconds",
+		Help:    "Длительность вызовов AuthService на стороне клиента.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+	rpcRequestSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_client_rpc_request_bytes",
+		Help:    "Размер запросов к AuthService в байтах.",
+		Buckets: prometheus.ExponentialBuckets(32, 4, 6),
+	}, []string{"method"})
+	rpcResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_client_rpc_response_bytes",
+		Help:    "Размер ответов AuthService в байтах.",
+		Buckets: prometheus.ExponentialBuckets(32, 4, 6),
+	}, []string{"method"})
+)
+
+// PrometheusRecorder записывает метрики вызовов в реестр Prometheus по умолчанию,
+// который отдается обработчиком /metrics.
+type PrometheusRecorder struct{}
+
+// RecordRPC обновляет гистограммы длительности и размеров сообщений.
+func (PrometheusRecorder) RecordRPC(stats RPCStats) {
+	rpcDuration.WithLabelValues(stats.Method, stats.Code.String()).Observe(stats.Duration.Seconds())
+	rpcRequestSize.WithLabelValues(stats.Method).Observe(float64(stats.RequestSize))
+	if stats.Code == codes.OK {
+		rpcResponseSize.WithLabelValues(stats.Method).Observe(float64(stats.ResponseSize))
+	}
+}
+
+// MetricsInterceptor возвращает клиентский перехватчик, который для каждого вызова
+// логирует метод, длительность, код ответа и размеры сообщений и передает их в rec.
+func MetricsInterceptor(log *slog.Logger, rec Recorder) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		stats := RPCStats{
+			Method:      method,
+			Code:        status.Code(err),
+			Duration:    time.Since(start),
+			RequestSize: messageSize(req),
+		}
+		if err == nil {
+			stats.ResponseSize = messageSize(reply)
+		}
+		rec.RecordRPC(stats)
+
+		attrs := []any{
+			slog.String("method", stats.Method),
+			slog.String("code", stats.Code.String()),
+			slog.Duration("duration", stats.Duration),
+			slog.Int("request_bytes", stats.RequestSize),
+			slog.Int("response_bytes", stats.ResponseSize),
+		}
+		if err != nil {
+			log.Warn("auth rpc failed", attrs...)
+		} else {
+			log.Debug("auth rpc completed", attrs...)
+		}
+		return err
+	}
+}
+
+// messageSize возвращает размер сообщения protobuf в байтах или 0 для других типов.
+func messageSize(msg any) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
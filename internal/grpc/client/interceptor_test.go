@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+)
+
+// MockRecorder - мок для записи метрик вызовов
+type MockRecorder struct {
+	mock.Mock
+}
+
+func (m *MockRecorder) RecordRPC(stats RPCStats) {
+	m.Called(stats)
+}
+
+func newNoopLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// TestMetricsInterceptor_RecordsDuration проверяет, что перехватчик передает длительность, код и размеры вызова
+func TestMetricsInterceptor_RecordsDuration(t *testing.T) {
+	const method = "/auth.AuthService/Login"
+	req := &authpb.LoginRequest{Username: "testuser", Password: "password123"}
+	reply := &authpb.LoginResponse{}
+
+	rec := new(MockRecorder)
+	rec.On("RecordRPC", mock.MatchedBy(func(s RPCStats) bool {
+		return s.Method == method &&
+			s.Code == codes.OK &&
+			s.Duration >= 10*time.Millisecond &&
+			s.RequestSize == proto.Size(req) &&
+			s.ResponseSize == proto.Size(&authpb.LoginResponse{Token: "jwt-token"})
+	})).Once()
+
+	invoker := func(_ context.Context, _ string, _, r any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		time.Sleep(10 * time.Millisecond)
+		r.(*authpb.LoginResponse).Token = "jwt-token"
+		return nil
+	}
+
+	err := MetricsInterceptor(newNoopLogger(), rec)(context.Background(), method, req, reply, nil, invoker)
+	require.NoError(t, err)
+	rec.AssertExpectations(t)
+}
+
+// TestMetricsInterceptor_RecordsErrorCode проверяет, что при ошибке записывается ее код и ошибка возвращается вызывающему
+func TestMetricsInterceptor_RecordsErrorCode(t *testing.T) {
+	const method = "/auth.AuthService/ValidateToken"
+	rpcErr := status.Error(codes.Unauthenticated, "invalid token")
+
+	rec := new(MockRecorder)
+	rec.On("RecordRPC", mock.MatchedBy(func(s RPCStats) bool {
+		return s.Method == method && s.Code == codes.Unauthenticated && s.Duration > 0 && s.ResponseSize == 0
+	})).Once()
+
+	invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		time.Sleep(time.Millisecond)
+		return rpcErr
+	}
+
+	err := MetricsInterceptor(newNoopLogger(), rec)(context.Background(), method,
+		&authpb.ValidateTokenRequest{Token: "bad"}, &authpb.ValidateTokenResponse{}, nil, invoker)
+	assert.Equal(t, rpcErr, err)
+	rec.AssertExpectations(t)
+}
+
+// TestPrometheusRecorder_RecordRPC проверяет, что запись метрик не паникует для успешных и неуспешных вызовов
+func TestPrometheusRecorder_RecordRPC(t *testing.T) {
+	assert.NotPanics(t, func() {
+		PrometheusRecorder{}.RecordRPC(RPCStats{Method: "/auth.AuthService/Login", Code: codes.OK, Duration: time.Millisecond, RequestSize: 10, ResponseSize: 20})
+		PrometheusRecorder{}.RecordRPC(RPCStats{Method: "/auth.AuthService/Login", Code: codes.Unavailable, Duration: time.Second, RequestSize: 10})
+	})
+}
@@ -6,6 +6,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
@@ -36,11 +37,20 @@ func NewAuthServer(authService AuthServiceInterface, logger *slog.Logger) *AuthS
 	}
 }
 
-// Register создает нового пользователя
+// Register создает нового пользователя. Занятые username или email возвращаются как codes.AlreadyExists,
+// неразрешенный домен email — как codes.PermissionDenied.
 func (s *AuthServer) Register(ctx context.Context, req *authpb.RegisterRequest) (*authpb.RegisterResponse, error) {
 	s.log.Info("Register request", slog.String("username", req.Username))
 
 	uid, err := s.authService.Register(ctx, req.Email, req.Username, req.Password)
+	if errors.Is(err, models.ErrUserExists) {
+		s.log.Warn("Register rejected: user already exists", slog.String("username", req.Username))
+		return nil, status.Error(codes.AlreadyExists, models.ErrUserExists.Error())
+	}
+	if errors.Is(err, models.ErrEmailDomainNotAllowed) {
+		s.log.Warn("Register rejected: email domain is not allowed", slog.String("username", req.Username))
+		return nil, status.Error(codes.PermissionDenied, models.ErrEmailDomainNotAllowed.Error())
+	}
 	if err != nil {
 		s.log.Error("Register failed",
 			slog.String("username", req.Username),
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
@@ -110,6 +111,34 @@ func TestAuthServer_Register_Unit(t *testing.T) {
 			expectedError: true,
 			expectedCode:  codes.Internal,
 		},
+		{
+			name: "user already exists",
+			request: &authpb.RegisterRequest{
+				Email:    "test@example.com",
+				Username: "existinguser",
+				Password: "password123",
+			},
+			mockSetup: func(m *MockAuthService) {
+				m.On("Register", mock.Anything, "test@example.com", "existinguser", "password123").
+					Return("", fmt.Errorf("storage.RegisterUser: %w", models.ErrUserExists)).Once()
+			},
+			expectedError: true,
+			expectedCode:  codes.AlreadyExists,
+		},
+		{
+			name: "email domain not allowed",
+			request: &authpb.RegisterRequest{
+				Email:    "test@gmail.com",
+				Username: "testuser",
+				Password: "password123",
+			},
+			mockSetup: func(m *MockAuthService) {
+				m.On("Register", mock.Anything, "test@gmail.com", "testuser", "password123").
+					Return("", models.ErrEmailDomainNotAllowed).Once()
+			},
+			expectedError: true,
+			expectedCode:  codes.PermissionDenied,
+		},
 	}
 
 	for _, tt := range tests {
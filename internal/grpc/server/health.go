@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+)
+
+// Pinger определяет проверку доступности базы данных.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthChecker обновляет статус стандартного сервиса grpc.health.v1.Health
+// в зависимости от доступности базы данных.
+type HealthChecker struct {
+	health   *health.Server
+	db       Pinger
+	interval time.Duration
+	timeout  time.Duration
+	log      *slog.Logger
+}
+
+// NewHealthChecker создает HealthChecker. До первой проверки сервис считается NOT_SERVING.
+func NewHealthChecker(healthServer *health.Server, db Pinger, interval time.Duration, logger *slog.Logger) *HealthChecker {
+	h := &HealthChecker{
+		health:   healthServer,
+		db:       db,
+		interval: interval,
+		timeout:  interval,
+		log:      logger,
+	}
+	h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Check проверяет доступность базы данных, обновляет статус и возвращает его.
+func (h *HealthChecker) Check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := h.db.PingContext(ctx); err != nil {
+		h.log.Warn("database is unreachable, reporting NOT_SERVING", sl.Err(err))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	h.setStatus(status)
+	return status
+}
+
+// Run периодически проверяет доступность базы данных до отмены контекста.
+func (h *HealthChecker) Run(ctx context.Context) {
+	h.Check(ctx)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.health.Shutdown()
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
+
+// setStatus выставляет статус как для сервера в целом, так и для сервиса авторизации.
+func (h *HealthChecker) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.health.SetServingStatus("", status)
+	h.health.SetServingStatus(authpb.AuthService_ServiceDesc.ServiceName, status)
+}
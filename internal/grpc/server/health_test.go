@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	authpb "github.com/magabrotheeeer/subscription-aggregator/internal/grpc/gen"
+)
+
+// fakePinger имитирует базу данных с управляемой доступностью
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) PingContext(_ context.Context) error {
+	return p.err
+}
+
+func TestHealthChecker_ReportsDatabaseStatus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	db := &fakePinger{}
+	checker := NewHealthChecker(healthServer, db, time.Second, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""), "до первой проверки сервис не готов")
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checker.Check(ctx))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(authpb.AuthService_ServiceDesc.ServiceName))
+
+	db.err = errors.New("connection refused")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checker.Check(ctx))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(authpb.AuthService_ServiceDesc.ServiceName))
+
+	db.err = nil
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checker.Check(ctx))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+}
@@ -0,0 +1,163 @@
+// Package bankcsv разбирает выписку банка в формате CSV в данные для создания подписок.
+//
+// Каждая строка выписки — одно регулярное списание. Колонки с названием продавца, суммой
+// и датой задаются Mapping, поэтому один разборщик подходит для выписок разных банков.
+// Ошибки в отдельных строках не прерывают разбор: строка возвращается с описанием ошибки.
+package bankcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// startDateLayout — формат даты начала в models.DummyEntry.
+const startDateLayout = "02-01-2006"
+
+// amountPattern — сумма без знака с не более чем двумя знаками после точки.
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,2})?$`)
+
+// Ошибки разбора файла целиком.
+var (
+	ErrEmptyFile     = errors.New("csv file is empty")
+	ErrMissingColumn = errors.New("required column is missing")
+)
+
+// Mapping описывает, в каких колонках выписки находятся данные подписки.
+// Названия колонок сравниваются без учета регистра и пробелов по краям.
+type Mapping struct {
+	MerchantColumn string // Колонка с названием продавца, становится service_name
+	AmountColumn   string // Колонка с суммой списания, становится price
+	DateColumn     string // Колонка с датой списания, становится start_date
+	DateLayout     string // Формат даты в выписке в нотации time.Parse
+	Delimiter      rune   // Разделитель полей; 0 — запятая
+	CounterMonths  int    // Срок создаваемой подписки в месяцах
+}
+
+// Parse читает выписку из r. Первая строка должна быть заголовком с колонками из m.
+// Возвращает по одному результату на каждую строку данных; строки с ошибками
+// содержат Error и не содержат Entry. Ошибка возвращается, только если файл
+// нельзя разобрать целиком: он пуст или в заголовке нет нужной колонки.
+func Parse(r io.Reader, m Mapping) ([]models.ImportRow, error) {
+	const op = "bankcsv.Parse"
+
+	reader := csv.NewReader(r)
+	if m.Delimiter != 0 {
+		reader.Comma = m.Delimiter
+	}
+	reader.FieldsPerRecord = -1 // количество полей проверяется построчно
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	merchantIdx, amountIdx, dateIdx := -1, -1, -1
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) // BOM в начале файла из Excel
+		switch {
+		case strings.EqualFold(name, m.MerchantColumn):
+			merchantIdx = i
+		case strings.EqualFold(name, m.AmountColumn):
+			amountIdx = i
+		case strings.EqualFold(name, m.DateColumn):
+			dateIdx = i
+		}
+	}
+	columns := []struct {
+		name string
+		idx  int
+	}{{m.MerchantColumn, merchantIdx}, {m.AmountColumn, amountIdx}, {m.DateColumn, dateIdx}}
+	for _, c := range columns {
+		if c.idx < 0 {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrMissingColumn, c.name)
+		}
+	}
+	width := max(merchantIdx, amountIdx, dateIdx) + 1
+
+	rows := []models.ImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			rows = append(rows, models.ImportRow{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < width {
+			rows = append(rows, models.ImportRow{Line: line, Error: fmt.Sprintf("expected at least %d fields, got %d", width, len(record))})
+			continue
+		}
+
+		entry, err := parseRecord(record[merchantIdx], record[amountIdx], record[dateIdx], m)
+		if err != nil {
+			rows = append(rows, models.ImportRow{Line: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, models.ImportRow{Line: line, Entry: entry})
+	}
+	return rows, nil
+}
+
+// parseRecord преобразует поля одной строки выписки в данные подписки.
+func parseRecord(merchant, amount, date string, m Mapping) (*models.DummyEntry, error) {
+	merchant, err := models.NormalizeServiceName(merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := parseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err := time.Parse(m.DateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: expected layout %s", date, m.DateLayout)
+	}
+
+	return &models.DummyEntry{
+		ServiceName:   merchant,
+		Price:         price,
+		StartDate:     startDate.Format(startDateLayout),
+		CounterMonths: m.CounterMonths,
+		IsActive:      true,
+	}, nil
+}
+
+// parseAmount разбирает сумму списания вида "-1 299,90" в целые рубли с округлением.
+// Знак не учитывается: в выписках списания часто записаны отрицательными числами.
+func parseAmount(amount string) (int, error) {
+	value := strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(strings.TrimSpace(amount))
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	if !amountPattern.MatchString(value) {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	minor, err := money.ParseMinor(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	price := int((minor + 50) / 100)
+	if price <= 0 {
+		return 0, fmt.Errorf("amount %q must be at least 1", amount)
+	}
+	return price, nil
+}
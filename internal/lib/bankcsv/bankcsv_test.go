@@ -0,0 +1,122 @@
+package bankcsv
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+var testMapping = Mapping{
+	MerchantColumn: "Описание",
+	AmountColumn:   "Сумма",
+	DateColumn:     "Дата операции",
+	DateLayout:     "02.01.2006",
+	Delimiter:      ';',
+	CounterMonths:  12,
+}
+
+func TestParse_Statement(t *testing.T) {
+	f, err := os.Open("testdata/statement.csv")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	rows, err := Parse(f, testMapping)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.ImportRow{
+		{Line: 2, Entry: &models.DummyEntry{ServiceName: "Netflix", Price: 599, StartDate: "01-06-2025", CounterMonths: 12, IsActive: true}},
+		{Line: 3, Entry: &models.DummyEntry{ServiceName: "Яндекс Плюс", Price: 299, StartDate: "05-06-2025", CounterMonths: 12, IsActive: true}},
+		{Line: 4, Entry: &models.DummyEntry{ServiceName: "Spotify", Price: 1170, StartDate: "10-06-2025", CounterMonths: 12, IsActive: true}},
+	}, rows)
+}
+
+func TestParse_Malformed(t *testing.T) {
+	f, err := os.Open("testdata/malformed.csv")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	rows, err := Parse(f, testMapping)
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Empty(t, rows[0].Error)
+	require.NotNil(t, rows[0].Entry)
+	assert.Equal(t, "Netflix", rows[0].Entry.ServiceName)
+
+	wantErrors := []struct {
+		line  int
+		error string
+	}{
+		{3, "invalid date"},
+		{4, "field ServiceName must not be empty"},
+		{5, "invalid amount"},
+		{6, "expected at least 3 fields, got 2"},
+		{7, "quote"},
+	}
+	for i, want := range wantErrors {
+		row := rows[i+1]
+		assert.Equal(t, want.line, row.Line)
+		assert.Contains(t, row.Error, want.error)
+		assert.Nil(t, row.Entry)
+	}
+}
+
+func TestParse_FileErrors(t *testing.T) {
+	_, err := Parse(strings.NewReader(""), testMapping)
+	assert.ErrorIs(t, err, ErrEmptyFile)
+
+	_, err = Parse(strings.NewReader("Дата операции;Сумма\n01.06.2025;-599,00\n"), testMapping)
+	assert.ErrorIs(t, err, ErrMissingColumn)
+	assert.ErrorContains(t, err, "Описание")
+}
+
+func TestParse_DefaultDelimiterAndHeaderCase(t *testing.T) {
+	mapping := Mapping{MerchantColumn: "merchant", AmountColumn: "amount", DateColumn: "date",
+		DateLayout: "2006-01-02", CounterMonths: 1}
+	input := "\ufeffDATE,Merchant , Amount\n2025-06-01,Netflix,599\n"
+
+	rows, err := Parse(strings.NewReader(input), mapping)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, &models.DummyEntry{ServiceName: "Netflix", Price: 599, StartDate: "01-06-2025", CounterMonths: 1, IsActive: true}, rows[0].Entry)
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "599", want: 599},
+		{input: "-599,00", want: 599},
+		{input: "+299.49", want: 299},
+		{input: "299.50", want: 300},
+		{input: "1 169,50", want: 1170},
+		{input: "1\u00a0000", want: 1000},
+		{input: "0,40", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "10.001", wantErr: true},
+		{input: "--5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseAmount(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
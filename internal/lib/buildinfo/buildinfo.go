@@ -0,0 +1,33 @@
+// Package buildinfo хранит сведения о сборке приложения.
+//
+// Значения переменных подставляются на этапе сборки через ldflags, например:
+//
+//	go build -ldflags "-X github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo.Version=v1.2.0 \
+//	  -X github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/magabrotheeeer/subscription-aggregator/internal/lib/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Если значения не заданы, используются "dev" для версии и "unknown" для остальных полей.
+package buildinfo
+
+// Переменные заполняются через ldflags при сборке.
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info описывает сведения о сборке в виде, пригодном для отдачи в JSON.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// Get возвращает сведения о текущей сборке.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+}
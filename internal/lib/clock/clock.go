@@ -0,0 +1,69 @@
+// Package clock абстрагирует получение текущего времени, чтобы логику, зависящую
+// от даты (окончание подписок, даты списаний), можно было детерминированно тестировать.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock возвращает текущее время.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real — системные часы.
+type Real struct{}
+
+// Now возвращает текущее системное время.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// OrReal возвращает c или системные часы, если c равен nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Fake — управляемые часы для тестов: время меняется только через Set, Advance и AddDate.
+// Безопасны для одновременного использования из нескольких горутин.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake создает часы, показывающие now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now возвращает установленное время.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set устанавливает текущее время.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance сдвигает время на d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// AddDate сдвигает время на указанное число лет, месяцев и дней по правилам time.Time.AddDate.
+func (f *Fake) AddDate(years, months, days int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.AddDate(years, months, days)
+}
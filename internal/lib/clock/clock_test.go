@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(2 * time.Hour)
+	assert.Equal(t, time.Date(2025, 2, 1, 1, 0, 0, 0, time.UTC), c.Now())
+
+	c.AddDate(0, 1, 0)
+	assert.Equal(t, time.Date(2025, 3, 1, 1, 0, 0, 0, time.UTC), c.Now())
+
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
+
+func TestOrReal(t *testing.T) {
+	assert.IsType(t, Real{}, OrReal(nil))
+
+	fake := NewFake(time.Time{})
+	assert.Same(t, fake, OrReal(fake))
+
+	before := time.Now()
+	now := Real{}.Now()
+	assert.False(t, now.Before(before))
+}
@@ -0,0 +1,28 @@
+// Package dateparse разбирает даты, которые пользователи вводят в разных форматах.
+package dateparse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultLayouts — допустимые форматы даты по умолчанию в нотации time.Parse:
+// ISO, день-месяц-год и месяц-год (дата приходится на первое число месяца).
+var DefaultLayouts = []string{"2006-01-02", "02-01-2006", "01-2006"}
+
+// Parse разбирает value, пробуя форматы layouts по порядку, и возвращает дату
+// по первому подошедшему. Пустой layouts означает DefaultLayouts.
+// Если ни один формат не подошел, ошибка перечисляет допустимые форматы.
+func Parse(value string, layouts []string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = DefaultLayouts
+	}
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q does not match any accepted format: %s", value, strings.Join(layouts, ", "))
+}
@@ -0,0 +1,44 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{name: "ISO", value: "2024-03-15", want: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "day-month-year", value: "15-03-2024", want: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "month-year is the first day of month", value: "03-2024", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "surrounding spaces", value: " 2024-03-15 ", want: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_Rejected(t *testing.T) {
+	_, err := Parse("15/03/2024", nil)
+	require.Error(t, err)
+	assert.EqualError(t, err, `date "15/03/2024" does not match any accepted format: 2006-01-02, 02-01-2006, 01-2006`)
+}
+
+func TestParse_CustomLayouts(t *testing.T) {
+	got, err := Parse("15.03.2024", []string{"02.01.2006"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = Parse("2024-03-15", []string{"02.01.2006"})
+	assert.EqualError(t, err, `date "2024-03-15" does not match any accepted format: 02.01.2006`)
+}
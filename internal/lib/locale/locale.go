@@ -0,0 +1,84 @@
+// Package locale выбирает локаль, на которой сервис отвечает пользователю,
+// из заголовка Accept-Language и сохраненной локали пользователя.
+package locale
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
+)
+
+// Default используется, если ни заголовок, ни сохраненная локаль не указывают поддерживаемую.
+const Default = money.DefaultLocale
+
+// Supported — поддерживаемые локали; по языку выбирается первая подходящая.
+var Supported = []string{"ru-RU", "en-US", "de-DE"}
+
+// Match возвращает поддерживаемую локаль для тега вида "en-US", "en_us" или "en":
+// сначала ищется точное совпадение, затем локаль того же языка.
+func Match(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return "", false
+	}
+	for _, l := range Supported {
+		if strings.EqualFold(l, tag) {
+			return l, true
+		}
+	}
+	lang, _, _ := strings.Cut(tag, "-")
+	for _, l := range Supported {
+		if supportedLang, _, _ := strings.Cut(l, "-"); strings.EqualFold(supportedLang, lang) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// FromAcceptLanguage возвращает поддерживаемую локаль с наибольшим весом q из значения
+// заголовка Accept-Language, например "en-GB,en;q=0.9,ru;q=0.8". Языки с q=0 и "*"
+// не выбираются; false означает, что ни один язык из заголовка не поддерживается.
+func FromAcceptLanguage(header string) (string, bool) {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	// Стабильная сортировка сохраняет порядок клиента для языков с одинаковым весом
+	slices.SortStableFunc(tags, func(a, b weighted) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	for _, t := range tags {
+		if l, ok := Match(t.tag); ok {
+			return l, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,54 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{tag: "en-US", want: "en-US", wantOK: true},
+		{tag: "de_de", want: "de-DE", wantOK: true},
+		{tag: "en-GB", want: "en-US", wantOK: true},
+		{tag: "ru", want: "ru-RU", wantOK: true},
+		{tag: "fr-FR"},
+		{tag: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := Match(tt.tag)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOK bool
+	}{
+		{name: "single language", header: "en-US", want: "en-US", wantOK: true},
+		{name: "highest weight wins", header: "ru;q=0.5, de-DE;q=0.9, en;q=0.7", want: "de-DE", wantOK: true},
+		{name: "unsupported languages are skipped", header: "fr-FR, ja;q=0.9, en;q=0.1", want: "en-US", wantOK: true},
+		{name: "equal weights keep client order", header: "de, en", want: "de-DE", wantOK: true},
+		{name: "zero weight is never chosen", header: "en;q=0, fr", wantOK: false},
+		{name: "wildcard is ignored", header: "*", wantOK: false},
+		{name: "malformed weight is skipped", header: "en;q=abc, ru;q=0.3", want: "ru-RU", wantOK: true},
+		{name: "empty header", header: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromAcceptLanguage(tt.header)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package money форматирует денежные суммы для отображения пользователю
+// с учетом валюты и локали (разделители разрядов, десятичный знак, позиция символа).
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale используется, если локаль пользователя не задана или не поддерживается.
+const DefaultLocale = "ru-RU"
+
+// localeFormat описывает правила записи суммы для локали.
+type localeFormat struct {
+	groupSep     string // разделитель разрядов
+	decimalSep   string // десятичный разделитель
+	symbolBefore bool   // символ валюты перед числом
+	symbolSpace  bool   // пробел между числом и символом
+}
+
+var locales = map[string]localeFormat{
+	"ru": {groupSep: " ", decimalSep: ",", symbolBefore: false, symbolSpace: true},
+	"en": {groupSep: ",", decimalSep: ".", symbolBefore: true, symbolSpace: false},
+	"de": {groupSep: ".", decimalSep: ",", symbolBefore: false, symbolSpace: true},
+}
+
+var symbols = map[string]string{
+	"RUB": "₽",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// Format форматирует сумму amountMinor, заданную в минимальных единицах валюты
+// (копейках, центах), для указанных валюты и локали, например
+// Format(100000, "RUB", "ru-RU") = "1 000,00 ₽", Format(100000, "USD", "en-US") = "$1,000.00".
+// Для неизвестной валюты вместо символа выводится ее код.
+func Format(amountMinor int64, currency, locale string) string {
+	f := lookupLocale(locale)
+
+	sign := ""
+	if amountMinor < 0 {
+		sign = "-"
+		amountMinor = -amountMinor
+	}
+	units := strconv.FormatInt(amountMinor/100, 10)
+	cents := amountMinor % 100
+
+	var b strings.Builder
+	for i, r := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			b.WriteString(f.groupSep)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(f.decimalSep)
+	if cents < 10 {
+		b.WriteByte('0')
+	}
+	b.WriteString(strconv.FormatInt(cents, 10))
+	number := b.String()
+
+	symbol := strings.ToUpper(currency)
+	if s, ok := symbols[symbol]; ok {
+		symbol = s
+	}
+	space := ""
+	if f.symbolSpace {
+		space = " "
+	}
+	if f.symbolBefore {
+		return sign + symbol + space + number
+	}
+	return sign + number + space + symbol
+}
+
+// FormatUnits форматирует сумму, заданную в целых единицах валюты (рублях, долларах).
+func FormatUnits(amount int, currency, locale string) string {
+	return Format(int64(amount)*100, currency, locale)
+}
+
+// ParseMinor разбирает десятичную строку вида "100.00" в минимальные единицы валюты.
+func ParseMinor(value string) (int64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 {
+		return int64(f*100 - 0.5), nil
+	}
+	return int64(f*100 + 0.5), nil
+}
+
+// FormatDecimal записывает сумму в минимальных единицах валюты десятичной строкой
+// с двумя знаками после точки, например FormatDecimal(20000) = "200.00".
+// Это обратное к ParseMinor преобразование, в таком виде суммы передаются платежному провайдеру.
+func FormatDecimal(amountMinor int64) string {
+	sign := ""
+	if amountMinor < 0 {
+		sign = "-"
+		amountMinor = -amountMinor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amountMinor/100, amountMinor%100)
+}
+
+// lookupLocale возвращает правила для локали вида "ru-RU", "en_US" или "ru",
+// при отсутствии — правила DefaultLocale.
+func lookupLocale(locale string) localeFormat {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if f, ok := locales[lang]; ok {
+		return f
+	}
+	return locales["ru"]
+}
@@ -0,0 +1,59 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		currency string
+		locale   string
+		want     string
+	}{
+		{name: "RUB in ru locale", amount: 100000, currency: "RUB", locale: "ru-RU", want: "1 000,00 ₽"},
+		{name: "USD in en locale", amount: 100000, currency: "USD", locale: "en-US", want: "$1,000.00"},
+		{name: "same amount USD in ru locale", amount: 100000, currency: "USD", locale: "ru-RU", want: "1 000,00 $"},
+		{name: "same amount RUB in en locale", amount: 100000, currency: "RUB", locale: "en-US", want: "₽1,000.00"},
+		{name: "millions with cents", amount: 123456789, currency: "USD", locale: "en_US", want: "$1,234,567.89"},
+		{name: "small amount", amount: 5, currency: "RUB", locale: "ru", want: "0,05 ₽"},
+		{name: "negative amount", amount: -150050, currency: "RUB", locale: "ru-RU", want: "-1 500,50 ₽"},
+		{name: "unknown currency uses code", amount: 990, currency: "kzt", locale: "ru-RU", want: "9,90 KZT"},
+		{name: "unknown locale falls back to default", amount: 100000, currency: "RUB", locale: "xx-XX", want: "1 000,00 ₽"},
+		{name: "empty locale falls back to default", amount: 100000, currency: "RUB", locale: "", want: "1 000,00 ₽"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Format(tt.amount, tt.currency, tt.locale))
+		})
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	assert.Equal(t, "500,00 ₽", FormatUnits(500, "RUB", "ru-RU"))
+	assert.Equal(t, "$500.00", FormatUnits(500, "USD", "en-US"))
+}
+
+func TestFormatDecimal(t *testing.T) {
+	assert.Equal(t, "200.00", FormatDecimal(20000))
+	assert.Equal(t, "499.05", FormatDecimal(49905))
+	assert.Equal(t, "0.07", FormatDecimal(7))
+	assert.Equal(t, "-1.50", FormatDecimal(-150))
+
+	got, err := ParseMinor(FormatDecimal(123456))
+	require.NoError(t, err)
+	assert.Equal(t, int64(123456), got)
+}
+
+func TestParseMinor(t *testing.T) {
+	got, err := ParseMinor("100.10")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10010), got)
+
+	_, err = ParseMinor("abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package month
+
+import "time"
+
+// NextPaymentDate возвращает дату ближайшего ежемесячного списания по подписке:
+// первую дату вида start + k месяцев (1 ≤ k ≤ counterMonths), не раньше today.
+// Если все списания уже в прошлом, возвращается дата окончания подписки.
+// Время суток отбрасывается.
+func NextPaymentDate(start time.Time, counterMonths int, today time.Time) time.Time {
+	start = truncateDay(start)
+	today = truncateDay(today)
+
+	for k := 1; k <= counterMonths; k++ {
+		d := start.AddDate(0, k, 0)
+		if !d.Before(today) {
+			return d
+		}
+	}
+	return start.AddDate(0, counterMonths, 0)
+}
+
+// SameDay сообщает, приходятся ли a и b на один календарный день.
+func SameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// DaysBetween возвращает число календарных дней между a и b независимо от их порядка.
+// Время суток не учитывается: 23:59 и 00:01 следующего дня разделяет один день.
+func DaysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	days := int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
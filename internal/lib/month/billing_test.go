@@ -0,0 +1,72 @@
+package month
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextPaymentDate(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		months int
+		today  time.Time
+		want   time.Time
+	}{
+		{
+			name:   "future start",
+			months: 12,
+			today:  time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "payment due today",
+			months: 12,
+			today:  time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "between payments",
+			months: 12,
+			today:  time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "subscription ended",
+			months: 3,
+			today:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextPaymentDate(start, tt.months, tt.today)
+			if !got.Equal(tt.want) {
+				t.Errorf("NextPaymentDate(%v, %d, %v) = %v, want %v", start, tt.months, tt.today, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b time.Time
+		want int
+	}{
+		{"один день", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), 0},
+		{"через полночь", time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC), time.Date(2024, 3, 2, 0, 1, 0, 0, time.UTC), 1},
+		{"обратный порядок", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC), 3},
+		{"через февраль високосного года", time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysBetween(tt.a, tt.b); got != tt.want {
+				t.Errorf("DaysBetween(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package month
+
+import "time"
+
+// PriceSegment описывает цену подписки, действующую начиная с момента From.
+type PriceSegment struct {
+	From  time.Time
+	Price float64
+}
+
+// ProratedSum вычисляет стоимость месяцев подписки, остающихся активными на filterStart
+// (см. CountMonths), с учетом изменений цены. Каждый месяц оплачивается по цене,
+// действовавшей на дату начала этого месяца: initialPrice до первого сегмента,
+// далее — цена последнего сегмента, начавшегося не позже этой даты.
+// Сегменты должны быть упорядочены по From. Без сегментов результат равен
+// initialPrice * CountMonths(subStart, subMonths, filterStart).
+func ProratedSum(subStart time.Time, subMonths int, filterStart time.Time, initialPrice float64, segments []PriceSegment) float64 {
+	remaining := CountMonths(subStart, subMonths, filterStart)
+
+	var total float64
+	for k := subMonths - remaining; k < subMonths; k++ {
+		periodStart := subStart.AddDate(0, k, 0)
+		price := initialPrice
+		for _, seg := range segments {
+			if seg.From.After(periodStart) {
+				break
+			}
+			price = seg.Price
+		}
+		total += price
+	}
+	return total
+}
+
+// ChargesBetween возвращает количество месяцев подписки, которые начинаются не раньше from
+// и раньше to, то есть списаний по подписке в этом интервале. Месяцы считаются так же,
+// как в ProratedSum: k-й месяц начинается с subStart + k месяцев, 0 ≤ k < subMonths.
+func ChargesBetween(subStart time.Time, subMonths int, from, to time.Time) int {
+	charges := 0
+	for k := subMonths - CountMonths(subStart, subMonths, from); k < subMonths; k++ {
+		if !subStart.AddDate(0, k, 0).Before(to) {
+			break
+		}
+		charges++
+	}
+	return charges
+}
@@ -0,0 +1,88 @@
+package month
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProratedSum(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		subMonths   int
+		filterStart time.Time
+		segments    []PriceSegment
+		want        float64
+	}{
+		{
+			name:        "без изменений цены",
+			subMonths:   6,
+			filterStart: start,
+			want:        6000,
+		},
+		{
+			name:        "цена изменилась в середине периода",
+			subMonths:   6,
+			filterStart: start,
+			segments:    []PriceSegment{{From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Price: 1500}},
+			want:        3*1000 + 3*1500,
+		},
+		{
+			name:        "изменение в середине месяца действует со следующего месяца",
+			subMonths:   6,
+			filterStart: start,
+			segments:    []PriceSegment{{From: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Price: 1500}},
+			want:        3*1000 + 3*1500,
+		},
+		{
+			name:        "несколько изменений",
+			subMonths:   4,
+			filterStart: start,
+			segments: []PriceSegment{
+				{From: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Price: 1200},
+				{From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Price: 800},
+			},
+			want: 1000 + 1200 + 1200 + 800,
+		},
+		{
+			name:        "фильтр начинается после изменения",
+			subMonths:   6,
+			filterStart: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			segments:    []PriceSegment{{From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Price: 1500}},
+			want:        3 * 1500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, ProratedSum(start, tt.subMonths, tt.filterStart, 1000, tt.segments), 0.001)
+		})
+	}
+}
+
+func TestChargesBetween(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	tests := []struct {
+		name      string
+		subStart  time.Time
+		subMonths int
+		want      int
+	}{
+		{name: "срок длиннее интервала", subStart: start, subMonths: 24, want: 12},
+		{name: "срок заканчивается внутри интервала", subStart: start, subMonths: 6, want: 4},
+		{name: "подписка начинается внутри интервала", subStart: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), subMonths: 12, want: 6},
+		{name: "подписка начинается после интервала", subStart: to, subMonths: 12, want: 0},
+		{name: "срок закончился до интервала", subStart: start, subMonths: 1, want: 0},
+		{name: "месяц, начинающийся в from, учитывается", subStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), subMonths: 3, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ChargesBetween(tt.subStart, tt.subMonths, from, to))
+		})
+	}
+}
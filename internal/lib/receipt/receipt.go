@@ -0,0 +1,67 @@
+// Package receipt формирует HTML-квитанцию об оплате для отображения и печати пользователем.
+package receipt
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// dateLayout — формат даты платежа в квитанции.
+const dateLayout = "02.01.2006 15:04 MST"
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Квитанция об оплате № {{.ID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 480px; margin: 40px auto; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: 6px 0; border-bottom: 1px solid #ddd; }
+td.value { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Квитанция об оплате № {{.ID}}</h1>
+<table>
+<tr><td>Идентификатор платежа</td><td class="value">{{.PaymentID}}</td></tr>
+<tr><td>Сервис</td><td class="value">{{.ServiceName}}</td></tr>
+<tr><td>Дата</td><td class="value">{{.Date}}</td></tr>
+<tr><td>Сумма</td><td class="value">{{.Amount}}</td></tr>
+<tr><td>Валюта</td><td class="value">{{.Currency}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// view — данные квитанции, подготовленные для шаблона.
+type view struct {
+	ID          int
+	PaymentID   string
+	ServiceName string
+	Date        string
+	Amount      string
+	Currency    string
+}
+
+// RenderHTML записывает в w HTML-квитанцию по платежу r. Сумма форматируется
+// для локали locale, дата выводится в UTC. Если платеж не связан
+// с подпиской, вместо названия сервиса выводится прочерк.
+func RenderHTML(w io.Writer, r *models.PaymentReceipt, locale string) error {
+	serviceName := r.ServiceName
+	if serviceName == "" {
+		serviceName = "—"
+	}
+	return receiptTemplate.Execute(w, view{
+		ID:          r.ID,
+		PaymentID:   r.PaymentID,
+		ServiceName: serviceName,
+		Date:        r.CreatedAt.In(time.UTC).Format(dateLayout),
+		Amount:      money.Format(r.Amount, r.Currency, locale),
+		Currency:    r.Currency,
+	})
+}
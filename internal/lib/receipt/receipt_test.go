@@ -0,0 +1,61 @@
+package receipt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderHTML(&buf, &models.PaymentReceipt{
+		ID:          42,
+		PaymentID:   "2c5d6f1e-000f-5000-8000-1a2b3c4d5e6f",
+		Amount:      129900,
+		Currency:    "RUB",
+		Status:      models.PaymentStatusSucceeded,
+		ServiceName: "Netflix",
+		CreatedAt:   time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC),
+	}, "ru-RU")
+	require.NoError(t, err)
+
+	html := buf.String()
+	assert.Contains(t, html, "Квитанция об оплате № 42")
+	assert.Contains(t, html, "2c5d6f1e-000f-5000-8000-1a2b3c4d5e6f")
+	assert.Contains(t, html, "1 299,00 ₽")
+	assert.Contains(t, html, "RUB")
+	assert.Contains(t, html, "Netflix")
+	assert.Contains(t, html, "14.03.2025 09:30 UTC")
+}
+
+func TestRenderHTML_EscapesFields(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderHTML(&buf, &models.PaymentReceipt{
+		ID:          1,
+		PaymentID:   "pay_1",
+		Amount:      100,
+		Currency:    "RUB",
+		ServiceName: "<script>alert(1)</script>",
+	}, "ru-RU")
+	require.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "<script>")
+	assert.Contains(t, buf.String(), "&lt;script&gt;")
+}
+
+func TestRenderHTML_WithoutService(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderHTML(&buf, &models.PaymentReceipt{ID: 1, PaymentID: "pay_1", Amount: 100, Currency: "RUB"}, "ru-RU"))
+	assert.Contains(t, buf.String(), "—")
+}
+
+func TestRenderHTML_Locale(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderHTML(&buf, &models.PaymentReceipt{ID: 1, PaymentID: "pay_1", Amount: 129900, Currency: "USD"}, "en-US"))
+	assert.Contains(t, buf.String(), "$1,299.00")
+}
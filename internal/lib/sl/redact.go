@@ -0,0 +1,151 @@
+package sl
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedValue подставляется в лог вместо значения чувствительного поля.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactFields — поля, значения которых скрываются в логах, если список не задан в конфигурации.
+var DefaultRedactFields = []string{"password", "token", "authorization", "payment_method_token"}
+
+// Redactor скрывает значения полей с заданными именами в атрибутах лога.
+// Имена сравниваются без учета регистра, символов "_" и "-", поэтому
+// payment_method_token совпадает и с json-тегом, и с полем PaymentMethodToken.
+type Redactor struct {
+	fields map[string]struct{}
+}
+
+// NewRedactor создает Redactor для указанных полей. Пустой список означает DefaultRedactFields.
+func NewRedactor(fields []string) *Redactor {
+	if len(fields) == 0 {
+		fields = DefaultRedactFields
+	}
+	r := &Redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, f := range fields {
+		if f = normalizeField(f); f != "" {
+			r.fields[f] = struct{}{}
+		}
+	}
+	return r
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
+
+func init() {
+	defaultRedactor.Store(NewRedactor(nil))
+}
+
+// SetRedactFields задает список полей, используемый Redact и ReplaceAttr.
+func SetRedactFields(fields []string) {
+	defaultRedactor.Store(NewRedactor(fields))
+}
+
+// Redact возвращает slog.Attr, в значении которого скрыты чувствительные поля.
+//
+// Пример:
+//
+//	log.Info("request body decoded", sl.Redact("request", req))
+func Redact(key string, v any) slog.Attr {
+	return defaultRedactor.Load().Attr(key, v)
+}
+
+// ReplaceAttr предназначена для slog.HandlerOptions и скрывает чувствительные поля во всех записях лога.
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	return defaultRedactor.Load().ReplaceAttr(groups, a)
+}
+
+// Attr возвращает slog.Attr с ключом key и значением v, в котором скрыты чувствительные поля.
+func (r *Redactor) Attr(key string, v any) slog.Attr {
+	if r.match(key) {
+		return slog.String(key, RedactedValue)
+	}
+	return slog.Any(key, r.value(v))
+}
+
+// ReplaceAttr скрывает значение атрибута с чувствительным ключом, а также
+// чувствительные поля внутри структур, map и срезов.
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if r.match(a.Key) {
+		return slog.String(a.Key, RedactedValue)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		a.Value = slog.AnyValue(r.value(a.Value.Any()))
+	}
+	return a
+}
+
+// value возвращает представление v со скрытыми чувствительными полями.
+// Составные значения приводятся к JSON-представлению; ошибки и простые значения не меняются.
+func (r *Redactor) value(v any) any {
+	if v == nil {
+		return nil
+	}
+	if _, ok := v.(error); ok {
+		return v
+	}
+	if !isComposite(reflect.ValueOf(v)) {
+		return v
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return RedactedValue
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return RedactedValue
+	}
+	return r.walk(decoded)
+}
+
+func (r *Redactor) walk(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if r.match(k) {
+				t[k] = RedactedValue
+				continue
+			}
+			t[k] = r.walk(val)
+		}
+		return t
+	case []any:
+		for i, val := range t {
+			t[i] = r.walk(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) match(key string) bool {
+	_, ok := r.fields[normalizeField(key)]
+	return ok
+}
+
+func isComposite(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+func normalizeField(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
@@ -0,0 +1,84 @@
+package sl_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type paymentRequest struct {
+	PaymentMethodToken string
+	Nested             map[string]string
+}
+
+func newJSONLogger(buf *bytes.Buffer, replace func([]string, slog.Attr) slog.Attr) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{ReplaceAttr: replace}))
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestRedact_StructWithPassword(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newJSONLogger(&buf, nil)
+
+	logger.Info("request body decoded", sl.Redact("request", loginRequest{Username: "user1", Password: "secret123"}))
+
+	assert.NotContains(t, buf.String(), "secret123")
+	request := decodeLine(t, &buf)["request"].(map[string]any)
+	assert.Equal(t, "user1", request["username"])
+	assert.Equal(t, sl.RedactedValue, request["password"])
+}
+
+func TestReplaceAttr_RedactsAllRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newJSONLogger(&buf, sl.ReplaceAttr)
+
+	logger.Info("payment",
+		slog.String("Authorization", "Bearer abc"),
+		slog.Any("request", &paymentRequest{
+			PaymentMethodToken: "pm_123",
+			Nested:             map[string]string{"token": "tok_1", "id": "42"},
+		}),
+		slog.Any("err", errors.New("boom")),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "Bearer abc")
+	assert.NotContains(t, out, "pm_123")
+	assert.NotContains(t, out, "tok_1")
+
+	line := decodeLine(t, &buf)
+	assert.Equal(t, sl.RedactedValue, line["Authorization"])
+	assert.Equal(t, "boom", line["err"])
+	request := line["request"].(map[string]any)
+	assert.Equal(t, "42", request["Nested"].(map[string]any)["id"])
+}
+
+func TestSetRedactFields_Configurable(t *testing.T) {
+	sl.SetRedactFields([]string{"email"})
+	defer sl.SetRedactFields(nil)
+
+	var buf bytes.Buffer
+	logger := newJSONLogger(&buf, sl.ReplaceAttr)
+	logger.Info("user", slog.String("email", "user@example.com"), slog.String("password", "visible"))
+
+	line := decodeLine(t, &buf)
+	assert.Equal(t, sl.RedactedValue, line["email"])
+	assert.Equal(t, "visible", line["password"])
+}
@@ -0,0 +1,27 @@
+package smtp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+)
+
+// IsTransient сообщает, является ли ошибка отправки временной и имеет ли смысл повтор.
+// Временными считаются ответы сервера 4xx (например, 421 или 450), сетевые ошибки
+// и обрыв соединения. Ответы 5xx (несуществующий получатель, отказ в доставке)
+// и прочие ошибки считаются окончательными.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
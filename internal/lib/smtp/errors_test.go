@@ -0,0 +1,34 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "mailbox busy", err: &textproto.Error{Code: 450, Msg: "mailbox busy"}, want: true},
+		{name: "service unavailable", err: fmt.Errorf("rcpt: %w", &textproto.Error{Code: 421, Msg: "try later"}), want: true},
+		{name: "unknown recipient", err: &textproto.Error{Code: 550, Msg: "no such user"}, want: false},
+		{name: "connection dropped", err: io.EOF, want: true},
+		{name: "dial error", err: fmt.Errorf("failed to dial SMTP server: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), want: true},
+		{name: "other error", err: errors.New("smtp server does not support STARTTLS"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package webhooksig подписывает исходящие webhook-уведомления и проверяет их подпись.
+//
+// Подпись передается в заголовке Header в виде "t=<unix-время>,<алгоритм>=<hex>", например
+// "t=1719824400,hmac-sha256=5f1c…". Подписывается строка "<t>.<тело запроса>" секретом
+// получателя, поэтому тело нельзя подменить, а метка времени позволяет получателю отклонить
+// повторно отправленный старый запрос. Получатель проверяет подпись с помощью Verifier.
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+)
+
+// Header — заголовок запроса с подписью webhook-уведомления.
+const Header = "X-Webhook-Signature"
+
+// DefaultTolerance — допустимое по умолчанию расхождение метки времени подписи с часами получателя.
+const DefaultTolerance = 5 * time.Minute
+
+// Algorithm — алгоритм подписи; его название указывается в заголовке перед значением подписи.
+type Algorithm string
+
+// Поддерживаемые алгоритмы подписи.
+const (
+	HMACSHA256 Algorithm = "hmac-sha256" // по умолчанию
+	HMACSHA512 Algorithm = "hmac-sha512"
+)
+
+var (
+	// ErrUnsupportedAlgorithm — алгоритм подписи не поддерживается.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	// ErrMalformedHeader — заголовок подписи не соответствует формату "t=<unix>,<алгоритм>=<hex>".
+	ErrMalformedHeader = errors.New("malformed signature header")
+	// ErrStaleTimestamp — метка времени подписи отличается от текущего времени больше допустимого.
+	ErrStaleTimestamp = errors.New("signature timestamp is outside the tolerance")
+	// ErrInvalidSignature — подпись не совпадает с телом запроса и секретом.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// hashFor возвращает конструктор хеша для алгоритма; пустой алгоритм означает HMACSHA256.
+func hashFor(alg Algorithm) (Algorithm, func() hash.Hash, error) {
+	switch alg {
+	case "", HMACSHA256:
+		return HMACSHA256, sha256.New, nil
+	case HMACSHA512:
+		return HMACSHA512, sha512.New, nil
+	default:
+		return "", nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// Signer подписывает webhook-уведомления одного получателя его секретом.
+type Signer struct {
+	alg     Algorithm
+	newHash func() hash.Hash
+	secret  []byte
+	clock   clock.Clock
+}
+
+// NewSigner создает Signer с алгоритмом alg (пустой — HMACSHA256) и секретом получателя secret.
+// Если clk равен nil, метка времени берется из системных часов.
+func NewSigner(alg Algorithm, secret string, clk clock.Clock) (*Signer, error) {
+	alg, newHash, err := hashFor(alg)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, errors.New("webhook secret must not be empty")
+	}
+	return &Signer{alg: alg, newHash: newHash, secret: []byte(secret), clock: clock.OrReal(clk)}, nil
+}
+
+// Sign возвращает значение заголовка Header для тела body с текущей меткой времени.
+func (s *Signer) Sign(body []byte) string {
+	ts := s.clock.Now().Unix()
+	return fmt.Sprintf("t=%d,%s=%s", ts, s.alg, hex.EncodeToString(sign(s.newHash, s.secret, ts, body)))
+}
+
+// Verifier проверяет подпись webhook-уведомлений на стороне получателя.
+type Verifier struct {
+	alg       Algorithm
+	newHash   func() hash.Hash
+	secret    []byte
+	tolerance time.Duration
+	clock     clock.Clock
+}
+
+// NewVerifier создает Verifier с алгоритмом alg (пустой — HMACSHA256) и секретом secret.
+// Пустой секрет отклоняется: с ним подпись мог бы вычислить любой отправитель.
+// tolerance ограничивает расхождение метки времени подписи с часами получателя в обе стороны;
+// неположительное значение заменяется DefaultTolerance. Если clk равен nil, используются системные часы.
+func NewVerifier(alg Algorithm, secret string, tolerance time.Duration, clk clock.Clock) (*Verifier, error) {
+	alg, newHash, err := hashFor(alg)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, errors.New("webhook secret must not be empty")
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	return &Verifier{alg: alg, newHash: newHash, secret: []byte(secret), tolerance: tolerance, clock: clock.OrReal(clk)}, nil
+}
+
+// Verify проверяет значение заголовка Header для тела body. Возвращает ErrMalformedHeader,
+// если заголовок не разобран или подписан другим алгоритмом, ErrStaleTimestamp, если метка
+// времени вне допустимого окна, и ErrInvalidSignature, если подпись не совпадает.
+func (v *Verifier) Verify(header string, body []byte) error {
+	var (
+		ts     int64
+		sig    []byte
+		haveTS bool
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformedHeader
+		}
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrMalformedHeader
+			}
+			ts, haveTS = parsed, true
+		case string(v.alg):
+			decoded, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrMalformedHeader
+			}
+			sig = decoded
+		}
+	}
+	if !haveTS || sig == nil {
+		return ErrMalformedHeader
+	}
+
+	age := v.clock.Now().Sub(time.Unix(ts, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return ErrStaleTimestamp
+	}
+	if !hmac.Equal(sig, sign(v.newHash, v.secret, ts, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// sign вычисляет HMAC строки "<ts>.<body>".
+func sign(newHash func() hash.Hash, secret []byte, ts int64, body []byte) []byte {
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,129 @@
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+)
+
+var (
+	signedAt = time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	body     = []byte(`{"event":"subscription.renewed","id":42}`)
+)
+
+func TestSigner_Sign(t *testing.T) {
+	t.Run("по умолчанию HMAC-SHA256 от метки времени и тела", func(t *testing.T) {
+		signer, err := NewSigner("", "secret", clock.NewFake(signedAt))
+		require.NoError(t, err)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("1751360400." + string(body)))
+		assert.Equal(t, "t=1751360400,hmac-sha256="+hex.EncodeToString(mac.Sum(nil)), signer.Sign(body))
+	})
+
+	t.Run("HMAC-SHA512", func(t *testing.T) {
+		signer, err := NewSigner(HMACSHA512, "secret", clock.NewFake(signedAt))
+		require.NoError(t, err)
+
+		mac := hmac.New(sha512.New, []byte("secret"))
+		mac.Write([]byte("1751360400." + string(body)))
+		assert.Equal(t, "t=1751360400,hmac-sha512="+hex.EncodeToString(mac.Sum(nil)), signer.Sign(body))
+	})
+
+	t.Run("метка времени берется из часов в момент подписи", func(t *testing.T) {
+		clk := clock.NewFake(signedAt)
+		signer, err := NewSigner(HMACSHA256, "secret", clk)
+		require.NoError(t, err)
+
+		clk.Advance(time.Minute)
+		assert.True(t, strings.HasPrefix(signer.Sign(body), "t=1751360460,"))
+	})
+
+	t.Run("неподдерживаемый алгоритм и пустой секрет", func(t *testing.T) {
+		_, err := NewSigner("md5", "secret", nil)
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		_, err = NewSigner(HMACSHA256, "", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	signer, err := NewSigner(HMACSHA256, "secret", clock.NewFake(signedAt))
+	require.NoError(t, err)
+	header := signer.Sign(body)
+
+	tests := []struct {
+		name    string
+		alg     Algorithm
+		secret  string
+		now     time.Time
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{name: "подпись верна", secret: "secret", now: signedAt.Add(time.Minute), header: header, body: body},
+		{name: "на границе окна", secret: "secret", now: signedAt.Add(DefaultTolerance), header: header, body: body},
+		{name: "устаревшая метка времени", secret: "secret", now: signedAt.Add(DefaultTolerance + time.Second),
+			header: header, body: body, wantErr: ErrStaleTimestamp},
+		{name: "метка времени из будущего", secret: "secret", now: signedAt.Add(-DefaultTolerance - time.Second),
+			header: header, body: body, wantErr: ErrStaleTimestamp},
+		{name: "измененное тело", secret: "secret", now: signedAt, header: header,
+			body: []byte(`{"event":"subscription.renewed","id":43}`), wantErr: ErrInvalidSignature},
+		{name: "другой секрет", secret: "other", now: signedAt, header: header, body: body, wantErr: ErrInvalidSignature},
+		{name: "подмененная метка времени", secret: "secret", now: signedAt,
+			header: strings.Replace(header, "t=1751360400", "t=1751360401", 1), body: body, wantErr: ErrInvalidSignature},
+		{name: "подпись другим алгоритмом", alg: HMACSHA512, secret: "secret", now: signedAt,
+			header: header, body: body, wantErr: ErrMalformedHeader},
+		{name: "нет метки времени", secret: "secret", now: signedAt,
+			header: header[strings.Index(header, ",")+1:], body: body, wantErr: ErrMalformedHeader},
+		{name: "пустой заголовок", secret: "secret", now: signedAt, header: "", body: body, wantErr: ErrMalformedHeader},
+		{name: "подпись не в hex", secret: "secret", now: signedAt, header: "t=1751360400,hmac-sha256=zz",
+			body: body, wantErr: ErrMalformedHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewVerifier(tt.alg, tt.secret, 0, clock.NewFake(tt.now))
+			require.NoError(t, err)
+
+			err = verifier.Verify(tt.header, tt.body)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifier_Tolerance(t *testing.T) {
+	signer, err := NewSigner(HMACSHA512, "secret", clock.NewFake(signedAt))
+	require.NoError(t, err)
+	header := signer.Sign(body)
+
+	verifier, err := NewVerifier(HMACSHA512, "secret", 30*time.Second, clock.NewFake(signedAt.Add(time.Minute)))
+	require.NoError(t, err)
+	assert.ErrorIs(t, verifier.Verify(header, body), ErrStaleTimestamp)
+
+	verifier, err = NewVerifier(HMACSHA512, "secret", 2*time.Minute, clock.NewFake(signedAt.Add(time.Minute)))
+	require.NoError(t, err)
+	assert.NoError(t, verifier.Verify(header, body))
+}
+
+func TestNewVerifier_Rejected(t *testing.T) {
+	_, err := NewVerifier("md5", "secret", 0, nil)
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = NewVerifier(HMACSHA256, "", 0, nil)
+	assert.Error(t, err)
+}
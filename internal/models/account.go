@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Режимы удаления аккаунта.
+const (
+	DeletionModeImmediate = "immediate"
+	DeletionModeScheduled = "scheduled"
+)
+
+// Статусы пользователя, связанные с удалением аккаунта.
+const (
+	StatusPendingDeletion = "pending_deletion"
+	StatusDeleted         = "deleted"
+)
+
+// DeletionRequest — запись о запросе пользователя на удаление аккаунта,
+// сохраняемая для подтверждения соответствия требованиям GDPR.
+type DeletionRequest struct {
+	ID           int        `json:"id"`
+	UserUID      string     `json:"user_uid"`
+	Mode         string     `json:"mode"`
+	RequestedAt  time.Time  `json:"requested_at"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "errors"
+
+// EntryStatusCanceled — целевой статус массовой смены статуса: подписка отменяется
+// и больше не попадает в списки и суммы.
+const EntryStatusCanceled = "canceled"
+
+// ErrInvalidBulkRequest — в запросе массовой смены статуса должен быть указан
+// ровно один способ выбора подписок: ids или service_name.
+var ErrInvalidBulkRequest = errors.New("exactly one of ids or service_name must be set")
+
+// BulkStatusRequest описывает запрос на смену статуса нескольких подписок пользователя.
+// Подписки выбираются либо по списку ID, либо по названию сервиса.
+type BulkStatusRequest struct {
+	IDs         []int  `json:"ids,omitempty" validate:"max=100,dive,gt=0"`              // ID подписок, не более 100
+	ServiceName string `json:"service_name,omitempty"`                                  // Название сервиса без учета регистра
+	Status      string `json:"status" validate:"required,oneof=active paused canceled"` // Целевой статус
+}
+
+// BulkStatusResult описывает результат смены статуса одной подписки.
+// Подписка, которую не удалось изменить, содержит Error.
+type BulkStatusResult struct {
+	ID     int    `json:"id"`
+	Status string `json:"status,omitempty"` // Новый статус подписки
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// EntryLimits — настроенные ограничения значений подписки, которые сервис проверяет
+// при создании и обновлении. Нулевое число или пустой список снимают соответствующее ограничение.
+type EntryLimits struct {
+	MaxPrice         int      // Максимальная цена за месяц
+	MaxCounterMonths int      // Максимальный срок подписки в месяцах
+	Currencies       []string // Допустимые коды валют ISO 4217
+	// AllowEndedUpdates разрешает обновлять подписку так, что ее срок закончился раньше сегодняшнего дня;
+	// по умолчанию такое обновление отклоняется с ErrEndDateInPast
+	AllowEndedUpdates bool
+	// MaxActivePerService — сколько активных подписок на один сервис может быть у пользователя;
+	// создание и активация сверх него отклоняются с ErrActiveLimitExceeded
+	MaxActivePerService int
+}
+
+// Constraints описывает ограничения входных данных, которые применяет сервер.
+// Клиенты получают их через GET /meta/constraints, чтобы строить формы без дублирования правил.
+type Constraints struct {
+	// DateFormats — допустимые форматы start_date в нотации Go time.Parse, например 2006-01-02.
+	DateFormats      []string `json:"date_formats"`
+	MinPrice         int      `json:"min_price"`
+	MaxPrice         int      `json:"max_price,omitempty"` // Отсутствует, если цена не ограничена
+	MinCounterMonths int      `json:"min_counter_months"`
+	MaxCounterMonths int      `json:"max_counter_months,omitempty"` // Отсутствует, если срок не ограничен
+	// Currencies — допустимые валюты; пустой список означает любой код ISO 4217.
+	Currencies      []string `json:"supported_currencies"`
+	DefaultCurrency string   `json:"default_currency"`
+	PageSizeDefault int      `json:"page_size_default"`
+	PageSizeMax     int      `json:"page_size_max"`
+}
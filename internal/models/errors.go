@@ -0,0 +1,90 @@
+package models
+
+import "errors"
+
+// Ошибки проверки данных подписки, которые обработчики возвращают клиенту как 422.
+var (
+	// ErrInvalidStartDate — дата начала подписки не соответствует ни одному из допустимых форматов.
+	ErrInvalidStartDate = errors.New("invalid start date")
+	// ErrEndDateInPast — подписка закончилась раньше сегодняшнего дня: start_date плюс counter_months
+	// меньше текущей даты. Создание такой подписки отклоняется всегда, обновление — если
+	// subscription_limits.allow_ended_updates выключен.
+	ErrEndDateInPast = errors.New("subscription end date must not be earlier than today")
+	// ErrInvalidServiceName — название сервиса пустое, слишком длинное или содержит управляющие символы.
+	ErrInvalidServiceName = errors.New("invalid service name")
+	// ErrInvalidNotes — заметка к подписке длиннее MaxNotesLength символов или содержит недопустимые символы.
+	ErrInvalidNotes = errors.New("invalid notes")
+	// ErrInvalidMetadata — метаданные подписки не JSON-объект или превышают MaxMetadataBytes.
+	ErrInvalidMetadata = errors.New("invalid metadata")
+	// ErrEntryOutOfLimits — цена, срок или валюта подписки выходят за настроенные ограничения.
+	ErrEntryOutOfLimits = errors.New("subscription is out of allowed limits")
+)
+
+// ErrUserExists — пользователь с таким username или email уже зарегистрирован.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrEmailDomainNotAllowed — домен email не входит в registration.allowed_email_domains.
+var ErrEmailDomainNotAllowed = errors.New("email domain is not allowed for registration")
+
+// ErrSubscriptionNotFound — подписка не существует или принадлежит другому пользователю.
+// Обе причины возвращаются клиенту одинаково, чтобы по ответу нельзя было перебрать чужие ID.
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// ErrCurrencyMismatch — обновление указывает цену в валюте, отличной от валюты подписки,
+// без явной смены валюты. Такая цена исказила бы суммы, посчитанные в сохраненной валюте.
+var ErrCurrencyMismatch = errors.New("currency differs from subscription currency")
+
+// ErrSubscriptionAlreadyActive — возобновляется подписка, которая активна и срок которой не истек.
+var ErrSubscriptionAlreadyActive = errors.New("subscription is already active")
+
+// ErrActiveLimitExceeded — создание или активация подписки превысили бы
+// subscription_limits.max_active_per_service активных подписок пользователя на один сервис.
+var ErrActiveLimitExceeded = errors.New("too many active subscriptions for service")
+
+// Ошибки работы с сохраненными платежными токенами.
+var (
+	// ErrPaymentTokenNotFound — токен не найден среди активных токенов пользователя.
+	ErrPaymentTokenNotFound = errors.New("payment token not found")
+)
+
+// Ошибки получения квитанции об оплате.
+var (
+	// ErrPaymentNotFound — платеж не найден среди платежей пользователя.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentNotSucceeded — квитанция доступна только для успешно проведенных платежей.
+	ErrPaymentNotSucceeded = errors.New("receipt is available only for succeeded payments")
+)
+
+// Ошибки возврата платежа.
+var (
+	// ErrRefundNotAllowed — вернуть можно только успешно проведенный платеж.
+	ErrRefundNotAllowed = errors.New("only succeeded payments can be refunded")
+	// ErrPaymentAlreadyRefunded — по платежу уже выполнен возврат.
+	ErrPaymentAlreadyRefunded = errors.New("payment has already been refunded")
+	// ErrRefundWindowExpired — срок, в течение которого можно запросить возврат, истек.
+	ErrRefundWindowExpired = errors.New("refund window has expired")
+	// ErrRefundRejected — платежный провайдер отклонил возврат.
+	ErrRefundRejected = errors.New("refund rejected by payment provider")
+)
+
+// ErrNoUpcomingCharge — у пользователя нет запланированного списания: подписка истекла или отменена.
+var ErrNoUpcomingCharge = errors.New("no upcoming charge")
+
+// Ошибки применения промокода.
+var (
+	// ErrPromoCodeNotFound — промокод не существует.
+	ErrPromoCodeNotFound = errors.New("promo code not found")
+	// ErrPromoCodeExpired — срок действия промокода истек или еще не начался.
+	ErrPromoCodeExpired = errors.New("promo code is not valid at this time")
+	// ErrPromoCodeExhausted — промокод использован максимальное количество раз.
+	ErrPromoCodeExhausted = errors.New("promo code usage limit reached")
+)
+
+// ErrPlanNotFound — для пользователя не найден ни выбранный тариф, ни тариф по умолчанию.
+var ErrPlanNotFound = errors.New("plan not found")
+
+// ErrInvalidSort — параметры сортировки списка не входят в допустимые значения.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ErrReplayDateInFuture — повторить уведомления можно только за прошедший или текущий день.
+var ErrReplayDateInFuture = errors.New("replay date must not be in the future")
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ExportProfile — профиль пользователя в выгрузке данных, без хэша пароля.
+type ExportProfile struct {
+	UUID               string     `json:"uid"`
+	Email              string     `json:"email"`
+	Username           string     `json:"username"`
+	Role               string     `json:"role"`
+	TrialEndDate       *time.Time `json:"trial_end_date,omitempty"`
+	SubscriptionExpire *time.Time `json:"subscription_expiry,omitempty"`
+	SubscriptionStatus string     `json:"subscription_status"`
+}
+
+// NotificationRecord — запись об отправленном пользователю уведомлении.
+type NotificationRecord struct {
+	Type   string    `json:"type"` // ключ маршрутизации уведомления
+	SentAt time.Time `json:"sent_at"`
+}
+
+// DataExport — все данные пользователя, выгружаемые по запросу (GDPR).
+type DataExport struct {
+	ExportedAt    time.Time            `json:"exported_at"`
+	Profile       ExportProfile        `json:"profile"`
+	Subscriptions []*Entry             `json:"subscriptions"`
+	Payments      []*Payment           `json:"payments"`
+	PaymentTokens []MaskedPaymentToken `json:"payment_tokens"`
+	Notifications []NotificationRecord `json:"notifications"`
+}
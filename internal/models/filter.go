@@ -9,7 +9,7 @@ import "time"
 // FilterSum представляет параметры фильтрации, которые передаются в слой доступа к данным.
 // Используется при подсчёте суммы подписок за определённый период.
 type FilterSum struct {
-	Username      string    // Имя пользователя
+	UserUID       string    // UID пользователя — владельца подписок
 	ServiceName   *string   // Название сервиса (nil, если фильтра по сервису нет)
 	StartDate     time.Time // Дата начала периода
 	CounterMonths int       // Количество месяцев
@@ -22,3 +22,16 @@ type DummyFilterSum struct {
 	StartDate     string `json:"start_date" validate:"required"`              // Дата начала периода
 	CounterMonths int    `json:"counter_months" validate:"required"`          // Количество месяцев подписки
 }
+
+// SumContribution описывает вклад одной подписки в сумму за период.
+type SumContribution struct {
+	ID          int     `json:"id"`
+	ServiceName string  `json:"service_name"`
+	Amount      float64 `json:"amount"` // Стоимость месяцев подписки, попавших в период
+}
+
+// SumBreakdown содержит сумму подписок за период и вклад каждой подписки в нее.
+type SumBreakdown struct {
+	Total         float64           `json:"sum_of_subscriptions"`
+	Subscriptions []SumContribution `json:"subscriptions"`
+}
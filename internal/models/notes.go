@@ -0,0 +1,46 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения заметок и метаданных подписки.
+const (
+	MaxNotesLength   = 500  // Максимальная длина заметки в символах
+	MaxMetadataBytes = 4096 // Максимальный размер метаданных в байтах JSON
+)
+
+// NormalizeNotes обрезает пробелы по краям заметки и проверяет, что она в UTF-8
+// и не длиннее MaxNotesLength символов. Текст ошибки можно вернуть клиенту.
+func NormalizeNotes(notes string) (string, error) {
+	notes = strings.TrimSpace(notes)
+	switch {
+	case !utf8.ValidString(notes):
+		return "", fmt.Errorf("%w: field Notes must be valid UTF-8", ErrInvalidNotes)
+	case utf8.RuneCountInString(notes) > MaxNotesLength:
+		return "", fmt.Errorf("%w: field Notes must be at most %d characters", ErrInvalidNotes, MaxNotesLength)
+	}
+	return notes, nil
+}
+
+// NormalizeMetadata проверяет, что raw — JSON-объект не больше MaxMetadataBytes байт,
+// и возвращает его без лишних пробелов. Пустое значение и null означают отсутствие
+// метаданных, для них возвращается nil. Текст ошибки можно вернуть клиенту.
+func NormalizeMetadata(raw json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	var compact bytes.Buffer
+	if trimmed[0] != '{' || json.Compact(&compact, trimmed) != nil {
+		return nil, fmt.Errorf("%w: field Metadata must be a JSON object", ErrInvalidMetadata)
+	}
+	if compact.Len() > MaxMetadataBytes {
+		return nil, fmt.Errorf("%w: field Metadata must be at most %d bytes", ErrInvalidMetadata, MaxMetadataBytes)
+	}
+	return compact.Bytes(), nil
+}
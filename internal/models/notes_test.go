@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeNotes(t *testing.T) {
+	notes, err := NormalizeNotes("  общая с семьей ")
+	require.NoError(t, err)
+	assert.Equal(t, "общая с семьей", notes)
+
+	_, err = NormalizeNotes(strings.Repeat("я", MaxNotesLength))
+	require.NoError(t, err)
+
+	_, err = NormalizeNotes(strings.Repeat("я", MaxNotesLength+1))
+	assert.ErrorIs(t, err, ErrInvalidNotes)
+	assert.ErrorContains(t, err, "at most 500 characters")
+
+	_, err = NormalizeNotes("note\xff")
+	assert.ErrorIs(t, err, ErrInvalidNotes)
+}
+
+func TestNormalizeMetadata(t *testing.T) {
+	got, err := NormalizeMetadata(json.RawMessage(`{ "shared_with": ["mom", "dad"], "card": "*1234" }`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"shared_with":["mom","dad"],"card":"*1234"}`, string(got))
+
+	for _, empty := range []string{"", "null", "  "} {
+		got, err := NormalizeMetadata(json.RawMessage(empty))
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	}
+
+	for _, invalid := range []string{`["a"]`, `"text"`, `42`, `{"a":`} {
+		_, err := NormalizeMetadata(json.RawMessage(invalid))
+		assert.ErrorIs(t, err, ErrInvalidMetadata, invalid)
+	}
+
+	big := `{"data":"` + strings.Repeat("x", MaxMetadataBytes) + `"}`
+	_, err = NormalizeMetadata(json.RawMessage(big))
+	assert.ErrorIs(t, err, ErrInvalidMetadata)
+	assert.ErrorContains(t, err, "at most 4096 bytes")
+}
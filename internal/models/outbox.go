@@ -0,0 +1,33 @@
+package models
+
+// OutboxMessage представляет уведомление в таблице notification_outbox,
+// ожидающее публикации в RabbitMQ.
+type OutboxMessage struct {
+	ID         int64
+	RoutingKey string
+	Payload    []byte // тело сообщения в JSON
+	DedupKey   string // повторная запись уведомления с тем же ключом игнорируется
+	Attempts   int    // количество неудавшихся попыток публикации
+	// CorrelationID — идентификатор прохода планировщика, записавшего уведомление;
+	// публикуется в свойстве correlation_id сообщения
+	CorrelationID string
+	// UserUID — получатель уведомления; пусто для уведомлений не пользователю
+	UserUID string
+	// SubscriptionIDs — подписки, о которых уведомление; при записи в outbox им проставляется last_notified_at
+	SubscriptionIDs []int
+}
+
+// NotificationReplayResult описывает итог повторного поиска уведомлений за прошедший день.
+type NotificationReplayResult struct {
+	Date          string                  `json:"date"`           // день в формате 2006-01-02
+	Reminders     NotificationReplayStats `json:"reminders"`      // напоминания об окончании подписок
+	TrialExpiring NotificationReplayStats `json:"trial_expiring"` // уведомления об окончании пробного периода
+}
+
+// NotificationReplayStats описывает уведомления одного вида, найденные при повторном поиске.
+type NotificationReplayStats struct {
+	Found   int `json:"found"`   // найдено подписок или пользователей
+	Queued  int `json:"queued"`  // записано новых уведомлений в outbox
+	Skipped int `json:"skipped"` // уведомление уже было записано ранее
+	Failed  int `json:"failed"`  // не удалось записать в outbox
+}
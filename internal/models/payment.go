@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Payment представляет сохраненный платеж пользователя.
+type Payment struct {
+	ID             int       `json:"id"`
+	UserUID        string    `json:"user_uid"`
+	SubscriptionID *int      `json:"subscription_id,omitempty"`
+	PaymentID      string    `json:"payment_id"`
+	Amount         int64     `json:"amount"` // в копейках
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	PaymentTokenID *int      `json:"payment_token_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PaymentStatusSucceeded — статус успешно проведенного платежа.
+const PaymentStatusSucceeded = "succeeded"
+
+// PaymentStatusCanceled — статус платежа, отклоненного провайдером.
+const PaymentStatusCanceled = "canceled"
+
+// Refund описывает возврат платежа.
+type Refund struct {
+	ID        int       `json:"id"`
+	PaymentID int       `json:"payment_id"` // ID платежа в yookassa_payments
+	UserUID   string    `json:"user_uid"`
+	RefundID  string    `json:"refund_id"` // ID возврата из ЮKassa
+	Amount    int64     `json:"amount"`    // в копейках
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaymentReceipt содержит данные платежа, необходимые для формирования квитанции.
+type PaymentReceipt struct {
+	ID          int       `json:"id"`
+	PaymentID   string    `json:"payment_id"`
+	Amount      int64     `json:"amount"` // в копейках
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	ServiceName string    `json:"service_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PaymentDetails — данные платежа пользователя для API-ответа. Идентификатор платежа
+// у провайдера маскируется, а платежный токен и владелец платежа не раскрываются.
+type PaymentDetails struct {
+	ID             int       `json:"id"`
+	PaymentID      string    `json:"payment_id"` // маскированный ID платежа в ЮKassa
+	Amount         int64     `json:"amount"`     // в копейках
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	SubscriptionID *int      `json:"subscription_id,omitempty"`
+	ServiceName    string    `json:"service_name,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NextCharge описывает следующее списание за подписку на сервис.
+type NextCharge struct {
+	Amount   int64     `json:"amount"` // в копейках
+	Currency string    `json:"currency"`
+	Date     time.Time `json:"date"`
+	PlanCode string    `json:"plan_code"`
+	Trial    bool      `json:"trial"` // первое списание после окончания пробного периода
+}
+
+// PaymentMetadataPurpose — ключ metadata платежа с назначением списания.
+const PaymentMetadataPurpose = "purpose"
+
+// PaymentPurposeTrialConversion — списание при переводе пробного периода в оплаченную подписку.
+const PaymentPurposeTrialConversion = "trial_conversion"
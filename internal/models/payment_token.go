@@ -1,11 +1,56 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// maskedTokenVisibleChars — количество последних символов токена, видимых в API-ответах.
+const maskedTokenVisibleChars = 4
 
 // PaymentToken представляет токен платежного метода пользователя.
+// Полное значение Token используется только на стороне сервера для списаний
+// и не сериализуется в JSON; в ответах API используется MaskedPaymentToken.
 type PaymentToken struct {
+	ID        int       `json:"id"`
+	UserUID   string    `json:"user_uid"`
+	Token     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaskedPaymentToken — представление платежного токена для API-ответов,
+// в котором видны только последние символы значения.
+type MaskedPaymentToken struct {
 	ID        int       `json:"id"`
 	UserUID   string    `json:"user_uid"`
 	Token     string    `json:"token"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// MaskToken заменяет звездочками все символы токена, кроме последних четырех.
+// Токены длиной не более четырех символов скрываются полностью.
+func MaskToken(token string) string {
+	if len(token) <= maskedTokenVisibleChars {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", len(token)-maskedTokenVisibleChars) + token[len(token)-maskedTokenVisibleChars:]
+}
+
+// Masked возвращает маскированное представление токена.
+func (t *PaymentToken) Masked() MaskedPaymentToken {
+	return MaskedPaymentToken{
+		ID:        t.ID,
+		UserUID:   t.UserUID,
+		Token:     MaskToken(t.Token),
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// MaskPaymentTokens возвращает маскированные представления списка токенов.
+func MaskPaymentTokens(tokens []*PaymentToken) []MaskedPaymentToken {
+	result := make([]MaskedPaymentToken, 0, len(tokens))
+	for _, t := range tokens {
+		result = append(result, t.Masked())
+	}
+	return result
+}
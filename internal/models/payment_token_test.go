@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{token: "card_1234567890", want: "***********7890"},
+		{token: "12345", want: "*2345"},
+		{token: "1234", want: "****"},
+		{token: "", want: ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MaskToken(tt.token))
+	}
+}
+
+func TestPaymentToken_NeverSerializesFullToken(t *testing.T) {
+	token := &PaymentToken{ID: 1, UserUID: "user123", Token: "card_1234567890"}
+
+	raw, err := json.Marshal(token)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "card_1234567890")
+
+	raw, err = json.Marshal(MaskPaymentTokens([]*PaymentToken{token}))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "card_1234567890")
+	assert.Contains(t, string(raw), `"token":"***********7890"`)
+}
@@ -0,0 +1,12 @@
+package models
+
+// Plan представляет тариф подписки на сервис. Пользователь без выбранного тарифа
+// оплачивает тариф по умолчанию.
+type Plan struct {
+	ID        int    `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Price     int64  `json:"price"` // в копейках
+	Currency  string `json:"currency"`
+	IsDefault bool   `json:"is_default"`
+}
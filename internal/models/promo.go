@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PromoCode представляет промокод на скидку при оплате подписки на сервис.
+// Скидка задается либо процентом PercentOff, либо суммой AmountOff.
+type PromoCode struct {
+	ID         int        `json:"id"`
+	Code       string     `json:"code"`
+	PercentOff int        `json:"percent_off,omitempty"`
+	AmountOff  int64      `json:"amount_off,omitempty"` // в копейках
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	MaxUses    *int       `json:"max_uses,omitempty"` // nil — без ограничения
+	UsedCount  int        `json:"used_count"`
+}
+
+// PromoApplication описывает результат применения промокода к сумме платежа.
+type PromoApplication struct {
+	PromoCodeID int   `json:"promo_code_id"`
+	Amount      int64 `json:"amount"`   // сумма к оплате после скидки, в копейках
+	Discount    int64 `json:"discount"` // в копейках
+}
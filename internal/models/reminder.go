@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxReminderDaysBefore — наибольший срок напоминания до окончания подписки в днях.
+const MaxReminderDaysBefore = 30
+
+// ErrInvalidReminderDays — срок напоминания выходит за пределы от 1 до MaxReminderDaysBefore дней.
+var ErrInvalidReminderDays = errors.New("invalid reminder days")
+
+// ReminderSettings описывает, за сколько дней до окончания подписок пользователь получает напоминания
+// и приходят ли они отдельными письмами или одним дайджестом.
+type ReminderSettings struct {
+	// ReminderDaysBefore — собственный срок пользователя; nil — напоминания по DefaultDaysBefore
+	ReminderDaysBefore *int `json:"reminder_days_before"`
+	// DefaultDaysBefore — сроки из scheduler.reminder_days_before
+	DefaultDaysBefore []int `json:"default_days_before"`
+	// Digest — напоминания обо всех подписках за проход планировщика приходят одним письмом
+	Digest bool `json:"digest"`
+}
+
+// ReminderDigest — сообщение с напоминаниями пользователю обо всех подписках, найденных
+// за один проход планировщика, для отправки одним письмом.
+type ReminderDigest struct {
+	UserUID       string               `json:"-"` // не передается в сообщении, нужен для ключа дедупликации
+	Email         string               `json:"email"`
+	Username      string               `json:"username"`
+	Locale        string               `json:"locale,omitempty"`
+	Date          string               `json:"date"` // день прохода в формате EntryInfoDateLayout
+	Subscriptions []ReminderDigestItem `json:"subscriptions"`
+}
+
+// ReminderDigestItem — одна подписка в ReminderDigest.
+type ReminderDigestItem struct {
+	SubscriptionID int `json:"-"` // не передается в сообщении, нужен для отметки об уведомлении
+
+	ServiceName string `json:"service_name"`
+	EndDate     string `json:"end_date"` // в формате EntryInfoDateLayout
+	Price       int    `json:"price"`
+	Currency    string `json:"currency,omitempty"` // валюта цены; в старых сообщениях отсутствует
+	DaysBefore  int    `json:"days_before"`
+}
+
+// ValidateReminderDays проверяет срок напоминания. nil допустим и означает сроки по умолчанию.
+func ValidateReminderDays(days *int) error {
+	if days != nil && (*days < 1 || *days > MaxReminderDaysBefore) {
+		return fmt.Errorf("%w: field ReminderDaysBefore must be between 1 and %d", ErrInvalidReminderDays, MaxReminderDaysBefore)
+	}
+	return nil
+}
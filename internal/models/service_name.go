@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxServiceNameLength — максимальная длина названия сервиса в символах.
+const MaxServiceNameLength = 100
+
+// NormalizeServiceName обрезает пробелы по краям названия сервиса и проверяет его:
+// название не должно быть пустым, длиннее MaxServiceNameLength символов
+// и не должно содержать управляющих символов. Текст ошибки можно вернуть клиенту.
+func NormalizeServiceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: field ServiceName must not be empty", ErrInvalidServiceName)
+	case !utf8.ValidString(name):
+		return "", fmt.Errorf("%w: field ServiceName must be valid UTF-8", ErrInvalidServiceName)
+	case utf8.RuneCountInString(name) > MaxServiceNameLength:
+		return "", fmt.Errorf("%w: field ServiceName must be at most %d characters", ErrInvalidServiceName, MaxServiceNameLength)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "", fmt.Errorf("%w: field ServiceName must not contain control characters", ErrInvalidServiceName)
+	}
+	return name, nil
+}
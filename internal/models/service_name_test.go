@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeServiceName(t *testing.T) {
+	name, err := NormalizeServiceName("  Netflix Premium ")
+	require.NoError(t, err)
+	assert.Equal(t, "Netflix Premium", name)
+
+	name, err = NormalizeServiceName(strings.Repeat("я", MaxServiceNameLength))
+	require.NoError(t, err)
+	assert.Equal(t, MaxServiceNameLength, len([]rune(name)))
+
+	tests := []struct {
+		name    string
+		input   string
+		wantMsg string
+	}{
+		{"пустое название", "", "must not be empty"},
+		{"только пробелы", " \t\n ", "must not be empty"},
+		{"слишком длинное", strings.Repeat("a", MaxServiceNameLength+1), "at most 100 characters"},
+		{"перевод строки внутри", "Net\nflix", "control characters"},
+		{"нулевой байт", "Netflix\x00", "control characters"},
+		{"некорректный UTF-8", "Net\xffflix", "valid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NormalizeServiceName(tt.input)
+
+			require.ErrorIs(t, err, ErrInvalidServiceName)
+			assert.Contains(t, err.Error(), tt.wantMsg)
+		})
+	}
+}
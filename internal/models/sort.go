@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Поля, по которым можно сортировать список подписок.
+const (
+	SortByID              = "id"
+	SortByNextPaymentDate = "next_payment_date"
+	SortByPrice           = "price"
+	SortByServiceName     = "service_name"
+)
+
+// SortableFields — допустимые значения параметра sort. Значения, не входящие
+// в этот список, не должны попадать в ORDER BY.
+var SortableFields = []string{SortByID, SortByNextPaymentDate, SortByPrice, SortByServiceName}
+
+// ListSort задает порядок сортировки списка подписок. Нулевое значение — сортировка по id по возрастанию.
+type ListSort struct {
+	Field string // Поле сортировки из SortableFields
+	Desc  bool   // Сортировка по убыванию
+}
+
+// ParseListSort проверяет параметры sort и order запроса. Пустые значения
+// означают сортировку по id по возрастанию.
+func ParseListSort(field, order string) (ListSort, error) {
+	sort := ListSort{Field: SortByID}
+	if field != "" {
+		field = strings.ToLower(field)
+		allowed := false
+		for _, f := range SortableFields {
+			if f == field {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ListSort{}, fmt.Errorf("%w: sort must be one of %s", ErrInvalidSort, strings.Join(SortableFields, ", "))
+		}
+		sort.Field = field
+	}
+
+	switch strings.ToLower(order) {
+	case "", "asc":
+	case "desc":
+		sort.Desc = true
+	default:
+		return ListSort{}, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+	return sort, nil
+}
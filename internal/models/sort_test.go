@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListSort(t *testing.T) {
+	sort, err := ParseListSort("", "")
+	require.NoError(t, err)
+	assert.Equal(t, ListSort{Field: SortByID}, sort)
+
+	for _, field := range SortableFields {
+		sort, err := ParseListSort(field, "desc")
+		require.NoError(t, err)
+		assert.Equal(t, ListSort{Field: field, Desc: true}, sort)
+	}
+
+	sort, err = ParseListSort("Price", "ASC")
+	require.NoError(t, err)
+	assert.Equal(t, ListSort{Field: SortByPrice}, sort)
+
+	_, err = ParseListSort("password_hash", "")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+
+	_, err = ParseListSort("id; DROP TABLE subscriptions", "")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+
+	_, err = ParseListSort("price", "random")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
@@ -0,0 +1,63 @@
+package models
+
+import "errors"
+
+// AdminStats содержит агрегированные показатели сервиса для панели администратора.
+type AdminStats struct {
+	TotalUsers          int            `json:"total_users"`          // Общее количество пользователей
+	UsersByStatus       map[string]int `json:"users_by_status"`      // Количество пользователей по статусу подписки
+	ActiveSubscriptions int            `json:"active_subscriptions"` // Количество активных подписок
+	MRR                 float64        `json:"mrr"`                  // Ежемесячная регулярная выручка по активным подпискам
+	TrialConversions    int            `json:"trial_conversions"`    // Количество пользователей, перешедших с пробного периода на оплату
+}
+
+// RecomputeResult описывает итог пересчета дат следующего платежа.
+type RecomputeResult struct {
+	Checked   int `json:"checked"`
+	Corrected int `json:"corrected"`
+	Failed    int `json:"failed"`
+}
+
+// ErrInvalidShiftRequest — некорректный запрос на сдвиг дат следующего платежа.
+var ErrInvalidShiftRequest = errors.New("invalid shift request")
+
+// PaymentDateShiftFilter выбирает подписки для сдвига даты следующего платежа.
+// Условия объединяются через И; должно быть задано хотя бы одно из них.
+type PaymentDateShiftFilter struct {
+	ServiceName string `json:"service_name,omitempty"` // Название сервиса без учета регистра
+	UserUID     string `json:"user_uid,omitempty"`     // Владелец подписок
+	DueFrom     string `json:"due_from,omitempty"`     // Дата следующего платежа не раньше, в формате 2006-01-02
+	DueTo       string `json:"due_to,omitempty"`       // Дата следующего платежа не позже, в формате 2006-01-02
+}
+
+// PaymentDateShiftRequest описывает запрос на сдвиг next_payment_date группы подписок
+// на OffsetDays дней; отрицательное значение сдвигает даты назад.
+type PaymentDateShiftRequest struct {
+	Filter     PaymentDateShiftFilter `json:"filter"`
+	OffsetDays int                    `json:"offset_days" validate:"required,min=-366,max=366"`
+}
+
+// PaymentDateShiftResult описывает итог сдвига дат следующего платежа.
+type PaymentDateShiftResult struct {
+	OffsetDays int `json:"offset_days"`
+	Shifted    int `json:"shifted"`
+}
+
+// IntegrityReportSampleSize — сколько ID записей каждого вида аномалии возвращает проверка целостности.
+const IntegrityReportSampleSize = 100
+
+// IntegrityIssue описывает один вид аномалии данных: сколько записей ее содержат
+// и ID первых IntegrityReportSampleSize из них по возрастанию.
+type IntegrityIssue struct {
+	Count int   `json:"count"`
+	IDs   []int `json:"ids"`
+}
+
+// IntegrityReport описывает итог проверки целостности данных.
+type IntegrityReport struct {
+	OrphanSubscriptions IntegrityIssue `json:"orphan_subscriptions"` // Подписки, владельца которых нет среди пользователей
+	OrphanPayments      IntegrityIssue `json:"orphan_payments"`      // Платежи со ссылкой на несуществующую подписку
+	NegativePrices      IntegrityIssue `json:"negative_prices"`      // Подписки с отрицательной ценой
+	PaymentBeforeStart  IntegrityIssue `json:"payment_before_start"` // Подписки с next_payment_date раньше start_date
+	Total               int            `json:"total"`                // Общее число записей с аномалиями
+}
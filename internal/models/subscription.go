@@ -2,7 +2,11 @@
 // а также вспомогательные типы для работы с данными из внешних источников (например, JSON-запросы).
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Entry представляет собой основную модель подписки,
 // используемую в бизнес-логике и хранилище.
@@ -18,6 +22,9 @@ type Entry struct {
 	NextPaymentDate time.Time
 	IsActive        bool
 	UserUID         string
+	Currency        string          // Валюта цены подписки, например RUB
+	Notes           string          // Заметка пользователя, например "общая с семьей"; пусто — не задана
+	Metadata        json.RawMessage // Произвольный JSON-объект пользователя; nil — не задан
 }
 
 // DummyEntry используется для приёма данных из JSON-запроса,
@@ -26,16 +33,206 @@ type Entry struct {
 type DummyEntry struct {
 	ServiceName   string `json:"service_name" validate:"required"`        // Название сервиса
 	Price         int    `json:"price" validate:"required,gt=0"`          // Цена (>0)
-	StartDate     string `json:"start_date" validate:"required"`          // Дата начала в формате 01-2006
+	StartDate     string `json:"start_date" validate:"required"`          // Дата начала, например 02-01-2006 или 2006-01-02
 	CounterMonths int    `json:"counter_months" validate:"required,gt=0"` // Количество месяцев
 	IsActive      bool   `json:"is_active"`
+	// Currency — код валюты ISO 4217, в которой указана цена. При создании пустое значение
+	// означает валюту по умолчанию, при обновлении — текущую валюту подписки.
+	Currency string `json:"currency,omitempty" validate:"omitempty,len=3"`
+	// CurrencyChanged подтверждает при обновлении, что валюта меняется намеренно
+	// и price уже пересчитана в новую валюту. Без него другая валюта отклоняется.
+	CurrencyChanged bool `json:"currency_changed,omitempty"`
+	// Notes — заметка к подписке. При обновлении отсутствующее поле оставляет текущую заметку,
+	// пустая строка удаляет ее.
+	Notes *string `json:"notes,omitempty"`
+	// Metadata — JSON-объект с произвольными данными. При обновлении отсутствующее поле
+	// оставляет текущие метаданные, null удаляет их.
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
 }
 
+// EntryPreview описывает расчетную стоимость подписки, которую пользователь собирается создать.
+type EntryPreview struct {
+	ServiceName     string  `json:"service_name"`
+	Price           int     `json:"price"`
+	StartDate       string  `json:"start_date"`
+	EndDate         string  `json:"end_date"`
+	CounterMonths   int     `json:"counter_months"`
+	NextPaymentDate string  `json:"next_payment_date"`
+	Total           float64 `json:"total"`
+}
+
+// Статусы подписки в сгруппированном списке.
+const (
+	EntryStatusActive  = "active"  // подписка активна и срок не истек
+	EntryStatusPaused  = "paused"  // подписка приостановлена пользователем, срок не истек
+	EntryStatusExpired = "expired" // срок подписки истек
+)
+
+// EntryGroup содержит подписки пользователя с одним статусом.
+type EntryGroup struct {
+	Count   int      `json:"count"`
+	Total   int      `json:"total"` // Сумма ежемесячных цен подписок группы
+	Entries []*Entry `json:"entries"`
+}
+
+// GroupedEntries содержит подписки пользователя, сгруппированные по статусу.
+type GroupedEntries struct {
+	Active  EntryGroup `json:"active"`
+	Paused  EntryGroup `json:"paused"`
+	Expired EntryGroup `json:"expired"`
+}
+
+// YearlyEstimate содержит годовую стоимость активных подписок пользователя в одной валюте.
+// Цена подписки ежемесячная, поэтому годовая стоимость — сумма цен, умноженная на 12.
+type YearlyEstimate struct {
+	Currency string `json:"currency"`
+	Count    int    `json:"count"`
+	Total    int    `json:"total"` // Годовая стоимость в целых единицах валюты
+}
+
+// CancelSavingsMonths — за сколько месяцев вперед считается экономия от отмены подписки.
+const CancelSavingsMonths = 12
+
+// CancelSavings описывает, сколько пользователь сэкономит, если отменит подписку сегодня:
+// стоимость ее списаний с From (включительно) до To (не включая).
+type CancelSavings struct {
+	ID          int    `json:"id"`
+	ServiceName string `json:"service_name"`
+	Price       int    `json:"price"` // Текущая цена за месяц
+	Currency    string `json:"currency"`
+	From        string `json:"from"`   // Начало периода расчета в формате 2006-01-02
+	To          string `json:"to"`     // Конец периода расчета в формате 2006-01-02
+	Months      int    `json:"months"` // Сколько ежемесячных списаний не состоится
+	Savings     int    `json:"savings"`
+}
+
+// MonthlySpend — стоимость подписок пользователя за один календарный месяц.
+type MonthlySpend struct {
+	Month string  `json:"month"` // Месяц в формате 2006-01
+	Total float64 `json:"total"` // Сумма ежемесячных цен подписок, списываемых в этом месяце
+}
+
+// AttentionEntry описывает активную подписку в списке подписок, требующих внимания:
+// дату ближайшего платежа и признаки, по которым этот платеж может не пройти.
+type AttentionEntry struct {
+	ID                  int       `json:"id"`
+	ServiceName         string    `json:"service_name"`
+	Price               int       `json:"price"`
+	Currency            string    `json:"currency"`
+	NextPaymentDate     time.Time `json:"next_payment_date"`
+	MissingPaymentToken bool      `json:"missing_payment_token"` // У пользователя нет действующей сохраненной карты
+	LastPaymentFailed   bool      `json:"last_payment_failed"`   // Последний платеж по подписке отклонен провайдером
+}
+
+// ImportRow описывает результат разбора или импорта одной строки файла с подписками.
+// Строка с ошибкой содержит Error и не создает подписку.
+type ImportRow struct {
+	Line  int         `json:"line"`            // Номер строки в файле, начиная с 1
+	Entry *DummyEntry `json:"entry,omitempty"` // Данные подписки, если строку удалось разобрать
+	ID    int         `json:"id,omitempty"`    // ID созданной подписки после импорта
+	Error string      `json:"error,omitempty"`
+}
+
+// PriceChange описывает изменение цены подписки, сохраненное в истории.
+type PriceChange struct {
+	SubscriptionID int
+	OldPrice       int
+	NewPrice       int
+	ChangedAt      time.Time
+}
+
+// PricePoint описывает цену подписки на сервис, действующую с момента Date.
+type PricePoint struct {
+	Date           time.Time `json:"date"`
+	Price          int       `json:"price"`
+	Currency       string    `json:"currency"`
+	SubscriptionID int       `json:"subscription_id"` // подписка, к которой относится цена
+}
+
+// EntryInfoDateLayout задает формат поля end_date в сообщениях RabbitMQ.
+// Дата окончания хранится как DATE, поэтому время суток не передается.
+const EntryInfoDateLayout = "2006-01-02"
+
 // EntryInfo содержит информацию о подписке для уведомлений.
 type EntryInfo struct {
-	Email       string
-	Username    string
-	ServiceName string
-	EndDate     time.Time
-	Price       int
+	SubscriptionID int       `json:"-"` // не передается в сообщении, нужен для ключа дедупликации
+	UserUID        string    `json:"-"` // не передается в сообщении, нужен для группировки в дайджест
+	Email          string    `json:"email"`
+	Username       string    `json:"username"`
+	ServiceName    string    `json:"service_name"`
+	EndDate        time.Time `json:"end_date"`
+	Price          int       `json:"price"`
+	Currency       string    `json:"currency,omitempty"` // валюта цены; в старых сообщениях отсутствует
+	Locale         string    `json:"locale,omitempty"`
+	DaysBefore     int       `json:"days_before,omitempty"` // за сколько дней до окончания отправлено напоминание
+	Digest         bool      `json:"-"`                     // пользователь получает напоминания одним письмом
+	// LastNotifiedAt — когда о подписке последний раз записывалось уведомление; nil — еще ни разу
+	LastNotifiedAt *time.Time `json:"-"`
+}
+
+// entryInfoJSON описывает представление EntryInfo в JSON с датой в виде строки.
+type entryInfoJSON struct {
+	Email       string `json:"email"`
+	Username    string `json:"username"`
+	ServiceName string `json:"service_name"`
+	EndDate     string `json:"end_date"`
+	Price       int    `json:"price"`
+	Currency    string `json:"currency,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	DaysBefore  int    `json:"days_before,omitempty"`
+}
+
+// MarshalJSON сериализует EntryInfo, записывая end_date в формате EntryInfoDateLayout.
+func (e EntryInfo) MarshalJSON() ([]byte, error) {
+	var endDate string
+	if !e.EndDate.IsZero() {
+		endDate = e.EndDate.Format(EntryInfoDateLayout)
+	}
+	return json.Marshal(entryInfoJSON{
+		Email:       e.Email,
+		Username:    e.Username,
+		ServiceName: e.ServiceName,
+		EndDate:     endDate,
+		Price:       e.Price,
+		Currency:    e.Currency,
+		Locale:      e.Locale,
+		DaysBefore:  e.DaysBefore,
+	})
+}
+
+// UnmarshalJSON десериализует EntryInfo. Поле end_date принимается как в формате
+// EntryInfoDateLayout, так и в RFC3339 — для совместимости со старыми сообщениями.
+func (e *EntryInfo) UnmarshalJSON(data []byte) error {
+	var raw entryInfoJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	endDate, err := parseEntryInfoDate(raw.EndDate)
+	if err != nil {
+		return err
+	}
+	*e = EntryInfo{
+		Email:       raw.Email,
+		Username:    raw.Username,
+		ServiceName: raw.ServiceName,
+		EndDate:     endDate,
+		Price:       raw.Price,
+		Currency:    raw.Currency,
+		Locale:      raw.Locale,
+		DaysBefore:  raw.DaysBefore,
+	}
+	return nil
+}
+
+func parseEntryInfoDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(EntryInfoDateLayout, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid end_date %q: expected %s or RFC3339", value, EntryInfoDateLayout)
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryInfo_JSONRoundTrip(t *testing.T) {
+	info := EntryInfo{
+		Email:       "test@example.com",
+		Username:    "testuser",
+		ServiceName: "Netflix",
+		EndDate:     time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Price:       500,
+		Currency:    "USD",
+		DaysBefore:  3,
+	}
+
+	raw, err := json.Marshal(info)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"2024-01-31","price":500,"currency":"USD","days_before":3}`, string(raw))
+
+	var decoded EntryInfo
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, info, decoded)
+}
+
+func TestEntryInfo_UnmarshalEndDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		endDate string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "date only", endDate: `"2024-01-01"`, want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "RFC3339", endDate: `"2024-01-01T00:00:00Z"`, want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "empty", endDate: `""`, want: time.Time{}},
+		{name: "invalid", endDate: `"01/01/2024"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info EntryInfo
+			err := json.Unmarshal([]byte(`{"email":"test@example.com","end_date":`+tt.endDate+`}`), &info)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(info.EndDate))
+			assert.Equal(t, "test@example.com", info.Email)
+		})
+	}
+}
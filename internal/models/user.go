@@ -15,4 +15,16 @@ type User struct {
 	TrialEndDate       *time.Time // Дата истечения пробного периода
 	SubscriptionExpire *time.Time // Дата истечения оплаченной подписки на сервис
 	SubscriptionStatus string
+	Locale             string // Локаль для уведомлений, например ru-RU или en-US
+}
+
+// UserSummary — данные пользователя для административного поиска, без хэша пароля.
+type UserSummary struct {
+	UUID               string     `json:"uid"`
+	Email              string     `json:"email"`
+	Username           string     `json:"username"`
+	Role               string     `json:"role"`
+	SubscriptionStatus string     `json:"subscription_status"`
+	TrialEndDate       *time.Time `json:"trial_end_date,omitempty"`
+	SubscriptionExpire *time.Time `json:"subscription_expiry,omitempty"`
 }
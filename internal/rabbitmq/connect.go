@@ -2,44 +2,141 @@
 package rabbitmq
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"time"
 
 	"github.com/streadway/amqp"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 )
 
-// Connect устанавливает соединение с RabbitMQ с повторными попытками.
-func Connect(connection string, retries int, delay time.Duration) (*amqp.Connection, error) {
+// RetryPolicy задает повторы подключения к RabbitMQ при старте сервиса.
+// Задержка растет экспоненциально и случайно уменьшается на долю Jitter,
+// чтобы сервисы не переподключались одновременно после перезапуска брокера.
+type RetryPolicy struct {
+	MaxAttempts int           // Максимальное число попыток, включая первую; меньше 1 — одна попытка
+	BaseDelay   time.Duration // Задержка перед первым повтором; каждая следующая вдвое больше
+	MaxDelay    time.Duration // Верхняя граница задержки (0 — без ограничения)
+	Jitter      float64       // Доля задержки от 0 до 1, на которую она может быть случайно уменьшена
+}
+
+// backoff возвращает задержку перед повтором с номером attempt (начиная с 1) без учета Jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d < p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// delay возвращает задержку перед повтором с номером attempt с учетом Jitter.
+// random возвращает число из [0, 1).
+func (p RetryPolicy) delay(attempt int, random func() float64) time.Duration {
+	d := p.backoff(attempt)
+	jitter := min(max(p.Jitter, 0), 1)
+	return d - time.Duration(float64(d)*jitter*random())
+}
+
+// retrier содержит зависимости цикла подключения, которые подменяются в тестах.
+type retrier struct {
+	dial   func() (*amqp.Connection, error)           // Открывает соединение с брокером
+	random func() float64                             // Случайное число из [0, 1) для Jitter
+	wait   func(context.Context, time.Duration) error // Ожидание перед повтором с учетом отмены
+}
+
+// waitContext ждет d или отмены ctx.
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Connect устанавливает соединение с RabbitMQ с повторными попытками по policy.
+// Каждая неудачная попытка записывается в лог. Ожидание между попытками прерывается
+// отменой ctx, и тогда возвращается ошибка контекста.
+func Connect(ctx context.Context, connection string, policy RetryPolicy, log *slog.Logger) (*amqp.Connection, error) {
+	r := retrier{
+		dial:   func() (*amqp.Connection, error) { return amqp.Dial(connection) },
+		random: rand.Float64,
+		wait:   waitContext,
+	}
+	return r.connect(ctx, policy, log)
+}
+
+func (r retrier) connect(ctx context.Context, policy RetryPolicy, log *slog.Logger) (*amqp.Connection, error) {
 	const op = "rabbitmq.ConnectRabbitMQ"
-	var conn *amqp.Connection
+	attempts := max(policy.MaxAttempts, 1)
+
 	var err error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 
-	for range retries {
-		conn, err = amqp.Dial(connection)
+		var conn *amqp.Connection
+		conn, err = r.dial()
 		if err == nil {
+			if attempt > 1 {
+				log.Info("connected to RabbitMQ", slog.Int("attempt", attempt))
+			}
 			return conn, nil
 		}
-		time.Sleep(delay)
+		if attempt == attempts {
+			break
+		}
+
+		delay := policy.delay(attempt, r.random)
+		log.Warn("failed to connect to RabbitMQ, retrying",
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", attempts),
+			slog.Duration("delay", delay),
+			sl.Err(err))
+
+		if err := r.wait(ctx, delay); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
+	log.Error("failed to connect to RabbitMQ", slog.Int("attempts", attempts), sl.Err(err))
 	return nil, fmt.Errorf("%s: %w", op, err)
 }
 
-// SetupChannel настраивает канал RabbitMQ и создает необходимые очереди.
-func SetupChannel(conn *amqp.Connection, queues []QueueConfig) (*amqp.Channel, error) {
-	const op = "rabbitmq.SetupChannel"
+// OpenChannel открывает канал RabbitMQ с ограничением числа неподтвержденных сообщений,
+// не объявляя exchange и очереди.
+func OpenChannel(conn *amqp.Connection) (*amqp.Channel, error) {
+	const op = "rabbitmq.OpenChannel"
 
 	ch, err := conn.Channel()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	if err := ch.Qos(10, 0, false); err != nil {
+		_ = ch.Close()
 		return nil, fmt.Errorf("failed to set QoS: %w", err)
 	}
+	return ch, nil
+}
+
+// SetupChannel настраивает канал RabbitMQ и создает необходимые очереди.
+func SetupChannel(conn *amqp.Connection, queues []QueueConfig) (*amqp.Channel, error) {
+	const op = "rabbitmq.SetupChannel"
+
+	ch, err := OpenChannel(conn)
+	if err != nil {
+		return nil, err
+	}
 
 	err = ch.ExchangeDeclare(
-		"notifications", // exchange
-		"direct",        // тип
+		NotificationsExchange, // exchange
+		"direct",              // тип
 		true,
 		false,
 		false,
@@ -66,7 +163,7 @@ func SetupChannel(conn *amqp.Connection, queues []QueueConfig) (*amqp.Channel, e
 		err = ch.QueueBind(
 			q.QueueName,
 			q.RoutingKey,
-			"notifications",
+			NotificationsExchange,
 			false,
 			nil,
 		)
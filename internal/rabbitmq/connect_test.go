@@ -2,11 +2,15 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -15,6 +19,9 @@ import (
 
 const SkipRabbitMQTestsEnv = "true"
 
+// testRetryPolicy — повторы подключения к тестовому брокеру.
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}
+
 func SetupRabbitMQContainer(ctx context.Context, t *testing.T) (testcontainers.Container, func()) {
 	req := testcontainers.ContainerRequest{
 		Image:        "rabbitmq:3-management",
@@ -118,7 +125,7 @@ func TestConnectAndSetupChannel(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt // локальная копия для параллельных вызовов
 		t.Run(tt.name, func(t *testing.T) {
-			conn, err := Connect(tt.amqpURI, 3, time.Second)
+			conn, err := Connect(ctx, tt.amqpURI, testRetryPolicy, slog.New(slog.DiscardHandler))
 			if tt.wantErr {
 				require.Error(t, err)
 				if tt.errMessage != "" {
@@ -150,3 +157,107 @@ func TestConnectAndSetupChannel(t *testing.T) {
 		})
 	}
 }
+
+func TestConnect_Backoff(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	dialErr := errors.New("connection refused")
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	// failingDialer возвращает ошибку failures раз, затем соединение.
+	failingDialer := func(failures int, calls *int) func() (*amqp.Connection, error) {
+		return func() (*amqp.Connection, error) {
+			*calls++
+			if *calls <= failures {
+				return nil, dialErr
+			}
+			return &amqp.Connection{}, nil
+		}
+	}
+
+	t.Run("подключение после нескольких неудач с растущей задержкой", func(t *testing.T) {
+		var calls int
+		var delays []time.Duration
+		r := retrier{
+			dial:   failingDialer(4, &calls),
+			random: func() float64 { return 0 },
+			wait: func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			},
+		}
+
+		conn, err := r.connect(context.Background(), policy, logger)
+		require.NoError(t, err)
+		assert.NotNil(t, conn)
+		assert.Equal(t, 5, calls)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}, delays)
+	})
+
+	t.Run("задержка ограничена MaxDelay и уменьшается на Jitter", func(t *testing.T) {
+		var calls int
+		var delays []time.Duration
+		jittered := policy
+		jittered.Jitter = 0.5
+		r := retrier{
+			dial:   failingDialer(10, &calls),
+			random: func() float64 { return 0.5 },
+			wait: func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			},
+		}
+
+		_, err := r.connect(context.Background(), jittered, logger)
+		assert.ErrorIs(t, err, dialErr)
+		assert.Equal(t, policy.MaxAttempts, calls)
+		// Каждая задержка уменьшена на четверть: 0.5 доли Jitter * 0.5 случайного значения
+		assert.Equal(t, []time.Duration{75 * time.Millisecond, 150 * time.Millisecond, 300 * time.Millisecond,
+			600 * time.Millisecond, 750 * time.Millisecond}, delays)
+	})
+
+	t.Run("случайная задержка в пределах Jitter", func(t *testing.T) {
+		jittered := RetryPolicy{BaseDelay: time.Second, Jitter: 0.3}
+		for range 100 {
+			d := jittered.delay(1, rand.Float64)
+			assert.GreaterOrEqual(t, d, 700*time.Millisecond)
+			assert.LessOrEqual(t, d, time.Second)
+		}
+	})
+
+	t.Run("отмена контекста прерывает ожидание", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int
+		r := retrier{
+			dial:   failingDialer(10, &calls),
+			random: func() float64 { return 0 },
+			wait: func(ctx context.Context, d time.Duration) error {
+				cancel()
+				return waitContext(ctx, d)
+			},
+		}
+
+		_, err := r.connect(ctx, policy, logger)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("отмененный контекст не начинает подключение", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var calls int
+		r := retrier{dial: failingDialer(0, &calls), random: rand.Float64, wait: waitContext}
+
+		_, err := r.connect(ctx, policy, logger)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, calls)
+	})
+
+	t.Run("без MaxAttempts выполняется одна попытка", func(t *testing.T) {
+		var calls int
+		r := retrier{dial: failingDialer(10, &calls), random: rand.Float64, wait: waitContext}
+
+		_, err := r.connect(context.Background(), RetryPolicy{}, logger)
+		assert.ErrorIs(t, err, dialErr)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -2,18 +2,53 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 )
 
-// ConsumerMessage создает потребителя сообщений из очереди RabbitMQ.
+// ErrConsumerClosed возвращается, если брокер закрыл канал доставки до отмены контекста.
+var ErrConsumerClosed = errors.New("delivery channel closed")
+
+// maxInFlight — сколько сообщений одного потребителя обрабатываются одновременно.
+const maxInFlight = 10
+
+// ConsumerMessage потребляет сообщения из очереди RabbitMQ, пока не отменен ctx.
+// После отмены новые сообщения не принимаются, а ConsumerMessage ждет завершения уже
+// запущенных обработчиков и возвращает nil. Канал ch после возврата можно закрывать:
+// все принятые сообщения подтверждены или возвращены в очередь.
 func ConsumerMessage(ctx context.Context, ch *amqp.Channel, queueName string, handler func([]byte) error) error {
 	const op = "rabbitmq.ConsumerMessage"
+	if err := consume(ctx, ch, queueName, func(d amqp.Delivery) error {
+		return handler(d.Body)
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ConsumerRouted потребляет сообщения из очереди и передает их обработчикам Router
+// по ключу маршрутизации доставки. Контекст обработчика содержит идентификатор корреляции
+// из свойства correlation_id сообщения (см. CorrelationID). Останавливается так же, как ConsumerMessage.
+func ConsumerRouted(ctx context.Context, ch *amqp.Channel, queueName string, router *Router) error {
+	const op = "rabbitmq.ConsumerRouted"
+	if err := consume(ctx, ch, queueName, func(d amqp.Delivery) error {
+		return router.Dispatch(deliveryContext(ctx, d), d.RoutingKey, d.Body)
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func consume(ctx context.Context, ch *amqp.Channel, queueName string, handler func(amqp.Delivery) error) error {
+	tag := queueName + "-" + uuid.NewString()
 	delivery, err := ch.Consume(
 		queueName,
-		"",
+		tag,
 		false,
 		false,
 		false,
@@ -21,34 +56,64 @@ func ConsumerMessage(ctx context.Context, ch *amqp.Channel, queueName string, ha
 		nil,
 	)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	sem := make(chan struct{}, 10)
-	go func() {
-		for {
-			select {
-			case d, ok := <-delivery:
-				if !ok {
-					return
+	// Отмена потребителя на брокере останавливает выдачу новых сообщений,
+	// пока дорабатываются уже принятые.
+	stop := context.AfterFunc(ctx, func() {
+		if cancelErr := ch.Cancel(tag, false); cancelErr != nil {
+			log.Printf("failed to cancel consumer %s: %v", tag, cancelErr)
+		}
+	})
+	defer stop()
+
+	return serve(ctx, delivery, handler)
+}
+
+// serve передает доставки обработчику, не более maxInFlight одновременно, пока не отменен ctx
+// или не закрыт канал доставки. Перед возвратом дожидается запущенных обработчиков.
+func serve(ctx context.Context, delivery <-chan amqp.Delivery, handler func(amqp.Delivery) error) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, maxInFlight)
+	for {
+		select {
+		case d, ok := <-delivery:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
 				}
-				sem <- struct{}{}
-				go func(delivery amqp.Delivery) {
-					defer func() { <-sem }()
-					if err := handler(delivery.Body); err != nil {
-						if nackErr := delivery.Nack(false, true); nackErr != nil {
-							log.Printf("failed to nack message: %v", nackErr)
-						}
-						return
-					}
-					if ackErr := delivery.Ack(false); ackErr != nil {
-						log.Printf("failed to ack message: %v", ackErr)
-					}
-				}(d)
+				return ErrConsumerClosed
+			}
+			select {
+			case sem <- struct{}{}:
 			case <-ctx.Done():
-				return
+				// Сообщение принято, но обработать его уже не успеем — возвращаем в очередь
+				if nackErr := d.Nack(false, true); nackErr != nil {
+					log.Printf("failed to nack message: %v", nackErr)
+				}
+				return nil
 			}
+			wg.Add(1)
+			go func(delivery amqp.Delivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if err := handler(delivery); err != nil {
+					// Сообщение без обработчика или с окончательной ошибкой не станет обрабатываемым при повторе.
+					requeue := !errors.Is(err, ErrNoHandler) && !errors.Is(err, ErrPermanent)
+					if nackErr := delivery.Nack(false, requeue); nackErr != nil {
+						log.Printf("failed to nack message: %v", nackErr)
+					}
+					return
+				}
+				if ackErr := delivery.Ack(false); ackErr != nil {
+					log.Printf("failed to ack message: %v", ackErr)
+				}
+			}(d)
+		case <-ctx.Done():
+			return nil
 		}
-	}()
-	return nil
+	}
 }
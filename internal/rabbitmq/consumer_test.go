@@ -3,6 +3,7 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"sync"
 	"testing"
@@ -43,7 +44,7 @@ func TestConsumerMessage_HandleMessages(t *testing.T) {
 	defer cleanup()
 
 	// Подключаемся
-	conn, err := Connect(amqpURI, 3, time.Second)
+	conn, err := Connect(ctx, amqpURI, testRetryPolicy, slog.New(slog.DiscardHandler))
 	require.NoError(t, err)
 	defer func() {
 		if err := conn.Close(); err != nil {
@@ -82,8 +83,12 @@ func TestConsumerMessage_HandleMessages(t *testing.T) {
 	}
 
 	// Запуск консьюмера
-	err = ConsumerMessage(ctx, ch, queueName, handler)
-	require.NoError(t, err)
+	consumerCtx, stopConsumer := context.WithCancel(ctx)
+	defer stopConsumer()
+	consumerDone := make(chan error, 1)
+	go func() {
+		consumerDone <- ConsumerMessage(consumerCtx, ch, queueName, handler)
+	}()
 
 	// Публикуем 2 сообщения
 	for _, msg := range []string{"hello", "world"} {
@@ -111,8 +116,17 @@ func TestConsumerMessage_HandleMessages(t *testing.T) {
 	}
 
 	mu.Lock()
-	defer mu.Unlock()
 	assert.ElementsMatch(t, []string{"hello", "world"}, received)
+	mu.Unlock()
+
+	// Отмена контекста останавливает потребителя
+	stopConsumer()
+	select {
+	case err := <-consumerDone:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("ConsumerMessage did not return after context cancellation")
+	}
 }
 
 func TestConsumerMessage_HandlerErrorTriggersNack(t *testing.T) {
@@ -144,7 +158,7 @@ func TestConsumerMessage_HandlerErrorTriggersNack(t *testing.T) {
 	}
 	defer cleanup()
 
-	conn, err := Connect(amqpURI, 3, time.Second)
+	conn, err := Connect(ctx, amqpURI, testRetryPolicy, slog.New(slog.DiscardHandler))
 	require.NoError(t, err)
 	defer func() {
 		if err := conn.Close(); err != nil {
@@ -169,8 +183,9 @@ func TestConsumerMessage_HandlerErrorTriggersNack(t *testing.T) {
 		return fmt.Errorf("fail")
 	}
 
-	err = ConsumerMessage(ctx, ch, queueName, handler)
-	require.NoError(t, err)
+	go func() {
+		_ = ConsumerMessage(ctx, ch, queueName, handler)
+	}()
 
 	// Публикуем сообщение
 	err = ch.Publish("", queueName, false, false, amqp.Publishing{
@@ -190,3 +205,76 @@ func TestConsumerMessage_HandlerErrorTriggersNack(t *testing.T) {
 		t.Fatal("Did not receive requeued message after Nack")
 	}
 }
+
+// fakeAcknowledger запоминает подтверждения доставок без брокера.
+type fakeAcknowledger struct {
+	mu     sync.Mutex
+	acked  []uint64
+	nacked []uint64
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, _ bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, _ bool, _ bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacked = append(a.nacked, tag)
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func TestServe_ContextCancelDrainsInFlight(t *testing.T) {
+	ack := &fakeAcknowledger{}
+	delivery := make(chan amqp.Delivery, 2)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := func(d amqp.Delivery) error {
+		close(started)
+		<-release
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, delivery, handler)
+	}()
+
+	delivery <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1}
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("serve returned before in-flight handler finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("serve did not return after context cancellation")
+	}
+
+	ack.mu.Lock()
+	defer ack.mu.Unlock()
+	assert.Equal(t, []uint64{1}, ack.acked, "in-flight message is acknowledged before return")
+}
+
+func TestServe_DeliveryChannelClosed(t *testing.T) {
+	delivery := make(chan amqp.Delivery)
+	close(delivery)
+
+	err := serve(context.Background(), delivery, func(amqp.Delivery) error { return nil })
+	assert.ErrorIs(t, err, ErrConsumerClosed)
+}
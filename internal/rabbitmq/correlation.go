@@ -0,0 +1,33 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// correlationIDKey — ключ идентификатора корреляции в контексте.
+type correlationIDKey struct{}
+
+// WithCorrelationID возвращает контекст с идентификатором корреляции id: PublishMessage
+// передает его в свойстве correlation_id сообщения, а ConsumerRouted восстанавливает
+// из этого свойства в контексте обработчика. Пустой id не сохраняется.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID возвращает идентификатор корреляции из ctx или пустую строку.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// deliveryContext возвращает контекст обработки доставки d с ее идентификатором корреляции.
+// Контекст не отменяется вместе с ctx потребителя: принятое сообщение дорабатывается
+// и после остановки потребителя, так же как serve дожидается запущенных обработчиков.
+func deliveryContext(ctx context.Context, d amqp.Delivery) context.Context {
+	return WithCorrelationID(context.WithoutCancel(ctx), d.CorrelationId)
+}
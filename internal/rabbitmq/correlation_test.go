@@ -0,0 +1,35 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	withID := deliveryContext(ctx, amqp.Delivery{CorrelationId: "run-1"})
+	withoutID := deliveryContext(ctx, amqp.Delivery{})
+	cancel()
+
+	assert.Equal(t, "run-1", CorrelationID(withID))
+	assert.Empty(t, CorrelationID(withoutID))
+	assert.NoError(t, withID.Err(), "handler context outlives consumer shutdown")
+}
+
+func TestRouter_PassesContextToHandler(t *testing.T) {
+	r := NewRouter()
+	var got string
+	r.Handle(RoutingKeySubscriptionExpiring, func(ctx context.Context, _ []byte) error {
+		got = CorrelationID(ctx)
+		return nil
+	})
+
+	err := r.Dispatch(WithCorrelationID(context.Background(), "run-1"), RoutingKeySubscriptionExpiring, []byte(`{}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "run-1", got)
+}
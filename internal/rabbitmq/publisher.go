@@ -1,14 +1,16 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/streadway/amqp"
 )
 
-// PublishMessage публикует сообщение в RabbitMQ.
-func PublishMessage(ch *amqp.Channel, exchange string, routingkey string, message any) error {
+// PublishMessage публикует сообщение в RabbitMQ. Идентификатор корреляции из ctx
+// передается в свойстве correlation_id сообщения.
+func PublishMessage(ctx context.Context, ch *amqp.Channel, exchange string, routingkey string, message any) error {
 	const op = "rabbitmq.PublishMessage"
 	body, err := json.Marshal(message)
 	if err != nil {
@@ -21,9 +23,10 @@ func PublishMessage(ch *amqp.Channel, exchange string, routingkey string, messag
 		false,
 		false,
 		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,
+			ContentType:   "application/json",
+			Body:          body,
+			DeliveryMode:  amqp.Persistent,
+			CorrelationId: CorrelationID(ctx),
 		},
 	)
 	if err != nil {
@@ -3,6 +3,7 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"os"
 	"testing"
 	"time"
@@ -40,7 +41,7 @@ func TestPublishMessage(t *testing.T) {
 	}
 	defer cleanup()
 
-	conn, err := Connect(amqpURI, 3, time.Second)
+	conn, err := Connect(ctx, amqpURI, testRetryPolicy, slog.New(slog.DiscardHandler))
 	require.NoError(t, err)
 	defer func() {
 		if err := conn.Close(); err != nil {
@@ -69,7 +70,7 @@ func TestPublishMessage(t *testing.T) {
 		msg := TestMsg{ID: 1, Name: "Hello"}
 
 		// Публикуем сообщение
-		err = PublishMessage(ch, "", queueName, msg)
+		err = PublishMessage(WithCorrelationID(ctx, "run-1"), ch, "", queueName, msg)
 		require.NoError(t, err)
 
 		// Читаем из очереди
@@ -83,6 +84,7 @@ func TestPublishMessage(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, msg, got)
 			assert.Equal(t, "application/json", d.ContentType)
+			assert.Equal(t, "run-1", d.CorrelationId)
 		case <-time.After(5 * time.Second):
 			t.Fatal("timeout waiting for message")
 		}
@@ -96,7 +98,7 @@ func TestPublishMessage(t *testing.T) {
 			Ch: make(chan int),
 		}
 
-		err := PublishMessage(ch, "", queueName, badMsg)
+		err := PublishMessage(ctx, ch, "", queueName, badMsg)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "rabbitmq.PublishMessage")
 	})
@@ -130,7 +132,7 @@ func TestPublishMessage_ToExchangeWithRoutingKey(t *testing.T) {
 	}
 	defer cleanup()
 
-	conn, err := Connect(amqpURI, 3, time.Second)
+	conn, err := Connect(ctx, amqpURI, testRetryPolicy, slog.New(slog.DiscardHandler))
 	require.NoError(t, err)
 	defer func() {
 		if err := conn.Close(); err != nil {
@@ -163,7 +165,7 @@ func TestPublishMessage_ToExchangeWithRoutingKey(t *testing.T) {
 	msg := map[string]any{"ok": true}
 
 	// публикуем сообщение в обменник
-	err = PublishMessage(ch, exchangeName, routingKey, msg)
+	err = PublishMessage(ctx, ch, exchangeName, routingKey, msg)
 	require.NoError(t, err)
 
 	deliveries, err := ch.Consume(queueName, "test-consumer2", true, false, false, false, nil)
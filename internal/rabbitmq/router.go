@@ -0,0 +1,71 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoHandler возвращается, если для типа сообщения не зарегистрирован обработчик.
+var ErrNoHandler = errors.New("no handler for message type")
+
+// ErrPermanent помечает ошибку обработки, которая не исчезнет при повторе:
+// такое сообщение не возвращается в очередь.
+var ErrPermanent = errors.New("permanent message processing failure")
+
+// HandlerFunc обрабатывает тело сообщения. ctx содержит идентификатор корреляции сообщения.
+type HandlerFunc func(ctx context.Context, body []byte) error
+
+// Router направляет сообщения обработчикам по ключу маршрутизации
+// или по типу, указанному в теле сообщения.
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// NewRouter создает пустой маршрутизатор сообщений.
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle регистрирует обработчик для ключа маршрутизации.
+func (r *Router) Handle(key string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[key] = handler
+}
+
+// Dispatch вызывает обработчик для routingKey. Если ключ пуст или неизвестен,
+// тип сообщения берется из поля "type" тела, а при его отсутствии — из "event"
+// (формат уведомлений платежного провайдера).
+func (r *Router) Dispatch(ctx context.Context, routingKey string, body []byte) error {
+	const op = "rabbitmq.Router.Dispatch"
+
+	r.mu.RLock()
+	handler, ok := r.handlers[routingKey]
+	r.mu.RUnlock()
+	if ok {
+		return handler(ctx, body)
+	}
+
+	var envelope struct {
+		Type  string `json:"type"`
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Type == "" {
+		envelope.Type = envelope.Event
+	}
+	if envelope.Type != "" {
+		r.mu.RLock()
+		handler, ok = r.handlers[envelope.Type]
+		r.mu.RUnlock()
+		if ok {
+			return handler(ctx, body)
+		}
+		return fmt.Errorf("%s: %w: %s", op, ErrNoHandler, envelope.Type)
+	}
+
+	return fmt.Errorf("%s: %w: %q", op, ErrNoHandler, routingKey)
+}
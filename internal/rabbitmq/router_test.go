@@ -0,0 +1,96 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(calls map[string]int) *Router {
+	r := NewRouter()
+	for _, key := range []string{
+		RoutingKeySubscriptionExpiring,
+		RoutingKeyTrialExpiring,
+		RoutingKeyTrialExpired,
+		RoutingKeyPaymentSucceeded,
+		RoutingKeyPaymentFailed,
+	} {
+		r.Handle(key, func(context.Context, []byte) error {
+			calls[key]++
+			return nil
+		})
+	}
+	return r
+}
+
+func TestRouter_DispatchByRoutingKey(t *testing.T) {
+	keys := []string{
+		RoutingKeySubscriptionExpiring,
+		RoutingKeyTrialExpiring,
+		RoutingKeyTrialExpired,
+		RoutingKeyPaymentSucceeded,
+		RoutingKeyPaymentFailed,
+	}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			calls := map[string]int{}
+			r := newTestRouter(calls)
+
+			require.NoError(t, r.Dispatch(context.Background(), key, []byte(`{}`)))
+			assert.Equal(t, map[string]int{key: 1}, calls)
+		})
+	}
+}
+
+func TestRouter_DispatchByTypeField(t *testing.T) {
+	calls := map[string]int{}
+	r := newTestRouter(calls)
+
+	err := r.Dispatch(context.Background(), "", []byte(`{"type":"payment.failed","object":{}}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{RoutingKeyPaymentFailed: 1}, calls)
+}
+
+func TestRouter_DispatchByEventField(t *testing.T) {
+	calls := map[string]int{}
+	r := newTestRouter(calls)
+
+	err := r.Dispatch(context.Background(), "", []byte(`{"event":"payment.succeeded","object":{"id":"p1"}}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{RoutingKeyPaymentSucceeded: 1}, calls)
+}
+
+func TestRouter_RoutingKeyTakesPrecedence(t *testing.T) {
+	calls := map[string]int{}
+	r := newTestRouter(calls)
+
+	err := r.Dispatch(context.Background(), RoutingKeyTrialExpiring, []byte(`{"type":"payment.succeeded"}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{RoutingKeyTrialExpiring: 1}, calls)
+}
+
+func TestRouter_UnknownType(t *testing.T) {
+	r := newTestRouter(map[string]int{})
+
+	err := r.Dispatch(context.Background(), "unknown.key", []byte(`not json`))
+	assert.True(t, errors.Is(err, ErrNoHandler))
+
+	err = r.Dispatch(context.Background(), "", []byte(`{"type":"unknown.type"}`))
+	assert.True(t, errors.Is(err, ErrNoHandler))
+}
+
+func TestRouter_HandlerErrorPropagates(t *testing.T) {
+	r := NewRouter()
+	handlerErr := errors.New("smtp down")
+	r.Handle(RoutingKeyPaymentSucceeded, func(context.Context, []byte) error { return handlerErr })
+
+	err := r.Dispatch(context.Background(), RoutingKeyPaymentSucceeded, []byte(`{}`))
+
+	assert.ErrorIs(t, err, handlerErr)
+}
@@ -1,15 +1,48 @@
 package rabbitmq
 
+// Ключи маршрутизации уведомлений в exchange "notifications".
+const (
+	RoutingKeySubscriptionExpiring = "subscription.expiring.tomorrow"
+	RoutingKeyTrialExpiring        = "subscription.trial.expiring"
+	RoutingKeyTrialExpired         = "subscription.trial.expired"
+	RoutingKeyPaymentSucceeded     = "payment.succeeded"
+	RoutingKeyPaymentFailed        = "payment.failed"
+	// RoutingKeyPaymentWebhook — принятые webhook-уведомления платежного провайдера, ожидающие обработки.
+	RoutingKeyPaymentWebhook = "payment.webhook.received"
+	// RoutingKeyReminderDigest — напоминания пользователю обо всех подписках за проход планировщика одним письмом.
+	RoutingKeyReminderDigest = "subscription.expiring.digest"
+	// RoutingKeySubscriptionExpired — оплаченный срок подписки на сервис закончился, и пользователь переведен в статус expired.
+	RoutingKeySubscriptionExpired = "subscription.expired"
+)
+
+// NotificationsExchange — exchange, через который публикуются уведомления.
+const NotificationsExchange = "notifications"
+
+// NotificationsQueue — общая очередь уведомлений, привязанная ко всем ключам маршрутизации.
+const NotificationsQueue = "notifications_queue"
+
+// PaymentWebhooksQueue — очередь webhook-уведомлений платежного провайдера, которую
+// обрабатывает основной API после того, как уведомление принято и сохранено.
+const PaymentWebhooksQueue = "payment_webhooks_queue"
+
 // QueueConfig содержит конфигурацию очереди RabbitMQ.
 type QueueConfig struct {
 	QueueName  string
 	RoutingKey string
 }
 
-// GetNotificationQueues возвращает конфигурацию очередей для уведомлений.
+// GetNotificationQueues возвращает конфигурацию очередей для уведомлений. Очередь webhook-уведомлений
+// входит в список, чтобы ее объявлял и планировщик: иначе релей outbox мог бы опубликовать
+// уведомление раньше, чем основной API создаст очередь, и оно бы потерялось.
 func GetNotificationQueues() []QueueConfig {
 	return []QueueConfig{
-		{QueueName: "subscription_expiring_queue", RoutingKey: "subscription.expiring.tomorrow"},
-		{QueueName: "trial_expiring_queue", RoutingKey: "subscription.trial.expiring"},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeySubscriptionExpiring},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeyReminderDigest},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeyTrialExpiring},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeyTrialExpired},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeySubscriptionExpired},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeyPaymentSucceeded},
+		{QueueName: NotificationsQueue, RoutingKey: RoutingKeyPaymentFailed},
+		{QueueName: PaymentWebhooksQueue, RoutingKey: RoutingKeyPaymentWebhook},
 	}
 }
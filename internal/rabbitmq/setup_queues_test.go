@@ -14,13 +14,23 @@ func TestGetNotificationQueues(t *testing.T) {
 
 	// Проверка первой очереди
 	first := queues[0]
-	assert.Equal(t, "subscription_expiring_queue", first.QueueName)
+	assert.Equal(t, NotificationsQueue, first.QueueName)
 	assert.Equal(t, "subscription.expiring.tomorrow", first.RoutingKey)
 
-	// Проверка уникальности QueueName
+	// Все ключи привязаны к одной очереди и не повторяются
 	seen := map[string]bool{}
 	for _, q := range queues {
-		assert.Falsef(t, seen[q.QueueName], "duplicate queue name: %s", q.QueueName)
-		seen[q.QueueName] = true
+		assert.Equal(t, NotificationsQueue, q.QueueName)
+		assert.Falsef(t, seen[q.RoutingKey], "duplicate routing key: %s", q.RoutingKey)
+		seen[q.RoutingKey] = true
+	}
+
+	for _, key := range []string{
+		RoutingKeySubscriptionExpiring,
+		RoutingKeyTrialExpiring,
+		RoutingKeyPaymentSucceeded,
+		RoutingKeyPaymentFailed,
+	} {
+		assert.Truef(t, seen[key], "routing key %s is not bound", key)
 	}
 }
@@ -0,0 +1,210 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrTopologyMissing возвращается, если ожидаемые exchange, очереди или привязки не найдены.
+var ErrTopologyMissing = errors.New("rabbitmq topology is incomplete")
+
+// Topology проверяет наличие элементов топологии RabbitMQ, не создавая их.
+type Topology interface {
+	ExchangeExists(name string) (bool, error)
+	QueueExists(name string) (bool, error)
+	BindingExists(exchange, queue, routingKey string) (bool, error)
+}
+
+// VerifyTopology проверяет, что exchange, очереди и привязки из queues существуют.
+// Возвращает ErrTopologyMissing со списком всех отсутствующих элементов.
+func VerifyTopology(t Topology, exchange string, queues []QueueConfig) error {
+	const op = "rabbitmq.VerifyTopology"
+
+	var missing []string
+	ok, err := t.ExchangeExists(exchange)
+	if err != nil {
+		return fmt.Errorf("%s: exchange %s: %w", op, exchange, err)
+	}
+	if !ok {
+		missing = append(missing, "exchange "+exchange)
+	}
+
+	checkedQueues := make(map[string]bool)
+	for _, q := range queues {
+		queueOK, seen := checkedQueues[q.QueueName]
+		if !seen {
+			queueOK, err = t.QueueExists(q.QueueName)
+			if err != nil {
+				return fmt.Errorf("%s: queue %s: %w", op, q.QueueName, err)
+			}
+			checkedQueues[q.QueueName] = queueOK
+			if !queueOK {
+				missing = append(missing, "queue "+q.QueueName)
+			}
+		}
+		// Привязки несуществующей очереди или exchange заведомо отсутствуют.
+		if !queueOK || !ok {
+			missing = append(missing, fmt.Sprintf("binding %s -> %s (%s)", exchange, q.QueueName, q.RoutingKey))
+			continue
+		}
+		bound, err := t.BindingExists(exchange, q.QueueName, q.RoutingKey)
+		if err != nil {
+			return fmt.Errorf("%s: binding %s -> %s (%s): %w", op, exchange, q.QueueName, q.RoutingKey, err)
+		}
+		if !bound {
+			missing = append(missing, fmt.Sprintf("binding %s -> %s (%s)", exchange, q.QueueName, q.RoutingKey))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: %w: missing %s", op, ErrTopologyMissing, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// AMQPTopology проверяет топологию через пассивное объявление exchange и очередей.
+// AMQP не позволяет проверить привязку без ее создания, поэтому привязки
+// проверяются через HTTP API плагина management, если задан его адрес.
+type AMQPTopology struct {
+	conn          *amqp.Connection
+	vhost         string
+	managementURL string
+	client        *http.Client
+}
+
+// NewAMQPTopology создает AMQPTopology. Если managementURL пустой, привязки
+// считаются существующими и не проверяются.
+func NewAMQPTopology(conn *amqp.Connection, vhost, managementURL string) *AMQPTopology {
+	if vhost == "" {
+		vhost = "/"
+	}
+	return &AMQPTopology{
+		conn:          conn,
+		vhost:         vhost,
+		managementURL: strings.TrimRight(managementURL, "/"),
+		client:        &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ExchangeExists проверяет наличие exchange пассивным объявлением.
+func (a *AMQPTopology) ExchangeExists(name string) (bool, error) {
+	return a.passive(func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclarePassive(name, "direct", true, false, false, false, nil)
+	})
+}
+
+// QueueExists проверяет наличие очереди пассивным объявлением.
+func (a *AMQPTopology) QueueExists(name string) (bool, error) {
+	return a.passive(func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+		return err
+	})
+}
+
+// QueueMessages возвращает количество готовых к доставке сообщений в очереди name.
+// Очередь проверяется пассивным объявлением в отдельном канале, поэтому отсутствие
+// очереди не закрывает каналы, через которые публикуются уведомления.
+func (a *AMQPTopology) QueueMessages(name string) (int, error) {
+	var messages int
+	ok, err := a.passive(func(ch *amqp.Channel) error {
+		q, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+		messages = q.Messages
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("queue %s: %w", name, ErrTopologyMissing)
+	}
+	return messages, nil
+}
+
+// passive выполняет пассивное объявление в отдельном канале: при отсутствии
+// объекта брокер закрывает канал, и остальные проверки не должны от этого зависеть.
+func (a *AMQPTopology) passive(declare func(ch *amqp.Channel) error) (bool, error) {
+	ch, err := a.conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	err = declare(ch)
+	var amqpErr *amqp.Error
+	if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		_ = ch.Close()
+		return false, err
+	}
+	_ = ch.Close()
+	return true, nil
+}
+
+// BindingExists проверяет привязку очереди к exchange через HTTP API management.
+func (a *AMQPTopology) BindingExists(exchange, queue, routingKey string) (bool, error) {
+	if a.managementURL == "" {
+		return true, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/api/bindings/%s/e/%s/q/%s", a.managementURL,
+		url.PathEscape(a.vhost), url.PathEscape(exchange), url.PathEscape(queue))
+	resp, err := a.client.Get(endpoint)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("management api returned %s", resp.Status)
+	}
+
+	var bindings []struct {
+		RoutingKey string `json:"routing_key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&bindings); err != nil {
+		return false, err
+	}
+	for _, b := range bindings {
+		if b.RoutingKey == routingKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NotificationChannel открывает канал для уведомлений. Если declare, топология из
+// GetNotificationQueues объявляется через SetupChannel. Иначе топология должна быть
+// создана заранее: она проверяется VerifyNotificationTopology до открытия канала,
+// и при отсутствии элементов возвращается ErrTopologyMissing.
+func NotificationChannel(conn *amqp.Connection, amqpURL, managementURL string, declare bool) (*amqp.Channel, error) {
+	if declare {
+		return SetupChannel(conn, GetNotificationQueues())
+	}
+	if err := VerifyNotificationTopology(conn, amqpURL, managementURL); err != nil {
+		return nil, err
+	}
+	return OpenChannel(conn)
+}
+
+// VerifyNotificationTopology проверяет топологию уведомлений из GetNotificationQueues.
+// vhost берется из amqpURL; привязки проверяются, только если задан managementURL.
+func VerifyNotificationTopology(conn *amqp.Connection, amqpURL, managementURL string) error {
+	const op = "rabbitmq.VerifyNotificationTopology"
+
+	uri, err := amqp.ParseURI(amqpURL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return VerifyTopology(NewAMQPTopology(conn, uri.Vhost, managementURL), NotificationsExchange, GetNotificationQueues())
+}
@@ -0,0 +1,93 @@
+package rabbitmq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTopology описывает существующие на брокере элементы топологии.
+type fakeTopology struct {
+	exchanges map[string]bool
+	queues    map[string]bool
+	bindings  map[string]bool // ключ: exchange|queue|routingKey
+}
+
+func (f *fakeTopology) ExchangeExists(name string) (bool, error) { return f.exchanges[name], nil }
+func (f *fakeTopology) QueueExists(name string) (bool, error)    { return f.queues[name], nil }
+func (f *fakeTopology) BindingExists(exchange, queue, routingKey string) (bool, error) {
+	return f.bindings[exchange+"|"+queue+"|"+routingKey], nil
+}
+
+func completeTopology() *fakeTopology {
+	f := &fakeTopology{
+		exchanges: map[string]bool{NotificationsExchange: true},
+		queues:    map[string]bool{NotificationsQueue: true},
+		bindings:  map[string]bool{},
+	}
+	for _, q := range GetNotificationQueues() {
+		f.bindings[NotificationsExchange+"|"+q.QueueName+"|"+q.RoutingKey] = true
+	}
+	return f
+}
+
+func TestVerifyTopology(t *testing.T) {
+	t.Run("complete topology", func(t *testing.T) {
+		require.NoError(t, VerifyTopology(completeTopology(), NotificationsExchange, GetNotificationQueues()))
+	})
+
+	t.Run("missing binding", func(t *testing.T) {
+		f := completeTopology()
+		delete(f.bindings, NotificationsExchange+"|"+NotificationsQueue+"|"+RoutingKeyPaymentFailed)
+
+		err := VerifyTopology(f, NotificationsExchange, GetNotificationQueues())
+		require.ErrorIs(t, err, ErrTopologyMissing)
+		assert.Contains(t, err.Error(), "binding notifications -> notifications_queue (payment.failed)")
+		assert.NotContains(t, err.Error(), RoutingKeyPaymentSucceeded)
+	})
+
+	t.Run("missing queue and exchange", func(t *testing.T) {
+		f := &fakeTopology{}
+
+		err := VerifyTopology(f, NotificationsExchange, GetNotificationQueues())
+		require.ErrorIs(t, err, ErrTopologyMissing)
+		assert.Contains(t, err.Error(), "exchange notifications")
+		assert.Contains(t, err.Error(), "queue notifications_queue")
+		for _, q := range GetNotificationQueues() {
+			assert.Contains(t, err.Error(), q.RoutingKey)
+		}
+	})
+}
+
+func TestAMQPTopology_BindingExists(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/bindings/%2F/e/notifications/q/notifications_queue" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"routing_key":"payment.succeeded"}]`))
+	}))
+	defer srv.Close()
+
+	topology := NewAMQPTopology(nil, "/", srv.URL)
+
+	ok, err := topology.BindingExists(NotificationsExchange, NotificationsQueue, RoutingKeyPaymentSucceeded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = topology.BindingExists(NotificationsExchange, NotificationsQueue, RoutingKeyPaymentFailed)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = topology.BindingExists(NotificationsExchange, "other_queue", RoutingKeyPaymentFailed)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Без адреса management привязки не проверяются
+	ok, err = NewAMQPTopology(nil, "/", "").BindingExists(NotificationsExchange, "other_queue", RoutingKeyPaymentFailed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,180 @@
+// Package services содержит бизнес-логику управления аккаунтом пользователя:
+// удаление и выгрузку персональных данных и настройки напоминаний.
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// AccountRepository определяет методы хранилища для удаления аккаунтов.
+type AccountRepository interface {
+	CreateDeletionRequest(ctx context.Context, userUID, mode string, scheduledFor time.Time) (*models.DeletionRequest, error)
+	RevokePaymentTokens(ctx context.Context, userUID string) (int, error)
+	AnonymizeUser(ctx context.Context, userUID string) error
+	FindDueAccountDeletions(ctx context.Context) ([]string, error)
+	GetUser(ctx context.Context, userUID string) (*models.User, error)
+	ListEntrysByUserUID(ctx context.Context, userUID string) ([]*models.Entry, error)
+	ListPayments(ctx context.Context, userUID string) ([]*models.Payment, error)
+	ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error)
+	ListSentNotifications(ctx context.Context, userUID string) ([]models.NotificationRecord, error)
+	GetReminderSettings(ctx context.Context, userUID string) (*int, bool, error)
+	SetReminderSettings(ctx context.Context, userUID string, days *int, digest bool) error
+}
+
+// AccountService реализует удаление аккаунта: немедленное или отложенное
+// на период, заданный в конфигурации.
+type AccountService struct {
+	repo         AccountRepository
+	immediate    bool
+	gracePeriod  time.Duration
+	reminderDays []int // сроки напоминаний scheduler.reminder_days_before
+	log          *slog.Logger
+}
+
+// NewAccountService создает новый экземпляр AccountService. reminderDays — сроки напоминаний
+// для пользователей без собственной настройки.
+func NewAccountService(repo AccountRepository, cfg config.AccountDeletion, reminderDays []int, log *slog.Logger) *AccountService {
+	return &AccountService{
+		repo:         repo,
+		immediate:    cfg.DeletionImmediate,
+		gracePeriod:  cfg.DeletionGracePeriod,
+		reminderDays: reminderDays,
+		log:          log,
+	}
+}
+
+// DeleteAccount регистрирует запрос на удаление аккаунта и сразу отзывает платежные токены.
+// В немедленном режиме персональные данные анонимизируются в том же вызове,
+// в отложенном — после истечения grace-периода.
+func (s *AccountService) DeleteAccount(ctx context.Context, userUID string) (*models.DeletionRequest, error) {
+	mode := models.DeletionModeScheduled
+	scheduledFor := time.Now().Add(s.gracePeriod)
+	if s.immediate {
+		mode = models.DeletionModeImmediate
+		scheduledFor = time.Now()
+	}
+
+	req, err := s.repo.CreateDeletionRequest(ctx, userUID, mode, scheduledFor)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("account deletion requested", slog.String("user_uid", userUID), slog.String("mode", mode))
+
+	revoked, err := s.repo.RevokePaymentTokens(ctx, userUID)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("payment tokens revoked", slog.String("user_uid", userUID), slog.Int("count", revoked))
+
+	if s.immediate {
+		if err := s.repo.AnonymizeUser(ctx, userUID); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		req.CompletedAt = &now
+		s.log.Info("account anonymized", slog.String("user_uid", userUID))
+	}
+	return req, nil
+}
+
+// ProcessDueDeletions анонимизирует аккаунты, срок отложенного удаления которых наступил.
+// Возвращает количество обработанных аккаунтов.
+func (s *AccountService) ProcessDueDeletions(ctx context.Context) (int, error) {
+	uids, err := s.repo.FindDueAccountDeletions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find due deletions: %w", err)
+	}
+
+	processed := 0
+	for _, uid := range uids {
+		if err := s.repo.AnonymizeUser(ctx, uid); err != nil {
+			s.log.Error("failed to anonymize user", slog.String("user_uid", uid), sl.Err(err))
+			continue
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// ExportData собирает все данные пользователя для выгрузки: профиль, подписки,
+// платежи, маскированные платежные токены и отправленные уведомления.
+func (s *AccountService) ExportData(ctx context.Context, userUID string) (*models.DataExport, error) {
+	user, err := s.repo.GetUser(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	subscriptions, err := s.repo.ListEntrysByUserUID(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	payments, err := s.repo.ListPayments(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	tokens, err := s.repo.ListPaymentTokens(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment tokens: %w", err)
+	}
+	notifications, err := s.repo.ListSentNotifications(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	export := &models.DataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: models.ExportProfile{
+			UUID:               user.UUID,
+			Email:              user.Email,
+			Username:           user.Username,
+			Role:               user.Role,
+			TrialEndDate:       user.TrialEndDate,
+			SubscriptionExpire: user.SubscriptionExpire,
+			SubscriptionStatus: user.SubscriptionStatus,
+		},
+		Subscriptions: subscriptions,
+		Payments:      payments,
+		PaymentTokens: models.MaskPaymentTokens(tokens),
+		Notifications: notifications,
+	}
+	if export.Subscriptions == nil {
+		export.Subscriptions = []*models.Entry{}
+	}
+	if export.Payments == nil {
+		export.Payments = []*models.Payment{}
+	}
+	if export.Notifications == nil {
+		export.Notifications = []models.NotificationRecord{}
+	}
+	return export, nil
+}
+
+// GetReminderSettings возвращает срок напоминаний пользователя вместе со сроками по умолчанию
+// и признак получения напоминаний одним письмом.
+func (s *AccountService) GetReminderSettings(ctx context.Context, userUID string) (*models.ReminderSettings, error) {
+	days, digest, err := s.repo.GetReminderSettings(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder settings: %w", err)
+	}
+	return &models.ReminderSettings{ReminderDaysBefore: days, DefaultDaysBefore: s.reminderDays, Digest: digest}, nil
+}
+
+// UpdateReminderSettings сохраняет срок напоминаний пользователя и признак дайджеста. nil возвращает
+// сроки по умолчанию; срок вне диапазона от 1 до models.MaxReminderDaysBefore дней возвращает
+// models.ErrInvalidReminderDays.
+func (s *AccountService) UpdateReminderSettings(ctx context.Context, userUID string, days *int, digest bool) (*models.ReminderSettings, error) {
+	if err := models.ValidateReminderDays(days); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetReminderSettings(ctx, userUID, days, digest); err != nil {
+		return nil, fmt.Errorf("failed to update reminder settings: %w", err)
+	}
+	s.log.Info("reminder settings updated", slog.String("user_uid", userUID), slog.Bool("digest", digest))
+	return &models.ReminderSettings{ReminderDaysBefore: days, DefaultDaysBefore: s.reminderDays, Digest: digest}, nil
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type RepoMock struct{ mock.Mock }
+
+// Убеждаемся, что RepoMock реализует интерфейс AccountRepository
+var _ AccountRepository = (*RepoMock)(nil)
+
+func (m *RepoMock) CreateDeletionRequest(ctx context.Context, userUID, mode string, scheduledFor time.Time) (*models.DeletionRequest, error) {
+	args := m.Called(ctx, userUID, mode, scheduledFor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeletionRequest), args.Error(1)
+}
+
+func (m *RepoMock) RevokePaymentTokens(ctx context.Context, userUID string) (int, error) {
+	args := m.Called(ctx, userUID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RepoMock) AnonymizeUser(ctx context.Context, userUID string) error {
+	return m.Called(ctx, userUID).Error(0)
+}
+
+func (m *RepoMock) FindDueAccountDeletions(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *RepoMock) GetUser(ctx context.Context, userUID string) (*models.User, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *RepoMock) ListEntrysByUserUID(ctx context.Context, userUID string) ([]*models.Entry, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Entry), args.Error(1)
+}
+
+func (m *RepoMock) ListPayments(ctx context.Context, userUID string) ([]*models.Payment, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Payment), args.Error(1)
+}
+
+func (m *RepoMock) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PaymentToken), args.Error(1)
+}
+
+func (m *RepoMock) ListSentNotifications(ctx context.Context, userUID string) ([]models.NotificationRecord, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.NotificationRecord), args.Error(1)
+}
+
+func (m *RepoMock) GetReminderSettings(ctx context.Context, userUID string) (*int, bool, error) {
+	args := m.Called(ctx, userUID)
+	days, _ := args.Get(0).(*int)
+	return days, args.Bool(1), args.Error(2)
+}
+
+func (m *RepoMock) SetReminderSettings(ctx context.Context, userUID string, days *int, digest bool) error {
+	return m.Called(ctx, userUID, days, digest).Error(0)
+}
+
+func newNoopLogger() *slog.Logger {
+	h := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})
+	return slog.New(h)
+}
+
+func TestAccountService_DeleteAccount_Immediate(t *testing.T) {
+	repo := new(RepoMock)
+	svc := NewAccountService(repo, config.AccountDeletion{DeletionImmediate: true}, nil, newNoopLogger())
+
+	repo.On("CreateDeletionRequest", mock.Anything, "user1", models.DeletionModeImmediate, mock.AnythingOfType("time.Time")).
+		Return(&models.DeletionRequest{ID: 1, UserUID: "user1", Mode: models.DeletionModeImmediate}, nil).Once()
+	repo.On("RevokePaymentTokens", mock.Anything, "user1").Return(2, nil).Once()
+	repo.On("AnonymizeUser", mock.Anything, "user1").Return(nil).Once()
+
+	req, err := svc.DeleteAccount(context.Background(), "user1")
+
+	require.NoError(t, err)
+	assert.Equal(t, models.DeletionModeImmediate, req.Mode)
+	assert.NotNil(t, req.CompletedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestAccountService_DeleteAccount_Scheduled(t *testing.T) {
+	repo := new(RepoMock)
+	grace := 72 * time.Hour
+	svc := NewAccountService(repo, config.AccountDeletion{DeletionGracePeriod: grace}, nil, newNoopLogger())
+
+	repo.On("CreateDeletionRequest", mock.Anything, "user1", models.DeletionModeScheduled,
+		mock.MatchedBy(func(at time.Time) bool {
+			return at.After(time.Now().Add(grace - time.Minute))
+		})).
+		Return(&models.DeletionRequest{ID: 1, UserUID: "user1", Mode: models.DeletionModeScheduled}, nil).Once()
+	repo.On("RevokePaymentTokens", mock.Anything, "user1").Return(1, nil).Once()
+
+	req, err := svc.DeleteAccount(context.Background(), "user1")
+
+	require.NoError(t, err)
+	assert.Nil(t, req.CompletedAt)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "AnonymizeUser", mock.Anything, mock.Anything)
+}
+
+func TestAccountService_DeleteAccount_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(r *RepoMock)
+	}{
+		{
+			name: "request not recorded",
+			setupMocks: func(r *RepoMock) {
+				r.On("CreateDeletionRequest", mock.Anything, "user1", mock.Anything, mock.Anything).
+					Return(nil, errors.New("db error")).Once()
+			},
+		},
+		{
+			name: "tokens not revoked",
+			setupMocks: func(r *RepoMock) {
+				r.On("CreateDeletionRequest", mock.Anything, "user1", mock.Anything, mock.Anything).
+					Return(&models.DeletionRequest{}, nil).Once()
+				r.On("RevokePaymentTokens", mock.Anything, "user1").Return(0, errors.New("db error")).Once()
+			},
+		},
+		{
+			name: "anonymization failed",
+			setupMocks: func(r *RepoMock) {
+				r.On("CreateDeletionRequest", mock.Anything, "user1", mock.Anything, mock.Anything).
+					Return(&models.DeletionRequest{}, nil).Once()
+				r.On("RevokePaymentTokens", mock.Anything, "user1").Return(0, nil).Once()
+				r.On("AnonymizeUser", mock.Anything, "user1").Return(errors.New("db error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			svc := NewAccountService(repo, config.AccountDeletion{DeletionImmediate: true}, nil, newNoopLogger())
+			tt.setupMocks(repo)
+
+			_, err := svc.DeleteAccount(context.Background(), "user1")
+
+			assert.Error(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountService_ProcessDueDeletions(t *testing.T) {
+	repo := new(RepoMock)
+	svc := NewAccountService(repo, config.AccountDeletion{}, nil, newNoopLogger())
+
+	repo.On("FindDueAccountDeletions", mock.Anything).Return([]string{"u1", "u2"}, nil).Once()
+	repo.On("AnonymizeUser", mock.Anything, "u1").Return(nil).Once()
+	repo.On("AnonymizeUser", mock.Anything, "u2").Return(errors.New("db error")).Once()
+
+	processed, err := svc.ProcessDueDeletions(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	repo.AssertExpectations(t)
+}
+
+func TestAccountService_ExportData(t *testing.T) {
+	repo := new(RepoMock)
+	svc := NewAccountService(repo, config.AccountDeletion{}, nil, newNoopLogger())
+
+	repo.On("GetUser", mock.Anything, "user1").Return(&models.User{
+		UUID: "user1", Email: "u@example.com", Username: "u", PasswordHash: "secret-hash",
+	}, nil).Once()
+	repo.On("ListEntrysByUserUID", mock.Anything, "user1").Return([]*models.Entry{{ID: 1, ServiceName: "Netflix"}}, nil).Once()
+	repo.On("ListPayments", mock.Anything, "user1").Return(nil, nil).Once()
+	repo.On("ListPaymentTokens", mock.Anything, "user1").Return([]*models.PaymentToken{
+		{ID: 3, Token: "card_1234567890"},
+	}, nil).Once()
+	sentAt := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	repo.On("ListSentNotifications", mock.Anything, "user1").Return([]models.NotificationRecord{
+		{Type: "subscription.expiring.tomorrow", SentAt: sentAt},
+	}, nil).Once()
+
+	export, err := svc.ExportData(context.Background(), "user1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "u@example.com", export.Profile.Email)
+	assert.Len(t, export.Subscriptions, 1)
+	assert.NotNil(t, export.Payments)
+	assert.Equal(t, []models.NotificationRecord{{Type: "subscription.expiring.tomorrow", SentAt: sentAt}}, export.Notifications)
+	require.Len(t, export.PaymentTokens, 1)
+	assert.Equal(t, "***********7890", export.PaymentTokens[0].Token)
+	repo.AssertExpectations(t)
+}
+
+func TestAccountService_ExportData_Error(t *testing.T) {
+	repo := new(RepoMock)
+	svc := NewAccountService(repo, config.AccountDeletion{}, nil, newNoopLogger())
+
+	repo.On("GetUser", mock.Anything, "user1").Return(&models.User{UUID: "user1"}, nil).Once()
+	repo.On("ListEntrysByUserUID", mock.Anything, "user1").Return(nil, errors.New("db error")).Once()
+
+	_, err := svc.ExportData(context.Background(), "user1")
+
+	assert.ErrorContains(t, err, "failed to list subscriptions")
+	repo.AssertExpectations(t)
+}
+
+func TestAccountService_ReminderSettings(t *testing.T) {
+	ctx := context.Background()
+	days := 7
+
+	t.Run("собственный срок пользователя", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("SetReminderSettings", ctx, "user-1", &days, false).Return(nil).Once()
+		repo.On("GetReminderSettings", ctx, "user-1").Return(&days, false, nil).Once()
+		svc := NewAccountService(repo, config.AccountDeletion{}, []int{3, 1}, newNoopLogger())
+
+		updated, err := svc.UpdateReminderSettings(ctx, "user-1", &days, false)
+		require.NoError(t, err)
+		assert.Equal(t, &models.ReminderSettings{ReminderDaysBefore: &days, DefaultDaysBefore: []int{3, 1}}, updated)
+
+		settings, err := svc.GetReminderSettings(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, updated, settings)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("сброс к срокам по умолчанию", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("SetReminderSettings", ctx, "user-1", (*int)(nil), false).Return(nil).Once()
+		svc := NewAccountService(repo, config.AccountDeletion{}, []int{1}, newNoopLogger())
+
+		settings, err := svc.UpdateReminderSettings(ctx, "user-1", nil, false)
+		require.NoError(t, err)
+		assert.Nil(t, settings.ReminderDaysBefore)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("напоминания одним письмом", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("SetReminderSettings", ctx, "user-1", (*int)(nil), true).Return(nil).Once()
+		repo.On("GetReminderSettings", ctx, "user-1").Return(nil, true, nil).Once()
+		svc := NewAccountService(repo, config.AccountDeletion{}, []int{1}, newNoopLogger())
+
+		updated, err := svc.UpdateReminderSettings(ctx, "user-1", nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, &models.ReminderSettings{DefaultDaysBefore: []int{1}, Digest: true}, updated)
+
+		settings, err := svc.GetReminderSettings(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, updated, settings)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("срок вне диапазона", func(t *testing.T) {
+		repo := new(RepoMock)
+		svc := NewAccountService(repo, config.AccountDeletion{}, []int{1}, newNoopLogger())
+		invalid := models.MaxReminderDaysBefore + 1
+
+		_, err := svc.UpdateReminderSettings(ctx, "user-1", &invalid, false)
+		assert.ErrorIs(t, err, models.ErrInvalidReminderDays)
+		repo.AssertNotCalled(t, "SetReminderSettings", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("GetReminderSettings", ctx, "user-1").Return(nil, false, errors.New("db error")).Once()
+		svc := NewAccountService(repo, config.AccountDeletion{}, []int{1}, newNoopLogger())
+
+		_, err := svc.GetReminderSettings(ctx, "user-1")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,262 @@
+// Package services содержит бизнес-логику административных операций.
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/month"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+const (
+	statsCacheKey = "admin:stats"
+	statsCacheTTL = time.Minute
+	// recomputeBatchSize — количество подписок, обрабатываемых за один запрос к БД.
+	recomputeBatchSize = 500
+	// shiftDateLayout — формат дат в фильтре сдвига дат следующего платежа.
+	shiftDateLayout = "2006-01-02"
+)
+
+// StatsRepository определяет агрегирующие запросы для статистики сервиса.
+type StatsRepository interface {
+	CountUsersByStatus(ctx context.Context) (map[string]int, error)
+	CountActiveSubscriptions(ctx context.Context) (int, error)
+	SumMonthlyRecurringRevenue(ctx context.Context) (float64, error)
+	CountTrialConversions(ctx context.Context) (int, error)
+}
+
+// MaintenanceRepository определяет запросы для обслуживающих задач над подписками.
+type MaintenanceRepository interface {
+	ListActiveEntrysAfterID(ctx context.Context, afterID, limit int) ([]*models.Entry, error)
+	UpdateNextPaymentDate(ctx context.Context, entry *models.Entry) (int, error)
+	ShiftNextPaymentDates(ctx context.Context, filter models.PaymentDateShiftFilter, offsetDays int) ([]int, error)
+}
+
+// UserSearchRepository определяет поиск пользователей для службы поддержки.
+type UserSearchRepository interface {
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error)
+}
+
+// IntegrityRepository определяет поиск аномалий данных.
+type IntegrityRepository interface {
+	CheckIntegrity(ctx context.Context, sampleSize int) (*models.IntegrityReport, error)
+}
+
+// Repository объединяет запросы хранилища, необходимые административному сервису.
+type Repository interface {
+	StatsRepository
+	MaintenanceRepository
+	UserSearchRepository
+	IntegrityRepository
+}
+
+// Cache описывает методы для кэширования данных.
+type Cache interface {
+	// Get пытается получить значение из кеша по ключу.
+	Get(key string, result any) (bool, error)
+	// Set сохраняет значение в кеш с временем жизни.
+	Set(key string, value any, expiration time.Duration) error
+	// Invalidate удаляет значение из кеша по ключу.
+	Invalidate(key string) error
+}
+
+// AdminService реализует бизнес-логику административных операций.
+type AdminService struct {
+	repo  Repository
+	cache Cache
+	clock clock.Clock
+	log   *slog.Logger
+}
+
+// NewAdminService создает новый экземпляр AdminService.
+// Если clk равен nil, используется системное время.
+func NewAdminService(repo Repository, cache Cache, clk clock.Clock, log *slog.Logger) *AdminService {
+	return &AdminService{
+		repo:  repo,
+		cache: cache,
+		clock: clock.OrReal(clk),
+		log:   log,
+	}
+}
+
+// GetStats возвращает агрегированную статистику сервиса.
+// Результат кешируется на короткое время, так как запросы к БД дорогие.
+func (s *AdminService) GetStats(ctx context.Context) (*models.AdminStats, error) {
+	var cached models.AdminStats
+	found, err := s.cache.Get(statsCacheKey, &cached)
+	if err != nil {
+		s.log.Warn("failed to get stats from cache", slog.String("key", statsCacheKey), sl.Err(err))
+	}
+	if found {
+		return &cached, nil
+	}
+
+	byStatus, err := s.repo.CountUsersByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	activeSubscriptions, err := s.repo.CountActiveSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mrr, err := s.repo.SumMonthlyRecurringRevenue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conversions, err := s.repo.CountTrialConversions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalUsers int
+	for _, count := range byStatus {
+		totalUsers += count
+	}
+
+	stats := &models.AdminStats{
+		TotalUsers:          totalUsers,
+		UsersByStatus:       byStatus,
+		ActiveSubscriptions: activeSubscriptions,
+		MRR:                 mrr,
+		TrialConversions:    conversions,
+	}
+
+	if err := s.cache.Set(statsCacheKey, stats, statsCacheTTL); err != nil {
+		s.log.Warn("failed to cache stats", slog.String("key", statsCacheKey), sl.Err(err))
+	}
+	return stats, nil
+}
+
+// SearchUsers ищет пользователей по части имени или электронной почты без учета регистра.
+func (s *AdminService) SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error) {
+	users, err := s.repo.SearchUsers(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return users, nil
+}
+
+// RecomputePaymentDates пересчитывает next_payment_date всех активных подписок
+// по month.NextPaymentDate и исправляет расхождения. Подписки обходятся пачками.
+func (s *AdminService) RecomputePaymentDates(ctx context.Context) (*models.RecomputeResult, error) {
+	result := &models.RecomputeResult{}
+	today := s.clock.Now()
+	afterID := 0
+
+	for {
+		entries, err := s.repo.ListActiveEntrysAfterID(ctx, afterID, recomputeBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		for _, entry := range entries {
+			afterID = entry.ID
+			result.Checked++
+
+			expected := month.NextPaymentDate(entry.StartDate, entry.CounterMonths, today)
+			if month.SameDay(entry.NextPaymentDate, expected) {
+				continue
+			}
+
+			entry.NextPaymentDate = expected
+			if _, err := s.repo.UpdateNextPaymentDate(ctx, entry); err != nil {
+				s.log.Error("failed to correct next payment date", slog.Int("id", entry.ID), sl.Err(err))
+				result.Failed++
+				continue
+			}
+			result.Corrected++
+
+			cacheKey := fmt.Sprintf("subscription:%d", entry.ID)
+			if err := s.cache.Invalidate(cacheKey); err != nil {
+				s.log.Warn("failed to invalidate cache", slog.String("key", cacheKey), sl.Err(err))
+			}
+		}
+
+		if len(entries) < recomputeBatchSize {
+			break
+		}
+	}
+
+	s.log.Info("payment dates recomputed",
+		slog.Int("checked", result.Checked),
+		slog.Int("corrected", result.Corrected),
+		slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// CheckIntegrity проверяет данные на аномалии: подписки без пользователя, платежи
+// со ссылкой на несуществующую подписку, отрицательные цены и next_payment_date раньше
+// start_date. Данные не исправляются; найденные аномалии записываются в лог предупреждением.
+func (s *AdminService) CheckIntegrity(ctx context.Context) (*models.IntegrityReport, error) {
+	report, err := s.repo.CheckIntegrity(ctx, models.IntegrityReportSampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check data integrity: %w", err)
+	}
+
+	logResult := s.log.Info
+	if report.Total > 0 {
+		logResult = s.log.Warn
+	}
+	logResult("data integrity checked",
+		slog.Int("total", report.Total),
+		slog.Int("orphan_subscriptions", report.OrphanSubscriptions.Count),
+		slog.Int("orphan_payments", report.OrphanPayments.Count),
+		slog.Int("negative_prices", report.NegativePrices.Count),
+		slog.Int("payment_before_start", report.PaymentBeforeStart.Count))
+	return report, nil
+}
+
+// ShiftPaymentDates сдвигает next_payment_date активных подписок, выбранных фильтром запроса,
+// на req.OffsetDays дней в одной транзакции и сбрасывает их кеш. Пустой фильтр отклоняется,
+// чтобы случайно не сдвинуть даты всех подписок сервиса.
+func (s *AdminService) ShiftPaymentDates(ctx context.Context, req models.PaymentDateShiftRequest) (*models.PaymentDateShiftResult, error) {
+	filter := req.Filter
+	if filter.ServiceName == "" && filter.UserUID == "" && filter.DueFrom == "" && filter.DueTo == "" {
+		return nil, fmt.Errorf("%w: filter must contain at least one condition", models.ErrInvalidShiftRequest)
+	}
+	from, err := parseShiftDate("due_from", filter.DueFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseShiftDate("due_to", filter.DueTo)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("%w: due_to must not be before due_from", models.ErrInvalidShiftRequest)
+	}
+
+	ids, err := s.repo.ShiftNextPaymentDates(ctx, filter, req.OffsetDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to shift payment dates: %w", err)
+	}
+	for _, id := range ids {
+		cacheKey := fmt.Sprintf("subscription:%d", id)
+		if err := s.cache.Invalidate(cacheKey); err != nil {
+			s.log.Warn("failed to invalidate cache", slog.String("key", cacheKey), sl.Err(err))
+		}
+	}
+
+	s.log.Info("payment dates shifted", slog.Int("offset_days", req.OffsetDays), slog.Int("shifted", len(ids)))
+	return &models.PaymentDateShiftResult{OffsetDays: req.OffsetDays, Shifted: len(ids)}, nil
+}
+
+// parseShiftDate разбирает границу фильтра сдвига дат; пустое значение дает нулевое время.
+func parseShiftDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(shiftDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be in format %s", models.ErrInvalidShiftRequest, name, shiftDateLayout)
+	}
+	return date, nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type RepoMock struct{ mock.Mock }
+
+// Убеждаемся, что RepoMock реализует интерфейс Repository
+var _ Repository = (*RepoMock)(nil)
+
+func (m *RepoMock) CountUsersByStatus(ctx context.Context) (map[string]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *RepoMock) CountActiveSubscriptions(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RepoMock) SumMonthlyRecurringRevenue(ctx context.Context) (float64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *RepoMock) CountTrialConversions(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RepoMock) ListActiveEntrysAfterID(ctx context.Context, afterID, limit int) ([]*models.Entry, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Entry), args.Error(1)
+}
+
+func (m *RepoMock) UpdateNextPaymentDate(ctx context.Context, entry *models.Entry) (int, error) {
+	args := m.Called(ctx, entry)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *RepoMock) ShiftNextPaymentDates(ctx context.Context, filter models.PaymentDateShiftFilter, offsetDays int) ([]int, error) {
+	args := m.Called(ctx, filter, offsetDays)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *RepoMock) SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UserSummary), args.Error(1)
+}
+
+func (m *RepoMock) CheckIntegrity(ctx context.Context, sampleSize int) (*models.IntegrityReport, error) {
+	args := m.Called(ctx, sampleSize)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IntegrityReport), args.Error(1)
+}
+
+type CacheMock struct{ mock.Mock }
+
+func (m *CacheMock) Get(key string, result any) (bool, error) {
+	args := m.Called(key, result)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *CacheMock) Set(key string, value any, expiration time.Duration) error {
+	return m.Called(key, value, expiration).Error(0)
+}
+
+func (m *CacheMock) Invalidate(key string) error {
+	return m.Called(key).Error(0)
+}
+
+func newNoopLogger() *slog.Logger {
+	h := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})
+	return slog.New(h)
+}
+
+func TestAdminService_GetStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(r *RepoMock, c *CacheMock)
+		want       *models.AdminStats
+		wantErr    bool
+	}{
+		{
+			name: "cache miss - aggregate from repository",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				c.On("Get", statsCacheKey, mock.Anything).Return(false, nil).Once()
+				r.On("CountUsersByStatus", mock.Anything).Return(map[string]int{"trial": 2, "active": 3}, nil).Once()
+				r.On("CountActiveSubscriptions", mock.Anything).Return(7, nil).Once()
+				r.On("SumMonthlyRecurringRevenue", mock.Anything).Return(2500.0, nil).Once()
+				r.On("CountTrialConversions", mock.Anything).Return(2, nil).Once()
+				c.On("Set", statsCacheKey, mock.Anything, statsCacheTTL).Return(nil).Once()
+			},
+			want: &models.AdminStats{
+				TotalUsers:          5,
+				UsersByStatus:       map[string]int{"trial": 2, "active": 3},
+				ActiveSubscriptions: 7,
+				MRR:                 2500,
+				TrialConversions:    2,
+			},
+		},
+		{
+			name: "cache hit - repository not called",
+			setupMocks: func(_ *RepoMock, c *CacheMock) {
+				c.On("Get", statsCacheKey, mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+					res := args.Get(1).(*models.AdminStats)
+					res.TotalUsers = 10
+				}).Once()
+			},
+			want: &models.AdminStats{TotalUsers: 10},
+		},
+		{
+			name: "cache error - fall back to repository",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				c.On("Get", statsCacheKey, mock.Anything).Return(false, errors.New("redis down")).Once()
+				r.On("CountUsersByStatus", mock.Anything).Return(map[string]int{}, nil).Once()
+				r.On("CountActiveSubscriptions", mock.Anything).Return(0, nil).Once()
+				r.On("SumMonthlyRecurringRevenue", mock.Anything).Return(0.0, nil).Once()
+				r.On("CountTrialConversions", mock.Anything).Return(0, nil).Once()
+				c.On("Set", statsCacheKey, mock.Anything, statsCacheTTL).Return(errors.New("redis down")).Once()
+			},
+			want: &models.AdminStats{UsersByStatus: map[string]int{}},
+		},
+		{
+			name: "repository error",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				c.On("Get", statsCacheKey, mock.Anything).Return(false, nil).Once()
+				r.On("CountUsersByStatus", mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			tt.setupMocks(repo, cache)
+
+			service := NewAdminService(repo, cache, nil, newNoopLogger())
+			got, err := service.GetStats(context.Background())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			repo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_RecomputePaymentDates(t *testing.T) {
+	today := time.Now()
+	start := today.AddDate(0, -2, -3)
+	correct := start.AddDate(0, 3, 0)
+
+	drifted := &models.Entry{ID: 1, StartDate: start, CounterMonths: 12, NextPaymentDate: start.AddDate(0, 1, 0)}
+	inSync := &models.Entry{ID: 2, StartDate: start, CounterMonths: 12, NextPaymentDate: correct}
+
+	repo := new(RepoMock)
+	cache := new(CacheMock)
+	repo.On("ListActiveEntrysAfterID", mock.Anything, 0, recomputeBatchSize).Return([]*models.Entry{drifted, inSync}, nil).Once()
+	repo.On("UpdateNextPaymentDate", mock.Anything, mock.MatchedBy(func(e *models.Entry) bool {
+		return e.ID == 1 && e.NextPaymentDate.Equal(time.Date(correct.Year(), correct.Month(), correct.Day(), 0, 0, 0, 0, correct.Location()))
+	})).Return(1, nil).Once()
+	cache.On("Invalidate", "subscription:1").Return(nil).Once()
+
+	svc := NewAdminService(repo, cache, nil, newNoopLogger())
+	result, err := svc.RecomputePaymentDates(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RecomputeResult{Checked: 2, Corrected: 1}, result)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestAdminService_RecomputePaymentDates_Batches(t *testing.T) {
+	start := time.Now().AddDate(0, 0, -3)
+	batch := make([]*models.Entry, recomputeBatchSize)
+	for i := range batch {
+		next := start.AddDate(0, 1, 0)
+		batch[i] = &models.Entry{ID: i + 1, StartDate: start, CounterMonths: 6, NextPaymentDate: next}
+	}
+
+	repo := new(RepoMock)
+	repo.On("ListActiveEntrysAfterID", mock.Anything, 0, recomputeBatchSize).Return(batch, nil).Once()
+	repo.On("ListActiveEntrysAfterID", mock.Anything, recomputeBatchSize, recomputeBatchSize).Return(nil, errors.New("db error")).Once()
+
+	svc := NewAdminService(repo, new(CacheMock), nil, newNoopLogger())
+	result, err := svc.RecomputePaymentDates(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, recomputeBatchSize, result.Checked)
+	assert.Zero(t, result.Corrected)
+	repo.AssertExpectations(t)
+}
+
+func TestAdminService_SearchUsers(t *testing.T) {
+	users := []*models.UserSummary{{UUID: "uid-1", Username: "alice", Email: "alice@example.com"}}
+
+	repo := new(RepoMock)
+	repo.On("SearchUsers", mock.Anything, "ali", 10, 0).Return(users, nil).Once()
+	repo.On("SearchUsers", mock.Anything, "bob", 10, 0).Return(nil, errors.New("db error")).Once()
+	svc := NewAdminService(repo, new(CacheMock), nil, newNoopLogger())
+
+	got, err := svc.SearchUsers(context.Background(), "ali", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, users, got)
+
+	_, err = svc.SearchUsers(context.Background(), "bob", 10, 0)
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestAdminService_CheckIntegrity(t *testing.T) {
+	report := &models.IntegrityReport{
+		OrphanSubscriptions: models.IntegrityIssue{Count: 1, IDs: []int{7}},
+		NegativePrices:      models.IntegrityIssue{Count: 2, IDs: []int{3, 4}},
+		Total:               3,
+	}
+
+	repo := new(RepoMock)
+	repo.On("CheckIntegrity", mock.Anything, models.IntegrityReportSampleSize).Return(report, nil).Once()
+	repo.On("CheckIntegrity", mock.Anything, models.IntegrityReportSampleSize).Return(nil, errors.New("db error")).Once()
+	svc := NewAdminService(repo, new(CacheMock), nil, newNoopLogger())
+
+	got, err := svc.CheckIntegrity(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, report, got)
+
+	_, err = svc.CheckIntegrity(context.Background())
+	assert.ErrorContains(t, err, "db error")
+	repo.AssertExpectations(t)
+}
+
+func TestAdminService_ShiftPaymentDates(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    models.PaymentDateShiftRequest
+		ids    []int
+		result *models.PaymentDateShiftResult
+	}{
+		{
+			name:   "сдвиг вперед",
+			req:    models.PaymentDateShiftRequest{Filter: models.PaymentDateShiftFilter{ServiceName: "Netflix"}, OffsetDays: 7},
+			ids:    []int{1, 2},
+			result: &models.PaymentDateShiftResult{OffsetDays: 7, Shifted: 2},
+		},
+		{
+			name: "сдвиг назад",
+			req: models.PaymentDateShiftRequest{
+				Filter:     models.PaymentDateShiftFilter{DueFrom: "2025-07-01", DueTo: "2025-07-31"},
+				OffsetDays: -3,
+			},
+			ids:    []int{5},
+			result: &models.PaymentDateShiftResult{OffsetDays: -3, Shifted: 1},
+		},
+		{
+			name:   "ни одна подписка не подошла",
+			req:    models.PaymentDateShiftRequest{Filter: models.PaymentDateShiftFilter{UserUID: "user-1"}, OffsetDays: 1},
+			result: &models.PaymentDateShiftResult{OffsetDays: 1, Shifted: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			repo.On("ShiftNextPaymentDates", mock.Anything, tt.req.Filter, tt.req.OffsetDays).Return(tt.ids, nil).Once()
+			for _, id := range tt.ids {
+				cache.On("Invalidate", fmt.Sprintf("subscription:%d", id)).Return(nil).Once()
+			}
+
+			result, err := NewAdminService(repo, cache, nil, newNoopLogger()).ShiftPaymentDates(context.Background(), tt.req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.result, result)
+			repo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminService_ShiftPaymentDates_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  models.PaymentDateShiftFilter
+		wantErr string
+	}{
+		{name: "пустой фильтр", wantErr: "invalid shift request: filter must contain at least one condition"},
+		{
+			name:    "неверный формат даты",
+			filter:  models.PaymentDateShiftFilter{DueFrom: "01-07-2025"},
+			wantErr: "invalid shift request: due_from must be in format 2006-01-02",
+		},
+		{
+			name:    "границы перепутаны",
+			filter:  models.PaymentDateShiftFilter{DueFrom: "2025-07-31", DueTo: "2025-07-01"},
+			wantErr: "invalid shift request: due_to must not be before due_from",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			req := models.PaymentDateShiftRequest{Filter: tt.filter, OffsetDays: 7}
+
+			_, err := NewAdminService(repo, new(CacheMock), nil, newNoopLogger()).ShiftPaymentDates(context.Background(), req)
+
+			assert.ErrorIs(t, err, models.ErrInvalidShiftRequest)
+			assert.EqualError(t, err, tt.wantErr)
+			repo.AssertNotCalled(t, "ShiftNextPaymentDates", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAdminService_ShiftPaymentDates_RepoError(t *testing.T) {
+	repo := new(RepoMock)
+	repo.On("ShiftNextPaymentDates", mock.Anything, mock.Anything, 7).Return(nil, errors.New("db error")).Once()
+
+	req := models.PaymentDateShiftRequest{Filter: models.PaymentDateShiftFilter{ServiceName: "Netflix"}, OffsetDays: 7}
+	_, err := NewAdminService(repo, new(CacheMock), nil, newNoopLogger()).ShiftPaymentDates(context.Background(), req)
+
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
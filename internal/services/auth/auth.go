@@ -4,6 +4,7 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/jwt"
@@ -22,20 +23,48 @@ type UserRepository interface {
 
 // AuthService отвечает за регистрацию, авторизацию и валидацию JWT.
 type AuthService struct {
-	users    UserRepository
-	jwtMaker jwt.Maker
+	users          UserRepository
+	jwtMaker       jwt.Maker
+	allowedDomains map[string]struct{} // Разрешенные домены email; пусто — без ограничений
 }
 
 // NewAuthService создает новый экземпляр AuthService.
-func NewAuthService(users UserRepository, jwtMaker jwt.Maker) *AuthService {
+// allowedDomains ограничивает регистрацию указанными доменами email;
+// пустой список означает, что разрешены любые домены.
+func NewAuthService(users UserRepository, jwtMaker jwt.Maker, allowedDomains []string) *AuthService {
+	domains := make(map[string]struct{}, len(allowedDomains))
+	for _, d := range allowedDomains {
+		d = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(d), "@")))
+		if d != "" {
+			domains[d] = struct{}{}
+		}
+	}
 	return &AuthService{
-		users:    users,
-		jwtMaker: jwtMaker,
+		users:          users,
+		jwtMaker:       jwtMaker,
+		allowedDomains: domains,
+	}
+}
+
+// domainAllowed проверяет, разрешен ли домен email для регистрации.
+func (s *AuthService) domainAllowed(email string) bool {
+	if len(s.allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return false
 	}
+	_, ok := s.allowedDomains[strings.ToLower(email[at+1:])]
+	return ok
 }
 
 // Register создает нового пользователя с хэшированием пароля и дефолтной ролью "user".
+// Если домен email не разрешен, возвращает models.ErrEmailDomainNotAllowed.
 func (s *AuthService) Register(ctx context.Context, email, username, rawPassword string) (string, error) {
+	if !s.domainAllowed(email) {
+		return "", models.ErrEmailDomainNotAllowed
+	}
 	hashed, err := password.GetHash(rawPassword)
 	if err != nil {
 		return "", err
@@ -20,6 +20,9 @@ type UserRepoMock struct {
 	mock.Mock
 }
 
+// Убеждаемся, что UserRepoMock реализует интерфейс UserRepository
+var _ services.UserRepository = (*UserRepoMock)(nil)
+
 func (m *UserRepoMock) RegisterUser(ctx context.Context, user models.User) (string, error) {
 	args := m.Called(ctx, user)
 	return args.String(0), args.Error(1)
@@ -96,7 +99,7 @@ func TestAuthService_Register(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(UserRepoMock)
 			jwtMock := new(JwtMakerMock)
-			svc := services.NewAuthService(repo, jwtMock)
+			svc := services.NewAuthService(repo, jwtMock, nil)
 
 			tt.setupMocks(repo)
 
@@ -115,6 +118,44 @@ func TestAuthService_Register(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthService_Register_AllowedEmailDomains(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedDomains []string
+		email          string
+		wantRegister   bool
+	}{
+		{name: "allowed domain", allowedDomains: []string{"corp.example"}, email: "user1@corp.example", wantRegister: true},
+		{name: "allowed domain is case-insensitive", allowedDomains: []string{"@Corp.Example"}, email: "user1@CORP.example", wantRegister: true},
+		{name: "disallowed domain", allowedDomains: []string{"corp.example"}, email: "user1@gmail.com"},
+		{name: "subdomain is not allowed implicitly", allowedDomains: []string{"corp.example"}, email: "user1@evil.corp.example"},
+		{name: "email without domain", allowedDomains: []string{"corp.example"}, email: "user1@"},
+		{name: "empty list allows all", allowedDomains: nil, email: "user1@gmail.com", wantRegister: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(UserRepoMock)
+			svc := services.NewAuthService(repo, new(JwtMakerMock), tt.allowedDomains)
+			if tt.wantRegister {
+				repo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(user models.User) bool {
+					return user.Email == tt.email
+				})).Return("user123", nil).Once()
+			}
+
+			got, err := svc.Register(context.Background(), tt.email, "user1", "password123")
+			if tt.wantRegister {
+				assert.NoError(t, err)
+				assert.Equal(t, "user123", got)
+			} else {
+				assert.ErrorIs(t, err, models.ErrEmailDomainNotAllowed)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAuthService_Login(t *testing.T) {
 	// Правильный сырой пароль для теста
 	rawPassword := "correctpassword"
@@ -202,7 +243,7 @@ func TestAuthService_Login(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(UserRepoMock)
 			jwtMock := new(JwtMakerMock)
-			svc := services.NewAuthService(repo, jwtMock)
+			svc := services.NewAuthService(repo, jwtMock, nil)
 
 			tt.setupMocks(repo, jwtMock)
 
@@ -290,7 +331,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(UserRepoMock)
 			jwtMock := new(JwtMakerMock)
-			svc := services.NewAuthService(repo, jwtMock)
+			svc := services.NewAuthService(repo, jwtMock, nil)
 
 			tt.setupMocks(jwtMock)
 
@@ -5,34 +5,80 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"strconv"
+	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
 )
 
-// SubscriptionRepository определяет интерфейс для работы с подписками в репозитории.
-type SubscriptionRepository interface {
+// Статусы подписки пользователя на сервис, при которых запланировано следующее списание.
+const (
+	subscriptionStatusTrial  = "trial"
+	subscriptionStatusActive = "active"
+)
+
+// minChargeAmount — минимальная сумма списания в копейках после применения промокода.
+// Скидка не может сделать платеж бесплатным: провайдер не принимает нулевые платежи.
+const minChargeAmount int64 = 100
+
+// PaymentRepository определяет интерфейс хранилища платежей, платежных токенов
+// и статусов подписок, используемый сервисом платежей.
+type PaymentRepository interface {
 	FindPaymentToken(ctx context.Context, userUID string, token string) (int, bool, error)
 	CreatePaymentToken(ctx context.Context, userUID string, token string) (int, error)
-	ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error)
+	ListPaymentTokensPage(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error)
+	DeletePaymentToken(ctx context.Context, userUID string, id int) error
+	GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error)
+	GetPayment(ctx context.Context, userUID string, id int) (*models.Payment, error)
+	GetPaymentByID(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error)
+	IsPaymentRefunded(ctx context.Context, paymentID int) (bool, error)
+	GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error)
+	GetUser(ctx context.Context, userUID string) (*models.User, error)
 	GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID, serviceName string) (string, error)
 	SavePayment(ctx context.Context, payload *paymentwebhook.Payload, amount int64, userUID string) (int, error)
+	SaveRefund(ctx context.Context, providerPaymentID string, refund *models.Refund, subscriptionStatus string, now time.Time) (bool, error)
 	UpdateStatusActiveForSubscription(ctx context.Context, userUID, status string) error
+	ActivateTrialSubscription(ctx context.Context, userUID string) error
 	UpdateStatusCancelForSubscription(ctx context.Context, userUID, status string) error
+	GetPromoCode(ctx context.Context, code string) (*models.PromoCode, error)
+	ReservePromoUse(ctx context.Context, promoCodeID int) error
+	ReleasePromoUse(ctx context.Context, promoCodeID int) error
+	RecordPromoRedemption(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error
+	EnqueueNotifications(ctx context.Context, messages []models.OutboxMessage) (int, error)
+}
+
+// IdempotencyStore определяет хранилище ключей идемпотентности обработки webhook-уведомлений.
+type IdempotencyStore interface {
+	AcquireIdempotencyKey(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
 }
 
 // Service предоставляет сервис для работы с платежами.
 type Service struct {
-	repo SubscriptionRepository
-	log  *slog.Logger
+	repo           PaymentRepository
+	idempotency    IdempotencyStore
+	idempotencyTTL time.Duration
+	refundWindow   time.Duration
+	clock          clock.Clock
+	log            *slog.Logger
 }
 
-// New создает новый экземпляр Service.
-func New(repo SubscriptionRepository, log *slog.Logger) *Service {
+// New создает новый экземпляр Service. Если idempotency равен nil,
+// повторные webhook-уведомления не отсеиваются. Возврат можно запросить
+// в течение refundWindow после оплаты; нулевое значение отключает возвраты.
+// Если clk равен nil, используется системное время.
+func New(repo PaymentRepository, idempotency IdempotencyStore, idempotencyTTL, refundWindow time.Duration,
+	clk clock.Clock, log *slog.Logger) *Service {
 	return &Service{
-		repo: repo,
-		log:  log,
+		repo:           repo,
+		idempotency:    idempotency,
+		idempotencyTTL: idempotencyTTL,
+		refundWindow:   refundWindow,
+		clock:          clock.OrReal(clk),
+		log:            log,
 	}
 }
 
@@ -52,9 +98,42 @@ func (s *Service) GetOrCreatePaymentToken(ctx context.Context, userUID string, t
 	return res, nil
 }
 
-// ListPaymentTokens возвращает список токенов платежных методов пользователя.
-func (s *Service) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
-	return s.repo.ListPaymentTokens(ctx, userUID)
+// ListPaymentTokens возвращает страницу токенов платежных методов пользователя.
+func (s *Service) ListPaymentTokens(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error) {
+	return s.repo.ListPaymentTokensPage(ctx, userUID, limit, offset)
+}
+
+// DeletePaymentToken отзывает сохраненный токен платежного метода пользователя.
+func (s *Service) DeletePaymentToken(ctx context.Context, userUID string, id int) error {
+	if err := s.repo.DeletePaymentToken(ctx, userUID, id); err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+	return nil
+}
+
+// GetPaymentReceipt возвращает данные для квитанции по платежу пользователя.
+// Квитанция формируется только для успешных платежей, иначе возвращается
+// models.ErrPaymentNotSucceeded.
+func (s *Service) GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error) {
+	receipt, err := s.repo.GetPaymentReceipt(ctx, userUID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if receipt.Status != models.PaymentStatusSucceeded {
+		return nil, models.ErrPaymentNotSucceeded
+	}
+	return receipt, nil
+}
+
+// GetPaymentDetails возвращает платеж пользователя с маскированным идентификатором платежа
+// у провайдера. Чужой или несуществующий платеж — models.ErrPaymentNotFound.
+func (s *Service) GetPaymentDetails(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error) {
+	details, err := s.repo.GetPaymentByID(ctx, userUID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	details.PaymentID = models.MaskToken(details.PaymentID)
+	return details, nil
 }
 
 // GetActiveSubscriptionIDByUserUID возвращает ID активной подписки пользователя.
@@ -63,24 +142,191 @@ func (s *Service) GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID
 	return s.repo.GetActiveSubscriptionIDByUserUID(ctx, userUID, serviceName)
 }
 
-// SavePayment сохраняет информацию о платеже.
+// GetUserPlan возвращает тариф пользователя, стоимость которого списывается при оплате подписки.
+func (s *Service) GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error) {
+	plan, err := s.repo.GetUserPlan(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return plan, nil
+}
+
+// GetNextCharge возвращает следующее списание за подписку на сервис: стоимость тарифа
+// пользователя и дату списания. В пробном периоде это первое списание в день окончания
+// пробного периода, для активной подписки — в день истечения оплаченного периода.
+// Если списание не запланировано, возвращает models.ErrNoUpcomingCharge.
+func (s *Service) GetNextCharge(ctx context.Context, userUID string) (*models.NextCharge, error) {
+	user, err := s.repo.GetUser(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var date *time.Time
+	switch user.SubscriptionStatus {
+	case subscriptionStatusTrial:
+		date = user.TrialEndDate
+	case subscriptionStatusActive:
+		date = user.SubscriptionExpire
+	}
+	if date == nil {
+		return nil, models.ErrNoUpcomingCharge
+	}
+
+	plan, err := s.repo.GetUserPlan(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return &models.NextCharge{
+		Amount:   plan.Price,
+		Currency: plan.Currency,
+		Date:     *date,
+		PlanCode: plan.Code,
+		Trial:    user.SubscriptionStatus == subscriptionStatusTrial,
+	}, nil
+}
+
+// SavePayment сохраняет информацию о платеже. Сумма переводится в копейки с округлением,
+// как и суммы возвратов, иначе доли возврата считались бы от заниженной суммы платежа.
 func (s *Service) SavePayment(ctx context.Context, payload *paymentwebhook.Payload) (int, error) {
 	userUID, exists := payload.Object.Metadata["user_uid"]
 	if !exists || userUID == "" {
 		return 0, fmt.Errorf("user_uid not found in metadata")
 	}
 
-	amount, err := strconv.ParseFloat(payload.Object.Amount.Value, 64)
+	amount, err := money.ParseMinor(payload.Object.Amount.Value)
 	if err != nil {
 		return 0, fmt.Errorf("invalid amount format: %w", err)
 	}
-	amountInKopecks := int64(amount * 100)
-	return s.repo.SavePayment(ctx, payload, amountInKopecks, userUID)
+	return s.repo.SavePayment(ctx, payload, amount, userUID)
+}
+
+// ProcessRefund сохраняет возврат из уведомления refund.succeeded и сокращает оплаченный
+// возвращенным платежом месяц подписки пропорционально сумме возврата.
+// Повторное уведомление о том же возврате ничего не меняет.
+func (s *Service) ProcessRefund(ctx context.Context, payload *paymentwebhook.Payload) error {
+	if payload.Object.PaymentID == "" {
+		return fmt.Errorf("payment_id not found in refund")
+	}
+	amount, err := money.ParseMinor(payload.Object.Amount.Value)
+	if err != nil {
+		return fmt.Errorf("invalid amount format: %w", err)
+	}
+
+	refund := &models.Refund{
+		RefundID: payload.Object.ID,
+		Amount:   amount,
+		Currency: payload.Object.Amount.Currency,
+		Status:   payload.Object.Status,
+	}
+	return s.RecordRefund(ctx, payload.Object.PaymentID, refund)
+}
+
+// GetRefundablePayment возвращает платеж пользователя, если по нему можно оформить возврат:
+// платеж успешен, оплачен не раньше чем refundWindow назад и еще не возвращен.
+// Иначе возвращает models.ErrRefundNotAllowed, models.ErrRefundWindowExpired
+// или models.ErrPaymentAlreadyRefunded.
+func (s *Service) GetRefundablePayment(ctx context.Context, userUID string, id int) (*models.Payment, error) {
+	payment, err := s.repo.GetPayment(ctx, userUID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment.Status != models.PaymentStatusSucceeded {
+		return nil, models.ErrRefundNotAllowed
+	}
+	if s.refundWindow <= 0 || s.clock.Now().Sub(payment.CreatedAt) > s.refundWindow {
+		return nil, models.ErrRefundWindowExpired
+	}
+	refunded, err := s.repo.IsPaymentRefunded(ctx, payment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check refund: %w", err)
+	}
+	if refunded {
+		return nil, models.ErrPaymentAlreadyRefunded
+	}
+	return payment, nil
+}
+
+// RecordRefund сохраняет возврат платежа providerPaymentID. Полный возврат отменяет подписку
+// и завершает оплаченный платежом месяц не позже текущего дня, частичный — сокращает этот месяц
+// пропорционально возвращенной доле суммы. Повторное сохранение того же возврата ничего не меняет.
+func (s *Service) RecordRefund(ctx context.Context, providerPaymentID string, refund *models.Refund) error {
+	created, err := s.repo.SaveRefund(ctx, providerPaymentID, refund, "cancel", s.clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save refund: %w", err)
+	}
+	if !created {
+		s.log.Info("refund already recorded", slog.String("refund_id", refund.RefundID))
+	}
+	return nil
+}
+
+// ApplyPromo проверяет промокод code, резервирует одно его использование и рассчитывает
+// сумму платежа amount в копейках со скидкой. Резерв засчитывается в лимит сразу, поэтому
+// параллельные платежи не превысят max_uses; если платеж не будет создан, резерв нужно снять
+// через ReleasePromo. Возвращает models.ErrPromoCodeNotFound, если промокод не существует,
+// models.ErrPromoCodeExpired, если он вне срока действия, и models.ErrPromoCodeExhausted,
+// если лимит использований исчерпан. Сумма со скидкой не опускается ниже minChargeAmount.
+func (s *Service) ApplyPromo(ctx context.Context, code string, amount int64) (*models.PromoApplication, error) {
+	promo, err := s.repo.GetPromoCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	now := s.clock.Now()
+	if promo.ValidFrom != nil && now.Before(*promo.ValidFrom) {
+		return nil, models.ErrPromoCodeExpired
+	}
+	if promo.ValidUntil != nil && now.After(*promo.ValidUntil) {
+		return nil, models.ErrPromoCodeExpired
+	}
+	if promo.MaxUses != nil && promo.UsedCount >= *promo.MaxUses {
+		return nil, models.ErrPromoCodeExhausted
+	}
+
+	discount := promo.AmountOff
+	if promo.PercentOff > 0 {
+		discount = amount * int64(promo.PercentOff) / 100
+	}
+	if amount-discount < minChargeAmount {
+		discount = max(amount-minChargeAmount, 0)
+	}
+	if err := s.repo.ReservePromoUse(ctx, promo.ID); err != nil {
+		return nil, fmt.Errorf("failed to reserve promo code: %w", err)
+	}
+	return &models.PromoApplication{
+		PromoCodeID: promo.ID,
+		Amount:      amount - discount,
+		Discount:    discount,
+	}, nil
+}
+
+// ReleasePromo снимает резерв использования промокода promoCodeID, сделанный ApplyPromo,
+// если платеж со скидкой не был создан.
+func (s *Service) ReleasePromo(ctx context.Context, promoCodeID int) error {
+	if err := s.repo.ReleasePromoUse(ctx, promoCodeID); err != nil {
+		return fmt.Errorf("failed to release promo code: %w", err)
+	}
+	return nil
+}
+
+// RecordPromoUsage сохраняет использование промокода promoCodeID, зарезервированное ApplyPromo,
+// в платеже paymentID.
+func (s *Service) RecordPromoUsage(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error {
+	if err := s.repo.RecordPromoRedemption(ctx, promoCodeID, userUID, paymentID, discount); err != nil {
+		return fmt.Errorf("failed to record promo usage: %w", err)
+	}
+	return nil
 }
 
 // UpdateStatusActiveForSubscription обновляет статус подписки на активный.
 func (s *Service) UpdateStatusActiveForSubscription(ctx context.Context, userUID string) error {
-	return s.repo.UpdateStatusActiveForSubscription(ctx, userUID, "active")
+	return s.repo.UpdateStatusActiveForSubscription(ctx, userUID, subscriptionStatusActive)
+}
+
+// ActivateTrialSubscription переводит пользователя из пробного периода в активную подписку.
+// Для пользователей, уже вышедших из пробного периода, ничего не меняет.
+func (s *Service) ActivateTrialSubscription(ctx context.Context, userUID string) error {
+	return s.repo.ActivateTrialSubscription(ctx, userUID)
 }
 
 // UpdateStatusExpireForSubscription обновляет статус подписки на истекший.
@@ -92,3 +338,48 @@ func (s *Service) UpdateStatusExpireForSubscription(ctx context.Context, userUID
 func (s *Service) UpdateStatusCancelForSubscription(ctx context.Context, userUID string) error {
 	return s.repo.UpdateStatusCancelForSubscription(ctx, userUID, "cancel")
 }
+
+// webhookIdempotencyKey возвращает ключ идемпотентности уведомления:
+// провайдер может повторять одно событие по одному платежу многократно.
+func webhookIdempotencyKey(payload *paymentwebhook.Payload) string {
+	return fmt.Sprintf("webhook:%s:%s", payload.Event, payload.Object.ID)
+}
+
+// EnqueueWebhook сохраняет принятое webhook-уведомление body в outbox, откуда релей
+// планировщика публикует его в очередь обработки. Возвращает false, если то же событие
+// по тому же объекту уже было принято раньше.
+func (s *Service) EnqueueWebhook(ctx context.Context, payload *paymentwebhook.Payload, body []byte) (bool, error) {
+	queued, err := s.repo.EnqueueNotifications(ctx, []models.OutboxMessage{{
+		RoutingKey: rabbitmq.RoutingKeyPaymentWebhook,
+		Payload:    body,
+		DedupKey:   webhookIdempotencyKey(payload),
+	}})
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue webhook: %w", err)
+	}
+	return queued > 0, nil
+}
+
+// AcquireWebhook отмечает уведомление как обрабатываемое. Возвращает false,
+// если это же событие по этому платежу уже было обработано в пределах срока хранения.
+func (s *Service) AcquireWebhook(ctx context.Context, payload *paymentwebhook.Payload) (bool, error) {
+	if s.idempotency == nil {
+		return true, nil
+	}
+	ok, err := s.idempotency.AcquireIdempotencyKey(ctx, webhookIdempotencyKey(payload), s.idempotencyTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+	return ok, nil
+}
+
+// ReleaseWebhook снимает отметку обработки, чтобы повторная доставка после ошибки была обработана.
+func (s *Service) ReleaseWebhook(ctx context.Context, payload *paymentwebhook.Payload) error {
+	if s.idempotency == nil {
+		return nil
+	}
+	if err := s.idempotency.ReleaseIdempotencyKey(ctx, webhookIdempotencyKey(payload)); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -6,9 +6,12 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,6 +20,9 @@ type MockRepository struct {
 	mock.Mock
 }
 
+// Убеждаемся, что MockRepository реализует интерфейс PaymentRepository
+var _ PaymentRepository = (*MockRepository)(nil)
+
 func (m *MockRepository) FindPaymentToken(ctx context.Context, userUID string, token string) (int, bool, error) {
 	args := m.Called(ctx, userUID, token)
 	return args.Int(0), args.Bool(1), args.Error(2)
@@ -27,14 +33,51 @@ func (m *MockRepository) CreatePaymentToken(ctx context.Context, userUID string,
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockRepository) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
-	args := m.Called(ctx, userUID)
+func (m *MockRepository) ListPaymentTokensPage(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error) {
+	args := m.Called(ctx, userUID, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.PaymentToken), args.Error(1)
 }
 
+func (m *MockRepository) DeletePaymentToken(ctx context.Context, userUID string, id int) error {
+	args := m.Called(ctx, userUID, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentReceipt), args.Error(1)
+}
+
+func (m *MockRepository) GetPaymentByID(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error) {
+	args := m.Called(ctx, userUID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentDetails), args.Error(1)
+}
+
+func (m *MockRepository) GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Plan), args.Error(1)
+}
+
+func (m *MockRepository) GetUser(ctx context.Context, userUID string) (*models.User, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockRepository) GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID, serviceName string) (string, error) {
 	args := m.Called(ctx, userUID, serviceName)
 	return args.String(0), args.Error(1)
@@ -42,7 +85,25 @@ func (m *MockRepository) GetActiveSubscriptionIDByUserUID(ctx context.Context, u
 
 func (m *MockRepository) SavePayment(ctx context.Context, payload *paymentwebhook.Payload, amount int64, userUID string) (int, error) {
 	args := m.Called(ctx, payload, amount, userUID)
-	return args.Int(0), args.Error(1) 
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetPayment(ctx context.Context, userUID string, id int) (*models.Payment, error) {
+	args := m.Called(ctx, userUID, id)
+	if res := args.Get(0); res != nil {
+		return res.(*models.Payment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) IsPaymentRefunded(ctx context.Context, paymentID int) (bool, error) {
+	args := m.Called(ctx, paymentID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) SaveRefund(ctx context.Context, providerPaymentID string, refund *models.Refund, subscriptionStatus string, now time.Time) (bool, error) {
+	args := m.Called(ctx, providerPaymentID, refund, subscriptionStatus, now)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockRepository) UpdateStatusActiveForSubscription(ctx context.Context, userUID, status string) error {
@@ -50,11 +111,44 @@ func (m *MockRepository) UpdateStatusActiveForSubscription(ctx context.Context,
 	return args.Error(0)
 }
 
+func (m *MockRepository) ActivateTrialSubscription(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
 func (m *MockRepository) UpdateStatusCancelForSubscription(ctx context.Context, userUID, status string) error {
 	args := m.Called(ctx, userUID, status)
 	return args.Error(0)
 }
 
+func (m *MockRepository) GetPromoCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoCode), args.Error(1)
+}
+
+func (m *MockRepository) ReservePromoUse(ctx context.Context, promoCodeID int) error {
+	args := m.Called(ctx, promoCodeID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleasePromoUse(ctx context.Context, promoCodeID int) error {
+	args := m.Called(ctx, promoCodeID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordPromoRedemption(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error {
+	args := m.Called(ctx, promoCodeID, userUID, paymentID, discount)
+	return args.Error(0)
+}
+
+func (m *MockRepository) EnqueueNotifications(ctx context.Context, messages []models.OutboxMessage) (int, error) {
+	args := m.Called(ctx, messages)
+	return args.Int(0), args.Error(1)
+}
+
 func newNoopLogger() *slog.Logger {
 	h := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})
 	return slog.New(h)
@@ -119,7 +213,7 @@ func TestService_GetOrCreatePaymentToken(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
@@ -157,7 +251,7 @@ func TestService_ListPaymentTokens(t *testing.T) {
 			name:    "success - return tokens",
 			userUID: "user123",
 			setupMocks: func(r *MockRepository) {
-				r.On("ListPaymentTokens", mock.Anything, "user123").Return(expectedTokens, nil).Once()
+				r.On("ListPaymentTokensPage", mock.Anything, "user123", 10, 20).Return(expectedTokens, nil).Once()
 			},
 			expectedTokens: expectedTokens,
 			expectedError:  false,
@@ -166,7 +260,7 @@ func TestService_ListPaymentTokens(t *testing.T) {
 			name:    "empty result",
 			userUID: "user456",
 			setupMocks: func(r *MockRepository) {
-				r.On("ListPaymentTokens", mock.Anything, "user456").Return([]*models.PaymentToken{}, nil).Once()
+				r.On("ListPaymentTokensPage", mock.Anything, "user456", 10, 20).Return([]*models.PaymentToken{}, nil).Once()
 			},
 			expectedTokens: []*models.PaymentToken{},
 			expectedError:  false,
@@ -175,7 +269,7 @@ func TestService_ListPaymentTokens(t *testing.T) {
 			name:    "repository error",
 			userUID: "user789",
 			setupMocks: func(r *MockRepository) {
-				r.On("ListPaymentTokens", mock.Anything, "user789").Return(nil, errors.New("db error")).Once()
+				r.On("ListPaymentTokensPage", mock.Anything, "user789", 10, 20).Return(nil, errors.New("db error")).Once()
 			},
 			expectedTokens: nil,
 			expectedError:  true,
@@ -186,11 +280,11 @@ func TestService_ListPaymentTokens(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
-			result, err := service.ListPaymentTokens(context.Background(), tt.userUID)
+			result, err := service.ListPaymentTokens(context.Background(), tt.userUID, 10, 20)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -206,6 +300,293 @@ func TestService_ListPaymentTokens(t *testing.T) {
 	}
 }
 
+func TestService_DeletePaymentToken(t *testing.T) {
+	repo := new(MockRepository)
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	repo.On("DeletePaymentToken", mock.Anything, "user123", 1).Return(nil).Once()
+	repo.On("DeletePaymentToken", mock.Anything, "user123", 2).Return(models.ErrPaymentTokenNotFound).Once()
+
+	assert.NoError(t, service.DeletePaymentToken(context.Background(), "user123", 1))
+
+	err := service.DeletePaymentToken(context.Background(), "user123", 2)
+	assert.ErrorIs(t, err, models.ErrPaymentTokenNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetPaymentReceipt(t *testing.T) {
+	repo := new(MockRepository)
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	succeeded := &models.PaymentReceipt{ID: 1, PaymentID: "pay_1", Amount: 29900, Currency: "RUB", Status: "succeeded"}
+	canceled := &models.PaymentReceipt{ID: 2, PaymentID: "pay_2", Amount: 29900, Currency: "RUB", Status: "canceled"}
+	repo.On("GetPaymentReceipt", mock.Anything, "user123", 1).Return(succeeded, nil).Once()
+	repo.On("GetPaymentReceipt", mock.Anything, "user123", 2).Return(canceled, nil).Once()
+	repo.On("GetPaymentReceipt", mock.Anything, "user123", 3).Return(nil, models.ErrPaymentNotFound).Once()
+
+	receipt, err := service.GetPaymentReceipt(context.Background(), "user123", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, succeeded, receipt)
+
+	_, err = service.GetPaymentReceipt(context.Background(), "user123", 2)
+	assert.ErrorIs(t, err, models.ErrPaymentNotSucceeded)
+
+	_, err = service.GetPaymentReceipt(context.Background(), "user123", 3)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetPaymentDetails(t *testing.T) {
+	repo := new(MockRepository)
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	owned := &models.PaymentDetails{ID: 1, PaymentID: "2c5d6f1e-000f-5000-8000-1a2b3c4d5e6f", Amount: 29900, Currency: "RUB", Status: "succeeded"}
+	repo.On("GetPaymentByID", mock.Anything, "user123", 1).Return(owned, nil).Once()
+	repo.On("GetPaymentByID", mock.Anything, "user123", 2).Return(nil, models.ErrPaymentNotFound).Once()
+
+	details, err := service.GetPaymentDetails(context.Background(), "user123", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "********************************5e6f", details.PaymentID)
+	assert.Equal(t, int64(29900), details.Amount)
+
+	_, err = service.GetPaymentDetails(context.Background(), "user123", 2)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetUserPlan(t *testing.T) {
+	repo := new(MockRepository)
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	plan := &models.Plan{ID: 1, Code: "standard", Price: 20000, Currency: "RUB", IsDefault: true}
+	repo.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+	repo.On("GetUserPlan", mock.Anything, "user456").Return(nil, models.ErrPlanNotFound).Once()
+
+	got, err := service.GetUserPlan(context.Background(), "user123")
+	assert.NoError(t, err)
+	assert.Equal(t, plan, got)
+
+	_, err = service.GetUserPlan(context.Background(), "user456")
+	assert.ErrorIs(t, err, models.ErrPlanNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_GetNextCharge(t *testing.T) {
+	trialEnd := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{ID: 2, Code: "premium", Price: 49900, Currency: "RUB"}
+
+	tests := []struct {
+		name      string
+		user      *models.User
+		setupPlan bool
+		want      *models.NextCharge
+		wantErr   error
+	}{
+		{
+			name:      "активная подписка списывается в день истечения",
+			user:      &models.User{UUID: "user123", SubscriptionStatus: "active", TrialEndDate: &trialEnd, SubscriptionExpire: &expiry},
+			setupPlan: true,
+			want:      &models.NextCharge{Amount: 49900, Currency: "RUB", Date: expiry, PlanCode: "premium"},
+		},
+		{
+			name:      "пробный период показывает первое списание",
+			user:      &models.User{UUID: "user123", SubscriptionStatus: "trial", TrialEndDate: &trialEnd},
+			setupPlan: true,
+			want:      &models.NextCharge{Amount: 49900, Currency: "RUB", Date: trialEnd, PlanCode: "premium", Trial: true},
+		},
+		{
+			name:    "истекшая подписка",
+			user:    &models.User{UUID: "user123", SubscriptionStatus: "expired", SubscriptionExpire: &expiry},
+			wantErr: models.ErrNoUpcomingCharge,
+		},
+		{
+			name:    "отмененная подписка",
+			user:    &models.User{UUID: "user123", SubscriptionStatus: "cancel", SubscriptionExpire: &expiry},
+			wantErr: models.ErrNoUpcomingCharge,
+		},
+		{
+			name:    "активная подписка без даты истечения",
+			user:    &models.User{UUID: "user123", SubscriptionStatus: "active"},
+			wantErr: models.ErrNoUpcomingCharge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			repo.On("GetUser", mock.Anything, "user123").Return(tt.user, nil).Once()
+			if tt.setupPlan {
+				repo.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+			}
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+			got, err := service.GetNextCharge(context.Background(), "user123")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("тариф не найден", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetUser", mock.Anything, "user123").
+			Return(&models.User{UUID: "user123", SubscriptionStatus: "active", SubscriptionExpire: &expiry}, nil).Once()
+		repo.On("GetUserPlan", mock.Anything, "user123").Return(nil, models.ErrPlanNotFound).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		_, err := service.GetNextCharge(context.Background(), "user123")
+		assert.ErrorIs(t, err, models.ErrPlanNotFound)
+	})
+
+	t.Run("ошибка получения пользователя", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetUser", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		_, err := service.GetNextCharge(context.Background(), "user123")
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "GetUserPlan", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ApplyPromo(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+	limit := 10
+
+	tests := []struct {
+		name    string
+		promo   *models.PromoCode
+		amount  int64
+		want    *models.PromoApplication
+		wantErr error
+	}{
+		{
+			name:   "процентная скидка в сроке действия",
+			promo:  &models.PromoCode{ID: 1, Code: "SUMMER25", PercentOff: 25, ValidFrom: &past, ValidUntil: &future, MaxUses: &limit, UsedCount: 3},
+			amount: 49900,
+			want:   &models.PromoApplication{PromoCodeID: 1, Amount: 37425, Discount: 12475},
+		},
+		{
+			name:   "фиксированная скидка без ограничений",
+			promo:  &models.PromoCode{ID: 2, Code: "MINUS100", AmountOff: 10000},
+			amount: 49900,
+			want:   &models.PromoApplication{PromoCodeID: 2, Amount: 39900, Discount: 10000},
+		},
+		{
+			name:   "скидка не опускает сумму ниже минимальной",
+			promo:  &models.PromoCode{ID: 3, Code: "FREE", PercentOff: 100},
+			amount: 49900,
+			want:   &models.PromoApplication{PromoCodeID: 3, Amount: minChargeAmount, Discount: 49900 - minChargeAmount},
+		},
+		{
+			name:    "срок действия истек",
+			promo:   &models.PromoCode{ID: 4, Code: "OLD", PercentOff: 10, ValidUntil: &past},
+			amount:  49900,
+			wantErr: models.ErrPromoCodeExpired,
+		},
+		{
+			name:    "срок действия еще не начался",
+			promo:   &models.PromoCode{ID: 5, Code: "SOON", PercentOff: 10, ValidFrom: &future},
+			amount:  49900,
+			wantErr: models.ErrPromoCodeExpired,
+		},
+		{
+			name:    "лимит использований исчерпан",
+			promo:   &models.PromoCode{ID: 6, Code: "LIMITED", PercentOff: 10, MaxUses: &limit, UsedCount: limit},
+			amount:  49900,
+			wantErr: models.ErrPromoCodeExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			repo.On("GetPromoCode", mock.Anything, tt.promo.Code).Return(tt.promo, nil).Once()
+			if tt.wantErr == nil {
+				repo.On("ReservePromoUse", mock.Anything, tt.promo.ID).Return(nil).Once()
+			}
+			service := New(repo, nil, 0, 0, clock.NewFake(now), newNoopLogger())
+
+			got, err := service.ApplyPromo(context.Background(), tt.promo.Code, tt.amount)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("промокод не найден", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("GetPromoCode", mock.Anything, "UNKNOWN").Return(nil, models.ErrPromoCodeNotFound).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		_, err := service.ApplyPromo(context.Background(), "UNKNOWN", 49900)
+		assert.ErrorIs(t, err, models.ErrPromoCodeNotFound)
+	})
+
+	t.Run("лимит исчерпан параллельным платежом", func(t *testing.T) {
+		repo := new(MockRepository)
+		promo := &models.PromoCode{ID: 7, Code: "RACE", PercentOff: 10, MaxUses: &limit, UsedCount: limit - 1}
+		repo.On("GetPromoCode", mock.Anything, "RACE").Return(promo, nil).Once()
+		repo.On("ReservePromoUse", mock.Anything, 7).Return(models.ErrPromoCodeExhausted).Once()
+		service := New(repo, nil, 0, 0, clock.NewFake(now), newNoopLogger())
+
+		got, err := service.ApplyPromo(context.Background(), "RACE", 49900)
+		assert.ErrorIs(t, err, models.ErrPromoCodeExhausted)
+		assert.Nil(t, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("срок действия проверяется по часам сервиса", func(t *testing.T) {
+		repo := new(MockRepository)
+		promo := &models.PromoCode{ID: 8, Code: "JUNE", PercentOff: 10, ValidUntil: &future}
+		repo.On("GetPromoCode", mock.Anything, "JUNE").Return(promo, nil).Once()
+		service := New(repo, nil, 0, 0, clock.NewFake(future.Add(time.Hour)), newNoopLogger())
+
+		_, err := service.ApplyPromo(context.Background(), "JUNE", 49900)
+		assert.ErrorIs(t, err, models.ErrPromoCodeExpired)
+		repo.AssertNotCalled(t, "ReservePromoUse", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ReleasePromo(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("ReleasePromoUse", mock.Anything, 1).Return(nil).Once()
+	repo.On("ReleasePromoUse", mock.Anything, 2).Return(errors.New("db error")).Once()
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	assert.NoError(t, service.ReleasePromo(context.Background(), 1))
+	assert.ErrorContains(t, service.ReleasePromo(context.Background(), 2), "db error")
+	repo.AssertExpectations(t)
+}
+
+func TestService_RecordPromoUsage(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("RecordPromoRedemption", mock.Anything, 1, "user123", "pay_1", int64(12475)).Return(nil).Once()
+	repo.On("RecordPromoRedemption", mock.Anything, 1, "user123", "pay_2", int64(12475)).Return(errors.New("db error")).Once()
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	assert.NoError(t, service.RecordPromoUsage(context.Background(), 1, "user123", "pay_1", 12475))
+	assert.ErrorContains(t, service.RecordPromoUsage(context.Background(), 1, "user123", "pay_2", 12475), "db error")
+	repo.AssertExpectations(t)
+}
+
 func TestService_GetActiveSubscriptionIDByUserUID(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -248,7 +629,7 @@ func TestService_GetActiveSubscriptionIDByUserUID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
@@ -271,9 +652,10 @@ func TestService_GetActiveSubscriptionIDByUserUID(t *testing.T) {
 func TestService_SavePayment(t *testing.T) {
 	payload := &paymentwebhook.Payload{
 		Object: struct {
-			ID     string `json:"id"`
-			Status string `json:"status"`
-			Amount struct {
+			ID        string `json:"id"`
+			PaymentID string `json:"payment_id,omitempty"`
+			Status    string `json:"status"`
+			Amount    struct {
 				Value    string `json:"value"`
 				Currency string `json:"currency"`
 			} `json:"amount"`
@@ -301,6 +683,9 @@ func TestService_SavePayment(t *testing.T) {
 			},
 		},
 	}
+	// Сумма, которую float64 представляет чуть меньше точного значения: 0.29*100 = 28.999...
+	kopecks := *payload
+	kopecks.Object.Amount.Value = "0.29"
 
 	tests := []struct {
 		name          string
@@ -314,16 +699,25 @@ func TestService_SavePayment(t *testing.T) {
 			name:    "success - save payment",
 			payload: payload,
 			setupMocks: func(r *MockRepository) {
-				r.On("SavePayment", mock.Anything, payload).Return(42, nil).Once()
+				r.On("SavePayment", mock.Anything, payload, int64(10000), "user123").Return(42, nil).Once()
 			},
 			expectedID:    42,
 			expectedError: false,
 		},
+		{
+			name:    "amount is rounded to kopecks",
+			payload: &kopecks,
+			setupMocks: func(r *MockRepository) {
+				r.On("SavePayment", mock.Anything, &kopecks, int64(29), "user123").Return(43, nil).Once()
+			},
+			expectedID:    43,
+			expectedError: false,
+		},
 		{
 			name:    "repository error",
 			payload: payload,
 			setupMocks: func(r *MockRepository) {
-				r.On("SavePayment", mock.Anything, payload).Return(0, errors.New("db error")).Once()
+				r.On("SavePayment", mock.Anything, payload, int64(10000), "user123").Return(0, errors.New("db error")).Once()
 			},
 			expectedID:    0,
 			expectedError: true,
@@ -334,7 +728,7 @@ func TestService_SavePayment(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
@@ -384,7 +778,7 @@ func TestService_UpdateStatusActiveForSubscription(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
@@ -402,6 +796,177 @@ func TestService_UpdateStatusActiveForSubscription(t *testing.T) {
 	}
 }
 
+func TestService_ActivateTrialSubscription(t *testing.T) {
+	repo := new(MockRepository)
+	service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+	repo.On("ActivateTrialSubscription", mock.Anything, "user123").Return(nil).Once()
+	repo.On("ActivateTrialSubscription", mock.Anything, "user456").Return(errors.New("db error")).Once()
+
+	assert.NoError(t, service.ActivateTrialSubscription(context.Background(), "user123"))
+	assert.EqualError(t, service.ActivateTrialSubscription(context.Background(), "user456"), "db error")
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_ProcessRefund(t *testing.T) {
+	newPayload := func(paymentID, value string) *paymentwebhook.Payload {
+		payload := &paymentwebhook.Payload{Event: paymentwebhook.RefundSucceeded}
+		payload.Object.ID = "refund_1"
+		payload.Object.PaymentID = paymentID
+		payload.Object.Status = "succeeded"
+		payload.Object.Amount.Value = value
+		payload.Object.Amount.Currency = "RUB"
+		return payload
+	}
+	wantRefund := &models.Refund{RefundID: "refund_1", Amount: 20000, Currency: "RUB", Status: "succeeded"}
+	// Срок подписки при полном возврате ограничивается временем часов сервиса
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		payload    *paymentwebhook.Payload
+		setupMocks func(*MockRepository)
+		wantErr    string
+	}{
+		{
+			name:    "refund recorded",
+			payload: newPayload("payment_1", "200.00"),
+			setupMocks: func(r *MockRepository) {
+				r.On("SaveRefund", mock.Anything, "payment_1", wantRefund, "cancel", now).Return(true, nil).Once()
+			},
+		},
+		{
+			name:    "duplicate refund",
+			payload: newPayload("payment_1", "200.00"),
+			setupMocks: func(r *MockRepository) {
+				r.On("SaveRefund", mock.Anything, "payment_1", wantRefund, "cancel", now).Return(false, nil).Once()
+			},
+		},
+		{
+			name:       "missing payment id",
+			payload:    newPayload("", "200.00"),
+			setupMocks: func(_ *MockRepository) {},
+			wantErr:    "payment_id not found in refund",
+		},
+		{
+			name:       "invalid amount",
+			payload:    newPayload("payment_1", "abc"),
+			setupMocks: func(_ *MockRepository) {},
+			wantErr:    "invalid amount format",
+		},
+		{
+			name:    "payment not found",
+			payload: newPayload("payment_1", "200.00"),
+			setupMocks: func(r *MockRepository) {
+				r.On("SaveRefund", mock.Anything, "payment_1", wantRefund, "cancel", now).Return(false, models.ErrPaymentNotFound).Once()
+			},
+			wantErr: "failed to save refund",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			tt.setupMocks(repo)
+			service := New(repo, nil, 0, 0, clock.NewFake(now), newNoopLogger())
+
+			err := service.ProcessRefund(context.Background(), tt.payload)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_GetRefundablePayment(t *testing.T) {
+	const window = 14 * 24 * time.Hour
+	payment := func(status string, age time.Duration) *models.Payment {
+		return &models.Payment{ID: 7, UserUID: "user123", PaymentID: "pay_1", Amount: 20000, Currency: "RUB",
+			Status: status, CreatedAt: time.Now().Add(-age)}
+	}
+
+	tests := []struct {
+		name       string
+		window     time.Duration
+		setupMocks func(*MockRepository)
+		wantErr    error
+	}{
+		{
+			name:   "refund allowed within window",
+			window: window,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(payment(models.PaymentStatusSucceeded, 24*time.Hour), nil).Once()
+				r.On("IsPaymentRefunded", mock.Anything, 7).Return(false, nil).Once()
+			},
+		},
+		{
+			name:   "out of refund window",
+			window: window,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(payment(models.PaymentStatusSucceeded, window+time.Hour), nil).Once()
+			},
+			wantErr: models.ErrRefundWindowExpired,
+		},
+		{
+			name:   "refunds disabled",
+			window: 0,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(payment(models.PaymentStatusSucceeded, time.Minute), nil).Once()
+			},
+			wantErr: models.ErrRefundWindowExpired,
+		},
+		{
+			name:   "payment not succeeded",
+			window: window,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(payment("canceled", time.Hour), nil).Once()
+			},
+			wantErr: models.ErrRefundNotAllowed,
+		},
+		{
+			name:   "already refunded",
+			window: window,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(payment(models.PaymentStatusSucceeded, time.Hour), nil).Once()
+				r.On("IsPaymentRefunded", mock.Anything, 7).Return(true, nil).Once()
+			},
+			wantErr: models.ErrPaymentAlreadyRefunded,
+		},
+		{
+			name:   "payment of another user",
+			window: window,
+			setupMocks: func(r *MockRepository) {
+				r.On("GetPayment", mock.Anything, "user123", 7).Return(nil, models.ErrPaymentNotFound).Once()
+			},
+			wantErr: models.ErrPaymentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			tt.setupMocks(repo)
+			service := New(repo, nil, 0, tt.window, nil, newNoopLogger())
+
+			got, err := service.GetRefundablePayment(context.Background(), "user123", 7)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "pay_1", got.PaymentID)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestService_UpdateStatusExpireForSubscription(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -432,7 +997,7 @@ func TestService_UpdateStatusExpireForSubscription(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
-			service := New(repo, newNoopLogger())
+			service := New(repo, nil, 0, 0, nil, newNoopLogger())
 
 			tt.setupMocks(repo)
 
@@ -449,3 +1014,132 @@ func TestService_UpdateStatusExpireForSubscription(t *testing.T) {
 		})
 	}
 }
+
+type MockIdempotencyStore struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyStore) AcquireIdempotencyKey(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, key, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func TestService_AcquireWebhook(t *testing.T) {
+	payload := &paymentwebhook.Payload{Event: "payment.succeeded"}
+	payload.Object.ID = "pay_1"
+	ttl := 48 * time.Hour
+
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockIdempotencyStore)
+		expected      bool
+		expectedError bool
+	}{
+		{
+			name: "first delivery",
+			setupMocks: func(s *MockIdempotencyStore) {
+				s.On("AcquireIdempotencyKey", mock.Anything, "webhook:payment.succeeded:pay_1", ttl).Return(true, nil).Once()
+			},
+			expected: true,
+		},
+		{
+			name: "duplicate delivery",
+			setupMocks: func(s *MockIdempotencyStore) {
+				s.On("AcquireIdempotencyKey", mock.Anything, "webhook:payment.succeeded:pay_1", ttl).Return(false, nil).Once()
+			},
+			expected: false,
+		},
+		{
+			name: "store error",
+			setupMocks: func(s *MockIdempotencyStore) {
+				s.On("AcquireIdempotencyKey", mock.Anything, "webhook:payment.succeeded:pay_1", ttl).Return(false, errors.New("redis down")).Once()
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockIdempotencyStore)
+			tt.setupMocks(store)
+			service := New(new(MockRepository), store, ttl, 0, nil, newNoopLogger())
+
+			got, err := service.AcquireWebhook(context.Background(), payload)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, got)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_EnqueueWebhook(t *testing.T) {
+	payload := &paymentwebhook.Payload{Event: "payment.succeeded"}
+	payload.Object.ID = "pay_1"
+	body := []byte(`{"event":"payment.succeeded","object":{"id":"pay_1"}}`)
+	want := []models.OutboxMessage{{
+		RoutingKey: rabbitmq.RoutingKeyPaymentWebhook,
+		Payload:    body,
+		DedupKey:   "webhook:payment.succeeded:pay_1",
+	}}
+
+	t.Run("новое уведомление", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("EnqueueNotifications", mock.Anything, want).Return(1, nil).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		queued, err := service.EnqueueWebhook(context.Background(), payload, body)
+		assert.NoError(t, err)
+		assert.True(t, queued)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("уведомление уже принято", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("EnqueueNotifications", mock.Anything, want).Return(0, nil).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		queued, err := service.EnqueueWebhook(context.Background(), payload, body)
+		assert.NoError(t, err)
+		assert.False(t, queued)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("EnqueueNotifications", mock.Anything, want).Return(0, errors.New("db error")).Once()
+		service := New(repo, nil, 0, 0, nil, newNoopLogger())
+
+		_, err := service.EnqueueWebhook(context.Background(), payload, body)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_ReleaseWebhook(t *testing.T) {
+	payload := &paymentwebhook.Payload{Event: "payment.canceled"}
+	payload.Object.ID = "pay_2"
+
+	store := new(MockIdempotencyStore)
+	store.On("ReleaseIdempotencyKey", mock.Anything, "webhook:payment.canceled:pay_2").Return(nil).Once()
+	service := New(new(MockRepository), store, time.Hour, 0, nil, newNoopLogger())
+
+	assert.NoError(t, service.ReleaseWebhook(context.Background(), payload))
+	store.AssertExpectations(t)
+}
+
+func TestService_WebhookWithoutStore(t *testing.T) {
+	service := New(new(MockRepository), nil, 0, 0, nil, newNoopLogger())
+	payload := &paymentwebhook.Payload{Event: "payment.succeeded"}
+
+	first, err := service.AcquireWebhook(context.Background(), payload)
+	assert.NoError(t, err)
+	assert.True(t, first)
+	assert.NoError(t, service.ReleaseWebhook(context.Background(), payload))
+}
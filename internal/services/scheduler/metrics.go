@@ -0,0 +1,75 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Названия задач планировщика в метриках и логах.
+const (
+	jobExpiringTomorrow = "expiring_tomorrow"
+	jobExpiringToday    = "expiring_today"
+	jobOutboxRelay      = "outbox_relay"
+	jobExpireLapsed     = "expire_lapsed"
+)
+
+// RunStats описывает результат одного прохода задачи планировщика.
+type RunStats struct {
+	Job       string // Название задачи, например expiring_tomorrow
+	Found     int    // Количество найденных подписок или уведомлений outbox
+	Queued    int    // Количество уведомлений, записанных в outbox
+	Published int    // Количество опубликованных уведомлений
+	Failed    int    // Количество уведомлений, которые не удалось записать в outbox или опубликовать
+}
+
+// Recorder сохраняет метрики проходов планировщика.
+type Recorder interface {
+	RecordRun(stats RunStats)
+	// RecordQueueDepth сохраняет количество сообщений в очереди queue.
+	RecordQueueDepth(queue string, messages int)
+}
+
+var (
+	schedulerRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_runs_total",
+		Help: "Количество проходов задач планировщика.",
+	}, []string{"job"})
+	schedulerFound = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_subscriptions_found_total",
+		Help: "Количество подписок, найденных задачами планировщика.",
+	}, []string{"job"})
+	schedulerQueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_notifications_queued_total",
+		Help: "Количество уведомлений, записанных планировщиком в outbox.",
+	}, []string{"job"})
+	schedulerPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_notifications_published_total",
+		Help: "Количество уведомлений, опубликованных планировщиком.",
+	}, []string{"job"})
+	schedulerFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_notifications_failed_total",
+		Help: "Количество уведомлений, которые планировщик не смог опубликовать.",
+	}, []string{"job"})
+	schedulerQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scheduler_queue_messages",
+		Help: "Количество сообщений в очереди RabbitMQ при последней проверке планировщика.",
+	}, []string{"queue"})
+)
+
+// PrometheusRecorder записывает метрики проходов в реестр Prometheus по умолчанию,
+// который отдается обработчиком /metrics планировщика.
+type PrometheusRecorder struct{}
+
+// RecordRun увеличивает счетчики проходов, найденных подписок и уведомлений задачи.
+func (PrometheusRecorder) RecordRun(stats RunStats) {
+	schedulerRuns.WithLabelValues(stats.Job).Inc()
+	schedulerFound.WithLabelValues(stats.Job).Add(float64(stats.Found))
+	schedulerQueued.WithLabelValues(stats.Job).Add(float64(stats.Queued))
+	schedulerPublished.WithLabelValues(stats.Job).Add(float64(stats.Published))
+	schedulerFailed.WithLabelValues(stats.Job).Add(float64(stats.Failed))
+}
+
+// RecordQueueDepth выставляет текущее количество сообщений в очереди.
+func (PrometheusRecorder) RecordQueueDepth(queue string, messages int) {
+	schedulerQueueDepth.WithLabelValues(queue).Set(float64(messages))
+}
@@ -3,22 +3,74 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/month"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 	"github.com/streadway/amqp"
 )
 
+// paymentStatusCanceled — статус платежа, отклоненного провайдером.
+const paymentStatusCanceled = "canceled"
+
+// archiveBatchSize — количество подписок, архивируемых за один запрос к БД.
+const archiveBatchSize = 500
+
+// outboxBatchSize — количество уведомлений, публикуемых релеем outbox за один проход.
+const outboxBatchSize = 100
+
+// outboxClaimTTL — на сколько релей откладывает забранные уведомления: за это время он должен
+// их опубликовать, иначе уведомления заберет следующий проход.
+const outboxClaimTTL = 5 * time.Minute
+
+// outboxMaxRetryDelay ограничивает паузу перед повторной публикацией уведомления.
+const outboxMaxRetryDelay = time.Hour
+
+// errNoChannel возвращается при попытке публикации без канала RabbitMQ.
+var errNoChannel = errors.New("publish channel is nil")
+
 // SubscriptionRepository определяет интерфейс для работы с подписками.
 type SubscriptionRepository interface {
-	FindSubscriptionExpiringTomorrow(ctx context.Context) ([]*models.EntryInfo, error)
-	FindSubscriptionExpiringToday(ctx context.Context) ([]*models.User, error)
+	FindSubscriptionsDueReminder(ctx context.Context, today time.Time, defaultDays []int) ([]*models.EntryInfo, error)
+	FindSubscriptionExpiringToday(ctx context.Context, today time.Time) ([]*models.User, error)
 	FindOldNextPaymentDate(ctx context.Context) ([]*models.Entry, error)
 	UpdateNextPaymentDate(ctx context.Context, entry *models.Entry) (int, error)
+	FindEndedTrials(ctx context.Context) ([]*models.User, error)
+	ExpireLapsedSubscriptions(ctx context.Context, cutoff time.Time,
+		notification func(*models.User) (models.OutboxMessage, error)) ([]*models.User, int, error)
+	ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error)
+	ActivateTrialSubscription(ctx context.Context, userUID string) error
+	ExpireTrial(ctx context.Context, userUID string, message models.OutboxMessage) (bool, error)
+	GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error)
+	ArchiveInactiveEntrys(ctx context.Context, cutoff time.Time, limit int) (int, error)
+	EnqueueNotifications(ctx context.Context, messages []models.OutboxMessage) (int, error)
+	ClaimPendingNotifications(ctx context.Context, now, claimUntil time.Time, limit int) ([]*models.OutboxMessage, error)
+	MarkNotificationSent(ctx context.Context, id int64) error
+	MarkNotificationFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+}
+
+// PaymentProvider определяет интерфейс платежного провайдера для автоматических списаний.
+type PaymentProvider interface {
+	CreatePayment(reqParams yookassa.CreatePaymentRequest) (*yookassa.CreatePaymentResponse, error)
+}
+
+// QueueInspector сообщает количество сообщений в очереди брокера.
+type QueueInspector interface {
+	QueueMessages(name string) (int, error)
 }
 
 // Cache определяет интерфейс для работы с кэшем.
@@ -29,99 +81,662 @@ type Cache interface {
 
 // SchedulerService предоставляет сервис для планирования задач.
 type SchedulerService struct {
-	repo  SubscriptionRepository
-	cache Cache
-	log   *slog.Logger
+	repo     SubscriptionRepository
+	cache    Cache
+	provider PaymentProvider
+	clock    clock.Clock
+	cfg      config.Scheduler
+	log      *slog.Logger
+	metrics  Recorder
+	publish  func(ctx context.Context, ch *amqp.Channel, exchange, routingKey string, message any) error
 }
 
 // NewSchedulerService создает новый экземпляр SchedulerService.
-func NewSchedulerService(repo SubscriptionRepository, cache Cache, log *slog.Logger) *SchedulerService {
+// Если clk равен nil, используется системное время. cfg задает периодичность задач;
+// нулевые периоды заменяются значениями по умолчанию.
+func NewSchedulerService(repo SubscriptionRepository, cache Cache, provider PaymentProvider, clk clock.Clock,
+	cfg config.Scheduler, log *slog.Logger) *SchedulerService {
 	return &SchedulerService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+		repo:     repo,
+		cache:    cache,
+		provider: provider,
+		clock:    clock.OrReal(clk),
+		cfg:      withDefaultIntervals(cfg),
+		log:      log,
+		metrics:  PrometheusRecorder{},
+		publish:  rabbitmq.PublishMessage,
+	}
+}
+
+// withDefaultIntervals заменяет неположительные периоды задач значениями по умолчанию,
+// а пустой список сроков напоминаний — напоминанием за один день.
+func withDefaultIntervals(cfg config.Scheduler) config.Scheduler {
+	defaults := []struct {
+		interval *time.Duration
+		value    time.Duration
+	}{
+		{&cfg.ExpiringTomorrowInterval, 12 * time.Hour},
+		{&cfg.ExpiringTodayInterval, 24 * time.Hour},
+		{&cfg.TrialConversionInterval, 24 * time.Hour},
+		{&cfg.PaymentDateInterval, 24 * time.Hour},
+		{&cfg.AccountDeletionInterval, 24 * time.Hour},
+		{&cfg.ArchiveInterval, 24 * time.Hour},
+		{&cfg.ArchiveRetention, 365 * 24 * time.Hour},
+		{&cfg.OutboxRelayInterval, 10 * time.Second},
+		{&cfg.ExpiryInterval, time.Hour},
+		{&cfg.DeadLetterCheckInterval, time.Minute},
+	}
+	for _, d := range defaults {
+		if *d.interval <= 0 {
+			*d.interval = d.value
+		}
+	}
+	if len(cfg.ReminderDaysBefore) == 0 {
+		cfg.ReminderDaysBefore = []int{1}
 	}
+	return cfg
 }
 
-// FindExpiringSubscriptionsDueTomorrow находит подписки, истекающие завтра.
-func (s *SchedulerService) FindExpiringSubscriptionsDueTomorrow(ctx context.Context, channel *amqp.Channel) {
-	s.runFindExpiringSubscriptionsDueTomorrow(ctx, channel)
+// FindExpiringSubscriptionsDueTomorrow находит подписки, о скором окончании которых пора
+// напомнить, и записывает уведомления о них в outbox. Срок напоминания берется из настройки
+// пользователя reminder_days_before, а без нее — из scheduler.reminder_days_before.
+func (s *SchedulerService) FindExpiringSubscriptionsDueTomorrow(ctx context.Context) {
+	s.runFindExpiringSubscriptionsDueTomorrow(ctx)
 
-	ticker := time.NewTicker(12 * time.Hour)
+	ticker := time.NewTicker(s.cfg.ExpiringTomorrowInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		s.runFindExpiringSubscriptionsDueTomorrow(ctx, channel)
+		s.runFindExpiringSubscriptionsDueTomorrow(ctx)
+	}
+}
+
+func (s *SchedulerService) runFindExpiringSubscriptionsDueTomorrow(ctx context.Context) {
+	if _, err := s.scanExpiringSubscriptions(ctx, s.clock.Now()); err != nil {
+		s.log.Error("failed to find entries", sl.Err(err))
 	}
 }
 
-func (s *SchedulerService) runFindExpiringSubscriptionsDueTomorrow(ctx context.Context, channel *amqp.Channel) {
-	s.log.Info("starting service to find expiring subscriptions due tomorrow")
-	entriesInfo, err := s.repo.FindSubscriptionExpiringTomorrow(ctx)
+// scanExpiringSubscriptions записывает в outbox напоминания о подписках, срок напоминания
+// о которых наступает в день now. Пользователи, выбравшие дайджест,
+// получают одно уведомление обо всех своих подписках за проход; в статистике оно считается
+// одним уведомлением. Подписки, уведомление о которых записано меньше чем
+// scheduler.min_days_between_notifications дней назад, пропускаются и считаются найденными,
+// но не записанными.
+// Уведомления прохода получают его идентификатор корреляции (см. withRunID).
+func (s *SchedulerService) scanExpiringSubscriptions(ctx context.Context, now time.Time) (RunStats, error) {
+	ctx = withRunID(ctx)
+	log := s.logger(ctx)
+	log.Debug("starting service to find expiring subscriptions due for reminder")
+	stats := RunStats{Job: jobExpiringTomorrow}
+	entriesInfo, err := s.repo.FindSubscriptionsDueReminder(ctx, now, s.cfg.ReminderDaysBefore)
 	if err != nil {
+		return stats, err
+	}
+	due, suppressed := s.skipRecentlyNotified(entriesInfo, now)
+	single, digests := groupDigests(due, now.Format(models.EntryInfoDateLayout))
+	stats.Found = len(single) + len(digests) + suppressed
+	defer func() { s.recordRun(stats) }()
+	if len(entriesInfo) == 0 {
+		log.Debug("no expiring subscriptions due for reminder found")
+		return stats, nil
+	}
+	log.Info("found expiring subscriptions", "count", len(entriesInfo), "digests", len(digests),
+		"recently_notified", suppressed)
+	if len(single) > 0 {
+		queued, failed := enqueueAll(ctx, s, rabbitmq.RoutingKeySubscriptionExpiring, single,
+			func(e *models.EntryInfo) string { return e.UserUID },
+			func(e *models.EntryInfo) string {
+				return fmt.Sprintf("%d:%s:%d", e.SubscriptionID, e.EndDate.Format(models.EntryInfoDateLayout), e.DaysBefore)
+			},
+			func(e *models.EntryInfo) []int { return []int{e.SubscriptionID} })
+		stats.Queued += queued
+		stats.Failed += failed
+	}
+	if len(digests) > 0 {
+		queued, failed := enqueueAll(ctx, s, rabbitmq.RoutingKeyReminderDigest, digests,
+			func(d *models.ReminderDigest) string { return d.UserUID },
+			func(d *models.ReminderDigest) string { return d.UserUID + ":" + d.Date },
+			func(d *models.ReminderDigest) []int {
+				ids := make([]int, 0, len(d.Subscriptions))
+				for _, item := range d.Subscriptions {
+					ids = append(ids, item.SubscriptionID)
+				}
+				return ids
+			})
+		stats.Queued += queued
+		stats.Failed += failed
+	}
+	return stats, nil
+}
+
+// skipRecentlyNotified отбрасывает подписки, уведомление о которых записано меньше чем
+// scheduler.min_days_between_notifications календарных дней от now, и возвращает
+// оставшиеся подписки и количество отброшенных.
+func (s *SchedulerService) skipRecentlyNotified(entries []*models.EntryInfo, now time.Time) ([]*models.EntryInfo, int) {
+	gap := s.cfg.MinDaysBetweenNotifications
+	if gap <= 0 {
+		return entries, 0
+	}
+	due := make([]*models.EntryInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.LastNotifiedAt != nil && month.DaysBetween(*e.LastNotifiedAt, now) < gap {
+			s.log.Debug("subscription notified recently, skipping reminder",
+				slog.Int("subscription_id", e.SubscriptionID), slog.Time("last_notified_at", *e.LastNotifiedAt))
+			continue
+		}
+		due = append(due, e)
+	}
+	return due, len(entries) - len(due)
+}
+
+// groupDigests отделяет напоминания пользователей, выбравших дайджест, и собирает их
+// в один дайджест на пользователя за день date. Подписки в дайджесте упорядочены
+// по близости окончания, дайджесты — по первому появлению пользователя в entries.
+func groupDigests(entries []*models.EntryInfo, date string) ([]*models.EntryInfo, []*models.ReminderDigest) {
+	var single []*models.EntryInfo
+	var digests []*models.ReminderDigest
+	byUser := make(map[string]*models.ReminderDigest)
+	for _, e := range entries {
+		if !e.Digest {
+			single = append(single, e)
+			continue
+		}
+		d, ok := byUser[e.UserUID]
+		if !ok {
+			d = &models.ReminderDigest{UserUID: e.UserUID, Email: e.Email, Username: e.Username, Locale: e.Locale, Date: date}
+			byUser[e.UserUID] = d
+			digests = append(digests, d)
+		}
+		d.Subscriptions = append(d.Subscriptions, models.ReminderDigestItem{
+			SubscriptionID: e.SubscriptionID,
+			ServiceName:    e.ServiceName,
+			EndDate:        e.EndDate.Format(models.EntryInfoDateLayout),
+			Price:          e.Price,
+			Currency:       e.Currency,
+			DaysBefore:     e.DaysBefore,
+		})
+	}
+	for _, d := range digests {
+		sort.SliceStable(d.Subscriptions, func(i, j int) bool {
+			return d.Subscriptions[i].DaysBefore < d.Subscriptions[j].DaysBefore
+		})
+	}
+	return single, digests
+}
+
+// FindExpiringSubscriptionsDueToday находит подписки, истекающие сегодня,
+// и записывает уведомления о них в outbox.
+func (s *SchedulerService) FindExpiringSubscriptionsDueToday(ctx context.Context) {
+	s.runFindExpiringTrialPeriod(ctx)
+
+	ticker := time.NewTicker(s.cfg.ExpiringTodayInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.runFindExpiringTrialPeriod(ctx)
+	}
+}
+
+func (s *SchedulerService) runFindExpiringTrialPeriod(ctx context.Context) {
+	if _, err := s.scanExpiringTrials(ctx, s.clock.Now()); err != nil {
 		s.log.Error("failed to find entries", sl.Err(err))
-		return
 	}
+}
+
+// scanExpiringTrials записывает в outbox уведомления пользователям, пробный период которых
+// истекает в день now.
+func (s *SchedulerService) scanExpiringTrials(ctx context.Context, now time.Time) (RunStats, error) {
+	ctx = withRunID(ctx)
+	log := s.logger(ctx)
+	log.Debug("starting service to find expiring trial period for subscription")
+	stats := RunStats{Job: jobExpiringToday}
+	entriesInfo, err := s.repo.FindSubscriptionExpiringToday(ctx, now)
+	if err != nil {
+		return stats, err
+	}
+	stats.Found = len(entriesInfo)
+	defer func() { s.recordRun(stats) }()
 	if len(entriesInfo) == 0 {
-		s.log.Info("no expiring subscriptions due tomorrow found")
+		log.Debug("no expiring trial period subscriptions found")
+		return stats, nil
+	}
+	log.Info("found expiring subscriptions", "count", len(entriesInfo))
+	today := now.Format(models.EntryInfoDateLayout)
+	stats.Queued, stats.Failed = enqueueAll(ctx, s, rabbitmq.RoutingKeyTrialExpiring, entriesInfo,
+		func(u *models.User) string { return u.UUID },
+		func(u *models.User) string { return u.UUID + ":" + today }, nil)
+	return stats, nil
+}
+
+// ReplayNotifications повторяет поиск напоминаний об окончании подписок и пробных периодов так,
+// будто сегодня date, и записывает найденные уведомления в outbox, откуда их публикует релей.
+// Ключи дедупликации совпадают с ключами обычного прохода за этот день, поэтому уже записанные
+// уведомления не дублируются и учитываются как пропущенные. Дата из будущего отклоняется
+// с models.ErrReplayDateInFuture.
+func (s *SchedulerService) ReplayNotifications(ctx context.Context, date time.Time) (*models.NotificationReplayResult, error) {
+	const op = "services.scheduler.ReplayNotifications"
+	y, m, d := date.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if day.After(s.clock.Now()) {
+		return nil, models.ErrReplayDateInFuture
+	}
+
+	reminders, err := s.scanExpiringSubscriptions(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	trials, err := s.scanExpiringTrials(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.NotificationReplayResult{
+		Date:          day.Format(models.EntryInfoDateLayout),
+		Reminders:     replayStats(reminders),
+		TrialExpiring: replayStats(trials),
+	}, nil
+}
+
+func replayStats(stats RunStats) models.NotificationReplayStats {
+	return models.NotificationReplayStats{
+		Found:   stats.Found,
+		Queued:  stats.Queued,
+		Skipped: stats.Found - stats.Queued - stats.Failed,
+		Failed:  stats.Failed,
+	}
+}
+
+// enqueueAll записывает в outbox по одному уведомлению на каждый элемент одной транзакцией.
+// userUID возвращает получателя уведомления. Ключ дедупликации строится из routingKey
+// и dedupKey элемента, поэтому повторный проход
+// задачи не создает второе уведомление о том же событии. subscriptions возвращает подписки,
+// о которых уведомление, и может быть nil для уведомлений не о подписках. Возвращает
+// количество новых уведомлений и количество уведомлений, которые не удалось записать.
+func enqueueAll[T any](ctx context.Context, s *SchedulerService, routingKey string, items []T,
+	userUID func(T) string, dedupKey func(T) string, subscriptions func(T) []int) (queued, failed int) {
+	messages := make([]models.OutboxMessage, 0, len(items))
+	for _, item := range items {
+		message, err := newOutboxMessage(ctx, routingKey, userUID(item), item, dedupKey(item))
+		if err != nil {
+			s.log.Error("failed to marshal notification", slog.String("routing_key", routingKey), sl.Err(err))
+			failed++
+			continue
+		}
+		if subscriptions != nil {
+			message.SubscriptionIDs = subscriptions(item)
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return 0, failed
+	}
+
+	queued, err := s.repo.EnqueueNotifications(ctx, messages)
+	if err != nil {
+		s.log.Error("failed to enqueue notifications", slog.String("routing_key", routingKey), sl.Err(err))
+		return 0, failed + len(messages)
+	}
+	return queued, failed
+}
+
+// newOutboxMessage готовит уведомление пользователю userUID с ключом routingKey о событии item
+// для записи в outbox. Ключ дедупликации строится из routingKey и dedupKey.
+func newOutboxMessage(ctx context.Context, routingKey, userUID string, item any, dedupKey string) (models.OutboxMessage, error) {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+	return models.OutboxMessage{
+		RoutingKey: routingKey,
+		Payload:    payload,
+		DedupKey:   routingKey + ":" + dedupKey,
+		UserUID:    userUID,
+		// Релей публикует уведомление с идентификатором корреляции прохода, который его записал
+		CorrelationID: rabbitmq.CorrelationID(ctx),
+	}, nil
+}
+
+// RelayOutbox с периодом scheduler.outbox_relay_interval публикует уведомления из outbox
+// до отмены ctx. Уведомление отмечается отправленным только после успешной публикации,
+// поэтому при сбое оно будет опубликовано повторно: доставка гарантируется хотя бы один раз.
+// Без канала публиковать некуда: RelayOutbox пишет ошибку в лог и сразу возвращается,
+// а уведомления остаются в outbox.
+func (s *SchedulerService) RelayOutbox(ctx context.Context, channel *amqp.Channel) {
+	if channel == nil {
+		s.log.Error("publish channel is nil, outbox relay disabled")
+		return
+	}
+	s.runRelayOutbox(ctx, channel)
+
+	ticker := time.NewTicker(s.cfg.OutboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runRelayOutbox(ctx, channel)
+		}
+	}
+}
+
+// runRelayOutbox забирает и публикует пачку уведомлений, время попытки которых наступило.
+// Забранные уведомления откладываются на outboxClaimTTL, поэтому параллельно
+// работающие планировщики не публикуют одно уведомление дважды.
+// Неудавшаяся публикация откладывается с экспоненциально растущей паузой.
+func (s *SchedulerService) runRelayOutbox(ctx context.Context, channel *amqp.Channel) {
+	// Без канала каждая публикация завершилась бы ошибкой и откладывала уведомления
+	if channel == nil {
+		s.log.Warn("publish channel is nil, skipping outbox relay")
+		return
+	}
+	now := s.clock.Now()
+	messages, err := s.repo.ClaimPendingNotifications(ctx, now, now.Add(outboxClaimTTL), outboxBatchSize)
+	if err != nil {
+		s.log.Error("failed to claim pending notifications", sl.Err(err))
 		return
 	}
-	s.log.Info("found expiring subscriptions", "count", len(entriesInfo))
-	if channel != nil {
-		for _, entryInfo := range entriesInfo {
-			err = rabbitmq.PublishMessage(channel, "notifications", "subscription.expiring.tomorrow", entryInfo)
-			if err != nil {
-				s.log.Error("failed to publish message", sl.Err(err))
+	if len(messages) == 0 {
+		return
+	}
+
+	stats := RunStats{Job: jobOutboxRelay, Found: len(messages)}
+	defer func() { s.recordRun(stats) }()
+	for _, m := range messages {
+		msgCtx := rabbitmq.WithCorrelationID(ctx, m.CorrelationID)
+		log := s.logger(msgCtx).With(slog.Int64("outbox_id", m.ID), slog.String("routing_key", m.RoutingKey))
+		if err := s.publishTo(msgCtx, channel, m.RoutingKey, json.RawMessage(m.Payload)); err != nil {
+			stats.Failed++
+			retryAt := now.Add(s.outboxRetryDelay(m.Attempts))
+			log.Error("failed to publish notification, will retry",
+				slog.Int("attempts", m.Attempts+1), slog.Time("retry_at", retryAt), sl.Err(err))
+			if err := s.repo.MarkNotificationFailed(ctx, m.ID, err.Error(), retryAt); err != nil {
+				log.Error("failed to mark notification failed", sl.Err(err))
 			}
+			continue
+		}
+		// Если отметка не сохранится, уведомление будет опубликовано повторно
+		if err := s.repo.MarkNotificationSent(ctx, m.ID); err != nil {
+			log.Error("failed to mark notification sent", sl.Err(err))
+		}
+		stats.Published++
+	}
+}
+
+// outboxRetryDelay возвращает паузу перед следующей попыткой публикации после attempts
+// неудавшихся: период релея, удваиваемый с каждой попыткой, но не больше outboxMaxRetryDelay.
+func (s *SchedulerService) outboxRetryDelay(attempts int) time.Duration {
+	delay := s.cfg.OutboxRelayInterval
+	for range attempts {
+		if delay >= outboxMaxRetryDelay {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, outboxMaxRetryDelay)
+}
+
+// recordRun сохраняет метрики прохода задачи и пишет итог прохода в лог.
+// Проход, который ничего не нашел, пишется на уровне debug, чтобы не засорять лог.
+func (s *SchedulerService) recordRun(stats RunStats) {
+	s.metrics.RecordRun(stats)
+	logRun := s.log.Info
+	if stats.Found == 0 {
+		logRun = s.log.Debug
+	}
+	logRun("scheduler run finished",
+		slog.String("job", stats.Job),
+		slog.Int("found", stats.Found),
+		slog.Int("queued", stats.Queued),
+		slog.Int("published", stats.Published),
+		slog.Int("failed", stats.Failed),
+	)
+}
+
+// MonitorDeadLetterQueue с периодом scheduler.dead_letter_check_interval проверяет размер
+// очереди scheduler.dead_letter_queue до отмены ctx. Если очередь не задана, проверка не запускается.
+func (s *SchedulerService) MonitorDeadLetterQueue(ctx context.Context, inspector QueueInspector) {
+	if s.cfg.DeadLetterQueue == "" {
+		s.log.Info("dead letter queue is not configured, skipping monitoring")
+		return
+	}
+	s.checkDeadLetterQueue(inspector)
+
+	ticker := time.NewTicker(s.cfg.DeadLetterCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkDeadLetterQueue(inspector)
 		}
-		s.log.Info("success to publish all messages")
-	} else {
-		s.log.Info("channel is nil, skipping message publishing")
 	}
 }
 
-// FindExpiringSubscriptionsDueToday находит подписки, истекающие сегодня.
-func (s *SchedulerService) FindExpiringSubscriptionsDueToday(ctx context.Context, channel *amqp.Channel) {
-	s.runFindExpiringTrialPeriod(ctx, channel)
+// checkDeadLetterQueue записывает размер очереди недоставленных сообщений в метрику
+// scheduler_queue_messages и предупреждает в логе, если сообщений не меньше
+// scheduler.dead_letter_threshold: сообщения копятся там при постоянных сбоях обработки.
+// Порог не меньше 1, это проверяет config.Validate.
+// Возвращает true, если предупреждение было записано.
+func (s *SchedulerService) checkDeadLetterQueue(inspector QueueInspector) bool {
+	queue := s.cfg.DeadLetterQueue
+	messages, err := inspector.QueueMessages(queue)
+	if err != nil {
+		s.log.Error("failed to inspect dead letter queue", slog.String("queue", queue), sl.Err(err))
+		return false
+	}
+	s.metrics.RecordQueueDepth(queue, messages)
+	if messages < s.cfg.DeadLetterThreshold {
+		return false
+	}
+	s.log.Warn("dead letter queue is growing, messages keep failing",
+		slog.String("queue", queue),
+		slog.Int("messages", messages),
+		slog.Int("threshold", s.cfg.DeadLetterThreshold))
+	return true
+}
+
+// ConvertEndedTrials раз в сутки переводит пользователей с закончившимся пробным
+// периодом в оплаченную подписку или в истекший статус. События об окончании
+// пробного периода записываются в outbox и публикуются релеем.
+func (s *SchedulerService) ConvertEndedTrials(ctx context.Context) {
+	s.runConvertEndedTrials(ctx)
 
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(s.cfg.TrialConversionInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		s.runFindExpiringTrialPeriod(ctx, channel)
+		s.runConvertEndedTrials(ctx)
 	}
 }
 
-func (s *SchedulerService) runFindExpiringTrialPeriod(ctx context.Context, channel *amqp.Channel) {
-	s.log.Info("starting service to find expiring trial period for subscription")
-	entriesInfo, err := s.repo.FindSubscriptionExpiringToday(ctx)
+func (s *SchedulerService) runConvertEndedTrials(ctx context.Context) {
+	ctx = withRunID(ctx)
+	log := s.logger(ctx)
+	log.Debug("starting service to convert ended trial periods")
+	users, err := s.repo.FindEndedTrials(ctx)
 	if err != nil {
-		s.log.Error("failed to find entries", sl.Err(err))
+		log.Error("failed to find ended trials", sl.Err(err))
 		return
 	}
-	if len(entriesInfo) == 0 {
-		s.log.Info("no expiring trial period subscriptions found")
+	if len(users) == 0 {
+		log.Debug("no ended trial periods found")
 		return
 	}
-	s.log.Info("found expiring subscriptions", "count", len(entriesInfo))
-	if channel != nil {
-		for _, entryInfo := range entriesInfo {
-			err = rabbitmq.PublishMessage(channel, "notifications", "subscription.trial.expiring", entryInfo)
-			if err != nil {
-				s.log.Error("failed to publish message", sl.Err(err))
-			}
+	log.Info("found ended trial periods", "count", len(users))
+	for _, user := range users {
+		s.convertTrial(ctx, user)
+	}
+}
+
+// convertTrial списывает стоимость тарифа пользователя с последнего сохраненного токена
+// и при успехе активирует подписку. Если токена нет или провайдер отклонил платеж, пробный период
+// завершается. При ошибке провайдера или незавершенном платеже статус не меняется:
+// пользователь будет обработан при следующем запуске или по webhook-уведомлению.
+func (s *SchedulerService) convertTrial(ctx context.Context, user *models.User) {
+	log := s.logger(ctx).With(slog.String("user_uid", user.UUID))
+
+	tokens, err := s.repo.ListPaymentTokens(ctx, user.UUID)
+	if err != nil {
+		log.Error("failed to list payment tokens", sl.Err(err))
+		return
+	}
+	if len(tokens) == 0 {
+		log.Info("no saved payment token, expiring trial")
+		s.expireTrial(ctx, user)
+		return
+	}
+
+	plan, err := s.repo.GetUserPlan(ctx, user.UUID)
+	if err != nil {
+		log.Error("failed to get user plan", sl.Err(err))
+		return
+	}
+
+	token := tokens[len(tokens)-1]
+	req := yookassa.CreatePaymentRequest{
+		PaymentToken: token.Token,
+		Metadata: map[string]string{
+			"user_uid":                    user.UUID,
+			"payment_token_id":            strconv.Itoa(token.ID),
+			"plan_id":                     strconv.Itoa(plan.ID),
+			models.PaymentMetadataPurpose: models.PaymentPurposeTrialConversion,
+		},
+		// Повторный запуск для того же пользователя не должен создать второе списание
+		IdempotenceKey: "trial-conversion:" + user.UUID,
+	}
+	req.Amount.Value = money.FormatDecimal(plan.Price)
+	req.Amount.Currency = plan.Currency
+
+	resp, err := s.provider.CreatePayment(req)
+	if err != nil {
+		log.Error("failed to charge trial conversion", sl.Err(err))
+		return
+	}
+
+	switch resp.Status {
+	case models.PaymentStatusSucceeded:
+		if err := s.repo.ActivateTrialSubscription(ctx, user.UUID); err != nil {
+			log.Error("failed to activate subscription", sl.Err(err))
+			return
 		}
-		s.log.Info("success to publish all messages")
-	} else {
-		s.log.Info("channel is nil, skipping message publishing")
+		log.Info("trial converted to paid subscription", slog.String("payment_id", resp.ID))
+	case paymentStatusCanceled:
+		log.Info("trial conversion payment canceled, expiring trial", slog.String("payment_id", resp.ID))
+		s.expireTrial(ctx, user)
+	default:
+		log.Info("trial conversion payment is pending", slog.String("payment_id", resp.ID), slog.String("status", resp.Status))
 	}
 }
 
+// expireTrial переводит пользователя в статус expired и в той же транзакции записывает
+// в outbox событие об окончании пробного периода: событие не теряется, если статус изменен,
+// и не отправляется, если статус изменить не удалось.
+func (s *SchedulerService) expireTrial(ctx context.Context, user *models.User) {
+	log := s.logger(ctx).With(slog.String("user_uid", user.UUID))
+	message, err := newOutboxMessage(ctx, rabbitmq.RoutingKeyTrialExpired, user.UUID, user, user.UUID)
+	if err != nil {
+		log.Error("failed to marshal notification", slog.String("routing_key", rabbitmq.RoutingKeyTrialExpired), sl.Err(err))
+		return
+	}
+	expired, err := s.repo.ExpireTrial(ctx, user.UUID, message)
+	if err != nil {
+		log.Error("failed to expire trial", sl.Err(err))
+		return
+	}
+	if !expired {
+		log.Info("user is no longer on trial, skipping expiry")
+	}
+}
+
+// ExpireLapsedSubscriptions с периодом scheduler.expiry_interval до отмены ctx переводит
+// в статус expired пользователей, оплаченный срок подписки которых закончился больше
+// scheduler.expiry_grace_period назад, и в той же транзакции записывает уведомления о них в outbox.
+func (s *SchedulerService) ExpireLapsedSubscriptions(ctx context.Context) {
+	s.runExpireLapsedSubscriptions(ctx)
+
+	ticker := time.NewTicker(s.cfg.ExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runExpireLapsedSubscriptions(ctx)
+		}
+	}
+}
+
+// runExpireLapsedSubscriptions выполняет один проход перевода истекших подписок.
+// Статусы и уведомления записываются одной транзакцией: если outbox недоступен,
+// пользователи не отключаются до следующего прохода.
+func (s *SchedulerService) runExpireLapsedSubscriptions(ctx context.Context) RunStats {
+	ctx = withRunID(ctx)
+	log := s.logger(ctx)
+	stats := RunStats{Job: jobExpireLapsed}
+	cutoff := s.clock.Now().Add(-s.cfg.ExpiryGracePeriod)
+	users, queued, err := s.repo.ExpireLapsedSubscriptions(ctx, cutoff, func(u *models.User) (models.OutboxMessage, error) {
+		return newOutboxMessage(ctx, rabbitmq.RoutingKeySubscriptionExpired, u.UUID, u, lapsedDedupKey(u))
+	})
+	if err != nil {
+		log.Error("failed to expire lapsed subscriptions", sl.Err(err))
+		return stats
+	}
+	stats.Found = len(users)
+	stats.Queued = queued
+	defer func() { s.recordRun(stats) }()
+	if len(users) == 0 {
+		log.Debug("no lapsed subscriptions found", slog.Time("cutoff", cutoff))
+		return stats
+	}
+	log.Info("lapsed subscriptions expired", slog.Int("count", len(users)), slog.Time("cutoff", cutoff))
+	return stats
+}
+
+// lapsedDedupKey возвращает ключ дедупликации уведомления об окончании подписки пользователя u.
+// Пользователь, который продлит подписку и снова ее не оплатит, получит новое уведомление.
+func lapsedDedupKey(u *models.User) string {
+	if u.SubscriptionExpire == nil {
+		return u.UUID
+	}
+	return u.UUID + ":" + u.SubscriptionExpire.Format(models.EntryInfoDateLayout)
+}
+
+// publishTo публикует message в exchange уведомлений с ключом routingKey
+// и идентификатором корреляции из ctx.
+// Если channel равен nil, ничего не публикует и возвращает errNoChannel.
+func (s *SchedulerService) publishTo(ctx context.Context, channel *amqp.Channel, routingKey string, message any) error {
+	if channel == nil {
+		return errNoChannel
+	}
+	return s.publish(ctx, channel, rabbitmq.NotificationsExchange, routingKey, message)
+}
+
+// withRunID возвращает контекст прохода задачи с новым идентификатором корреляции.
+// Он записывается в логи прохода и в уведомления, которые проход отправляет, поэтому
+// логи обработки уведомления в sender связываются с проходом планировщика.
+func withRunID(ctx context.Context) context.Context {
+	return rabbitmq.WithCorrelationID(ctx, uuid.NewString())
+}
+
+// logger возвращает логгер сервиса с идентификатором корреляции из ctx, если он есть.
+func (s *SchedulerService) logger(ctx context.Context) *slog.Logger {
+	if id := rabbitmq.CorrelationID(ctx); id != "" {
+		return s.log.With(slog.String("correlation_id", id))
+	}
+	return s.log
+}
+
 // FindOldNextPaymentDate находит записи со старыми датами следующего платежа.
 func (s *SchedulerService) FindOldNextPaymentDate(ctx context.Context) {
 	s.runFindOldNextPaymentDate(ctx)
 
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(s.cfg.PaymentDateInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
@@ -130,26 +745,28 @@ func (s *SchedulerService) FindOldNextPaymentDate(ctx context.Context) {
 }
 
 func (s *SchedulerService) runFindOldNextPaymentDate(ctx context.Context) {
-	s.log.Info("starting worker which updates next payment date")
+	s.log.Debug("starting worker which updates next payment date")
 	entriesInfo, err := s.repo.FindOldNextPaymentDate(ctx)
 	if err != nil {
 		s.log.Error("failed to find entries", sl.Err(err))
 		return
 	}
 	if len(entriesInfo) == 0 {
-		s.log.Info("all entrys are up to date")
-	} else {
-		s.log.Info("outdated next payment dates found")
+		s.log.Debug("all entrys are up to date")
+		return
 	}
+	s.log.Info("outdated next payment dates found", slog.Int("count", len(entriesInfo)))
+	failed := 0
 	for _, entryInfo := range entriesInfo {
-		current := entryInfo.NextPaymentDate
-		newDate := current.AddDate(0, 1, 0)
-		entryInfo.NextPaymentDate = newDate
+		// Дата сдвигается на месяц от прошлой даты платежа, а не пересчитывается от начала
+		// подписки, как в RecomputePaymentDates
+		entryInfo.NextPaymentDate = entryInfo.NextPaymentDate.AddDate(0, 1, 0)
 		id, err := s.repo.UpdateNextPaymentDate(ctx, entryInfo)
 		if err != nil {
 			s.log.Error("failed to update next payment date",
 				slog.Int("id", id),
 				sl.Err(err))
+			failed++
 			continue
 		}
 		cacheKey := fmt.Sprintf("subscription:%d", id)
@@ -157,5 +774,48 @@ func (s *SchedulerService) runFindOldNextPaymentDate(ctx context.Context) {
 			s.log.Warn("failed to cache subscription", slog.String("key", cacheKey), sl.Err(err))
 		}
 	}
-	s.log.Info("success to update")
+	s.log.Info("next payment dates updated",
+		slog.Int("updated", len(entriesInfo)-failed),
+		slog.Int("failed", failed))
+}
+
+// ArchiveInactiveSubscriptions периодически архивирует подписки, закончившиеся раньше срока хранения.
+func (s *SchedulerService) ArchiveInactiveSubscriptions(ctx context.Context) {
+	s.runArchiveInactiveSubscriptions(ctx)
+
+	ticker := time.NewTicker(s.cfg.ArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runArchiveInactiveSubscriptions(ctx)
+		}
+	}
+}
+
+// runArchiveInactiveSubscriptions архивирует пачками подписки, срок которых закончился
+// или которые удалены больше scheduler.archive_retention назад.
+func (s *SchedulerService) runArchiveInactiveSubscriptions(ctx context.Context) int {
+	cutoff := s.clock.Now().Add(-s.cfg.ArchiveRetention)
+	total := 0
+	for {
+		archived, err := s.repo.ArchiveInactiveEntrys(ctx, cutoff, archiveBatchSize)
+		if err != nil {
+			s.log.Error("failed to archive subscriptions", slog.Int("archived", total), sl.Err(err))
+			return total
+		}
+		total += archived
+		if archived < archiveBatchSize {
+			break
+		}
+	}
+	if total == 0 {
+		s.log.Debug("no inactive subscriptions to archive", slog.Time("cutoff", cutoff))
+		return total
+	}
+	s.log.Info("inactive subscriptions archived", slog.Int("count", total), slog.Time("cutoff", cutoff))
+	return total
 }
@@ -2,13 +2,20 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/yookassa"
 	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,16 +25,19 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) FindSubscriptionExpiringTomorrow(ctx context.Context) ([]*models.EntryInfo, error) {
-	args := m.Called(ctx)
+// Убеждаемся, что MockRepository реализует интерфейс SubscriptionRepository
+var _ SubscriptionRepository = (*MockRepository)(nil)
+
+func (m *MockRepository) FindSubscriptionsDueReminder(ctx context.Context, today time.Time, defaultDays []int) ([]*models.EntryInfo, error) {
+	args := m.Called(ctx, today, defaultDays)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.EntryInfo), args.Error(1)
 }
 
-func (m *MockRepository) FindSubscriptionExpiringToday(ctx context.Context) ([]*models.User, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) FindSubscriptionExpiringToday(ctx context.Context, today time.Time) ([]*models.User, error) {
+	args := m.Called(ctx, today)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -47,6 +57,89 @@ func (m *MockRepository) UpdateNextPaymentDate(ctx context.Context, entry *model
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepository) FindEndedTrials(ctx context.Context) ([]*models.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockRepository) ExpireLapsedSubscriptions(ctx context.Context, cutoff time.Time,
+	notification func(*models.User) (models.OutboxMessage, error)) ([]*models.User, int, error) {
+	args := m.Called(ctx, cutoff, notification)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PaymentToken), args.Error(1)
+}
+
+func (m *MockRepository) ActivateTrialSubscription(ctx context.Context, userUID string) error {
+	args := m.Called(ctx, userUID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ExpireTrial(ctx context.Context, userUID string, message models.OutboxMessage) (bool, error) {
+	args := m.Called(ctx, userUID, message)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error) {
+	args := m.Called(ctx, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Plan), args.Error(1)
+}
+
+func (m *MockRepository) ArchiveInactiveEntrys(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	args := m.Called(ctx, cutoff, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) EnqueueNotifications(ctx context.Context, messages []models.OutboxMessage) (int, error) {
+	args := m.Called(ctx, messages)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ClaimPendingNotifications(ctx context.Context, now, claimUntil time.Time, limit int) ([]*models.OutboxMessage, error) {
+	args := m.Called(ctx, now, claimUntil, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OutboxMessage), args.Error(1)
+}
+
+func (m *MockRepository) MarkNotificationSent(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkNotificationFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, lastError, nextAttemptAt)
+	return args.Error(0)
+}
+
+type MockProvider struct {
+	mock.Mock
+}
+
+func (m *MockProvider) CreatePayment(reqParams yookassa.CreatePaymentRequest) (*yookassa.CreatePaymentResponse, error) {
+	args := m.Called(reqParams)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*yookassa.CreatePaymentResponse), args.Error(1)
+}
+
 type MockCache struct {
 	mock.Mock
 }
@@ -73,12 +166,33 @@ func newNoopLogger() *slog.Logger {
 func TestSchedulerService_runFindExpiringSubscriptionsDueTomorrow(t *testing.T) {
 	now := time.Now()
 	entryInfo := &models.EntryInfo{
-		Email:       "test@example.com",
-		Username:    "testuser",
-		ServiceName: "Netflix",
-		EndDate:     now.Add(24 * time.Hour),
-		Price:       500,
+		SubscriptionID: 42,
+		UserUID:        "user123",
+		Email:          "test@example.com",
+		Username:       "testuser",
+		ServiceName:    "Netflix",
+		EndDate:        now.Add(24 * time.Hour),
+		Price:          500,
+		DaysBefore:     1,
 	}
+	payload, err := json.Marshal(entryInfo)
+	assert.NoError(t, err)
+	outbox := []models.OutboxMessage{{
+		RoutingKey:      rabbitmq.RoutingKeySubscriptionExpiring,
+		Payload:         payload,
+		DedupKey:        rabbitmq.RoutingKeySubscriptionExpiring + ":42:" + entryInfo.EndDate.Format(models.EntryInfoDateLayout) + ":1",
+		UserUID:         "user123",
+		SubscriptionIDs: []int{42},
+	}}
+	// Идентификатор корреляции прохода случайный: проверяется только, что он проставлен
+	matchOutbox := mock.MatchedBy(func(got []models.OutboxMessage) bool {
+		if len(got) != len(outbox) || got[0].CorrelationID == "" {
+			return false
+		}
+		m := got[0]
+		m.CorrelationID = ""
+		return assert.ObjectsAreEqual(outbox, []models.OutboxMessage{m})
+	})
 
 	tests := []struct {
 		name          string
@@ -89,30 +203,30 @@ func TestSchedulerService_runFindExpiringSubscriptionsDueTomorrow(t *testing.T)
 		{
 			name: "success - found expiring subscriptions",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringTomorrow", mock.Anything).Return([]*models.EntryInfo{entryInfo}, nil).Once()
-				// Не ожидаем Publish, так как канал nil
+				r.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return([]*models.EntryInfo{entryInfo}, nil).Once()
+				r.On("EnqueueNotifications", mock.Anything, matchOutbox).Return(1, nil).Once()
 			},
 			expectedError: false,
 		},
 		{
 			name: "success - no expiring subscriptions",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringTomorrow", mock.Anything).Return([]*models.EntryInfo{}, nil).Once()
+				r.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return([]*models.EntryInfo{}, nil).Once()
 			},
 			expectedError: false,
 		},
 		{
 			name: "repository error",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringTomorrow", mock.Anything).Return(nil, errors.New("db error")).Once()
+				r.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return(nil, errors.New("db error")).Once()
 			},
 			expectedError: false, // метод не возвращает ошибку, только логирует
 		},
 		{
-			name: "publish error",
+			name: "outbox error",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringTomorrow", mock.Anything).Return([]*models.EntryInfo{entryInfo}, nil).Once()
-				// Не ожидаем Publish, так как канал nil
+				r.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return([]*models.EntryInfo{entryInfo}, nil).Once()
+				r.On("EnqueueNotifications", mock.Anything, matchOutbox).Return(0, errors.New("db error")).Once()
 			},
 			expectedError: false, // метод не возвращает ошибку, только логирует
 		},
@@ -123,13 +237,12 @@ func TestSchedulerService_runFindExpiringSubscriptionsDueTomorrow(t *testing.T)
 			repo := new(MockRepository)
 			cache := new(MockCache)
 			channel := new(MockChannel)
-			service := NewSchedulerService(repo, cache, newNoopLogger())
+			service := NewSchedulerService(repo, cache, nil, nil, config.Scheduler{}, newNoopLogger())
 
 			tt.setupMocks(repo, channel)
 
 			// Вызываем приватный метод через публичный
-			// Создаем реальный канал для тестирования
-			service.runFindExpiringSubscriptionsDueTomorrow(context.Background(), nil)
+			service.runFindExpiringSubscriptionsDueTomorrow(context.Background())
 
 			repo.AssertExpectations(t)
 			channel.AssertExpectations(t)
@@ -138,6 +251,8 @@ func TestSchedulerService_runFindExpiringSubscriptionsDueTomorrow(t *testing.T)
 }
 
 func TestSchedulerService_runFindExpiringTrialPeriod(t *testing.T) {
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	today := "2025-07-01"
 	user := &models.User{
 		UUID:     "user123",
 		Email:    "test@example.com",
@@ -153,30 +268,34 @@ func TestSchedulerService_runFindExpiringTrialPeriod(t *testing.T) {
 		{
 			name: "success - found expiring trial subscriptions",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringToday", mock.Anything).Return([]*models.User{user}, nil).Once()
-				// Не ожидаем Publish, так как канал nil
+				r.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+					return len(m) == 1 && m[0].RoutingKey == rabbitmq.RoutingKeyTrialExpiring &&
+						m[0].DedupKey == rabbitmq.RoutingKeyTrialExpiring+":user123:"+today &&
+						m[0].UserUID == "user123"
+				})).Return(1, nil).Once()
 			},
 			expectedError: false,
 		},
 		{
 			name: "success - no expiring trial subscriptions",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringToday", mock.Anything).Return([]*models.User{}, nil).Once()
+				r.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return([]*models.User{}, nil).Once()
 			},
 			expectedError: false,
 		},
 		{
 			name: "repository error",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringToday", mock.Anything).Return(nil, errors.New("db error")).Once()
+				r.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
 			},
 			expectedError: false, // метод не возвращает ошибку, только логирует
 		},
 		{
-			name: "publish error",
+			name: "outbox error",
 			setupMocks: func(r *MockRepository, _ *MockChannel) {
-				r.On("FindSubscriptionExpiringToday", mock.Anything).Return([]*models.User{user}, nil).Once()
-				// Не ожидаем Publish, так как канал nil
+				r.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("EnqueueNotifications", mock.Anything, mock.Anything).Return(0, errors.New("db error")).Once()
 			},
 			expectedError: false, // метод не возвращает ошибку, только логирует
 		},
@@ -187,13 +306,12 @@ func TestSchedulerService_runFindExpiringTrialPeriod(t *testing.T) {
 			repo := new(MockRepository)
 			cache := new(MockCache)
 			channel := new(MockChannel)
-			service := NewSchedulerService(repo, cache, newNoopLogger())
+			service := NewSchedulerService(repo, cache, nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
 
 			tt.setupMocks(repo, channel)
 
 			// Вызываем приватный метод через публичный
-			// Создаем реальный канал для тестирования
-			service.runFindExpiringTrialPeriod(context.Background(), nil)
+			service.runFindExpiringTrialPeriod(context.Background())
 
 			repo.AssertExpectations(t)
 			channel.AssertExpectations(t)
@@ -201,6 +319,457 @@ func TestSchedulerService_runFindExpiringTrialPeriod(t *testing.T) {
 	}
 }
 
+func TestSchedulerService_ReminderDaysBefore(t *testing.T) {
+	endDate := time.Date(2025, 7, 8, 0, 0, 0, 0, time.UTC)
+	// Пользователь first выбрал срок 7 дней, second получает напоминание по глобальному списку
+	entries := []*models.EntryInfo{
+		{SubscriptionID: 1, Username: "first", EndDate: endDate, DaysBefore: 7},
+		{SubscriptionID: 2, Username: "second", EndDate: endDate, DaysBefore: 3},
+	}
+
+	repo := new(MockRepository)
+	repo.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{3, 1}).Return(entries, nil).Once()
+	repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+		return len(m) == 2 &&
+			m[0].DedupKey == rabbitmq.RoutingKeySubscriptionExpiring+":1:2025-07-08:7" &&
+			m[1].DedupKey == rabbitmq.RoutingKeySubscriptionExpiring+":2:2025-07-08:3" &&
+			strings.Contains(string(m[0].Payload), `"days_before":7`)
+	})).Return(2, nil).Once()
+	service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{ReminderDaysBefore: []int{3, 1}}, newNoopLogger())
+
+	service.runFindExpiringSubscriptionsDueTomorrow(context.Background())
+
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulerService_ReminderDigest(t *testing.T) {
+	endDate := time.Date(2025, 7, 8, 0, 0, 0, 0, time.UTC)
+	// Пользователь digest выбрал дайджест и получает одно письмо о двух подписках
+	entries := []*models.EntryInfo{
+		{SubscriptionID: 1, UserUID: "uid-digest", Username: "digest", ServiceName: "Spotify", EndDate: endDate, Currency: "USD", DaysBefore: 3, Digest: true},
+		{SubscriptionID: 2, UserUID: "uid-single", Username: "single", ServiceName: "Okko", EndDate: endDate, DaysBefore: 3},
+		{SubscriptionID: 3, UserUID: "uid-digest", Username: "digest", ServiceName: "Netflix", EndDate: endDate, Currency: "RUB", DaysBefore: 1, Digest: true},
+	}
+
+	repo := new(MockRepository)
+	service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{ReminderDaysBefore: []int{3, 1}}, newNoopLogger())
+	today := service.clock.Now().Format(models.EntryInfoDateLayout)
+	repo.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{3, 1}).Return(entries, nil).Once()
+	repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+		return len(m) == 1 && m[0].RoutingKey == rabbitmq.RoutingKeySubscriptionExpiring &&
+			m[0].DedupKey == rabbitmq.RoutingKeySubscriptionExpiring+":2:2025-07-08:3"
+	})).Return(1, nil).Once()
+	repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+		if len(m) != 1 || m[0].RoutingKey != rabbitmq.RoutingKeyReminderDigest ||
+			m[0].DedupKey != rabbitmq.RoutingKeyReminderDigest+":uid-digest:"+today {
+			return false
+		}
+		var digest models.ReminderDigest
+		if err := json.Unmarshal(m[0].Payload, &digest); err != nil {
+			return false
+		}
+		return len(digest.Subscriptions) == 2 &&
+			digest.Subscriptions[0].ServiceName == "Netflix" && digest.Subscriptions[0].Currency == "RUB" &&
+			digest.Subscriptions[1].ServiceName == "Spotify" && digest.Subscriptions[1].Currency == "USD"
+	})).Return(1, nil).Once()
+
+	stats, err := service.scanExpiringSubscriptions(context.Background(), service.clock.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Found)
+	assert.Equal(t, 2, stats.Queued)
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulerService_MinDaysBetweenNotifications(t *testing.T) {
+	endDate := time.Date(2025, 7, 8, 0, 0, 0, 0, time.UTC)
+	cfg := config.Scheduler{ReminderDaysBefore: []int{3, 1}, MinDaysBetweenNotifications: 3}
+
+	t.Run("второе окно напоминания в пределах промежутка пропускается", func(t *testing.T) {
+		// Напоминание за 3 дня записано 5 июля, за 1 день подошло 7 июля — через 2 дня
+		now := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+		notifiedAt := time.Date(2025, 7, 5, 9, 0, 0, 0, time.UTC)
+		entries := []*models.EntryInfo{
+			{SubscriptionID: 1, Username: "first", EndDate: endDate, DaysBefore: 1, LastNotifiedAt: &notifiedAt},
+		}
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, now, []int{3, 1}).Return(entries, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		stats, err := service.scanExpiringSubscriptions(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RunStats{Job: jobExpiringTomorrow, Found: 1}, stats)
+		repo.AssertNotCalled(t, "EnqueueNotifications", mock.Anything, mock.Anything)
+	})
+
+	t.Run("после промежутка и без прошлых уведомлений напоминание отправляется", func(t *testing.T) {
+		now := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+		longAgo := time.Date(2025, 7, 4, 23, 0, 0, 0, time.UTC)
+		entries := []*models.EntryInfo{
+			{SubscriptionID: 1, Username: "first", EndDate: endDate, DaysBefore: 1, LastNotifiedAt: &longAgo},
+			{SubscriptionID: 2, UserUID: "uid", Username: "second", EndDate: endDate, DaysBefore: 1, Digest: true},
+		}
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, now, []int{3, 1}).Return(entries, nil).Once()
+		repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+			return len(m) == 1 && m[0].RoutingKey == rabbitmq.RoutingKeySubscriptionExpiring &&
+				assert.ObjectsAreEqual([]int{1}, m[0].SubscriptionIDs)
+		})).Return(1, nil).Once()
+		repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+			return len(m) == 1 && m[0].RoutingKey == rabbitmq.RoutingKeyReminderDigest &&
+				assert.ObjectsAreEqual([]int{2}, m[0].SubscriptionIDs)
+		})).Return(1, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		stats, err := service.scanExpiringSubscriptions(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.Queued)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("без ограничения напоминания не пропускаются", func(t *testing.T) {
+		now := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+		entries := []*models.EntryInfo{
+			{SubscriptionID: 1, Username: "first", EndDate: endDate, DaysBefore: 1, LastNotifiedAt: &now},
+		}
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, now, []int{3, 1}).Return(entries, nil).Once()
+		repo.On("EnqueueNotifications", mock.Anything, mock.Anything).Return(1, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now),
+			config.Scheduler{ReminderDaysBefore: []int{3, 1}}, newNoopLogger())
+
+		stats, err := service.scanExpiringSubscriptions(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, stats.Queued)
+		repo.AssertExpectations(t)
+	})
+}
+
+type recorderStub struct {
+	runs   []RunStats
+	depths map[string]int
+}
+
+func (r *recorderStub) RecordRun(stats RunStats) {
+	r.runs = append(r.runs, stats)
+}
+
+func (r *recorderStub) RecordQueueDepth(queue string, messages int) {
+	if r.depths == nil {
+		r.depths = make(map[string]int)
+	}
+	r.depths[queue] = messages
+}
+
+func TestSchedulerService_RunStats(t *testing.T) {
+	entries := []*models.EntryInfo{
+		{SubscriptionID: 1, ServiceName: "Netflix", Username: "first"},
+		{SubscriptionID: 2, ServiceName: "Spotify", Username: "second"},
+		{SubscriptionID: 3, ServiceName: "Okko", Username: "third"},
+	}
+	users := []*models.User{{UUID: "user123"}, {UUID: "user456"}}
+
+	repo := new(MockRepository)
+	repo.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return(entries, nil).Once()
+	repo.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return(users, nil).Once()
+	// Одно из уведомлений о завтрашних подписках уже было записано предыдущим проходом
+	repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+		return len(m) == 3
+	})).Return(2, nil).Once()
+	repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+		return len(m) == 2
+	})).Return(0, errors.New("db error")).Once()
+	service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{}, newNoopLogger())
+	recorder := &recorderStub{}
+	service.metrics = recorder
+
+	service.runFindExpiringSubscriptionsDueTomorrow(context.Background())
+	service.runFindExpiringTrialPeriod(context.Background())
+
+	assert.Equal(t, []RunStats{
+		{Job: jobExpiringTomorrow, Found: 3, Queued: 2},
+		{Job: jobExpiringToday, Found: 2, Failed: 2},
+	}, recorder.runs)
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulerService_EmptyRunsLogNothingAtInfo(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return([]*models.EntryInfo{}, nil).Once()
+	repo.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return([]*models.User{}, nil).Once()
+	repo.On("FindEndedTrials", mock.Anything).Return([]*models.User{}, nil).Once()
+	repo.On("FindOldNextPaymentDate", mock.Anything).Return([]*models.Entry{}, nil).Once()
+	repo.On("ArchiveInactiveEntrys", mock.Anything, mock.Anything, archiveBatchSize).Return(0, nil).Once()
+
+	var logBuffer strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logBuffer, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{}, logger)
+	service.metrics = &recorderStub{}
+
+	service.runFindExpiringSubscriptionsDueTomorrow(context.Background())
+	service.runFindExpiringTrialPeriod(context.Background())
+	service.runConvertEndedTrials(context.Background())
+	service.runFindOldNextPaymentDate(context.Background())
+	service.runArchiveInactiveSubscriptions(context.Background())
+
+	// Проходы без работы пишутся только на уровне debug
+	assert.Empty(t, logBuffer.String())
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulerService_RunStatsFindError(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("FindSubscriptionsDueReminder", mock.Anything, mock.Anything, []int{1}).Return([]*models.EntryInfo{}, nil).Once()
+	repo.On("FindSubscriptionExpiringToday", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+	service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{}, newNoopLogger())
+	recorder := &recorderStub{}
+	service.metrics = recorder
+
+	service.runFindExpiringSubscriptionsDueTomorrow(context.Background())
+	service.runFindExpiringTrialPeriod(context.Background())
+
+	// Проход с ошибкой поиска не записывается; без найденных подписок outbox не трогается
+	assert.Equal(t, []RunStats{{Job: jobExpiringTomorrow}}, recorder.runs)
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulerService_ReplayNotifications(t *testing.T) {
+	now := time.Date(2025, 7, 10, 9, 0, 0, 0, time.UTC)
+	day := time.Date(2025, 7, 8, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2025, 7, 9, 0, 0, 0, 0, time.UTC)
+	entries := []*models.EntryInfo{
+		{SubscriptionID: 1, Username: "first", EndDate: endDate, DaysBefore: 1},
+		{SubscriptionID: 2, Username: "second", EndDate: endDate, DaysBefore: 1},
+	}
+	users := []*models.User{{UUID: "user123"}}
+
+	t.Run("уведомления за день записываются в outbox, уже отправленные пропускаются", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, day, []int{1}).Return(entries, nil).Once()
+		// Напоминание о подписке 1 было отправлено до сбоя, поэтому outbox принимает только одно
+		repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+			return len(m) == 2 &&
+				m[0].DedupKey == rabbitmq.RoutingKeySubscriptionExpiring+":1:2025-07-09:1" &&
+				m[1].DedupKey == rabbitmq.RoutingKeySubscriptionExpiring+":2:2025-07-09:1"
+		})).Return(1, nil).Once()
+		repo.On("FindSubscriptionExpiringToday", mock.Anything, day).Return(users, nil).Once()
+		repo.On("EnqueueNotifications", mock.Anything, mock.MatchedBy(func(m []models.OutboxMessage) bool {
+			return len(m) == 1 && m[0].DedupKey == rabbitmq.RoutingKeyTrialExpiring+":user123:2025-07-08"
+		})).Return(1, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+
+		got, err := service.ReplayNotifications(context.Background(), time.Date(2025, 7, 8, 15, 30, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, &models.NotificationReplayResult{
+			Date:          "2025-07-08",
+			Reminders:     models.NotificationReplayStats{Found: 2, Queued: 1, Skipped: 1},
+			TrialExpiring: models.NotificationReplayStats{Found: 1, Queued: 1},
+		}, got)
+		// Повтор не сдвигает часы самого планировщика
+		assert.Equal(t, now, service.clock.Now())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("повтор того же дня ничего не добавляет", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, day, []int{1}).Return(entries, nil).Once()
+		repo.On("FindSubscriptionExpiringToday", mock.Anything, day).Return(users, nil).Once()
+		repo.On("EnqueueNotifications", mock.Anything, mock.Anything).Return(0, nil).Twice()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+
+		got, err := service.ReplayNotifications(context.Background(), day)
+		assert.NoError(t, err)
+		assert.Equal(t, models.NotificationReplayStats{Found: 2, Skipped: 2}, got.Reminders)
+		assert.Equal(t, models.NotificationReplayStats{Found: 1, Skipped: 1}, got.TrialExpiring)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("дата из будущего отклоняется", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+
+		_, err := service.ReplayNotifications(context.Background(), now.AddDate(0, 0, 1))
+		assert.ErrorIs(t, err, models.ErrReplayDateInFuture)
+		repo.AssertNotCalled(t, "FindSubscriptionsDueReminder", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка поиска возвращается", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("FindSubscriptionsDueReminder", mock.Anything, day, []int{1}).Return(nil, errors.New("db error")).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+
+		_, err := service.ReplayNotifications(context.Background(), day)
+		assert.Error(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSchedulerService_runRelayOutbox(t *testing.T) {
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	message := &models.OutboxMessage{
+		ID:         7,
+		RoutingKey: rabbitmq.RoutingKeySubscriptionExpiring,
+		Payload:    []byte(`{"username":"first"}`),
+		DedupKey:   rabbitmq.RoutingKeySubscriptionExpiring + ":1:2025-07-02",
+		// Проход планировщика, записавший уведомление
+		CorrelationID: "run-1",
+	}
+
+	t.Run("неудавшаяся публикация повторяется из outbox", func(t *testing.T) {
+		clk := clock.NewFake(now)
+		repo := new(MockRepository)
+		service := NewSchedulerService(repo, new(MockCache), nil, clk, config.Scheduler{OutboxRelayInterval: time.Minute}, newNoopLogger())
+		recorder := &recorderStub{}
+		service.metrics = recorder
+		var published [][]byte
+		failures := 1
+		service.publish = func(ctx context.Context, _ *amqp.Channel, exchange, routingKey string, msg any) error {
+			assert.Equal(t, "run-1", rabbitmq.CorrelationID(ctx), "notification keeps the run correlation id")
+			assert.Equal(t, rabbitmq.NotificationsExchange, exchange)
+			assert.Equal(t, rabbitmq.RoutingKeySubscriptionExpiring, routingKey)
+			if failures > 0 {
+				failures--
+				return errors.New("channel closed")
+			}
+			body, err := json.Marshal(msg)
+			assert.NoError(t, err)
+			published = append(published, body)
+			return nil
+		}
+
+		repo.On("ClaimPendingNotifications", mock.Anything, now, now.Add(outboxClaimTTL), outboxBatchSize).Return([]*models.OutboxMessage{message}, nil).Once()
+		repo.On("MarkNotificationFailed", mock.Anything, int64(7), "channel closed", now.Add(time.Minute)).Return(nil).Once()
+		service.runRelayOutbox(context.Background(), &amqp.Channel{})
+		repo.AssertNotCalled(t, "MarkNotificationSent", mock.Anything, mock.Anything)
+
+		clk.Advance(time.Minute)
+		retried := *message
+		retried.Attempts = 1
+		repo.On("ClaimPendingNotifications", mock.Anything, now.Add(time.Minute), now.Add(time.Minute+outboxClaimTTL), outboxBatchSize).Return([]*models.OutboxMessage{&retried}, nil).Once()
+		repo.On("MarkNotificationSent", mock.Anything, int64(7)).Return(nil).Once()
+		service.runRelayOutbox(context.Background(), &amqp.Channel{})
+
+		assert.Equal(t, [][]byte{[]byte(`{"username":"first"}`)}, published, "payload is published as stored")
+		assert.Equal(t, []RunStats{
+			{Job: jobOutboxRelay, Found: 1, Failed: 1},
+			{Job: jobOutboxRelay, Found: 1, Published: 1},
+		}, recorder.runs)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("без канала outbox не читается", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+
+		service.runRelayOutbox(context.Background(), nil)
+
+		repo.AssertNotCalled(t, "ClaimPendingNotifications", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка чтения outbox", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), config.Scheduler{}, newNoopLogger())
+		recorder := &recorderStub{}
+		service.metrics = recorder
+		repo.On("ClaimPendingNotifications", mock.Anything, now, now.Add(outboxClaimTTL), outboxBatchSize).Return(nil, errors.New("db error")).Once()
+
+		service.runRelayOutbox(context.Background(), &amqp.Channel{})
+
+		assert.Empty(t, recorder.runs)
+		repo.AssertExpectations(t)
+	})
+}
+
+// queueInspectorStub сообщает заданное количество сообщений в любой очереди.
+type queueInspectorStub struct {
+	messages int
+	err      error
+}
+
+func (q queueInspectorStub) QueueMessages(string) (int, error) {
+	return q.messages, q.err
+}
+
+func TestSchedulerService_checkDeadLetterQueue(t *testing.T) {
+	tests := []struct {
+		name      string
+		inspector queueInspectorStub
+		wantWarn  bool
+		wantDepth map[string]int
+	}{
+		{
+			name:      "очередь выросла выше порога",
+			inspector: queueInspectorStub{messages: 250},
+			wantWarn:  true,
+			wantDepth: map[string]int{"notifications_dlq": 250},
+		},
+		{
+			name:      "очередь ровно на пороге",
+			inspector: queueInspectorStub{messages: 100},
+			wantWarn:  true,
+			wantDepth: map[string]int{"notifications_dlq": 100},
+		},
+		{
+			name:      "очередь на одно сообщение ниже порога",
+			inspector: queueInspectorStub{messages: 99},
+			wantDepth: map[string]int{"notifications_dlq": 99},
+		},
+		{
+			name:      "очередь ниже порога",
+			inspector: queueInspectorStub{messages: 3},
+			wantDepth: map[string]int{"notifications_dlq": 3},
+		},
+		{
+			name:      "очередь недоступна",
+			inspector: queueInspectorStub{err: errors.New("channel closed")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logBuffer strings.Builder
+			logger := slog.New(slog.NewTextHandler(&logBuffer, &slog.HandlerOptions{Level: slog.LevelWarn}))
+			cfg := config.Scheduler{DeadLetterQueue: "notifications_dlq", DeadLetterThreshold: 100}
+			service := NewSchedulerService(new(MockRepository), new(MockCache), nil, nil, cfg, logger)
+			recorder := &recorderStub{}
+			service.metrics = recorder
+
+			warned := service.checkDeadLetterQueue(tt.inspector)
+
+			assert.Equal(t, tt.wantWarn, warned)
+			assert.Equal(t, tt.wantDepth, recorder.depths)
+			assert.Equal(t, tt.wantWarn, strings.Contains(logBuffer.String(), "dead letter queue is growing"))
+			if tt.wantWarn {
+				assert.Contains(t, logBuffer.String(), fmt.Sprintf("messages=%d", tt.inspector.messages))
+			}
+		})
+	}
+}
+
+func TestSchedulerService_MonitorDeadLetterQueueDisabled(t *testing.T) {
+	service := NewSchedulerService(new(MockRepository), new(MockCache), nil, nil, config.Scheduler{}, newNoopLogger())
+	recorder := &recorderStub{}
+	service.metrics = recorder
+
+	// Без настроенной очереди проверка сразу завершается и не обращается к брокеру
+	service.MonitorDeadLetterQueue(context.Background(), queueInspectorStub{messages: 1000})
+
+	assert.Nil(t, recorder.depths)
+}
+
+func TestSchedulerService_outboxRetryDelay(t *testing.T) {
+	service := NewSchedulerService(new(MockRepository), new(MockCache), nil, nil,
+		config.Scheduler{OutboxRelayInterval: 10 * time.Second}, newNoopLogger())
+
+	assert.Equal(t, 10*time.Second, service.outboxRetryDelay(0))
+	assert.Equal(t, 40*time.Second, service.outboxRetryDelay(2))
+	assert.Equal(t, outboxMaxRetryDelay, service.outboxRetryDelay(20))
+	assert.Equal(t, outboxMaxRetryDelay, service.outboxRetryDelay(1000), "delay does not overflow")
+}
+
 func TestSchedulerService_runFindOldNextPaymentDate(t *testing.T) {
 	now := time.Now()
 	entry := &models.Entry{
@@ -263,7 +832,7 @@ func TestSchedulerService_runFindOldNextPaymentDate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			cache := new(MockCache)
-			service := NewSchedulerService(repo, cache, newNoopLogger())
+			service := NewSchedulerService(repo, cache, nil, nil, config.Scheduler{}, newNoopLogger())
 
 			tt.setupMocks(repo, cache)
 
@@ -276,19 +845,186 @@ func TestSchedulerService_runFindOldNextPaymentDate(t *testing.T) {
 	}
 }
 
+func TestSchedulerService_runConvertEndedTrials(t *testing.T) {
+	user := &models.User{UUID: "user123", Email: "test@example.com", Username: "testuser", SubscriptionStatus: "trial"}
+	tokens := []*models.PaymentToken{
+		{ID: 1, UserUID: "user123", Token: "card_old"},
+		{ID: 2, UserUID: "user123", Token: "card_new"},
+	}
+	plan := &models.Plan{ID: 3, Code: "premium", Price: 49950, Currency: "USD"}
+	chargeWithLatestToken := mock.MatchedBy(func(req yookassa.CreatePaymentRequest) bool {
+		return req.PaymentToken == "card_new" &&
+			req.Amount.Value == "499.50" && req.Amount.Currency == "USD" &&
+			req.Metadata["user_uid"] == "user123" &&
+			req.Metadata["plan_id"] == "3" &&
+			req.Metadata["payment_token_id"] == "2" &&
+			req.Metadata[models.PaymentMetadataPurpose] == models.PaymentPurposeTrialConversion &&
+			req.IdempotenceKey == "trial-conversion:user123"
+	})
+	trialExpiredMessage := mock.MatchedBy(func(m models.OutboxMessage) bool {
+		var payload models.User
+		return m.RoutingKey == rabbitmq.RoutingKeyTrialExpired &&
+			m.DedupKey == rabbitmq.RoutingKeyTrialExpired+":user123" &&
+			m.UserUID == "user123" &&
+			json.Unmarshal(m.Payload, &payload) == nil && payload.UUID == "user123"
+	})
+
+	tests := []struct {
+		name       string
+		setupMocks func(*MockRepository, *MockProvider)
+	}{
+		{
+			name: "есть токен - списание прошло, подписка активирована",
+			setupMocks: func(r *MockRepository, p *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return(tokens, nil).Once()
+				r.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+				p.On("CreatePayment", chargeWithLatestToken).
+					Return(&yookassa.CreatePaymentResponse{ID: "pay_1", Status: "succeeded"}, nil).Once()
+				r.On("ActivateTrialSubscription", mock.Anything, "user123").Return(nil).Once()
+			},
+		},
+		{
+			name: "нет токена - пробный период истек",
+			setupMocks: func(r *MockRepository, _ *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return([]*models.PaymentToken{}, nil).Once()
+				r.On("ExpireTrial", mock.Anything, "user123", trialExpiredMessage).Return(true, nil).Once()
+			},
+		},
+		{
+			name: "провайдер отклонил платеж - пробный период истек",
+			setupMocks: func(r *MockRepository, p *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return(tokens, nil).Once()
+				r.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+				p.On("CreatePayment", chargeWithLatestToken).
+					Return(&yookassa.CreatePaymentResponse{ID: "pay_1", Status: "canceled"}, nil).Once()
+				r.On("ExpireTrial", mock.Anything, "user123", trialExpiredMessage).Return(true, nil).Once()
+			},
+		},
+		{
+			name: "пользователь уже не на пробном периоде - событие не записывается",
+			setupMocks: func(r *MockRepository, _ *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return([]*models.PaymentToken{}, nil).Once()
+				r.On("ExpireTrial", mock.Anything, "user123", trialExpiredMessage).Return(false, nil).Once()
+			},
+		},
+		{
+			name: "ошибка перевода в expired",
+			setupMocks: func(r *MockRepository, _ *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return([]*models.PaymentToken{}, nil).Once()
+				r.On("ExpireTrial", mock.Anything, "user123", trialExpiredMessage).Return(false, errors.New("db error")).Once()
+			},
+		},
+		{
+			name: "платеж в обработке - статус не меняется",
+			setupMocks: func(r *MockRepository, p *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return(tokens, nil).Once()
+				r.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+				p.On("CreatePayment", chargeWithLatestToken).
+					Return(&yookassa.CreatePaymentResponse{ID: "pay_1", Status: "pending"}, nil).Once()
+			},
+		},
+		{
+			name: "ошибка провайдера - статус не меняется",
+			setupMocks: func(r *MockRepository, p *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return(tokens, nil).Once()
+				r.On("GetUserPlan", mock.Anything, "user123").Return(plan, nil).Once()
+				p.On("CreatePayment", chargeWithLatestToken).Return(nil, yookassa.ErrProviderUnavailable).Once()
+			},
+		},
+		{
+			name: "тариф не найден - списание не выполняется",
+			setupMocks: func(r *MockRepository, _ *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return([]*models.User{user}, nil).Once()
+				r.On("ListPaymentTokens", mock.Anything, "user123").Return(tokens, nil).Once()
+				r.On("GetUserPlan", mock.Anything, "user123").Return(nil, models.ErrPlanNotFound).Once()
+			},
+		},
+		{
+			name: "ошибка репозитория",
+			setupMocks: func(r *MockRepository, _ *MockProvider) {
+				r.On("FindEndedTrials", mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			provider := new(MockProvider)
+			service := NewSchedulerService(repo, new(MockCache), provider, nil, config.Scheduler{}, newNoopLogger())
+			// Событие об окончании пробного периода публикует релей outbox, а не конвертация
+			service.publish = func(context.Context, *amqp.Channel, string, string, any) error {
+				t.Fatal("trial conversion must not publish directly")
+				return nil
+			}
+
+			tt.setupMocks(repo, provider)
+
+			service.runConvertEndedTrials(context.Background())
+
+			repo.AssertExpectations(t)
+			provider.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSchedulerService_NilChannel(t *testing.T) {
+	t.Run("релей outbox не запускается", func(t *testing.T) {
+		repo := new(MockRepository)
+		var logBuffer strings.Builder
+		logger := slog.New(slog.NewTextHandler(&logBuffer, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		service := NewSchedulerService(repo, new(MockCache), nil, nil, config.Scheduler{}, logger)
+
+		// Без канала RelayOutbox возвращается сразу, не дожидаясь отмены контекста
+		assert.NotPanics(t, func() { service.RelayOutbox(context.Background(), nil) })
+		assert.Contains(t, logBuffer.String(), "outbox relay disabled")
+		repo.AssertNotCalled(t, "ClaimPendingNotifications", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func TestSchedulerService_NewSchedulerService(t *testing.T) {
 	repo := new(MockRepository)
 	cache := new(MockCache)
 	logger := newNoopLogger()
 
-	service := NewSchedulerService(repo, cache, logger)
+	provider := new(MockProvider)
+
+	service := NewSchedulerService(repo, cache, provider, nil, config.Scheduler{}, logger)
 
 	assert.NotNil(t, service)
 	assert.Equal(t, repo, service.repo)
 	assert.Equal(t, cache, service.cache)
+	assert.Equal(t, provider, service.provider)
 	assert.Equal(t, logger, service.log)
 }
 
+func TestSchedulerService_Intervals(t *testing.T) {
+	service := NewSchedulerService(new(MockRepository), new(MockCache), nil, nil, config.Scheduler{}, newNoopLogger())
+	assert.Equal(t, 12*time.Hour, service.cfg.ExpiringTomorrowInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.ExpiringTodayInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.TrialConversionInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.PaymentDateInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.AccountDeletionInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.ArchiveInterval)
+	assert.Equal(t, 365*24*time.Hour, service.cfg.ArchiveRetention)
+	assert.Equal(t, time.Minute, service.cfg.DeadLetterCheckInterval)
+	assert.Equal(t, time.Hour, service.cfg.ExpiryInterval)
+	assert.Equal(t, []int{1}, service.cfg.ReminderDaysBefore)
+
+	cfg := config.Scheduler{ExpiringTomorrowInterval: time.Hour, AccountDeletionInterval: 30 * time.Minute}
+	service = NewSchedulerService(new(MockRepository), new(MockCache), nil, nil, cfg, newNoopLogger())
+	assert.Equal(t, time.Hour, service.cfg.ExpiringTomorrowInterval)
+	assert.Equal(t, 24*time.Hour, service.cfg.ExpiringTodayInterval)
+	assert.Equal(t, 30*time.Minute, service.cfg.AccountDeletionInterval)
+}
+
 func TestSchedulerService_NextPaymentDateUpdate(t *testing.T) {
 	now := time.Now()
 	oldDate := now.Add(-24 * time.Hour)
@@ -302,7 +1038,7 @@ func TestSchedulerService_NextPaymentDateUpdate(t *testing.T) {
 
 	repo := new(MockRepository)
 	cache := new(MockCache)
-	service := NewSchedulerService(repo, cache, newNoopLogger())
+	service := NewSchedulerService(repo, cache, nil, nil, config.Scheduler{}, newNoopLogger())
 
 	repo.On("FindOldNextPaymentDate", mock.Anything).Return([]*models.Entry{entry}, nil).Once()
 	repo.On("UpdateNextPaymentDate", mock.Anything, mock.AnythingOfType("*models.Entry")).Return(1, nil).Once()
@@ -313,3 +1049,119 @@ func TestSchedulerService_NextPaymentDateUpdate(t *testing.T) {
 	repo.AssertExpectations(t)
 	cache.AssertExpectations(t)
 }
+
+func TestSchedulerService_NextPaymentDateAcrossMonthBoundary(t *testing.T) {
+	start := time.Date(2025, 1, 28, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(time.Date(2025, 2, 28, 3, 0, 0, 0, time.UTC))
+
+	repo := new(MockRepository)
+	cache := new(MockCache)
+	service := NewSchedulerService(repo, cache, nil, clk, config.Scheduler{}, newNoopLogger())
+
+	var got []time.Time
+	entry := &models.Entry{ID: 1, ServiceName: "Netflix", StartDate: start, CounterMonths: 12, NextPaymentDate: start}
+	repo.On("FindOldNextPaymentDate", mock.Anything).Return([]*models.Entry{entry}, nil).Twice()
+	repo.On("UpdateNextPaymentDate", mock.Anything, mock.MatchedBy(func(e *models.Entry) bool {
+		got = append(got, e.NextPaymentDate)
+		return true
+	})).Return(1, nil).Twice()
+	cache.On("Set", "subscription:1", mock.AnythingOfType("*models.Entry"), time.Hour).Return(nil).Twice()
+
+	// Каждый проход сдвигает дату на месяц от прошлой даты платежа, независимо от текущего дня
+	service.runFindOldNextPaymentDate(context.Background())
+	clk.Advance(24 * time.Hour)
+	service.runFindOldNextPaymentDate(context.Background())
+
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 28, 0, 0, 0, 0, time.UTC),
+	}, got)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestSchedulerService_runArchiveInactiveSubscriptions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-90 * 24 * time.Hour)
+	cfg := config.Scheduler{ArchiveRetention: 90 * 24 * time.Hour}
+
+	t.Run("архивирует пачками до неполной пачки", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ArchiveInactiveEntrys", mock.Anything, cutoff, archiveBatchSize).Return(archiveBatchSize, nil).Twice()
+		repo.On("ArchiveInactiveEntrys", mock.Anything, cutoff, archiveBatchSize).Return(7, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		archived := service.runArchiveInactiveSubscriptions(context.Background())
+
+		assert.Equal(t, 2*archiveBatchSize+7, archived)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ошибка останавливает проход", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ArchiveInactiveEntrys", mock.Anything, cutoff, archiveBatchSize).Return(archiveBatchSize, nil).Once()
+		repo.On("ArchiveInactiveEntrys", mock.Anything, cutoff, archiveBatchSize).Return(0, errors.New("db error")).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		archived := service.runArchiveInactiveSubscriptions(context.Background())
+
+		assert.Equal(t, archiveBatchSize, archived)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSchedulerService_runExpireLapsedSubscriptions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)
+	expiry := time.Date(2025, 5, 29, 0, 0, 0, 0, time.UTC)
+	cfg := config.Scheduler{ExpiryGracePeriod: 48 * time.Hour}
+	cutoff := now.Add(-48 * time.Hour)
+	user := &models.User{UUID: "user123", Email: "test@example.com", Username: "testuser", SubscriptionExpire: &expiry}
+
+	t.Run("истекшие подписки отключаются с уведомлением", func(t *testing.T) {
+		repo := new(MockRepository)
+		var message models.OutboxMessage
+		repo.On("ExpireLapsedSubscriptions", mock.Anything, cutoff, mock.Anything).
+			Run(func(args mock.Arguments) {
+				notification := args.Get(2).(func(*models.User) (models.OutboxMessage, error))
+				var err error
+				message, err = notification(user)
+				assert.NoError(t, err)
+			}).
+			Return([]*models.User{user}, 1, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		stats := service.runExpireLapsedSubscriptions(context.Background())
+
+		assert.Equal(t, RunStats{Job: jobExpireLapsed, Found: 1, Queued: 1}, stats)
+		assert.Equal(t, rabbitmq.RoutingKeySubscriptionExpired, message.RoutingKey)
+		assert.Equal(t, rabbitmq.RoutingKeySubscriptionExpired+":user123:2025-05-29", message.DedupKey)
+		assert.Equal(t, "user123", message.UserUID)
+		assert.NotEmpty(t, message.CorrelationID)
+		payload, err := json.Marshal(user)
+		assert.NoError(t, err)
+		assert.JSONEq(t, string(payload), string(message.Payload))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("нет истекших подписок", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ExpireLapsedSubscriptions", mock.Anything, cutoff, mock.Anything).Return([]*models.User{}, 0, nil).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		stats := service.runExpireLapsedSubscriptions(context.Background())
+
+		assert.Equal(t, RunStats{Job: jobExpireLapsed}, stats)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ошибка репозитория", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("ExpireLapsedSubscriptions", mock.Anything, cutoff, mock.Anything).Return(nil, 0, errors.New("db error")).Once()
+		service := NewSchedulerService(repo, new(MockCache), nil, clock.NewFake(now), cfg, newNoopLogger())
+
+		stats := service.runExpireLapsedSubscriptions(context.Background())
+
+		assert.Equal(t, RunStats{Job: jobExpireLapsed}, stats)
+		repo.AssertExpectations(t)
+	})
+}
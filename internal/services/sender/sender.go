@@ -7,55 +7,134 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/money"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/smtp"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
 )
 
+// defaultCurrency — валюта цен в сообщениях без валюты: напоминаниях, записанных
+// в outbox до появления поля currency, и webhook-уведомлениях без кода валюты.
+const defaultCurrency = "RUB"
+
+// priceCurrency возвращает валюту цены подписки из сообщения или defaultCurrency, если ее нет.
+func priceCurrency(currency string) string {
+	if currency == "" {
+		return defaultCurrency
+	}
+	return currency
+}
+
 // SubscriptionRepository определяет интерфейс для работы с подписками в репозитории.
 type SubscriptionRepository interface {
 	GetUser(ctx context.Context, userUID string) (*models.User, error)
 }
 
+// RetryPolicy задает повторы отправки письма при временных ошибках SMTP.
+// Нулевое значение означает одну попытку без повторов.
+type RetryPolicy struct {
+	MaxAttempts int           // Максимальное число попыток, включая первую
+	BaseDelay   time.Duration // Задержка перед первым повтором; каждая следующая вдвое больше
+	MaxDelay    time.Duration // Верхняя граница задержки (0 — без ограничения)
+}
+
+// delay возвращает задержку перед повтором с номером attempt (начиная с 1).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d < p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return d
+}
+
 // SenderService предоставляет сервис для отправки уведомлений.
 type SenderService struct {
 	repo      SubscriptionRepository
 	transport smtp.TransportInterface
 	log       *slog.Logger
+	retry     RetryPolicy
+	sleep     func(ctx context.Context, d time.Duration) error // Ожидание перед повтором; прерывается отменой ctx
 }
 
 // NewSenderService создает новый экземпляр SenderService.
-func NewSenderService(repo SubscriptionRepository, log *slog.Logger, transport smtp.TransportInterface) *SenderService {
+func NewSenderService(repo SubscriptionRepository, log *slog.Logger, transport smtp.TransportInterface, retry RetryPolicy) *SenderService {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
 	return &SenderService{
 		repo:      repo,
 		transport: transport,
 		log:       log,
+		retry:     retry,
+		sleep:     sleepContext,
 	}
 }
 
 // SendInfoExpiringSubscription отправляет уведомление об истекающей подписке.
-func (s *SenderService) SendInfoExpiringSubscription(body []byte) error {
+func (s *SenderService) SendInfoExpiringSubscription(ctx context.Context, body []byte) error {
 	var message models.EntryInfo
 	if err := json.Unmarshal(body, &message); err != nil {
-		s.log.Error("Failed to unmarshal message body", "error", sl.Err(err))
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
 		return fmt.Errorf("error unmarshalling message: %w", err)
 	}
 
 	to := []string{message.Email}
 	subject := "Уведомление о скором окончании подписки"
-	bodyText := fmt.Sprintf("Здравствуйте, %s!\n\nВаша подписка на сервис %s заканчивается завтра.\n\nПожалуйста, продлите её заранее.",
-		message.Username, message.ServiceName)
+	bodyText := fmt.Sprintf("Здравствуйте, %s!\n\nВаша подписка на сервис %s заканчивается %s.\nСтоимость подписки: %s в месяц.\n\nПожалуйста, продлите её заранее.",
+		message.Username, message.ServiceName, expiresIn(message.DaysBefore), money.FormatUnits(message.Price, priceCurrency(message.Currency), message.Locale))
+
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
+
+// SendReminderDigest отправляет одно письмо с напоминаниями обо всех подписках из дайджеста.
+func (s *SenderService) SendReminderDigest(ctx context.Context, body []byte) error {
+	var message models.ReminderDigest
+	if err := json.Unmarshal(body, &message); err != nil {
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
+		return fmt.Errorf("error unmarshalling message: %w", err)
+	}
+	if len(message.Subscriptions) == 0 {
+		return fmt.Errorf("%w: reminder digest without subscriptions", rabbitmq.ErrPermanent)
+	}
+
+	to := []string{message.Email}
+	subject := "Уведомление о скором окончании подписок"
+	var list strings.Builder
+	for _, item := range message.Subscriptions {
+		fmt.Fprintf(&list, "- %s: заканчивается %s, %s в месяц\n",
+			item.ServiceName, expiresIn(item.DaysBefore), money.FormatUnits(item.Price, priceCurrency(item.Currency), message.Locale))
+	}
+	bodyText := fmt.Sprintf("Здравствуйте, %s!\n\nСкоро заканчиваются ваши подписки:\n%s\nПожалуйста, продлите их заранее.",
+		message.Username, list.String())
+
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
 
-	return s.sendEmail(to, subject, bodyText)
+// expiresIn описывает, когда заканчивается подписка: "завтра" или "через 3 дня".
+// Сообщения без days_before отправлены до появления настраиваемых сроков и означают завтра.
+func expiresIn(days int) string {
+	if days <= 1 {
+		return "завтра"
+	}
+	unit := "дней"
+	switch {
+	case days%10 == 1 && days%100 != 11:
+		unit = "день"
+	case days%10 >= 2 && days%10 <= 4 && (days%100 < 12 || days%100 > 14):
+		unit = "дня"
+	}
+	return fmt.Sprintf("через %d %s", days, unit)
 }
 
 // SendInfoExpiringTrialPeriodSubscription отправляет уведомление об истекающем пробном периоде.
-func (s *SenderService) SendInfoExpiringTrialPeriodSubscription(body []byte) error {
+func (s *SenderService) SendInfoExpiringTrialPeriodSubscription(ctx context.Context, body []byte) error {
 	var message models.User
 	if err := json.Unmarshal(body, &message); err != nil {
-		s.log.Error("Failed to unmarshal message body", "error", sl.Err(err))
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
 		return fmt.Errorf("error unmarshalling message: %w", err)
 	}
 
@@ -67,42 +146,189 @@ func (s *SenderService) SendInfoExpiringTrialPeriodSubscription(body []byte) err
 			В противном случае сервис будет недоступен.
 		`, message.Username, "ссылка_на_оплату")
 
-	return s.sendEmail(to, subject, bodyText)
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
+
+// SendInfoTrialExpired отправляет уведомление о том, что пробный период закончился,
+// а оплатить подписку автоматически не удалось.
+func (s *SenderService) SendInfoTrialExpired(ctx context.Context, body []byte) error {
+	var message models.User
+	if err := json.Unmarshal(body, &message); err != nil {
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
+		return fmt.Errorf("error unmarshalling message: %w", err)
+	}
+
+	to := []string{message.Email}
+	subject := "Пробный период на Subscription-aggregator закончился"
+	bodyText := fmt.Sprintf(`Здравствуйте, %s!
+			Ваш пробный период на сервисе Subscription-aggregator закончился, и сервис стал недоступен.
+			Чтобы продолжить пользоваться сервисом, оплатите подписку по ссылке: %s.
+		`, message.Username, "ссылка_на_оплату")
+
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
+
+// SendInfoSubscriptionExpired отправляет уведомление о том, что оплаченный срок подписки
+// на сервис закончился и доступ к нему отключен.
+func (s *SenderService) SendInfoSubscriptionExpired(ctx context.Context, body []byte) error {
+	var message models.User
+	if err := json.Unmarshal(body, &message); err != nil {
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
+		return fmt.Errorf("error unmarshalling message: %w", err)
+	}
+
+	to := []string{message.Email}
+	subject := "Подписка на Subscription-aggregator закончилась"
+	bodyText := fmt.Sprintf(`Здравствуйте, %s!
+			Оплаченный срок вашей подписки на сервис Subscription-aggregator закончился, и сервис стал недоступен.
+			Чтобы продолжить пользоваться сервисом, продлите подписку по ссылке: %s.
+		`, message.Username, "ссылка_на_оплату")
+
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
+
+// SendTestNotification отправляет пользователю userUID тестовое письмо, чтобы он мог
+// убедиться, что уведомления доходят до его почты.
+func (s *SenderService) SendTestNotification(ctx context.Context, userUID string) error {
+	user, err := s.repo.GetUser(ctx, userUID)
+	if err != nil {
+		s.logger(ctx).Error("Failed to get user", "error", sl.Err(err))
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	to := []string{user.Email}
+	subject := "Тестовое уведомление Subscription-aggregator"
+	bodyText := fmt.Sprintf(`Здравствуйте, %s!
+			Это тестовое письмо: уведомления Subscription-aggregator доходят до вашей почты.
+			Ничего делать не нужно.
+		`, user.Username)
+	return s.sendEmail(ctx, to, subject, bodyText)
 }
 
 // SendInfoSuccessPayment отправляет уведомление об успешном платеже.
 func (s *SenderService) SendInfoSuccessPayment(payload *paymentwebhook.Payload) error {
-	user, err := s.repo.GetUser(context.Background(), payload.Object.Metadata["user_uid"])
+	return s.sendInfoSuccessPayment(context.Background(), payload)
+}
+
+func (s *SenderService) sendInfoSuccessPayment(ctx context.Context, payload *paymentwebhook.Payload) error {
+	user, err := s.repo.GetUser(ctx, payload.Object.Metadata["user_uid"])
 	if err != nil {
-		s.log.Error("Failed to get username", "error", sl.Err(err))
+		s.logger(ctx).Error("Failed to get username", "error", sl.Err(err))
 		return fmt.Errorf("failed to get username: %w", err)
 	}
 	to := []string{user.Email}
 	subject := "Уведомление об успешном списании денежных средств на Subscription-aggregator"
 	bodyText := fmt.Sprintf(`Здравствуйте, %s!
-			С вашего счёта успешно списана сумма за подписку на сервис Subscription-aggregator.
+			С вашего счёта успешно списана сумма %s за подписку на сервис Subscription-aggregator.
 			Спасибо за использование нашего сервиса!
-		`, user.Username)
-	return s.sendEmail(to, subject, bodyText)
+		`, user.Username, formatPaymentAmount(payload, user.Locale))
+	return s.sendEmail(ctx, to, subject, bodyText)
 }
 
 // SendInfoFailurePayment отправляет уведомление о неудачном платеже.
 func (s *SenderService) SendInfoFailurePayment(payload *paymentwebhook.Payload) error {
-	user, err := s.repo.GetUser(context.Background(), payload.Object.Metadata["user_uid"])
+	return s.sendInfoFailurePayment(context.Background(), payload)
+}
+
+func (s *SenderService) sendInfoFailurePayment(ctx context.Context, payload *paymentwebhook.Payload) error {
+	user, err := s.repo.GetUser(ctx, payload.Object.Metadata["user_uid"])
 	if err != nil {
-		s.log.Error("Failed to get username", "error", sl.Err(err))
+		s.logger(ctx).Error("Failed to get username", "error", sl.Err(err))
 		return fmt.Errorf("failed to get username: %w", err)
 	}
 	to := []string{user.Email}
 	subject := "Уведомление о неуспешном списании денежных средств на Subscription-aggregator"
 	bodyText := fmt.Sprintf(`Здравствуйте, %s!
-			К сожалению, с вашего счёта не удалось списать оплату за подписку на сервис Subscription-aggregator.
+			К сожалению, с вашего счёта не удалось списать оплату %s за подписку на сервис Subscription-aggregator.
 			Для повторной оплаты перейдите по ссылке: %s
-		`, user.Username, "ссылка_на_оплату")
-	return s.sendEmail(to, subject, bodyText)
+		`, user.Username, formatPaymentAmount(payload, user.Locale), "ссылка_на_оплату")
+	return s.sendEmail(ctx, to, subject, bodyText)
+}
+
+// SendInfoSuccessPaymentMessage разбирает сообщение очереди и отправляет уведомление об успешном платеже.
+func (s *SenderService) SendInfoSuccessPaymentMessage(ctx context.Context, body []byte) error {
+	var payload paymentwebhook.Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
+		return fmt.Errorf("error unmarshalling message: %w", err)
+	}
+	return s.sendInfoSuccessPayment(ctx, &payload)
+}
+
+// SendInfoFailurePaymentMessage разбирает сообщение очереди и отправляет уведомление о неудачном платеже.
+func (s *SenderService) SendInfoFailurePaymentMessage(ctx context.Context, body []byte) error {
+	var payload paymentwebhook.Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		s.logger(ctx).Error("Failed to unmarshal message body", "error", sl.Err(err))
+		return fmt.Errorf("error unmarshalling message: %w", err)
+	}
+	return s.sendInfoFailurePayment(ctx, &payload)
+}
+
+// formatPaymentAmount форматирует сумму платежа из webhook-уведомления для локали пользователя.
+// Если сумму не удалось разобрать, возвращается исходное значение с кодом валюты.
+func formatPaymentAmount(payload *paymentwebhook.Payload, locale string) string {
+	currency := payload.Object.Amount.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	amount, err := money.ParseMinor(payload.Object.Amount.Value)
+	if err != nil {
+		return strings.TrimSpace(payload.Object.Amount.Value + " " + currency)
+	}
+	return money.Format(amount, currency, locale)
+}
+
+// logger возвращает логгер сервиса с идентификатором корреляции сообщения из ctx,
+// чтобы логи обработки сообщения связывались с проходом планировщика, который его отправил.
+func (s *SenderService) logger(ctx context.Context) *slog.Logger {
+	if id := rabbitmq.CorrelationID(ctx); id != "" {
+		return s.log.With(slog.String("correlation_id", id))
+	}
+	return s.log
+}
+
+// sleepContext ждет d или отмены ctx; при отмене возвращает ctx.Err().
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// sendEmail отправляет письмо, повторяя попытку с экспоненциальной задержкой при
+// временных ошибках SMTP. Окончательные ошибки (например, несуществующий получатель)
+// не повторяются и помечаются rabbitmq.ErrPermanent, чтобы сообщение не вернулось в очередь.
+// Отмена ctx прерывает ожидание перед повтором: остановка сервиса не ждет оставшихся попыток.
+func (s *SenderService) sendEmail(ctx context.Context, to []string, subject, bodyText string) error {
+	log := s.logger(ctx)
+	var err error
+	for attempt := 1; attempt <= s.retry.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := s.retry.delay(attempt - 1)
+			log.Warn("retrying email send", "attempt", attempt, "delay", delay, "to", to)
+			if waitErr := s.sleep(ctx, delay); waitErr != nil {
+				log.Warn("email send retries interrupted", "attempt", attempt, "to", to, "error", sl.Err(waitErr))
+				return fmt.Errorf("email send interrupted after %d attempts: %w", attempt-1, waitErr)
+			}
+		}
+		err = s.sendEmailOnce(log, to, subject, bodyText)
+		if err == nil {
+			return nil
+		}
+		if !smtp.IsTransient(err) {
+			return fmt.Errorf("%w: %w", rabbitmq.ErrPermanent, err)
+		}
+	}
+	log.Error("email send failed after retries", "attempts", s.retry.MaxAttempts, "to", to, "error", sl.Err(err))
+	return err
 }
 
-func (s *SenderService) sendEmail(to []string, subject, bodyText string) error {
+func (s *SenderService) sendEmailOnce(log *slog.Logger, to []string, subject, bodyText string) error {
 	msg := strings.Join([]string{
 		"From: " + s.transport.GetSMTPUser(),
 		"To: " + strings.Join(to, ";"),
@@ -115,49 +341,49 @@ func (s *SenderService) sendEmail(to []string, subject, bodyText string) error {
 
 	client, err := s.transport.Connect()
 	if err != nil {
-		s.log.Error("Failed to connect to SMTP server", "error", sl.Err(err))
+		log.Error("Failed to connect to SMTP server", "error", sl.Err(err))
 		return err
 	}
 	defer func() {
 		if err := client.Close(); err != nil {
-			s.log.Error("failed to close SMTP client", "error", sl.Err(err))
+			log.Error("failed to close SMTP client", "error", sl.Err(err))
 		}
 	}()
 
 	if err := client.Mail(s.transport.GetSMTPUser()); err != nil {
-		s.log.Error("Failed to set MAIL FROM", "from", s.transport.GetSMTPUser(), "error", sl.Err(err))
+		log.Error("Failed to set MAIL FROM", "from", s.transport.GetSMTPUser(), "error", sl.Err(err))
 		return err
 	}
 
 	for _, addr := range to {
 		if err := client.Rcpt(addr); err != nil {
-			s.log.Error("Failed to set RCPT TO", "recipient", addr, "error", sl.Err(err))
+			log.Error("Failed to set RCPT TO", "recipient", addr, "error", sl.Err(err))
 			return err
 		}
 	}
 
 	wc, err := client.Data()
 	if err != nil {
-		s.log.Error("Failed to get Data writer", "error", sl.Err(err))
+		log.Error("Failed to get Data writer", "error", sl.Err(err))
 		return err
 	}
 
 	_, err = wc.Write([]byte(msg))
 	if err != nil {
-		s.log.Error("Failed to write email body", "error", sl.Err(err))
+		log.Error("Failed to write email body", "error", sl.Err(err))
 		return err
 	}
 
 	if err = wc.Close(); err != nil {
-		s.log.Error("Failed to close Data writer", "error", sl.Err(err))
+		log.Error("Failed to close Data writer", "error", sl.Err(err))
 		return err
 	}
 
 	if err = client.Quit(); err != nil {
-		s.log.Error("Failed to quit SMTP client", "error", sl.Err(err))
+		log.Error("Failed to quit SMTP client", "error", sl.Err(err))
 		return err
 	}
 
-	s.log.Info("email sent successfully", "to", to)
+	log.Info("email sent successfully", "to", to)
 	return nil
 }
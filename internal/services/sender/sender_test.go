@@ -1,25 +1,34 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/smtp"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/rabbitmq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockRepository struct {
 	mock.Mock
 }
 
+// Убеждаемся, что MockRepository реализует интерфейс SubscriptionRepository
+var _ SubscriptionRepository = (*MockRepository)(nil)
+
 func (m *MockRepository) GetUser(ctx context.Context, userUID string) (*models.User, error) {
 	args := m.Called(ctx, userUID)
 	if args.Get(0) == nil {
@@ -123,6 +132,34 @@ func TestSenderService_SendInfoExpiringSubscription(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "success - date-only end_date",
+			body: []byte(`{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"2024-01-01","price":500}`),
+			setupMocks: func(t *MockTransport) {
+				mockClient := new(MockSMTPClient)
+				mockWriter := new(MockSMTPWriter)
+
+				t.On("GetSMTPUser").Return("sender@example.com")
+				t.On("Connect").Return(mockClient, nil).Once()
+				mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+				mockClient.On("Rcpt", "test@example.com").Return(nil).Once()
+				mockClient.On("Data").Return(mockWriter, nil).Once()
+				mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Return(100, nil).Once()
+				mockWriter.On("Close").Return(nil).Once()
+				mockClient.On("Quit").Return(nil).Once()
+				mockClient.On("Close").Return(nil).Once()
+			},
+			expectedError: false,
+		},
+		{
+			name: "invalid end_date",
+			body: []byte(`{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"01/01/2024","price":500}`),
+			setupMocks: func(_ *MockTransport) {
+				// No transport calls expected for invalid date
+			},
+			expectedError: true,
+			errorMessage:  "error unmarshalling message",
+		},
 		{
 			name: "invalid JSON",
 			body: []byte(`invalid json`),
@@ -148,11 +185,11 @@ func TestSenderService_SendInfoExpiringSubscription(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			transport := new(MockTransport)
-			service := NewSenderService(repo, newNoopLogger(), transport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
 
 			tt.setupMocks(transport)
 
-			err := service.SendInfoExpiringSubscription(tt.body)
+			err := service.SendInfoExpiringSubscription(context.Background(), tt.body)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -166,6 +203,173 @@ func TestSenderService_SendInfoExpiringSubscription(t *testing.T) {
 	}
 }
 
+func TestSenderService_SendInfoExpiringSubscription_RoundTrip(t *testing.T) {
+	entryInfo := &models.EntryInfo{
+		Email:       "roundtrip@example.com",
+		Username:    "roundtrip",
+		ServiceName: "Spotify",
+		EndDate:     time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		Price:       300,
+		Locale:      "en-US",
+		DaysBefore:  3,
+	}
+
+	// Тело сообщения формируется так же, как в rabbitmq.PublishMessage
+	body, err := json.Marshal(entryInfo)
+	assert.NoError(t, err)
+
+	var written []byte
+	mockClient := new(MockSMTPClient)
+	mockWriter := new(MockSMTPWriter)
+	transport := new(MockTransport)
+	transport.On("GetSMTPUser").Return("sender@example.com")
+	transport.On("Connect").Return(mockClient, nil).Once()
+	mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+	mockClient.On("Rcpt", "roundtrip@example.com").Return(nil).Once()
+	mockClient.On("Data").Return(mockWriter, nil).Once()
+	mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Run(func(args mock.Arguments) {
+		written = args.Get(0).([]byte)
+	}).Return(100, nil).Once()
+	mockWriter.On("Close").Return(nil).Once()
+	mockClient.On("Quit").Return(nil).Once()
+	mockClient.On("Close").Return(nil).Once()
+
+	service := NewSenderService(new(MockRepository), newNoopLogger(), transport, RetryPolicy{})
+	err = service.SendInfoExpiringSubscription(context.Background(), body)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(written), "roundtrip")
+	assert.Contains(t, string(written), "Spotify")
+	assert.Contains(t, string(written), "₽300.00")
+	assert.Contains(t, string(written), "заканчивается через 3 дня")
+	transport.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSenderService_ReminderCurrency(t *testing.T) {
+	send := func(t *testing.T, email string, call func(*SenderService) error) string {
+		var written []byte
+		mockClient := new(MockSMTPClient)
+		mockWriter := new(MockSMTPWriter)
+		transport := new(MockTransport)
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(mockClient, nil).Once()
+		mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+		mockClient.On("Rcpt", email).Return(nil).Once()
+		mockClient.On("Data").Return(mockWriter, nil).Once()
+		mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Run(func(args mock.Arguments) {
+			written = args.Get(0).([]byte)
+		}).Return(100, nil).Once()
+		mockWriter.On("Close").Return(nil).Once()
+		mockClient.On("Quit").Return(nil).Once()
+		mockClient.On("Close").Return(nil).Once()
+
+		require.NoError(t, call(NewSenderService(new(MockRepository), newNoopLogger(), transport, RetryPolicy{})))
+		return string(written)
+	}
+
+	t.Run("напоминание о подписке в долларах", func(t *testing.T) {
+		body, err := json.Marshal(&models.EntryInfo{
+			Email: "usd@example.com", Username: "usd", ServiceName: "Spotify",
+			EndDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Price: 300, Currency: "USD", Locale: "en-US", DaysBefore: 3,
+		})
+		require.NoError(t, err)
+
+		written := send(t, "usd@example.com", func(s *SenderService) error {
+			return s.SendInfoExpiringSubscription(context.Background(), body)
+		})
+		assert.Contains(t, written, "$300.00")
+		assert.NotContains(t, written, "₽")
+	})
+
+	t.Run("дайджест с подписками в разных валютах", func(t *testing.T) {
+		body, err := json.Marshal(models.ReminderDigest{
+			Email: "digest@example.com", Username: "digest", Locale: "en-US", Date: "2024-03-12",
+			Subscriptions: []models.ReminderDigestItem{
+				{ServiceName: "Netflix", EndDate: "2024-03-13", Price: 500, Currency: "RUB", DaysBefore: 1},
+				{ServiceName: "Spotify", EndDate: "2024-03-15", Price: 300, Currency: "USD", DaysBefore: 3},
+			},
+		})
+		require.NoError(t, err)
+
+		written := send(t, "digest@example.com", func(s *SenderService) error {
+			return s.SendReminderDigest(context.Background(), body)
+		})
+		assert.Contains(t, written, "₽500.00")
+		assert.Contains(t, written, "$300.00")
+	})
+}
+
+func TestSenderService_SendReminderDigest(t *testing.T) {
+	t.Run("одно письмо со всеми подписками", func(t *testing.T) {
+		digest := models.ReminderDigest{
+			Email:    "digest@example.com",
+			Username: "digest",
+			Locale:   "ru-RU",
+			Date:     "2024-03-12",
+			Subscriptions: []models.ReminderDigestItem{
+				{ServiceName: "Netflix", EndDate: "2024-03-13", Price: 500, DaysBefore: 1},
+				{ServiceName: "Spotify", EndDate: "2024-03-15", Price: 300, DaysBefore: 3},
+			},
+		}
+		body, err := json.Marshal(digest)
+		require.NoError(t, err)
+
+		var written []byte
+		mockClient := new(MockSMTPClient)
+		mockWriter := new(MockSMTPWriter)
+		transport := new(MockTransport)
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(mockClient, nil).Once()
+		mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+		mockClient.On("Rcpt", "digest@example.com").Return(nil).Once()
+		mockClient.On("Data").Return(mockWriter, nil).Once()
+		mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Run(func(args mock.Arguments) {
+			written = args.Get(0).([]byte)
+		}).Return(100, nil).Once()
+		mockWriter.On("Close").Return(nil).Once()
+		mockClient.On("Quit").Return(nil).Once()
+		mockClient.On("Close").Return(nil).Once()
+
+		service := NewSenderService(new(MockRepository), newNoopLogger(), transport, RetryPolicy{})
+		err = service.SendReminderDigest(context.Background(), body)
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(written), "Netflix: заканчивается завтра")
+		assert.Contains(t, string(written), "Spotify: заканчивается через 3 дня")
+		transport.AssertExpectations(t)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("дайджест без подписок не отправляется", func(t *testing.T) {
+		body, err := json.Marshal(models.ReminderDigest{Email: "digest@example.com"})
+		require.NoError(t, err)
+
+		transport := new(MockTransport)
+		service := NewSenderService(new(MockRepository), newNoopLogger(), transport, RetryPolicy{})
+		err = service.SendReminderDigest(context.Background(), body)
+
+		assert.ErrorIs(t, err, rabbitmq.ErrPermanent)
+		transport.AssertNotCalled(t, "Connect")
+	})
+}
+
+func TestExpiresIn(t *testing.T) {
+	tests := map[int]string{
+		0:  "завтра",
+		1:  "завтра",
+		2:  "через 2 дня",
+		5:  "через 5 дней",
+		11: "через 11 дней",
+		21: "через 21 день",
+		22: "через 22 дня",
+		30: "через 30 дней",
+	}
+	for days, want := range tests {
+		assert.Equal(t, want, expiresIn(days), "days=%d", days)
+	}
+}
+
 func TestSenderService_SendInfoExpiringTrialPeriodSubscription(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -208,11 +412,11 @@ func TestSenderService_SendInfoExpiringTrialPeriodSubscription(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			transport := new(MockTransport)
-			service := NewSenderService(repo, newNoopLogger(), transport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
 
 			tt.setupMocks(transport)
 
-			err := service.SendInfoExpiringTrialPeriodSubscription(tt.body)
+			err := service.SendInfoExpiringTrialPeriodSubscription(context.Background(), tt.body)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -226,12 +430,105 @@ func TestSenderService_SendInfoExpiringTrialPeriodSubscription(t *testing.T) {
 	}
 }
 
+func TestSenderService_SendInfoTrialExpired(t *testing.T) {
+	repo := new(MockRepository)
+	transport := new(MockTransport)
+	mockClient := new(MockSMTPClient)
+	mockWriter := new(MockSMTPWriter)
+	service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
+
+	transport.On("GetSMTPUser").Return("sender@example.com")
+	transport.On("Connect").Return(mockClient, nil).Once()
+	mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+	mockClient.On("Rcpt", "test@example.com").Return(nil).Once()
+	mockClient.On("Data").Return(mockWriter, nil).Once()
+	mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Return(100, nil).Once()
+	mockWriter.On("Close").Return(nil).Once()
+	mockClient.On("Quit").Return(nil).Once()
+	mockClient.On("Close").Return(nil).Once()
+
+	err := service.SendInfoTrialExpired(context.Background(), []byte(`{"uuid":"user123","email":"test@example.com","username":"testuser"}`))
+	assert.NoError(t, err)
+
+	err = service.SendInfoTrialExpired(context.Background(), []byte(`invalid json`))
+	assert.ErrorContains(t, err, "error unmarshalling message")
+
+	transport.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSenderService_SendInfoSubscriptionExpired(t *testing.T) {
+	repo := new(MockRepository)
+	transport := new(MockTransport)
+	mockClient := new(MockSMTPClient)
+	mockWriter := new(MockSMTPWriter)
+	service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
+
+	transport.On("GetSMTPUser").Return("sender@example.com")
+	transport.On("Connect").Return(mockClient, nil).Once()
+	mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+	mockClient.On("Rcpt", "test@example.com").Return(nil).Once()
+	mockClient.On("Data").Return(mockWriter, nil).Once()
+	mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Return(100, nil).Once()
+	mockWriter.On("Close").Return(nil).Once()
+	mockClient.On("Quit").Return(nil).Once()
+	mockClient.On("Close").Return(nil).Once()
+
+	err := service.SendInfoSubscriptionExpired(context.Background(),
+		[]byte(`{"uuid":"user123","email":"test@example.com","username":"testuser","subscription_expiry":"2025-05-29T00:00:00Z"}`))
+	assert.NoError(t, err)
+
+	err = service.SendInfoSubscriptionExpired(context.Background(), []byte(`invalid json`))
+	assert.ErrorContains(t, err, "error unmarshalling message")
+
+	transport.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSenderService_SendTestNotification(t *testing.T) {
+	t.Run("письмо отправлено на адрес пользователя", func(t *testing.T) {
+		repo := new(MockRepository)
+		transport := new(MockTransport)
+		mockClient := new(MockSMTPClient)
+		mockWriter := new(MockSMTPWriter)
+		service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
+
+		repo.On("GetUser", mock.Anything, "user123").Return(&models.User{Email: "test@example.com", Username: "testuser"}, nil).Once()
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(mockClient, nil).Once()
+		mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+		mockClient.On("Rcpt", "test@example.com").Return(nil).Once()
+		mockClient.On("Data").Return(mockWriter, nil).Once()
+		mockWriter.On("Write", mock.MatchedBy(func(p []byte) bool {
+			return strings.Contains(string(p), "Subject: Тестовое уведомление")
+		})).Return(100, nil).Once()
+		mockWriter.On("Close").Return(nil).Once()
+		mockClient.On("Quit").Return(nil).Once()
+		mockClient.On("Close").Return(nil).Once()
+
+		require.NoError(t, service.SendTestNotification(context.Background(), "user123"))
+		repo.AssertExpectations(t)
+		transport.AssertExpectations(t)
+		mockClient.AssertExpectations(t)
+		mockWriter.AssertExpectations(t)
+	})
+
+	t.Run("пользователь не найден", func(t *testing.T) {
+		repo := new(MockRepository)
+		service := NewSenderService(repo, newNoopLogger(), new(MockTransport), RetryPolicy{})
+		repo.On("GetUser", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+
+		assert.ErrorContains(t, service.SendTestNotification(context.Background(), "user123"), "failed to get user")
+	})
+}
+
 func TestSenderService_SendInfoSuccessPayment(t *testing.T) {
 	payload := &paymentwebhook.Payload{
 		Object: struct {
-			ID     string `json:"id"`
-			Status string `json:"status"`
-			Amount struct {
+			ID        string `json:"id"`
+			PaymentID string `json:"payment_id,omitempty"`
+			Status    string `json:"status"`
+			Amount    struct {
 				Value    string `json:"value"`
 				Currency string `json:"currency"`
 			} `json:"amount"`
@@ -308,7 +605,7 @@ func TestSenderService_SendInfoSuccessPayment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			transport := new(MockTransport)
-			service := NewSenderService(repo, newNoopLogger(), transport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
 
 			tt.setupMocks(repo, transport)
 
@@ -330,9 +627,10 @@ func TestSenderService_SendInfoSuccessPayment(t *testing.T) {
 func TestSenderService_SendInfoFailurePayment(t *testing.T) {
 	payload := &paymentwebhook.Payload{
 		Object: struct {
-			ID     string `json:"id"`
-			Status string `json:"status"`
-			Amount struct {
+			ID        string `json:"id"`
+			PaymentID string `json:"payment_id,omitempty"`
+			Status    string `json:"status"`
+			Amount    struct {
 				Value    string `json:"value"`
 				Currency string `json:"currency"`
 			} `json:"amount"`
@@ -409,7 +707,7 @@ func TestSenderService_SendInfoFailurePayment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			transport := new(MockTransport)
-			service := NewSenderService(repo, newNoopLogger(), transport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
 
 			tt.setupMocks(repo, transport)
 
@@ -433,7 +731,7 @@ func TestSenderService_NewSenderService(t *testing.T) {
 	transport := new(MockTransport)
 	logger := newNoopLogger()
 
-	service := NewSenderService(repo, logger, transport)
+	service := NewSenderService(repo, logger, transport, RetryPolicy{})
 
 	assert.NotNil(t, service)
 	assert.Equal(t, repo, service.repo)
@@ -506,11 +804,11 @@ func TestSenderService_SMTPErrorHandling(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(MockRepository)
 			transport := new(MockTransport)
-			service := NewSenderService(repo, newNoopLogger(), transport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
 
 			tt.setupMocks(transport)
 
-			err := service.SendInfoExpiringSubscription(body)
+			err := service.SendInfoExpiringSubscription(context.Background(), body)
 
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.errorMessage)
@@ -519,3 +817,206 @@ func TestSenderService_SMTPErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestSenderService_PaymentMessages(t *testing.T) {
+	body := []byte(`{"event":"payment.succeeded","object":{"id":"payment123","metadata":{"user_uid":"user123"}}}`)
+
+	t.Run("payment message is decoded and passed to repository", func(t *testing.T) {
+		for _, send := range []func(*SenderService) rabbitmq.HandlerFunc{
+			func(s *SenderService) rabbitmq.HandlerFunc { return s.SendInfoSuccessPaymentMessage },
+			func(s *SenderService) rabbitmq.HandlerFunc { return s.SendInfoFailurePaymentMessage },
+		} {
+			repo := new(MockRepository)
+			transport := new(MockTransport)
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
+			repo.On("GetUser", mock.Anything, "user123").Return(nil, errors.New("user not found")).Once()
+
+			err := send(service)(context.Background(), body)
+
+			assert.ErrorContains(t, err, "user not found")
+			repo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		service := NewSenderService(new(MockRepository), newNoopLogger(), new(MockTransport), RetryPolicy{})
+
+		assert.ErrorContains(t, service.SendInfoSuccessPaymentMessage(context.Background(), []byte("{")), "error unmarshalling message")
+		assert.ErrorContains(t, service.SendInfoFailurePaymentMessage(context.Background(), []byte("{")), "error unmarshalling message")
+	})
+}
+
+func TestSenderService_CorrelationID(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	transport := new(MockTransport)
+	transport.On("GetSMTPUser").Return("sender@example.com")
+	transport.On("Connect").Return(nil, errors.New("connection refused")).Once()
+	service := NewSenderService(new(MockRepository), logger, transport, RetryPolicy{})
+	ctx := rabbitmq.WithCorrelationID(context.Background(), "run-42")
+
+	body := []byte(`{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"2024-01-01","price":500}`)
+	assert.Error(t, service.SendInfoExpiringSubscription(ctx, body))
+	assert.Error(t, service.SendInfoTrialExpired(ctx, []byte(`invalid json`)))
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, line, "correlation_id=run-42")
+	}
+	transport.AssertExpectations(t)
+}
+
+func TestSenderService_PaymentEmailCurrencyFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		locale   string
+		want     string
+	}{
+		{name: "RUB for ru-RU user", currency: "RUB", locale: "ru-RU", want: "1 000,00 ₽"},
+		{name: "USD for en-US user", currency: "USD", locale: "en-US", want: "$1,000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload paymentwebhook.Payload
+			err := json.Unmarshal([]byte(`{"event":"payment.succeeded","object":{"id":"payment123","status":"succeeded",`+
+				`"amount":{"value":"1000.00","currency":"`+tt.currency+`"},"metadata":{"user_uid":"user123"}}}`), &payload)
+			assert.NoError(t, err)
+
+			repo := new(MockRepository)
+			repo.On("GetUser", mock.Anything, "user123").Return(&models.User{
+				UUID: "user123", Email: "test@example.com", Username: "testuser", Locale: tt.locale,
+			}, nil).Once()
+
+			var written []byte
+			mockClient := new(MockSMTPClient)
+			mockWriter := new(MockSMTPWriter)
+			transport := new(MockTransport)
+			transport.On("GetSMTPUser").Return("sender@example.com")
+			transport.On("Connect").Return(mockClient, nil).Once()
+			mockClient.On("Mail", "sender@example.com").Return(nil).Once()
+			mockClient.On("Rcpt", "test@example.com").Return(nil).Once()
+			mockClient.On("Data").Return(mockWriter, nil).Once()
+			mockWriter.On("Write", mock.AnythingOfType("[]uint8")).Run(func(args mock.Arguments) {
+				written = args.Get(0).([]byte)
+			}).Return(100, nil).Once()
+			mockWriter.On("Close").Return(nil).Once()
+			mockClient.On("Quit").Return(nil).Once()
+			mockClient.On("Close").Return(nil).Once()
+
+			service := NewSenderService(repo, newNoopLogger(), transport, RetryPolicy{})
+			err = service.SendInfoSuccessPayment(&payload)
+
+			assert.NoError(t, err)
+			assert.Contains(t, string(written), tt.want)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSenderService_SMTPRetry(t *testing.T) {
+	body := []byte(`{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"2024-01-01","price":500}`)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	t.Run("transient error then success", func(t *testing.T) {
+		transport := new(MockTransport)
+		failing := new(MockSMTPClient)
+		working := new(MockSMTPClient)
+		writer := new(MockSMTPWriter)
+
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(failing, nil).Once()
+		failing.On("Mail", "sender@example.com").Return(nil).Once()
+		failing.On("Rcpt", "test@example.com").Return(&textproto.Error{Code: 451, Msg: "try again later"}).Once()
+		failing.On("Close").Return(nil).Once()
+
+		transport.On("Connect").Return(working, nil).Once()
+		working.On("Mail", "sender@example.com").Return(nil).Once()
+		working.On("Rcpt", "test@example.com").Return(nil).Once()
+		working.On("Data").Return(writer, nil).Once()
+		writer.On("Write", mock.AnythingOfType("[]uint8")).Return(100, nil).Once()
+		writer.On("Close").Return(nil).Once()
+		working.On("Quit").Return(nil).Once()
+		working.On("Close").Return(nil).Once()
+
+		service := NewSenderService(new(MockRepository), newNoopLogger(), transport, policy)
+		var delays []time.Duration
+		service.sleep = func(_ context.Context, d time.Duration) error { delays = append(delays, d); return nil }
+
+		require.NoError(t, service.SendInfoExpiringSubscription(context.Background(), body))
+		assert.Equal(t, []time.Duration{time.Second}, delays)
+		transport.AssertExpectations(t)
+		failing.AssertExpectations(t)
+		working.AssertExpectations(t)
+	})
+
+	t.Run("permanent error is not retried", func(t *testing.T) {
+		transport := new(MockTransport)
+		client := new(MockSMTPClient)
+
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(client, nil).Once()
+		client.On("Mail", "sender@example.com").Return(nil).Once()
+		client.On("Rcpt", "test@example.com").Return(&textproto.Error{Code: 550, Msg: "no such user"}).Once()
+		client.On("Close").Return(nil).Once()
+
+		service := NewSenderService(new(MockRepository), newNoopLogger(), transport, policy)
+		service.sleep = func(context.Context, time.Duration) error {
+			t.Fatal("permanent error must not be retried")
+			return nil
+		}
+
+		err := service.SendInfoExpiringSubscription(context.Background(), body)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, rabbitmq.ErrPermanent)
+		assert.Contains(t, err.Error(), "no such user")
+		transport.AssertExpectations(t)
+	})
+
+	t.Run("gives up after max attempts with growing delay", func(t *testing.T) {
+		transport := new(MockTransport)
+		transport.On("GetSMTPUser").Return("sender@example.com")
+		transport.On("Connect").Return(nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}).Times(3)
+
+		service := NewSenderService(new(MockRepository), newNoopLogger(), transport, policy)
+		var delays []time.Duration
+		service.sleep = func(_ context.Context, d time.Duration) error { delays = append(delays, d); return nil }
+
+		err := service.SendInfoExpiringSubscription(context.Background(), body)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, rabbitmq.ErrPermanent)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+		transport.AssertExpectations(t)
+	})
+}
+
+func TestSenderService_SMTPRetryCanceled(t *testing.T) {
+	transport := new(MockTransport)
+	transport.On("GetSMTPUser").Return("sender@example.com")
+	transport.On("Connect").Return(nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}).Once()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	service := NewSenderService(new(MockRepository), newNoopLogger(), transport, policy)
+	body := []byte(`{"email":"test@example.com","username":"testuser","service_name":"Netflix","end_date":"2024-01-01","price":500}`)
+
+	// Остановка сервиса во время ожидания перед повтором не ждет оставшийся час
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := service.SendInfoExpiringSubscription(ctx, body)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, rabbitmq.ErrPermanent)
+	assert.Less(t, time.Since(start), time.Second)
+	transport.AssertExpectations(t)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+}
@@ -3,10 +3,19 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/dateparse"
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/month"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/sl"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
@@ -15,20 +24,44 @@ import (
 type SubscriptionRepository interface {
 	// Create добавляет новую подписку и возвращает её ID.
 	CreateEntry(ctx context.Context, sub models.Entry) (int, error)
-	// Remove удаляет подписку по ID и возвращает количество удалённых записей.
+	// CreateEntries добавляет подписки в одной транзакции и возвращает их ID в том же порядке.
+	CreateEntries(ctx context.Context, entries []models.Entry) ([]int, error)
+	// RemoveEntryForUser удаляет подписку пользователя по ID и возвращает количество удалённых записей.
+	RemoveEntryForUser(ctx context.Context, id int, userUID string) (int, error)
+	// RemoveEntry удаляет подписку по ID без учета владельца.
 	RemoveEntry(ctx context.Context, id int) (int, error)
-	// Read возвращает подписку по ID.
+	// ReadEntryForUser возвращает подписку пользователя по ID.
+	ReadEntryForUser(ctx context.Context, id int, userUID string) (*models.Entry, error)
+	// ReadEntry возвращает подписку по ID без учета владельца.
 	ReadEntry(ctx context.Context, id int) (*models.Entry, error)
 	// Update обновляет данные подписки по ID.
 	UpdateEntry(ctx context.Context, req models.Entry, id int, username string) (int, error)
+	ReactivateEntry(ctx context.Context, id int, userUID string, startDate, nextPaymentDate time.Time) (int, error)
 	// List возвращает список подписок для пользователя с пагинацией.
-	ListEntrys(ctx context.Context, username string, limit, offset int) ([]*models.Entry, error)
+	ListEntrys(ctx context.Context, userUID string, limit, offset int, sort models.ListSort) ([]*models.Entry, error)
+	// FindByServiceName возвращает подписки пользователя на сервис с указанным названием.
+	FindByServiceName(ctx context.Context, userUID, service string) ([]*models.Entry, error)
+	// ListPriceHistory возвращает цены подписок пользователя на сервис в порядке времени.
+	ListPriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error)
+	// ListEntrysByUserUID возвращает все подписки пользователя.
+	ListEntrysByUserUID(ctx context.Context, userUID string) ([]*models.Entry, error)
+	// ListAttentionEntrys возвращает активные подписки пользователя в порядке ближайшего платежа.
+	ListAttentionEntrys(ctx context.Context, userUID string, today time.Time) ([]*models.AttentionEntry, error)
 	// CountSum подсчитывает сумму по фильтру.
 	CountSumEntrys(ctx context.Context, entry models.FilterSum) (float64, error)
+	CountSumEntrysDetailed(ctx context.Context, entry models.FilterSum) (*models.SumBreakdown, error)
+	// SumEntrysByMonth возвращает стоимость подписок пользователя по календарным месяцам.
+	SumEntrysByMonth(ctx context.Context, userUID string, from time.Time, months int) ([]models.MonthlySpend, error)
 	// ListAll возвращает список всех подписок с пагинацией.
-	ListAllEntrys(ctx context.Context, limit, offset int) ([]*models.Entry, error)
+	ListAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort) ([]*models.Entry, error)
+	// StreamAllEntrys передает все подписки с пагинацией в fn по мере чтения из хранилища.
+	StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error
+	// BulkUpdateStatus меняет статус подписок пользователя в одной транзакции.
+	BulkUpdateStatus(ctx context.Context, userUID string, ids []int, serviceName, status string) ([]models.BulkStatusResult, error)
 	GetSubscriptionStatus(ctx context.Context, userUID string) (bool, error)
 	GetUser(ctx context.Context, userUID string) (*models.User, error)
+	// ListPlans возвращает все тарифы.
+	ListPlans(ctx context.Context) ([]models.Plan, error)
 }
 
 // Cache описывает методы для кэширования данных.
@@ -43,62 +76,393 @@ type Cache interface {
 
 // SubscriptionService реализует бизнес-логику работы с подписками, включая кеширование.
 type SubscriptionService struct {
-	repo  SubscriptionRepository
-	cache Cache
-	log   *slog.Logger
+	repo        SubscriptionRepository
+	cache       Cache
+	clock       clock.Clock
+	dateLayouts []string           // допустимые форматы start_date, пробуются по порядку
+	limits      models.EntryLimits // ограничения цены, срока и валюты подписки
+	log         *slog.Logger
 }
 
 // NewSubscriptionService создает новый экземпляр SubscriptionService.
-func NewSubscriptionService(repo SubscriptionRepository, cache Cache, log *slog.Logger) *SubscriptionService {
+// Если clk равен nil, используется системное время; если dateLayouts пуст —
+// форматы даты dateparse.DefaultLayouts. Нулевые поля limits снимают ограничения.
+func NewSubscriptionService(repo SubscriptionRepository, cache Cache, clk clock.Clock, dateLayouts []string,
+	limits models.EntryLimits, log *slog.Logger) *SubscriptionService {
 	return &SubscriptionService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+		repo:        repo,
+		cache:       cache,
+		clock:       clock.OrReal(clk),
+		dateLayouts: dateLayouts,
+		limits:      limits,
+		log:         log,
 	}
 }
 
 // CreateEntry создает новую подписку для пользователя, кеширует её и возвращает ID.
+// Если у пользователя уже есть subscription_limits.max_active_per_service активных подписок
+// на этот сервис, возвращает models.ErrActiveLimitExceeded.
 func (s *SubscriptionService) CreateEntry(ctx context.Context, userName string, userUID string, req models.DummyEntry) (int, error) {
-	startDate, err := time.Parse("02-01-2006", req.StartDate)
+	entry, err := s.newEntry(userName, userUID, req)
 	if err != nil {
-		return 0, fmt.Errorf("invalid start date: %w", err)
+		return 0, err
 	}
-	endDate := startDate.AddDate(0, req.CounterMonths, 0)
-	today := time.Now().Truncate(24 * time.Hour)
-	if endDate.Before(today) {
-		return 0, fmt.Errorf("subscription end date must not be earlier than today")
+	if err := s.checkActiveLimit(ctx, userUID, entry.ServiceName, nil, 1); err != nil {
+		return 0, err
 	}
 
-	nextPaymentDate := startDate.AddDate(0, 1, 0)
-	entry := models.Entry{
-		ServiceName:     req.ServiceName,
+	id, err := s.repo.CreateEntry(ctx, entry)
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.Info("created new subscription", slog.Int("id", id))
+
+	cacheKey := fmt.Sprintf("subscription:%d", id)
+	if err := s.cache.Set(cacheKey, entry, time.Hour); err != nil {
+		s.log.Warn("failed to cache subscription", slog.String("key", cacheKey), sl.Err(err))
+	}
+	s.log.Info("created new subscription in cache")
+
+	return id, nil
+}
+
+// newEntry проверяет данные подписки и собирает из них новую активную подписку пользователя.
+func (s *SubscriptionService) newEntry(userName, userUID string, req models.DummyEntry) (models.Entry, error) {
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		return models.Entry{}, err
+	}
+	if err := s.checkLimits(req.Price, req.CounterMonths, req.Currency); err != nil {
+		return models.Entry{}, err
+	}
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	startDate, err := s.parseEntryStart(req, today)
+	if err != nil {
+		return models.Entry{}, err
+	}
+	notes, metadata, err := entryNotes(req, "", nil)
+	if err != nil {
+		return models.Entry{}, err
+	}
+
+	return models.Entry{
+		ServiceName:     serviceName,
 		Username:        userName,
 		Price:           req.Price,
 		StartDate:       startDate,
 		CounterMonths:   req.CounterMonths,
-		NextPaymentDate: nextPaymentDate,
+		NextPaymentDate: month.NextPaymentDate(startDate, req.CounterMonths, today),
 		IsActive:        true,
 		UserUID:         userUID,
+		Currency:        strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Notes:           notes,
+		Metadata:        metadata,
+	}, nil
+}
+
+// ImportEntries создает подписки из разобранных строк импорта и возвращает строки с результатом.
+// Строки с ошибкой разбора пропускаются; для остальных заполняется ID созданной подписки
+// или ошибка создания. Корректные строки сохраняются пачками по batchSize подписок, каждая
+// пачка — в своей транзакции: если она не сохранилась, ошибку получают все ее строки,
+// а импорт продолжается со следующей пачки. batchSize <= 0 сохраняет все строки одной пачкой.
+// Перед сохранением пачка проверяется по subscription_limits.max_active_per_service:
+// строки сервиса, на котором лимит был бы превышен, получают ошибку и не сохраняются.
+func (s *SubscriptionService) ImportEntries(ctx context.Context, userName, userUID string, rows []models.ImportRow, batchSize int) []models.ImportRow {
+	result := make([]models.ImportRow, len(rows))
+	var pending []int // индексы строк, подписки которых еще не сохранены
+	var entries []models.Entry
+	for i, row := range rows {
+		result[i] = row
+		if row.Error != "" || row.Entry == nil {
+			continue
+		}
+		entry, err := s.newEntry(userName, userUID, *row.Entry)
+		if err != nil {
+			s.log.Warn("failed to import subscription", slog.Int("line", row.Line), sl.Err(err))
+			result[i].Error = importError(err)
+			continue
+		}
+		pending = append(pending, i)
+		entries = append(entries, entry)
+	}
+	if batchSize <= 0 {
+		batchSize = len(entries)
 	}
 
-	id, err := s.repo.CreateEntry(ctx, entry)
+	for start := 0; start < len(entries); start += batchSize {
+		end := min(start+batchSize, len(entries))
+		batch, batchRows := s.checkImportActiveLimit(ctx, userUID, entries[start:end], pending[start:end], result)
+		if len(batch) == 0 {
+			continue
+		}
+		ids, err := s.repo.CreateEntries(ctx, batch)
+		if err != nil {
+			s.log.Warn("failed to import subscriptions batch",
+				slog.Int("first_line", rows[batchRows[0]].Line), slog.Int("size", len(batch)), sl.Err(err))
+			for _, i := range batchRows {
+				result[i].Error = importError(err)
+			}
+			continue
+		}
+		for k, id := range ids {
+			result[batchRows[k]].ID = id
+			cacheKey := fmt.Sprintf("subscription:%d", id)
+			if err := s.cache.Set(cacheKey, batch[k], time.Hour); err != nil {
+				s.log.Warn("failed to cache subscription", slog.String("key", cacheKey), sl.Err(err))
+			}
+		}
+		s.log.Info("imported subscriptions batch", slog.Int("size", len(ids)))
+	}
+	return result
+}
+
+// checkImportActiveLimit проверяет пачку импорта entries по subscription_limits.max_active_per_service:
+// активные подписки пачки считаются вместе с уже сохраненными, в том числе предыдущими пачками,
+// активными подписками пользователя на тот же сервис. Строкам сервиса, на котором лимит был бы
+// превышен, записывается ошибка в result. Возвращает оставшиеся подписки и индексы их строк
+// из rowIdx.
+func (s *SubscriptionService) checkImportActiveLimit(ctx context.Context, userUID string, entries []models.Entry, rowIdx []int, result []models.ImportRow) ([]models.Entry, []int) {
+	if s.limits.MaxActivePerService <= 0 {
+		return entries, rowIdx
+	}
+
+	// Подписки группируются по сервису без учета регистра, как в FindByServiceName
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	var services []string
+	adding := make(map[string]int)
+	for k := range entries {
+		if entryStatus(&entries[k], today) != models.EntryStatusActive {
+			continue
+		}
+		key := strings.ToLower(entries[k].ServiceName)
+		if _, ok := adding[key]; !ok {
+			services = append(services, entries[k].ServiceName)
+		}
+		adding[key]++
+	}
+	rejected := make(map[string]error)
+	for _, service := range services {
+		if err := s.checkActiveLimit(ctx, userUID, service, nil, adding[strings.ToLower(service)]); err != nil {
+			rejected[strings.ToLower(service)] = err
+		}
+	}
+	if len(rejected) == 0 {
+		return entries, rowIdx
+	}
+
+	kept := make([]models.Entry, 0, len(entries))
+	keptRows := make([]int, 0, len(rowIdx))
+	for k, entry := range entries {
+		err, ok := rejected[strings.ToLower(entry.ServiceName)]
+		if ok && entryStatus(&entry, today) == models.EntryStatusActive {
+			s.log.Warn("failed to import subscription", slog.Int("line", result[rowIdx[k]].Line), sl.Err(err))
+			result[rowIdx[k]].Error = importError(err)
+			continue
+		}
+		kept = append(kept, entry)
+		keptRows = append(keptRows, rowIdx[k])
+	}
+	return kept, keptRows
+}
+
+// importError возвращает текст ошибки строки импорта: ошибки проверки данных передаются
+// клиенту как есть, остальные скрываются за общим сообщением.
+func importError(err error) string {
+	if errors.Is(err, models.ErrEndDateInPast) || errors.Is(err, models.ErrInvalidStartDate) ||
+		errors.Is(err, models.ErrInvalidServiceName) || errors.Is(err, models.ErrEntryOutOfLimits) ||
+		errors.Is(err, models.ErrActiveLimitExceeded) {
+		return err.Error()
+	}
+	return "could not create subscription"
+}
+
+// PreviewEntry рассчитывает стоимость подписки за весь срок без сохранения в базе.
+// Входные данные проверяются так же, как при создании подписки.
+func (s *SubscriptionService) PreviewEntry(_ context.Context, req models.DummyEntry) (*models.EntryPreview, error) {
+	if err := s.checkLimits(req.Price, req.CounterMonths, req.Currency); err != nil {
+		return nil, err
+	}
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	startDate, err := s.parseEntryStart(req, today)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	s.log.Info("created new subscription", slog.Int("id", id))
+	return &models.EntryPreview{
+		ServiceName:     req.ServiceName,
+		Price:           req.Price,
+		StartDate:       startDate.Format("02-01-2006"),
+		EndDate:         startDate.AddDate(0, req.CounterMonths, 0).Format("02-01-2006"),
+		CounterMonths:   req.CounterMonths,
+		NextPaymentDate: month.NextPaymentDate(startDate, req.CounterMonths, today).Format("02-01-2006"),
+		Total:           month.ProratedSum(startDate, req.CounterMonths, startDate, float64(req.Price), nil),
+	}, nil
+}
+
+// ValidateEntry проверяет данные подписки так же, как CreateEntry, и возвращает их в том виде,
+// в котором они будут сохранены: с обрезанным названием сервиса, кодом валюты в верхнем регистре
+// и датой начала в формате 02-01-2006. Хранилище и кеш не используются.
+func (s *SubscriptionService) ValidateEntry(_ context.Context, req models.DummyEntry) (*models.DummyEntry, error) {
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLimits(req.Price, req.CounterMonths, req.Currency); err != nil {
+		return nil, err
+	}
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	startDate, err := s.parseEntryStart(req, today)
+	if err != nil {
+		return nil, err
+	}
+	notes, metadata, err := entryNotes(req, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DummyEntry{
+		ServiceName:   serviceName,
+		Price:         req.Price,
+		StartDate:     startDate.Format("02-01-2006"),
+		CounterMonths: req.CounterMonths,
+		IsActive:      true, // CreateEntry всегда создает активную подписку
+		Currency:      strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Metadata:      metadata,
+	}
+	if req.Notes != nil {
+		result.Notes = &notes
+	}
+	return result, nil
+}
+
+// parseEntryStart разбирает дату начала подписки в одном из допустимых форматов
+// и проверяет, что подписка не закончилась до today.
+func (s *SubscriptionService) parseEntryStart(req models.DummyEntry, today time.Time) (time.Time, error) {
+	startDate, err := dateparse.Parse(req.StartDate, s.dateLayouts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", models.ErrInvalidStartDate, err)
+	}
+	endDate := startDate.AddDate(0, req.CounterMonths, 0)
+	if endDate.Before(today) {
+		return time.Time{}, models.ErrEndDateInPast
+	}
+	return startDate, nil
+}
+
+// entryNotes возвращает заметку и метаданные подписки после запроса req. Поля, которых нет
+// в запросе, сохраняют текущие значения notes и metadata; пустая заметка и null удаляют их.
+func entryNotes(req models.DummyEntry, notes string, metadata json.RawMessage) (string, json.RawMessage, error) {
+	var err error
+	if req.Notes != nil {
+		if notes, err = models.NormalizeNotes(*req.Notes); err != nil {
+			return "", nil, err
+		}
+	}
+	if req.Metadata != nil {
+		if metadata, err = models.NormalizeMetadata(req.Metadata); err != nil {
+			return "", nil, err
+		}
+	}
+	return notes, metadata, nil
+}
+
+// checkLimits проверяет цену, срок и валюту подписки по настроенным ограничениям
+// и возвращает models.ErrEntryOutOfLimits с описанием нарушения. Пустая валюта не проверяется.
+func (s *SubscriptionService) checkLimits(price, counterMonths int, currency string) error {
+	if s.limits.MaxPrice > 0 && price > s.limits.MaxPrice {
+		return fmt.Errorf("%w: price must not exceed %d", models.ErrEntryOutOfLimits, s.limits.MaxPrice)
+	}
+	if s.limits.MaxCounterMonths > 0 && counterMonths > s.limits.MaxCounterMonths {
+		return fmt.Errorf("%w: counter_months must not exceed %d", models.ErrEntryOutOfLimits, s.limits.MaxCounterMonths)
+	}
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if code != "" && len(s.limits.Currencies) > 0 && !slices.Contains(s.limits.Currencies, code) {
+		return fmt.Errorf("%w: currency must be one of %s", models.ErrEntryOutOfLimits, strings.Join(s.limits.Currencies, ", "))
+	}
+	return nil
+}
+
+// checkActiveLimit проверяет, что у пользователя userUID останется не больше
+// s.limits.MaxActivePerService активных подписок на сервис serviceName, если к его
+// активным подпискам, кроме подписок except, добавятся adding активных подписок.
+// Иначе возвращает models.ErrActiveLimitExceeded. Подписки выбираются так же, как
+// в FindByServiceName: без учета регистра названия; истекшие подписки не считаются активными.
+// Проверка и последующее сохранение не атомарны, а в базе лимит не закреплен: параллельные
+// запросы одного пользователя могут превысить лимит на число одновременно созданных подписок.
+func (s *SubscriptionService) checkActiveLimit(ctx context.Context, userUID, serviceName string, except []int, adding int) error {
+	limit := s.limits.MaxActivePerService
+	if limit <= 0 {
+		return nil
+	}
+	entries, err := s.repo.FindByServiceName(ctx, userUID, serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to count active subscriptions: %w", err)
+	}
+
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	active := adding
+	for _, e := range entries {
+		if entryStatus(e, today) == models.EntryStatusActive && !slices.Contains(except, e.ID) {
+			active++
+		}
+	}
+	if active > limit {
+		return fmt.Errorf("%w: at most %d active subscriptions for %s", models.ErrActiveLimitExceeded, limit, serviceName)
+	}
+	return nil
+}
+
+// ownedEntry возвращает подписку id из репозитория, если ее владелец — userUID.
+// Репозиторий читает подписку только вместе с владельцем, поэтому несуществующая
+// и чужая подписки неразличимы для клиента: в обоих случаях возвращается
+// models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) ownedEntry(ctx context.Context, userUID string, id int) (*models.Entry, error) {
+	entry, err := s.repo.ReadEntryForUser(ctx, id, userUID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if entry == nil {
+		s.log.Info("subscription of user does not exist", slog.Int("id", id), slog.String("user_uid", userUID))
+		return nil, models.ErrSubscriptionNotFound
+	}
+	// Защита на случай, если репозиторий вернул подписку без учета владельца
+	if entry.UserUID != userUID {
+		s.log.Warn("subscription belongs to another user", slog.Int("id", id),
+			slog.String("user_uid", userUID), slog.String("owner_uid", entry.UserUID))
+		return nil, models.ErrSubscriptionNotFound
+	}
+	return entry, nil
+}
+
+// RemoveEntry удаляет подписку id пользователя userUID и инвалидирует кеш.
+// Если подписки нет или она принадлежит другому пользователю, возвращает models.ErrSubscriptionNotFound.
+// Удаление в репозитории тоже ограничено владельцем, поэтому подписка, сменившая владельца
+// или удаленная после проверки, не удаляется.
+func (s *SubscriptionService) RemoveEntry(ctx context.Context, userUID string, id int) (int, error) {
+	if _, err := s.ownedEntry(ctx, userUID, id); err != nil {
+		return 0, err
+	}
 
 	cacheKey := fmt.Sprintf("subscription:%d", id)
-	if err := s.cache.Set(cacheKey, entry, time.Hour); err != nil {
-		s.log.Warn("failed to cache subscription", slog.String("key", cacheKey), sl.Err(err))
+	if err := s.cache.Invalidate(cacheKey); err != nil {
+		s.log.Warn("failed to remove from cache", slog.String("key", cacheKey), sl.Err(err))
 	}
-	s.log.Info("created new subscription in cache")
 
-	return id, nil
+	count, err := s.repo.RemoveEntryForUser(ctx, id, userUID)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, models.ErrSubscriptionNotFound
+	}
+
+	return count, nil
 }
 
-// RemoveEntry удаляет подписку по ID и инвалидирует кеш.
-func (s *SubscriptionService) RemoveEntry(ctx context.Context, id int) (int, error) {
+// RemoveAnyEntry удаляет подписку id любого пользователя и инвалидирует кеш.
+// Предназначен для администратора; если подписки нет, возвращает models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) RemoveAnyEntry(ctx context.Context, id int) (int, error) {
 	cacheKey := fmt.Sprintf("subscription:%d", id)
 	if err := s.cache.Invalidate(cacheKey); err != nil {
 		s.log.Warn("failed to remove from cache", slog.String("key", cacheKey), sl.Err(err))
@@ -108,65 +472,188 @@ func (s *SubscriptionService) RemoveEntry(ctx context.Context, id int) (int, err
 	if err != nil {
 		return 0, err
 	}
-
+	if count == 0 {
+		return 0, models.ErrSubscriptionNotFound
+	}
 	return count, nil
 }
 
-// ReadEntry возвращает подписку по ID, используя кеш или репозиторий.
-func (s *SubscriptionService) ReadEntry(ctx context.Context, id int) (*models.Entry, error) {
+// ReadEntry возвращает подписку id пользователя userUID, используя кеш или репозиторий.
+// Недоступность кеша не прерывает запрос: подписка читается из репозитория.
+// Если подписки нет или она принадлежит другому пользователю, возвращает models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) ReadEntry(ctx context.Context, userUID string, id int) (*models.Entry, error) {
 	var result *models.Entry
 	cacheKey := fmt.Sprintf("subscription:%d", id)
 	found, err := s.cache.Get(cacheKey, &result)
+	if err != nil {
+		s.log.Warn("failed to read from cache, falling back to repository", slog.String("key", cacheKey), sl.Err(err))
+		found = false
+	}
+	// Владелец проверяется по репозиторию, если в кеше подписка другого пользователя
+	if found && result != nil && result.UserUID == userUID {
+		return result, nil
+	}
+	result, err = s.ownedEntry(ctx, userUID, id)
 	if err != nil {
 		return nil, err
 	}
-	if found {
+
+	if err := s.cache.Set(cacheKey, result, time.Hour); err != nil {
+		s.log.Warn("failed to add to cache", slog.String("key", cacheKey), sl.Err(err))
+	}
+	return result, nil
+}
+
+// ReadAnyEntry возвращает подписку id любого пользователя, используя кеш или репозиторий.
+// Предназначен для администратора; если подписки нет, возвращает models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) ReadAnyEntry(ctx context.Context, id int) (*models.Entry, error) {
+	var result *models.Entry
+	cacheKey := fmt.Sprintf("subscription:%d", id)
+	found, err := s.cache.Get(cacheKey, &result)
+	if err != nil {
+		s.log.Warn("failed to read from cache, falling back to repository", slog.String("key", cacheKey), sl.Err(err))
+		found = false
+	}
+	if found && result != nil {
 		return result, nil
 	}
 	result, err = s.repo.ReadEntry(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && result == nil) {
+		return nil, models.ErrSubscriptionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if result != nil {
-		if err := s.cache.Set(cacheKey, result, time.Hour); err != nil {
+	if err := s.cache.Set(cacheKey, result, time.Hour); err != nil {
+		s.log.Warn("failed to add to cache", slog.String("key", cacheKey), sl.Err(err))
+	}
+	return result, nil
+}
+
+// PlansCacheKey — ключ кеша со списком всех тарифов.
+const PlansCacheKey = "plans"
+
+// WarmupCache загружает в кеш список тарифов под ключом PlansCacheKey и до limit последних
+// созданных активных подписок, чтобы первые запросы после запуска не шли в репозиторий.
+// Подписки кешируются в том же виде, что и при чтении через ReadEntry. Ошибка чтения
+// подписки или записи в кеш не прерывает загрузку остальных данных. Возвращает количество
+// подписок, записанных в кеш.
+func (s *SubscriptionService) WarmupCache(ctx context.Context, limit int) (int, error) {
+	const op = "services.WarmupCache"
+	plans, err := s.repo.ListPlans(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.cache.Set(PlansCacheKey, plans, time.Hour); err != nil {
+		s.log.Warn("failed to add to cache", slog.String("key", PlansCacheKey), sl.Err(err))
+	}
+
+	entries, err := s.repo.ListAllEntrys(ctx, limit, 0, models.ListSort{Field: models.SortByID, Desc: true})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	warmed := 0
+	for _, listed := range entries {
+		if !listed.IsActive {
+			continue
+		}
+		// Список не содержит заметку и метаданные, поэтому подписка перечитывается так же,
+		// как при чтении без кеша: иначе ответ и ETag прогретой подписки отличались бы
+		entry, err := s.repo.ReadEntryForUser(ctx, listed.ID, listed.UserUID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return warmed, fmt.Errorf("%s: %w", op, err)
+			}
+			s.log.Warn("failed to read subscription for cache warmup", slog.Int("id", listed.ID), sl.Err(err))
+			continue
+		}
+		cacheKey := fmt.Sprintf("subscription:%d", listed.ID)
+		if err := s.cache.Set(cacheKey, entry, time.Hour); err != nil {
 			s.log.Warn("failed to add to cache", slog.String("key", cacheKey), sl.Err(err))
+			continue
 		}
+		warmed++
 	}
-	return result, nil
+	return warmed, nil
 }
 
-// UpdateEntry обновляет подписку и обновляет кеш.
-func (s *SubscriptionService) UpdateEntry(ctx context.Context, req models.DummyEntry, id int, username string) (int, error) {
+// UpdateEntry обновляет подписку id пользователя userUID и обновляет кеш.
+// Если подписки нет или она принадлежит другому пользователю, возвращает models.ErrSubscriptionNotFound.
+// Активная после обновления подписка проверяется по subscription_limits.max_active_per_service
+// вместе с остальными подписками пользователя на ее сервис.
+func (s *SubscriptionService) UpdateEntry(ctx context.Context, req models.DummyEntry, id int, userUID, username string) (int, error) {
+	serviceName, err := models.NormalizeServiceName(req.ServiceName)
+	if err != nil {
+		return 0, err
+	}
+
+	// Валюта проверяется после сравнения с текущей: прежняя валюта остается допустимой
+	if err := s.checkLimits(req.Price, req.CounterMonths, ""); err != nil {
+		return 0, err
+	}
+
 	// Конвертируем DummyEntry в Entry
-	startDate, err := time.Parse("02-01-2006", req.StartDate)
+	startDate, err := dateparse.Parse(req.StartDate, s.dateLayouts)
 	if err != nil {
-		return 0, fmt.Errorf("invalid start date format: %w", err)
+		return 0, fmt.Errorf("%w: %w", models.ErrInvalidStartDate, err)
 	}
 
+	today := s.clock.Now().Truncate(24 * time.Hour)
 	entry := models.Entry{
-		ServiceName:   req.ServiceName,
-		Price:         req.Price,
-		StartDate:     startDate,
-		CounterMonths: req.CounterMonths,
-		IsActive:      req.IsActive,
-		Username:      username,
-		ID:            id,
+		ServiceName:     serviceName,
+		Price:           req.Price,
+		StartDate:       startDate,
+		CounterMonths:   req.CounterMonths,
+		NextPaymentDate: month.NextPaymentDate(startDate, req.CounterMonths, today),
+		IsActive:        req.IsActive,
+		Username:        username,
+		UserUID:         userUID,
+		ID:              id,
 	}
 
-	// Валидация даты должна быть до вызова репозитория
-	endDate := entry.StartDate.AddDate(0, entry.CounterMonths, 0)
-	today := time.Now().Truncate(24 * time.Hour)
-	endDateTruncated := endDate.Truncate(24 * time.Hour)
+	// Срок проверяется до обращения к репозиторию
+	if !s.limits.AllowEndedUpdates {
+		endDate := entry.StartDate.AddDate(0, entry.CounterMonths, 0)
+		if endDate.Before(today) {
+			return 0, models.ErrEndDateInPast
+		}
+	}
 
-	if endDateTruncated.Before(today) {
-		return 0, fmt.Errorf("subscription end date must not be earlier than today")
+	current, err := s.ownedEntry(ctx, userUID, id)
+	if err != nil {
+		return 0, err
+	}
+	entry.Currency, err = updatedCurrency(current.Currency, req)
+	if err != nil {
+		return 0, err
+	}
+	entry.Notes, entry.Metadata, err = entryNotes(req, current.Notes, current.Metadata)
+	if err != nil {
+		return 0, err
+	}
+	if entry.Currency != current.Currency {
+		if err := s.checkLimits(0, 0, entry.Currency); err != nil {
+			return 0, err
+		}
+		s.log.Info("subscription currency changed", slog.Int("id", id),
+			slog.String("from", current.Currency), slog.String("to", entry.Currency))
+	}
+	if entryStatus(&entry, today) == models.EntryStatusActive {
+		if err := s.checkActiveLimit(ctx, userUID, entry.ServiceName, []int{id}, 1); err != nil {
+			return 0, err
+		}
 	}
 
 	res, err := s.repo.UpdateEntry(ctx, entry, id, username)
 	if err != nil {
 		return 0, err
 	}
+	// Подписка удалена или сменила владельца после проверки
+	if res == 0 {
+		return 0, models.ErrSubscriptionNotFound
+	}
 	s.log.Info("updated subscription in storage")
 
 	cacheKey := fmt.Sprintf("subscription:%d", id)
@@ -177,26 +664,354 @@ func (s *SubscriptionService) UpdateEntry(ctx context.Context, req models.DummyE
 	return res, nil
 }
 
-// ListEntrys возвращает список подписок в зависимости от роли пользователя.
-func (s *SubscriptionService) ListEntrys(ctx context.Context, username, role string, limit, offset int) ([]*models.Entry, error) {
+// ReactivateEntry возобновляет истекшую или приостановленную подписку id пользователя userUID:
+// делает ее активной и начинает срок заново с сегодняшнего дня, пересчитывая дату следующего
+// платежа. Цена, валюта, срок в месяцах и заметки сохраняются, пометка архивной снимается.
+// Активная подписка не возобновляется: возвращается models.ErrSubscriptionAlreadyActive.
+// Если подписки нет, она удалена или принадлежит другому пользователю, возвращает
+// models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) ReactivateEntry(ctx context.Context, userUID string, id int) (*models.Entry, error) {
+	current, err := s.ownedEntry(ctx, userUID, id)
+	if err != nil {
+		return nil, err
+	}
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	if entryStatus(current, today) == models.EntryStatusActive {
+		return nil, models.ErrSubscriptionAlreadyActive
+	}
+	if err := s.checkActiveLimit(ctx, userUID, current.ServiceName, []int{id}, 1); err != nil {
+		return nil, err
+	}
+
+	entry := *current
+	entry.ID = id
+	entry.StartDate = today
+	entry.NextPaymentDate = month.NextPaymentDate(today, entry.CounterMonths, today)
+	entry.IsActive = true
+	updated, err := s.repo.ReactivateEntry(ctx, id, userUID, entry.StartDate, entry.NextPaymentDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reactivate subscription: %w", err)
+	}
+	if updated == 0 {
+		return nil, models.ErrSubscriptionNotFound
+	}
+	s.log.Info("subscription reactivated", slog.Int("id", id), slog.String("user_uid", userUID))
+
+	cacheKey := fmt.Sprintf("subscription:%d", id)
+	if err := s.cache.Set(cacheKey, entry, time.Hour); err != nil {
+		s.log.Warn("failed to cache subscription", slog.String("key", cacheKey), sl.Err(err))
+	}
+	return &entry, nil
+}
+
+// updatedCurrency возвращает валюту подписки после обновления req. Без валюты в запросе
+// остается текущая валюта current. Другая валюта принимается, только если запрос помечен
+// CurrencyChanged: иначе цена в новой валюте смешалась бы с суммами в старой,
+// и возвращается models.ErrCurrencyMismatch.
+func updatedCurrency(current string, req models.DummyEntry) (string, error) {
+	requested := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if requested == "" || requested == current {
+		return current, nil
+	}
+	if !req.CurrencyChanged {
+		return "", fmt.Errorf("%w: subscription is billed in %s, got %s", models.ErrCurrencyMismatch, current, requested)
+	}
+	return requested, nil
+}
+
+// ListEntrys возвращает список подписок в зависимости от роли пользователя:
+// администратору — все подписки, остальным — подписки с владельцем userUID.
+func (s *SubscriptionService) ListEntrys(ctx context.Context, userUID, role string, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
 	var err error
 	var entries []*models.Entry
 	if role == "admin" {
-		entries, err = s.repo.ListAllEntrys(ctx, limit, offset)
+		entries, err = s.repo.ListAllEntrys(ctx, limit, offset, sort)
 	} else {
-		entries, err = s.repo.ListEntrys(ctx, username, limit, offset)
+		entries, err = s.repo.ListEntrys(ctx, userUID, limit, offset, sort)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// StreamAllEntrys передает подписки всех пользователей в fn по мере чтения из хранилища.
+// Используется для списка администратора, который может быть слишком большим,
+// чтобы собирать его в памяти целиком.
+func (s *SubscriptionService) StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error {
+	if err := s.repo.StreamAllEntrys(ctx, limit, offset, sort, fn); err != nil {
+		return fmt.Errorf("failed to stream subscriptions: %w", err)
+	}
+	return nil
+}
+
+// GroupEntrysByStatus возвращает все подписки пользователя, сгруппированные по статусу,
+// с количеством и суммой ежемесячных цен в каждой группе. Подписка истекла, если ее
+// срок закончился до сегодняшнего дня, иначе она активна или приостановлена по IsActive.
+func (s *SubscriptionService) GroupEntrysByStatus(ctx context.Context, userUID string) (*models.GroupedEntries, error) {
+	entries, err := s.repo.ListEntrysByUserUID(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	grouped := &models.GroupedEntries{
+		Active:  models.EntryGroup{Entries: []*models.Entry{}},
+		Paused:  models.EntryGroup{Entries: []*models.Entry{}},
+		Expired: models.EntryGroup{Entries: []*models.Entry{}},
+	}
+	for _, entry := range entries {
+		var group *models.EntryGroup
+		switch entryStatus(entry, today) {
+		case models.EntryStatusExpired:
+			group = &grouped.Expired
+		case models.EntryStatusPaused:
+			group = &grouped.Paused
+		default:
+			group = &grouped.Active
+		}
+		group.Count++
+		group.Total += entry.Price
+		group.Entries = append(group.Entries, entry)
+	}
+	return grouped, nil
+}
+
+// SpendTimeSeries возвращает стоимость подписок пользователя userUID за последние months
+// календарных месяцев, включая текущий, в хронологическом порядке.
+func (s *SubscriptionService) SpendTimeSeries(ctx context.Context, userUID string, months int) ([]models.MonthlySpend, error) {
+	now := s.clock.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(months - 1), 0)
+	series, err := s.repo.SumEntrysByMonth(ctx, userUID, from, months)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum subscriptions by month: %w", err)
+	}
+	return series, nil
+}
+
+// ListAttention возвращает активные подписки пользователя userUID в порядке ближайшего
+// следующего платежа с признаками того, что платеж может не пройти: у пользователя нет
+// действующей сохраненной карты или последний платеж по подписке был отклонен.
+func (s *SubscriptionService) ListAttention(ctx context.Context, userUID string) ([]*models.AttentionEntry, error) {
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	entries, err := s.repo.ListAttentionEntrys(ctx, userUID, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions needing attention: %w", err)
+	}
+	return entries, nil
+}
+
+// EstimateYearlyCost возвращает годовую стоимость активных подписок пользователя userUID
+// по каждой валюте в порядке кодов валют. Курсы валют сервису неизвестны, поэтому
+// подписки в разных валютах не суммируются. Приостановленные и истекшие подписки не учитываются.
+func (s *SubscriptionService) EstimateYearlyCost(ctx context.Context, userUID string) ([]models.YearlyEstimate, error) {
+	entries, err := s.repo.ListEntrysByUserUID(ctx, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	byCurrency := make(map[string]*models.YearlyEstimate)
+	for _, entry := range entries {
+		if entryStatus(entry, today) != models.EntryStatusActive {
+			continue
+		}
+		estimate, ok := byCurrency[entry.Currency]
+		if !ok {
+			estimate = &models.YearlyEstimate{Currency: entry.Currency}
+			byCurrency[entry.Currency] = estimate
+		}
+		estimate.Count++
+		estimate.Total += entry.Price * 12
 	}
+
+	result := make([]models.YearlyEstimate, 0, len(byCurrency))
+	for _, estimate := range byCurrency {
+		result = append(result, *estimate)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result, nil
+}
+
+// CancelSavings рассчитывает, сколько пользователь userUID сэкономит за следующие
+// models.CancelSavingsMonths месяцев, если отменит подписку id сегодня: сумму по текущей
+// цене всех ежемесячных списаний, которые начались бы в этот период. Приостановленная
+// и закончившаяся подписки ничего не списывают, поэтому экономия по ним нулевая.
+// Если подписки нет или она принадлежит другому пользователю, возвращает models.ErrSubscriptionNotFound.
+func (s *SubscriptionService) CancelSavings(ctx context.Context, userUID string, id int) (*models.CancelSavings, error) {
+	entry, err := s.ownedEntry(ctx, userUID, id)
 	if err != nil {
 		return nil, err
 	}
+
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	to := today.AddDate(0, models.CancelSavingsMonths, 0)
+	months := 0
+	if entryStatus(entry, today) == models.EntryStatusActive {
+		months = month.ChargesBetween(entry.StartDate, entry.CounterMonths, today, to)
+	}
+	return &models.CancelSavings{
+		ID:          id,
+		ServiceName: entry.ServiceName,
+		Price:       entry.Price,
+		Currency:    entry.Currency,
+		From:        today.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Months:      months,
+		Savings:     months * entry.Price,
+	}, nil
+}
+
+// entryStatus определяет статус подписки на дату today.
+func entryStatus(entry *models.Entry, today time.Time) string {
+	if entry.StartDate.AddDate(0, entry.CounterMonths, 0).Before(today) {
+		return models.EntryStatusExpired
+	}
+	if !entry.IsActive {
+		return models.EntryStatusPaused
+	}
+	return models.EntryStatusActive
+}
+
+// BulkUpdateStatus меняет статус подписок пользователя userUID, выбранных по ID или по названию
+// сервиса, и инвалидирует кеш измененных подписок. Возвращает результат по каждой подписке;
+// ErrInvalidBulkRequest, если не указан ни один способ выбора или указаны оба.
+// Активация, после которой на каком-либо сервисе стало бы больше
+// subscription_limits.max_active_per_service активных подписок, отклоняется целиком
+// с models.ErrActiveLimitExceeded.
+func (s *SubscriptionService) BulkUpdateStatus(ctx context.Context, userUID string, req models.BulkStatusRequest) ([]models.BulkStatusResult, error) {
+	serviceName := strings.TrimSpace(req.ServiceName)
+	if (len(req.IDs) == 0) == (serviceName == "") {
+		return nil, models.ErrInvalidBulkRequest
+	}
+	if req.Status == models.EntryStatusActive {
+		if err := s.checkBulkActivation(ctx, userUID, req.IDs, serviceName); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := s.repo.BulkUpdateStatus(ctx, userUID, req.IDs, serviceName, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update subscriptions status: %w", err)
+	}
+	for _, res := range results {
+		if res.Error != "" {
+			continue
+		}
+		cacheKey := fmt.Sprintf("subscription:%d", res.ID)
+		if err := s.cache.Invalidate(cacheKey); err != nil {
+			s.log.Warn("failed to remove from cache", slog.String("key", cacheKey), sl.Err(err))
+		}
+	}
+	return results, nil
+}
+
+// checkBulkActivation проверяет ограничение активных подписок на сервис для массовой активации
+// подписок ids или всех подписок на сервис serviceName. Несуществующие и чужие подписки
+// пропускаются: их ошибку вернет BulkUpdateStatus репозитория.
+func (s *SubscriptionService) checkBulkActivation(ctx context.Context, userUID string, ids []int, serviceName string) error {
+	if s.limits.MaxActivePerService <= 0 {
+		return nil
+	}
+	var targets []*models.Entry
+	if serviceName != "" {
+		entries, err := s.repo.FindByServiceName(ctx, userUID, serviceName)
+		if err != nil {
+			return fmt.Errorf("failed to find subscriptions: %w", err)
+		}
+		targets = entries
+	}
+	for _, id := range ids {
+		entry, err := s.repo.ReadEntryForUser(ctx, id, userUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to read subscription: %w", err)
+		}
+		if entry != nil && entry.UserUID == userUID {
+			targets = append(targets, entry)
+		}
+	}
+
+	// Подписки группируются по сервису без учета регистра, как в FindByServiceName
+	today := s.clock.Now().Truncate(24 * time.Hour)
+	var services []string
+	activating := make(map[string][]int)
+	for _, e := range targets {
+		if entryStatus(e, today) == models.EntryStatusExpired {
+			continue
+		}
+		key := strings.ToLower(e.ServiceName)
+		if _, ok := activating[key]; !ok {
+			services = append(services, e.ServiceName)
+		}
+		activating[key] = append(activating[key], e.ID)
+	}
+	for _, service := range services {
+		ids := activating[strings.ToLower(service)]
+		if err := s.checkActiveLimit(ctx, userUID, service, ids, len(ids)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PriceHistory возвращает историю цены сервиса service по всем подпискам пользователя userUID
+// в порядке времени. Точка добавляется, только когда цена или валюта отличается от предыдущей,
+// поэтому новая подписка по прежней цене не выглядит изменением. Если подписок нет,
+// возвращается пустой список.
+func (s *SubscriptionService) PriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error) {
+	points, err := s.repo.ListPriceHistory(ctx, userUID, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price history: %w", err)
+	}
+
+	result := []*models.PricePoint{}
+	for _, p := range points {
+		if n := len(result); n > 0 && result[n-1].Price == p.Price && result[n-1].Currency == p.Currency {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// FindByServiceName возвращает подписки пользователя на сервис с указанным названием.
+// Если подписок нет, возвращается пустой список.
+func (s *SubscriptionService) FindByServiceName(ctx context.Context, userUID, service string) ([]*models.Entry, error) {
+	entries, err := s.repo.FindByServiceName(ctx, userUID, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscriptions by service: %w", err)
+	}
+	if entries == nil {
+		entries = []*models.Entry{}
+	}
 	return entries, nil
 }
 
-// CountSumWithFilter считает сумму подписок по заданным фильтрам.
-func (s *SubscriptionService) CountSumWithFilter(ctx context.Context, username string, req models.DummyFilterSum) (float64, error) {
-	startDate, err := time.Parse("02-01-2006", req.StartDate)
+// CountSumWithFilter считает сумму подписок пользователя userUID по заданным фильтрам.
+func (s *SubscriptionService) CountSumWithFilter(ctx context.Context, userUID string, req models.DummyFilterSum) (float64, error) {
+	filter, err := s.sumFilter(userUID, req)
 	if err != nil {
-		return 0, fmt.Errorf("invalid start date: %w", err)
+		return 0, err
+	}
+	return s.repo.CountSumEntrys(ctx, filter)
+}
+
+// CountSumDetailed считает ту же сумму, что и CountSumWithFilter, и возвращает
+// пропорциональную стоимость каждой подписки, из которых она сложилась.
+func (s *SubscriptionService) CountSumDetailed(ctx context.Context, userUID string, req models.DummyFilterSum) (*models.SumBreakdown, error) {
+	filter, err := s.sumFilter(userUID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CountSumEntrysDetailed(ctx, filter)
+}
+
+// sumFilter преобразует фильтр из запроса в фильтр хранилища. Дата начала принимается
+// в тех же форматах, что и при создании подписки.
+func (s *SubscriptionService) sumFilter(userUID string, req models.DummyFilterSum) (models.FilterSum, error) {
+	startDate, err := dateparse.Parse(req.StartDate, s.dateLayouts)
+	if err != nil {
+		return models.FilterSum{}, fmt.Errorf("%w: %w", models.ErrInvalidStartDate, err)
 	}
 
 	var serviceNamePtr *string
@@ -204,18 +1019,17 @@ func (s *SubscriptionService) CountSumWithFilter(ctx context.Context, username s
 		serviceNamePtr = &req.ServiceName
 	}
 
-	filter := models.FilterSum{
-		Username:      username,
+	return models.FilterSum{
+		UserUID:       userUID,
 		ServiceName:   serviceNamePtr,
 		StartDate:     startDate,
 		CounterMonths: req.CounterMonths,
-	}
-
-	return s.repo.CountSumEntrys(ctx, filter)
+	}, nil
 }
 
 // CreateEntrySubscriptionAggregator создает подписку для сервиса Subscription-Aggregator.
 func (s *SubscriptionService) CreateEntrySubscriptionAggregator(ctx context.Context, username, userUID string) (int, error) {
+	now := s.clock.Now()
 	entry := models.Entry{
 		ServiceName:     "Subscription-Aggregator",
 		Price:           0,
@@ -223,8 +1037,8 @@ func (s *SubscriptionService) CreateEntrySubscriptionAggregator(ctx context.Cont
 		CounterMonths:   1,
 		Username:        username,
 		UserUID:         userUID,
-		StartDate:       time.Now(),
-		NextPaymentDate: time.Now().AddDate(0, 1, 0),
+		StartDate:       now,
+		NextPaymentDate: now.AddDate(0, 1, 0),
 	}
 	id, err := s.repo.CreateEntry(ctx, entry)
 	if err != nil {
@@ -246,6 +1060,7 @@ func (s *SubscriptionService) GetSubscriptionStatus(ctx context.Context, userUID
 	return s.repo.GetSubscriptionStatus(ctx, userUID)
 }
 
+// GetUser возвращает пользователя по UID.
 func (s *SubscriptionService) GetUser(ctx context.Context, userUID string) (*models.User, error) {
-	return s.GetUser(ctx, userUID)
+	return s.repo.GetUser(ctx, userUID)
 }
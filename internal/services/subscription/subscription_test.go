@@ -2,25 +2,49 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/clock"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type RepoMock struct{ mock.Mock }
 
+// Убеждаемся, что RepoMock реализует интерфейс SubscriptionRepository
+var _ SubscriptionRepository = (*RepoMock)(nil)
+
 func (m *RepoMock) CreateEntry(ctx context.Context, sub models.Entry) (int, error) {
 	args := m.Called(ctx, sub)
 	return args.Int(0), args.Error(1)
 }
+func (m *RepoMock) CreateEntries(ctx context.Context, entries []models.Entry) ([]int, error) {
+	args := m.Called(ctx, entries)
+	ids, _ := args.Get(0).([]int)
+	return ids, args.Error(1)
+}
+func (m *RepoMock) RemoveEntryForUser(ctx context.Context, id int, userUID string) (int, error) {
+	args := m.Called(ctx, id, userUID)
+	return args.Int(0), args.Error(1)
+}
+func (m *RepoMock) ReadEntryForUser(ctx context.Context, id int, userUID string) (*models.Entry, error) {
+	args := m.Called(ctx, id, userUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
 func (m *RepoMock) RemoveEntry(ctx context.Context, id int) (int, error) {
 	args := m.Called(ctx, id)
 	return args.Int(0), args.Error(1)
@@ -32,34 +56,108 @@ func (m *RepoMock) ReadEntry(ctx context.Context, id int) (*models.Entry, error)
 	}
 	return args.Get(0).(*models.Entry), args.Error(1)
 }
+func (m *RepoMock) ReactivateEntry(ctx context.Context, id int, userUID string, startDate, nextPaymentDate time.Time) (int, error) {
+	args := m.Called(ctx, id, userUID, startDate, nextPaymentDate)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *RepoMock) UpdateEntry(ctx context.Context, req models.Entry, id int, username string) (int, error) {
 	args := m.Called(ctx, req, id, username)
 	return args.Int(0), args.Error(1)
 }
-func (m *RepoMock) ListEntrys(ctx context.Context, username string, limit, offset int) ([]*models.Entry, error) {
-	args := m.Called(ctx, username, limit, offset)
+func (m *RepoMock) ListEntrys(ctx context.Context, username string, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
+	args := m.Called(ctx, username, limit, offset, sort)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Entry), args.Error(1)
+}
+func (m *RepoMock) FindByServiceName(ctx context.Context, username, service string) ([]*models.Entry, error) {
+	args := m.Called(ctx, username, service)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Entry), args.Error(1)
 }
+func (m *RepoMock) ListEntrysByUserUID(ctx context.Context, userUID string) ([]*models.Entry, error) {
+	args := m.Called(ctx, userUID)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *RepoMock) SumEntrysByMonth(ctx context.Context, userUID string, from time.Time, months int) ([]models.MonthlySpend, error) {
+	args := m.Called(ctx, userUID, from, months)
+	if res := args.Get(0); res != nil {
+		return res.([]models.MonthlySpend), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *RepoMock) StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error {
+	args := m.Called(ctx, limit, offset, sort)
+	if entries, ok := args.Get(0).([]*models.Entry); ok {
+		for _, e := range entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+func (m *RepoMock) ListAttentionEntrys(ctx context.Context, userUID string, today time.Time) ([]*models.AttentionEntry, error) {
+	args := m.Called(ctx, userUID, today)
+	if res := args.Get(0); res != nil {
+		return res.([]*models.AttentionEntry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
 func (m *RepoMock) CountSumEntrys(ctx context.Context, filter models.FilterSum) (float64, error) {
 	args := m.Called(ctx, filter)
 	return args.Get(0).(float64), args.Error(1)
 }
-func (m *RepoMock) ListAllEntrys(ctx context.Context, limit, offset int) ([]*models.Entry, error) {
-	args := m.Called(ctx, limit, offset)
+
+func (m *RepoMock) CountSumEntrysDetailed(ctx context.Context, filter models.FilterSum) (*models.SumBreakdown, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SumBreakdown), args.Error(1)
+}
+func (m *RepoMock) ListAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
+	args := m.Called(ctx, limit, offset, sort)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Entry), args.Error(1)
 }
+func (m *RepoMock) ListPlans(ctx context.Context) ([]models.Plan, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Plan), args.Error(1)
+}
+func (m *RepoMock) BulkUpdateStatus(ctx context.Context, userUID string, ids []int, serviceName, status string) ([]models.BulkStatusResult, error) {
+	args := m.Called(ctx, userUID, ids, serviceName, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BulkStatusResult), args.Error(1)
+}
 
 func (m *RepoMock) CreateEntrySubscriptionAggregator(ctx context.Context, entry models.Entry) (int, error) {
 	args := m.Called(ctx, entry)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *RepoMock) ListPriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error) {
+	args := m.Called(ctx, userUID, service)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PricePoint), args.Error(1)
+}
+
 func (m *RepoMock) GetSubscriptionStatus(ctx context.Context, userUID string) (bool, error) {
 	args := m.Called(ctx, userUID)
 	return args.Bool(0), args.Error(1)
@@ -152,7 +250,7 @@ func TestSubscriptionService_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(RepoMock)
 			cache := new(CacheMock)
-			svc := NewSubscriptionService(repo, cache, newNoopLogger())
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
 
 			tt.setupMocks(repo, cache)
 
@@ -170,6 +268,530 @@ func TestSubscriptionService_Create(t *testing.T) {
 	}
 }
 
+func TestSubscriptionService_CreateDateFormats(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		startDate string
+		want      time.Time
+	}{
+		{name: "ISO", startDate: "2025-07-14", want: time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "день-месяц-год", startDate: "14-07-2025", want: time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "месяц-год", startDate: "07-2025", want: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			repo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e models.Entry) bool {
+				return e.StartDate.Equal(tt.want)
+			})).Return(1, nil).Once()
+			cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+			svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+			id, err := svc.CreateEntry(context.Background(), "user1", "uid1",
+				models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: tt.startDate, CounterMonths: 12})
+			require.NoError(t, err)
+			assert.Equal(t, 1, id)
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("неизвестный формат перечисляет допустимые", func(t *testing.T) {
+		repo := new(RepoMock)
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1",
+			models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "14/07/2025", CounterMonths: 12})
+		assert.ErrorIs(t, err, models.ErrInvalidStartDate)
+		assert.ErrorContains(t, err, "accepted format: 2006-01-02, 02-01-2006, 01-2006")
+		repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+	})
+
+	t.Run("форматы из конфига", func(t *testing.T) {
+		svc := NewSubscriptionService(new(RepoMock), new(CacheMock), clk, []string{"02.01.2006"}, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1",
+			models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-07-14", CounterMonths: 12})
+		assert.ErrorIs(t, err, models.ErrInvalidStartDate)
+		assert.ErrorContains(t, err, "accepted format: 02.01.2006")
+	})
+}
+
+func TestSubscriptionService_AnyEntryForAdmin(t *testing.T) {
+	foreign := &models.Entry{ID: 7, ServiceName: "Netflix", UserUID: "owner"}
+
+	t.Run("чтение подписки любого пользователя", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		cache.On("Get", "subscription:7", mock.Anything).Return(false, nil).Once()
+		repo.On("ReadEntry", mock.Anything, 7).Return(foreign, nil).Once()
+		cache.On("Set", "subscription:7", foreign, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.ReadAnyEntry(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, foreign, got)
+		repo.AssertNotCalled(t, "ReadEntryForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("чтение несуществующей подписки", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		cache.On("Get", "subscription:8", mock.Anything).Return(false, nil).Once()
+		repo.On("ReadEntry", mock.Anything, 8).Return(nil, sql.ErrNoRows).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.ReadAnyEntry(context.Background(), 8)
+		assert.ErrorIs(t, err, models.ErrSubscriptionNotFound)
+	})
+
+	t.Run("удаление подписки любого пользователя", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		cache.On("Invalidate", "subscription:7").Return(nil).Once()
+		repo.On("RemoveEntry", mock.Anything, 7).Return(1, nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		count, err := svc.RemoveAnyEntry(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		repo.AssertNotCalled(t, "RemoveEntryForUser", mock.Anything, mock.Anything, mock.Anything)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("удаление несуществующей подписки", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		cache.On("Invalidate", "subscription:8").Return(nil).Once()
+		repo.On("RemoveEntry", mock.Anything, 8).Return(0, nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.RemoveAnyEntry(context.Background(), 8)
+		assert.ErrorIs(t, err, models.ErrSubscriptionNotFound)
+	})
+}
+
+func TestSubscriptionService_UpdateEndedPeriod(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	ended := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-01-01", CounterMonths: 3, IsActive: true}
+	current := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-01-01", CounterMonths: 12, IsActive: true}
+
+	t.Run("срок закончился в прошлом", func(t *testing.T) {
+		repo := new(RepoMock)
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.UpdateEntry(context.Background(), ended, 1, "uid1", "user1")
+		assert.ErrorIs(t, err, models.ErrEndDateInPast)
+		repo.AssertNotCalled(t, "ReadEntryForUser", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "UpdateEntry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("срок заканчивается в будущем", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
+		repo.On("UpdateEntry", mock.Anything, mock.Anything, 1, "user1").Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		res, err := svc.UpdateEntry(context.Background(), current, 1, "uid1", "user1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, res)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("обновление закончившейся подписки разрешено настройкой", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
+		repo.On("UpdateEntry", mock.Anything, mock.Anything, 1, "user1").Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{AllowEndedUpdates: true}, newNoopLogger())
+
+		_, err := svc.UpdateEntry(context.Background(), ended, 1, "uid1", "user1")
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSubscriptionService_UpdateNextPaymentDate(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	// Подписка с 1 января на 12 месяцев: ближайший платеж после сегодняшнего дня — 1 июля
+	want := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	repo := new(RepoMock)
+	cache := new(CacheMock)
+	repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
+	repo.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(e models.Entry) bool {
+		return e.NextPaymentDate.Equal(want)
+	}), 1, "user1").Return(1, nil).Once()
+	cache.On("Set", "subscription:1", mock.MatchedBy(func(e models.Entry) bool {
+		return e.NextPaymentDate.Equal(want)
+	}), time.Hour).Return(nil).Once()
+	svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+	_, err := svc.UpdateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-01-01", CounterMonths: 12, IsActive: true}, 1, "uid1", "user1")
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestSubscriptionService_NotesAndMetadata(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	notes := "  общая с семьей "
+	base := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-07-01", CounterMonths: 12, IsActive: true}
+
+	t.Run("создание сохраняет заметку и метаданные", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(e models.Entry) bool {
+			return e.Notes == "общая с семьей" && string(e.Metadata) == `{"shared_with":2}`
+		})).Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		req := base
+		req.Notes = &notes
+		req.Metadata = json.RawMessage(`{ "shared_with": 2 }`)
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("некорректные метаданные отклоняются", func(t *testing.T) {
+		repo := new(RepoMock)
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		req := base
+		req.Metadata = json.RawMessage(`["family"]`)
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+
+		long := strings.Repeat("a", models.MaxNotesLength+1)
+		req = base
+		req.Notes = &long
+		_, err = svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.ErrorIs(t, err, models.ErrInvalidNotes)
+		repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+	})
+
+	current := &models.Entry{ID: 1, UserUID: "uid1", Notes: "старая заметка", Metadata: json.RawMessage(`{"a":1}`)}
+	updateTests := []struct {
+		name         string
+		notes        *string
+		metadata     json.RawMessage
+		wantNotes    string
+		wantMetadata string
+	}{
+		{name: "без полей в запросе сохраняются текущие", wantNotes: "старая заметка", wantMetadata: `{"a":1}`},
+		{name: "новые значения заменяют текущие", notes: &notes, metadata: json.RawMessage(`{"b":2}`),
+			wantNotes: "общая с семьей", wantMetadata: `{"b":2}`},
+		{name: "пустая заметка и null удаляют значения", notes: new(string), metadata: json.RawMessage(`null`)},
+	}
+	for _, tt := range updateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(current, nil).Once()
+			repo.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(e models.Entry) bool {
+				return e.Notes == tt.wantNotes && string(e.Metadata) == tt.wantMetadata
+			}), 1, "user1").Return(1, nil).Once()
+			cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+			svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+			req := base
+			req.Notes, req.Metadata = tt.notes, tt.metadata
+			_, err := svc.UpdateEntry(context.Background(), req, 1, "uid1", "user1")
+			require.NoError(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSubscriptionService_CancelSavings(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		entry       models.Entry
+		wantMonths  int
+		wantSavings int
+	}{
+		{
+			name:       "срок длиннее года — экономия за 12 списаний",
+			entry:      models.Entry{StartDate: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), CounterMonths: 36, Price: 799, IsActive: true},
+			wantMonths: 12, wantSavings: 12 * 799,
+		},
+		{
+			name:       "срок заканчивается раньше года",
+			entry:      models.Entry{StartDate: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), CounterMonths: 9, Price: 299, IsActive: true},
+			wantMonths: 3, wantSavings: 3 * 299,
+		},
+		{
+			name:       "подписка начнется через два месяца",
+			entry:      models.Entry{StartDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 6, Price: 1500, IsActive: true},
+			wantMonths: 6, wantSavings: 6 * 1500,
+		},
+		{
+			name:  "приостановленная подписка ничего не списывает",
+			entry: models.Entry{StartDate: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), CounterMonths: 36, Price: 799, IsActive: false},
+		},
+		{
+			name:  "закончившаяся подписка",
+			entry: models.Entry{StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12, Price: 799, IsActive: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			entry := tt.entry
+			entry.UserUID, entry.ServiceName, entry.Currency = "uid1", "Netflix", "RUB"
+			repo.On("ReadEntryForUser", mock.Anything, 5, "uid1").Return(&entry, nil).Once()
+			svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+			got, err := svc.CancelSavings(context.Background(), "uid1", 5)
+			require.NoError(t, err)
+			assert.Equal(t, &models.CancelSavings{
+				ID: 5, ServiceName: "Netflix", Price: entry.Price, Currency: "RUB",
+				From: "2025-06-15", To: "2026-06-15", Months: tt.wantMonths, Savings: tt.wantSavings,
+			}, got)
+		})
+	}
+
+	t.Run("чужая подписка", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ReadEntryForUser", mock.Anything, 5, "uid1").Return(&models.Entry{UserUID: "uid2"}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.CancelSavings(context.Background(), "uid1", 5)
+		assert.ErrorIs(t, err, models.ErrSubscriptionNotFound)
+	})
+}
+
+func TestSubscriptionService_EntryLimits(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	limits := models.EntryLimits{MaxPrice: 10000, MaxCounterMonths: 24, Currencies: []string{"RUB", "USD"}}
+	valid := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-07-01", CounterMonths: 12}
+
+	tests := []struct {
+		name    string
+		modify  func(*models.DummyEntry)
+		wantErr string
+	}{
+		{
+			name:    "цена выше предела",
+			modify:  func(e *models.DummyEntry) { e.Price = 10001 },
+			wantErr: "subscription is out of allowed limits: price must not exceed 10000",
+		},
+		{
+			name:    "срок больше предела",
+			modify:  func(e *models.DummyEntry) { e.CounterMonths = 25 },
+			wantErr: "subscription is out of allowed limits: counter_months must not exceed 24",
+		},
+		{
+			name:    "неподдерживаемая валюта",
+			modify:  func(e *models.DummyEntry) { e.Currency = "kzt" },
+			wantErr: "subscription is out of allowed limits: currency must be one of RUB, USD",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, limits, newNoopLogger())
+			req := valid
+			tt.modify(&req)
+
+			_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+			assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+			assert.EqualError(t, err, tt.wantErr)
+
+			_, err = svc.ValidateEntry(context.Background(), req)
+			assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+			_, err = svc.PreviewEntry(context.Background(), req)
+			assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+			repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("значения на границе принимаются", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntry", mock.Anything, mock.Anything).Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, limits, newNoopLogger())
+
+		req := valid
+		req.Price, req.CounterMonths, req.Currency = 10000, 24, "usd"
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("обновление сохраняет прежнюю валюту вне списка", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1", Currency: "KZT"}, nil).Once()
+		repo.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(e models.Entry) bool {
+			return e.Currency == "KZT"
+		}), 1, "user1").Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, limits, newNoopLogger())
+
+		_, err := svc.UpdateEntry(context.Background(), valid, 1, "uid1", "user1")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("смена валюты на неподдерживаемую отклоняется", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1", Currency: "RUB"}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, limits, newNoopLogger())
+
+		req := valid
+		req.Currency, req.CurrencyChanged = "KZT", true
+		_, err := svc.UpdateEntry(context.Background(), req, 1, "uid1", "user1")
+		assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+		repo.AssertNotCalled(t, "UpdateEntry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSubscriptionService_MaxActivePerService(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	limits := models.EntryLimits{MaxActivePerService: 1}
+	req := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-07-01", CounterMonths: 12}
+	active := &models.Entry{ID: 1, UserUID: "uid1", ServiceName: "Netflix", StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12, IsActive: true}
+	paused := &models.Entry{ID: 2, UserUID: "uid1", ServiceName: "netflix", StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12}
+	expired := &models.Entry{ID: 3, UserUID: "uid1", ServiceName: "Netflix", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 1, IsActive: true}
+
+	t.Run("создание сверх предела отклоняется", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("FindByServiceName", mock.Anything, "uid1", "Netflix").Return([]*models.Entry{active, paused, expired}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, limits, newNoopLogger())
+
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.ErrorIs(t, err, models.ErrActiveLimitExceeded)
+		assert.EqualError(t, err, "too many active subscriptions for service: at most 1 active subscriptions for Netflix")
+		repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+	})
+
+	t.Run("приостановленные и истекшие не считаются", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("FindByServiceName", mock.Anything, "uid1", "Netflix").Return([]*models.Entry{paused, expired}, nil).Once()
+		repo.On("CreateEntry", mock.Anything, mock.Anything).Return(4, nil).Once()
+		cache.On("Set", "subscription:4", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, limits, newNoopLogger())
+
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("без предела подписки не подсчитываются", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntry", mock.Anything, mock.Anything).Return(4, nil).Once()
+		cache.On("Set", "subscription:4", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.CreateEntry(context.Background(), "user1", "uid1", req)
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "FindByServiceName", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("обновление самой активной подписки не считается повторно", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(active, nil).Once()
+		repo.On("FindByServiceName", mock.Anything, "uid1", "Netflix").Return([]*models.Entry{active}, nil).Once()
+		repo.On("UpdateEntry", mock.Anything, mock.Anything, 1, "user1").Return(1, nil).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, limits, newNoopLogger())
+
+		update := req
+		update.IsActive = true
+		_, err := svc.UpdateEntry(context.Background(), update, 1, "uid1", "user1")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("массовая активация сверх предела отклоняется", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ReadEntryForUser", mock.Anything, 2, "uid1").Return(paused, nil).Once()
+		repo.On("FindByServiceName", mock.Anything, "uid1", "netflix").Return([]*models.Entry{active, paused}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, limits, newNoopLogger())
+
+		_, err := svc.BulkUpdateStatus(context.Background(), "uid1", models.BulkStatusRequest{IDs: []int{2}, Status: models.EntryStatusActive})
+		assert.ErrorIs(t, err, models.ErrActiveLimitExceeded)
+		repo.AssertNotCalled(t, "BulkUpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSubscriptionService_ReactivateEntry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("истекшая подписка начинается заново", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		expired := &models.Entry{
+			ID: 1, ServiceName: "Netflix", Price: 799, Username: "user1", UserUID: "uid1", Currency: "USD", Notes: "семейная",
+			StartDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), CounterMonths: 3,
+			NextPaymentDate: time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC), IsActive: true,
+		}
+		want := models.Entry{
+			ID: 1, ServiceName: "Netflix", Price: 799, Username: "user1", UserUID: "uid1", Currency: "USD", Notes: "семейная",
+			StartDate: today, CounterMonths: 3, NextPaymentDate: time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC), IsActive: true,
+		}
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(expired, nil).Once()
+		repo.On("ReactivateEntry", mock.Anything, 1, "uid1", today, want.NextPaymentDate).Return(1, nil).Once()
+		cache.On("Set", "subscription:1", want, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.ReactivateEntry(context.Background(), "uid1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, &want, got)
+		assert.Equal(t, time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC), expired.NextPaymentDate)
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("активная подписка не возобновляется", func(t *testing.T) {
+		repo := new(RepoMock)
+		active := &models.Entry{ID: 1, ServiceName: "Netflix", UserUID: "uid1", StartDate: today.AddDate(0, -1, 0), CounterMonths: 12, IsActive: true}
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(active, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.ReactivateEntry(context.Background(), "uid1", 1)
+		assert.ErrorIs(t, err, models.ErrSubscriptionAlreadyActive)
+		repo.AssertNotCalled(t, "ReactivateEntry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("чужая подписка", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid2"}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.ReactivateEntry(context.Background(), "uid1", 1)
+		assert.ErrorIs(t, err, models.ErrSubscriptionNotFound)
+		repo.AssertNotCalled(t, "ReactivateEntry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("подписка удалена до обновления", func(t *testing.T) {
+		repo := new(RepoMock)
+		expired := &models.Entry{ID: 1, ServiceName: "Netflix", UserUID: "uid1", StartDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), CounterMonths: 3}
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(expired, nil).Once()
+		repo.On("ReactivateEntry", mock.Anything, 1, "uid1", today, mock.Anything).Return(0, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.ReactivateEntry(context.Background(), "uid1", 1)
+		assert.ErrorIs(t, err, models.ErrSubscriptionNotFound)
+		repo.AssertExpectations(t)
+	})
+}
+
 func TestSubscriptionService_Update(t *testing.T) {
 	now := time.Now()
 	entry := models.DummyEntry{
@@ -188,10 +810,12 @@ func TestSubscriptionService_Update(t *testing.T) {
 		username   string
 		wantRes    int
 		wantErr    bool
+		wantErrIs  error
 	}{
 		{
 			name: "success update",
 			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
 				r.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(req models.Entry) bool {
 					// Парсим дату из DummyEntry для сравнения
 					startDate, _ := time.Parse("02-01-2006", entry.StartDate)
@@ -229,6 +853,7 @@ func TestSubscriptionService_Update(t *testing.T) {
 		{
 			name: "cache set error logs warning but returns res",
 			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
 				r.On("UpdateEntry", mock.Anything, mock.Anything, 1, "user1").Return(1, nil).Once()
 				c.On("Set", "subscription:1", mock.Anything, time.Hour).Return(errors.New("redis down")).Once()
 			},
@@ -238,6 +863,72 @@ func TestSubscriptionService_Update(t *testing.T) {
 			wantRes:  1,
 			wantErr:  false,
 		},
+		{
+			name: "subscription of another user",
+			setupMocks: func(r *RepoMock, _ *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid2"}, nil).Once()
+			},
+			req:      entry,
+			id:       1,
+			username: "user1",
+			wantErr:  true,
+		},
+		{
+			name: "subscription removed after ownership check",
+			setupMocks: func(r *RepoMock, _ *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
+				r.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(req models.Entry) bool {
+					return req.UserUID == "uid1"
+				}), 1, "user1").Return(0, nil).Once()
+			},
+			req:       entry,
+			id:        1,
+			username:  "user1",
+			wantErr:   true,
+			wantErrIs: models.ErrSubscriptionNotFound,
+		},
+		{
+			name: "same currency keeps subscription currency",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1", Currency: "RUB"}, nil).Once()
+				r.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(req models.Entry) bool {
+					return req.Currency == "RUB" && req.Price == 700
+				}), 1, "user1").Return(1, nil).Once()
+				c.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+			},
+			req: models.DummyEntry{ServiceName: "Netflix", Price: 700, StartDate: entry.StartDate,
+				CounterMonths: 5, IsActive: true, Currency: "rub"},
+			id:       1,
+			username: "user1",
+			wantRes:  1,
+		},
+		{
+			name: "other currency without explicit change is rejected",
+			setupMocks: func(r *RepoMock, _ *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1", Currency: "RUB"}, nil).Once()
+			},
+			req: models.DummyEntry{ServiceName: "Netflix", Price: 10, StartDate: entry.StartDate,
+				CounterMonths: 5, IsActive: true, Currency: "USD"},
+			id:        1,
+			username:  "user1",
+			wantErr:   true,
+			wantErrIs: models.ErrCurrencyMismatch,
+		},
+		{
+			name: "explicit currency change stores new currency",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1", Currency: "RUB"}, nil).Once()
+				r.On("UpdateEntry", mock.Anything, mock.MatchedBy(func(req models.Entry) bool {
+					return req.Currency == "USD" && req.Price == 10
+				}), 1, "user1").Return(1, nil).Once()
+				c.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+			},
+			req: models.DummyEntry{ServiceName: "Netflix", Price: 10, StartDate: entry.StartDate,
+				CounterMonths: 5, IsActive: true, Currency: "USD", CurrencyChanged: true},
+			id:       1,
+			username: "user1",
+			wantRes:  1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -247,13 +938,16 @@ func TestSubscriptionService_Update(t *testing.T) {
 			// Создаем логгер с уровнем DEBUG для отладки
 			h := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 			logger := slog.New(h)
-			svc := NewSubscriptionService(repo, cache, logger)
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, logger)
 
 			tt.setupMocks(repo, cache)
 
-			res, err := svc.UpdateEntry(context.Background(), tt.req, tt.id, tt.username)
+			res, err := svc.UpdateEntry(context.Background(), tt.req, tt.id, "uid1", tt.username)
 			if tt.wantErr {
 				assert.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.wantRes, res)
@@ -276,8 +970,9 @@ func TestSubscriptionService_Remove(t *testing.T) {
 		{
 			name: "success remove",
 			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(&models.Entry{ID: 1, UserUID: "uid1"}, nil).Once()
 				c.On("Invalidate", "subscription:1").Return(nil).Once()
-				r.On("RemoveEntry", mock.Anything, 1).Return(1, nil).Once()
+				r.On("RemoveEntryForUser", mock.Anything, 1, "uid1").Return(1, nil).Once()
 			},
 			id:        1,
 			wantCount: 1,
@@ -286,8 +981,9 @@ func TestSubscriptionService_Remove(t *testing.T) {
 		{
 			name: "cache invalidate error but proceed",
 			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 2, "uid1").Return(&models.Entry{ID: 2, UserUID: "uid1"}, nil).Once()
 				c.On("Invalidate", "subscription:2").Return(errors.New("cache fail")).Once()
-				r.On("RemoveEntry", mock.Anything, 2).Return(1, nil).Once()
+				r.On("RemoveEntryForUser", mock.Anything, 2, "uid1").Return(1, nil).Once()
 			},
 			id:        2,
 			wantCount: 1,
@@ -296,24 +992,43 @@ func TestSubscriptionService_Remove(t *testing.T) {
 		{
 			name: "repo remove error",
 			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 3, "uid1").Return(&models.Entry{ID: 3, UserUID: "uid1"}, nil).Once()
 				c.On("Invalidate", "subscription:3").Return(nil).Once()
-				r.On("RemoveEntry", mock.Anything, 3).Return(0, errors.New("not found")).Once()
+				r.On("RemoveEntryForUser", mock.Anything, 3, "uid1").Return(0, errors.New("db error")).Once()
 			},
 			id:        3,
 			wantCount: 0,
 			wantErr:   true,
 		},
+		{
+			name: "subscription of another user is not removed",
+			setupMocks: func(r *RepoMock, _ *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 4, "uid1").Return(&models.Entry{ID: 4, UserUID: "uid2"}, nil).Once()
+			},
+			id:      4,
+			wantErr: true,
+		},
+		{
+			name: "subscription removed after ownership check",
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("ReadEntryForUser", mock.Anything, 5, "uid1").Return(&models.Entry{ID: 5, UserUID: "uid1"}, nil).Once()
+				c.On("Invalidate", "subscription:5").Return(nil).Once()
+				r.On("RemoveEntryForUser", mock.Anything, 5, "uid1").Return(0, nil).Once()
+			},
+			id:      5,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(RepoMock)
 			cache := new(CacheMock)
-			svc := NewSubscriptionService(repo, cache, newNoopLogger())
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
 
 			tt.setupMocks(repo, cache)
 
-			count, err := svc.RemoveEntry(context.Background(), tt.id)
+			count, err := svc.RemoveEntry(context.Background(), "uid1", tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -327,6 +1042,98 @@ func TestSubscriptionService_Remove(t *testing.T) {
 	}
 }
 
+func TestSubscriptionService_WarmupCache(t *testing.T) {
+	newest := models.ListSort{Field: models.SortByID, Desc: true}
+	plans := []models.Plan{{ID: 1, Code: "standard", Price: 20000, Currency: "RUB", IsDefault: true}}
+	entries := []*models.Entry{
+		{ID: 3, ServiceName: "Netflix", UserUID: "uid1", IsActive: true},
+		{ID: 2, ServiceName: "Spotify", UserUID: "uid2", IsActive: false},
+		{ID: 1, ServiceName: "Yandex Plus", UserUID: "uid1", IsActive: true},
+	}
+	// Полные подписки, как их возвращает ReadEntryForUser: с заметкой и метаданными
+	netflix := &models.Entry{ServiceName: "Netflix", UserUID: "uid1", IsActive: true,
+		Notes: "общая с семьей", Metadata: json.RawMessage(`{"shared_with":2}`)}
+	yandex := &models.Entry{ServiceName: "Yandex Plus", UserUID: "uid1", IsActive: true}
+
+	t.Run("тарифы и активные подписки записываются в кеш", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ListPlans", mock.Anything).Return(plans, nil).Once()
+		repo.On("ListAllEntrys", mock.Anything, 100, 0, newest).Return(entries, nil).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 3, "uid1").Return(netflix, nil).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(yandex, nil).Once()
+		cache.On("Set", PlansCacheKey, plans, time.Hour).Return(nil).Once()
+		cache.On("Set", "subscription:3", netflix, time.Hour).Return(nil).Once()
+		cache.On("Set", "subscription:1", yandex, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		warmed, err := svc.WarmupCache(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, 2, warmed)
+		cache.AssertExpectations(t)
+		cache.AssertNotCalled(t, "Set", "subscription:2", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка кеша не прерывает прогрев", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ListPlans", mock.Anything).Return(plans, nil).Once()
+		repo.On("ListAllEntrys", mock.Anything, 100, 0, newest).Return(entries, nil).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 3, "uid1").Return(netflix, nil).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(yandex, nil).Once()
+		cache.On("Set", PlansCacheKey, mock.Anything, time.Hour).Return(errors.New("redis unavailable")).Once()
+		cache.On("Set", "subscription:3", mock.Anything, time.Hour).Return(errors.New("redis unavailable")).Once()
+		cache.On("Set", "subscription:1", mock.Anything, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		warmed, err := svc.WarmupCache(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, 1, warmed)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("ошибка чтения подписки не прерывает прогрев", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ListPlans", mock.Anything).Return(plans, nil).Once()
+		repo.On("ListAllEntrys", mock.Anything, 100, 0, newest).Return(entries, nil).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 3, "uid1").Return(nil, errors.New("db error")).Once()
+		repo.On("ReadEntryForUser", mock.Anything, 1, "uid1").Return(yandex, nil).Once()
+		cache.On("Set", PlansCacheKey, plans, time.Hour).Return(nil).Once()
+		cache.On("Set", "subscription:1", yandex, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		warmed, err := svc.WarmupCache(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, 1, warmed)
+		cache.AssertNotCalled(t, "Set", "subscription:3", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка загрузки тарифов", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListPlans", mock.Anything).Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		warmed, err := svc.WarmupCache(context.Background(), 100)
+		assert.ErrorContains(t, err, "db error")
+		assert.Zero(t, warmed)
+		repo.AssertNotCalled(t, "ListAllEntrys", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка загрузки подписок", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("ListPlans", mock.Anything).Return(plans, nil).Once()
+		repo.On("ListAllEntrys", mock.Anything, 100, 0, newest).Return(nil, errors.New("db error")).Once()
+		cache.On("Set", PlansCacheKey, plans, time.Hour).Return(nil).Once()
+		svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		warmed, err := svc.WarmupCache(context.Background(), 100)
+		assert.ErrorContains(t, err, "db error")
+		assert.Zero(t, warmed)
+	})
+}
+
 func TestSubscriptionService_List(t *testing.T) {
 	entries := []*models.Entry{
 		{ServiceName: "Netflix", Username: "user1"},
@@ -351,7 +1158,7 @@ func TestSubscriptionService_List(t *testing.T) {
 			limit:    10,
 			offset:   0,
 			setupMocks: func(r *RepoMock) {
-				r.On("ListAllEntrys", mock.Anything, 10, 0).Return(entries, nil).Once()
+				r.On("ListAllEntrys", mock.Anything, 10, 0, models.ListSort{}).Return(entries, nil).Once()
 			},
 			want:    entries,
 			wantErr: false,
@@ -363,7 +1170,7 @@ func TestSubscriptionService_List(t *testing.T) {
 			limit:    5,
 			offset:   2,
 			setupMocks: func(r *RepoMock) {
-				r.On("ListEntrys", mock.Anything, "user1", 5, 2).Return(entries, nil).Once()
+				r.On("ListEntrys", mock.Anything, "user1", 5, 2, models.ListSort{}).Return(entries, nil).Once()
 			},
 			want:    entries,
 			wantErr: false,
@@ -375,7 +1182,7 @@ func TestSubscriptionService_List(t *testing.T) {
 			limit:    10,
 			offset:   0,
 			setupMocks: func(r *RepoMock) {
-				r.On("ListAllEntrys", mock.Anything, 10, 0).Return(nil, errors.New("db error")).Once()
+				r.On("ListAllEntrys", mock.Anything, 10, 0, models.ListSort{}).Return(nil, errors.New("db error")).Once()
 			},
 			want:    nil,
 			wantErr: true,
@@ -388,7 +1195,7 @@ func TestSubscriptionService_List(t *testing.T) {
 			limit:    10,
 			offset:   0,
 			setupMocks: func(r *RepoMock) {
-				r.On("ListEntrys", mock.Anything, "user1", 10, 0).Return(nil, errors.New("db error")).Once()
+				r.On("ListEntrys", mock.Anything, "user1", 10, 0, models.ListSort{}).Return(nil, errors.New("db error")).Once()
 			},
 			want:    nil,
 			wantErr: true,
@@ -401,7 +1208,7 @@ func TestSubscriptionService_List(t *testing.T) {
 			limit:    10,
 			offset:   0,
 			setupMocks: func(r *RepoMock) {
-				r.On("ListEntrys", mock.Anything, "user2", 10, 0).Return([]*models.Entry{}, nil).Once()
+				r.On("ListEntrys", mock.Anything, "user2", 10, 0, models.ListSort{}).Return([]*models.Entry{}, nil).Once()
 			},
 			want:    []*models.Entry{},
 			wantErr: false,
@@ -412,11 +1219,11 @@ func TestSubscriptionService_List(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(RepoMock)
 			cache := new(CacheMock)
-			svc := NewSubscriptionService(repo, cache, newNoopLogger())
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
 
 			tt.setupMocks(repo)
 
-			got, err := svc.ListEntrys(context.Background(), tt.username, tt.role, tt.limit, tt.offset)
+			got, err := svc.ListEntrys(context.Background(), tt.username, tt.role, tt.limit, tt.offset, models.ListSort{})
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.errMsg != "" {
@@ -432,6 +1239,114 @@ func TestSubscriptionService_List(t *testing.T) {
 	}
 }
 
+func TestSubscriptionService_FindByServiceName(t *testing.T) {
+	repo := new(RepoMock)
+	svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+
+	entries := []*models.Entry{{ID: 1, ServiceName: "Netflix"}, {ID: 2, ServiceName: "Netflix"}}
+	repo.On("FindByServiceName", mock.Anything, "user1", "Netflix").Return(entries, nil).Once()
+	repo.On("FindByServiceName", mock.Anything, "user1", "Unknown").Return(nil, nil).Once()
+	repo.On("FindByServiceName", mock.Anything, "user1", "Broken").Return(nil, errors.New("db error")).Once()
+
+	got, err := svc.FindByServiceName(context.Background(), "user1", "Netflix")
+	require.NoError(t, err)
+	assert.Equal(t, entries, got)
+
+	got, err = svc.FindByServiceName(context.Background(), "user1", "Unknown")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.NotNil(t, got)
+
+	_, err = svc.FindByServiceName(context.Background(), "user1", "Broken")
+	assert.ErrorContains(t, err, "db error")
+
+	repo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_BulkUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        models.BulkStatusRequest
+		setupMocks func(r *RepoMock, c *CacheMock)
+		want       []models.BulkStatusResult
+		wantErr    error
+	}{
+		{
+			name: "pause by ids",
+			req:  models.BulkStatusRequest{IDs: []int{1, 2, 3}, Status: models.EntryStatusPaused},
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("BulkUpdateStatus", mock.Anything, "user1", []int{1, 2, 3}, "", models.EntryStatusPaused).Return([]models.BulkStatusResult{
+					{ID: 1, Status: models.EntryStatusPaused},
+					{ID: 2, Error: "subscription not found"},
+					{ID: 3, Status: models.EntryStatusPaused},
+				}, nil).Once()
+				c.On("Invalidate", "subscription:1").Return(nil).Once()
+				c.On("Invalidate", "subscription:3").Return(nil).Once()
+			},
+			want: []models.BulkStatusResult{
+				{ID: 1, Status: models.EntryStatusPaused},
+				{ID: 2, Error: "subscription not found"},
+				{ID: 3, Status: models.EntryStatusPaused},
+			},
+		},
+		{
+			name: "pause by service name",
+			req:  models.BulkStatusRequest{ServiceName: " Netflix ", Status: models.EntryStatusPaused},
+			setupMocks: func(r *RepoMock, c *CacheMock) {
+				r.On("BulkUpdateStatus", mock.Anything, "user1", []int(nil), "Netflix", models.EntryStatusPaused).Return([]models.BulkStatusResult{
+					{ID: 4, Status: models.EntryStatusPaused},
+					{ID: 5, Status: models.EntryStatusPaused},
+				}, nil).Once()
+				c.On("Invalidate", "subscription:4").Return(errors.New("redis down")).Once()
+				c.On("Invalidate", "subscription:5").Return(nil).Once()
+			},
+			want: []models.BulkStatusResult{
+				{ID: 4, Status: models.EntryStatusPaused},
+				{ID: 5, Status: models.EntryStatusPaused},
+			},
+		},
+		{
+			name:       "neither ids nor service name",
+			req:        models.BulkStatusRequest{ServiceName: "  ", Status: models.EntryStatusPaused},
+			setupMocks: func(_ *RepoMock, _ *CacheMock) {},
+			wantErr:    models.ErrInvalidBulkRequest,
+		},
+		{
+			name:       "both ids and service name",
+			req:        models.BulkStatusRequest{IDs: []int{1}, ServiceName: "Netflix", Status: models.EntryStatusCanceled},
+			setupMocks: func(_ *RepoMock, _ *CacheMock) {},
+			wantErr:    models.ErrInvalidBulkRequest,
+		},
+		{
+			name: "repository error",
+			req:  models.BulkStatusRequest{IDs: []int{1}, Status: models.EntryStatusActive},
+			setupMocks: func(r *RepoMock, _ *CacheMock) {
+				r.On("BulkUpdateStatus", mock.Anything, "user1", []int{1}, "", models.EntryStatusActive).Return(nil, errors.New("db error")).Once()
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			tt.setupMocks(repo, cache)
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+			got, err := svc.BulkUpdateStatus(context.Background(), "user1", tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			repo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
 func TestSubscriptionService_Read(t *testing.T) {
 	fixedTime := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
 	entry := &models.Entry{
@@ -440,18 +1355,22 @@ func TestSubscriptionService_Read(t *testing.T) {
 		Username:      "user1",
 		StartDate:     fixedTime,
 		CounterMonths: 5,
+		UserUID:       "uid1",
 	}
+	foreign := &models.Entry{ServiceName: "Netflix", Username: "user2", UserUID: "uid2"}
 
 	tests := []struct {
-		name       string
-		id         int
-		cacheFound bool
-		cacheErr   error
-		repoEntry  *models.Entry
-		repoErr    error
-		wantEntry  *models.Entry
-		wantErr    bool
-		errMsg     string
+		name        string
+		id          int
+		cached      *models.Entry
+		cacheFound  bool
+		cacheErr    error
+		cacheSetErr error
+		repoEntry   *models.Entry
+		repoErr     error
+		wantEntry   *models.Entry
+		wantErr     bool
+		errMsg      string
 	}{
 		{
 			name:       "cache hit",
@@ -474,15 +1393,26 @@ func TestSubscriptionService_Read(t *testing.T) {
 			wantErr:    false,
 		},
 		{
-			name:       "cache error",
-			id:         3,
+			name:        "cache error falls back to repo",
+			id:          3,
+			cacheFound:  false,
+			cacheErr:    errors.New("cache unavailable"),
+			cacheSetErr: errors.New("cache unavailable"),
+			repoEntry:   entry,
+			repoErr:     nil,
+			wantEntry:   entry,
+			wantErr:     false,
+		},
+		{
+			name:       "cache error and repo error",
+			id:         6,
 			cacheFound: false,
 			cacheErr:   errors.New("cache unavailable"),
 			repoEntry:  nil,
-			repoErr:    nil,
+			repoErr:    errors.New("db down"),
 			wantEntry:  nil,
 			wantErr:    true,
-			errMsg:     "cache unavailable",
+			errMsg:     "db down",
 		},
 		{
 			name:       "repo error - not found",
@@ -503,7 +1433,32 @@ func TestSubscriptionService_Read(t *testing.T) {
 			repoEntry:  nil,
 			repoErr:    nil,
 			wantEntry:  nil,
-			wantErr:    false,
+			wantErr:    true,
+			errMsg:     models.ErrSubscriptionNotFound.Error(),
+		},
+		{
+			name:      "missing subscription is not found",
+			id:        7,
+			repoErr:   fmt.Errorf("storage.ReadEntryForUser: %w", sql.ErrNoRows),
+			wantErr:   true,
+			errMsg:    models.ErrSubscriptionNotFound.Error(),
+			wantEntry: nil,
+		},
+		{
+			name:      "subscription of another user is not found",
+			id:        8,
+			repoEntry: foreign,
+			wantErr:   true,
+			errMsg:    models.ErrSubscriptionNotFound.Error(),
+		},
+		{
+			name:       "cached subscription of another user is checked in repo",
+			id:         9,
+			cached:     foreign,
+			cacheFound: true,
+			repoEntry:  foreign,
+			wantErr:    true,
+			errMsg:     models.ErrSubscriptionNotFound.Error(),
 		},
 	}
 
@@ -511,7 +1466,7 @@ func TestSubscriptionService_Read(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(RepoMock)
 			cache := new(CacheMock)
-			svc := NewSubscriptionService(repo, cache, newNoopLogger())
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
 
 			cacheKey := fmt.Sprintf("subscription:%d", tt.id)
 
@@ -520,21 +1475,27 @@ func TestSubscriptionService_Read(t *testing.T) {
 					ptrPtr := args.Get(1).(**models.Entry)
 					if ptrPtr != nil {
 						*ptrPtr = entry
+						if tt.cached != nil {
+							*ptrPtr = tt.cached
+						}
 					}
 				}
 			}).Once()
 
-			if !tt.cacheFound && tt.cacheErr == nil {
-				repo.On("ReadEntry", mock.Anything, tt.id).Return(tt.repoEntry, tt.repoErr).Once()
+			if !tt.cacheFound || tt.cached != nil {
+				repo.On("ReadEntryForUser", mock.Anything, tt.id, "uid1").Return(tt.repoEntry, tt.repoErr).Once()
 
-				if tt.repoEntry != nil {
-					cache.On("Set", cacheKey, tt.repoEntry, time.Hour).Return(nil).Once()
+				if tt.repoEntry != nil && tt.repoEntry.UserUID == "uid1" {
+					cache.On("Set", cacheKey, tt.repoEntry, time.Hour).Return(tt.cacheSetErr).Once()
 				}
 			}
 
-			got, err := svc.ReadEntry(context.Background(), tt.id)
+			got, err := svc.ReadEntry(context.Background(), "uid1", tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
+				if tt.errMsg == models.ErrSubscriptionNotFound.Error() {
+					assert.Equal(t, models.ErrSubscriptionNotFound, err, "missing and foreign subscriptions look the same")
+				}
 				if tt.errMsg != "" {
 					assert.Contains(t, err.Error(), tt.errMsg)
 				}
@@ -556,7 +1517,7 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 
 	tests := []struct {
 		name       string
-		username   string
+		userUID    string
 		req        models.DummyFilterSum
 		setupMocks func(r *RepoMock)
 		wantSum    float64
@@ -564,8 +1525,8 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 		errMsg     string
 	}{
 		{
-			name:     "success with service name filter",
-			username: "user1",
+			name:    "success with service name filter",
+			userUID: "user1",
 			req: models.DummyFilterSum{
 				ServiceName:   "Netflix",
 				StartDate:     validDate,
@@ -573,7 +1534,7 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			},
 			setupMocks: func(r *RepoMock) {
 				r.On("CountSumEntrys", mock.Anything, mock.MatchedBy(func(f models.FilterSum) bool {
-					return f.Username == "user1" &&
+					return f.UserUID == "user1" &&
 						f.ServiceName != nil && *f.ServiceName == "Netflix" &&
 						f.StartDate.Equal(parsedDate) &&
 						f.CounterMonths == 5
@@ -583,8 +1544,8 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:     "success without service name filter",
-			username: "user2",
+			name:    "success without service name filter",
+			userUID: "user2",
 			req: models.DummyFilterSum{
 				ServiceName:   "",
 				StartDate:     validDate,
@@ -592,7 +1553,7 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			},
 			setupMocks: func(r *RepoMock) {
 				r.On("CountSumEntrys", mock.Anything, mock.MatchedBy(func(f models.FilterSum) bool {
-					return f.Username == "user2" &&
+					return f.UserUID == "user2" &&
 						f.ServiceName == nil &&
 						f.StartDate.Equal(parsedDate) &&
 						f.CounterMonths == 3
@@ -602,8 +1563,8 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:     "invalid start date format",
-			username: "user1",
+			name:    "invalid start date format",
+			userUID: "user1",
 			req: models.DummyFilterSum{
 				ServiceName:   "Netflix",
 				StartDate:     "invalid-date",
@@ -615,8 +1576,8 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			errMsg:     "invalid start date",
 		},
 		{
-			name:     "repo returns error",
-			username: "user1",
+			name:    "repo returns error",
+			userUID: "user1",
 			req: models.DummyFilterSum{
 				ServiceName:   "Netflix",
 				StartDate:     validDate,
@@ -630,8 +1591,8 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 			errMsg:  "database error",
 		},
 		{
-			name:     "zero months",
-			username: "user1",
+			name:    "zero months",
+			userUID: "user1",
 			req: models.DummyFilterSum{
 				ServiceName:   "Netflix",
 				StartDate:     validDate,
@@ -651,11 +1612,11 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := new(RepoMock)
 			cache := new(CacheMock)
-			svc := NewSubscriptionService(repo, cache, newNoopLogger())
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
 
 			tt.setupMocks(repo)
 
-			got, err := svc.CountSumWithFilter(context.Background(), tt.username, tt.req)
+			got, err := svc.CountSumWithFilter(context.Background(), tt.userUID, tt.req)
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.errMsg != "" {
@@ -670,3 +1631,575 @@ func TestSubscriptionService_CountSumWithFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionService_PreviewEntry(t *testing.T) {
+	start := time.Now().AddDate(0, 1, 0)
+
+	tests := []struct {
+		name      string
+		req       models.DummyEntry
+		wantTotal float64
+		wantErr   error
+	}{
+		{
+			name:      "valid preview",
+			req:       models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: start.Format("02-01-2006"), CounterMonths: 12},
+			wantTotal: 6000,
+		},
+		{
+			name:    "invalid date",
+			req:     models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2030/01/01", CounterMonths: 12},
+			wantErr: models.ErrInvalidStartDate,
+		},
+		{
+			name:    "end date in the past",
+			req:     models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-01-2000", CounterMonths: 1},
+			wantErr: models.ErrEndDateInPast,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(RepoMock)
+			cache := new(CacheMock)
+			svc := NewSubscriptionService(repo, cache, nil, nil, models.EntryLimits{}, newNoopLogger())
+
+			got, err := svc.PreviewEntry(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.wantTotal, got.Total, 0.001)
+				assert.Equal(t, tt.req.StartDate, got.StartDate)
+				assert.Equal(t, start.AddDate(0, tt.req.CounterMonths, 0).Format("02-01-2006"), got.EndDate)
+			}
+
+			// Предварительный расчет не обращается к хранилищу и кешу
+			repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+			cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSubscriptionService_ValidateEntry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	repo := new(RepoMock)
+	cache := new(CacheMock)
+	svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+	got, err := svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "  Netflix ", Price: 500, StartDate: "01-07-2025", CounterMonths: 12, IsActive: false})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-07-2025", CounterMonths: 12, IsActive: true}, got)
+
+	// Дата в другом допустимом формате возвращается в формате хранения
+	got, err = svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "2025-07-01", CounterMonths: 12})
+	assert.NoError(t, err)
+	assert.Equal(t, "01-07-2025", got.StartDate)
+
+	_, err = svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01.07.2025", CounterMonths: 12})
+	assert.ErrorIs(t, err, models.ErrInvalidStartDate)
+
+	_, err = svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: " ", Price: 500, StartDate: "01-07-2025", CounterMonths: 12})
+	assert.ErrorIs(t, err, models.ErrInvalidServiceName)
+
+	// Проверка не обращается к хранилищу и кешу
+	repo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything)
+	cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_ValidateEntryCurrency(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	svc := NewSubscriptionService(new(RepoMock), new(CacheMock), clk, nil,
+		models.EntryLimits{Currencies: []string{"RUB", "USD"}}, newNoopLogger())
+
+	// Валюта возвращается в том виде, в котором будет сохранена
+	got, err := svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-07-2025", CounterMonths: 12, Currency: " usd "})
+	require.NoError(t, err)
+	assert.Equal(t, "USD", got.Currency)
+
+	// Валюта вне списка допустимых отклоняется так же, как при создании
+	_, err = svc.ValidateEntry(context.Background(),
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-07-2025", CounterMonths: 12, Currency: "EUR"})
+	assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+	_, err = svc.CreateEntry(context.Background(), "john", "user123",
+		models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-07-2025", CounterMonths: 12, Currency: "EUR"})
+	assert.ErrorIs(t, err, models.ErrEntryOutOfLimits)
+}
+
+func TestSubscriptionService_PreviewEntryAcrossMonthBoundary(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC))
+	svc := NewSubscriptionService(new(RepoMock), new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+	req := models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "28-01-2025", CounterMonths: 2}
+
+	got, err := svc.PreviewEntry(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "28-02-2025", got.NextPaymentDate)
+
+	// После перехода на март февральское списание уже в прошлом
+	clk.Advance(24 * time.Hour)
+	got, err = svc.PreviewEntry(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "28-03-2025", got.NextPaymentDate)
+
+	// После окончания подписки предпросмотр отклоняется
+	clk.AddDate(0, 1, 0)
+	_, err = svc.PreviewEntry(context.Background(), req)
+	assert.ErrorIs(t, err, models.ErrEndDateInPast)
+}
+
+func TestSubscriptionService_GroupEntrysByStatus(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	active1 := &models.Entry{ID: 1, ServiceName: "Netflix", Price: 500, IsActive: true,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12}
+	active2 := &models.Entry{ID: 2, ServiceName: "Spotify", Price: 300, IsActive: true,
+		StartDate: time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC), CounterMonths: 1} // заканчивается сегодня
+	paused := &models.Entry{ID: 3, ServiceName: "Yandex Plus", Price: 250, IsActive: false,
+		StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 6}
+	expired1 := &models.Entry{ID: 4, ServiceName: "Okko", Price: 400, IsActive: true,
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 3}
+	expired2 := &models.Entry{ID: 5, ServiceName: "Ivi", Price: 200, IsActive: false,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 2}
+
+	t.Run("подписки с разными статусами", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").
+			Return([]*models.Entry{active1, paused, expired1, active2, expired2}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.GroupEntrysByStatus(context.Background(), "user123")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, got.Active.Count)
+		assert.Equal(t, 800, got.Active.Total)
+		assert.Equal(t, []*models.Entry{active1, active2}, got.Active.Entries)
+		assert.Equal(t, 1, got.Paused.Count)
+		assert.Equal(t, 250, got.Paused.Total)
+		assert.Equal(t, []*models.Entry{paused}, got.Paused.Entries)
+		assert.Equal(t, 2, got.Expired.Count)
+		assert.Equal(t, 600, got.Expired.Total)
+		assert.Equal(t, []*models.Entry{expired1, expired2}, got.Expired.Entries)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("нет подписок", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").Return(nil, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.GroupEntrysByStatus(context.Background(), "user123")
+		require.NoError(t, err)
+
+		for _, group := range []models.EntryGroup{got.Active, got.Paused, got.Expired} {
+			assert.Zero(t, group.Count)
+			assert.Zero(t, group.Total)
+			assert.NotNil(t, group.Entries)
+			assert.Empty(t, group.Entries)
+		}
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.GroupEntrysByStatus(context.Background(), "user123")
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestSubscriptionService_EstimateYearlyCost(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	netflix := &models.Entry{ID: 1, Price: 500, Currency: "RUB", IsActive: true, StartDate: start, CounterMonths: 12}
+	spotify := &models.Entry{ID: 2, Price: 300, Currency: "RUB", IsActive: true, StartDate: start, CounterMonths: 12}
+	youtube := &models.Entry{ID: 3, Price: 10, Currency: "USD", IsActive: true, StartDate: start, CounterMonths: 12}
+	paused := &models.Entry{ID: 4, Price: 250, Currency: "RUB", IsActive: false, StartDate: start, CounterMonths: 12}
+	expired := &models.Entry{ID: 5, Price: 400, Currency: "RUB", IsActive: true, StartDate: start, CounterMonths: 2}
+
+	t.Run("активные подписки по валютам", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").
+			Return([]*models.Entry{youtube, netflix, paused, expired, spotify}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.EstimateYearlyCost(context.Background(), "user123")
+		require.NoError(t, err)
+		assert.Equal(t, []models.YearlyEstimate{
+			{Currency: "RUB", Count: 2, Total: 9600},
+			{Currency: "USD", Count: 1, Total: 120},
+		}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("нет подписок", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").Return(nil, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.EstimateYearlyCost(context.Background(), "user123")
+		require.NoError(t, err)
+		assert.NotNil(t, got)
+		assert.Empty(t, got)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListEntrysByUserUID", mock.Anything, "user123").Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.EstimateYearlyCost(context.Background(), "user123")
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestSubscriptionService_ImportEntries(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	valid := &models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-06-2025", CounterMonths: 12, IsActive: true}
+	ended := &models.DummyEntry{ServiceName: "Okko", Price: 400, StartDate: "01-01-2024", CounterMonths: 1, IsActive: true}
+	failing := &models.DummyEntry{ServiceName: "Spotify", Price: 300, StartDate: "10-06-2025", CounterMonths: 12, IsActive: true}
+	badName := &models.DummyEntry{ServiceName: "Ivi\x07", Price: 300, StartDate: "10-06-2025", CounterMonths: 12, IsActive: true}
+	rows := []models.ImportRow{
+		{Line: 2, Entry: valid},
+		{Line: 3, Error: "invalid amount \"abc\""},
+		{Line: 4, Entry: ended},
+		{Line: 5, Entry: failing},
+		{Line: 6, Entry: badName},
+	}
+
+	repo := new(RepoMock)
+	cache := new(CacheMock)
+	repo.On("CreateEntries", mock.Anything, mock.MatchedBy(func(e []models.Entry) bool {
+		return len(e) == 1 && e[0].ServiceName == "Netflix" && e[0].UserUID == "user123" && e[0].Username == "testuser"
+	})).Return([]int{11}, nil).Once()
+	repo.On("CreateEntries", mock.Anything, mock.MatchedBy(func(e []models.Entry) bool {
+		return len(e) == 1 && e[0].ServiceName == "Spotify"
+	})).Return(nil, errors.New("db error")).Once()
+	cache.On("Set", "subscription:11", mock.Anything, time.Hour).Return(nil).Once()
+	svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+	got := svc.ImportEntries(context.Background(), "testuser", "user123", rows, 1)
+
+	require.Len(t, got, 5)
+	assert.Equal(t, models.ImportRow{Line: 2, Entry: valid, ID: 11}, got[0])
+	assert.Equal(t, rows[1], got[1], "строка с ошибкой разбора не импортируется")
+	assert.Equal(t, 4, got[2].Line)
+	assert.Zero(t, got[2].ID)
+	assert.Equal(t, models.ErrEndDateInPast.Error(), got[2].Error)
+	assert.Zero(t, got[3].ID)
+	assert.Equal(t, "could not create subscription", got[3].Error)
+	assert.Zero(t, got[4].ID)
+	assert.Contains(t, got[4].Error, "field ServiceName must not contain control characters")
+	assert.Empty(t, rows[0].Error, "исходные строки не изменяются")
+	assert.Zero(t, rows[0].ID)
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ImportEntriesActiveLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	existing := &models.Entry{ID: 1, ServiceName: "Netflix", UserUID: "user123",
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12, IsActive: true}
+	netflix := func() *models.DummyEntry {
+		return &models.DummyEntry{ServiceName: "Netflix", Price: 500, StartDate: "01-06-2025", CounterMonths: 12, IsActive: true}
+	}
+	rows := []models.ImportRow{
+		{Line: 2, Entry: netflix()},
+		{Line: 3, Entry: &models.DummyEntry{ServiceName: "Spotify", Price: 300, StartDate: "01-06-2025", CounterMonths: 12, IsActive: true}},
+		{Line: 4, Entry: netflix()},
+		{Line: 5, Entry: netflix()},
+	}
+
+	repo := new(RepoMock)
+	cache := new(CacheMock)
+	// Первая пачка: одна новая подписка Netflix вместе с существующей укладывается в лимит
+	repo.On("FindByServiceName", mock.Anything, "user123", "Netflix").Return([]*models.Entry{existing}, nil).Once()
+	repo.On("FindByServiceName", mock.Anything, "user123", "Spotify").Return([]*models.Entry{}, nil).Once()
+	repo.On("CreateEntries", mock.Anything, mock.MatchedBy(func(e []models.Entry) bool {
+		return len(e) == 2 && e[0].ServiceName == "Netflix" && e[1].ServiceName == "Spotify"
+	})).Return([]int{11, 12}, nil).Once()
+	// Вторая пачка считается вместе с сохраненной первой и превышает лимит целиком
+	saved := &models.Entry{ID: 11, ServiceName: "Netflix", UserUID: "user123",
+		StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), CounterMonths: 12, IsActive: true}
+	repo.On("FindByServiceName", mock.Anything, "user123", "Netflix").Return([]*models.Entry{existing, saved}, nil).Once()
+	cache.On("Set", mock.Anything, mock.Anything, time.Hour).Return(nil).Twice()
+	svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{MaxActivePerService: 3}, newNoopLogger())
+
+	got := svc.ImportEntries(context.Background(), "testuser", "user123", rows, 2)
+
+	require.Len(t, got, 4)
+	assert.Equal(t, 11, got[0].ID)
+	assert.Equal(t, 12, got[1].ID)
+	for _, row := range got[2:] {
+		assert.Zero(t, row.ID)
+		assert.Contains(t, row.Error, models.ErrActiveLimitExceeded.Error())
+	}
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ImportEntriesBatches(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	const total, batchSize = 7, 3
+	rows := make([]models.ImportRow, total)
+	for i := range rows {
+		rows[i] = models.ImportRow{Line: i + 2, Entry: &models.DummyEntry{
+			ServiceName: fmt.Sprintf("Service %d", i), Price: 100 + i, StartDate: "01-06-2025", CounterMonths: 12,
+		}}
+	}
+	// batch проверяет, что пачка содержит подписки строк с first по last включительно
+	batch := func(first, last int) any {
+		return mock.MatchedBy(func(e []models.Entry) bool {
+			if len(e) != last-first+1 {
+				return false
+			}
+			for k, entry := range e {
+				if entry.ServiceName != fmt.Sprintf("Service %d", first+k) || entry.UserUID != "user123" {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	t.Run("все строки сохраняются несколькими пачками", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntries", mock.Anything, batch(0, 2)).Return([]int{101, 102, 103}, nil).Once()
+		repo.On("CreateEntries", mock.Anything, batch(3, 5)).Return([]int{104, 105, 106}, nil).Once()
+		repo.On("CreateEntries", mock.Anything, batch(6, 6)).Return([]int{107}, nil).Once()
+		cache.On("Set", mock.Anything, mock.Anything, time.Hour).Return(nil).Times(total)
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got := svc.ImportEntries(context.Background(), "testuser", "user123", rows, batchSize)
+
+		require.Len(t, got, total)
+		for i, row := range got {
+			assert.Empty(t, row.Error)
+			assert.Equal(t, 101+i, row.ID, "ID соответствует строке")
+		}
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("ошибка пачки не прерывает импорт остальных", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntries", mock.Anything, batch(0, 2)).Return([]int{101, 102, 103}, nil).Once()
+		repo.On("CreateEntries", mock.Anything, batch(3, 5)).Return(nil, errors.New("db error")).Once()
+		repo.On("CreateEntries", mock.Anything, batch(6, 6)).Return([]int{107}, nil).Once()
+		cache.On("Set", mock.Anything, mock.Anything, time.Hour).Return(nil).Times(4)
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got := svc.ImportEntries(context.Background(), "testuser", "user123", rows, batchSize)
+
+		var created, failed int
+		for i, row := range got {
+			if row.Error != "" {
+				failed++
+				assert.Equal(t, "could not create subscription", row.Error)
+				assert.Zero(t, row.ID)
+				continue
+			}
+			created++
+			assert.Equal(t, 101+i, row.ID)
+		}
+		assert.Equal(t, 4, created)
+		assert.Equal(t, 3, failed)
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("без размера пачки все строки в одной транзакции", func(t *testing.T) {
+		repo := new(RepoMock)
+		cache := new(CacheMock)
+		repo.On("CreateEntries", mock.Anything, batch(0, 6)).Return([]int{1, 2, 3, 4, 5, 6, 7}, nil).Once()
+		cache.On("Set", mock.Anything, mock.Anything, time.Hour).Return(nil).Times(total)
+		svc := NewSubscriptionService(repo, cache, clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got := svc.ImportEntries(context.Background(), "testuser", "user123", rows, 0)
+
+		assert.Equal(t, 7, got[6].ID)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSubscriptionService_CountSumDetailed(t *testing.T) {
+	parsedDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breakdown := &models.SumBreakdown{
+		Total:         1500,
+		Subscriptions: []models.SumContribution{{ID: 1, ServiceName: "Netflix", Amount: 1500}},
+	}
+
+	repo := new(RepoMock)
+	svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+	repo.On("CountSumEntrysDetailed", mock.Anything, mock.MatchedBy(func(f models.FilterSum) bool {
+		return f.UserUID == "user1" && f.ServiceName == nil && f.StartDate.Equal(parsedDate) && f.CounterMonths == 3
+	})).Return(breakdown, nil).Once()
+
+	got, err := svc.CountSumDetailed(context.Background(), "user1", models.DummyFilterSum{StartDate: "01-01-2024", CounterMonths: 3})
+	require.NoError(t, err)
+	assert.Equal(t, breakdown, got)
+
+	// Дата принимается в любом из форматов создания подписки
+	repo.On("CountSumEntrysDetailed", mock.Anything, mock.MatchedBy(func(f models.FilterSum) bool {
+		return f.StartDate.Equal(parsedDate)
+	})).Return(breakdown, nil).Once()
+	_, err = svc.CountSumDetailed(context.Background(), "user1", models.DummyFilterSum{StartDate: "2024-01-01", CounterMonths: 3})
+	require.NoError(t, err)
+
+	_, err = svc.CountSumDetailed(context.Background(), "user1", models.DummyFilterSum{StartDate: "01/01/2024", CounterMonths: 3})
+	assert.ErrorIs(t, err, models.ErrInvalidStartDate)
+	repo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ListAttention(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	t.Run("подписки из хранилища", func(t *testing.T) {
+		entries := []*models.AttentionEntry{
+			{ID: 2, ServiceName: "Spotify", NextPaymentDate: today, MissingPaymentToken: true},
+			{ID: 1, ServiceName: "Netflix", NextPaymentDate: today.AddDate(0, 0, 3), LastPaymentFailed: true},
+		}
+		repo := new(RepoMock)
+		repo.On("ListAttentionEntrys", mock.Anything, "user123", today).Return(entries, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.ListAttention(context.Background(), "user123")
+		require.NoError(t, err)
+		assert.Equal(t, entries, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListAttentionEntrys", mock.Anything, "user123", today).Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.ListAttention(context.Background(), "user123")
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestSubscriptionService_SpendTimeSeries(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+
+	t.Run("период заканчивается текущим месяцем", func(t *testing.T) {
+		series := []models.MonthlySpend{{Month: "2025-04", Total: 500}, {Month: "2025-05", Total: 0}, {Month: "2025-06", Total: 800}}
+		repo := new(RepoMock)
+		repo.On("SumEntrysByMonth", mock.Anything, "user123", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), 3).
+			Return(series, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.SpendTimeSeries(context.Background(), "user123", 3)
+		require.NoError(t, err)
+		assert.Equal(t, series, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("период через границу года", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("SumEntrysByMonth", mock.Anything, "user123", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), 12).
+			Return([]models.MonthlySpend{}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.SpendTimeSeries(context.Background(), "user123", 12)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("SumEntrysByMonth", mock.Anything, "user123", mock.Anything, 12).Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clk, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.SpendTimeSeries(context.Background(), "user123", 12)
+		assert.Error(t, err)
+	})
+}
+
+func TestSubscriptionService_StreamAllEntrys(t *testing.T) {
+	entries := []*models.Entry{{ID: 1, ServiceName: "Netflix"}, {ID: 2, ServiceName: "Spotify"}}
+	sort := models.ListSort{Field: models.SortByID}
+
+	t.Run("подписки передаются по одной", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("StreamAllEntrys", mock.Anything, 100, 0, sort).Return(entries, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clock.Real{}, nil, models.EntryLimits{}, newNoopLogger())
+
+		var got []*models.Entry
+		err := svc.StreamAllEntrys(context.Background(), 100, 0, sort, func(e *models.Entry) error {
+			got = append(got, e)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, entries, got)
+	})
+
+	t.Run("ошибка обработчика прекращает чтение", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("StreamAllEntrys", mock.Anything, 100, 0, sort).Return(entries, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), clock.Real{}, nil, models.EntryLimits{}, newNoopLogger())
+
+		calls := 0
+		err := svc.StreamAllEntrys(context.Background(), 100, 0, sort, func(*models.Entry) error {
+			calls++
+			return errors.New("client gone")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestSubscriptionService_PriceHistory(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("повторы одной цены схлопываются", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListPriceHistory", mock.Anything, "uid1", "Netflix").Return([]*models.PricePoint{
+			{Date: day(2023, 1, 1), Price: 500, Currency: "RUB", SubscriptionID: 1},
+			{Date: day(2023, 6, 1), Price: 600, Currency: "RUB", SubscriptionID: 1},
+			{Date: day(2024, 1, 1), Price: 600, Currency: "RUB", SubscriptionID: 2},
+			{Date: day(2024, 3, 1), Price: 700, Currency: "RUB", SubscriptionID: 2},
+			{Date: day(2024, 9, 1), Price: 700, Currency: "USD", SubscriptionID: 3},
+		}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.PriceHistory(context.Background(), "uid1", "Netflix")
+		require.NoError(t, err)
+		assert.Equal(t, []*models.PricePoint{
+			{Date: day(2023, 1, 1), Price: 500, Currency: "RUB", SubscriptionID: 1},
+			{Date: day(2023, 6, 1), Price: 600, Currency: "RUB", SubscriptionID: 1},
+			{Date: day(2024, 3, 1), Price: 700, Currency: "RUB", SubscriptionID: 2},
+			{Date: day(2024, 9, 1), Price: 700, Currency: "USD", SubscriptionID: 3},
+		}, got)
+	})
+
+	t.Run("нет подписок", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListPriceHistory", mock.Anything, "uid1", "Okko").Return([]*models.PricePoint{}, nil).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		got, err := svc.PriceHistory(context.Background(), "uid1", "Okko")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		assert.NotNil(t, got)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		repo := new(RepoMock)
+		repo.On("ListPriceHistory", mock.Anything, "uid1", "Netflix").Return(nil, errors.New("db error")).Once()
+		svc := NewSubscriptionService(repo, new(CacheMock), nil, nil, models.EntryLimits{}, newNoopLogger())
+
+		_, err := svc.PriceHistory(context.Background(), "uid1", "Netflix")
+		assert.Error(t, err)
+	})
+}
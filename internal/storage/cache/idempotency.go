@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// idempotencyKeyPrefix отделяет ключи идемпотентности от закешированных данных.
+const idempotencyKeyPrefix = "idempotency:"
+
+// AcquireIdempotencyKey атомарно занимает ключ на время ttl.
+// Возвращает true, если ключ занят впервые, и false, если он уже был занят и не истек.
+func (c *Cache) AcquireIdempotencyKey(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	const op = "cache.AcquireIdempotencyKey"
+
+	ok, err := c.DB.SetNX(ctx, idempotencyKeyPrefix+key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы повторная доставка могла быть обработана.
+func (c *Cache) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	const op = "cache.ReleaseIdempotencyKey"
+
+	if err := c.DB.Del(ctx, idempotencyKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
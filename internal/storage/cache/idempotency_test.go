@@ -0,0 +1,59 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/storage/cache"
+)
+
+func setupMiniredis(t *testing.T) (*cache.Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return &cache.Cache{DB: client}, mr
+}
+
+func TestCache_AcquireIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	c, mr := setupMiniredis(t)
+
+	first, err := c.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, first, "first delivery must be processed")
+
+	again, err := c.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, again, "duplicate delivery must be skipped")
+
+	other, err := c.AcquireIdempotencyKey(ctx, "payment.canceled:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, other, "different key is independent")
+
+	mr.FastForward(time.Hour + time.Second)
+	expired, err := c.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, expired, "key is acquirable after retention expires")
+}
+
+func TestCache_ReleaseIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupMiniredis(t)
+
+	_, err := c.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_2", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, c.ReleaseIdempotencyKey(ctx, "payment.succeeded:pay_2"))
+
+	again, err := c.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, again, "released key can be acquired again")
+
+	assert.NoError(t, c.ReleaseIdempotencyKey(ctx, "missing"), "releasing unknown key is not an error")
+}
@@ -85,3 +85,12 @@ func (c *Cache) Invalidate(key string) error {
 	}
 	return nil
 }
+
+// Ping проверяет доступность Redis.
+func (c *Cache) Ping(ctx context.Context) error {
+	const op = "cache.Ping"
+	if err := c.DB.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// CreateDeletionRequest сохраняет запрос на удаление аккаунта и переводит
+// пользователя в статус ожидания удаления.
+func (s *Storage) CreateDeletionRequest(ctx context.Context, userUID, mode string, scheduledFor time.Time) (*models.DeletionRequest, error) {
+	const op = "storage.CreateDeletionRequest"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `INSERT INTO account_deletion_requests (user_uid, mode, scheduled_for)
+			  VALUES ($1, $2, $3)
+			  RETURNING id, requested_at`
+	req := &models.DeletionRequest{
+		UserUID:      userUID,
+		Mode:         mode,
+		ScheduledFor: scheduledFor,
+	}
+	if err := tx.QueryRowContext(ctx, query, userUID, mode, scheduledFor).Scan(&req.ID, &req.RequestedAt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `UPDATE users
+			 SET subscription_status = $1
+			 WHERE uid = $2 AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, models.StatusPendingDeletion, userUID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return req, nil
+}
+
+// RevokePaymentTokens отзывает все активные платежные токены пользователя так же,
+// как DeletePaymentToken: записи сохраняются для истории платежей, но больше не
+// используются для списаний. Возвращает количество отозванных токенов.
+func (s *Storage) RevokePaymentTokens(ctx context.Context, userUID string) (int, error) {
+	const op = "storage.RevokePaymentTokens"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE yookassa_payment_tokens SET revoked_at = NOW()
+			  WHERE user_uid = $1 AND revoked_at IS NULL`
+	res, err := s.DB.ExecContext(ctx, query, userUID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(count), nil
+}
+
+// AnonymizeUser удаляет персональные данные пользователя, мягко удаляет его подписки,
+// отзывает платежные токены и отмечает запросы на удаление выполненными.
+func (s *Storage) AnonymizeUser(ctx context.Context, userUID string) error {
+	const op = "storage.AnonymizeUser"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	anonymous := "deleted_" + userUID
+	query := `UPDATE users
+			  SET username = $1,
+			      email = $2,
+			      password_hash = '',
+			      subscription_status = $3,
+			      deleted_at = NOW()
+			  WHERE uid = $4 AND deleted_at IS NULL`
+	res, err := tx.ExecContext(ctx, query, anonymous, anonymous+"@deleted.invalid", models.StatusDeleted, userUID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if n == 0 {
+		return fmt.Errorf("%s: %w", op, sql.ErrNoRows)
+	}
+
+	query = `UPDATE subscriptions
+			 SET username = $1,
+			     is_active = FALSE,
+			     deleted_at = NOW()
+			 WHERE user_uid = $2 AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, anonymous, userUID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `UPDATE yookassa_payment_tokens SET revoked_at = NOW()
+			 WHERE user_uid = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, userUID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `UPDATE account_deletion_requests
+			 SET completed_at = NOW()
+			 WHERE user_uid = $1 AND completed_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, userUID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// FindDueAccountDeletions возвращает UID пользователей, у которых наступил срок
+// отложенного удаления аккаунта.
+func (s *Storage) FindDueAccountDeletions(ctx context.Context) ([]string, error) {
+	const op = "storage.FindDueAccountDeletions"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT DISTINCT user_uid
+			  FROM account_deletion_requests
+			  WHERE completed_at IS NULL AND scheduled_for <= NOW()`
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
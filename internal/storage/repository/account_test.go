@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_AnonymizeUser(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	uid := uuid.New().String()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	factory.CreateUser(t, uid, "gdpruser", "gdpr@example.com", "hash", "user")
+	factory.CreateSubscription(t, "Netflix", 1000.0, "gdpruser", startDate, 12, uid, startDate, true)
+	factory.CreatePaymentToken(t, uid, "card_1")
+
+	_, err := storage.CreateDeletionRequest(ctx, uid, models.DeletionModeImmediate, time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, storage.AnonymizeUser(ctx, uid))
+
+	user, err := storage.GetUser(ctx, uid)
+	require.NoError(t, err)
+	assert.NotEqual(t, "gdpruser", user.Username)
+	assert.NotContains(t, user.Email, "gdpr@example.com")
+	assert.Empty(t, user.PasswordHash)
+	assert.Equal(t, models.StatusDeleted, user.SubscriptionStatus)
+
+	tokens, err := storage.ListPaymentTokens(ctx, uid)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	var revokedTokens, activeSubs, pendingRequests int
+	require.NoError(t, storage.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM yookassa_payment_tokens WHERE user_uid = $1 AND revoked_at IS NOT NULL`, uid).Scan(&revokedTokens))
+	assert.Equal(t, 1, revokedTokens)
+	require.NoError(t, storage.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM subscriptions WHERE user_uid = $1 AND (is_active OR deleted_at IS NULL)`, uid).Scan(&activeSubs))
+	assert.Zero(t, activeSubs)
+	require.NoError(t, storage.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM account_deletion_requests WHERE user_uid = $1 AND completed_at IS NULL`, uid).Scan(&pendingRequests))
+	assert.Zero(t, pendingRequests)
+
+	// Повторная анонимизация не находит пользователя
+	assert.Error(t, storage.AnonymizeUser(ctx, uid))
+}
+
+func TestStorage_ScheduledDeletion(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	dueUID := uuid.New().String()
+	laterUID := uuid.New().String()
+	factory.CreateUser(t, dueUID, "due", "due@example.com", "hash", "user")
+	factory.CreateUser(t, laterUID, "later", "later@example.com", "hash", "user")
+	factory.CreatePaymentToken(t, dueUID, "card_due")
+
+	req, err := storage.CreateDeletionRequest(ctx, dueUID, models.DeletionModeScheduled, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.NotZero(t, req.ID)
+	_, err = storage.CreateDeletionRequest(ctx, laterUID, models.DeletionModeScheduled, time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+
+	NewTestVerification(storage).VerifyUserSubscriptionStatus(t, dueUID, models.StatusPendingDeletion)
+
+	revoked, err := storage.RevokePaymentTokens(ctx, dueUID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+
+	// Токен отзывается, а не удаляется: запись остается для истории платежей
+	var revokedTokens int
+	require.NoError(t, storage.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM yookassa_payment_tokens WHERE user_uid = $1 AND revoked_at IS NOT NULL`, dueUID).Scan(&revokedTokens))
+	assert.Equal(t, 1, revokedTokens)
+	tokens, err := storage.ListPaymentTokens(ctx, dueUID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+	revoked, err = storage.RevokePaymentTokens(ctx, dueUID)
+	require.NoError(t, err)
+	assert.Zero(t, revoked, "already revoked tokens are not counted again")
+
+	due, err := storage.FindDueAccountDeletions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{dueUID}, due)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// ListAttentionEntrys возвращает активные подписки пользователя userUID, срок которых не
+// закончился к today, в порядке ближайшей даты следующего платежа. Для каждой подписки
+// отмечается, есть ли у пользователя действующий платежный токен и отклонен ли последний
+// платеж по этой подписке. Подписки без даты следующего платежа не возвращаются.
+func (s *Storage) ListAttentionEntrys(ctx context.Context, userUID string, today time.Time) ([]*models.AttentionEntry, error) {
+	const op = "storage.ListAttentionEntrys"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT s.id, s.service_name, s.price, s.currency, s.next_payment_date,
+			      NOT EXISTS (
+			          SELECT 1 FROM yookassa_payment_tokens t
+			          WHERE t.user_uid = s.user_uid AND t.revoked_at IS NULL
+			      ),
+			      COALESCE((
+			          SELECT p.status FROM yookassa_payments p
+			          WHERE p.subscription_id = s.id
+			          ORDER BY p.created_at DESC, p.id DESC
+			          LIMIT 1
+			      ) = $3, FALSE)
+			  FROM subscriptions s
+			  WHERE s.user_uid = $1 AND s.is_active AND s.next_payment_date IS NOT NULL
+			    AND s.deleted_at IS NULL AND s.archived_at IS NULL
+			    AND (s.start_date + (s.counter_months || ' months')::INTERVAL) >= $2
+			  ORDER BY s.next_payment_date, s.id`
+	rows, err := s.reader().QueryContext(ctx, query, userUID, today, models.PaymentStatusCanceled)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := []*models.AttentionEntry{}
+	for rows.Next() {
+		var item models.AttentionEntry
+		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, s.currencyDest(&item.Currency),
+			&item.NextPaymentDate, &item.MissingPaymentToken, &item.LastPaymentFailed); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
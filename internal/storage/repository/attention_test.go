@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_ListAttentionEntrys(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	factory := NewTestDataFactory(storage)
+	ctx := context.Background()
+
+	today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	uid := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, uid, "user1", "user1@example.com", "hash", "user")
+	factory.CreateUser(t, otherUID, "user2", "user2@example.com", "hash", "user")
+
+	later := factory.CreateSubscription(t, "Netflix", 500, "user1", start, 12, uid, today.AddDate(0, 0, 10), true)
+	sooner := factory.CreateSubscription(t, "Spotify", 300, "user1", start, 12, uid, today.AddDate(0, 0, 2), true)
+	recovered := factory.CreateSubscription(t, "Okko", 400, "user1", start, 12, uid, today.AddDate(0, 0, 5), true)
+	factory.CreateSubscription(t, "Ivi", 200, "user1", start, 12, uid, today.AddDate(0, 0, 1), false)      // приостановлена
+	factory.CreateSubscription(t, "Kion", 250, "user1", start, 3, uid, today.AddDate(0, 0, 1), true)       // срок истек
+	factory.CreateSubscription(t, "Wink", 100, "user2", start, 12, otherUID, today.AddDate(0, 0, 1), true) // другой пользователь
+
+	addPayment := func(subscriptionID int, status string, createdAt time.Time) {
+		_, err := storage.DB.Exec(`INSERT INTO yookassa_payments (user_uid, subscription_id, payment_id, amount, status, created_at)
+			VALUES ($1, $2, $3, 100, $4, $5)`, uid, subscriptionID, uuid.New().String(), status, createdAt)
+		require.NoError(t, err)
+	}
+	addPayment(sooner, models.PaymentStatusSucceeded, today.AddDate(0, -1, 0))
+	addPayment(sooner, models.PaymentStatusCanceled, today.AddDate(0, 0, -1))
+	addPayment(recovered, models.PaymentStatusCanceled, today.AddDate(0, 0, -3))
+	addPayment(recovered, models.PaymentStatusSucceeded, today.AddDate(0, 0, -2))
+
+	t.Run("без действующей карты", func(t *testing.T) {
+		got, err := storage.ListAttentionEntrys(ctx, uid, today)
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+
+		assert.Equal(t, []int{sooner, recovered, later}, []int{got[0].ID, got[1].ID, got[2].ID},
+			"подписки идут в порядке ближайшего платежа")
+		for _, entry := range got {
+			assert.True(t, entry.MissingPaymentToken)
+		}
+		assert.True(t, got[0].LastPaymentFailed, "последний платеж отклонен")
+		assert.False(t, got[1].LastPaymentFailed, "после отклоненного платежа прошел успешный")
+		assert.False(t, got[2].LastPaymentFailed, "платежей по подписке не было")
+	})
+
+	t.Run("с действующей картой", func(t *testing.T) {
+		factory.CreatePaymentToken(t, uid, "card_1")
+
+		got, err := storage.ListAttentionEntrys(ctx, uid, today)
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+		for _, entry := range got {
+			assert.False(t, entry.MissingPaymentToken)
+		}
+	})
+
+	t.Run("удаленная карта не считается действующей", func(t *testing.T) {
+		_, err := storage.DB.Exec(`UPDATE yookassa_payment_tokens SET revoked_at = NOW() WHERE user_uid = $1`, uid)
+		require.NoError(t, err)
+
+		got, err := storage.ListAttentionEntrys(ctx, uid, today)
+		require.NoError(t, err)
+		require.NotEmpty(t, got)
+		assert.True(t, got[0].MissingPaymentToken)
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// BulkUpdateStatus переводит подписки пользователя userUID в статус status в одной транзакции.
+// Подписки выбираются по ids, а если ids пуст — по названию сервиса serviceName без учета регистра.
+// Статус active возобновляет подписку, paused приостанавливает, canceled отменяет ее
+// (помечает удаленной). Удаленные и архивные подписки не изменяются.
+// Для ids результат возвращается по каждому запрошенному ID в порядке запроса,
+// чужие и несуществующие подписки содержат ошибку; для serviceName — по каждой измененной подписке.
+func (s *Storage) BulkUpdateStatus(ctx context.Context, userUID string, ids []int, serviceName, status string) ([]models.BulkStatusResult, error) {
+	const op = "storage.BulkUpdateStatus"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	isActive := status == models.EntryStatusActive
+	cancel := status == models.EntryStatusCanceled
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `SELECT id FROM subscriptions
+			  WHERE user_uid = $1 AND deleted_at IS NULL AND archived_at IS NULL
+			    AND LOWER(service_name) = LOWER($2)
+			  ORDER BY id
+			  FOR UPDATE`
+	args := []any{userUID, serviceName}
+	if len(ids) > 0 {
+		query = `SELECT id FROM subscriptions
+				 WHERE user_uid = $1 AND deleted_at IS NULL AND archived_at IS NULL
+				   AND id = ANY($2::INT[])
+				 ORDER BY id
+				 FOR UPDATE`
+		args = []any{userUID, ids}
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var owned []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		owned = append(owned, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(owned) > 0 {
+		update := `UPDATE subscriptions
+				   SET is_active = $2,
+				       deleted_at = CASE WHEN $3 THEN NOW() ELSE deleted_at END
+				   WHERE id = ANY($1::INT[])`
+		if _, err := tx.ExecContext(ctx, update, owned, isActive, cancel); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(ids) == 0 {
+		result := make([]models.BulkStatusResult, 0, len(owned))
+		for _, id := range owned {
+			result = append(result, models.BulkStatusResult{ID: id, Status: status})
+		}
+		return result, nil
+	}
+
+	updated := make(map[int]bool, len(owned))
+	for _, id := range owned {
+		updated[id] = true
+	}
+	result := make([]models.BulkStatusResult, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if updated[id] {
+			result = append(result, models.BulkStatusResult{ID: id, Status: status})
+		} else {
+			result = append(result, models.BulkStatusResult{ID: id, Error: models.ErrSubscriptionNotFound.Error()})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_BulkUpdateStatus(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	owner, other := uuid.New().String(), uuid.New().String()
+	startDate := time.Now().AddDate(0, -1, 0)
+	factory.CreateUser(t, owner, "owner", "owner@example.com", "hash", "user")
+	factory.CreateUser(t, other, "other", "other@example.com", "hash", "user")
+	netflix := factory.CreateSubscription(t, "Netflix", 500, "owner", startDate, 12, owner, startDate, true)
+	spotify := factory.CreateSubscription(t, "Spotify", 300, "owner", startDate, 12, owner, startDate, true)
+	foreign := factory.CreateSubscription(t, "Netflix", 500, "other", startDate, 12, other, startDate, true)
+
+	t.Run("pause by ids skips foreign subscriptions", func(t *testing.T) {
+		result, err := storage.BulkUpdateStatus(ctx, owner, []int{spotify, foreign, netflix}, "", models.EntryStatusPaused)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BulkStatusResult{
+			{ID: spotify, Status: models.EntryStatusPaused},
+			{ID: foreign, Error: "subscription not found"},
+			{ID: netflix, Status: models.EntryStatusPaused},
+		}, result)
+
+		entry, err := storage.ReadEntryForUser(ctx, foreign, other)
+		require.NoError(t, err)
+		assert.True(t, entry.IsActive, "foreign subscription is not changed")
+	})
+
+	t.Run("resume and cancel by service name", func(t *testing.T) {
+		result, err := storage.BulkUpdateStatus(ctx, owner, nil, "netflix", models.EntryStatusActive)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BulkStatusResult{{ID: netflix, Status: models.EntryStatusActive}}, result)
+
+		result, err = storage.BulkUpdateStatus(ctx, owner, nil, "NETFLIX", models.EntryStatusCanceled)
+		require.NoError(t, err)
+		assert.Equal(t, []models.BulkStatusResult{{ID: netflix, Status: models.EntryStatusCanceled}}, result)
+
+		entries, err := storage.ListEntrysByUserUID(ctx, owner)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, spotify, entries[0].ID)
+
+		// Отмененная подписка не читается и не обновляется по id
+		_, err = storage.ReadEntryForUser(ctx, netflix, owner)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		updated, err := storage.UpdateEntry(ctx, models.Entry{
+			ServiceName: "Netflix", Price: 500, StartDate: startDate, CounterMonths: 12, UserUID: owner, IsActive: true,
+		}, netflix, "owner")
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AcquireIdempotencyKey атомарно занимает ключ на время ttl.
+// Возвращает true, если ключ занят впервые или предыдущая запись истекла,
+// и false, если ключ уже занят.
+func (s *Storage) AcquireIdempotencyKey(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	const op = "storage.AcquireIdempotencyKey"
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `INSERT INTO idempotency_keys (key, expires_at)
+			  VALUES ($1, NOW() + $2 * INTERVAL '1 millisecond')
+			  ON CONFLICT (key) DO UPDATE
+			      SET expires_at = EXCLUDED.expires_at, created_at = NOW()
+			      WHERE idempotency_keys.expires_at <= NOW()`
+	res, err := s.DB.ExecContext(ctx, query, key, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return n == 1, nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, чтобы повторная доставка могла быть обработана.
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	const op = "storage.ReleaseIdempotencyKey"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `DELETE FROM idempotency_keys WHERE key = $1`
+	if _, err := s.DB.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_AcquireIdempotencyKey(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	first, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, first, "first delivery must be processed")
+
+	again, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, again, "duplicate delivery must be skipped")
+
+	other, err := storage.AcquireIdempotencyKey(ctx, "payment.canceled:pay_1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, other, "different key is independent")
+
+	short, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_short", 10*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, short)
+	time.Sleep(50 * time.Millisecond)
+	expired, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_short", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, expired, "key is acquirable after retention expires")
+}
+
+func TestStorage_ReleaseIdempotencyKey(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_2", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, storage.ReleaseIdempotencyKey(ctx, "payment.succeeded:pay_2"))
+
+	again, err := storage.AcquireIdempotencyKey(ctx, "payment.succeeded:pay_2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, again, "released key can be acquired again")
+
+	assert.NoError(t, storage.ReleaseIdempotencyKey(ctx, "missing"), "releasing unknown key is not an error")
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// CheckIntegrity ищет аномалии данных, которые не должны появляться при работе сервиса:
+// подписки без пользователя, платежи со ссылкой на несуществующую подписку, подписки
+// с отрицательной ценой и с next_payment_date раньше start_date. Проверяются все записи,
+// включая удаленные и архивные подписки. Для каждого вида возвращается число записей
+// и ID первых sampleSize из них.
+func (s *Storage) CheckIntegrity(ctx context.Context, sampleSize int) (*models.IntegrityReport, error) {
+	const op = "storage.CheckIntegrity"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	report := &models.IntegrityReport{}
+	checks := []struct {
+		issue *models.IntegrityIssue
+		query string
+	}{
+		{&report.OrphanSubscriptions, `SELECT s.id, COUNT(*) OVER ()
+			  FROM subscriptions s
+			  LEFT JOIN users u ON u.uid = s.user_uid
+			  WHERE u.uid IS NULL
+			  ORDER BY s.id
+			  LIMIT $1`},
+		{&report.OrphanPayments, `SELECT p.id, COUNT(*) OVER ()
+			  FROM yookassa_payments p
+			  LEFT JOIN subscriptions s ON s.id = p.subscription_id
+			  WHERE p.subscription_id IS NOT NULL AND s.id IS NULL
+			  ORDER BY p.id
+			  LIMIT $1`},
+		{&report.NegativePrices, `SELECT id, COUNT(*) OVER ()
+			  FROM subscriptions
+			  WHERE price < 0
+			  ORDER BY id
+			  LIMIT $1`},
+		{&report.PaymentBeforeStart, `SELECT id, COUNT(*) OVER ()
+			  FROM subscriptions
+			  WHERE next_payment_date < start_date
+			  ORDER BY id
+			  LIMIT $1`},
+	}
+	for _, c := range checks {
+		issue, err := s.integrityIssue(ctx, c.query, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		*c.issue = issue
+		report.Total += issue.Count
+	}
+	return report, nil
+}
+
+// integrityIssue выполняет запрос проверки целостности, возвращающий ID записи
+// и общее число найденных записей.
+func (s *Storage) integrityIssue(ctx context.Context, query string, limit int) (models.IntegrityIssue, error) {
+	// Проверка читает основную базу: отставание реплики скрыло бы свежие аномалии
+	rows, err := s.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return models.IntegrityIssue{}, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	issue := models.IntegrityIssue{IDs: []int{}}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id, &issue.Count); err != nil {
+			return models.IntegrityIssue{}, err
+		}
+		issue.IDs = append(issue.IDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return models.IntegrityIssue{}, err
+	}
+	return issue, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_CheckIntegrity(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+
+	clean, err := storage.CheckIntegrity(ctx, models.IntegrityReportSampleSize)
+	require.NoError(t, err)
+	assert.Equal(t, 0, clean.Total)
+	assert.Equal(t, []int{}, clean.OrphanSubscriptions.IDs)
+
+	valid := factory.CreateSubscription(t, "Netflix", 500, "testuser", startDate, 12, userUID, startDate.AddDate(0, 1, 0), true)
+	negative := factory.CreateSubscription(t, "Spotify", -300, "testuser", startDate, 12, userUID, startDate, true)
+	negative2 := factory.CreateSubscription(t, "Kinopoisk", -1, "testuser", startDate, 12, userUID, startDate, true)
+	beforeStart := factory.CreateSubscription(t, "Okko", 250, "testuser", startDate, 12, userUID, startDate.AddDate(0, 0, -1), true)
+
+	// Аномалии, которые не допускают внешние ключи, появляются только в обход схемы
+	_, err = storage.DB.Exec(`ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_user_uid_fkey`)
+	require.NoError(t, err)
+	_, err = storage.DB.Exec(`ALTER TABLE yookassa_payments DROP CONSTRAINT yookassa_payments_subscription_id_fkey`)
+	require.NoError(t, err)
+	orphan := factory.CreateSubscription(t, "Ivi", 199, "ghost", startDate, 12, uuid.New().String(), startDate, true)
+	var payment, orphanPayment int
+	require.NoError(t, storage.DB.QueryRow(`INSERT INTO yookassa_payments (user_uid, subscription_id, payment_id, amount, status)
+		VALUES ($1, $2, 'payment_ok', 50000, 'succeeded') RETURNING id`, userUID, valid).Scan(&payment))
+	require.NoError(t, storage.DB.QueryRow(`INSERT INTO yookassa_payments (user_uid, subscription_id, payment_id, amount, status)
+		VALUES ($1, $2, 'payment_orphan', 50000, 'succeeded') RETURNING id`, userUID, orphan+1000).Scan(&orphanPayment))
+
+	report, err := storage.CheckIntegrity(ctx, models.IntegrityReportSampleSize)
+	require.NoError(t, err)
+	assert.Equal(t, models.IntegrityIssue{Count: 1, IDs: []int{orphan}}, report.OrphanSubscriptions)
+	assert.Equal(t, models.IntegrityIssue{Count: 1, IDs: []int{orphanPayment}}, report.OrphanPayments)
+	assert.Equal(t, models.IntegrityIssue{Count: 2, IDs: []int{negative, negative2}}, report.NegativePrices)
+	assert.Equal(t, models.IntegrityIssue{Count: 1, IDs: []int{beforeStart}}, report.PaymentBeforeStart)
+	assert.Equal(t, 5, report.Total)
+	assert.NotContains(t, report.OrphanPayments.IDs, payment)
+
+	sampled, err := storage.CheckIntegrity(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.IntegrityIssue{Count: 2, IDs: []int{negative}}, sampled.NegativePrices, "sample size limits ids, not counts")
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// EnqueueNotifications записывает уведомления в outbox в одной транзакции: либо сохраняются
+// все уведомления, либо ни одного. Уведомления с уже записанным DedupKey пропускаются.
+// Подпискам из SubscriptionIDs нового уведомления в той же транзакции проставляется
+// last_notified_at. Возвращает количество новых записей.
+func (s *Storage) EnqueueNotifications(ctx context.Context, messages []models.OutboxMessage) (int, error) {
+	const op = "storage.EnqueueNotifications"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	queued, err := insertNotifications(ctx, tx, messages)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return queued, nil
+}
+
+// insertNotifications записывает уведомления в outbox в транзакции tx, пропуская
+// уведомления с уже записанным DedupKey, и проставляет last_notified_at подпискам новых
+// уведомлений. Возвращает количество новых записей.
+func insertNotifications(ctx context.Context, tx *sql.Tx, messages []models.OutboxMessage) (int, error) {
+	query := `INSERT INTO notification_outbox (routing_key, payload, dedup_key, correlation_id, user_uid)
+			  VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid)
+			  ON CONFLICT (dedup_key) DO NOTHING`
+	markQuery := `UPDATE subscriptions SET last_notified_at = NOW() WHERE id = ANY($1::INT[])`
+	queued := 0
+	for _, m := range messages {
+		res, err := tx.ExecContext(ctx, query, m.RoutingKey, m.Payload, m.DedupKey, m.CorrelationID, m.UserUID)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		queued += int(n)
+		// Отметка ставится только новому уведомлению: дубликат не означает повторной отправки
+		if n > 0 && len(m.SubscriptionIDs) > 0 {
+			if _, err := tx.ExecContext(ctx, markQuery, m.SubscriptionIDs); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return queued, nil
+}
+
+// ClaimPendingNotifications забирает до limit неотправленных уведомлений, время очередной
+// попытки которых наступило к now, и откладывает их следующую попытку до claimUntil.
+// Строки выбираются с FOR UPDATE SKIP LOCKED, а отложенная попытка не дает другому релею
+// выбрать уведомление, пока первый его публикует, поэтому параллельные релеи публикуют
+// разные уведомления. Если релей не отметил уведомление, оно будет забрано снова после
+// claimUntil. Уведомления возвращаются в порядке записи.
+func (s *Storage) ClaimPendingNotifications(ctx context.Context, now, claimUntil time.Time, limit int) ([]*models.OutboxMessage, error) {
+	const op = "storage.ClaimPendingNotifications"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE notification_outbox
+			  SET next_attempt_at = $2
+			  WHERE id IN (
+			      SELECT id FROM notification_outbox
+			      WHERE sent_at IS NULL AND next_attempt_at <= $1
+			      ORDER BY id
+			      LIMIT $3
+			      FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING id, routing_key, payload, dedup_key, correlation_id, attempts`
+	rows, err := s.DB.QueryContext(ctx, query, now, claimUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.OutboxMessage
+	for rows.Next() {
+		var m models.OutboxMessage
+		if err = rows.Scan(&m.ID, &m.RoutingKey, &m.Payload, &m.DedupKey, &m.CorrelationID, &m.Attempts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// RETURNING не гарантирует порядок строк
+	slices.SortFunc(result, func(a, b *models.OutboxMessage) int { return cmp.Compare(a.ID, b.ID) })
+	return result, nil
+}
+
+// MarkNotificationSent отмечает уведомление опубликованным.
+func (s *Storage) MarkNotificationSent(ctx context.Context, id int64) error {
+	const op = "storage.MarkNotificationSent"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE notification_outbox
+			  SET sent_at = NOW(), last_error = NULL
+			  WHERE id = $1`
+	if _, err := s.DB.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// MarkNotificationFailed сохраняет ошибку публикации уведомления и откладывает
+// следующую попытку до nextAttemptAt.
+func (s *Storage) MarkNotificationFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	const op = "storage.MarkNotificationFailed"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE notification_outbox
+			  SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+			  WHERE id = $1`
+	if _, err := s.DB.ExecContext(ctx, query, id, lastError, nextAttemptAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListSentNotifications возвращает отправленные пользователю userUID уведомления
+// в порядке отправки.
+func (s *Storage) ListSentNotifications(ctx context.Context, userUID string) ([]models.NotificationRecord, error) {
+	const op = "storage.ListSentNotifications"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT routing_key, sent_at
+			  FROM notification_outbox
+			  WHERE user_uid = $1 AND sent_at IS NOT NULL
+			  ORDER BY sent_at, id`
+	rows, err := s.DB.QueryContext(ctx, query, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := []models.NotificationRecord{}
+	for rows.Next() {
+		var r models.NotificationRecord
+		if err = rows.Scan(&r.Type, &r.SentAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_NotificationOutbox(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	messages := []models.OutboxMessage{
+		{RoutingKey: "subscription.expiring", Payload: []byte(`{"username":"first"}`), DedupKey: "subscription.expiring:1:2025-07-01", CorrelationID: "run-1"},
+		{RoutingKey: "subscription.expiring", Payload: []byte(`{"username":"second"}`), DedupKey: "subscription.expiring:2:2025-07-01"},
+	}
+	queued, err := storage.EnqueueNotifications(ctx, messages)
+	require.NoError(t, err)
+	assert.Equal(t, 2, queued)
+
+	again, err := storage.EnqueueNotifications(ctx, messages[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 0, again, "notification with the same dedup key is queued once")
+
+	claimUntil := time.Now().Add(time.Minute)
+	pending, err := storage.ClaimPendingNotifications(ctx, time.Now(), claimUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "subscription.expiring:1:2025-07-01", pending[0].DedupKey)
+	assert.JSONEq(t, `{"username":"first"}`, string(pending[0].Payload))
+	assert.Equal(t, "run-1", pending[0].CorrelationID)
+	assert.Empty(t, pending[1].CorrelationID)
+
+	claimed, err := storage.ClaimPendingNotifications(ctx, time.Now(), claimUntil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "claimed notifications are not handed to another relay")
+
+	retryAt := time.Now().Add(time.Hour)
+	require.NoError(t, storage.MarkNotificationFailed(ctx, pending[0].ID, "channel closed", retryAt))
+	require.NoError(t, storage.MarkNotificationSent(ctx, pending[1].ID))
+
+	pending, err = storage.ClaimPendingNotifications(ctx, claimUntil, claimUntil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "failed notification waits for its next attempt, sent one is done")
+
+	pending, err = storage.ClaimPendingNotifications(ctx, retryAt, retryAt.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+}
+
+func TestStorage_ClaimPendingNotificationsExpiredClaim(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := storage.EnqueueNotifications(ctx, []models.OutboxMessage{
+		{RoutingKey: "subscription.expiring", Payload: []byte(`{}`), DedupKey: "subscription.expiring:1"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	claimUntil := now.Add(time.Minute)
+	pending, err := storage.ClaimPendingNotifications(ctx, now, claimUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	// Релей не отметил уведомление: после окончания захвата его забирает следующий проход
+	pending, err = storage.ClaimPendingNotifications(ctx, claimUntil, claimUntil.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 0, pending[0].Attempts)
+}
+
+func TestStorage_EnqueueNotificationsMarksSubscriptions(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	start := time.Now().UTC().AddDate(0, -1, 1)
+	notified := factory.CreateSubscription(t, "Netflix", 500, "testuser", start, 1, userUID, start, true)
+	other := factory.CreateSubscription(t, "Spotify", 300, "testuser", start, 1, userUID, start, true)
+
+	message := models.OutboxMessage{
+		RoutingKey:      "subscription.expiring",
+		Payload:         []byte(`{"username":"testuser"}`),
+		DedupKey:        "subscription.expiring:netflix",
+		SubscriptionIDs: []int{notified},
+	}
+	queued, err := storage.EnqueueNotifications(ctx, []models.OutboxMessage{message})
+	require.NoError(t, err)
+	require.Equal(t, 1, queued)
+
+	entries, err := storage.FindSubscriptionsDueReminder(ctx, time.Now(), []int{1})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		switch e.SubscriptionID {
+		case notified:
+			require.NotNil(t, e.LastNotifiedAt)
+			assert.WithinDuration(t, time.Now(), *e.LastNotifiedAt, time.Minute)
+		case other:
+			assert.Nil(t, e.LastNotifiedAt)
+		}
+	}
+
+	// Дубликат уведомления не сдвигает время последней отправки
+	lastNotifiedAt := func() time.Time {
+		var at time.Time
+		require.NoError(t, storage.DB.QueryRow(`SELECT last_notified_at FROM subscriptions WHERE id = $1`, notified).Scan(&at))
+		return at
+	}
+	first := lastNotifiedAt()
+	_, err = storage.EnqueueNotifications(ctx, []models.OutboxMessage{message})
+	require.NoError(t, err)
+	assert.True(t, first.Equal(lastNotifiedAt()))
+}
+
+func TestStorage_ListSentNotifications(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, otherUID, "otheruser", "other@example.com", "hashedpassword", "user")
+
+	_, err := storage.EnqueueNotifications(ctx, []models.OutboxMessage{
+		{RoutingKey: "subscription.trial.expired", Payload: []byte(`{}`), DedupKey: "subscription.trial.expired:" + userUID, UserUID: userUID},
+		{RoutingKey: "subscription.expired", Payload: []byte(`{}`), DedupKey: "subscription.expired:" + userUID, UserUID: userUID},
+		{RoutingKey: "subscription.trial.expired", Payload: []byte(`{}`), DedupKey: "subscription.trial.expired:" + otherUID, UserUID: otherUID},
+		{RoutingKey: "payment.webhook.received", Payload: []byte(`{}`), DedupKey: "webhook:payment.succeeded:pay_1"},
+	})
+	require.NoError(t, err)
+
+	pending, err := storage.ClaimPendingNotifications(ctx, time.Now(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 4)
+	// Второе уведомление пользователя еще не отправлено и в выгрузку не попадает
+	require.NoError(t, storage.MarkNotificationSent(ctx, pending[0].ID))
+	require.NoError(t, storage.MarkNotificationSent(ctx, pending[2].ID))
+	require.NoError(t, storage.MarkNotificationSent(ctx, pending[3].ID))
+
+	records, err := storage.ListSentNotifications(ctx, userUID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "subscription.trial.expired", records[0].Type)
+	assert.WithinDuration(t, time.Now(), records[0].SentAt, time.Minute)
+
+	records, err = storage.ListSentNotifications(ctx, uuid.New().String())
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -3,13 +3,17 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/api/handlers/payment/paymentwebhook"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
-// FindPaymentToken находит токен платежа
+// FindPaymentToken находит активный токен платежа пользователя. Отозванные пользователем
+// записи не учитываются, поэтому ту же карту можно сохранить снова.
 func (s *Storage) FindPaymentToken(ctx context.Context, userUID string, token string) (int, bool, error) {
 	const op = "storage.FindPaymentToken"
 	select {
@@ -18,8 +22,8 @@ func (s *Storage) FindPaymentToken(ctx context.Context, userUID string, token st
 	default:
 	}
 
-	query := `SELECT id FROM yookassa_payment_tokens 
-			  WHERE user_uid = $1 AND token = $2`
+	query := `SELECT id FROM yookassa_payment_tokens
+			  WHERE user_uid = $1 AND token = $2 AND revoked_at IS NULL`
 	var id int
 	err := s.DB.QueryRowContext(ctx, query, userUID, token).Scan(&id)
 	if err != nil {
@@ -31,7 +35,9 @@ func (s *Storage) FindPaymentToken(ctx context.Context, userUID string, token st
 	return id, true, nil
 }
 
-// CreatePaymentToken создает новый токен платежа
+// CreatePaymentToken сохраняет токен платежа. Если у пользователя уже есть активная запись
+// с этим токеном (например, при одновременном сохранении той же карты), возвращает ее ID;
+// отозванные записи не учитываются.
 func (s *Storage) CreatePaymentToken(ctx context.Context, userUID string, token string) (int, error) {
 	const op = "storage.CreatePaymentToken"
 	select {
@@ -40,8 +46,11 @@ func (s *Storage) CreatePaymentToken(ctx context.Context, userUID string, token
 	default:
 	}
 
-	query := `INSERT INTO yookassa_payment_tokens (user_uid, token) 
-			  VALUES ($1, $2) RETURNING id`
+	query := `INSERT INTO yookassa_payment_tokens (user_uid, token)
+			  VALUES ($1, $2)
+			  ON CONFLICT (user_uid, token) WHERE revoked_at IS NULL
+			  DO UPDATE SET token = EXCLUDED.token
+			  RETURNING id`
 	var newID int
 	err := s.DB.QueryRowContext(ctx, query, userUID, token).Scan(&newID)
 	if err != nil {
@@ -50,7 +59,7 @@ func (s *Storage) CreatePaymentToken(ctx context.Context, userUID string, token
 	return newID, nil
 }
 
-// ListPaymentTokens возвращает список токенов платежей пользователя
+// ListPaymentTokens возвращает список активных токенов платежей пользователя в порядке добавления
 func (s *Storage) ListPaymentTokens(ctx context.Context, userUID string) ([]*models.PaymentToken, error) {
 	const op = "storage.ListPaymentTokens"
 	select {
@@ -61,7 +70,8 @@ func (s *Storage) ListPaymentTokens(ctx context.Context, userUID string) ([]*mod
 
 	query := `SELECT id, user_uid, token, created_at 
 			  FROM yookassa_payment_tokens 
-		      WHERE user_uid = $1`
+		      WHERE user_uid = $1 AND revoked_at IS NULL
+			  ORDER BY created_at, id`
 	rows, err := s.DB.QueryContext(ctx, query, userUID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -81,7 +91,76 @@ func (s *Storage) ListPaymentTokens(ctx context.Context, userUID string) ([]*mod
 	return result, nil
 }
 
-// SavePayment сохраняет информацию о платеже
+// ListPaymentTokensPage возвращает страницу активных токенов платежей пользователя
+// в порядке добавления: не больше limit токенов, начиная с offset.
+func (s *Storage) ListPaymentTokensPage(ctx context.Context, userUID string, limit, offset int) ([]*models.PaymentToken, error) {
+	const op = "storage.ListPaymentTokensPage"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, user_uid, token, created_at
+			  FROM yookassa_payment_tokens
+			  WHERE user_uid = $1 AND revoked_at IS NULL
+			  ORDER BY created_at, id
+			  LIMIT $2 OFFSET $3`
+	rows, err := s.DB.QueryContext(ctx, query, userUID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.PaymentToken
+	for rows.Next() {
+		var pt models.PaymentToken
+		if err := rows.Scan(&pt.ID, &pt.UserUID, &pt.Token, &pt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &pt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// DeletePaymentToken отзывает токен платежа пользователя. Запись сохраняется для истории
+// платежей, но больше не возвращается в списке и не может использоваться для списаний.
+// Если токен не найден, уже отозван или принадлежит другому пользователю,
+// возвращает models.ErrPaymentTokenNotFound.
+func (s *Storage) DeletePaymentToken(ctx context.Context, userUID string, id int) error {
+	const op = "storage.DeletePaymentToken"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE yookassa_payment_tokens SET revoked_at = NOW()
+			  WHERE id = $1 AND user_uid = $2 AND revoked_at IS NULL`
+	result, err := s.DB.ExecContext(ctx, query, id, userUID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrPaymentTokenNotFound)
+	}
+	return nil
+}
+
+// SavePayment сохраняет информацию о платеже. Подписка и платежный токен берутся
+// из metadata (subscription_id, payment_token_id) и связываются с платежом, только
+// если принадлежат пользователю; иначе соответствующие колонки остаются NULL.
+// Повторное сохранение платежа с тем же payment_id (например, при повторной доставке
+// webhook) не создает новую запись: у существующей обновляется статус и возвращается ее ID.
 func (s *Storage) SavePayment(ctx context.Context, payload *paymentwebhook.Payload, amount int64, userUID string) (int, error) {
 	const op = "storage.SavePayment"
 	select {
@@ -90,14 +169,309 @@ func (s *Storage) SavePayment(ctx context.Context, payload *paymentwebhook.Paylo
 	default:
 	}
 
-	query := `INSERT INTO yookassa_payments (user_uid, payment_id, status, amount, currency, created_at) 
-			  VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id`
+	query := `INSERT INTO yookassa_payments (user_uid, payment_id, status, amount, currency,
+			      subscription_id, payment_token_id, created_at)
+			  VALUES ($1, $2, $3, $4, $5,
+			      (SELECT id FROM subscriptions WHERE id = $6 AND user_uid = $1),
+			      (SELECT id FROM yookassa_payment_tokens WHERE id = $7 AND user_uid = $1),
+			      NOW())
+			  ON CONFLICT (payment_id) DO UPDATE SET status = EXCLUDED.status
+			  RETURNING id`
 	var newID int
 	err := s.DB.QueryRowContext(ctx, query,
 		userUID, payload.Object.ID, payload.Object.Status, amount,
-		payload.Object.Amount.Currency).Scan(&newID)
+		payload.Object.Amount.Currency,
+		metadataID(payload.Object.Metadata, "subscription_id"),
+		metadataID(payload.Object.Metadata, "payment_token_id")).Scan(&newID)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	return newID, nil
 }
+
+// SaveRefund сохраняет возврат по успешному платежу providerPaymentID и в той же транзакции
+// сокращает оплаченный этим платежом месяц пропорционально возвращенной доле суммы.
+// Каждый возврат отнимает разницу между долей месяца, возвращенной с ним, и уже отнятой прежними
+// возвратами по платежу, поэтому все возвраты вместе сокращают срок не больше чем на месяц.
+// Если платеж возвращен полностью, подписка пользователя получает статус subscriptionStatus,
+// а срок ее действия заканчивается не позже момента now. При частичном возврате статус
+// не меняется. Возвращает false, если возврат с таким refund.RefundID уже сохранен: повторное
+// уведомление не должно сокращать срок подписки еще раз. Если платеж не найден, возвращает
+// models.ErrPaymentNotFound. Поля refund.ID, refund.PaymentID и refund.UserUID заполняются.
+func (s *Storage) SaveRefund(ctx context.Context, providerPaymentID string, refund *models.Refund, subscriptionStatus string, now time.Time) (bool, error) {
+	const op = "storage.SaveRefund"
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var paid int64
+	query := `SELECT id, user_uid, amount FROM yookassa_payments
+			  WHERE payment_id = $1 AND status = $2
+			  ORDER BY id DESC
+			  LIMIT 1
+			  FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, providerPaymentID, models.PaymentStatusSucceeded).
+		Scan(&refund.PaymentID, &refund.UserUID, &paid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%s: %w", op, models.ErrPaymentNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var refundedBefore, reducedBefore int64
+	query = `SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(expiry_reduction_seconds), 0)
+			 FROM yookassa_refunds WHERE payment_id = $1`
+	if err := tx.QueryRowContext(ctx, query, refund.PaymentID).Scan(&refundedBefore, &reducedBefore); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `INSERT INTO yookassa_refunds (payment_id, user_uid, refund_id, amount, currency, status)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (refund_id) DO NOTHING
+			 RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query, refund.PaymentID, refund.UserUID, refund.RefundID,
+		refund.Amount, refund.Currency, refund.Status).Scan(&refund.ID, &refund.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var expiry sql.NullTime
+	query = `SELECT subscription_expiry FROM users WHERE uid = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, refund.UserUID).Scan(&expiry); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	share, full := refundShare(paid, refundedBefore+refund.Amount)
+	if expiry.Valid {
+		newExpiry, reduction := proratedExpiry(expiry.Time, time.Duration(reducedBefore)*time.Second, share)
+		if full && newExpiry.After(now) {
+			newExpiry = now
+		}
+		query = `UPDATE users SET subscription_expiry = $1 WHERE uid = $2`
+		if _, err := tx.ExecContext(ctx, query, newExpiry, refund.UserUID); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		query = `UPDATE yookassa_refunds SET expiry_reduction_seconds = $1 WHERE id = $2`
+		if _, err := tx.ExecContext(ctx, query, int64(reduction/time.Second), refund.ID); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if full {
+		query = `UPDATE users SET subscription_status = $1 WHERE uid = $2`
+		if _, err := tx.ExecContext(ctx, query, subscriptionStatus, refund.UserUID); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return true, nil
+}
+
+// refundShare возвращает долю платежа на сумму paid, возвращенную всеми возвратами на сумму
+// refunded, и признак того, что платеж возвращен полностью.
+func refundShare(paid, refunded int64) (share float64, full bool) {
+	if paid <= 0 || refunded >= paid {
+		return 1, true
+	}
+	return float64(refunded) / float64(paid), false
+}
+
+// proratedExpiry возвращает срок подписки после возврата, которым вместе с прежними возвращена
+// доля share оплаченного месяца, и на сколько этот возврат сокращает срок. expiry — текущий срок,
+// reducedBefore — на сколько его уже сократили прежние возвраты по платежу. Оплаченный месяц —
+// месяц, заканчивающийся сроком без учета прежних возвратов, поэтому доли считаются от одной
+// длины: половина февраля — 14 дней, марта — 15,5.
+func proratedExpiry(expiry time.Time, reducedBefore time.Duration, share float64) (time.Time, time.Duration) {
+	original := expiry.Add(reducedBefore)
+	month := original.Sub(original.AddDate(0, -1, 0))
+	reduction := time.Duration(float64(month)*share) - reducedBefore
+	if reduction < 0 {
+		reduction = 0
+	}
+	return expiry.Add(-reduction), reduction
+}
+
+// metadataID возвращает числовой идентификатор из metadata платежа
+// или NULL, если ключ отсутствует либо значение не является числом.
+func metadataID(metadata map[string]string, key string) sql.NullInt64 {
+	id, err := strconv.ParseInt(metadata[key], 10, 64)
+	if err != nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: id, Valid: true}
+}
+
+// ListPayments возвращает все платежи пользователя в порядке создания.
+func (s *Storage) ListPayments(ctx context.Context, userUID string) ([]*models.Payment, error) {
+	const op = "storage.ListPayments"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, user_uid, subscription_id, payment_id, amount, currency, status,
+			      payment_token_id, created_at
+			  FROM yookassa_payments
+			  WHERE user_uid = $1
+			  ORDER BY created_at, id`
+	rows, err := s.DB.QueryContext(ctx, query, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.Payment
+	for rows.Next() {
+		var p models.Payment
+		var subscriptionID, paymentTokenID sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.UserUID, &subscriptionID, &p.PaymentID, &p.Amount, &p.Currency,
+			&p.Status, &paymentTokenID, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if subscriptionID.Valid {
+			id := int(subscriptionID.Int64)
+			p.SubscriptionID = &id
+		}
+		if paymentTokenID.Valid {
+			id := int(paymentTokenID.Int64)
+			p.PaymentTokenID = &id
+		}
+		result = append(result, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// GetPaymentReceipt возвращает данные платежа пользователя для квитанции, включая
+// название сервиса связанной подписки. Если платеж не найден или принадлежит
+// другому пользователю, возвращает models.ErrPaymentNotFound.
+func (s *Storage) GetPaymentReceipt(ctx context.Context, userUID string, id int) (*models.PaymentReceipt, error) {
+	const op = "storage.GetPaymentReceipt"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT p.id, p.payment_id, p.amount, p.currency, p.status,
+			      COALESCE(s.service_name, ''), p.created_at
+			  FROM yookassa_payments p
+			  LEFT JOIN subscriptions s ON s.id = p.subscription_id
+			  WHERE p.id = $1 AND p.user_uid = $2`
+	var r models.PaymentReceipt
+	err := s.DB.QueryRowContext(ctx, query, id, userUID).Scan(&r.ID, &r.PaymentID, &r.Amount, &r.Currency,
+		&r.Status, &r.ServiceName, &r.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrPaymentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &r, nil
+}
+
+// GetPayment возвращает платеж пользователя по ID. Если платеж не найден
+// или принадлежит другому пользователю, возвращает models.ErrPaymentNotFound.
+func (s *Storage) GetPayment(ctx context.Context, userUID string, id int) (*models.Payment, error) {
+	const op = "storage.GetPayment"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, user_uid, subscription_id, payment_id, amount, currency, status,
+			      payment_token_id, created_at
+			  FROM yookassa_payments
+			  WHERE id = $1 AND user_uid = $2`
+	var p models.Payment
+	var subscriptionID, paymentTokenID sql.NullInt64
+	err := s.DB.QueryRowContext(ctx, query, id, userUID).Scan(&p.ID, &p.UserUID, &subscriptionID, &p.PaymentID,
+		&p.Amount, &p.Currency, &p.Status, &paymentTokenID, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrPaymentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if subscriptionID.Valid {
+		v := int(subscriptionID.Int64)
+		p.SubscriptionID = &v
+	}
+	if paymentTokenID.Valid {
+		v := int(paymentTokenID.Int64)
+		p.PaymentTokenID = &v
+	}
+	return &p, nil
+}
+
+// GetPaymentByID возвращает платеж пользователя по ID вместе с названием сервиса
+// связанной подписки. Идентификатор платежа у провайдера возвращается без маскирования.
+// Если платеж не найден или принадлежит другому пользователю, возвращает models.ErrPaymentNotFound.
+func (s *Storage) GetPaymentByID(ctx context.Context, userUID string, id int) (*models.PaymentDetails, error) {
+	const op = "storage.GetPaymentByID"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT p.id, p.payment_id, p.amount, p.currency, p.status,
+			      p.subscription_id, COALESCE(s.service_name, ''), p.created_at
+			  FROM yookassa_payments p
+			  LEFT JOIN subscriptions s ON s.id = p.subscription_id
+			  WHERE p.id = $1 AND p.user_uid = $2`
+	var d models.PaymentDetails
+	var subscriptionID sql.NullInt64
+	err := s.DB.QueryRowContext(ctx, query, id, userUID).Scan(&d.ID, &d.PaymentID, &d.Amount, &d.Currency,
+		&d.Status, &subscriptionID, &d.ServiceName, &d.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrPaymentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if subscriptionID.Valid {
+		v := int(subscriptionID.Int64)
+		d.SubscriptionID = &v
+	}
+	return &d, nil
+}
+
+// IsPaymentRefunded сообщает, сохранен ли возврат по платежу с ID paymentID.
+func (s *Storage) IsPaymentRefunded(ctx context.Context, paymentID int) (bool, error) {
+	const op = "storage.IsPaymentRefunded"
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var refunded bool
+	query := `SELECT EXISTS (SELECT 1 FROM yookassa_refunds WHERE payment_id = $1)`
+	if err := s.DB.QueryRowContext(ctx, query, paymentID).Scan(&refunded); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return refunded, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// ListPlans возвращает все тарифы в порядке ID.
+func (s *Storage) ListPlans(ctx context.Context) ([]models.Plan, error) {
+	const op = "storage.ListPlans"
+	rows, err := s.reader().QueryContext(ctx, `SELECT id, code, name, price, currency, is_default FROM plans ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := []models.Plan{}
+	for rows.Next() {
+		var p models.Plan
+		if err := rows.Scan(&p.ID, &p.Code, &p.Name, &p.Price, &p.Currency, &p.IsDefault); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// GetUserPlan возвращает тариф пользователя, а если тариф не выбран — тариф по умолчанию.
+// Если пользователь не найден или тариф по умолчанию не задан, возвращает models.ErrPlanNotFound.
+func (s *Storage) GetUserPlan(ctx context.Context, userUID string) (*models.Plan, error) {
+	const op = "storage.GetUserPlan"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT p.id, p.code, p.name, p.price, p.currency, p.is_default
+			  FROM users u
+			  JOIN plans p ON p.id = COALESCE(u.plan_id, (SELECT id FROM plans WHERE is_default))
+			  WHERE u.uid = $1`
+	var p models.Plan
+	err := s.DB.QueryRowContext(ctx, query, userUID).Scan(&p.ID, &p.Code, &p.Name, &p.Price, &p.Currency, &p.IsDefault)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrPlanNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_GetUserPlan(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	defaultUID := uuid.New().String()
+	factory.CreateUser(t, defaultUID, "default", "default@example.com", "hashedpassword", "user")
+
+	var premiumID int
+	err := storage.DB.QueryRow(`INSERT INTO plans (code, name, price, currency)
+		VALUES ('premium', 'Премиум', 49900, 'USD') RETURNING id`).Scan(&premiumID)
+	require.NoError(t, err)
+	premiumUID := uuid.New().String()
+	factory.CreateUser(t, premiumUID, "premium", "premium@example.com", "hashedpassword", "user")
+	_, err = storage.DB.Exec(`UPDATE users SET plan_id = $1 WHERE uid = $2`, premiumID, premiumUID)
+	require.NoError(t, err)
+
+	plan, err := storage.GetUserPlan(context.Background(), defaultUID)
+	require.NoError(t, err)
+	assert.Equal(t, "standard", plan.Code)
+	assert.Equal(t, int64(20000), plan.Price)
+	assert.Equal(t, "RUB", plan.Currency)
+	assert.True(t, plan.IsDefault)
+
+	plan, err = storage.GetUserPlan(context.Background(), premiumUID)
+	require.NoError(t, err)
+	assert.Equal(t, premiumID, plan.ID)
+	assert.Equal(t, int64(49900), plan.Price)
+	assert.Equal(t, "USD", plan.Currency)
+	assert.False(t, plan.IsDefault)
+
+	_, err = storage.GetUserPlan(context.Background(), uuid.New().String())
+	assert.ErrorIs(t, err, models.ErrPlanNotFound)
+}
+
+func TestStorage_ListPlans(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	_, err := storage.DB.Exec(`INSERT INTO plans (code, name, price, currency)
+		VALUES ('premium', 'Премиум', 49900, 'USD')`)
+	require.NoError(t, err)
+
+	plans, err := storage.ListPlans(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plans, 2)
+	assert.Equal(t, "standard", plans[0].Code)
+	assert.True(t, plans[0].IsDefault)
+	assert.Equal(t, "premium", plans[1].Code)
+	assert.Equal(t, int64(49900), plans[1].Price)
+	assert.Equal(t, "USD", plans[1].Currency)
+}
@@ -7,34 +7,134 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	// Регистрация драйвера pgx для использования с database/sql.
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
+// DefaultCurrency — валюта подписок по умолчанию, если она не задана при создании хранилища.
+const DefaultCurrency = "RUB"
+
 // Storage инкапсулирует соединение с базой данных PostgreSQL
 // и реализует методы работы с подписками и пользователями.
+// Записи и чтения, которым нужны только что записанные данные, выполняются через DB;
+// списки, поиск и сводные выборки — через реплику, если она подключена.
 type Storage struct {
-	DB *sql.DB
+	DB              *sql.DB
+	replica         *sql.DB // Реплика для чтения; nil — все запросы идут в DB
+	defaultCurrency string  // Валюта подписок без сохраненной валюты
 }
 
 // New создаёт подключение к PostgreSQL и инициализирует необходимые таблицы и индексы.
-func New(storageConnectionString string) (*Storage, error) {
+// Непустой replicaConnectionString подключает реплику, в которую направляются списки,
+// поиск и сводные выборки; пустое значение оставляет все запросы на основной базе.
+// Положительный statementTimeout передается каждому соединению пула параметром
+// statement_timeout: запросы дольше этого времени отменяет сам Postgres, и они
+// не удерживают соединения пула. Нулевое значение оставляет настройку сервера.
+// defaultCurrency подставляется при чтении подписок, у которых валюта не сохранена;
+// пустое значение заменяется DefaultCurrency.
+func New(storageConnectionString, replicaConnectionString string, statementTimeout time.Duration, defaultCurrency string) (*Storage, error) {
 	const op = "storage.New"
 
-	db, err := sql.Open("pgx", storageConnectionString)
+	db, err := open(storageConnectionString, statementTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	if err = db.PingContext(context.Background()); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+
+	var replica *sql.DB
+	if replicaConnectionString != "" {
+		replica, err = open(replicaConnectionString, statementTimeout)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("%s: replica: %w", op, err)
+		}
 	}
 
+	if defaultCurrency == "" {
+		defaultCurrency = DefaultCurrency
+	}
 	return &Storage{
-		DB: db,
+		DB:              db,
+		replica:         replica,
+		defaultCurrency: defaultCurrency,
 	}, nil
 }
 
+// open открывает пул соединений по строке подключения и проверяет доступность базы.
+func open(connectionString string, statementTimeout time.Duration) (*sql.DB, error) {
+	cfg, err := pgx.ParseConfig(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if statementTimeout > 0 {
+		// Postgres принимает таймаут в миллисекундах, а 0 означает отсутствие ограничения
+		cfg.RuntimeParams["statement_timeout"] = strconv.FormatInt(max(statementTimeout.Milliseconds(), 1), 10)
+	}
+
+	db := stdlib.OpenDB(*cfg)
+	if err = db.PingContext(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// reader возвращает пул для запросов, которые допускают отставание реплики.
+// Без реплики возвращается основная база.
+func (s *Storage) reader() *sql.DB {
+	if s.replica != nil {
+		return s.replica
+	}
+	return s.DB
+}
+
+// Close закрывает соединения с основной базой и репликой.
+func (s *Storage) Close() error {
+	err := s.DB.Close()
+	if s.replica != nil {
+		err = errors.Join(err, s.replica.Close())
+	}
+	return err
+}
+
+// currencyDest возвращает приемник для колонки currency, который заменяет NULL
+// валютой по умолчанию: подписки, созданные до появления колонки, не возвращаются без валюты.
+func (s *Storage) currencyDest(dest *string) sql.Scanner {
+	return &currencyScanner{dest: dest, fallback: s.defaultCurrency}
+}
+
+type currencyScanner struct {
+	dest     *string
+	fallback string
+}
+
+// Scan реализует sql.Scanner.
+func (c *currencyScanner) Scan(src any) error {
+	var value sql.NullString
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+	if !value.Valid || value.String == "" {
+		*c.dest = c.fallback
+		return nil
+	}
+	*c.dest = value.String
+	return nil
+}
+
+// Ping проверяет доступность базы данных.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.Ping"
+	if err := s.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
 // CheckDatabaseReady проверяет готовность базы данных.
 func CheckDatabaseReady(storage *Storage) error {
 	var exists bool
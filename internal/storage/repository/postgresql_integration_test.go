@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"strconv"
 	"testing"
 	"time"
@@ -15,13 +17,14 @@ import (
 
 func TestStorage_ListEntrys(t *testing.T) {
 	type args struct {
-		ctx      context.Context
-		username string
-		limit    int
-		offset   int
+		ctx     context.Context
+		userUID string
+		limit   int
+		offset  int
 	}
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
 
 	tests := []struct {
 		name      string
@@ -33,15 +36,14 @@ func TestStorage_ListEntrys(t *testing.T) {
 		{
 			name: "successful list entries with pagination",
 			args: args{
-				ctx:      context.Background(),
-				username: "testuser",
-				limit:    10,
-				offset:   0,
+				ctx:     context.Background(),
+				userUID: userUID,
+				limit:   10,
+				offset:  0,
 			},
 			wantCount: 2,
 			wantErr:   false,
 			setup: func(t *testing.T, factory *TestDataFactory) {
-				userUID := uuid.New().String()
 				factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
 				factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
 				factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 6, userUID, startDate, true)
@@ -50,10 +52,10 @@ func TestStorage_ListEntrys(t *testing.T) {
 		{
 			name: "list entries for non-existing user",
 			args: args{
-				ctx:      context.Background(),
-				username: "nonexistent",
-				limit:    10,
-				offset:   0,
+				ctx:     context.Background(),
+				userUID: uuid.New().String(),
+				limit:   10,
+				offset:  0,
 			},
 			wantCount: 0,
 			wantErr:   false,
@@ -69,7 +71,7 @@ func TestStorage_ListEntrys(t *testing.T) {
 			factory := NewTestDataFactory(storage)
 			tt.setup(t, factory)
 
-			got, err := storage.ListEntrys(tt.args.ctx, tt.args.username, tt.args.limit, tt.args.offset)
+			got, err := storage.ListEntrys(tt.args.ctx, tt.args.userUID, tt.args.limit, tt.args.offset, models.ListSort{})
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -130,7 +132,7 @@ func TestStorage_ListAllEntrys(t *testing.T) {
 			factory := NewTestDataFactory(storage)
 			tt.setup(t, factory)
 
-			got, err := storage.ListAllEntrys(tt.args.ctx, tt.args.limit, tt.args.offset)
+			got, err := storage.ListAllEntrys(tt.args.ctx, tt.args.limit, tt.args.offset, models.ListSort{})
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -142,6 +144,238 @@ func TestStorage_ListAllEntrys(t *testing.T) {
 	}
 }
 
+func TestStorage_ListAllEntrys_OrderedByID(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	first := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	second := factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 12, userUID, startDate, true)
+	third := factory.CreateSubscription(t, "Disney+", 800.0, "testuser", startDate, 12, userUID, startDate, true)
+
+	page, err := storage.ListAllEntrys(context.Background(), 2, 0, models.ListSort{})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, first, page[0].ID)
+	assert.Equal(t, second, page[1].ID)
+
+	page, err = storage.ListAllEntrys(context.Background(), 2, 2, models.ListSort{})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, third, page[0].ID)
+}
+
+func TestStorage_ListAllEntrys_StablePagination(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID1 := uuid.New().String()
+	userUID2 := uuid.New().String()
+	factory.CreateUser(t, userUID1, "user1", "user1@example.com", "hashedpassword1", "user")
+	factory.CreateUser(t, userUID2, "user2", "user2@example.com", "hashedpassword2", "user")
+
+	const total = 25
+	want := make([]int, 0, total)
+	for i := 0; i < total; i++ {
+		username, userUID := "user1", userUID1
+		if i%2 == 1 {
+			username, userUID = "user2", userUID2
+		}
+		id := factory.CreateSubscription(t, "Service-"+strconv.Itoa(i), float64(100+i), username,
+			startDate, 12, userUID, startDate, i%3 != 0)
+		want = append(want, id)
+	}
+
+	// Обновление части строк меняет их физическое расположение, но не должно влиять на порядок страниц
+	for _, id := range want[:5] {
+		_, err := storage.DB.ExecContext(context.Background(),
+			`UPDATE subscriptions SET price = price + 1 WHERE id = $1`, id)
+		require.NoError(t, err)
+	}
+
+	const pageSize = 4
+	seen := make(map[int]bool, total)
+	var got []int
+	for offset := 0; ; offset += pageSize {
+		page, err := storage.ListAllEntrys(context.Background(), pageSize, offset, models.ListSort{})
+		require.NoError(t, err)
+		for _, entry := range page {
+			require.False(t, seen[entry.ID], "duplicate id %d at offset %d", entry.ID, offset)
+			seen[entry.ID] = true
+			got = append(got, entry.ID)
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestStorage_ListEntrys_Sort(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID,
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true)
+	factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 12, userUID,
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), true)
+	factory.CreateSubscription(t, "Disney+", 800.0, "testuser", startDate, 12, userUID,
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), true)
+
+	tests := []struct {
+		name string
+		sort models.ListSort
+		want []string
+	}{
+		{name: "default id", sort: models.ListSort{}, want: []string{"Netflix", "Spotify", "Disney+"}},
+		{name: "id desc", sort: models.ListSort{Field: models.SortByID, Desc: true}, want: []string{"Disney+", "Spotify", "Netflix"}},
+		{name: "next payment date", sort: models.ListSort{Field: models.SortByNextPaymentDate}, want: []string{"Spotify", "Netflix", "Disney+"}},
+		{name: "price desc", sort: models.ListSort{Field: models.SortByPrice, Desc: true}, want: []string{"Netflix", "Disney+", "Spotify"}},
+		{name: "service name", sort: models.ListSort{Field: models.SortByServiceName}, want: []string{"Disney+", "Netflix", "Spotify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := storage.ListEntrys(context.Background(), userUID, 10, 0, tt.sort)
+			require.NoError(t, err)
+			var gotNames []string
+			for _, e := range got {
+				gotNames = append(gotNames, e.ServiceName)
+			}
+			assert.Equal(t, tt.want, gotNames)
+
+			all, err := storage.ListAllEntrys(context.Background(), 10, 0, tt.sort)
+			require.NoError(t, err)
+			gotNames = gotNames[:0]
+			for _, e := range all {
+				gotNames = append(gotNames, e.ServiceName)
+			}
+			assert.Equal(t, tt.want, gotNames)
+		})
+	}
+}
+
+func TestStorage_FindByServiceName(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	first := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	second := factory.CreateSubscription(t, "netflix", 800.0, "testuser", startDate, 6, userUID, startDate, false)
+	factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 12, userUID, startDate, true)
+	factory.CreateSubscription(t, "Netflix", 1000.0, "other", startDate, 12, otherUID, startDate, true)
+
+	got, err := storage.FindByServiceName(context.Background(), userUID, "NETFLIX")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, first, got[0].ID)
+	assert.Equal(t, second, got[1].ID)
+
+	got, err = storage.FindByServiceName(context.Background(), userUID, "Spotify")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	got, err = storage.FindByServiceName(context.Background(), userUID, "Disney+")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestStorage_QueriesByUserUIDSurviveUsernameChange(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	ctx := context.Background()
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "oldname", "old@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	id := factory.CreateSubscription(t, "Netflix", 1000.0, "oldname", startDate, 12, userUID, startDate, true)
+	factory.CreateSubscription(t, "Netflix", 500.0, "other", startDate, 12, otherUID, startDate, true)
+
+	// Пользователь сменил имя; username в подписках остается прежним, а другой пользователь занял старое имя
+	_, err := storage.DB.Exec(`UPDATE users SET username = 'newname' WHERE uid = $1`, userUID)
+	require.NoError(t, err)
+	_, err = storage.DB.Exec(`UPDATE users SET username = 'oldname' WHERE uid = $1`, otherUID)
+	require.NoError(t, err)
+
+	list, err := storage.ListEntrys(ctx, userUID, 10, 0, models.ListSort{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 1000, list[0].Price)
+
+	found, err := storage.FindByServiceName(ctx, userUID, "netflix")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, id, found[0].ID)
+
+	total, err := storage.CountSumEntrys(ctx, models.FilterSum{UserUID: userUID, StartDate: startDate, CounterMonths: 12})
+	require.NoError(t, err)
+	assert.InDelta(t, 12*1000.0, total, 0.001)
+
+	_, err = storage.UpdateEntry(ctx, models.Entry{
+		ServiceName: "Netflix", Price: 1200, StartDate: startDate, CounterMonths: 12,
+		UserUID: userUID, NextPaymentDate: startDate, IsActive: true,
+	}, id, "newname")
+	require.NoError(t, err)
+	got, err := storage.ReadEntryForUser(ctx, id, userUID)
+	require.NoError(t, err)
+	assert.Equal(t, userUID, got.UserUID, "обновление не меняет владельца подписки")
+	assert.Equal(t, "newname", got.Username)
+}
+
+func TestStorage_EntryNotesAndMetadata(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	factory := NewTestDataFactory(storage)
+	ctx := context.Background()
+	startDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "user", "user@example.com", "hashedpassword", "user")
+
+	entry := models.Entry{
+		ServiceName: "Netflix", Price: 500, Username: "user", StartDate: startDate, CounterMonths: 12,
+		UserUID: userUID, NextPaymentDate: startDate, IsActive: true,
+		Notes: "общая с семьей", Metadata: json.RawMessage(`{"shared_with":["mom","dad"]}`),
+	}
+	id, err := storage.CreateEntry(ctx, entry)
+	require.NoError(t, err)
+
+	got, err := storage.ReadEntryForUser(ctx, id, userUID)
+	require.NoError(t, err)
+	assert.Equal(t, "общая с семьей", got.Notes)
+	assert.JSONEq(t, `{"shared_with":["mom","dad"]}`, string(got.Metadata))
+
+	entry.Notes, entry.Metadata = "", nil
+	_, err = storage.UpdateEntry(ctx, entry, id, "user")
+	require.NoError(t, err)
+
+	got, err = storage.ReadEntryForUser(ctx, id, userUID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Notes)
+	assert.Nil(t, got.Metadata)
+
+	var storedAsNull bool
+	require.NoError(t, storage.DB.QueryRow(`SELECT notes IS NULL AND metadata IS NULL FROM subscriptions WHERE id = $1`, id).Scan(&storedAsNull))
+	assert.True(t, storedAsNull, "пустые заметка и метаданные хранятся как NULL")
+}
+
 func TestStorage_GetUser(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -415,6 +649,83 @@ func TestStorage_ListPaymentTokens(t *testing.T) {
 	}
 }
 
+func TestStorage_ListPaymentTokensPage(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	factory.CreatePaymentToken(t, userUID, "token1")
+	factory.CreatePaymentToken(t, userUID, "token2")
+	factory.CreatePaymentToken(t, userUID, "token3")
+
+	page, err := storage.ListPaymentTokensPage(context.Background(), userUID, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "token1", page[0].Token)
+	assert.Equal(t, "token2", page[1].Token)
+
+	page, err = storage.ListPaymentTokensPage(context.Background(), userUID, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "token3", page[0].Token)
+
+	page, err = storage.ListPaymentTokensPage(context.Background(), userUID, 2, 3)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func TestStorage_DeletePaymentToken(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	ownerUID := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, ownerUID, "owner", "owner@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+
+	tokenID, err := storage.CreatePaymentToken(context.Background(), ownerUID, "token123")
+	require.NoError(t, err)
+
+	// Чужой токен удалить нельзя
+	err = storage.DeletePaymentToken(context.Background(), otherUID, tokenID)
+	require.ErrorIs(t, err, models.ErrPaymentTokenNotFound)
+
+	err = storage.DeletePaymentToken(context.Background(), ownerUID, tokenID)
+	require.NoError(t, err)
+
+	// Повторное удаление не находит активный токен
+	err = storage.DeletePaymentToken(context.Background(), ownerUID, tokenID)
+	require.ErrorIs(t, err, models.ErrPaymentTokenNotFound)
+
+	tokens, err := storage.ListPaymentTokens(context.Background(), ownerUID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// Отозванный токен не находится и не мешает сохранить ту же карту снова
+	_, found, err := storage.FindPaymentToken(context.Background(), ownerUID, "token123")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	newID, err := storage.CreatePaymentToken(context.Background(), ownerUID, "token123")
+	require.NoError(t, err)
+	assert.NotEqual(t, tokenID, newID)
+	gotID, found, err := storage.FindPaymentToken(context.Background(), ownerUID, "token123")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, newID, gotID)
+
+	// Повторное сохранение активной карты возвращает ту же запись
+	againID, err := storage.CreatePaymentToken(context.Background(), ownerUID, "token123")
+	require.NoError(t, err)
+	assert.Equal(t, newID, againID)
+	tokens, err = storage.ListPaymentTokens(context.Background(), ownerUID)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
+
 func TestStorage_SavePayment(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -434,9 +745,10 @@ func TestStorage_SavePayment(t *testing.T) {
 				ctx: context.Background(),
 				payload: &paymentwebhook.Payload{
 					Object: struct {
-						ID     string `json:"id"`
-						Status string `json:"status"`
-						Amount struct {
+						ID        string `json:"id"`
+						PaymentID string `json:"payment_id,omitempty"`
+						Status    string `json:"status"`
+						Amount    struct {
 							Value    string `json:"value"`
 							Currency string `json:"currency"`
 						} `json:"amount"`
@@ -498,6 +810,320 @@ func TestStorage_SavePayment(t *testing.T) {
 	}
 }
 
+func TestStorage_SavePayment_LinksSubscriptionAndToken(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	subID := factory.CreateSubscription(t, "Subscription-Aggregator", 200.0, "testuser", startDate, 1, userUID, startDate, true)
+	tokenID, err := storage.CreatePaymentToken(context.Background(), userUID, "token123")
+	require.NoError(t, err)
+
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	otherTokenID, err := storage.CreatePaymentToken(context.Background(), otherUID, "token456")
+	require.NoError(t, err)
+
+	var payload paymentwebhook.Payload
+	payload.Object.ID = "payment_linked"
+	payload.Object.Status = "succeeded"
+	payload.Object.Amount.Value = "200.00"
+	payload.Object.Amount.Currency = "RUB"
+	payload.Object.Metadata = map[string]string{
+		"user_uid":         userUID,
+		"subscription_id":  strconv.Itoa(subID),
+		"payment_token_id": strconv.Itoa(tokenID),
+	}
+
+	_, err = storage.SavePayment(context.Background(), &payload, 20000, userUID)
+	require.NoError(t, err)
+
+	// Токен другого пользователя не должен связываться с платежом
+	payload.Object.ID = "payment_foreign_token"
+	payload.Object.Metadata["payment_token_id"] = strconv.Itoa(otherTokenID)
+	_, err = storage.SavePayment(context.Background(), &payload, 20000, userUID)
+	require.NoError(t, err)
+
+	payments, err := storage.ListPayments(context.Background(), userUID)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+
+	require.NotNil(t, payments[0].SubscriptionID)
+	assert.Equal(t, subID, *payments[0].SubscriptionID)
+	require.NotNil(t, payments[0].PaymentTokenID)
+	assert.Equal(t, tokenID, *payments[0].PaymentTokenID)
+
+	require.NotNil(t, payments[1].SubscriptionID)
+	assert.Nil(t, payments[1].PaymentTokenID)
+}
+
+func TestStorage_SavePayment_Upsert(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+
+	var payload paymentwebhook.Payload
+	payload.Object.ID = "payment_retried"
+	payload.Object.Status = "pending"
+	payload.Object.Amount.Currency = "RUB"
+	firstID, err := storage.SavePayment(context.Background(), &payload, 20000, userUID)
+	require.NoError(t, err)
+
+	// Повторная доставка webhook с новым статусом
+	payload.Object.Status = "succeeded"
+	secondID, err := storage.SavePayment(context.Background(), &payload, 20000, userUID)
+	require.NoError(t, err)
+	assert.Equal(t, firstID, secondID, "existing payment id is returned on conflict")
+
+	payments, err := storage.ListPayments(context.Background(), userUID)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, firstID, payments[0].ID)
+	assert.Equal(t, "succeeded", payments[0].Status)
+}
+
+func TestStorage_GetPaymentReceipt(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	subID := factory.CreateSubscription(t, "Netflix", 499.0, "testuser", startDate, 1, userUID, startDate, true)
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+
+	var payload paymentwebhook.Payload
+	payload.Object.ID = "payment_receipt"
+	payload.Object.Status = "succeeded"
+	payload.Object.Amount.Currency = "RUB"
+	payload.Object.Metadata = map[string]string{"subscription_id": strconv.Itoa(subID)}
+	id, err := storage.SavePayment(context.Background(), &payload, 49900, userUID)
+	require.NoError(t, err)
+
+	receipt, err := storage.GetPaymentReceipt(context.Background(), userUID, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, receipt.ID)
+	assert.Equal(t, "payment_receipt", receipt.PaymentID)
+	assert.Equal(t, int64(49900), receipt.Amount)
+	assert.Equal(t, "RUB", receipt.Currency)
+	assert.Equal(t, "succeeded", receipt.Status)
+	assert.Equal(t, "Netflix", receipt.ServiceName)
+
+	_, err = storage.GetPaymentReceipt(context.Background(), otherUID, id)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+
+	_, err = storage.GetPaymentReceipt(context.Background(), userUID, id+1000)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+}
+
+func TestStorage_GetPaymentByID(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	subID := factory.CreateSubscription(t, "Netflix", 499.0, "testuser", startDate, 1, userUID, startDate, true)
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+
+	var payload paymentwebhook.Payload
+	payload.Object.ID = "payment_details"
+	payload.Object.Status = "succeeded"
+	payload.Object.Amount.Currency = "RUB"
+	payload.Object.Metadata = map[string]string{"subscription_id": strconv.Itoa(subID)}
+	id, err := storage.SavePayment(context.Background(), &payload, 49900, userUID)
+	require.NoError(t, err)
+
+	details, err := storage.GetPaymentByID(context.Background(), userUID, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, details.ID)
+	assert.Equal(t, "payment_details", details.PaymentID)
+	assert.Equal(t, int64(49900), details.Amount)
+	assert.Equal(t, "RUB", details.Currency)
+	assert.Equal(t, "succeeded", details.Status)
+	require.NotNil(t, details.SubscriptionID)
+	assert.Equal(t, subID, *details.SubscriptionID)
+	assert.Equal(t, "Netflix", details.ServiceName)
+
+	_, err = storage.GetPaymentByID(context.Background(), otherUID, id)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+
+	_, err = storage.GetPaymentByID(context.Background(), userUID, id+1000)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+}
+
+func TestStorage_SaveRefund(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	_, err := storage.DB.Exec(`UPDATE users SET subscription_status = 'active', subscription_expiry = '2025-04-10' WHERE uid = $1`, userUID)
+	require.NoError(t, err)
+
+	var payload paymentwebhook.Payload
+	payload.Object.ID = "payment_refund"
+	payload.Object.Status = "succeeded"
+	payload.Object.Amount.Currency = "RUB"
+	paymentID, err := storage.SavePayment(context.Background(), &payload, 20000, userUID)
+	require.NoError(t, err)
+
+	payment, err := storage.GetPayment(context.Background(), userUID, paymentID)
+	require.NoError(t, err)
+	assert.Equal(t, "payment_refund", payment.PaymentID)
+	assert.Equal(t, int64(20000), payment.Amount)
+	_, err = storage.GetPayment(context.Background(), uuid.New().String(), paymentID)
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+
+	refunded, err := storage.IsPaymentRefunded(context.Background(), paymentID)
+	require.NoError(t, err)
+	assert.False(t, refunded)
+
+	refund := &models.Refund{RefundID: "refund_1", Amount: 20000, Currency: "RUB", Status: "succeeded"}
+	created, err := storage.SaveRefund(context.Background(), "payment_refund", refund, "cancel", time.Now())
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotZero(t, refund.ID)
+	assert.Equal(t, paymentID, refund.PaymentID)
+	assert.Equal(t, userUID, refund.UserUID)
+
+	var status string
+	var expiry time.Time
+	err = storage.DB.QueryRow(`SELECT subscription_status, subscription_expiry FROM users WHERE uid = $1`, userUID).Scan(&status, &expiry)
+	require.NoError(t, err)
+	assert.Equal(t, "cancel", status)
+	assert.Equal(t, "2025-03-10", expiry.Format("2006-01-02"))
+	refunded, err = storage.IsPaymentRefunded(context.Background(), paymentID)
+	require.NoError(t, err)
+	assert.True(t, refunded)
+
+	// Повторное уведомление о том же возврате не сокращает срок еще раз
+	created, err = storage.SaveRefund(context.Background(), "payment_refund", &models.Refund{RefundID: "refund_1", Amount: 20000, Currency: "RUB", Status: "succeeded"}, "cancel", time.Now())
+	require.NoError(t, err)
+	assert.False(t, created)
+	err = storage.DB.QueryRow(`SELECT subscription_expiry FROM users WHERE uid = $1`, userUID).Scan(&expiry)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-10", expiry.Format("2006-01-02"))
+
+	_, err = storage.SaveRefund(context.Background(), "unknown_payment", &models.Refund{RefundID: "refund_2"}, "cancel", time.Now())
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+}
+
+func TestStorage_SaveRefund_Proration(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+
+	newPaidUser := func(username, paymentID, expiry string) string {
+		userUID := uuid.New().String()
+		factory.CreateUser(t, userUID, username, username+"@example.com", "hashedpassword", "user")
+		_, err := storage.DB.Exec(`UPDATE users SET subscription_status = 'active', subscription_expiry = $2 WHERE uid = $1`, userUID, expiry)
+		require.NoError(t, err)
+		var payload paymentwebhook.Payload
+		payload.Object.ID = paymentID
+		payload.Object.Status = "succeeded"
+		payload.Object.Amount.Currency = "RUB"
+		_, err = storage.SavePayment(ctx, &payload, 20000, userUID)
+		require.NoError(t, err)
+		return userUID
+	}
+	userState := func(userUID string) (string, string) {
+		var status string
+		var expiry time.Time
+		err := storage.DB.QueryRow(`SELECT subscription_status, subscription_expiry FROM users WHERE uid = $1`, userUID).Scan(&status, &expiry)
+		require.NoError(t, err)
+		return status, expiry.Format("2006-01-02")
+	}
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	saveRefund := func(paymentID, refundID string, amount int64) {
+		created, err := storage.SaveRefund(ctx, paymentID,
+			&models.Refund{RefundID: refundID, Amount: amount, Currency: "RUB", Status: "succeeded"}, "cancel", now)
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+
+	t.Run("частичный возврат, затем остаток сокращают срок ровно на месяц", func(t *testing.T) {
+		userUID := newPaidUser("partial", "payment_partial", "2025-03-01")
+
+		// Возврат половины суммы сокращает оплаченный февраль на 14 дней из 28, подписка остается активной
+		saveRefund("payment_partial", "refund_half", 10000)
+		status, expiry := userState(userUID)
+		assert.Equal(t, "active", status)
+		assert.Equal(t, "2025-02-15", expiry)
+
+		// Остаток отнимает только вторую половину февраля, а не еще один месяц
+		saveRefund("payment_partial", "refund_rest", 10000)
+		status, expiry = userState(userUID)
+		assert.Equal(t, "cancel", status)
+		assert.Equal(t, "2025-02-01", expiry)
+	})
+
+	t.Run("полный возврат завершает будущий срок сразу", func(t *testing.T) {
+		userUID := newPaidUser("future", "payment_future", "2099-03-01")
+
+		saveRefund("payment_future", "refund_future", 20000)
+		status, expiry := userState(userUID)
+		assert.Equal(t, "cancel", status)
+		assert.Equal(t, "2025-06-10", expiry)
+	})
+}
+
+func TestRefundShare(t *testing.T) {
+	tests := []struct {
+		name           string
+		paid, refunded int64
+		wantShare      float64
+		wantFull       bool
+	}{
+		{name: "частичный возврат", paid: 20000, refunded: 5000, wantShare: 0.25},
+		{name: "полный возврат", paid: 20000, refunded: 20000, wantShare: 1, wantFull: true},
+		{name: "возврат больше платежа", paid: 20000, refunded: 25000, wantShare: 1, wantFull: true},
+		{name: "платеж без суммы", paid: 0, refunded: 100, wantShare: 1, wantFull: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			share, full := refundShare(tt.paid, tt.refunded)
+			assert.InDelta(t, tt.wantShare, share, 1e-9)
+			assert.Equal(t, tt.wantFull, full)
+		})
+	}
+}
+
+func TestProratedExpiry(t *testing.T) {
+	day := 24 * time.Hour
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+
+	// Половина оплаченного февраля
+	expiry, reduction := proratedExpiry(date(3, 1), 0, 0.5)
+	assert.Equal(t, date(2, 15), expiry)
+	assert.Equal(t, 14*day, reduction)
+
+	// Остаток после половины: месяц считается от срока без прежнего возврата
+	expiry, reduction = proratedExpiry(date(2, 15), 14*day, 1)
+	assert.Equal(t, date(2, 1), expiry)
+	assert.Equal(t, 14*day, reduction)
+
+	// Четверть, затем еще четверть марта — вместе половина
+	expiry, reduction = proratedExpiry(date(4, 1), 0, 0.25)
+	assert.Equal(t, 186*time.Hour, reduction)
+	expiry, reduction = proratedExpiry(expiry, 186*time.Hour, 0.5)
+	assert.Equal(t, 186*time.Hour, reduction)
+	assert.Equal(t, date(4, 1).Add(-372*time.Hour), expiry)
+}
+
 func TestStorage_GetActiveSubscriptionIDByUserUID(t *testing.T) {
 	type args struct {
 		ctx         context.Context
@@ -786,7 +1412,7 @@ func TestStorage_FindSubscriptionExpiringToday(t *testing.T) {
 			err := tt.setup(t, factory)
 			require.NoError(t, err)
 
-			got, err := storage.FindSubscriptionExpiringToday(context.Background())
+			got, err := storage.FindSubscriptionExpiringToday(context.Background(), time.Now())
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -798,6 +1424,154 @@ func TestStorage_FindSubscriptionExpiringToday(t *testing.T) {
 	}
 }
 
+func TestStorage_FindEndedTrialsAndActivate(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	insertUser := func(username, status string, trialEnd time.Time) string {
+		userUID := uuid.New().String()
+		_, err := storage.DB.Exec(`INSERT INTO users
+			(uid, username, email, password_hash, role, trial_end_date, subscription_status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			userUID, username, username+"@example.com", "hashedpassword", "user", trialEnd, status)
+		require.NoError(t, err)
+		return userUID
+	}
+	trialEnd := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	endedUID := insertUser("ended", "trial", trialEnd)
+	insertUser("running", "trial", time.Now().AddDate(0, 0, 3))
+	insertUser("paid", "active", time.Now().AddDate(0, 0, -10))
+
+	users, err := storage.FindEndedTrials(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, endedUID, users[0].UUID)
+
+	require.NoError(t, storage.ActivateTrialSubscription(context.Background(), endedUID))
+	// Повторная активация не должна продлевать подписку еще на месяц
+	require.NoError(t, storage.ActivateTrialSubscription(context.Background(), endedUID))
+
+	user, err := storage.GetUser(context.Background(), endedUID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", user.SubscriptionStatus)
+	require.NotNil(t, user.SubscriptionExpire)
+	assert.WithinDuration(t, trialEnd.AddDate(0, 1, 0), *user.SubscriptionExpire, time.Second)
+
+	users, err = storage.FindEndedTrials(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestStorage_ExpireLapsedSubscriptions(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-72 * time.Hour)
+	trialEnd := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	lapsedUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, lapsedUID, "lapsed", "lapsed@example.com", "hashedpassword", "user",
+		trialEnd, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "active")
+	graceUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, graceUID, "grace", "grace@example.com", "hashedpassword", "user",
+		trialEnd, time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC), "active")
+	paidUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, paidUID, "paid", "paid@example.com", "hashedpassword", "user",
+		trialEnd, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), "active")
+	canceledUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, canceledUID, "canceled", "canceled@example.com", "hashedpassword", "user",
+		trialEnd, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), "canceled")
+
+	notification := func(u *models.User) (models.OutboxMessage, error) {
+		return models.OutboxMessage{RoutingKey: "subscription.expired", Payload: []byte(`{}`), DedupKey: "subscription.expired:" + u.UUID}, nil
+	}
+	users, queued, err := storage.ExpireLapsedSubscriptions(ctx, cutoff, notification)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, 1, queued)
+	assert.Equal(t, lapsedUID, users[0].UUID)
+	assert.Equal(t, "lapsed@example.com", users[0].Email)
+	assert.Equal(t, "expired", users[0].SubscriptionStatus)
+
+	statuses := map[string]string{lapsedUID: "expired", graceUID: "active", paidUID: "active", canceledUID: "canceled"}
+	for uid, want := range statuses {
+		user, err := storage.GetUser(ctx, uid)
+		require.NoError(t, err)
+		assert.Equal(t, want, user.SubscriptionStatus, user.Username)
+	}
+
+	// Уведомление записано вместе со сменой статуса
+	pending, err := storage.ClaimPendingNotifications(ctx, time.Now(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "subscription.expired:"+lapsedUID, pending[0].DedupKey)
+
+	// Повторный проход не возвращает уже отключенных пользователей
+	users, queued, err = storage.ExpireLapsedSubscriptions(ctx, cutoff, notification)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Zero(t, queued)
+}
+
+func TestStorage_ExpireLapsedSubscriptionsRollsBackOnNotificationError(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	lapsedUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, lapsedUID, "lapsed", "lapsed@example.com", "hashedpassword", "user",
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "active")
+
+	_, _, err := storage.ExpireLapsedSubscriptions(ctx, time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		func(*models.User) (models.OutboxMessage, error) {
+			return models.OutboxMessage{}, errors.New("marshal failed")
+		})
+	require.Error(t, err)
+
+	// Без уведомления пользователь не отключается
+	user, err := storage.GetUser(ctx, lapsedUID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", user.SubscriptionStatus)
+}
+
+func TestStorage_ExpireTrial(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	trialEnd := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trialUID := uuid.New().String()
+	factory.CreateUserWithSubscription(t, trialUID, "trial", "trial@example.com", "hashedpassword", "user",
+		trialEnd, trialEnd, "trial")
+	message := func(uid string) models.OutboxMessage {
+		return models.OutboxMessage{RoutingKey: "trial.expired", Payload: []byte(`{}`), DedupKey: "trial.expired:" + uid}
+	}
+
+	expired, err := storage.ExpireTrial(ctx, trialUID, message(trialUID))
+	require.NoError(t, err)
+	assert.True(t, expired)
+	user, err := storage.GetUser(ctx, trialUID)
+	require.NoError(t, err)
+	assert.Equal(t, "expired", user.SubscriptionStatus)
+
+	// Повторный вызов не меняет статус и не записывает второе уведомление
+	expired, err = storage.ExpireTrial(ctx, trialUID, models.OutboxMessage{
+		RoutingKey: "trial.expired", Payload: []byte(`{}`), DedupKey: "trial.expired:again",
+	})
+	require.NoError(t, err)
+	assert.False(t, expired)
+
+	pending, err := storage.ClaimPendingNotifications(ctx, time.Now(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "trial.expired:"+trialUID, pending[0].DedupKey)
+}
+
 func TestStorage_FindOldNextPaymentDate(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -939,3 +1713,360 @@ func TestStorage_UpdateNextPaymentDate(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_ListEntrysByUserUID(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	firstID := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 6, userUID, startDate, false)
+	factory.CreateSubscription(t, "Disney+", 800.0, "other", startDate, 12, otherUID, startDate, true)
+
+	got, err := storage.ListEntrysByUserUID(context.Background(), userUID)
+
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, firstID, got[0].ID)
+	assert.Equal(t, "Netflix", got[0].ServiceName)
+	assert.Equal(t, "Spotify", got[1].ServiceName)
+}
+
+func TestStorage_ReadEntry_NullCurrency(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	// Фабрика не заполняет currency, как у строк, созданных до появления колонки
+	legacyID := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	_, err := storage.CreateEntry(context.Background(), models.Entry{
+		ServiceName: "Spotify", Price: 5, Username: "testuser", StartDate: startDate, CounterMonths: 6,
+		UserUID: userUID, NextPaymentDate: startDate, IsActive: true, Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	got, err := storage.ReadEntryForUser(context.Background(), legacyID, userUID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, got.Currency)
+
+	list, err := storage.ListEntrysByUserUID(context.Background(), userUID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, DefaultCurrency, list[0].Currency)
+	assert.Equal(t, "USD", list[1].Currency)
+}
+
+func TestStorage_BackfillCurrency(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	storage.defaultCurrency = "USD"
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	legacyID := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	eurID, err := storage.CreateEntry(context.Background(), models.Entry{
+		ServiceName: "Spotify", Price: 5, Username: "testuser", StartDate: startDate, CounterMonths: 6,
+		UserUID: userUID, NextPaymentDate: startDate, IsActive: true, Currency: "EUR",
+	})
+	require.NoError(t, err)
+
+	updated, err := storage.BackfillCurrency(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	var legacy, eur string
+	require.NoError(t, storage.DB.QueryRow(`SELECT currency FROM subscriptions WHERE id = $1`, legacyID).Scan(&legacy))
+	require.NoError(t, storage.DB.QueryRow(`SELECT currency FROM subscriptions WHERE id = $1`, eurID).Scan(&eur))
+	assert.Equal(t, "USD", legacy)
+	assert.Equal(t, "EUR", eur)
+
+	// Повторный запуск ничего не меняет
+	updated, err = storage.BackfillCurrency(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updated)
+}
+
+func TestStorage_ListPayments(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+
+	payload := &paymentwebhook.Payload{}
+	payload.Object.ID = "pay_1"
+	payload.Object.Status = "succeeded"
+	payload.Object.Amount.Currency = "RUB"
+	_, err := storage.SavePayment(context.Background(), payload, 20000, userUID)
+	require.NoError(t, err)
+
+	got, err := storage.ListPayments(context.Background(), userUID)
+
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "pay_1", got[0].PaymentID)
+	assert.Equal(t, int64(20000), got[0].Amount)
+	assert.Nil(t, got[0].SubscriptionID)
+
+	empty, err := storage.ListPayments(context.Background(), uuid.New().String())
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestStorage_ListActiveEntrysAfterID(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	first := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
+	factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 12, userUID, startDate, false)
+	third := factory.CreateSubscription(t, "Disney+", 800.0, "testuser", startDate, 12, userUID, startDate, true)
+
+	batch, err := storage.ListActiveEntrysAfterID(context.Background(), 0, 1)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, first, batch[0].ID)
+
+	batch, err = storage.ListActiveEntrysAfterID(context.Background(), first, 10)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, third, batch[0].ID)
+
+	// Исправление дрейфа: дата следующего платежа записывается и читается обратно
+	fixed := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	batch[0].NextPaymentDate = fixed
+	_, err = storage.UpdateNextPaymentDate(context.Background(), batch[0])
+	require.NoError(t, err)
+
+	batch, err = storage.ListActiveEntrysAfterID(context.Background(), first, 10)
+	require.NoError(t, err)
+	assert.True(t, batch[0].NextPaymentDate.Equal(fixed))
+}
+
+func TestStorage_ShiftNextPaymentDates(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	netflix := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, due, true)
+	later := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, due.AddDate(0, 1, 0), true)
+	paused := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, due, false)
+	spotify := factory.CreateSubscription(t, "Spotify", 500.0, "testuser", startDate, 12, userUID, due, true)
+
+	nextPayment := func(id int) time.Time {
+		t.Helper()
+		entry, err := storage.ReadEntryForUser(ctx, id, userUID)
+		require.NoError(t, err)
+		return entry.NextPaymentDate
+	}
+
+	t.Run("сдвиг вперед по сервису и периоду", func(t *testing.T) {
+		filter := models.PaymentDateShiftFilter{ServiceName: "netflix", DueFrom: "2024-06-01", DueTo: "2024-07-15"}
+		ids, err := storage.ShiftNextPaymentDates(ctx, filter, 7)
+		require.NoError(t, err)
+		assert.Equal(t, []int{netflix}, ids)
+
+		assert.True(t, nextPayment(netflix).Equal(due.AddDate(0, 0, 7)))
+		assert.True(t, nextPayment(later).Equal(due.AddDate(0, 1, 0)), "outside of the due range")
+		assert.True(t, nextPayment(paused).Equal(due), "paused subscription is not shifted")
+		assert.True(t, nextPayment(spotify).Equal(due), "other service is not shifted")
+	})
+
+	t.Run("сдвиг назад по пользователю", func(t *testing.T) {
+		ids, err := storage.ShiftNextPaymentDates(ctx, models.PaymentDateShiftFilter{UserUID: userUID}, -10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{netflix, later, spotify}, ids)
+
+		assert.True(t, nextPayment(netflix).Equal(due.AddDate(0, 0, -3)))
+		assert.True(t, nextPayment(later).Equal(due.AddDate(0, 1, -10)))
+		assert.True(t, nextPayment(spotify).Equal(due.AddDate(0, 0, -10)))
+	})
+}
+
+func TestStorage_CountSumEntrys_ProratesPriceChange(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	id := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 6, userUID, startDate, true)
+
+	// Цена меняется с 1000 на 1500 через три месяца после начала
+	rows, err := storage.UpdateEntry(context.Background(), models.Entry{
+		ServiceName:     "Netflix",
+		Price:           1500,
+		StartDate:       startDate,
+		CounterMonths:   6,
+		UserUID:         userUID,
+		NextPaymentDate: startDate,
+		IsActive:        true,
+	}, id, "testuser")
+	require.NoError(t, err)
+	require.Equal(t, 1, rows)
+
+	var oldPrice, newPrice int
+	err = storage.DB.QueryRow(`SELECT old_price, new_price FROM subscription_price_history WHERE subscription_id = $1`, id).
+		Scan(&oldPrice, &newPrice)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, oldPrice)
+	assert.Equal(t, 1500, newPrice)
+
+	_, err = storage.DB.Exec(`UPDATE subscription_price_history SET changed_at = $1 WHERE subscription_id = $2`,
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), id)
+	require.NoError(t, err)
+
+	total, err := storage.CountSumEntrys(context.Background(), models.FilterSum{
+		UserUID:       userUID,
+		StartDate:     startDate,
+		CounterMonths: 6,
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 3*1000.0+3*1500.0, total, 0.001)
+}
+
+func TestStorage_CountSumEntrysDetailed(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	netflix := factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 6, userUID, startDate, true)
+	spotify := factory.CreateSubscription(t, "Spotify", 300.0, "testuser", startDate, 3, userUID, startDate, true)
+	factory.CreateSubscription(t, "Paused", 500.0, "testuser", startDate, 6, userUID, startDate, false)
+
+	filter := models.FilterSum{UserUID: userUID, StartDate: startDate, CounterMonths: 6}
+	breakdown, err := storage.CountSumEntrysDetailed(context.Background(), filter)
+	require.NoError(t, err)
+
+	require.Len(t, breakdown.Subscriptions, 2, "inactive subscription does not contribute")
+	assert.Equal(t, netflix, breakdown.Subscriptions[0].ID)
+	assert.InDelta(t, 6*1000.0, breakdown.Subscriptions[0].Amount, 0.001)
+	assert.Equal(t, spotify, breakdown.Subscriptions[1].ID)
+	assert.InDelta(t, 3*300.0, breakdown.Subscriptions[1].Amount, 0.001)
+
+	var sum float64
+	for _, c := range breakdown.Subscriptions {
+		sum += c.Amount
+	}
+	assert.InDelta(t, breakdown.Total, sum, 0.001, "breakdown adds up to the total")
+
+	total, err := storage.CountSumEntrys(context.Background(), filter)
+	require.NoError(t, err)
+	assert.InDelta(t, total, breakdown.Total, 0.001)
+}
+
+func TestStorage_SumEntrysByMonth(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", time.Date(2024, 10, 20, 0, 0, 0, 0, time.UTC), 3, userUID, from, true)
+	spotify := factory.CreateSubscription(t, "Spotify", 400.0, "testuser", time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC), 12, userUID, from, true)
+	// Неактивная сейчас подписка оплачивалась в прошлых месяцах и входит в ряд
+	factory.CreateSubscription(t, "Paused", 500.0, "testuser", time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC), 4, userUID, from, false)
+	canceled := factory.CreateSubscription(t, "Canceled", 700.0, "testuser", from, 12, userUID, from, true)
+	_, err := storage.DB.Exec(`UPDATE subscriptions SET deleted_at = NOW() WHERE id = $1`, canceled)
+	require.NoError(t, err)
+	// Цена Spotify выросла после списания за январь, поэтому январь оплачен по старой цене
+	_, err = storage.DB.Exec(`INSERT INTO subscription_price_history (subscription_id, old_price, new_price, changed_at)
+		VALUES ($1, 300, 400, $2)`, spotify, time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	series, err := storage.SumEntrysByMonth(context.Background(), userUID, from, 4)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.MonthlySpend{
+		{Month: "2024-11", Total: 1500},
+		{Month: "2024-12", Total: 1800},
+		{Month: "2025-01", Total: 300}, // Netflix списывается в октябре, ноябре и декабре, Paused — с сентября по декабрь
+		{Month: "2025-02", Total: 400},
+	}, series)
+
+	empty, err := storage.SumEntrysByMonth(context.Background(), uuid.New().String(), from, 3)
+	require.NoError(t, err)
+	assert.Len(t, empty, 3, "месяцы без подписок возвращаются с нулем")
+	for _, m := range empty {
+		assert.Zero(t, m.Total)
+	}
+}
+
+func TestStorage_ListPriceHistory(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	first := factory.CreateSubscription(t, "Netflix", 600.0, "testuser",
+		time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 12, userUID, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), false)
+	second := factory.CreateSubscription(t, "netflix", 750.0, "testuser",
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 12, userUID, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), true)
+	factory.CreateSubscription(t, "Spotify", 300.0, "testuser",
+		time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 12, userUID, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), true)
+
+	changes := []struct {
+		id       int
+		old, new int
+		at       time.Time
+	}{
+		{first, 500, 600, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{second, 600, 700, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{second, 700, 750, time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range changes {
+		_, err := storage.DB.Exec(`INSERT INTO subscription_price_history (subscription_id, old_price, new_price, changed_at)
+			VALUES ($1, $2, $3, $4)`, c.id, c.old, c.new, c.at)
+		require.NoError(t, err)
+	}
+
+	points, err := storage.ListPriceHistory(context.Background(), userUID, "NETFLIX")
+	require.NoError(t, err)
+	require.Len(t, points, 5)
+
+	want := []struct {
+		id    int
+		price int
+		at    time.Time
+	}{
+		{first, 500, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{first, 600, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{second, 600, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{second, 700, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{second, 750, time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, w := range want {
+		assert.Equal(t, w.id, points[i].SubscriptionID, "point %d", i)
+		assert.Equal(t, w.price, points[i].Price, "point %d", i)
+		assert.True(t, w.at.Equal(points[i].Date), "point %d: got %v", i, points[i].Date)
+	}
+
+	points, err = storage.ListPriceHistory(context.Background(), userUID, "Okko")
+	require.NoError(t, err)
+	assert.Empty(t, points)
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,6 +61,51 @@ func TestStorage_Create(t *testing.T) {
 	}
 }
 
+func TestStorage_CreateEntries(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	userUID := uuid.New().String()
+	NewTestDataFactory(storage).CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	entry := func(service string) models.Entry {
+		return models.Entry{
+			ServiceName:   service,
+			Price:         500,
+			Username:      "testuser",
+			StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			CounterMonths: 6,
+			UserUID:       userUID,
+			IsActive:      true,
+		}
+	}
+
+	t.Run("все подписки сохраняются", func(t *testing.T) {
+		ids, err := storage.CreateEntries(ctx, []models.Entry{entry("Spotify"), entry("Netflix")})
+		require.NoError(t, err)
+		require.Len(t, ids, 2)
+
+		for i, service := range []string{"Spotify", "Netflix"} {
+			got, err := storage.ReadEntryForUser(ctx, ids[i], userUID)
+			require.NoError(t, err)
+			assert.Equal(t, service, got.ServiceName)
+		}
+	})
+
+	t.Run("ошибка откатывает всю пачку", func(t *testing.T) {
+		broken := entry("Okko")
+		broken.UserUID = uuid.New().String() // нет такого пользователя
+
+		ids, err := storage.CreateEntries(ctx, []models.Entry{entry("Ivi"), broken})
+		require.Error(t, err)
+		assert.Nil(t, ids)
+
+		entries, err := storage.FindByServiceName(ctx, userUID, "Ivi")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
 func TestStorage_Remove(t *testing.T) {
 	type args struct {
 		ctx context.Context
@@ -66,6 +113,7 @@ func TestStorage_Remove(t *testing.T) {
 	}
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUID := uuid.New().String()
 	tests := []struct {
 		name             string
 		args             args
@@ -82,7 +130,6 @@ func TestStorage_Remove(t *testing.T) {
 			wantRowsAffected: 1,
 			wantError:        false,
 			setup: func(t *testing.T, factory *TestDataFactory) int {
-				userUID := uuid.New().String()
 				factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
 				return factory.CreateSubscription(t, "Netflix", 1000, "testuser", startDate, 5, userUID, startDate, true)
 			},
@@ -96,7 +143,6 @@ func TestStorage_Remove(t *testing.T) {
 			wantRowsAffected: 0,
 			wantError:        true,
 			setup: func(t *testing.T, factory *TestDataFactory) int {
-				userUID := uuid.New().String()
 				factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
 				factory.CreateSubscription(t, "Netflix", 1000, "testuser", startDate, 5, userUID, startDate, true)
 				return 9999 // несуществующий ID
@@ -113,7 +159,7 @@ func TestStorage_Remove(t *testing.T) {
 			subscriptionID := tt.setup(t, factory)
 			tt.args.id = subscriptionID
 
-			gotRowsAffected, err := storage.RemoveEntry(tt.args.ctx, tt.args.id)
+			gotRowsAffected, err := storage.RemoveEntryForUser(tt.args.ctx, tt.args.id, userUID)
 
 			require.NoError(t, err)
 			assert.Equal(t, tt.wantRowsAffected, gotRowsAffected)
@@ -126,6 +172,145 @@ func TestStorage_Remove(t *testing.T) {
 	}
 }
 
+func TestStorage_RemoveEntryForUser(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ownerUID := uuid.New().String()
+	factory.CreateUser(t, ownerUID, "owner", "owner@example.com", "hashedpassword", "user")
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	id := factory.CreateSubscription(t, "Netflix", 1000, "owner", startDate, 5, ownerUID, startDate, true)
+
+	// Подписка другого пользователя не удаляется
+	removed, err := storage.RemoveEntryForUser(ctx, id, otherUID)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	entry, err := storage.ReadEntryForUser(ctx, id, ownerUID)
+	require.NoError(t, err)
+	assert.Equal(t, ownerUID, entry.UserUID)
+
+	removed, err = storage.RemoveEntryForUser(ctx, id, ownerUID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	NewTestVerification(storage).VerifySubscriptionDeleted(t, id)
+}
+
+func TestStorage_ReadAndRemoveEntry_Unscoped(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ownerUID := uuid.New().String()
+	factory.CreateUser(t, ownerUID, "owner", "owner@example.com", "hashedpassword", "user")
+	id := factory.CreateSubscription(t, "Netflix", 1000, "owner", startDate, 5, ownerUID, startDate, true)
+
+	// Подписка читается и удаляется без указания владельца
+	entry, err := storage.ReadEntry(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, ownerUID, entry.UserUID)
+
+	removed, err := storage.RemoveEntry(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	NewTestVerification(storage).VerifySubscriptionDeleted(t, id)
+
+	_, err = storage.ReadEntry(ctx, id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestStorage_UpdateEntry_ScopedToOwner(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ownerUID := uuid.New().String()
+	factory.CreateUser(t, ownerUID, "owner", "owner@example.com", "hashedpassword", "user")
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+	id := factory.CreateSubscription(t, "Netflix", 1000, "owner", startDate, 12, ownerUID, startDate, true)
+
+	entry := models.Entry{
+		ServiceName: "Netflix", Price: 2000, StartDate: startDate, CounterMonths: 12,
+		UserUID: otherUID, NextPaymentDate: startDate, IsActive: true,
+	}
+	updated, err := storage.UpdateEntry(ctx, entry, id, "other")
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+	NewTestVerification(storage).VerifySubscriptionData(t, id, "Netflix", 1000, 12)
+
+	entry.UserUID = ownerUID
+	updated, err = storage.UpdateEntry(ctx, entry, id, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	NewTestVerification(storage).VerifySubscriptionData(t, id, "Netflix", 2000, 12)
+}
+
+func TestStorage_ReactivateEntry(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	nextPayment := time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)
+	ownerUID := uuid.New().String()
+	factory.CreateUser(t, ownerUID, "owner", "owner@example.com", "hashedpassword", "user")
+	otherUID := uuid.New().String()
+	factory.CreateUser(t, otherUID, "other", "other@example.com", "hashedpassword", "user")
+
+	t.Run("архивная подписка возобновляется и снова видна в списке", func(t *testing.T) {
+		id := factory.CreateSubscription(t, "Netflix", 1000, "owner", startDate, 3, ownerUID, startDate, false)
+		_, err := storage.DB.ExecContext(ctx, `UPDATE subscriptions SET archived_at = NOW() WHERE id = $1`, id)
+		require.NoError(t, err)
+
+		updated, err := storage.ReactivateEntry(ctx, id, ownerUID, today, nextPayment)
+		require.NoError(t, err)
+		assert.Equal(t, 1, updated)
+
+		var archived sql.NullTime
+		var isActive bool
+		var gotStart time.Time
+		err = storage.DB.QueryRowContext(ctx,
+			`SELECT archived_at, is_active, start_date FROM subscriptions WHERE id = $1`, id).
+			Scan(&archived, &isActive, &gotStart)
+		require.NoError(t, err)
+		assert.False(t, archived.Valid)
+		assert.True(t, isActive)
+		assert.True(t, gotStart.Equal(today))
+
+		entries, err := storage.ListEntrys(ctx, ownerUID, 10, 0, models.ListSort{})
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("удаленная подписка не возобновляется", func(t *testing.T) {
+		id := factory.CreateSubscription(t, "Spotify", 300, "owner", startDate, 3, ownerUID, startDate, false)
+		_, err := storage.DB.ExecContext(ctx, `UPDATE subscriptions SET deleted_at = NOW() WHERE id = $1`, id)
+		require.NoError(t, err)
+
+		updated, err := storage.ReactivateEntry(ctx, id, ownerUID, today, nextPayment)
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+
+	t.Run("чужая подписка не возобновляется", func(t *testing.T) {
+		id := factory.CreateSubscription(t, "HBO", 500, "owner", startDate, 3, ownerUID, startDate, false)
+
+		updated, err := storage.ReactivateEntry(ctx, id, otherUID, today, nextPayment)
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+}
+
 func TestStorage_Read(t *testing.T) {
 	type args struct {
 		ctx context.Context
@@ -183,7 +368,7 @@ func TestStorage_Read(t *testing.T) {
 			subscriptionID := tt.setup(t, factory)
 			tt.args.id = subscriptionID
 
-			got, err := storage.ReadEntry(tt.args.ctx, tt.args.id)
+			got, err := storage.ReadEntryForUser(tt.args.ctx, tt.args.id, userUID)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -211,6 +396,7 @@ func TestStorage_CountSum(t *testing.T) {
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	netflixService := "Netflix"
+	userUID := uuid.New().String()
 
 	tests := []struct {
 		name      string
@@ -224,7 +410,7 @@ func TestStorage_CountSum(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				filter: models.FilterSum{
-					Username:      "testuser",
+					UserUID:       userUID,
 					ServiceName:   nil, // Нет фильтра по service_name
 					StartDate:     startDate,
 					CounterMonths: 12,
@@ -233,7 +419,6 @@ func TestStorage_CountSum(t *testing.T) {
 			wantTotal: 12000.0, // 1000.0 * 12 месяцев
 			wantErr:   false,
 			setup: func(t *testing.T, factory *TestDataFactory) {
-				userUID := uuid.New().String()
 				factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
 				factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
 			},
@@ -243,7 +428,7 @@ func TestStorage_CountSum(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				filter: models.FilterSum{
-					Username:      "testuser",
+					UserUID:       userUID,
 					ServiceName:   &netflixService, // Фильтр по Netflix
 					StartDate:     startDate,
 					CounterMonths: 12,
@@ -252,7 +437,6 @@ func TestStorage_CountSum(t *testing.T) {
 			wantTotal: 12000.0, // 1000.0 * 12 месяцев (только Netflix)
 			wantErr:   false,
 			setup: func(t *testing.T, factory *TestDataFactory) {
-				userUID := uuid.New().String()
 				factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
 				factory.CreateSubscription(t, "Netflix", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
 				factory.CreateSubscription(t, "Spotify", 1000.0, "testuser", startDate, 12, userUID, startDate, true)
@@ -432,6 +616,62 @@ func TestStorage_GetUserByUsername(t *testing.T) {
 	}
 }
 
+func TestStorage_SearchUsers(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	factory.CreateUser(t, uuid.New().String(), "AliceSmith", "alice@example.com", "hashedpassword", "user")
+	factory.CreateUser(t, uuid.New().String(), "bob", "bob.smith@Mail.ru", "hashedpassword", "user")
+	factory.CreateUser(t, uuid.New().String(), "carol", "carol@example.com", "hashedpassword", "admin")
+	factory.CreateUser(t, uuid.New().String(), "under_score", "us@example.com", "hashedpassword", "user")
+	deletedUID := uuid.New().String()
+	factory.CreateUser(t, deletedUID, "smithers", "smithers@example.com", "hashedpassword", "user")
+	_, err := storage.DB.Exec(`UPDATE users SET deleted_at = NOW() WHERE uid = $1`, deletedUID)
+	require.NoError(t, err)
+
+	usernames := func(users []*models.UserSummary) []string {
+		names := make([]string, 0, len(users))
+		for _, u := range users {
+			names = append(names, u.Username)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		limit  int
+		offset int
+		want   []string
+	}{
+		{name: "часть имени без учета регистра", query: "alice", limit: 10, want: []string{"AliceSmith"}},
+		{name: "часть почты без учета регистра", query: "MAIL.RU", limit: 10, want: []string{"bob"}},
+		{name: "совпадение в имени и в почте", query: "smith", limit: 10, want: []string{"AliceSmith", "bob"}},
+		{name: "общий домен почты", query: "@example", limit: 10, want: []string{"AliceSmith", "carol", "under_score"}},
+		{name: "пагинация", query: "@example", limit: 1, offset: 1, want: []string{"carol"}},
+		{name: "спецсимволы LIKE ищутся буквально", query: "_", limit: 10, want: []string{"under_score"}},
+		{name: "нет совпадений", query: "zzz", limit: 10, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := storage.SearchUsers(context.Background(), tt.query, tt.limit, tt.offset)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, usernames(got))
+		})
+	}
+
+	t.Run("результат содержит данные пользователя", func(t *testing.T) {
+		got, err := storage.SearchUsers(context.Background(), "carol", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "carol@example.com", got[0].Email)
+		assert.Equal(t, "admin", got[0].Role)
+		assert.Equal(t, "trial", got[0].SubscriptionStatus)
+	})
+}
+
 // TestStorage_UpdateEntry удален, так как метод UpdateEntry изменил сигнатуру
 func TestStorage_UpdateEntry_DISABLED(t *testing.T) {
 	type args struct {
@@ -593,11 +833,65 @@ func TestCheckDatabaseReady(t *testing.T) {
 	}
 }
 
-func TestStorage_FindSubscriptionExpiringTomorrow(t *testing.T) {
+func TestNew_StatementTimeout(t *testing.T) {
+	storage, cleanup := setupTestDatabaseWithTimeout(t, 200*time.Millisecond)
+	defer cleanup()
+
+	var timeout string
+	require.NoError(t, storage.DB.QueryRow(`SHOW statement_timeout`).Scan(&timeout))
+	assert.Equal(t, "200ms", timeout)
+
+	// Медленный запрос отменяет сам Postgres, а не контекст клиента
+	start := time.Now()
+	_, err := storage.DB.ExecContext(context.Background(), `SELECT pg_sleep(5)`)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "57014", pgErr.Code, "query_canceled")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// После отмены соединение пула остается рабочим
+	require.NoError(t, storage.Ping(context.Background()))
+	require.NoError(t, storage.DB.QueryRow(`SELECT 1`).Scan(new(int)))
+}
+
+func TestNew_InvalidConnectionString(t *testing.T) {
+	_, err := New("postgres://%zz", "", time.Second, "")
+	assert.Error(t, err)
+}
+
+func TestStorage_FindSubscriptionsDueReminder_Currency(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+	storage.defaultCurrency = "EUR"
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "somehash", "user")
+	// Подписка в долларах и подписка без сохраненной валюты, обе истекают завтра
+	_, err := storage.DB.Exec(`
+		INSERT INTO subscriptions
+			(service_name, price, username, start_date, counter_months, user_uid, next_payment_date, is_active, currency)
+		VALUES
+			('Dollars', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', true, 'USD'),
+			('Legacy', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', true, NULL)
+	`, userUID)
+	require.NoError(t, err)
+
+	res, err := storage.FindSubscriptionsDueReminder(context.Background(), time.Now(), []int{1})
+	require.NoError(t, err)
+	currencies := make(map[string]string, len(res))
+	for _, entry := range res {
+		currencies[entry.ServiceName] = entry.Currency
+	}
+	assert.Equal(t, map[string]string{"Dollars": "USD", "Legacy": "EUR"}, currencies)
+}
+
+func TestStorage_FindSubscriptionsDueReminder(t *testing.T) {
 	tests := []struct {
 		name      string
 		setup     func(t *testing.T, factory *TestDataFactory) error
 		wantCount int
+		wantDays  int
 		wantError bool
 	}{
 		{
@@ -616,6 +910,7 @@ func TestStorage_FindSubscriptionExpiringTomorrow(t *testing.T) {
 				return err
 			},
 			wantCount: 1,
+			wantDays:  1,
 			wantError: false,
 		},
 		{
@@ -624,6 +919,51 @@ func TestStorage_FindSubscriptionExpiringTomorrow(t *testing.T) {
 			wantCount: 0,
 			wantError: false,
 		},
+		{
+			name: "user lead time overrides default days",
+			setup: func(t *testing.T, factory *TestDataFactory) error {
+				userUID := uuid.New().String()
+				factory.CreateUser(t, userUID, "testuser", "test@example.com", "somehash", "user")
+				days := 7
+				if err := factory.storage.SetReminderSettings(context.Background(), userUID, &days, false); err != nil {
+					return err
+				}
+
+				// Подписка, истекающая завтра, не попадает в выборку: пользователь выбрал 7 дней
+				_, err := factory.storage.DB.Exec(`
+					INSERT INTO subscriptions
+						(service_name, price, username, start_date, counter_months, user_uid, next_payment_date, is_active)
+					VALUES
+						('Tomorrow', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', true),
+						('InAWeek', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '7 days', 1, $1, CURRENT_DATE + INTERVAL '7 days', true)
+				`, userUID)
+				return err
+			},
+			wantCount: 1,
+			wantDays:  7,
+			wantError: false,
+		},
+		{
+			name: "paused and archived subscriptions are skipped",
+			setup: func(t *testing.T, factory *TestDataFactory) error {
+				userUID := uuid.New().String()
+				factory.CreateUser(t, userUID, "testuser", "test@example.com", "somehash", "user")
+
+				// Все три подписки истекают завтра, но напоминание положено только активной
+				_, err := factory.storage.DB.Exec(`
+					INSERT INTO subscriptions
+						(service_name, price, username, start_date, counter_months, user_uid, next_payment_date, is_active, archived_at)
+					VALUES
+						('Active', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', true, NULL),
+						('Paused', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', false, NULL),
+						('Archived', 100, 'testuser', CURRENT_DATE - INTERVAL '1 month' + INTERVAL '1 day', 1, $1, CURRENT_DATE + INTERVAL '1 day', true, NOW())
+				`, userUID)
+				return err
+			},
+			wantCount: 1,
+			wantDays:  1,
+			wantError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -636,14 +976,78 @@ func TestStorage_FindSubscriptionExpiringTomorrow(t *testing.T) {
 			require.NoError(t, err)
 
 			ctx := context.Background()
-			res, err := storage.FindSubscriptionExpiringTomorrow(ctx)
+			res, err := storage.FindSubscriptionsDueReminder(ctx, time.Now(), []int{1})
 
 			if tt.wantError {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
-				assert.Len(t, res, tt.wantCount)
+				require.Len(t, res, tt.wantCount)
+				for _, entry := range res {
+					assert.Equal(t, tt.wantDays, entry.DaysBefore)
+				}
 			}
 		})
 	}
 }
+
+func TestStorage_ArchiveInactiveEntrys(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+
+	now := time.Now()
+	cutoff := now.AddDate(-1, 0, 0)
+	// Срок закончился 2 года назад: архивируется
+	oldExpired := factory.CreateSubscription(t, "Old", 100, "testuser", now.AddDate(-3, 0, 0), 12, userUID, now.AddDate(-2, 0, 0), false)
+	oldExpiredActive := factory.CreateSubscription(t, "OldActive", 100, "testuser", now.AddDate(-3, 0, 0), 12, userUID, now.AddDate(-2, 0, 0), true)
+	// Срок закончился полгода назад: моложе срока хранения
+	recentExpired := factory.CreateSubscription(t, "Recent", 100, "testuser", now.AddDate(-1, -6, 0), 12, userUID, now.AddDate(0, -6, 0), false)
+	// Приостановлена, но срок еще идет
+	paused := factory.CreateSubscription(t, "Paused", 100, "testuser", now.AddDate(0, -1, 0), 12, userUID, now, false)
+	active := factory.CreateSubscription(t, "Active", 100, "testuser", now.AddDate(0, -1, 0), 12, userUID, now, true)
+	// Удалена давно, хотя срок еще идет
+	deleted := factory.CreateSubscription(t, "Deleted", 100, "testuser", now.AddDate(-1, -1, 0), 36, userUID, now, false)
+	_, err := storage.DB.Exec(`UPDATE subscriptions SET deleted_at = $1 WHERE id = $2`, now.AddDate(-1, 0, -1), deleted)
+	require.NoError(t, err)
+
+	archivedIDs := func() map[int]bool {
+		rows, err := storage.DB.Query(`SELECT id FROM subscriptions WHERE archived_at IS NOT NULL`)
+		require.NoError(t, err)
+		defer func() { _ = rows.Close() }()
+		ids := map[int]bool{}
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+			ids[id] = true
+		}
+		require.NoError(t, rows.Err())
+		return ids
+	}
+
+	// Пачками по две подписки
+	archived, err := storage.ArchiveInactiveEntrys(context.Background(), cutoff, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+	archived, err = storage.ArchiveInactiveEntrys(context.Background(), cutoff, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+	archived, err = storage.ArchiveInactiveEntrys(context.Background(), cutoff, 2)
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+
+	assert.Equal(t, map[int]bool{oldExpired: true, oldExpiredActive: true, deleted: true}, archivedIDs())
+	for _, id := range []int{recentExpired, paused, active} {
+		var count int
+		require.NoError(t, storage.DB.QueryRow(`SELECT COUNT(*) FROM subscriptions WHERE id = $1 AND archived_at IS NULL`, id).Scan(&count))
+		assert.Equal(t, 1, count, "subscription %d must not be archived", id)
+	}
+
+	// Архивные подписки не попадают в список пользователя
+	entries, err := storage.ListEntrysByUserUID(context.Background(), userUID)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// GetPromoCode возвращает промокод по его коду без учета регистра.
+// Если промокод не найден, возвращает models.ErrPromoCodeNotFound.
+func (s *Storage) GetPromoCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	const op = "storage.GetPromoCode"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, code, percent_off, amount_off, valid_from, valid_until, max_uses, used_count
+			  FROM promo_codes
+			  WHERE lower(code) = lower($1)`
+	var p models.PromoCode
+	var percentOff, amountOff, maxUses sql.NullInt64
+	var validFrom, validUntil sql.NullTime
+	err := s.DB.QueryRowContext(ctx, query, code).Scan(&p.ID, &p.Code, &percentOff, &amountOff,
+		&validFrom, &validUntil, &maxUses, &p.UsedCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrPromoCodeNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	p.PercentOff = int(percentOff.Int64)
+	p.AmountOff = amountOff.Int64
+	if validFrom.Valid {
+		p.ValidFrom = &validFrom.Time
+	}
+	if validUntil.Valid {
+		p.ValidUntil = &validUntil.Time
+	}
+	if maxUses.Valid {
+		v := int(maxUses.Int64)
+		p.MaxUses = &v
+	}
+	return &p, nil
+}
+
+// ReservePromoUse засчитывает одно использование промокода promoCodeID. Если лимит
+// использований уже исчерпан, ничего не меняет и возвращает models.ErrPromoCodeExhausted.
+func (s *Storage) ReservePromoUse(ctx context.Context, promoCodeID int) error {
+	const op = "storage.ReservePromoUse"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	// Условие в UPDATE не дает превысить лимит при одновременных оплатах
+	res, err := s.DB.ExecContext(ctx, `UPDATE promo_codes SET used_count = used_count + 1
+		WHERE id = $1 AND (max_uses IS NULL OR used_count < max_uses)`, promoCodeID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrPromoCodeExhausted)
+	}
+	return nil
+}
+
+// ReleasePromoUse возвращает использование промокода promoCodeID, засчитанное ReservePromoUse.
+func (s *Storage) ReleasePromoUse(ctx context.Context, promoCodeID int) error {
+	const op = "storage.ReleasePromoUse"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := s.DB.ExecContext(ctx, `UPDATE promo_codes SET used_count = used_count - 1
+		WHERE id = $1 AND used_count > 0`, promoCodeID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RecordPromoRedemption сохраняет использование промокода promoCodeID для платежа paymentID.
+// Счетчик использований не меняется: использование засчитывается заранее через ReservePromoUse.
+func (s *Storage) RecordPromoRedemption(ctx context.Context, promoCodeID int, userUID, paymentID string, discount int64) error {
+	const op = "storage.RecordPromoRedemption"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO promo_code_redemptions (promo_code_id, user_uid, payment_id, discount)
+		VALUES ($1, $2, $3, $4)`, promoCodeID, userUID, paymentID, discount)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_GetPromoCode(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	validUntil := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := storage.DB.Exec(`INSERT INTO promo_codes (code, percent_off, valid_until, max_uses)
+		VALUES ('SUMMER25', 25, $1, 100)`, validUntil)
+	require.NoError(t, err)
+	_, err = storage.DB.Exec(`INSERT INTO promo_codes (code, amount_off) VALUES ('MINUS50', 5000)`)
+	require.NoError(t, err)
+
+	promo, err := storage.GetPromoCode(context.Background(), "summer25")
+	require.NoError(t, err)
+	assert.Equal(t, "SUMMER25", promo.Code)
+	assert.Equal(t, 25, promo.PercentOff)
+	assert.Zero(t, promo.AmountOff)
+	assert.Nil(t, promo.ValidFrom)
+	require.NotNil(t, promo.ValidUntil)
+	assert.True(t, validUntil.Equal(*promo.ValidUntil))
+	require.NotNil(t, promo.MaxUses)
+	assert.Equal(t, 100, *promo.MaxUses)
+
+	promo, err = storage.GetPromoCode(context.Background(), "MINUS50")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), promo.AmountOff)
+	assert.Nil(t, promo.MaxUses)
+
+	_, err = storage.GetPromoCode(context.Background(), "UNKNOWN")
+	assert.ErrorIs(t, err, models.ErrPromoCodeNotFound)
+}
+
+func TestStorage_ReservePromoUse(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "promo", "promo@example.com", "hashedpassword", "user")
+
+	var promoID int
+	err := storage.DB.QueryRow(`INSERT INTO promo_codes (code, percent_off, max_uses)
+		VALUES ('ONCE', 10, 1) RETURNING id`).Scan(&promoID)
+	require.NoError(t, err)
+	usedCount := func() int {
+		var n int
+		require.NoError(t, storage.DB.QueryRow(`SELECT used_count FROM promo_codes WHERE id = $1`, promoID).Scan(&n))
+		return n
+	}
+
+	require.NoError(t, storage.ReservePromoUse(ctx, promoID))
+	// Лимит исчерпан резервом, даже пока платеж еще не создан
+	assert.ErrorIs(t, storage.ReservePromoUse(ctx, promoID), models.ErrPromoCodeExhausted)
+	assert.Equal(t, 1, usedCount())
+
+	// Снятый резерв освобождает использование
+	require.NoError(t, storage.ReleasePromoUse(ctx, promoID))
+	assert.Equal(t, 0, usedCount())
+	require.NoError(t, storage.ReleasePromoUse(ctx, promoID))
+	assert.Equal(t, 0, usedCount())
+
+	require.NoError(t, storage.ReservePromoUse(ctx, promoID))
+	require.NoError(t, storage.RecordPromoRedemption(ctx, promoID, userUID, "pay_1", 2000))
+	var redemptions int
+	require.NoError(t, storage.DB.QueryRow(`SELECT COUNT(*) FROM promo_code_redemptions WHERE promo_code_id = $1`, promoID).Scan(&redemptions))
+	assert.Equal(t, 1, usedCount())
+	assert.Equal(t, 1, redemptions)
+}
+
+func TestStorage_PromoCodeUniqueIgnoresCase(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	_, err := storage.DB.Exec(`INSERT INTO promo_codes (code, percent_off) VALUES ('SUMMER25', 25)`)
+	require.NoError(t, err)
+	_, err = storage.DB.Exec(`INSERT INTO promo_codes (code, percent_off) VALUES ('summer25', 10)`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// recordingConnector — драйвер базы, который не выполняет запросы, а запоминает их.
+// Запросы возвращают пустой результат, команды — одну измененную строку;
+// если задан err, запросы и команды завершаются этой ошибкой.
+type recordingConnector struct {
+	mu      sync.Mutex
+	queries []string
+	err     error
+}
+
+func (c *recordingConnector) record(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+}
+
+func (c *recordingConnector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queries)
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{connector: c.connector, query: query}, nil
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *recordingConn) Commit() error { return nil }
+
+func (c *recordingConn) Rollback() error { return nil }
+
+// CheckNamedValue принимает аргументы любых типов: значения в этом драйвере не используются.
+func (c *recordingConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+type recordingStmt struct {
+	connector *recordingConnector
+	query     string
+}
+
+func (s *recordingStmt) Close() error { return nil }
+
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.connector.record(s.query)
+	if s.connector.err != nil {
+		return nil, s.connector.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.connector.record(s.query)
+	if s.connector.err != nil {
+		return nil, s.connector.err
+	}
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string { return nil }
+
+func (emptyRows) Close() error { return nil }
+
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+// newRoutingStorage возвращает хранилище с основной базой и репликой на записывающих драйверах.
+func newRoutingStorage(t *testing.T, withReplica bool) (*Storage, *recordingConnector, *recordingConnector) {
+	primary, replica := &recordingConnector{}, &recordingConnector{}
+	storage := &Storage{DB: sql.OpenDB(primary), defaultCurrency: DefaultCurrency}
+	if withReplica {
+		storage.replica = sql.OpenDB(replica)
+	}
+	t.Cleanup(func() {
+		require.NoError(t, storage.Close())
+	})
+	return storage, primary, replica
+}
+
+func TestStorage_ReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	userUID := "550e8400-e29b-41d4-a716-446655440000"
+
+	reads := map[string]func(s *Storage) error{
+		"ListEntrys": func(s *Storage) error {
+			_, err := s.ListEntrys(ctx, userUID, 10, 0, models.ListSort{})
+			return err
+		},
+		"ListEntrysByUserUID": func(s *Storage) error {
+			_, err := s.ListEntrysByUserUID(ctx, userUID)
+			return err
+		},
+		"SumEntrysByMonth": func(s *Storage) error {
+			_, err := s.SumEntrysByMonth(ctx, userUID, time.Now(), 12)
+			return err
+		},
+		"ListAttentionEntrys": func(s *Storage) error {
+			_, err := s.ListAttentionEntrys(ctx, userUID, time.Now())
+			return err
+		},
+		"FindByServiceName": func(s *Storage) error {
+			_, err := s.FindByServiceName(ctx, userUID, "Netflix")
+			return err
+		},
+		"ListPriceHistory": func(s *Storage) error {
+			_, err := s.ListPriceHistory(ctx, userUID, "Netflix")
+			return err
+		},
+		"CountSumEntrys": func(s *Storage) error {
+			_, err := s.CountSumEntrys(ctx, models.FilterSum{UserUID: userUID, StartDate: time.Now(), CounterMonths: 1})
+			return err
+		},
+		"ListAllEntrys": func(s *Storage) error {
+			_, err := s.ListAllEntrys(ctx, 10, 0, models.ListSort{})
+			return err
+		},
+		"SearchUsers": func(s *Storage) error {
+			_, err := s.SearchUsers(ctx, "test", 10, 0)
+			return err
+		},
+		"CountUsersByStatus": func(s *Storage) error {
+			_, err := s.CountUsersByStatus(ctx)
+			return err
+		},
+	}
+	writes := map[string]func(s *Storage) error{
+		"CreateEntry": func(s *Storage) error {
+			_, err := s.CreateEntry(ctx, models.Entry{ServiceName: "Netflix", Price: 100, UserUID: userUID})
+			return err
+		},
+		"RemoveEntry": func(s *Storage) error {
+			_, err := s.RemoveEntry(ctx, 1)
+			return err
+		},
+		"RemoveEntryForUser": func(s *Storage) error {
+			_, err := s.RemoveEntryForUser(ctx, 1, userUID)
+			return err
+		},
+		"ReactivateEntry": func(s *Storage) error {
+			_, err := s.ReactivateEntry(ctx, 1, userUID, time.Now(), time.Now())
+			return err
+		},
+	}
+
+	for name, read := range reads {
+		t.Run("чтение "+name+" идет в реплику", func(t *testing.T) {
+			storage, primary, replica := newRoutingStorage(t, true)
+			require.NoError(t, read(storage))
+			assert.Positive(t, replica.count())
+			assert.Zero(t, primary.count())
+		})
+		t.Run("чтение "+name+" без реплики идет в основную базу", func(t *testing.T) {
+			storage, primary, _ := newRoutingStorage(t, false)
+			require.NoError(t, read(storage))
+			assert.Positive(t, primary.count())
+		})
+	}
+	for name, write := range writes {
+		t.Run("запись "+name+" идет в основную базу", func(t *testing.T) {
+			storage, primary, replica := newRoutingStorage(t, true)
+			_ = write(storage) // пустой результат RETURNING не важен, проверяется только соединение
+			assert.Positive(t, primary.count())
+			assert.Zero(t, replica.count())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// CountUsersByStatus возвращает количество пользователей, сгруппированных по статусу подписки.
+func (s *Storage) CountUsersByStatus(ctx context.Context) (map[string]int, error) {
+	const op = "storage.CountUsersByStatus"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT subscription_status, COUNT(*)
+			  FROM users
+			  GROUP BY subscription_status`
+	rows, err := s.reader().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// CountActiveSubscriptions возвращает количество активных подписок.
+func (s *Storage) CountActiveSubscriptions(ctx context.Context) (int, error) {
+	const op = "storage.CountActiveSubscriptions"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT COUNT(*) FROM subscriptions WHERE is_active = true AND archived_at IS NULL`
+	var count int
+	if err := s.reader().QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// SumMonthlyRecurringRevenue возвращает суммарную месячную стоимость активных подписок.
+func (s *Storage) SumMonthlyRecurringRevenue(ctx context.Context) (float64, error) {
+	const op = "storage.SumMonthlyRecurringRevenue"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT COALESCE(SUM(price), 0)::FLOAT
+			  FROM subscriptions
+			  WHERE is_active = true AND archived_at IS NULL`
+	var total float64
+	if err := s.reader().QueryRowContext(ctx, query).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return total, nil
+}
+
+// CountTrialConversions возвращает количество пользователей, оплативших подписку после пробного периода.
+func (s *Storage) CountTrialConversions(ctx context.Context) (int, error) {
+	const op = "storage.CountTrialConversions"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT COUNT(*)
+			  FROM users
+			  WHERE trial_end_date IS NOT NULL
+			    AND subscription_status = 'active'`
+	var count int
+	if err := s.reader().QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
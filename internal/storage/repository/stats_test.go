@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedStatsData(t *testing.T, factory *TestDataFactory) {
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trialEnd := time.Now().AddDate(0, 0, -10)
+	expiry := time.Now().AddDate(0, 1, 0)
+
+	uid1 := uuid.New().String()
+	uid2 := uuid.New().String()
+	uid3 := uuid.New().String()
+	factory.CreateUserWithSubscription(t, uid1, "user1", "user1@example.com", "hash", "user", trialEnd, expiry, "active")
+	factory.CreateUserWithSubscription(t, uid2, "user2", "user2@example.com", "hash", "user", trialEnd, expiry, "trial")
+	factory.CreateUser(t, uid3, "user3", "user3@example.com", "hash", "user")
+
+	factory.CreateSubscription(t, "Netflix", 1000.0, "user1", startDate, 12, uid1, startDate, true)
+	factory.CreateSubscription(t, "Spotify", 500.0, "user1", startDate, 6, uid1, startDate, true)
+	factory.CreateSubscription(t, "Disney+", 800.0, "user2", startDate, 12, uid2, startDate, false)
+}
+
+func TestStorage_CountUsersByStatus(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	seedStatsData(t, NewTestDataFactory(storage))
+
+	got, err := storage.CountUsersByStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"active": 1, "trial": 2}, got)
+}
+
+func TestStorage_CountActiveSubscriptions(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	seedStatsData(t, NewTestDataFactory(storage))
+
+	got, err := storage.CountActiveSubscriptions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, got)
+}
+
+func TestStorage_SumMonthlyRecurringRevenue(t *testing.T) {
+	tests := []struct {
+		name string
+		seed bool
+		want float64
+	}{
+		{name: "sum of active subscriptions", seed: true, want: 1500.0},
+		{name: "empty table", seed: false, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, cleanup := setupTestDatabase(t)
+			defer cleanup()
+
+			if tt.seed {
+				seedStatsData(t, NewTestDataFactory(storage))
+			}
+
+			got, err := storage.SumMonthlyRecurringRevenue(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStorage_CountTrialConversions(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	seedStatsData(t, NewTestDataFactory(storage))
+
+	got, err := storage.CountTrialConversions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,21 +20,86 @@ func (s *Storage) CreateEntry(ctx context.Context, entry models.Entry) (int, err
 	default:
 	}
 
-	query := `INSERT INTO subscriptions (service_name, price, username, start_date,
-			      counter_months, user_uid, next_payment_date, is_active) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-			  RETURNING id`
 	var newID int
-	err := s.DB.QueryRowContext(ctx, query,
-		entry.ServiceName, entry.Price, entry.Username, entry.StartDate, entry.CounterMonths,
-		entry.UserUID, entry.NextPaymentDate, entry.IsActive).Scan(&newID)
+	err := s.DB.QueryRowContext(ctx, insertEntryQuery, s.insertEntryArgs(entry)...).Scan(&newID)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	return newID, nil
 }
 
-// RemoveEntry удаляет подписку по ID и возвращает количество удалённых строк.
+// CreateEntries вставляет подписки в одной транзакции и возвращает их ID в порядке entries.
+// Если одна вставка не удалась, не сохраняется ни одна подписка.
+func (s *Storage) CreateEntries(ctx context.Context, entries []models.Entry) ([]int, error) {
+	const op = "storage.CreateEntries"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, insertEntryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		var id int
+		if err := stmt.QueryRowContext(ctx, s.insertEntryArgs(entry)...).Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+// insertEntryQuery вставляет подписку и возвращает ее ID; пустые заметка и метаданные сохраняются как NULL.
+const insertEntryQuery = `INSERT INTO subscriptions (service_name, price, username, start_date,
+			      counter_months, user_uid, next_payment_date, is_active, currency, notes, metadata) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+			  RETURNING id`
+
+// insertEntryArgs возвращает аргументы insertEntryQuery; без валюты подписка получает валюту по умолчанию.
+func (s *Storage) insertEntryArgs(entry models.Entry) []any {
+	currency := entry.Currency
+	if currency == "" {
+		currency = s.defaultCurrency
+	}
+	return []any{entry.ServiceName, entry.Price, entry.Username, entry.StartDate, entry.CounterMonths,
+		entry.UserUID, entry.NextPaymentDate, entry.IsActive, currency, entry.Notes, []byte(entry.Metadata)}
+}
+
+// BackfillCurrency сохраняет валюту по умолчанию подпискам, у которых валюта не сохранена,
+// и возвращает количество обновленных подписок.
+func (s *Storage) BackfillCurrency(ctx context.Context) (int64, error) {
+	const op = "storage.BackfillCurrency"
+	result, err := s.DB.ExecContext(ctx, `UPDATE subscriptions SET currency = $1 WHERE currency IS NULL`, s.defaultCurrency)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return updated, nil
+}
+
+// RemoveEntry удаляет подписку по ID без учета владельца и возвращает количество удалённых строк;
+// используется только для администратора.
 func (s *Storage) RemoveEntry(ctx context.Context, id int) (int, error) {
 	const op = "storage.RemoveEntry"
 	select {
@@ -53,7 +120,54 @@ func (s *Storage) RemoveEntry(ctx context.Context, id int) (int, error) {
 	return int(rowsAffected), nil
 }
 
-// ReadEntry возвращает данные подписки по её ID.
+// RemoveEntryForUser удаляет подписку id, только если она принадлежит пользователю userUID,
+// и возвращает количество удалённых строк. Для подписки другого пользователя возвращает 0.
+func (s *Storage) RemoveEntryForUser(ctx context.Context, id int, userUID string) (int, error) {
+	const op = "storage.RemoveEntryForUser"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `DELETE FROM subscriptions WHERE id = $1 AND user_uid = $2`
+	result, err := s.DB.ExecContext(ctx, query, id, userUID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(rowsAffected), nil
+}
+
+// ReadEntryForUser возвращает данные подписки id, только если она принадлежит пользователю userUID.
+// Для подписки другого пользователя, отмененной (удаленной) и несуществующей возвращается sql.ErrNoRows.
+func (s *Storage) ReadEntryForUser(ctx context.Context, id int, userUID string) (*models.Entry, error) {
+	const op = "storage.ReadEntryForUser"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT service_name, price, username, start_date, counter_months,
+				user_uid, next_payment_date, is_active, currency, COALESCE(notes, ''), metadata
+			  FROM subscriptions WHERE id = $1 AND user_uid = $2 AND deleted_at IS NULL`
+	row := s.DB.QueryRowContext(ctx, query, id, userUID)
+
+	var result models.Entry
+	if err := row.Scan(&result.ServiceName, &result.Price, &result.Username, &result.StartDate,
+		&result.CounterMonths, &result.UserUID, &result.NextPaymentDate, &result.IsActive, s.currencyDest(&result.Currency),
+		&result.Notes, (*[]byte)(&result.Metadata)); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &result, nil
+}
+
+// ReadEntry возвращает данные подписки по её ID без учета владельца; используется только
+// для администратора. Для отмененной (удаленной) и несуществующей подписки возвращается sql.ErrNoRows.
 func (s *Storage) ReadEntry(ctx context.Context, id int) (*models.Entry, error) {
 	const op = "storage.ReadEntry"
 	select {
@@ -63,19 +177,22 @@ func (s *Storage) ReadEntry(ctx context.Context, id int) (*models.Entry, error)
 	}
 
 	query := `SELECT service_name, price, username, start_date, counter_months,
-				user_uid, next_payment_date, is_active
-			  FROM subscriptions WHERE id = $1`
+				user_uid, next_payment_date, is_active, currency, COALESCE(notes, ''), metadata
+			  FROM subscriptions WHERE id = $1 AND deleted_at IS NULL`
 	row := s.DB.QueryRowContext(ctx, query, id)
 
 	var result models.Entry
 	if err := row.Scan(&result.ServiceName, &result.Price, &result.Username, &result.StartDate,
-		&result.CounterMonths, &result.UserUID, &result.NextPaymentDate, &result.IsActive); err != nil {
+		&result.CounterMonths, &result.UserUID, &result.NextPaymentDate, &result.IsActive, s.currencyDest(&result.Currency),
+		&result.Notes, (*[]byte)(&result.Metadata)); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &result, nil
 }
 
 // UpdateEntry обновляет данные подписки по её ID и возвращает количество изменённых строк.
+// Обновляется только подписка владельца req.UserUID; отмененная (удаленная) подписка не обновляется.
+// Заметка и метаданные заменяются значениями req; сохранить текущие должен вызывающий код.
 func (s *Storage) UpdateEntry(ctx context.Context, req models.Entry, id int, username string) (int, error) {
 	const op = "storage.UpdateEntry"
 	select {
@@ -84,13 +201,34 @@ func (s *Storage) UpdateEntry(ctx context.Context, req models.Entry, id int, use
 	default:
 	}
 
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var oldPrice int
+	err = tx.QueryRowContext(ctx, `SELECT price FROM subscriptions
+		WHERE id = $1 AND user_uid = $2 AND deleted_at IS NULL FOR UPDATE`, id, req.UserUID).Scan(&oldPrice)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Владелец подписки (user_uid) не меняется; username хранится только для отображения
 	query := `UPDATE subscriptions 
 			  SET service_name = $1, price = $2, username = $3, start_date = $4, 
-			      counter_months = $5, user_uid = $6, next_payment_date = $7, is_active = $8
-			  WHERE id = $9`
-	result, err := s.DB.ExecContext(ctx, query,
+			      counter_months = $5, next_payment_date = $6, is_active = $7,
+			      currency = COALESCE(NULLIF($9, ''), currency),
+			      notes = NULLIF($10, ''), metadata = $11
+			  WHERE id = $8 AND user_uid = $12 AND deleted_at IS NULL`
+	result, err := tx.ExecContext(ctx, query,
 		req.ServiceName, req.Price, username, req.StartDate,
-		req.CounterMonths, req.UserUID, req.NextPaymentDate, req.IsActive, id)
+		req.CounterMonths, req.NextPaymentDate, req.IsActive, id, req.Currency, req.Notes, []byte(req.Metadata), req.UserUID)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -99,11 +237,48 @@ func (s *Storage) UpdateEntry(ctx context.Context, req models.Entry, id int, use
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// Изменение цены сохраняется в истории для пропорционального расчета стоимости
+	if oldPrice != req.Price {
+		query = `INSERT INTO subscription_price_history (subscription_id, old_price, new_price)
+				 VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, query, id, oldPrice, req.Price); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 	return int(rowsAffected), nil
 }
 
-// ListEntrys возвращает список всех подписок пользователя с пагинацией.
-func (s *Storage) ListEntrys(ctx context.Context, username string, limit, offset int) ([]*models.Entry, error) {
+// ReactivateEntry делает активной подписку id пользователя userUID со сроком, начинающимся
+// с startDate, и снимает с нее пометку архивной. Удаленные подписки не возобновляются.
+// Возвращает количество обновленных строк: 0, если подходящей подписки нет.
+func (s *Storage) ReactivateEntry(ctx context.Context, id int, userUID string, startDate, nextPaymentDate time.Time) (int, error) {
+	const op = "storage.ReactivateEntry"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE subscriptions
+			  SET is_active = true, start_date = $3, next_payment_date = $4, archived_at = NULL
+			  WHERE id = $1 AND user_uid = $2 AND deleted_at IS NULL`
+	res, err := s.DB.ExecContext(ctx, query, id, userUID, startDate, nextPaymentDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(updated), nil
+}
+
+// ListEntrys возвращает список неудаленных подписок пользователя userUID с пагинацией и сортировкой.
+func (s *Storage) ListEntrys(ctx context.Context, userUID string, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
 	const op = "storage.ListEntrys"
 	select {
 	case <-ctx.Done():
@@ -111,12 +286,12 @@ func (s *Storage) ListEntrys(ctx context.Context, username string, limit, offset
 	default:
 	}
 
-	query := `SELECT service_name, price, username, start_date, counter_months, user_uid, next_payment_date, is_active
+	query := `SELECT service_name, price, username, start_date, counter_months, user_uid, next_payment_date, is_active, currency
 			  FROM subscriptions
-			  WHERE username = $1
-			  ORDER BY id
+			  WHERE user_uid = $1 AND deleted_at IS NULL AND archived_at IS NULL
+			  ORDER BY ` + orderByClause(sort) + `
 			  LIMIT $2 OFFSET $3`
-	rows, err := s.DB.QueryContext(ctx, query, username, limit, offset)
+	rows, err := s.reader().QueryContext(ctx, query, userUID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -125,7 +300,7 @@ func (s *Storage) ListEntrys(ctx context.Context, username string, limit, offset
 	for rows.Next() {
 		var item models.Entry
 		if err := rows.Scan(&item.ServiceName, &item.Price, &item.Username, &item.StartDate,
-			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive); err != nil {
+			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		result = append(result, &item)
@@ -137,70 +312,408 @@ func (s *Storage) ListEntrys(ctx context.Context, username string, limit, offset
 	return result, nil
 }
 
+// sortColumns сопоставляет поля сортировки с колонками таблицы subscriptions.
+// В ORDER BY подставляются только значения из этой таблицы, поэтому
+// пользовательский ввод не попадает в текст запроса.
+var sortColumns = map[string]string{
+	models.SortByID:              "id",
+	models.SortByNextPaymentDate: "next_payment_date",
+	models.SortByPrice:           "price",
+	models.SortByServiceName:     "service_name",
+}
+
+// orderByClause возвращает выражение ORDER BY для сортировки. Неизвестные поля
+// заменяются на id; для одинаковых значений порядок определяется по id,
+// чтобы пагинация оставалась стабильной.
+func orderByClause(sort models.ListSort) string {
+	column, ok := sortColumns[sort.Field]
+	if !ok {
+		column = "id"
+	}
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+	if column == "id" {
+		return "id " + direction
+	}
+	return column + " " + direction + ", id ASC"
+}
+
+// ListEntrysByUserUID возвращает все неудаленные подписки пользователя по его UID.
+func (s *Storage) ListEntrysByUserUID(ctx context.Context, userUID string) ([]*models.Entry, error) {
+	const op = "storage.ListEntrysByUserUID"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, service_name, price, username, start_date, counter_months, user_uid,
+			      next_payment_date, is_active, currency
+			  FROM subscriptions
+			  WHERE user_uid = $1 AND deleted_at IS NULL AND archived_at IS NULL
+			  ORDER BY id`
+	rows, err := s.reader().QueryContext(ctx, query, userUID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.Entry
+	for rows.Next() {
+		var item models.Entry
+		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, &item.Username, &item.StartDate,
+			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// FindByServiceName возвращает неудаленные подписки пользователя userUID на сервис
+// с указанным названием (без учета регистра) в порядке создания.
+func (s *Storage) FindByServiceName(ctx context.Context, userUID, service string) ([]*models.Entry, error) {
+	const op = "storage.FindByServiceName"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, service_name, price, username, start_date, counter_months, user_uid,
+			      next_payment_date, is_active, currency
+			  FROM subscriptions
+			  WHERE user_uid = $1 AND LOWER(service_name) = LOWER($2) AND deleted_at IS NULL
+			    AND archived_at IS NULL
+			  ORDER BY id`
+	rows, err := s.reader().QueryContext(ctx, query, userUID, service)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.Entry
+	for rows.Next() {
+		var item models.Entry
+		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, &item.Username, &item.StartDate,
+			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// ListPriceHistory возвращает цены подписок пользователя userUID на сервис service (без учета регистра)
+// по времени: для каждой неудаленной подписки, в том числе архивной, — первоначальную цену на дату
+// начала и каждое изменение из subscription_price_history на момент изменения.
+func (s *Storage) ListPriceHistory(ctx context.Context, userUID, service string) ([]*models.PricePoint, error) {
+	const op = "storage.ListPriceHistory"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT p.subscription_id, p.price, p.currency, p.at
+			  FROM (
+			      SELECT s.id AS subscription_id, COALESCE(f.old_price, s.price) AS price, s.currency,
+			             s.start_date::TIMESTAMPTZ AS at, 0 AS seq
+			      FROM subscriptions s
+			      LEFT JOIN LATERAL (
+			          SELECT h.old_price FROM subscription_price_history h
+			          WHERE h.subscription_id = s.id
+			          ORDER BY h.changed_at, h.id
+			          LIMIT 1
+			      ) f ON TRUE
+			      WHERE s.user_uid = $1 AND LOWER(s.service_name) = LOWER($2) AND s.deleted_at IS NULL
+			      UNION ALL
+			      SELECT s.id, h.new_price, s.currency, h.changed_at, h.id
+			      FROM subscription_price_history h
+			      JOIN subscriptions s ON s.id = h.subscription_id
+			      WHERE s.user_uid = $1 AND LOWER(s.service_name) = LOWER($2) AND s.deleted_at IS NULL
+			  ) p
+			  ORDER BY p.at, p.subscription_id, p.seq`
+	rows, err := s.reader().QueryContext(ctx, query, userUID, service)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := []*models.PricePoint{}
+	for rows.Next() {
+		var p models.PricePoint
+		if err := rows.Scan(&p.SubscriptionID, &p.Price, s.currencyDest(&p.Currency), &p.Date); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
 // CountSumEntrys подсчитывает суммарную стоимость подписок пользователя за выбранный период с учётом фильтров.
+// Сумма считается в базе одним запросом (см. sumEntrysTotal). Если запрос не удался не из-за
+// отмены ctx, сумма пересчитывается по строкам подписок так же, как в CountSumEntrysDetailed.
 func (s *Storage) CountSumEntrys(ctx context.Context, entry models.FilterSum) (float64, error) {
 	const op = "storage.CountSumEntrys"
+	total, err := s.sumEntrysTotal(ctx, entry)
+	if err == nil {
+		return total, nil
+	}
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	breakdown, fallbackErr := s.sumEntrys(ctx, entry)
+	if fallbackErr != nil {
+		return 0, fmt.Errorf("%s: %w", op, errors.Join(err, fallbackErr))
+	}
+	return breakdown.Total, nil
+}
+
+// sumEntrysTotal считает в базе ту же сумму, что и sumEntrys, не передавая подписки в приложение.
+// Месяцы подписки повторяют month.CountMonths и time.AddDate: k-й месяц начинается в первый день
+// месяца start_date плюс k месяцев и (день start_date - 1) дней, поэтому 31 января плюс месяц —
+// это 3 марта, как в Go, а не 29 февраля, как при сложении с интервалом в Postgres.
+// Подписки без истории цен оплачиваются текущей ценой за каждый оставшийся месяц; для остальных
+// месяцы перебираются generate_series и каждый оплачивается ценой, действовавшей на его начало.
+func (s *Storage) sumEntrysTotal(ctx context.Context, entry models.FilterSum) (float64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	filterEnd := entry.StartDate.AddDate(0, entry.CounterMonths, 0)
+	query := `WITH subs AS (
+			      SELECT s.id, s.price, s.counter_months,
+			             date_trunc('month', s.start_date::timestamp) AS month_start,
+			             (EXTRACT(DAY FROM s.start_date)::int - 1) * INTERVAL '1 day' AS day_offset,
+			             CASE
+			                 WHEN $4::date >= date_trunc('month', s.start_date::timestamp)
+			                      + s.counter_months * INTERVAL '1 month'
+			                      + (EXTRACT(DAY FROM s.start_date)::int - 1) * INTERVAL '1 day' THEN 0
+			                 WHEN $4::date <= s.start_date THEN s.counter_months
+			                 ELSE GREATEST(s.counter_months - (
+			                     (EXTRACT(YEAR FROM $4::date)::int - EXTRACT(YEAR FROM s.start_date)::int) * 12
+			                     + EXTRACT(MONTH FROM $4::date)::int - EXTRACT(MONTH FROM s.start_date)::int
+			                     + CASE WHEN EXTRACT(DAY FROM $4::date) > EXTRACT(DAY FROM s.start_date) THEN 1 ELSE 0 END
+			                 ), 0)
+			             END AS remaining,
+			             (SELECT h.old_price FROM subscription_price_history h
+			              WHERE h.subscription_id = s.id
+			              ORDER BY h.changed_at, h.id LIMIT 1) AS initial_price
+			      FROM subscriptions s
+			      WHERE s.user_uid = $1
+			        AND s.is_active = true
+			        AND s.archived_at IS NULL
+			        AND ($2::text IS NULL OR s.service_name = $2)
+			        AND s.start_date < $3
+			        AND (s.start_date + (s.counter_months || ' months')::interval) > $4::date
+			  )
+			  SELECT COALESCE(SUM(
+			      CASE WHEN subs.initial_price IS NULL THEN subs.price * subs.remaining
+			      ELSE (
+			          SELECT SUM(COALESCE((
+			              SELECT h.new_price FROM subscription_price_history h
+			              WHERE h.subscription_id = subs.id
+			                AND h.changed_at <= (subs.month_start + k * INTERVAL '1 month' + subs.day_offset) AT TIME ZONE 'UTC'
+			              ORDER BY h.changed_at DESC, h.id DESC LIMIT 1
+			          ), subs.initial_price))
+			          FROM generate_series(subs.counter_months - subs.remaining, subs.counter_months - 1) AS k
+			      )
+			      END), 0)::FLOAT
+			  FROM subs`
+	var total float64
+	err := s.reader().QueryRowContext(ctx, query, entry.UserUID, entry.ServiceName, filterEnd, entry.StartDate).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// CountSumEntrysDetailed подсчитывает ту же сумму, что и CountSumEntrys, и возвращает вклад
+// каждой подписки в порядке ID. Подписки с нулевым вкладом в список не попадают.
+func (s *Storage) CountSumEntrysDetailed(ctx context.Context, entry models.FilterSum) (*models.SumBreakdown, error) {
+	const op = "storage.CountSumEntrysDetailed"
+	breakdown, err := s.sumEntrys(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return breakdown, nil
+}
+
+// sumEntrys считает пропорциональную стоимость каждой подписки, попадающей под фильтр, и их сумму.
+func (s *Storage) sumEntrys(ctx context.Context, entry models.FilterSum) (*models.SumBreakdown, error) {
 	select {
 	case <-ctx.Done():
-		return 0.0, fmt.Errorf("%s: %w", op, ctx.Err())
+		return nil, ctx.Err()
 	default:
 	}
 
 	filterEnd := entry.StartDate.AddDate(0, entry.CounterMonths, 0)
 
-	query := `SELECT service_name, price, start_date, counter_months
+	changes, err := s.priceChangesForSum(ctx, entry, filterEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, service_name, price, start_date, counter_months
               FROM subscriptions
-              WHERE username = $1
+              WHERE user_uid = $1
 		      	AND is_active = true	
+		      	AND archived_at IS NULL
           		AND ($2::text IS NULL OR service_name = $2)
           		AND start_date < $3
-          		AND (start_date + (counter_months || ' months')::interval) > $4`
-	rows, err := s.DB.QueryContext(ctx, query, entry.Username, entry.ServiceName, filterEnd, entry.StartDate)
-
+          		AND (start_date + (counter_months || ' months')::interval) > $4
+              ORDER BY id`
+	rows, err := s.reader().QueryContext(ctx, query, entry.UserUID, entry.ServiceName, filterEnd, entry.StartDate)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
-	var total float64
+	breakdown := &models.SumBreakdown{Subscriptions: []models.SumContribution{}}
 	for rows.Next() {
+		var id int
 		var serviceName string
 		var price float64
 		var startDate time.Time
 		var counterMonths int
 
-		if err := rows.Scan(&serviceName, &price, &startDate, &counterMonths); err != nil {
-			return 0, fmt.Errorf("%s: %w", op, err)
+		if err := rows.Scan(&id, &serviceName, &price, &startDate, &counterMonths); err != nil {
+			return nil, err
 		}
 
-		remainingMonths := month.CountMonths(startDate, counterMonths, entry.StartDate)
-		total += price * float64(remainingMonths)
+		// Месяцы до первого изменения оплачиваются по старой цене, после — по новым
+		initialPrice := price
+		var segments []month.PriceSegment
+		if history := changes[id]; len(history) > 0 {
+			initialPrice = float64(history[0].OldPrice)
+			for _, c := range history {
+				segments = append(segments, month.PriceSegment{From: c.ChangedAt, Price: float64(c.NewPrice)})
+			}
+		}
+		amount := month.ProratedSum(startDate, counterMonths, entry.StartDate, initialPrice, segments)
+		if amount == 0 {
+			continue
+		}
+		breakdown.Total += amount
+		breakdown.Subscriptions = append(breakdown.Subscriptions, models.SumContribution{
+			ID:          id,
+			ServiceName: serviceName,
+			Amount:      amount,
+		})
 	}
-
 	if err := rows.Err(); err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
-	err = rows.Close()
+	return breakdown, nil
+}
+
+// priceChangesForSum возвращает историю изменения цен подписок, попадающих под фильтр CountSumEntrys,
+// сгруппированную по идентификатору подписки и упорядоченную по времени изменения.
+func (s *Storage) priceChangesForSum(ctx context.Context, entry models.FilterSum, filterEnd time.Time) (map[int][]models.PriceChange, error) {
+	query := `SELECT h.subscription_id, h.old_price, h.new_price, h.changed_at
+			  FROM subscription_price_history h
+			  JOIN subscriptions s ON s.id = h.subscription_id
+			  WHERE s.user_uid = $1
+			    AND s.is_active = true
+			    AND ($2::text IS NULL OR s.service_name = $2)
+			    AND s.start_date < $3
+			    AND (s.start_date + (s.counter_months || ' months')::interval) > $4
+			  ORDER BY h.subscription_id, h.changed_at, h.id`
+	rows, err := s.reader().QueryContext(ctx, query, entry.UserUID, entry.ServiceName, filterEnd, entry.StartDate)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
-	return total, nil
+	result := make(map[int][]models.PriceChange)
+	for rows.Next() {
+		var c models.PriceChange
+		if err := rows.Scan(&c.SubscriptionID, &c.OldPrice, &c.NewPrice, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		result[c.SubscriptionID] = append(result[c.SubscriptionID], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-// ListAllEntrys возвращает список всех подписок с пагинацией.
-func (s *Storage) ListAllEntrys(ctx context.Context, limit, offset int) ([]*models.Entry, error) {
-	const op = "storage.ListAllEntrys"
+// SumEntrysByMonth возвращает стоимость подписок пользователя userUID за months
+// календарных месяцев начиная с месяца from, по одному значению на месяц в хронологическом
+// порядке. Подписка со сроком counter_months списывается в каждом из counter_months месяцев,
+// начиная с месяца start_date, по цене, действовавшей на начало месяца подписки, как в
+// sumEntrysTotal. Ряд описывает прошлые траты, поэтому в него входят и подписки, которые
+// сейчас неактивны или архивированы; отмененные (deleted_at) не учитываются.
+// Месяцы без списаний возвращаются с нулем.
+func (s *Storage) SumEntrysByMonth(ctx context.Context, userUID string, from time.Time, months int) ([]models.MonthlySpend, error) {
+	const op = "storage.SumEntrysByMonth"
 	select {
 	case <-ctx.Done():
 		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	query := `SELECT service_name, price, username, start_date, counter_months, user_uid,
-			      next_payment_date, is_active
-			  FROM subscriptions
-		      LIMIT $1 OFFSET $2`
-	rows, err := s.DB.QueryContext(ctx, query, limit, offset)
+	// k — номер месяца подписки; перебираются только месяцы, попадающие в ряд
+	query := `WITH subs AS (
+			      SELECT s.id, s.price, s.counter_months,
+			             date_trunc('month', s.start_date::timestamp) AS month_start,
+			             (EXTRACT(DAY FROM s.start_date)::int - 1) * INTERVAL '1 day' AS day_offset,
+			             (EXTRACT(YEAR FROM $2::date)::int - EXTRACT(YEAR FROM s.start_date)::int) * 12
+			                 + EXTRACT(MONTH FROM $2::date)::int - EXTRACT(MONTH FROM s.start_date)::int AS first_k,
+			             (SELECT h.old_price FROM subscription_price_history h
+			              WHERE h.subscription_id = s.id
+			              ORDER BY h.changed_at, h.id LIMIT 1) AS initial_price
+			      FROM subscriptions s
+			      WHERE s.user_uid = $1
+			        AND s.deleted_at IS NULL
+			  ),
+			  charges AS (
+			      SELECT subs.month_start + k * INTERVAL '1 month' AS month,
+			             CASE WHEN subs.initial_price IS NULL THEN subs.price
+			             ELSE COALESCE((
+			                 SELECT h.new_price FROM subscription_price_history h
+			                 WHERE h.subscription_id = subs.id
+			                   AND h.changed_at <= (subs.month_start + k * INTERVAL '1 month' + subs.day_offset) AT TIME ZONE 'UTC'
+			                 ORDER BY h.changed_at DESC, h.id DESC LIMIT 1
+			             ), subs.initial_price)
+			             END AS price
+			      FROM subs,
+			           generate_series(GREATEST(subs.first_k, 0), LEAST(subs.counter_months, subs.first_k + $3) - 1) AS k
+			  )
+			  SELECT m.month, COALESCE(SUM(c.price), 0)::FLOAT
+			  FROM generate_series(date_trunc('month', $2::date::timestamp),
+			                       date_trunc('month', $2::date::timestamp) + ($3 - 1) * INTERVAL '1 month',
+			                       INTERVAL '1 month') AS m(month)
+			  LEFT JOIN charges c ON c.month = m.month
+			  GROUP BY m.month
+			  ORDER BY m.month`
+	rows, err := s.reader().QueryContext(ctx, query, userUID, from, months)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -208,27 +721,83 @@ func (s *Storage) ListAllEntrys(ctx context.Context, limit, offset int) ([]*mode
 		_ = rows.Close()
 	}()
 
-	var result []*models.Entry
+	result := make([]models.MonthlySpend, 0, months)
 	for rows.Next() {
-		var item models.Entry
-		if err := rows.Scan(&item.ServiceName, &item.Price, &item.Username, &item.StartDate,
-			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive); err != nil {
+		var month time.Time
+		var total float64
+		if err := rows.Scan(&month, &total); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		result = append(result, &item)
+		result = append(result, models.MonthlySpend{Month: month.Format("2006-01"), Total: total})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
 
-	err = rows.Close()
+// ListAllEntrys возвращает список всех подписок с пагинацией и сортировкой.
+func (s *Storage) ListAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort) ([]*models.Entry, error) {
+	const op = "storage.ListAllEntrys"
+	var result []*models.Entry
+	err := s.StreamAllEntrys(ctx, limit, offset, sort, func(item *models.Entry) error {
+		result = append(result, item)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-
 	return result, nil
 }
 
-// FindSubscriptionExpiringTomorrow находит подписки, истекающие завтра
-func (s *Storage) FindSubscriptionExpiringTomorrow(ctx context.Context) ([]*models.EntryInfo, error) {
-	const op = "storage.FindSubscriptionExpiringTomorrow"
+// StreamAllEntrys выбирает все подписки с пагинацией и сортировкой, как ListAllEntrys,
+// и передает их в fn по мере чтения из базы, не собирая список в памяти.
+// Ошибка fn прекращает чтение и возвращается вызывающему.
+func (s *Storage) StreamAllEntrys(ctx context.Context, limit, offset int, sort models.ListSort, fn func(*models.Entry) error) error {
+	const op = "storage.StreamAllEntrys"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, service_name, price, username, start_date, counter_months, user_uid,
+			      next_payment_date, is_active, currency
+			  FROM subscriptions
+			  WHERE deleted_at IS NULL AND archived_at IS NULL
+			  ORDER BY ` + orderByClause(sort) + `
+		      LIMIT $1 OFFSET $2`
+	rows, err := s.reader().QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var item models.Entry
+		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, &item.Username, &item.StartDate,
+			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := fn(&item); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// FindSubscriptionsDueReminder находит подписки, о скором окончании которых пора напомнить на дату today.
+// Для пользователя с собственным сроком reminder_days_before подписка попадает в выборку
+// за столько дней до окончания, для остальных — за каждое число дней из defaultDays.
+// Приостановленные, архивные и отмененные подписки не выбираются.
+// Подписка без сохраненной валюты получает валюту по умолчанию.
+func (s *Storage) FindSubscriptionsDueReminder(ctx context.Context, today time.Time, defaultDays []int) ([]*models.EntryInfo, error) {
+	const op = "storage.FindSubscriptionsDueReminder"
 	select {
 	case <-ctx.Done():
 		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
@@ -236,15 +805,31 @@ func (s *Storage) FindSubscriptionExpiringTomorrow(ctx context.Context) ([]*mode
 	}
 
 	query := `SELECT
+			      s.id,
+			      u.uid,
 		          u.email,
-			      s.username,
+			      u.username,
 			      s.service_name,
-			      (s.start_date + (s.counter_months || ' months')::INTERVAL)::DATE AS end_date,
-			      s.price
+			      e.end_date,
+			      s.price,
+			      s.currency,
+			      u.locale,
+			      e.end_date - $1::DATE AS days_before,
+			      u.reminder_digest,
+			      s.last_notified_at
 			  FROM subscriptions s
-		      JOIN users u ON s.username = u.username
-		      WHERE (s.start_date + (s.counter_months || ' months')::INTERVAL)::DATE = CURRENT_DATE + INTERVAL '1 day';`
-	rows, err := s.DB.QueryContext(ctx, query)
+		      JOIN users u ON u.uid = s.user_uid
+		      CROSS JOIN LATERAL (
+		          SELECT (s.start_date + (s.counter_months || ' months')::INTERVAL)::DATE AS end_date
+		      ) e
+		      WHERE s.deleted_at IS NULL
+			    AND s.is_active
+			    AND s.archived_at IS NULL
+			    AND CASE
+			        WHEN u.reminder_days_before IS NULL THEN e.end_date - $1::DATE = ANY($2::INT[])
+			        ELSE e.end_date - $1::DATE = u.reminder_days_before
+			    END;`
+	rows, err := s.DB.QueryContext(ctx, query, today, defaultDays)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -255,10 +840,14 @@ func (s *Storage) FindSubscriptionExpiringTomorrow(ctx context.Context) ([]*mode
 	var result []*models.EntryInfo
 	for rows.Next() {
 		var si models.EntryInfo
-		if err = rows.Scan(&si.Email, &si.Username, &si.ServiceName,
-			&si.EndDate, &si.Price); err != nil {
+		var lastNotifiedAt sql.NullTime
+		if err = rows.Scan(&si.SubscriptionID, &si.UserUID, &si.Email, &si.Username, &si.ServiceName,
+			&si.EndDate, &si.Price, s.currencyDest(&si.Currency), &si.Locale, &si.DaysBefore, &si.Digest, &lastNotifiedAt); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		if lastNotifiedAt.Valid {
+			si.LastNotifiedAt = &lastNotifiedAt.Time
+		}
 		result = append(result, &si)
 	}
 	if err = rows.Err(); err != nil {
@@ -276,10 +865,11 @@ func (s *Storage) FindOldNextPaymentDate(ctx context.Context) ([]*models.Entry,
 	default:
 	}
 	query := `SELECT id, service_name, price, username, 
-			    start_date, counter_months, user_uid, next_payment_date, is_active
+			    start_date, counter_months, user_uid, next_payment_date, is_active, currency
 			  FROM subscriptions
 			  WHERE next_payment_date < CURRENT_DATE
-			  AND is_active = true`
+			  AND is_active = true
+			  AND archived_at IS NULL`
 
 	rows, err := s.DB.QueryContext(ctx, query)
 	if err != nil {
@@ -293,7 +883,7 @@ func (s *Storage) FindOldNextPaymentDate(ctx context.Context) ([]*models.Entry,
 	for rows.Next() {
 		var item models.Entry
 		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, &item.Username, &item.StartDate,
-			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive); err != nil {
+			&item.CounterMonths, &item.UserUID, &item.NextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		result = append(result, &item)
@@ -327,6 +917,91 @@ func (s *Storage) UpdateNextPaymentDate(ctx context.Context, entry *models.Entry
 	return int(rowsAffected), nil
 }
 
+// ListActiveEntrysAfterID возвращает до limit активных подписок с ID больше afterID
+// в порядке возрастания ID. Используется для постраничного обхода всех подписок.
+func (s *Storage) ListActiveEntrysAfterID(ctx context.Context, afterID, limit int) ([]*models.Entry, error) {
+	const op = "storage.ListActiveEntrysAfterID"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT id, service_name, price, username, start_date, counter_months, user_uid,
+			      next_payment_date, is_active, currency
+			  FROM subscriptions
+			  WHERE is_active = true AND deleted_at IS NULL AND archived_at IS NULL AND id > $1
+			  ORDER BY id
+			  LIMIT $2`
+	rows, err := s.DB.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []*models.Entry
+	for rows.Next() {
+		var item models.Entry
+		var nextPaymentDate sql.NullTime
+		if err := rows.Scan(&item.ID, &item.ServiceName, &item.Price, &item.Username, &item.StartDate,
+			&item.CounterMonths, &item.UserUID, &nextPaymentDate, &item.IsActive, s.currencyDest(&item.Currency)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		item.NextPaymentDate = nextPaymentDate.Time
+		result = append(result, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// ShiftNextPaymentDates сдвигает next_payment_date активных подписок, подходящих под filter,
+// на offsetDays дней одним запросом, то есть в одной транзакции, и возвращает ID измененных
+// подписок. Пустые условия фильтра не ограничивают выборку; даты фильтра задаются в формате
+// 2006-01-02 и должны быть проверены вызывающим кодом. Удаленные, архивные подписки и
+// подписки без даты следующего платежа не изменяются.
+func (s *Storage) ShiftNextPaymentDates(ctx context.Context, filter models.PaymentDateShiftFilter, offsetDays int) ([]int, error) {
+	const op = "storage.ShiftNextPaymentDates"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE subscriptions
+		      SET next_payment_date = next_payment_date + $1::INT
+		      WHERE is_active = true AND deleted_at IS NULL AND archived_at IS NULL
+		        AND next_payment_date IS NOT NULL
+		        AND ($2 = '' OR LOWER(service_name) = LOWER($2))
+		        AND ($3 = '' OR user_uid::TEXT = $3)
+		        AND (NULLIF($4, '') IS NULL OR next_payment_date >= NULLIF($4, '')::DATE)
+		        AND (NULLIF($5, '') IS NULL OR next_payment_date <= NULLIF($5, '')::DATE)
+		      RETURNING id`
+	rows, err := s.DB.QueryContext(ctx, query, offsetDays, filter.ServiceName, filter.UserUID, filter.DueFrom, filter.DueTo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
 // GetActiveSubscriptionIDByUserUID получает ID активной подписки пользователя
 func (s *Storage) GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID string, serviceName string) (string, error) {
 	const op = "storage.GetActiveSubscriptionIDByUserUID"
@@ -347,3 +1022,37 @@ func (s *Storage) GetActiveSubscriptionIDByUserUID(ctx context.Context, userUID
 	}
 	return res, nil
 }
+
+// ArchiveInactiveEntrys помечает архивными не более limit подписок, срок которых
+// (start_date + counter_months) закончился до cutoff или которые удалены до cutoff.
+// Архивные подписки не попадают в списки, суммы и статистику. Возвращает количество архивированных подписок.
+func (s *Storage) ArchiveInactiveEntrys(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	const op = "storage.ArchiveInactiveEntrys"
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE subscriptions
+			  SET archived_at = NOW()
+			  WHERE id IN (
+			      SELECT id
+			      FROM subscriptions
+			      WHERE archived_at IS NULL
+			        AND ((start_date + (counter_months || ' months')::INTERVAL) < $1
+			             OR deleted_at < $1)
+			      ORDER BY id
+			      LIMIT $2
+			      FOR UPDATE SKIP LOCKED
+			  )`
+	res, err := s.DB.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	archived, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(archived), nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+// TestStorage_SumEntrysTotal сравнивает сумму, посчитанную в базе, с подсчетом по строкам в Go.
+func TestStorage_SumEntrysTotal(t *testing.T) {
+	storage, cleanup := setupTestDatabase(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(t, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	factory.CreateSubscription(t, "Netflix", 500, "testuser", date(2024, 1, 1), 12, userUID, date(2024, 1, 1), true)
+	factory.CreateSubscription(t, "Spotify", 300, "testuser", date(2024, 1, 31), 6, userUID, date(2024, 1, 31), true)
+	factory.CreateSubscription(t, "Okko", 250, "testuser", date(2024, 3, 15), 24, userUID, date(2024, 3, 15), true)
+	factory.CreateSubscription(t, "Ivi", 199, "testuser", date(2024, 2, 29), 3, userUID, date(2024, 2, 29), true)
+	factory.CreateSubscription(t, "Paused", 900, "testuser", date(2024, 1, 1), 12, userUID, date(2024, 1, 1), false)
+	repriced := factory.CreateSubscription(t, "Kinopoisk", 700, "testuser", date(2024, 1, 10), 12, userUID, date(2024, 1, 10), true)
+	changes := []struct {
+		old, new int
+		at       time.Time
+	}{
+		{400, 500, date(2023, 12, 1)},                             // до начала подписки
+		{500, 600, date(2024, 4, 10)},                             // ровно в начале месяца подписки
+		{600, 650, time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)}, // в середине дня начала месяца
+		{650, 700, date(2024, 9, 1)},
+	}
+	for _, c := range changes {
+		_, err := storage.DB.Exec(`INSERT INTO subscription_price_history (subscription_id, old_price, new_price, changed_at)
+			VALUES ($1, $2, $3, $4)`, repriced, c.old, c.new, c.at)
+		require.NoError(t, err)
+	}
+
+	okko := "Okko"
+	filters := []struct {
+		name   string
+		filter models.FilterSum
+	}{
+		{name: "с начала года", filter: models.FilterSum{StartDate: date(2024, 1, 1), CounterMonths: 12}},
+		{name: "до начала подписок", filter: models.FilterSum{StartDate: date(2023, 6, 1), CounterMonths: 12}},
+		{name: "день фильтра позже дня подписки", filter: models.FilterSum{StartDate: date(2024, 5, 20), CounterMonths: 3}},
+		{name: "день фильтра раньше дня подписки", filter: models.FilterSum{StartDate: date(2024, 4, 5), CounterMonths: 1}},
+		{name: "конец месяца", filter: models.FilterSum{StartDate: date(2024, 3, 1), CounterMonths: 2}},
+		{name: "в середине изменений цены", filter: models.FilterSum{StartDate: date(2024, 6, 10), CounterMonths: 6}},
+		{name: "фильтр по сервису", filter: models.FilterSum{StartDate: date(2024, 6, 1), CounterMonths: 6, ServiceName: &okko}},
+		{name: "после окончания подписок", filter: models.FilterSum{StartDate: date(2027, 1, 1), CounterMonths: 1}},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.UserUID = userUID
+
+			want, err := storage.sumEntrys(ctx, tt.filter)
+			require.NoError(t, err)
+			got, err := storage.sumEntrysTotal(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.InDelta(t, want.Total, got, 0.001)
+
+			total, err := storage.CountSumEntrys(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.InDelta(t, want.Total, total, 0.001)
+		})
+	}
+}
+
+// BenchmarkStorage_CountSumEntrys сравнивает подсчет суммы в базе с подсчетом по строкам в Go
+// для пользователя с большим числом подписок.
+func BenchmarkStorage_CountSumEntrys(b *testing.B) {
+	storage, cleanup := setupTestDatabase(b)
+	defer cleanup()
+
+	ctx := context.Background()
+	factory := NewTestDataFactory(storage)
+	userUID := uuid.New().String()
+	factory.CreateUser(b, userUID, "testuser", "test@example.com", "hashedpassword", "user")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 1000 {
+		startDate := start.AddDate(0, 0, i%365)
+		id := factory.CreateSubscription(b, fmt.Sprintf("Service %d", i), float64(100+i), "testuser", startDate, 12+i%24, userUID, startDate, true)
+		if i%10 == 0 {
+			_, err := storage.DB.Exec(`INSERT INTO subscription_price_history (subscription_id, old_price, new_price, changed_at)
+				VALUES ($1, $2, $3, $4)`, id, 100+i, 150+i, startDate.AddDate(0, 3, 0))
+			require.NoError(b, err)
+		}
+	}
+	filter := models.FilterSum{UserUID: userUID, StartDate: start.AddDate(0, 6, 0), CounterMonths: 12}
+
+	b.Run("sql", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := storage.sumEntrysTotal(ctx, filter); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("go", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := storage.sumEntrys(ctx, filter); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
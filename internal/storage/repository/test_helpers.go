@@ -23,7 +23,7 @@ func NewTestDataFactory(storage *Storage) *TestDataFactory {
 }
 
 // CreateUser создает тестового пользователя
-func (f *TestDataFactory) CreateUser(t *testing.T, userUID, username, email, passwordHash, role string) {
+func (f *TestDataFactory) CreateUser(t testing.TB, userUID, username, email, passwordHash, role string) {
 	_, err := f.storage.DB.Exec(`INSERT INTO users (uid, username, email, password_hash, role) 
 		VALUES ($1, $2, $3, $4, $5)`,
 		userUID, username, email, passwordHash, role)
@@ -41,7 +41,7 @@ func (f *TestDataFactory) CreateUserWithSubscription(t *testing.T, userUID, user
 }
 
 // CreateSubscription создает тестовую подписку
-func (f *TestDataFactory) CreateSubscription(t *testing.T, serviceName string, price float64, username string,
+func (f *TestDataFactory) CreateSubscription(t testing.TB, serviceName string, price float64, username string,
 	startDate time.Time, counterMonths int, userUID string, nextPaymentDate time.Time, isActive bool) int {
 	var id int
 	err := f.storage.DB.QueryRow(`INSERT INTO subscriptions 
@@ -176,7 +176,12 @@ func (v *TestVerification) VerifyUserSubscriptionStatus(t *testing.T, userUID, e
 }
 
 // setupTestDatabase создает тестовую БД с контейнером PostgreSQL
-func setupTestDatabase(t *testing.T) (*Storage, func()) {
+func setupTestDatabase(t testing.TB) (*Storage, func()) {
+	return setupTestDatabaseWithTimeout(t, 0)
+}
+
+// setupTestDatabaseWithTimeout создает тестовую БД, подключаясь к ней с заданным statement_timeout.
+func setupTestDatabaseWithTimeout(t testing.TB, statementTimeout time.Duration) (*Storage, func()) {
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -210,7 +215,7 @@ func setupTestDatabase(t *testing.T) (*Storage, func()) {
 	// Пробуем подключиться несколько раз с ретраями
 	var storage *Storage
 	for range 10 {
-		storage, err = New(connStr)
+		storage, err = New(connStr, "", statementTimeout, DefaultCurrency)
 		if err == nil {
 			// Проверяем, что подключение действительно работает
 			err = storage.DB.Ping()
@@ -224,12 +229,30 @@ func setupTestDatabase(t *testing.T) (*Storage, func()) {
 
 	// Создаем таблицы
 	_, err = storage.DB.Exec(`
+        DROP TABLE IF EXISTS promo_code_redemptions CASCADE;
+        DROP TABLE IF EXISTS promo_codes CASCADE;
+        DROP TABLE IF EXISTS yookassa_refunds CASCADE;
         DROP TABLE IF EXISTS yookassa_payments CASCADE;
         DROP TABLE IF EXISTS yookassa_payment_tokens CASCADE;
         DROP TABLE IF EXISTS subscriptions CASCADE;
         DROP TABLE IF EXISTS users CASCADE;
         
+        DROP TABLE IF EXISTS plans CASCADE;
+        
         CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+        CREATE EXTENSION IF NOT EXISTS pg_trgm;
+        
+        CREATE TABLE plans (
+            id SERIAL PRIMARY KEY,
+            code TEXT NOT NULL UNIQUE,
+            name TEXT NOT NULL,
+            price BIGINT NOT NULL,
+            currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+            is_default BOOLEAN NOT NULL DEFAULT FALSE
+        );
+        CREATE UNIQUE INDEX idx_plans_default ON plans(is_default) WHERE is_default;
+        INSERT INTO plans (code, name, price, currency, is_default)
+        VALUES ('standard', 'Стандартный', 20000, 'RUB', TRUE);
         
         CREATE TABLE users (
             uid UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -239,8 +262,15 @@ func setupTestDatabase(t *testing.T) (*Storage, func()) {
             role TEXT NOT NULL DEFAULT 'user',
             trial_end_date DATE,
             subscription_status TEXT DEFAULT 'trial',
-            subscription_expiry DATE
+            subscription_expiry DATE,
+            deleted_at TIMESTAMPTZ,
+            locale TEXT NOT NULL DEFAULT 'ru-RU',
+            plan_id INT REFERENCES plans(id),
+            reminder_days_before INT CHECK (reminder_days_before BETWEEN 1 AND 30),
+            reminder_digest BOOLEAN NOT NULL DEFAULT false
         );
+        CREATE INDEX idx_users_username_trgm ON users USING gin (lower(username) gin_trgm_ops);
+        CREATE INDEX idx_users_email_trgm ON users USING gin (lower(email) gin_trgm_ops);
         
         CREATE TABLE subscriptions (
 			id SERIAL PRIMARY KEY,
@@ -249,23 +279,32 @@ func setupTestDatabase(t *testing.T) (*Storage, func()) {
             username TEXT NOT NULL,
             start_date DATE NOT NULL,
             counter_months INT NOT NULL,
-            user_uid UUID REFERENCES users(uid),
+            user_uid UUID NOT NULL REFERENCES users(uid),
             next_payment_date DATE,
-            is_active BOOLEAN DEFAULT true
+            is_active BOOLEAN DEFAULT true,
+            deleted_at TIMESTAMPTZ,
+            archived_at TIMESTAMPTZ,
+            currency VARCHAR(3),
+            notes TEXT CHECK (char_length(notes) <= 500),
+            metadata JSONB CHECK (jsonb_typeof(metadata) = 'object'),
+            last_notified_at TIMESTAMPTZ
         );
         
         CREATE TABLE yookassa_payment_tokens (
             id SERIAL PRIMARY KEY,
             user_uid UUID NOT NULL REFERENCES users(uid),
             token TEXT NOT NULL,
-            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            revoked_at TIMESTAMPTZ
         );
+        CREATE UNIQUE INDEX yookassa_payment_tokens_user_token_active_key
+            ON yookassa_payment_tokens(user_uid, token) WHERE revoked_at IS NULL;
         
         CREATE TABLE yookassa_payments (
             id SERIAL PRIMARY KEY,
             user_uid UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
             subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE SET NULL,
-            payment_id VARCHAR(255) NOT NULL,
+            payment_id VARCHAR(255) NOT NULL UNIQUE,
             amount BIGINT NOT NULL,
             currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
             status VARCHAR(50) NOT NULL,
@@ -273,11 +312,83 @@ func setupTestDatabase(t *testing.T) (*Storage, func()) {
             created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
         );
         
+        CREATE TABLE yookassa_refunds (
+            id SERIAL PRIMARY KEY,
+            payment_id INTEGER NOT NULL REFERENCES yookassa_payments(id) ON DELETE CASCADE,
+            user_uid UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+            refund_id VARCHAR(255) NOT NULL UNIQUE,
+            amount BIGINT NOT NULL,
+            currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+            status VARCHAR(50) NOT NULL,
+            expiry_reduction_seconds BIGINT NOT NULL DEFAULT 0,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+        
+        CREATE TABLE promo_codes (
+            id SERIAL PRIMARY KEY,
+            code TEXT NOT NULL,
+            percent_off INT CHECK (percent_off BETWEEN 1 AND 100),
+            amount_off BIGINT CHECK (amount_off > 0),
+            valid_from TIMESTAMPTZ,
+            valid_until TIMESTAMPTZ,
+            max_uses INT CHECK (max_uses > 0),
+            used_count INT NOT NULL DEFAULT 0,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            CHECK ((percent_off IS NULL) <> (amount_off IS NULL))
+        );
+        CREATE UNIQUE INDEX promo_codes_code_lower_key ON promo_codes (lower(code));
+        
+        CREATE TABLE promo_code_redemptions (
+            id SERIAL PRIMARY KEY,
+            promo_code_id INT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+            user_uid UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+            payment_id VARCHAR(255) NOT NULL,
+            discount BIGINT NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+        
+        CREATE TABLE account_deletion_requests (
+            id SERIAL PRIMARY KEY,
+            user_uid UUID NOT NULL,
+            mode TEXT NOT NULL,
+            requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            scheduled_for TIMESTAMPTZ NOT NULL,
+            completed_at TIMESTAMPTZ
+        );
+        
+        CREATE TABLE idempotency_keys (
+            key TEXT PRIMARY KEY,
+            expires_at TIMESTAMPTZ NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+        
+        CREATE TABLE subscription_price_history (
+            id SERIAL PRIMARY KEY,
+            subscription_id INT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+            old_price INT NOT NULL,
+            new_price INT NOT NULL,
+            changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+        
+        CREATE TABLE notification_outbox (
+            id BIGSERIAL PRIMARY KEY,
+            routing_key TEXT NOT NULL,
+            payload JSONB NOT NULL,
+            dedup_key TEXT NOT NULL UNIQUE,
+            correlation_id TEXT NOT NULL DEFAULT '',
+            attempts INT NOT NULL DEFAULT 0,
+            last_error TEXT,
+            next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            sent_at TIMESTAMPTZ
+        );
+        
         CREATE INDEX idx_subscriptions_username ON subscriptions(username);
         CREATE INDEX idx_subscriptions_user_uid ON subscriptions(user_uid);
         CREATE INDEX idx_subscriptions_next_payment_date ON subscriptions(next_payment_date);
         CREATE INDEX idx_yookassa_payments_user_uid ON yookassa_payments(user_uid);
         CREATE INDEX idx_yookassa_payments_subscription_id ON yookassa_payments(subscription_id);
+        CREATE INDEX idx_notification_outbox_pending ON notification_outbox(next_attempt_at) WHERE sent_at IS NULL;
     `)
 	require.NoError(t, err, "Failed to create tables")
 
@@ -3,12 +3,28 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
 )
 
+// uniqueViolation — код ошибки Postgres при нарушении ограничения уникальности.
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, нарушено ли ограничение уникальности.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
 // RegisterUser сохраняет нового пользователя в базу данных и возвращает его ID.
+// Если username или email уже заняты, возвращает models.ErrUserExists: так
+// одновременные регистрации с одним именем различимы от прочих ошибок базы.
 func (s *Storage) RegisterUser(ctx context.Context, user models.User) (string, error) {
 	const op = "storage.RegisterUser"
 	select {
@@ -25,6 +41,9 @@ func (s *Storage) RegisterUser(ctx context.Context, user models.User) (string, e
 	if err := s.DB.QueryRowContext(ctx, query,
 		user.Email, user.Username, user.PasswordHash, user.Role, user.TrialEndDate,
 		user.SubscriptionStatus).Scan(&newID); err != nil {
+		if isUniqueViolation(err) {
+			return "", fmt.Errorf("%s: %w", op, models.ErrUserExists)
+		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 	return newID, nil
@@ -71,7 +90,7 @@ func (s *Storage) GetUser(ctx context.Context, userUID string) (*models.User, er
 	}
 
 	query := `SELECT uid, email, username, password_hash, role, trial_end_date,
-			      subscription_status, subscription_expiry
+			      subscription_status, subscription_expiry, locale
 			  FROM users
 			  WHERE uid = $1`
 	u := &models.User{}
@@ -79,7 +98,7 @@ func (s *Storage) GetUser(ctx context.Context, userUID string) (*models.User, er
 
 	var trialEndDate, subscriptionExpiry sql.NullTime
 	if err := row.Scan(&u.UUID, &u.Email, &u.Username, &u.PasswordHash,
-		&u.Role, &trialEndDate, &u.SubscriptionStatus, &subscriptionExpiry); err != nil {
+		&u.Role, &trialEndDate, &u.SubscriptionStatus, &subscriptionExpiry, &u.Locale); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -92,8 +111,56 @@ func (s *Storage) GetUser(ctx context.Context, userUID string) (*models.User, er
 	return u, nil
 }
 
-// FindSubscriptionExpiringToday находит пользователей с истекающим сегодня пробным периодом
-func (s *Storage) FindSubscriptionExpiringToday(ctx context.Context) ([]*models.User, error) {
+// GetReminderSettings возвращает собственный срок напоминаний пользователя в днях
+// (nil означает сроки по умолчанию) и признак получения напоминаний одним письмом.
+func (s *Storage) GetReminderSettings(ctx context.Context, userUID string) (*int, bool, error) {
+	const op = "storage.GetReminderSettings"
+	select {
+	case <-ctx.Done():
+		return nil, false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT reminder_days_before, reminder_digest FROM users WHERE uid = $1`
+	var days sql.NullInt32
+	var digest bool
+	if err := s.DB.QueryRowContext(ctx, query, userUID).Scan(&days, &digest); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if !days.Valid {
+		return nil, digest, nil
+	}
+	value := int(days.Int32)
+	return &value, digest, nil
+}
+
+// SetReminderSettings сохраняет срок напоминаний пользователя и признак получения их одним письмом;
+// days равный nil возвращает сроки по умолчанию.
+func (s *Storage) SetReminderSettings(ctx context.Context, userUID string, days *int, digest bool) error {
+	const op = "storage.SetReminderSettings"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE users SET reminder_days_before = $2, reminder_digest = $3 WHERE uid = $1`
+	res, err := s.DB.ExecContext(ctx, query, userUID, days, digest)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// FindSubscriptionExpiringToday находит пользователей, пробный период которых истекает в день today
+func (s *Storage) FindSubscriptionExpiringToday(ctx context.Context, today time.Time) ([]*models.User, error) {
 	const op = "storage.FindSubscriptionExpiringToday"
 	select {
 	case <-ctx.Done():
@@ -105,8 +172,8 @@ func (s *Storage) FindSubscriptionExpiringToday(ctx context.Context) ([]*models.
 			      uid, email, username, password_hash, role, trial_end_date,
 			      subscription_status, subscription_expiry
 			  FROM users
-		      WHERE trial_end_date::DATE = CURRENT_DATE;`
-	rows, err := s.DB.QueryContext(ctx, query)
+		      WHERE trial_end_date::DATE = $1::DATE;`
+	rows, err := s.DB.QueryContext(ctx, query, today)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -137,6 +204,201 @@ func (s *Storage) FindSubscriptionExpiringToday(ctx context.Context) ([]*models.
 	return result, nil
 }
 
+// FindEndedTrials находит пользователей, пробный период которых уже закончился,
+// но еще не был переведен в оплаченную подписку или в истекший статус.
+func (s *Storage) FindEndedTrials(ctx context.Context) ([]*models.User, error) {
+	const op = "storage.FindEndedTrials"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `SELECT
+			      uid, email, username, role, trial_end_date,
+			      subscription_status, subscription_expiry, locale
+			  FROM users
+			  WHERE subscription_status = 'trial' AND trial_end_date <= NOW()
+			  ORDER BY trial_end_date, uid`
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []*models.User
+	for rows.Next() {
+		var u models.User
+		var trialEndDate, subscriptionExpiry sql.NullTime
+		if err = rows.Scan(&u.UUID, &u.Email, &u.Username, &u.Role, &trialEndDate,
+			&u.SubscriptionStatus, &subscriptionExpiry, &u.Locale,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if trialEndDate.Valid {
+			u.TrialEndDate = &trialEndDate.Time
+		}
+		if subscriptionExpiry.Valid {
+			u.SubscriptionExpire = &subscriptionExpiry.Time
+		}
+		result = append(result, &u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+// ExpireLapsedSubscriptions переводит в статус expired активных пользователей, у которых
+// subscription_expiry раньше cutoff, и в той же транзакции записывает в outbox уведомление,
+// построенное notification для каждого из них: статусы и уведомления сохраняются только вместе.
+// Статус меняется одним запросом, поэтому при параллельных запусках каждый пользователь
+// возвращается только один раз. Возвращает отключенных пользователей и количество новых
+// уведомлений.
+func (s *Storage) ExpireLapsedSubscriptions(ctx context.Context, cutoff time.Time,
+	notification func(*models.User) (models.OutboxMessage, error)) ([]*models.User, int, error) {
+	const op = "storage.ExpireLapsedSubscriptions"
+	select {
+	case <-ctx.Done():
+		return nil, 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `UPDATE users
+			  SET subscription_status = 'expired'
+			  WHERE subscription_status = 'active' AND subscription_expiry < $1
+			  RETURNING uid, email, username, role, trial_end_date,
+			      subscription_status, subscription_expiry, locale`
+	users, err := scanExpiredUsers(tx.QueryContext(ctx, query, cutoff))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	messages := make([]models.OutboxMessage, 0, len(users))
+	for _, u := range users {
+		message, err := notification(u)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		messages = append(messages, message)
+	}
+	queued, err := insertNotifications(ctx, tx, messages)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, queued, nil
+}
+
+// scanExpiredUsers читает пользователей, возвращенные запросом перевода в статус expired.
+// Строки закрываются до возврата, поэтому транзакция запроса может выполнять следующие запросы.
+func scanExpiredUsers(rows *sql.Rows, err error) ([]*models.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var result []*models.User
+	for rows.Next() {
+		var u models.User
+		var trialEndDate, subscriptionExpiry sql.NullTime
+		if err = rows.Scan(&u.UUID, &u.Email, &u.Username, &u.Role, &trialEndDate,
+			&u.SubscriptionStatus, &subscriptionExpiry, &u.Locale,
+		); err != nil {
+			return nil, err
+		}
+
+		if trialEndDate.Valid {
+			u.TrialEndDate = &trialEndDate.Time
+		}
+		if subscriptionExpiry.Valid {
+			u.SubscriptionExpire = &subscriptionExpiry.Time
+		}
+		result = append(result, &u)
+	}
+	return result, rows.Err()
+}
+
+// ExpireTrial переводит пользователя userUID из пробного периода в статус expired и в той же
+// транзакции записывает в outbox уведомление message: статус и уведомление сохраняются только
+// вместе. Пользователь не в статусе trial не изменяется, и уведомление для него не записывается.
+// Возвращает true, если статус изменен.
+func (s *Storage) ExpireTrial(ctx context.Context, userUID string, message models.OutboxMessage) (bool, error) {
+	const op = "storage.ExpireTrial"
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `UPDATE users
+			  SET subscription_status = 'expired'
+			  WHERE uid = $1 AND subscription_status = 'trial'`
+	res, err := tx.ExecContext(ctx, query, userUID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := insertNotifications(ctx, tx, []models.OutboxMessage{message}); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return true, nil
+}
+
+// ActivateTrialSubscription переводит пользователя из пробного периода в активную
+// подписку на месяц с даты окончания пробного периода. Пользователи не в статусе
+// trial не изменяются, поэтому повторный вызов для того же платежа безопасен.
+func (s *Storage) ActivateTrialSubscription(ctx context.Context, userUID string) error {
+	const op = "storage.ActivateTrialSubscription"
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query := `UPDATE users
+			  SET subscription_status = 'active',
+			      subscription_expiry = COALESCE(trial_end_date, NOW()) + INTERVAL '1 month'
+			  WHERE uid = $1 AND subscription_status = 'trial'`
+	_, err := s.DB.ExecContext(ctx, query, userUID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
 // UpdateStatusActiveForSubscription обновляет статус подписки на активный
 func (s *Storage) UpdateStatusActiveForSubscription(ctx context.Context, userUID, status string) error {
 	const op = "storage.UpdateStatusActiveForSubscription"
@@ -193,3 +455,53 @@ func (s *Storage) GetSubscriptionStatus(ctx context.Context, userUID string) (bo
 	}
 	return isActive, nil
 }
+
+// likeEscaper экранирует спецсимволы шаблона LIKE, чтобы запрос искал их буквально.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers ищет пользователей, у которых имя или электронная почта содержит query
+// без учета регистра. Удаленные пользователи не возвращаются. Результаты упорядочены по имени.
+func (s *Storage) SearchUsers(ctx context.Context, query string, limit, offset int) ([]*models.UserSummary, error) {
+	const op = "storage.SearchUsers"
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+	sqlQuery := `SELECT uid, email, username, role, subscription_status, trial_end_date, subscription_expiry
+			  FROM users
+			  WHERE deleted_at IS NULL
+			    AND (lower(username) LIKE $1 OR lower(email) LIKE $1)
+			  ORDER BY username
+			  LIMIT $2 OFFSET $3`
+	rows, err := s.reader().QueryContext(ctx, sqlQuery, pattern, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := []*models.UserSummary{}
+	for rows.Next() {
+		var u models.UserSummary
+		var trialEndDate, subscriptionExpiry sql.NullTime
+		if err := rows.Scan(&u.UUID, &u.Email, &u.Username, &u.Role, &u.SubscriptionStatus,
+			&trialEndDate, &subscriptionExpiry); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if trialEndDate.Valid {
+			u.TrialEndDate = &trialEndDate.Time
+		}
+		if subscriptionExpiry.Valid {
+			u.SubscriptionExpire = &subscriptionExpiry.Time
+		}
+		result = append(result, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
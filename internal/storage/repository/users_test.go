@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/models"
+)
+
+func TestStorage_RegisterUser_TranslatesUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name       string
+		dbErr      error
+		wantExists bool
+	}{
+		{
+			name:       "нарушение уникальности username",
+			dbErr:      &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"},
+			wantExists: true,
+		},
+		{
+			name:  "другая ошибка Postgres",
+			dbErr: &pgconn.PgError{Code: "23502"},
+		},
+		{
+			name:  "ошибка соединения",
+			dbErr: errors.New("connection reset"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, primary, _ := newRoutingStorage(t, false)
+			primary.err = tt.dbErr
+
+			_, err := storage.RegisterUser(context.Background(), models.User{Username: "alice", Email: "alice@example.com"})
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantExists, errors.Is(err, models.ErrUserExists))
+			if !tt.wantExists {
+				assert.ErrorIs(t, err, tt.dbErr)
+			}
+		})
+	}
+}
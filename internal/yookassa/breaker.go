@@ -0,0 +1,87 @@
+package yookassa
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState описывает состояние автоматического выключателя.
+type breakerState int
+
+const (
+	stateClosed   breakerState = iota // запросы проходят, ошибки подсчитываются
+	stateOpen                         // запросы отклоняются до истечения cooldown
+	stateHalfOpen                     // пропускается один пробный запрос
+)
+
+// CircuitBreaker размыкает цепь после threshold ошибок подряд и отклоняет запросы
+// в течение cooldown. После cooldown пропускается один пробный запрос: при успехе
+// цепь замыкается, при ошибке снова размыкается.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// NewCircuitBreaker создает CircuitBreaker. threshold меньше 1 считается равным 1.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow сообщает, можно ли выполнить запрос. В полуоткрытом состоянии
+// пропускается только один запрос до получения его результата.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		return true
+	case stateHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// Success фиксирует успешный запрос и замыкает цепь.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = stateClosed
+	b.failures = 0
+}
+
+// Failure фиксирует неудачный запрос и при достижении порога размыкает цепь.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Open сообщает, разомкнута ли цепь.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == stateOpen
+}
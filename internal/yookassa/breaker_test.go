@@ -0,0 +1,60 @@
+package yookassa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		assert.True(t, b.Allow())
+		b.Failure()
+	}
+	assert.False(t, b.Open(), "порог еще не достигнут")
+
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.True(t, b.Open())
+	assert.False(t, b.Allow(), "разомкнутая цепь отклоняет запросы")
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Minute)
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+	assert.False(t, b.Open(), "ошибки считаются только подряд")
+}
+
+func TestCircuitBreaker_HalfOpensAfterCooldown(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(1, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	assert.False(t, b.Allow())
+
+	now = now.Add(29 * time.Second)
+	assert.False(t, b.Allow(), "cooldown еще не истек")
+
+	now = now.Add(time.Second)
+	assert.True(t, b.Allow(), "после cooldown пропускается пробный запрос")
+	assert.False(t, b.Allow(), "в полуоткрытом состоянии пропускается только один запрос")
+
+	// Неудачный пробный запрос снова размыкает цепь
+	b.Failure()
+	assert.True(t, b.Open())
+	assert.False(t, b.Allow())
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.False(t, b.Open())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+}
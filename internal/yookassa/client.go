@@ -5,11 +5,20 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"time"
 )
 
+// StatusError описывает ответ провайдера с неожиданным HTTP-статусом.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status: " + e.Status
+}
+
 // Client представляет клиент для работы с платежным провайдером.
 type Client struct {
 	shopID     string
@@ -48,14 +57,36 @@ func (c *Client) newRequest(method, path string, body interface{}) (*http.Reques
 
 // CreatePayment отправляет запрос на создание платежа с использованием payment_token
 func (c *Client) CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentResponse, error) {
-	req, err := c.newRequest("POST", "/payments", reqParams)
-	if err != nil {
+	var paymentResp CreatePaymentResponse
+	if err := c.post("/payments", reqParams.IdempotenceKey, reqParams, &paymentResp); err != nil {
+		return nil, err
+	}
+	return &paymentResp, nil
+}
+
+// CreateRefund отправляет запрос на возврат платежа
+func (c *Client) CreateRefund(reqParams CreateRefundRequest) (*CreateRefundResponse, error) {
+	var refundResp CreateRefundResponse
+	if err := c.post("/refunds", reqParams.IdempotenceKey, reqParams, &refundResp); err != nil {
 		return nil, err
 	}
+	return &refundResp, nil
+}
+
+// post отправляет POST-запрос и декодирует ответ в out. Ответ с неожиданным
+// HTTP-статусом возвращается как *StatusError.
+func (c *Client) post(path, idempotenceKey string, body, out interface{}) error {
+	req, err := c.newRequest("POST", path, body)
+	if err != nil {
+		return err
+	}
+	if idempotenceKey != "" {
+		req.Header.Set("Idempotence-Key", idempotenceKey)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -64,12 +95,7 @@ func (c *Client) CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentRe
 	}()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, errors.New("unexpected status: " + resp.Status)
+		return &StatusError{Code: resp.StatusCode, Status: resp.Status}
 	}
-
-	var paymentResp CreatePaymentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&paymentResp); err != nil {
-		return nil, err
-	}
-	return &paymentResp, nil
+	return json.NewDecoder(resp.Body).Decode(out)
 }
@@ -0,0 +1,103 @@
+package yookassa
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+)
+
+// ErrProviderUnavailable возвращается без обращения к провайдеру, пока цепь разомкнута.
+var ErrProviderUnavailable = errors.New("payment provider unavailable")
+
+// Provider определяет вызовы платежного провайдера.
+type Provider interface {
+	CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentResponse, error)
+	CreateRefund(reqParams CreateRefundRequest) (*CreateRefundResponse, error)
+}
+
+// ResilientClient оборачивает Provider повторами при временных ошибках
+// и автоматическим выключателем, который быстро отклоняет запросы при недоступности провайдера.
+type ResilientClient struct {
+	provider   Provider
+	breaker    *CircuitBreaker
+	maxRetries int
+	retryDelay time.Duration
+	sleep      func(time.Duration)
+}
+
+// NewResilientClient создает ResilientClient с настройками из cfg.
+func NewResilientClient(provider Provider, cfg config.PaymentProvider) *ResilientClient {
+	return &ResilientClient{
+		provider:   provider,
+		breaker:    NewCircuitBreaker(cfg.ProviderBreakerThreshold, cfg.ProviderBreakerCooldown),
+		maxRetries: cfg.ProviderMaxRetries,
+		retryDelay: cfg.ProviderRetryDelay,
+		sleep:      time.Sleep,
+	}
+}
+
+// CreatePayment создает платеж, повторяя запрос при временных ошибках.
+// Все попытки используют один Idempotence-Key, поэтому повтор не создает второй платеж.
+// Пока цепь разомкнута, сразу возвращается ErrProviderUnavailable.
+func (c *ResilientClient) CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	if reqParams.IdempotenceKey == "" {
+		reqParams.IdempotenceKey = uuid.NewString()
+	}
+	return withRetry(c, func() (*CreatePaymentResponse, error) {
+		return c.provider.CreatePayment(reqParams)
+	})
+}
+
+// CreateRefund создает возврат платежа с теми же повторами и выключателем, что и CreatePayment.
+// Все попытки используют один Idempotence-Key, поэтому повтор не создает второй возврат.
+func (c *ResilientClient) CreateRefund(reqParams CreateRefundRequest) (*CreateRefundResponse, error) {
+	if reqParams.IdempotenceKey == "" {
+		reqParams.IdempotenceKey = uuid.NewString()
+	}
+	return withRetry(c, func() (*CreateRefundResponse, error) {
+		return c.provider.CreateRefund(reqParams)
+	})
+}
+
+// withRetry выполняет call, повторяя его при временных ошибках с линейно растущей задержкой.
+func withRetry[T any](c *ResilientClient, call func() (*T, error)) (*T, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			c.sleep(c.retryDelay * time.Duration(attempt))
+		}
+		if !c.breaker.Allow() {
+			return nil, ErrProviderUnavailable
+		}
+
+		resp, err := call()
+		if err == nil {
+			c.breaker.Success()
+			return resp, nil
+		}
+		if !IsTransient(err) {
+			// Ошибка запроса, а не недоступность провайдера: цепь не размыкаем
+			c.breaker.Success()
+			return nil, err
+		}
+		c.breaker.Failure()
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// IsTransient сообщает, является ли ошибка временной: сетевые ошибки и таймауты,
+// ответы 429 и 5xx. Остальные ответы провайдера считаются окончательными.
+func IsTransient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,177 @@
+package yookassa
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/config"
+)
+
+// ProviderMock реализует интерфейс Provider
+type ProviderMock struct {
+	mock.Mock
+}
+
+func (m *ProviderMock) CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	args := m.Called(reqParams)
+	if res := args.Get(0); res != nil {
+		return res.(*CreatePaymentResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ProviderMock) CreateRefund(reqParams CreateRefundRequest) (*CreateRefundResponse, error) {
+	args := m.Called(reqParams)
+	if res := args.Get(0); res != nil {
+		return res.(*CreateRefundResponse), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func newTestResilientClient(provider Provider, retries, threshold int) *ResilientClient {
+	c := NewResilientClient(provider, config.PaymentProvider{
+		ProviderMaxRetries:       retries,
+		ProviderRetryDelay:       time.Millisecond,
+		ProviderBreakerThreshold: threshold,
+		ProviderBreakerCooldown:  time.Minute,
+	})
+	c.sleep = func(time.Duration) {}
+	return c
+}
+
+func TestResilientClient_RetriesTransientErrors(t *testing.T) {
+	provider := new(ProviderMock)
+	var keys []string
+	provider.On("CreatePayment", mock.Anything).Run(func(args mock.Arguments) {
+		keys = append(keys, args.Get(0).(CreatePaymentRequest).IdempotenceKey)
+	}).Return(nil, &StatusError{Code: http.StatusBadGateway, Status: "502 Bad Gateway"}).Once()
+	provider.On("CreatePayment", mock.Anything).Run(func(args mock.Arguments) {
+		keys = append(keys, args.Get(0).(CreatePaymentRequest).IdempotenceKey)
+	}).Return(&CreatePaymentResponse{ID: "pay_1"}, nil).Once()
+
+	c := newTestResilientClient(provider, 2, 5)
+	resp, err := c.CreatePayment(CreatePaymentRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "pay_1", resp.ID)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1], "повтор использует тот же Idempotence-Key")
+	provider.AssertExpectations(t)
+}
+
+func TestResilientClient_CreateRefundRetriesTransientErrors(t *testing.T) {
+	provider := new(ProviderMock)
+	var keys []string
+	provider.On("CreateRefund", mock.Anything).Run(func(args mock.Arguments) {
+		keys = append(keys, args.Get(0).(CreateRefundRequest).IdempotenceKey)
+	}).Return(nil, &StatusError{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}).Once()
+	provider.On("CreateRefund", mock.Anything).Run(func(args mock.Arguments) {
+		keys = append(keys, args.Get(0).(CreateRefundRequest).IdempotenceKey)
+	}).Return(&CreateRefundResponse{ID: "refund_1"}, nil).Once()
+
+	c := newTestResilientClient(provider, 2, 5)
+	resp, err := c.CreateRefund(CreateRefundRequest{PaymentID: "pay_1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "refund_1", resp.ID)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1], "повтор использует тот же Idempotence-Key")
+	provider.AssertExpectations(t)
+}
+
+func TestResilientClient_DoesNotRetryClientErrors(t *testing.T) {
+	provider := new(ProviderMock)
+	provider.On("CreatePayment", mock.Anything).
+		Return(nil, &StatusError{Code: http.StatusBadRequest, Status: "400 Bad Request"}).Once()
+
+	c := newTestResilientClient(provider, 3, 1)
+	_, err := c.CreatePayment(CreatePaymentRequest{})
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.Code)
+	assert.False(t, c.breaker.Open(), "ошибка запроса не размыкает цепь")
+	provider.AssertExpectations(t)
+}
+
+func TestResilientClient_FailsFastWhileOpen(t *testing.T) {
+	provider := new(ProviderMock)
+	provider.On("CreatePayment", mock.Anything).
+		Return(nil, &StatusError{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}).Times(3)
+
+	c := newTestResilientClient(provider, 0, 3)
+	for i := 0; i < 3; i++ {
+		_, err := c.CreatePayment(CreatePaymentRequest{})
+		assert.NotErrorIs(t, err, ErrProviderUnavailable)
+	}
+
+	_, err := c.CreatePayment(CreatePaymentRequest{})
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	provider.AssertNumberOfCalls(t, "CreatePayment", 3)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&StatusError{Code: http.StatusInternalServerError}))
+	assert.True(t, IsTransient(&StatusError{Code: http.StatusTooManyRequests}))
+	assert.False(t, IsTransient(&StatusError{Code: http.StatusUnauthorized}))
+	assert.False(t, IsTransient(errors.New("decode error")))
+}
+
+func TestClient_CreatePayment_StatusErrorAndIdempotenceKey(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("Idempotence-Key")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient("shop", "secret")
+	c.apiURL = srv.URL
+
+	_, err := c.CreatePayment(CreatePaymentRequest{IdempotenceKey: "key-1"})
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.Code)
+	assert.True(t, IsTransient(err))
+	assert.Equal(t, "key-1", gotKey)
+}
+
+func TestClient_CreateRefund(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody CreateRefundRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("Idempotence-Key")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = w.Write([]byte(`{"id":"refund_1","payment_id":"pay_1","status":"succeeded","amount":{"value":"200.00","currency":"RUB"}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("shop", "secret")
+	c.apiURL = srv.URL
+
+	resp, err := c.CreateRefund(CreateRefundRequest{
+		PaymentID:      "pay_1",
+		Amount:         Amount{Value: "200.00", Currency: "RUB"},
+		IdempotenceKey: "key-1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/refunds", gotPath)
+	assert.Equal(t, "key-1", gotKey)
+	assert.Equal(t, "pay_1", gotBody.PaymentID)
+	assert.Equal(t, "200.00", gotBody.Amount.Value)
+	assert.Equal(t, "refund_1", resp.ID)
+	assert.Equal(t, "succeeded", resp.Status)
+}
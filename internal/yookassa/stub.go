@@ -0,0 +1,148 @@
+package yookassa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
+)
+
+// StubFailTokenPrefix — префикс токена, при котором тестовый провайдер отклоняет платеж.
+const StubFailTokenPrefix = "fail_"
+
+// Статусы платежа, которые возвращает тестовый провайдер.
+const (
+	StubStatusSucceeded = "succeeded"
+	StubStatusCanceled  = "canceled"
+)
+
+// StubProvider имитирует платежного провайдера в тестовом режиме: реальные списания
+// не выполняются. Платеж с токеном, начинающимся с StubFailTokenPrefix, отклоняется,
+// остальные проходят успешно. Возвраты всегда проходят успешно. Если задан webhookURL, после ответа провайдер
+// отправляет подписанное уведомление, как это делает ЮKassa.
+type StubProvider struct {
+	webhookURL string
+	signer     *webhooksig.Signer
+	httpClient *http.Client
+	now        func() time.Time
+	deliver    func(body []byte)
+}
+
+// NewStubProvider создает тестового провайдера. Уведомления подписываются signer в заголовке
+// webhooksig.Header. Пустой webhookURL отключает отправку уведомлений, а nil signer — подпись.
+func NewStubProvider(webhookURL string, signer *webhooksig.Signer) *StubProvider {
+	p := &StubProvider{
+		webhookURL: webhookURL,
+		signer:     signer,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		now:        time.Now,
+	}
+	// Уведомление отправляется асинхронно, чтобы обработчик создания платежа успел ответить
+	p.deliver = func(body []byte) { go p.postWebhook(body) }
+	return p
+}
+
+// CreatePayment возвращает детерминированный ответ: ID платежа зависит только от
+// Idempotence-Key и токена, а статус — от префикса токена.
+func (p *StubProvider) CreatePayment(reqParams CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	status := StubStatusSucceeded
+	if strings.HasPrefix(reqParams.PaymentToken, StubFailTokenPrefix) {
+		status = StubStatusCanceled
+	}
+
+	sum := sha256.Sum256([]byte(reqParams.IdempotenceKey + ":" + reqParams.PaymentToken))
+	resp := &CreatePaymentResponse{
+		ID:        "test_" + hex.EncodeToString(sum[:8]),
+		Status:    status,
+		CreatedAt: p.now().UTC(),
+	}
+	resp.Amount.Value = reqParams.Amount.Value
+	resp.Amount.Currency = reqParams.Amount.Currency
+
+	if p.webhookURL != "" {
+		body, err := p.webhookBody(resp, reqParams)
+		if err != nil {
+			return nil, err
+		}
+		p.deliver(body)
+	}
+	return resp, nil
+}
+
+// CreateRefund возвращает успешный возврат, ID которого зависит только от Idempotence-Key и ID платежа.
+func (p *StubProvider) CreateRefund(reqParams CreateRefundRequest) (*CreateRefundResponse, error) {
+	sum := sha256.Sum256([]byte(reqParams.IdempotenceKey + ":" + reqParams.PaymentID))
+	resp := &CreateRefundResponse{
+		ID:        "test_refund_" + hex.EncodeToString(sum[:8]),
+		PaymentID: reqParams.PaymentID,
+		Status:    StubStatusSucceeded,
+		Amount:    reqParams.Amount,
+		CreatedAt: p.now().UTC(),
+	}
+
+	if p.webhookURL != "" {
+		var n stubWebhook
+		n.Type = "notification"
+		n.Event = "refund." + resp.Status
+		n.Object.ID = resp.ID
+		n.Object.PaymentID = resp.PaymentID
+		n.Object.Status = resp.Status
+		n.Object.Amount = resp.Amount
+		body, err := json.Marshal(n)
+		if err != nil {
+			return nil, err
+		}
+		p.deliver(body)
+	}
+	return resp, nil
+}
+
+// stubWebhook повторяет формат уведомления ЮKassa, который принимает обработчик webhook.
+type stubWebhook struct {
+	Type   string `json:"type"`
+	Event  string `json:"event"`
+	Object struct {
+		ID            string `json:"id"`
+		PaymentID     string `json:"payment_id,omitempty"`
+		Status        string `json:"status"`
+		Amount        Amount `json:"amount"`
+		PaymentMethod struct {
+			ID string `json:"id"`
+		} `json:"payment_method"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"object"`
+}
+
+func (p *StubProvider) webhookBody(resp *CreatePaymentResponse, reqParams CreatePaymentRequest) ([]byte, error) {
+	var n stubWebhook
+	n.Type = "notification"
+	n.Event = "payment." + resp.Status
+	n.Object.ID = resp.ID
+	n.Object.Status = resp.Status
+	n.Object.Amount = Amount{Value: resp.Amount.Value, Currency: resp.Amount.Currency}
+	n.Object.PaymentMethod.ID = reqParams.PaymentToken
+	n.Object.Metadata = reqParams.Metadata
+	return json.Marshal(n)
+}
+
+func (p *StubProvider) postWebhook(body []byte) {
+	req, err := http.NewRequest(http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.signer != nil {
+		req.Header.Set(webhooksig.Header, p.signer.Sign(body))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,127 @@
+package yookassa
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/magabrotheeeer/subscription-aggregator/internal/lib/webhooksig"
+)
+
+func stubRequest(token string) CreatePaymentRequest {
+	req := CreatePaymentRequest{
+		PaymentToken:   token,
+		Metadata:       map[string]string{"user_uid": "user123", "subscription_id": "1"},
+		IdempotenceKey: "key-1",
+	}
+	req.Amount.Value = "200.00"
+	req.Amount.Currency = "RUB"
+	return req
+}
+
+func TestStubProvider_CreatePayment(t *testing.T) {
+	p := NewStubProvider("", nil)
+
+	ok, err := p.CreatePayment(stubRequest("card_123"))
+	require.NoError(t, err)
+	assert.Equal(t, StubStatusSucceeded, ok.Status)
+	assert.Equal(t, "200.00", ok.Amount.Value)
+	assert.Equal(t, "RUB", ok.Amount.Currency)
+
+	failed, err := p.CreatePayment(stubRequest("fail_insufficient_funds"))
+	require.NoError(t, err)
+	assert.Equal(t, StubStatusCanceled, failed.Status)
+
+	// Ответ детерминирован: повтор с тем же ключом дает тот же платеж
+	again, err := p.CreatePayment(stubRequest("card_123"))
+	require.NoError(t, err)
+	assert.Equal(t, ok.ID, again.ID)
+	assert.NotEqual(t, ok.ID, failed.ID)
+}
+
+func TestStubProvider_CreateRefund(t *testing.T) {
+	got := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- body
+	}))
+	defer srv.Close()
+
+	p := NewStubProvider(srv.URL, nil)
+	req := CreateRefundRequest{PaymentID: "pay_1", Amount: Amount{Value: "200.00", Currency: "RUB"}, IdempotenceKey: "refund:1"}
+
+	resp, err := p.CreateRefund(req)
+	require.NoError(t, err)
+	assert.Equal(t, StubStatusSucceeded, resp.Status)
+	assert.Equal(t, "pay_1", resp.PaymentID)
+	assert.Equal(t, "200.00", resp.Amount.Value)
+
+	var body []byte
+	select {
+	case body = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	var payload stubWebhook
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "refund.succeeded", payload.Event)
+	assert.Equal(t, resp.ID, payload.Object.ID)
+	assert.Equal(t, "pay_1", payload.Object.PaymentID)
+
+	// Повтор с тем же ключом дает тот же возврат
+	again, err := NewStubProvider("", nil).CreateRefund(req)
+	require.NoError(t, err)
+	assert.Equal(t, resp.ID, again.ID)
+}
+
+func TestStubProvider_Webhook(t *testing.T) {
+	type received struct {
+		body      []byte
+		signature string
+	}
+	got := make(chan received, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{body: body, signature: r.Header.Get(webhooksig.Header)}
+	}))
+	defer srv.Close()
+
+	signer, err := webhooksig.NewSigner(webhooksig.HMACSHA256, "secret", nil)
+	require.NoError(t, err)
+	verifier, err := webhooksig.NewVerifier(webhooksig.HMACSHA256, "secret", 0, nil)
+	require.NoError(t, err)
+	p := NewStubProvider(srv.URL, signer)
+
+	for _, tc := range []struct {
+		token string
+		event string
+	}{
+		{token: "card_123", event: "payment.succeeded"},
+		{token: "fail_card", event: "payment.canceled"},
+	} {
+		resp, err := p.CreatePayment(stubRequest(tc.token))
+		require.NoError(t, err)
+
+		var r received
+		select {
+		case r = <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+
+		assert.NoError(t, verifier.Verify(r.signature, r.body))
+
+		var payload stubWebhook
+		require.NoError(t, json.Unmarshal(r.body, &payload))
+		assert.Equal(t, tc.event, payload.Event)
+		assert.Equal(t, resp.ID, payload.Object.ID)
+		assert.Equal(t, "200.00", payload.Object.Amount.Value)
+		assert.Equal(t, "user123", payload.Object.Metadata["user_uid"])
+	}
+}
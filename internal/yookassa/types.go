@@ -10,6 +10,8 @@ type CreatePaymentRequest struct {
 	} `json:"amount"`
 	PaymentToken string            `json:"payment_token"`      // токен карты (payment_method_token)
 	Metadata     map[string]string `json:"metadata,omitempty"` // дополнительная инфа: user_uid, subscription_id
+	// IdempotenceKey передается в заголовке Idempotence-Key, чтобы повторный запрос не создал второй платеж
+	IdempotenceKey string `json:"-"`
 }
 
 // CreatePaymentResponse представляет ответ на создание платежа.
@@ -28,3 +30,20 @@ type Amount struct {
 	Value    string `json:"value"`
 	Currency string `json:"currency"`
 }
+
+// CreateRefundRequest представляет запрос на возврат платежа.
+type CreateRefundRequest struct {
+	PaymentID string `json:"payment_id"` // ID возвращаемого платежа в ЮKassa
+	Amount    Amount `json:"amount"`     // сумма возврата, не больше суммы платежа
+	// IdempotenceKey передается в заголовке Idempotence-Key, чтобы повторный запрос не создал второй возврат
+	IdempotenceKey string `json:"-"`
+}
+
+// CreateRefundResponse представляет ответ на создание возврата.
+type CreateRefundResponse struct {
+	ID        string    `json:"id"`         // ID возврата в ЮKassa
+	PaymentID string    `json:"payment_id"` // ID возвращенного платежа
+	Status    string    `json:"status"`     // статус возврата: "pending", "succeeded" или "canceled"
+	Amount    Amount    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
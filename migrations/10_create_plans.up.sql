@@ -0,0 +1,16 @@
+CREATE TABLE plans (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    price BIGINT NOT NULL, -- в копейках
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- Тариф по умолчанию может быть только один: он применяется к пользователям без выбранного тарифа
+CREATE UNIQUE INDEX idx_plans_default ON plans(is_default) WHERE is_default;
+
+INSERT INTO plans (code, name, price, currency, is_default)
+VALUES ('standard', 'Стандартный', 20000, 'RUB', TRUE);
+
+ALTER TABLE users ADD COLUMN plan_id INT REFERENCES plans(id);
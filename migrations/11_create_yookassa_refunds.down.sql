@@ -0,0 +1 @@
+DROP TABLE yookassa_refunds;
@@ -0,0 +1,11 @@
+CREATE TABLE yookassa_refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES yookassa_payments(id) ON DELETE CASCADE,
+    user_uid UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    refund_id VARCHAR(255) NOT NULL UNIQUE, -- ID возврата из ЮKassa
+    amount BIGINT NOT NULL,                 -- сумма в копейках
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_yookassa_refunds_payment_id ON yookassa_refunds(payment_id);
@@ -0,0 +1,2 @@
+DROP TABLE promo_code_redemptions;
+DROP TABLE promo_codes;
@@ -0,0 +1,23 @@
+CREATE TABLE promo_codes (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    percent_off INT CHECK (percent_off BETWEEN 1 AND 100),
+    amount_off BIGINT CHECK (amount_off > 0), -- в копейках
+    valid_from TIMESTAMPTZ,                   -- NULL — действует сразу
+    valid_until TIMESTAMPTZ,                  -- NULL — бессрочно
+    max_uses INT CHECK (max_uses > 0),        -- NULL — без ограничения
+    used_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Скидка задается либо процентом, либо суммой
+    CHECK ((percent_off IS NULL) <> (amount_off IS NULL))
+);
+
+CREATE TABLE promo_code_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    user_uid UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    payment_id VARCHAR(255) NOT NULL, -- ID платежа из ЮKassa
+    discount BIGINT NOT NULL,         -- в копейках
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_promo_code_redemptions_promo_code_id ON promo_code_redemptions(promo_code_id);
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Триграммные индексы ускоряют поиск по подстроке: lower(...) LIKE '%q%'
+CREATE INDEX idx_users_username_trgm ON users USING gin (lower(username) gin_trgm_ops);
+CREATE INDEX idx_users_email_trgm ON users USING gin (lower(email) gin_trgm_ops);
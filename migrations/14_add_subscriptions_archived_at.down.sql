@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_not_archived;
+ALTER TABLE subscriptions DROP COLUMN archived_at;
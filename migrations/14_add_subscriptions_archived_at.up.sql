@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN archived_at TIMESTAMPTZ;
+
+-- Обычные запросы читают только неархивные подписки
+CREATE INDEX idx_subscriptions_not_archived ON subscriptions(username) WHERE archived_at IS NULL;
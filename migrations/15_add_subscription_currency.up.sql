@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN currency VARCHAR(3);
+
+-- Подписки, созданные до появления колонки, получают валюту из настройки default_currency:
+-- ее заполняет Storage.BackfillCurrency при запуске приложения, а до этого NULL подменяется при чтении
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_uid;
+
+ALTER TABLE subscriptions ALTER COLUMN user_uid DROP NOT NULL;
@@ -0,0 +1,14 @@
+-- Владелец подписки определяется по user_uid, username хранится только для отображения.
+-- Подписки без user_uid получают UID пользователя с тем же username.
+UPDATE subscriptions s
+SET user_uid = u.uid
+FROM users u
+WHERE s.user_uid IS NULL AND u.username = s.username;
+
+-- Подписки, для которых владельца найти не удалось, удаляются: иначе SET NOT NULL не применится.
+DELETE FROM subscriptions
+WHERE user_uid IS NULL;
+
+ALTER TABLE subscriptions ALTER COLUMN user_uid SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_uid ON subscriptions(user_uid);
@@ -0,0 +1,14 @@
+CREATE TABLE notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    routing_key TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    dedup_key TEXT NOT NULL UNIQUE,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ
+);
+
+-- Релей выбирает только неотправленные уведомления с наступившим временем попытки
+CREATE INDEX idx_notification_outbox_pending ON notification_outbox(next_attempt_at) WHERE sent_at IS NULL;
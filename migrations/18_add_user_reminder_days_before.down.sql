@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN reminder_days_before;
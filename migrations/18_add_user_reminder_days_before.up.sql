@@ -0,0 +1,2 @@
+-- NULL — напоминания отправляются по глобальному списку scheduler.reminder_days_before
+ALTER TABLE users ADD COLUMN reminder_days_before INT CHECK (reminder_days_before BETWEEN 1 AND 30);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_yookassa_payments_payment_id;
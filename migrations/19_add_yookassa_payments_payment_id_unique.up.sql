@@ -0,0 +1,32 @@
+-- Повторное уведомление о платеже обновляет существующую запись, а не создает новую.
+-- Ранее сохраненные дубликаты схлопываются в самую раннюю запись: она получает статус
+-- последнего дубликата, а возвраты по дубликатам переносятся на нее.
+WITH ranked AS (
+    SELECT id,
+           FIRST_VALUE(id) OVER (PARTITION BY payment_id ORDER BY created_at, id) AS keep_id,
+           FIRST_VALUE(status) OVER (PARTITION BY payment_id ORDER BY created_at DESC, id DESC) AS last_status
+    FROM yookassa_payments
+)
+UPDATE yookassa_payments p
+SET status = r.last_status
+FROM ranked r
+WHERE p.id = r.keep_id AND p.id = r.id AND p.status <> r.last_status;
+
+WITH ranked AS (
+    SELECT id, FIRST_VALUE(id) OVER (PARTITION BY payment_id ORDER BY created_at, id) AS keep_id
+    FROM yookassa_payments
+)
+UPDATE yookassa_refunds f
+SET payment_id = r.keep_id
+FROM ranked r
+WHERE f.payment_id = r.id AND r.id <> r.keep_id;
+
+WITH ranked AS (
+    SELECT id, FIRST_VALUE(id) OVER (PARTITION BY payment_id ORDER BY created_at, id) AS keep_id
+    FROM yookassa_payments
+)
+DELETE FROM yookassa_payments p
+USING ranked r
+WHERE p.id = r.id AND r.id <> r.keep_id;
+
+CREATE UNIQUE INDEX idx_yookassa_payments_payment_id ON yookassa_payments(payment_id);
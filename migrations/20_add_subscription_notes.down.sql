@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN metadata,
+    DROP COLUMN notes;
@@ -0,0 +1,4 @@
+-- Заметки и произвольные данные пользователя к подписке; NULL — не заданы
+ALTER TABLE subscriptions
+    ADD COLUMN notes TEXT CHECK (char_length(notes) <= 500),
+    ADD COLUMN metadata JSONB CHECK (jsonb_typeof(metadata) = 'object');
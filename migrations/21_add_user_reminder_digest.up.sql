@@ -0,0 +1,2 @@
+-- true — напоминания об окончании подписок за один проход планировщика приходят одним письмом
+ALTER TABLE users ADD COLUMN reminder_digest BOOLEAN NOT NULL DEFAULT false;
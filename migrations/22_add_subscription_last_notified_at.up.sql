@@ -0,0 +1,2 @@
+-- Время последнего уведомления о подписке: выдерживает scheduler.min_days_between_notifications
+ALTER TABLE subscriptions ADD COLUMN last_notified_at TIMESTAMPTZ;
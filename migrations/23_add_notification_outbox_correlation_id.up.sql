@@ -0,0 +1,2 @@
+-- Идентификатор прохода планировщика, записавшего уведомление: передается в сообщении RabbitMQ
+ALTER TABLE notification_outbox ADD COLUMN correlation_id TEXT NOT NULL DEFAULT '';
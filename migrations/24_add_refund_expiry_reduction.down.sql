@@ -0,0 +1 @@
+ALTER TABLE yookassa_refunds DROP COLUMN expiry_reduction_seconds;
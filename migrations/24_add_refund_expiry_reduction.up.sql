@@ -0,0 +1,2 @@
+-- На сколько секунд возврат сократил срок подписки: последний возврат по платежу отнимает только остаток оплаченного месяца
+ALTER TABLE yookassa_refunds ADD COLUMN expiry_reduction_seconds BIGINT NOT NULL DEFAULT 0;
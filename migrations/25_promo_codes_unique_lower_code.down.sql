@@ -0,0 +1,2 @@
+DROP INDEX promo_codes_code_lower_key;
+ALTER TABLE promo_codes ADD CONSTRAINT promo_codes_code_key UNIQUE (code);
@@ -0,0 +1,4 @@
+-- Промокоды ищутся без учета регистра, поэтому и уникальность кода проверяется без учета регистра.
+-- Если уже есть коды, различающиеся только регистром, миграция завершится ошибкой: их нужно переименовать вручную
+ALTER TABLE promo_codes DROP CONSTRAINT promo_codes_code_key;
+CREATE UNIQUE INDEX promo_codes_code_lower_key ON promo_codes (lower(code));
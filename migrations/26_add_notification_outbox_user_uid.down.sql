@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notification_outbox_user_uid;
+ALTER TABLE notification_outbox DROP COLUMN user_uid;
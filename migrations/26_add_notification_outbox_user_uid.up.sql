@@ -0,0 +1,19 @@
+-- Получатель уведомления: по нему выгрузка данных пользователя находит отправленные ему уведомления.
+-- У webhook-уведомлений платежного провайдера получателя нет
+ALTER TABLE notification_outbox ADD COLUMN user_uid UUID REFERENCES users(uid) ON DELETE CASCADE;
+
+-- Ранее записанные уведомления: ключ дедупликации имеет вид "<routing_key>:<uid или id подписки>:..."
+UPDATE notification_outbox o
+SET user_uid = u.uid
+FROM users u
+WHERE o.routing_key IN ('subscription.trial.expiring', 'subscription.trial.expired',
+                        'subscription.expiring.digest', 'subscription.expired')
+  AND u.uid::text = split_part(o.dedup_key, ':', 2);
+
+UPDATE notification_outbox o
+SET user_uid = s.user_uid
+FROM subscriptions s
+WHERE o.routing_key = 'subscription.expiring.tomorrow'
+  AND s.id::text = split_part(o.dedup_key, ':', 2);
+
+CREATE INDEX idx_notification_outbox_user_uid ON notification_outbox(user_uid) WHERE user_uid IS NOT NULL;
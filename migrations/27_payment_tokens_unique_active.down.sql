@@ -0,0 +1 @@
+DROP INDEX IF EXISTS yookassa_payment_tokens_user_token_active_key;
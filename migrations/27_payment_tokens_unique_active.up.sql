@@ -0,0 +1,13 @@
+-- Одновременные сохранения одной карты могли создать несколько активных записей:
+-- остается первая, остальные отзываются
+UPDATE yookassa_payment_tokens t
+SET revoked_at = NOW()
+WHERE t.revoked_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM yookassa_payment_tokens d
+      WHERE d.user_uid = t.user_uid AND d.token = t.token AND d.revoked_at IS NULL AND d.id < t.id
+  );
+
+-- Отозванные записи не мешают сохранить ту же карту снова
+CREATE UNIQUE INDEX yookassa_payment_tokens_user_token_active_key
+    ON yookassa_payment_tokens(user_uid, token) WHERE revoked_at IS NULL;
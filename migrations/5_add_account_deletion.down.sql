@@ -0,0 +1,4 @@
+DROP INDEX idx_account_deletion_requests_pending;
+DROP TABLE account_deletion_requests;
+ALTER TABLE subscriptions DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE TABLE account_deletion_requests (
+    id SERIAL PRIMARY KEY,
+    user_uid UUID NOT NULL,              -- без внешнего ключа: запись хранится для аудита
+    mode TEXT NOT NULL,                  -- immediate, scheduled
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+CREATE INDEX idx_account_deletion_requests_pending ON account_deletion_requests(scheduled_for) WHERE completed_at IS NULL;
@@ -0,0 +1,6 @@
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,2 @@
+DROP INDEX idx_subscription_price_history_subscription;
+DROP TABLE subscription_price_history;
@@ -0,0 +1,8 @@
+CREATE TABLE subscription_price_history (
+    id SERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    old_price INT NOT NULL,
+    new_price INT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_subscription_price_history_subscription ON subscription_price_history(subscription_id, changed_at);
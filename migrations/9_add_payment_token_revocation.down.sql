@@ -0,0 +1 @@
+ALTER TABLE yookassa_payment_tokens DROP COLUMN revoked_at;
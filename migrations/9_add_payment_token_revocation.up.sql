@@ -0,0 +1 @@
+ALTER TABLE yookassa_payment_tokens ADD COLUMN revoked_at TIMESTAMPTZ;